@@ -1,52 +1,300 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"flag"
-	"log"
+	"fmt"
+	"hash/fnv"
 	"os"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jt828/go-grpc-template/internal/migrationcheck"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/observability/implementation"
+	"github.com/lib/pq"
 )
 
+// Exit codes, so deploy tooling can tell the outcomes apart without parsing
+// the log. A run that finds nothing to apply exits with -no-change-exit-code,
+// 0 unless set, since a second pod finding the first already migrated is
+// the expected outcome of concurrent deploys.
+const (
+	exitApplied = 0
+	exitFailed  = 1
+	exitUsage   = 2
+	exitLocked  = 3
+	exitDirty   = 4
+	exitUnsafe  = 5
+)
+
+// lockPollInterval is how often a waiting run retries the migration lock.
+const lockPollInterval = time.Second
+
+// store maps a -store value to its migrations directory and the env vars
+// holding its connection settings.
+type store struct {
+	dir       string
+	dsnEnv    string
+	schemaEnv string
+}
+
+// The main store carries the full schema so a single-database deployment
+// only ever migrates it. Stores split into their own cluster run just their
+// own tables.
+var stores = map[string]store{
+	"main":        {dir: "migrations", dsnEnv: "DATABASE_DSN", schemaEnv: "DATABASE_SCHEMA"},
+	"idempotency": {dir: "migrations/idempotency", dsnEnv: "IDEMPOTENCY_DATABASE_DSN", schemaEnv: "IDEMPOTENCY_DATABASE_SCHEMA"},
+	"ledger":      {dir: "migrations/ledger", dsnEnv: "LEDGER_DATABASE_DSN", schemaEnv: "LEDGER_DATABASE_SCHEMA"},
+}
+
 func main() {
 	direction := flag.String("direction", "up", "migration direction: up or down")
 	steps := flag.Int("steps", 0, "number of steps to migrate (0 = all)")
+	storeName := flag.String("store", "main", "store to migrate: main, idempotency or ledger")
+	wait := flag.Duration("wait", 0, "how long to wait for a concurrent migration of the same schema to finish (0 = fail at once)")
+	noChangeExitCode := flag.Int("no-change-exit-code", 0, "exit code when there is nothing to migrate")
+	phase := flag.String("phase", "expand", "migrations to apply going up: expand (before the deploy) or contract (after it)")
+	allowDestructive := flag.Bool("allow-destructive", false, "apply migrations that drop, rename, retype or tighten columns")
 	flag.Parse()
 
-	dsn := os.Getenv("DATABASE_DSN")
+	log, err := implementation.NewZapLogger(observability.LogConfig{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create logger: %v\n", err)
+		os.Exit(exitFailed)
+	}
+	os.Exit(run(log, options{
+		storeName:        *storeName,
+		direction:        *direction,
+		steps:            *steps,
+		wait:             *wait,
+		noChangeExitCode: *noChangeExitCode,
+		phase:            *phase,
+		allowDestructive: *allowDestructive,
+	}))
+}
+
+type options struct {
+	storeName        string
+	direction        string
+	steps            int
+	wait             time.Duration
+	noChangeExitCode int
+	phase            string
+	allowDestructive bool
+}
+
+func run(log observability.Logger, opts options) int {
+	storeName, direction, steps, wait := opts.storeName, opts.direction, opts.steps, opts.wait
+	st, ok := stores[storeName]
+	if !ok {
+		log.Error("unknown store", observability.String("store", storeName))
+		return exitUsage
+	}
+	if direction != "up" && direction != "down" {
+		log.Error("unknown direction", observability.String("direction", direction))
+		return exitUsage
+	}
+	phase, err := migrationcheck.ParsePhase(opts.phase)
+	if err != nil {
+		log.Error("invalid phase", observability.Err(err))
+		return exitUsage
+	}
+
+	dsn := os.Getenv(st.dsnEnv)
 	if dsn == "" {
-		log.Fatal("DATABASE_DSN is required")
+		log.Error(st.dsnEnv + " is required")
+		return exitUsage
+	}
+
+	schemaName := os.Getenv(st.schemaEnv)
+	if schemaName == "" {
+		schemaName = model.DefaultSchema
+	}
+	log = log.With(observability.String("store", storeName), observability.String("schema", schemaName), observability.String("direction", direction))
+
+	ctx := context.Background()
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		log.Error("failed to open database", observability.Err(err))
+		return exitFailed
+	}
+	defer db.Close()
+
+	// Migrations use unqualified table names; pin one connection to the
+	// target schema so the same files apply to any schema.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		log.Error("failed to connect to database", observability.Err(err))
+		return exitFailed
+	}
+	defer conn.Close()
+
+	// Runs racing on the same schema, e.g. one per pod from a deploy hook,
+	// take turns on this lock before touching anything, even creating the
+	// schema. Whoever comes second finds the migrations applied.
+	locked, err := acquireLock(ctx, conn, lockKey(schemaName), wait)
+	if err != nil {
+		log.Error("failed to acquire the migration lock", observability.Err(err))
+		return exitFailed
+	}
+	if !locked {
+		log.Error("another migration of this schema is still running", observability.String("waited", wait.String()))
+		return exitLocked
+	}
+
+	if _, err := conn.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+pq.QuoteIdentifier(schemaName)); err != nil {
+		log.Error("failed to create schema", observability.Err(err))
+		return exitFailed
+	}
+	if _, err := conn.ExecContext(ctx, "SET search_path TO "+pq.QuoteIdentifier(schemaName)); err != nil {
+		log.Error("failed to set search_path", observability.Err(err))
+		return exitFailed
+	}
+
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{SchemaName: schemaName})
+	if err != nil {
+		log.Error("failed to create migrate driver", observability.Err(err))
+		return exitFailed
 	}
 
-	m, err := migrate.New("file://migrations", dsn)
+	m, err := migrate.NewWithDatabaseInstance("file://"+st.dir, "postgres", driver)
 	if err != nil {
-		log.Fatalf("failed to create migrate instance: %v", err)
+		log.Error("failed to create migrate instance", observability.Err(err))
+		return exitFailed
 	}
 	defer m.Close()
 
-	switch *direction {
-	case "up":
-		if *steps > 0 {
-			err = m.Steps(*steps)
-		} else {
-			err = m.Up()
+	from := version(m)
+	switch {
+	case direction == "up":
+		target, code := plan(log, st.dir, from, phase, steps, opts.allowDestructive)
+		if code != exitApplied {
+			return code
 		}
-	case "down":
-		if *steps > 0 {
-			err = m.Steps(-*steps)
+		if target < 0 {
+			err = migrate.ErrNoChange
 		} else {
-			err = m.Down()
+			err = m.Migrate(uint(target))
 		}
+	case steps > 0:
+		err = m.Steps(-steps)
 	default:
-		log.Fatalf("unknown direction: %s", *direction)
+		err = m.Down()
+	}
+	to := version(m)
+	log = log.With(observability.Int64("from_version", from), observability.Int64("to_version", to))
+
+	var dirty migrate.ErrDirty
+	switch {
+	case errors.Is(err, migrate.ErrNoChange):
+		log.Info("migration completed", observability.String("result", "no_change"))
+		return opts.noChangeExitCode
+	case errors.As(err, &dirty):
+		log.Error("database is dirty, a previous migration failed part way; fix the schema by hand and force the version before migrating again",
+			observability.Int("dirty_version", dirty.Version),
+		)
+		return exitDirty
+	case err != nil:
+		log.Error("migration failed", observability.Err(err))
+		return exitFailed
 	}
+	log.Info("migration completed", observability.String("result", "applied"))
+	return exitApplied
+}
 
-	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		log.Fatalf("migration failed: %v", err)
+// plan picks the version an up migration from version current goes to, -1
+// when there is nothing to apply, after checking that the migrations on the
+// way belong to phase and change the schema in ways the release serving
+// traffic survives. It returns exitApplied when the run may go ahead.
+func plan(log observability.Logger, dir string, current int64, phase migrationcheck.Phase, steps int, allowDestructive bool) (int64, int) {
+	migrations, err := migrationcheck.Load(os.DirFS(dir))
+	if err != nil {
+		log.Error("failed to read migrations", observability.Err(err))
+		return 0, exitFailed
+	}
+	pending, err := migrationcheck.Plan(migrations, current, phase)
+	if err != nil {
+		log.Error("migrations are out of order for this phase", observability.String("phase", string(phase)), observability.Err(err))
+		return 0, exitUnsafe
+	}
+	if steps > 0 && steps < len(pending) {
+		pending = pending[:steps]
+	} else if phase == migrationcheck.PhaseExpand {
+		next := current
+		if len(pending) > 0 {
+			next = int64(pending[len(pending)-1].Version)
+		}
+		for _, m := range migrations {
+			if int64(m.Version) > next {
+				log.Info("contract migrations wait for -phase contract", observability.Int64("migration_version", int64(m.Version)), observability.String("migration", m.Name))
+				break
+			}
+		}
 	}
 
-	log.Println("migration completed")
+	unsafe := false
+	for _, m := range pending {
+		for _, v := range migrationcheck.Check(m) {
+			fields := []observability.Field{
+				observability.Int64("migration_version", int64(v.Version)),
+				observability.String("migration", v.Name),
+				observability.String("reason", v.Reason),
+				observability.String("statement", v.Statement),
+			}
+			if allowDestructive {
+				log.Warn("applying destructive migration", fields...)
+				continue
+			}
+			log.Error("destructive migration", fields...)
+			unsafe = true
+		}
+	}
+	if unsafe {
+		log.Error("refusing destructive migrations; move them to a contract migration and pass -allow-destructive once no running release needs what they remove")
+		return 0, exitUnsafe
+	}
+
+	if len(pending) == 0 {
+		return -1, exitApplied
+	}
+	return int64(pending[len(pending)-1].Version), exitApplied
+}
+
+// lockKey returns the advisory lock key serialising migrations of schema.
+func lockKey(schema string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("go-grpc-template migration " + schema))
+	return int64(h.Sum64())
+}
+
+// acquireLock takes the session advisory lock key on conn, retrying for up
+// to wait while another session holds it, and reports whether it got it.
+// The lock is released when conn is closed.
+func acquireLock(ctx context.Context, conn *sql.Conn, key int64, wait time.Duration) (bool, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		var locked bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+			return false, err
+		}
+		if locked || !time.Now().Before(deadline) {
+			return locked, nil
+		}
+		time.Sleep(min(lockPollInterval, time.Until(deadline)))
+	}
+}
+
+// version returns m's current version, or -1 before the first migration.
+func version(m *migrate.Migrate) int64 {
+	v, _, err := m.Version()
+	if err != nil {
+		return -1
+	}
+	return int64(v)
 }
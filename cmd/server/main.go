@@ -2,42 +2,105 @@ package main
 
 import (
 	"context"
+	"maps"
 	"net"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/jt828/go-grpc-template/internal/authz"
 	"github.com/jt828/go-grpc-template/internal/bootstrap"
+	"github.com/jt828/go-grpc-template/internal/config"
+	"github.com/jt828/go-grpc-template/internal/consumer"
 	"github.com/jt828/go-grpc-template/internal/controller"
+	"github.com/jt828/go-grpc-template/internal/controller/convert"
 	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/internal/probe"
+	"github.com/jt828/go-grpc-template/internal/repository"
 	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	auditImpl "github.com/jt828/go-grpc-template/pkg/audit/implementation"
+	cbImpl "github.com/jt828/go-grpc-template/pkg/circuitbreaker/implementation"
+	"github.com/jt828/go-grpc-template/pkg/email"
+	emailImpl "github.com/jt828/go-grpc-template/pkg/email/implementation"
+	"github.com/jt828/go-grpc-template/pkg/fieldcrypto"
+	fieldcryptoImpl "github.com/jt828/go-grpc-template/pkg/fieldcrypto/implementation"
+	"github.com/jt828/go-grpc-template/pkg/httpclient"
+	httpclientImpl "github.com/jt828/go-grpc-template/pkg/httpclient/implementation"
+	"github.com/jt828/go-grpc-template/pkg/httpmiddleware"
+	"github.com/jt828/go-grpc-template/pkg/idcodec"
+	idcodecImpl "github.com/jt828/go-grpc-template/pkg/idcodec/implementation"
 	idempotencyImpl "github.com/jt828/go-grpc-template/pkg/idempotency/implementation"
+	"github.com/jt828/go-grpc-template/pkg/model"
 	"github.com/jt828/go-grpc-template/pkg/observability"
 	"github.com/jt828/go-grpc-template/pkg/observability/implementation"
+	"github.com/jt828/go-grpc-template/pkg/oidc"
+	oidcImpl "github.com/jt828/go-grpc-template/pkg/oidc/implementation"
+	"github.com/jt828/go-grpc-template/pkg/password"
+	passwordImpl "github.com/jt828/go-grpc-template/pkg/password/implementation"
+	"github.com/jt828/go-grpc-template/pkg/pgclass"
+	ratelimitImpl "github.com/jt828/go-grpc-template/pkg/ratelimit/implementation"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	retryImpl "github.com/jt828/go-grpc-template/pkg/retry/implementation"
 	v1 "github.com/jt828/go-grpc-template/proto"
+	"github.com/sony/gobreaker/v2"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
+const serviceName = "go-grpc-template"
+
+// metricsHealthService is the gRPC health service name reporting whether the
+// metrics server is still serving. It is kept apart from the overall status,
+// which a metrics outage must not take out of rotation.
+const metricsHealthService = "metrics"
+
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	cfg := implementation.Config{ServiceName: "go-grpc-template"}
-	obs, err := implementation.NewObservability(cfg)
+	// Configuration is loaded before observability, which needs its bucket
+	// presets, but its error can only be logged once the logger exists.
+	serverCfg, cfgErr := config.Load(serviceName)
+
+	obs, err := implementation.NewObservability(serviceName,
+		implementation.WithLogConfig(serverCfg.Log),
+		implementation.WithMeterOptions(
+			implementation.WithBucketPresets(serverCfg.BucketPresets),
+			implementation.WithNamespace(serverCfg.MetricNamespace, serverCfg.MetricSubsystem),
+			implementation.WithConstLabels(serverCfg.MetricLabels...),
+		),
+		implementation.WithMetricsAddress(serverCfg.MetricsAddress),
+		implementation.WithMetricsMiddleware(httpmiddleware.SecurityHeaders(serverCfg.HTTP.Security)),
+		implementation.WithOTLPEndpoint(serverCfg.OTLPEndpoint),
+		implementation.WithTraceExport(serverCfg.TraceExport),
+		implementation.WithPropagators(serverCfg.Propagators...),
+		implementation.WithResourceAttributes(serverCfg.ResourceAttributes()...),
+	)
 	if err != nil {
 		panic(err)
 	}
 	log := obs.Logger()
-	reg := implementation.PromRegistry(obs.Meter())
+	if cfgErr != nil {
+		log.Fatal("invalid configuration", observability.Err(cfgErr))
+	}
+	reg := implementation.PromRegisterer(obs.Meter())
 	if reg == nil {
 		log.Fatal("prometheus registry not available")
 	}
 
+	// grpc_server_handling_seconds is recorded by RPCMetricsInterceptors,
+	// which attach trace exemplars, rather than by go-grpc-prometheus.
 	grpcMetrics := grpc_prometheus.NewServerMetrics()
 	reg.MustRegister(grpcMetrics)
 
@@ -45,18 +108,152 @@ func main() {
 		log.Error("failed to start observability", observability.Err(err))
 	}
 
-	idGen, err := bootstrap.InitializeSnowflake()
+	configEntries := serverCfg.Entries()
+	configFields := make([]observability.Field, len(configEntries))
+	for i, entry := range configEntries {
+		configFields[i] = observability.String(entry.Key, entry.Value)
+	}
+	log.Info("effective configuration", configFields...)
+	configSvc := service.NewConfigService(configEntries, time.Now().UTC())
+
+	idGen, err := bootstrap.InitializeSnowflake(serverCfg.SnowflakeNodes)
 	if err != nil {
 		log.Fatal("failed to initialize snowflake", observability.Err(err))
 	}
-	dsn := os.Getenv("DATABASE_DSN")
-	dbs, err := bootstrap.InitializeDatabase(dsn, obs.Meter())
+	// Partial commits also reach callers as a *repository.PartialCommitError,
+	// which ErrorInterceptor returns as non-retryable. The counter lets them
+	// be alerted on.
+	partialCommits := obs.Meter().Counter("unit_of_work_partial_commits_total", observability.MetricOpt{
+		Help:      "Total number of units of work whose stores committed only in part, by the store that failed",
+		LabelKeys: []string{"failed"},
+	})
+	dbs, err := bootstrap.InitializeDatabases(
+		serverCfg.Main,
+		serverCfg.Idempotency,
+		serverCfg.Ledger,
+		serviceName,
+		obs,
+		idGen,
+		repository.WithPartialCommitHandler(func(ctx context.Context, err *repository.PartialCommitError) {
+			partialCommits.Inc(1, observability.Label{Key: "failed", Value: err.Failed})
+			observability.LoggerFromContext(ctx, log).Error("unit of work partially committed", observability.Err(err))
+		}),
+	)
 	if err != nil {
 		log.Fatal("failed to initialize database", observability.Err(err))
 	}
 
 	idem := idempotencyImpl.NewIdempotency()
-	userSvc := service.NewUserService(dbs.UnitOfWorkFactory, idem, idGen)
+	passwordHasher := passwordImpl.NewHasher(serverCfg.Password.HashAlgorithm)
+	serviceLog := log.With(observability.Module("service"))
+	// The Login RPC must call loginThrottleSvc before it is exposed.
+	loginThrottleSvc := service.NewLoginThrottleService(dbs.UnitOfWorkFactory, service.LoginLockout(serverCfg.Login), obs.Meter(), serviceLog)
+	userSvc := service.NewUserService(dbs.UnitOfWorkFactory, idem, idGen, passwordHasher, loginThrottleSvc, obs.Tracer())
+	ledgerSvc := service.NewLedgerService(dbs.UnitOfWorkFactory, obs.Tracer())
+	// Usage is recorded and stored but not reported until a broker
+	// event.Publisher is wired in here.
+	usageSvc := service.NewUsageService(dbs.UnitOfWorkFactory, nil, serviceLog)
+	// Register event handlers with eventConsumer.Register and start it with
+	// eventConsumer.Run once a broker Source is wired in.
+	eventConsumer := consumer.NewConsumer(dbs.UnitOfWorkFactory, retryImpl.NewRetry(5, retry.WithInterval(time.Second)), idGen, obs.Meter(), log.With(observability.Module("consumer")))
+	deadLetterSvc := service.NewDeadLetterService(dbs.UnitOfWorkFactory, map[model.DeadLetterSource]service.DeadLetterReplayer{
+		model.DeadLetterSourceConsumer: eventConsumer,
+	}, obs.Meter(), serviceLog)
+	var twoFactorSvc service.TwoFactorService
+	if len(serverCfg.FieldEncryptionKeys) > 0 {
+		var cipherOpts []fieldcrypto.Option
+		for keyId, key := range serverCfg.FieldEncryptionKeys {
+			cipherOpts = append(cipherOpts, fieldcrypto.WithKey(keyId, key))
+		}
+		cipher, err := fieldcryptoImpl.NewAESGCM(append(cipherOpts, fieldcrypto.WithPrimaryKey(serverCfg.FieldEncryptionPrimaryKey))...)
+		if err != nil {
+			log.Fatal("failed to create field cipher", observability.Err(err))
+		}
+		twoFactorSvc = service.NewTwoFactorService(dbs.UnitOfWorkFactory, cipher, loginThrottleSvc, serverCfg.TwoFactor.Issuer, serviceLog)
+	} else {
+		log.Info("FIELD_ENCRYPTION_KEYS is not set, two-factor authentication is disabled")
+	}
+	sessionSvc := service.NewSessionService(dbs.UnitOfWorkFactory, idGen, serviceLog)
+	identitySvc := service.NewIdentityService(dbs.UnitOfWorkFactory, serviceLog)
+	roleSvc := service.NewRoleService(dbs.UnitOfWorkFactory, serverCfg.Authz.CacheTTL, serviceLog)
+	idempotencyRecordSvc := service.NewIdempotencyRecordService(dbs.UnitOfWorkFactory, service.UserIdempotentRequests(), obs.Meter(), serviceLog)
+	var emailSender email.Sender
+	switch serverCfg.Email.Sender {
+	case config.EmailSenderSMTP:
+		if emailSender, err = emailImpl.NewSMTPSender(serverCfg.Email.SMTPAddress, serverCfg.Email.From, serverCfg.Email.SMTPUsername, serverCfg.Email.SMTPPassword); err != nil {
+			log.Fatal("failed to create email sender", observability.Err(err))
+		}
+	default:
+		emailSender = emailImpl.NewLogSender(log.With(observability.Module("email")))
+	}
+	verificationSvc := service.NewEmailVerificationService(dbs.UnitOfWorkFactory, emailSender, serverCfg.Email.VerificationURL, serverCfg.Email.VerificationTTL, serviceLog)
+	var dependencies []service.Dependency
+	for _, db := range dbs.Distinct() {
+		dependencies = append(dependencies, service.Dependency{
+			Name: db.Name,
+			Probe: func(ctx context.Context) error {
+				sqlDB, err := db.DB.DB()
+				if err != nil {
+					return err
+				}
+				return sqlDB.PingContext(ctx)
+			},
+			CircuitBreaker: db.CircuitBreaker,
+		})
+	}
+	dependencySvc := service.NewDependencyService(append(dependencies,
+		service.Dependency{
+			Name:  "metrics_server",
+			Probe: obs.Check,
+		},
+		service.Dependency{
+			Name: "otlp_exporter",
+			Probe: func(ctx context.Context) error {
+				conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", serverCfg.OTLPEndpoint)
+				if err != nil {
+					return err
+				}
+				return conn.Close()
+			},
+		},
+	)...)
+
+	// The main store's migrations carry every table; a store split into its
+	// own database only has the tables its own migrations create.
+	catalogRetry := retryImpl.NewRetry(3, retry.WithInterval(100*time.Millisecond), retry.WithRetryable(pgclass.IsRetryable))
+	schemaStore := func(db *bootstrap.Database, expected []model.TableSchema) service.SchemaStore {
+		return service.SchemaStore{
+			Name:       db.Name,
+			Schema:     db.Schema,
+			Expected:   expected,
+			Repository: repository.NewSchemaRepository(db.DB, db.CircuitBreaker, catalogRetry),
+		}
+	}
+	schemaStores := []service.SchemaStore{schemaStore(dbs.Main, repository.ExpectedSchema)}
+	if dbs.Ledger != dbs.Main {
+		schemaStores = append(schemaStores, schemaStore(dbs.Ledger, repository.ExpectedTables("ledgers")))
+	}
+	if dbs.Idempotency != dbs.Main {
+		schemaStores = append(schemaStores, schemaStore(dbs.Idempotency, repository.ExpectedTables("idempotency_records", "idempotency_quarantine")))
+	}
+	schemaDriftSvc := service.NewSchemaDriftService(schemaStores...)
+	tableStatsSvc := service.NewTableStatsService(schemaStores, obs.Meter(), serviceLog)
+
+	checkCtx, checkCancel := context.WithTimeout(ctx, 10*time.Second)
+	if drifts, err := schemaDriftSvc.CheckSchemaDrift(checkCtx); err != nil {
+		log.Error("failed to check schema drift", observability.Err(err))
+	} else {
+		for _, drift := range drifts {
+			log.Warn("live schema differs from migrations",
+				observability.String("store", drift.Store),
+				observability.String("object", drift.Object),
+				observability.String("kind", string(drift.Kind)),
+				observability.String("expected", drift.Expected),
+				observability.String("actual", drift.Actual),
+			)
+		}
+	}
+	checkCancel()
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
@@ -67,28 +264,222 @@ func main() {
 		cancel() // cancel root context
 	}()
 
-	lis, err := net.Listen("tcp", ":50051")
+	lis, err := net.Listen("tcp", serverCfg.GRPCAddress)
+	if err != nil {
+		log.Fatal("failed to listen", observability.Err(err))
+	}
+
+	serverCreds := insecure.NewCredentials()
+	probeCreds := insecure.NewCredentials()
+	var adminCreds credentials.TransportCredentials
+	if serverCfg.TLS.CertFile != "" {
+		certs, err := bootstrap.NewCertReloader(serverCfg.TLS, log.With(observability.Module("tls")))
+		if err != nil {
+			log.Fatal("failed to load TLS certificate", observability.Err(err))
+		}
+		serverCreds = credentials.NewTLS(certs.ServerConfig())
+		probeCreds = credentials.NewTLS(certs.PinnedClientConfig())
+		adminCreds = credentials.NewTLS(certs.MutualServerConfig())
+	}
+
+	policyRules := maps.Clone(serverCfg.Authz.Policy)
+	if policyRules == nil {
+		policyRules = map[string][]string{}
+	}
+	policyRules[config.AdminServicePrefix] = []string{serverCfg.Admin.Role}
+	for _, method := range config.AdminUserMethods {
+		policyRules[method] = []string{serverCfg.Admin.Role}
+	}
+	authzPolicy, err := authz.NewPolicy(policyRules, serverCfg.Authz.DenyUnlisted)
 	if err != nil {
-		log.Info("failed to listen: %v", observability.Err(err))
+		log.Fatal("invalid authorization policy", observability.Err(err))
 	}
 
-	server := grpc.NewServer(
+	signingSecrets := interceptor.StaticSigningSecrets{}
+	for keyId, secret := range serverCfg.SigningSecrets {
+		signingSecrets[keyId] = []byte(secret)
+	}
+	// Authentication interceptors go first, so limits are charged to the
+	// authenticated caller rather than the peer IP.
+	authenticators := []grpc.UnaryServerInterceptor{
+		interceptor.ClientCertInterceptor(),
+		interceptor.SignatureInterceptor(signingSecrets, serverCfg.SignedMethods, obs.Meter()),
+	}
+	if serverCfg.OIDC.Issuer != "" {
+		// The issuer is operator configured rather than user supplied, so
+		// an in-cluster provider on a private address is allowed.
+		verifier := oidcImpl.NewJWKSVerifier(
+			serverCfg.OIDC.Issuer,
+			serverCfg.OIDC.Audience,
+			httpclientImpl.NewHTTPClient(httpclient.WithTimeout(5*time.Second)),
+			cbImpl.NewCircuitBreaker(gobreaker.Settings{Name: "oidc_jwks"}),
+			retryImpl.NewRetry(2, retry.WithInterval(100*time.Millisecond)),
+			oidc.WithJWKSURL(serverCfg.OIDC.JWKSURL),
+			oidc.WithRefreshInterval(serverCfg.OIDC.JWKSRefreshInterval, time.Minute),
+		)
+		authenticators = append(authenticators, interceptor.OIDCInterceptor(verifier, identitySvc, sessionSvc, obs.Meter()))
+	}
+	// Quotas are held per replica. Pass ratelimitImpl.NewRedisStore instead
+	// to share them across replicas.
+	limiter := ratelimitImpl.NewTokenBucket(
+		serverCfg.RateLimitBurst,
+		time.Minute/time.Duration(serverCfg.RateLimitPerMinute),
+		ratelimitImpl.NewMemoryStore(),
+	)
+	rateLimitUnary, rateLimitStream := interceptor.RateLimitInterceptors(limiter, obs.Meter())
+	accessLogUnary, accessLogStream := interceptor.AccessLogInterceptors(
+		log.With(observability.Module("access")),
+		serverCfg.AccessLog.ExcludedMethods,
+		serverCfg.AccessLog.Payloads,
+	)
+	// With AUDIT_SINK=none no method is audited, so the sink is never used.
+	auditMethods := serverCfg.Audit.Methods
+	var auditSink audit.Sink
+	switch serverCfg.Audit.Sink {
+	case config.AuditSinkLog:
+		auditSink = auditImpl.NewLogSink(log.With(observability.Module("audit")))
+	case config.AuditSinkDatabase:
+		auditRetry := retryImpl.NewRetry(serverCfg.Main.Retry.MaxRetries, retry.WithInterval(serverCfg.Main.Retry.Interval), retry.WithRetryable(pgclass.IsRetryable))
+		auditSink = auditImpl.NewRepositorySink(repository.NewAuditRecordRepository(dbs.Main.DB, dbs.Main.CircuitBreaker, auditRetry), idGen)
+	default:
+		auditMethods = nil
+	}
+	auditUnary := interceptor.AuditInterceptor(auditSink, auditMethods, log.With(observability.Module("audit")))
+	concurrencyUnary, concurrencyStream := interceptor.ConcurrencyLimitInterceptors(
+		serverCfg.Concurrency.Max,
+		serverCfg.Concurrency.Methods,
+		obs.Meter(),
+	)
+	rpcMetricsUnary, rpcMetricsStream := interceptor.RPCMetricsInterceptors(obs.Meter(), serverCfg.MetricTenants)
+	metadataUnary, metadataStream := interceptor.MetadataInterceptors(
+		serverCfg.Metadata.MaxBytes,
+		serverCfg.Metadata.AllowedKeys,
+		obs.Meter(),
+	)
+	requestIdUnary, requestIdStream := interceptor.RequestIdInterceptors()
+	queryTagUnary, queryTagStream := interceptor.QueryTagInterceptors()
+	// Interceptors that register metrics are built once and shared by the
+	// public and admin servers, since a metric can only be registered once.
+	serverOpts := []grpc.ServerOption{
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.StatsHandler(interceptor.ConnectionStatsHandler(serverCfg.Keepalive, obs.Meter())),
+		grpc.KeepaliveParams(serverCfg.Keepalive),
+		grpc.KeepaliveEnforcementPolicy(serverCfg.KeepalivePolicy),
 		grpc.ChainUnaryInterceptor(
 			grpcMetrics.UnaryServerInterceptor(),
-			interceptor.ErrorInterceptor(log),
+			rpcMetricsUnary,
+			metadataUnary,
+			requestIdUnary,
+			accessLogUnary,
+			queryTagUnary,
+			interceptor.ErrorInterceptor(log.With(observability.Module("interceptor"))),
+			interceptor.DeadlineInterceptor(serverCfg.DefaultDeadline, serverCfg.MethodDeadlines),
+			concurrencyUnary,
 		),
-		grpc.StreamInterceptor(grpcMetrics.StreamServerInterceptor()),
-	)
+		grpc.ChainStreamInterceptor(
+			grpcMetrics.StreamServerInterceptor(),
+			rpcMetricsStream,
+			metadataStream,
+			requestIdStream,
+			accessLogStream,
+			queryTagStream,
+			interceptor.ErrorStreamInterceptor(log.With(observability.Module("interceptor"))),
+			concurrencyStream,
+			interceptor.StreamSendInterceptor(serverCfg.StreamSendTimeout, obs.Meter()),
+		),
+	}
+	// Methods cost interceptor.DefaultMethodCost unless listed here.
+	meteringUnary := interceptor.MeteringInterceptor(usageSvc, map[string]int64{
+		v1.LedgerService_ListLedgers_FullMethodName:  5,
+		v1.UserService_ExportUserData_FullMethodName: 20,
+	})
+	authzUnary := interceptor.AuthzInterceptor(authzPolicy, authz.Grants(serverCfg.Authz.Roles), roleSvc, obs.Meter())
+	server := grpc.NewServer(append(serverOpts,
+		grpc.Creds(serverCreds),
+		grpc.ChainUnaryInterceptor(authenticators...),
+		grpc.ChainUnaryInterceptor(
+			auditUnary,
+			authzUnary,
+			interceptor.ActorInterceptor(),
+			rateLimitUnary,
+			interceptor.ValidationInterceptor(),
+			meteringUnary,
+		),
+		// Server streams go through the same interceptors once their request
+		// arrives. They are rate limited before, by peer IP.
+		grpc.ChainStreamInterceptor(
+			rateLimitStream,
+			interceptor.UnaryOnServerStreams(append(slices.Clone(authenticators),
+				auditUnary,
+				authzUnary,
+				interceptor.ActorInterceptor(),
+				interceptor.ValidationInterceptor(),
+				meteringUnary,
+			)...),
+		),
+	)...)
+	// The admin server, when enabled, only authenticates client
+	// certificates: its callers are operators' tools and services, and the
+	// TLS handshake has already rejected anyone without one. AdminService is
+	// never served on the public server.
+	var adminServer *grpc.Server
+	if serverCfg.Admin.Address != "" {
+		adminServer = grpc.NewServer(append(serverOpts,
+			grpc.Creds(adminCreds),
+			grpc.ChainUnaryInterceptor(
+				interceptor.ClientCertInterceptor(),
+				auditUnary,
+				authzUnary,
+				interceptor.ActorInterceptor(),
+				interceptor.ValidationInterceptor(),
+			),
+		)...)
+	}
 
-	userCtrl := controller.NewUserController(userSvc)
+	var idOpts []idcodec.Option
+	if serverCfg.PublicIdKey != "" {
+		idOpts = append(idOpts, idcodec.WithKey([]byte(serverCfg.PublicIdKey)))
+	} else if serverCfg.PublicIdMode != idcodec.ModeInt64 {
+		log.Warn("PUBLIC_ID_KEY is not set, public ids can be decoded to reveal creation time")
+	}
+	ids := convert.NewIDs(idcodecImpl.NewBase62Codec(idOpts...), serverCfg.PublicIdMode)
+	passwordOpts := []password.Option{
+		password.WithLength(serverCfg.Password.MinLength, serverCfg.Password.MaxLength),
+		password.WithMinEntropy(serverCfg.Password.MinEntropyBits),
+	}
+	if serverCfg.Password.BreachCheck {
+		breaches := passwordImpl.NewPwnedPasswords(
+			serverCfg.Password.BreachAPIURL,
+			httpclientImpl.NewHTTPClient(httpclient.WithTimeout(2*time.Second), httpclient.WithEgressPolicy(httpclient.DefaultEgressPolicy())),
+			cbImpl.NewCircuitBreaker(gobreaker.Settings{Name: "pwned_passwords"}),
+			retryImpl.NewRetry(2, retry.WithInterval(100*time.Millisecond)),
+		)
+		passwordOpts = append(passwordOpts, password.WithBreachChecker(breaches, func(ctx context.Context, err error) {
+			log.Warn("password breach check failed, password accepted unchecked", observability.Err(err))
+		}))
+	}
+	passwordPolicy := passwordImpl.NewPolicy(passwordOpts...)
+	passwordResetSvc := service.NewPasswordResetService(dbs.UnitOfWorkFactory, emailSender, passwordPolicy, passwordHasher, idGen, serverCfg.Email.PasswordResetURL, serverCfg.Email.PasswordResetTTL, serviceLog)
+	exportSvc := service.NewDataExportService(dbs.UnitOfWorkFactory, serviceLog)
+	userCtrl := controller.NewUserController(userSvc, ids, passwordPolicy, twoFactorSvc, sessionSvc, verificationSvc, passwordResetSvc, exportSvc)
+	ledgerCtrl := controller.NewLedgerController(ledgerSvc, ids, controller.PageSizeLimit{
+		Default: serverCfg.LedgerPageSize.Max,
+		ByRole:  serverCfg.LedgerPageSize.MaxByRole,
+	})
+	adminCtrl := controller.NewAdminController(dependencySvc, deadLetterSvc, userSvc, configSvc, schemaDriftSvc, loginThrottleSvc, identitySvc, idempotencyRecordSvc, roleSvc)
 
 	v1.RegisterUserServiceServer(server, userCtrl)
+	v1.RegisterLedgerServiceServer(server, ledgerCtrl)
+	if adminServer != nil {
+		v1.RegisterAdminServiceServer(adminServer, adminCtrl)
+	} else {
+		log.Warn("ADMIN_GRPC_ADDRESS is not set, AdminService is not served")
+	}
 
 	healthServer := health.NewServer()
 	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
 
-	if sqlDB, err := dbs.DB.DB(); err != nil {
+	if sqlDB, err := dbs.Main.DB.DB(); err != nil {
 		log.Error("failed to get sql db for health check", observability.Err(err))
 	} else if err := sqlDB.PingContext(ctx); err != nil {
 		log.Error("database ping failed, server marked as not serving", observability.Err(err))
@@ -96,7 +487,23 @@ func main() {
 		healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
 	}
 
+	setMetricsHealth(ctx, obs, healthServer, log)
+
 	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	if adminServer != nil {
+		grpc_health_v1.RegisterHealthServer(adminServer, healthServer)
+	}
+	// Reflection lets cmd/smoketest and tools such as grpcurl discover the
+	// services without the proto files.
+	reflection.Register(server)
+
+	go dependencySvc.Run(ctx, 10*time.Second)
+	go deadLetterSvc.Run(ctx, 30*time.Second)
+	go tableStatsSvc.Run(ctx, time.Minute)
+	go usageSvc.Run(ctx, 10*time.Second)
+	if serverCfg.IdempotencyScanInterval > 0 {
+		go idempotencyRecordSvc.Run(ctx, serverCfg.IdempotencyScanInterval, serverCfg.IdempotencyRederive)
+	}
 
 	go func() {
 		ticker := time.NewTicker(10 * time.Second)
@@ -106,12 +513,13 @@ func main() {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				sqlDB, err := dbs.DB.DB()
+				sqlDB, err := dbs.Main.DB.DB()
 				if err != nil || sqlDB.PingContext(ctx) != nil {
 					healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
 				} else {
 					healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
 				}
+				setMetricsHealth(ctx, obs, healthServer, log)
 			}
 		}
 	}()
@@ -119,21 +527,99 @@ func main() {
 	grpcMetrics.InitializeMetrics(server)
 
 	go func() {
-		log.Info("gRPC server running on :50051")
+		log.Info("gRPC server running", observability.String("address", lis.Addr().String()), observability.Bool("tls", serverCfg.TLS.CertFile != ""))
 		if err := server.Serve(lis); err != nil {
 			log.Fatal("failed to serve: %v", observability.Err(err))
 		}
 	}()
 
+	servers := []*grpc.Server{server}
+	if adminServer != nil {
+		grpcMetrics.InitializeMetrics(adminServer)
+		adminLis, err := net.Listen("tcp", serverCfg.Admin.Address)
+		if err != nil {
+			log.Fatal("failed to listen for admin RPCs", observability.Err(err))
+		}
+		go func() {
+			log.Info("admin gRPC server running", observability.String("address", adminLis.Addr().String()))
+			if err := adminServer.Serve(adminLis); err != nil {
+				log.Fatal("failed to serve admin RPCs", observability.Err(err))
+			}
+		}()
+		servers = append(servers, adminServer)
+	}
+
+	if serverCfg.ProbeInterval > 0 {
+		// The probe dials this server so its requests cross every
+		// interceptor, exactly like a client's.
+		probeOpts := []grpc.DialOption{
+			grpc.WithTransportCredentials(probeCreds),
+			grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		}
+		if keyId := serverCfg.ProbeSigningKey; keyId != "" {
+			probeOpts = append(probeOpts, grpc.WithUnaryInterceptor(interceptor.SigningClientInterceptor(keyId, signingSecrets[keyId])))
+		}
+		probeConn, err := grpc.NewClient(dialTarget(lis.Addr()), probeOpts...)
+		if err != nil {
+			log.Fatal("failed to create probe client", observability.Err(err))
+		}
+		defer probeConn.Close()
+		go probe.NewProber(probeConn, obs.Meter(), log.With(observability.Module("probe"))).Run(ctx, serverCfg.ProbeInterval)
+	}
+
 	<-ctx.Done()
 	log.Info("Graceful stopping gRPC server...")
 	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
-	server.GracefulStop()
+	stopped := make(chan struct{})
+	go func() {
+		var wg sync.WaitGroup
+		for _, s := range servers {
+			wg.Go(s.GracefulStop)
+		}
+		wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(serverCfg.ShutdownGracePeriod):
+		log.Warn("grace period elapsed, cancelling in-flight RPCs")
+		for _, s := range servers {
+			s.Stop()
+		}
+	}
 	log.Info("gRPC server stopped")
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), serverCfg.ShutdownTimeout)
 	defer shutdownCancel()
 	if err := obs.Close(shutdownCtx); err != nil {
 		log.Error("failed to close observability", observability.Err(err))
 	}
 }
+
+// setMetricsHealth sets metricsHealthService's status from obs.Check,
+// logging when the metrics server stops answering.
+func setMetricsHealth(ctx context.Context, obs observability.Observability, healthServer *health.Server, log observability.Logger) {
+	checkCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := obs.Check(checkCtx); err != nil {
+		if resp, _ := healthServer.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: metricsHealthService}); resp.GetStatus() != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+			log.Error("metrics server is not serving", observability.Err(err))
+		}
+		healthServer.SetServingStatus(metricsHealthService, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+		return
+	}
+	healthServer.SetServingStatus(metricsHealthService, grpc_health_v1.HealthCheckResponse_SERVING)
+}
+
+// dialTarget is the address the server reaches itself on: addr's port on
+// loopback, since a wildcard listen address cannot be dialled.
+func dialTarget(addr net.Addr) string {
+	host := "localhost"
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		if !tcp.IP.IsUnspecified() {
+			host = tcp.IP.String()
+		}
+		return net.JoinHostPort(host, strconv.Itoa(tcp.Port))
+	}
+	return addr.String()
+}
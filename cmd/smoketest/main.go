@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/internal/smoketest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Exit codes: 0 when every check passed, 1 when any failed, 2 on bad usage.
+func main() {
+	target := flag.String("target", "localhost:50051", "server address to test")
+	probeId := flag.Int64("probe-id", 0, "id of a user that always exists")
+	probePublicId := flag.String("probe-public-id", "", "public id of a user that always exists, when PUBLIC_ID_MODE is opaque")
+	timeout := flag.Duration("timeout", 10*time.Second, "deadline for the whole run")
+	useTLS := flag.Bool("tls", false, "connect with TLS using the system roots")
+	certFile := flag.String("tls-cert", "", "client certificate to present, for servers requiring mutual TLS")
+	keyFile := flag.String("tls-key", "", "private key of -tls-cert")
+	flag.Parse()
+
+	if (*probeId == 0) == (*probePublicId == "") {
+		log.Print("exactly one of -probe-id and -probe-public-id is required")
+		os.Exit(2)
+	}
+	if (*certFile == "") != (*keyFile == "") || (*certFile != "" && !*useTLS) {
+		log.Print("-tls-cert and -tls-key must be set together, with -tls")
+		os.Exit(2)
+	}
+
+	cfg := smoketest.Config{ProbeUserId: *probeId, ProbeUserPublicId: *probePublicId}
+
+	creds := insecure.NewCredentials()
+	if *useTLS {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if *certFile != "" {
+			cert, err := tls.LoadX509KeyPair(*certFile, *keyFile)
+			if err != nil {
+				log.Printf("failed to load client certificate: %v", err)
+				os.Exit(2)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	// The signing secret is read from the environment rather than a flag so
+	// it does not show up in process listings.
+	if keyId := os.Getenv("SMOKETEST_SIGNING_KEY"); keyId != "" {
+		secret := []byte(os.Getenv("SMOKETEST_SIGNING_SECRET"))
+		dialOpts = append(dialOpts, grpc.WithUnaryInterceptor(interceptor.SigningClientInterceptor(keyId, secret)))
+	}
+	conn, err := grpc.NewClient(*target, dialOpts...)
+	if err != nil {
+		log.Printf("failed to create client: %v", err)
+		os.Exit(2)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	report := smoketest.Run(ctx, conn, *target, cfg)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Printf("failed to write report: %v", err)
+	}
+	if !report.Passed {
+		os.Exit(1)
+	}
+}
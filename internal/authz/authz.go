@@ -0,0 +1,146 @@
+// Package authz decides which callers may invoke which RPCs. A Policy maps
+// methods to the roles, or permissions, allowed to call them and Grants maps
+// callers to their roles. Both are loaded from configuration, so a new RPC is
+// covered by adding it, or its service, to the policy. A Resolver adds the
+// roles and permissions stored for a caller in the database.
+package authz
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+)
+
+// AnyRole in a policy allows every caller, authenticated or not, e.g. for
+// health checks when unlisted methods are denied.
+const AnyRole = "*"
+
+// Policy maps full method names, or service prefixes ending in "/", to the
+// roles allowed to call them. An exact method takes precedence over its
+// service. Methods matching no entry are allowed, unless the policy denies
+// unlisted methods.
+type Policy struct {
+	rules        map[string][]string
+	denyUnlisted bool
+}
+
+// NewPolicy builds a policy from rules, keyed by method or service prefix.
+// Every pattern must start with "/" and name at least one role.
+func NewPolicy(rules map[string][]string, denyUnlisted bool) (*Policy, error) {
+	for pattern, roles := range rules {
+		if !strings.HasPrefix(pattern, "/") {
+			return nil, fmt.Errorf("method pattern %q must start with /", pattern)
+		}
+		if len(roles) == 0 {
+			return nil, fmt.Errorf("method pattern %q lists no roles", pattern)
+		}
+	}
+	return &Policy{rules: rules, denyUnlisted: denyUnlisted}, nil
+}
+
+// Roles returns the roles allowed to call fullMethod. It reports false when
+// no entry covers the method.
+func (p *Policy) Roles(fullMethod string) ([]string, bool) {
+	if roles, ok := p.rules[fullMethod]; ok {
+		return roles, true
+	}
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		if roles, ok := p.rules[fullMethod[:i+1]]; ok {
+			return roles, true
+		}
+	}
+	return nil, false
+}
+
+// Public reports whether fullMethod may be called without authenticating.
+func (p *Policy) Public(fullMethod string) bool {
+	roles, ok := p.Roles(fullMethod)
+	if !ok {
+		return !p.denyUnlisted
+	}
+	return slices.Contains(roles, AnyRole)
+}
+
+// Authorize fails with apperror.ErrPermissionDenied unless one of roles may
+// call fullMethod. Permissions are passed among roles, as policy entries may
+// name either.
+func (p *Policy) Authorize(fullMethod string, roles []string) error {
+	if p.Public(fullMethod) {
+		return nil
+	}
+	allowed, ok := p.Roles(fullMethod)
+	if !ok {
+		return apperror.PermissionDeniedf("%s is not covered by the authorization policy", fullMethod)
+	}
+	for _, role := range roles {
+		if slices.Contains(allowed, role) {
+			return nil
+		}
+	}
+	return apperror.PermissionDeniedf("%s requires one of the roles %s", fullMethod, strings.Join(allowed, ", "))
+}
+
+// Grants maps callers, as "kind:id" (see interceptor.Caller), to their roles.
+// A "kind:*" entry grants roles to every caller of that kind.
+type Grants map[string][]string
+
+// Roles returns the roles granted to caller, directly or through its kind.
+func (g Grants) Roles(caller string) []string {
+	roles := slices.Clone(g[caller])
+	if kind, _, ok := strings.Cut(caller, ":"); ok {
+		roles = append(roles, g[kind+":*"]...)
+	}
+	return roles
+}
+
+// Access is what an authenticated caller holds: its roles and the
+// permissions those roles carry.
+type Access struct {
+	Roles       []string
+	Permissions []string
+}
+
+// Resolver looks up the access of caller, as "kind:id", given the roles
+// Grants gives it. Implementations add the roles stored for the caller and
+// the permissions of every role.
+type Resolver interface {
+	Resolve(ctx context.Context, caller string, roles []string) (Access, error)
+}
+
+type rolesKey struct{}
+
+// ContextWithRoles records the roles granted to the authenticated caller,
+// for handlers whose behaviour, rather than access, depends on them.
+func ContextWithRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
+
+// RolesFromContext returns the roles recorded by ContextWithRoles, or nil
+// when the caller is not authenticated.
+func RolesFromContext(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey{}).([]string)
+	return roles
+}
+
+type permissionsKey struct{}
+
+// ContextWithPermissions records the permissions the authenticated caller's
+// roles carry, so handlers can check them without looking them up again.
+func ContextWithPermissions(ctx context.Context, permissions []string) context.Context {
+	return context.WithValue(ctx, permissionsKey{}, permissions)
+}
+
+// PermissionsFromContext returns the permissions recorded by
+// ContextWithPermissions, or nil when the caller is not authenticated.
+func PermissionsFromContext(ctx context.Context) []string {
+	permissions, _ := ctx.Value(permissionsKey{}).([]string)
+	return permissions
+}
+
+// HasPermission reports whether the authenticated caller holds permission.
+func HasPermission(ctx context.Context, permission string) bool {
+	return slices.Contains(PermissionsFromContext(ctx), permission)
+}
@@ -1,70 +1,147 @@
 package bootstrap
 
 import (
-	"errors"
-	"net"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jt828/go-grpc-template/internal/config"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	auditImpl "github.com/jt828/go-grpc-template/pkg/audit/implementation"
 	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
 	cbImpl "github.com/jt828/go-grpc-template/pkg/circuitbreaker/implementation"
+	"github.com/jt828/go-grpc-template/pkg/model"
 	"github.com/jt828/go-grpc-template/pkg/observability"
 	obsImpl "github.com/jt828/go-grpc-template/pkg/observability/implementation"
+	"github.com/jt828/go-grpc-template/pkg/pgclass"
 	"github.com/jt828/go-grpc-template/pkg/retry"
 	retryImpl "github.com/jt828/go-grpc-template/pkg/retry/implementation"
-	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/snowflake"
 	"github.com/sony/gobreaker/v2"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 type Database struct {
-	DB             *gorm.DB
-	CircuitBreaker circuitbreaker.CircuitBreaker
+	Name              string
+	Schema            string
+	DB                *gorm.DB
+	CircuitBreaker    circuitbreaker.CircuitBreaker
+	UnitOfWorkFactory repository.UnitOfWorkFactory
+}
+
+// Databases holds the main store and the idempotency and ledger stores. A
+// secondary store configured without a DSN shares Main.
+type Databases struct {
+	Main              *Database
+	Idempotency       *Database
+	Ledger            *Database
 	UnitOfWorkFactory repository.UnitOfWorkFactory
 }
 
-func InitializeDatabase(dsn string, meter observability.Meter) (*Database, error) {
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// Distinct returns every separately connected store once, Main first.
+func (d *Databases) Distinct() []*Database {
+	distinct := []*Database{d.Main}
+	for _, db := range []*Database{d.Ledger, d.Idempotency} {
+		if db != d.Main {
+			distinct = append(distinct, db)
+		}
+	}
+	return distinct
+}
+
+// InitializeDatabases connects the stores. Inserts of rows keyed by an id
+// from ids draw a fresh one when it is already taken.
+func InitializeDatabases(main, idempotency, ledger config.DatabaseConfig, serviceName string, obs observability.Observability, ids snowflake.Snowflake, opts ...repository.CompositeOption) (*Databases, error) {
+	metrics := obsImpl.NewGormMetricsPlugin(obs.Meter())
+	repoOpts := []repository.Option{
+		repository.WithMetrics(obs.Meter()),
+		repository.WithTracing(obs.Tracer()),
+		repository.WithLogging(obs.Logger().With(observability.Module("repository"))),
+		repository.WithDeadlineTimeouts(),
+		repository.WithIdRegeneration(ids, repository.DefaultIdRegenerationAttempts, obs.Meter()),
+	}
+
+	mainDB, err := initializeDatabase(main, serviceName, metrics, repoOpts)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := db.Use(obsImpl.NewGormMetricsPlugin(meter)); err != nil {
+	open := func(cfg config.DatabaseConfig) (*Database, error) {
+		if cfg.DSN == "" {
+			return mainDB, nil
+		}
+		return initializeDatabase(cfg, serviceName, metrics, repoOpts)
+	}
+
+	idempotencyDB, err := open(idempotency)
+	if err != nil {
+		return nil, err
+	}
+	ledgerDB, err := open(ledger)
+	if err != nil {
 		return nil, err
 	}
 
-	cb := cbImpl.NewCircuitBreaker(gobreaker.Settings{
-		Name: "postgresql",
-	})
+	uowFactory := mainDB.UnitOfWorkFactory
+	if idempotencyDB != mainDB || ledgerDB != mainDB {
+		uowFactory = repository.NewCompositeUnitOfWorkFactory(mainDB.UnitOfWorkFactory, ledgerDB.UnitOfWorkFactory, idempotencyDB.UnitOfWorkFactory, opts...)
+	}
 
-	retry := retryImpl.NewRetry(3, retry.WithInterval(100*time.Millisecond), retry.WithRetryable(func(err error) bool {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case "40001": // serialization_failure
-				return true
-			case "40P01": // deadlock_detected
-				return true
-			case "08006": // connection_failure
-				return true
-			case "08001": // sqlclient_unable_to_establish_sqlconnection
-				return true
-			case "08004": // sqlserver_rejected_establishment_of_sqlconnection
-				return true
-			}
-		}
+	return &Databases{
+		Main:              mainDB,
+		Idempotency:       idempotencyDB,
+		Ledger:            ledgerDB,
+		UnitOfWorkFactory: uowFactory,
+	}, nil
+}
 
-		var netErr *net.OpError
-		if errors.As(err, &netErr) {
-			return true
-		}
+func initializeDatabase(cfg config.DatabaseConfig, serviceName string, metrics gorm.Plugin, repoOpts []repository.Option) (*Database, error) {
+	db, err := gorm.Open(postgres.Open(cfg.DSN), &gorm.Config{NamingStrategy: model.NamingStrategy(cfg.Schema)})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Use(metrics); err != nil {
+		return nil, err
+	}
+
+	if err := db.Use(obsImpl.NewGormQueryTagPlugin(serviceName)); err != nil {
+		return nil, err
+	}
+
+	if err := db.Use(auditImpl.NewGormActorPlugin()); err != nil {
+		return nil, err
+	}
+
+	if err := db.Use(auditImpl.NewGormTimestampPlugin(time.Now)); err != nil {
+		return nil, err
+	}
+
+	cb := cbImpl.NewCircuitBreaker(gobreaker.Settings{
+		Name:        cfg.Name,
+		MaxRequests: cfg.Breaker.MaxRequests,
+		Timeout:     cfg.Breaker.Timeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= cfg.Breaker.ConsecutiveFailures
+		},
+		// Only transient failures say anything about the database's health;
+		// a constraint violation or missing row must not trip the breaker.
+		IsSuccessful: func(err error) bool {
+			return err == nil || !pgclass.IsRetryable(err)
+		},
+	})
 
-		return false
+	// Repositories run inside a unit of work's transaction, which a
+	// serialization failure or deadlock aborts, so retrying the statement
+	// cannot succeed. service.RunInUnitOfWorkWithRetry restarts the whole
+	// transaction instead.
+	retry := retryImpl.NewRetry(cfg.Retry.MaxRetries, retry.WithInterval(cfg.Retry.Interval), retry.WithRetryable(func(err error) bool {
+		return pgclass.IsRetryable(err) && !pgclass.IsSerializationFailure(err)
 	}))
-	uowFactory := repository.NewTransactionDbUnitOfWorkFactory(db, cb, retry)
+	uowFactory := repository.NewTransactionDbUnitOfWorkFactory(db, cb, retry, repoOpts...)
 
 	return &Database{
+		Name:              cfg.Name,
+		Schema:            cfg.Schema,
 		DB:                db,
 		CircuitBreaker:    cb,
 		UnitOfWorkFactory: uowFactory,
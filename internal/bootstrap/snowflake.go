@@ -1,17 +1,17 @@
 package bootstrap
 
 import (
-	"encoding/binary"
-	"fmt"
-	"hash/fnv"
 	"os"
 
+	"github.com/jt828/go-grpc-template/internal/config"
 	"github.com/jt828/go-grpc-template/pkg/snowflake"
 	snowflakeImpl "github.com/jt828/go-grpc-template/pkg/snowflake/implementation"
 )
 
-func InitializeSnowflake() (snowflake.Snowflake, error) {
-	nodeID, err := PodNodeID()
+// InitializeSnowflake returns a generator whose node id is drawn from nodes
+// by the pod's hostname.
+func InitializeSnowflake(nodes config.NodeRange) (snowflake.Snowflake, error) {
+	nodeID, err := nodes.NodeID(os.Getenv("HOSTNAME"))
 	if err != nil {
 		return nil, err
 	}
@@ -19,14 +19,5 @@ func InitializeSnowflake() (snowflake.Snowflake, error) {
 }
 
 func PodNodeID() (int64, error) {
-	hostname := os.Getenv("HOSTNAME")
-	if hostname == "" {
-		return 0, fmt.Errorf("HOSTNAME is not set")
-	}
-
-	h := fnv.New64a()
-	h.Write([]byte(hostname))
-	nodeID := int64(binary.BigEndian.Uint64(h.Sum(nil)) % 1024)
-
-	return nodeID, nil
+	return config.NodeID(os.Getenv("HOSTNAME"))
 }
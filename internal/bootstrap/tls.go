@@ -0,0 +1,153 @@
+package bootstrap
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/config"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+)
+
+// CertReloader serves a certificate and key pair from disk, re-reading them
+// when either file's modification time changes, so a rotated certificate is
+// picked up by the next handshake without a restart. Existing connections
+// keep the certificate they were established with.
+//
+// When cfg names client CAs, the server also asks clients for a certificate
+// and verifies it against them. The CAs are read once at startup.
+type CertReloader struct {
+	certFile          string
+	keyFile           string
+	clientCAs         *x509.CertPool
+	requireClientCert bool
+	log               observability.Logger
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	certMod time.Time
+	keyMod  time.Time
+}
+
+// NewCertReloader loads cfg's certificate, key and client CAs, failing if
+// they cannot be read.
+func NewCertReloader(cfg config.TLSConfig, log observability.Logger) (*CertReloader, error) {
+	r := &CertReloader{certFile: cfg.CertFile, keyFile: cfg.KeyFile, requireClientCert: cfg.RequireClientCert, log: log}
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		r.clientCAs = x509.NewCertPool()
+		if !r.clientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s holds no PEM encoded certificates", cfg.ClientCAFile)
+		}
+	}
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(certMod, keyMod); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate. While the files are
+// missing or do not load, typically because only one of them has been
+// replaced yet, the previous certificate is served.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certMod, keyMod, err := r.modTimes()
+	if err != nil || (certMod.Equal(r.certMod) && keyMod.Equal(r.keyMod)) {
+		return r.cert, nil
+	}
+	if err := r.load(certMod, keyMod); err != nil {
+		// Remember the failed pair so it is retried once a file changes
+		// again rather than on every handshake.
+		r.certMod, r.keyMod = certMod, keyMod
+		r.log.Warn("failed to reload TLS certificate, serving the previous one", observability.Err(err))
+		return r.cert, nil
+	}
+	r.log.Info("reloaded TLS certificate", observability.String("cert_file", r.certFile))
+	return r.cert, nil
+}
+
+// ServerConfig returns a TLS configuration serving the reloaded certificate
+// and verifying client certificates against the client CAs, if any.
+func (r *CertReloader) ServerConfig() *tls.Config {
+	cfg := &tls.Config{GetCertificate: r.GetCertificate, MinVersion: tls.VersionTLS12}
+	if r.clientCAs != nil {
+		cfg.ClientCAs = r.clientCAs
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		if r.requireClientCert {
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return cfg
+}
+
+// MutualServerConfig is ServerConfig rejecting clients without a certificate
+// issued by the client CAs, which must be configured.
+func (r *CertReloader) MutualServerConfig() *tls.Config {
+	cfg := r.ServerConfig()
+	cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	return cfg
+}
+
+// PinnedClientConfig returns a TLS configuration that trusts exactly the
+// certificate being served, for the server to dial itself whatever its
+// certificate's issuer and names. When client certificates are required it
+// presents the served certificate as its own, which must then be issued by
+// one of the client CAs.
+func (r *CertReloader) PinnedClientConfig() *tls.Config {
+	var getClientCertificate func(*tls.CertificateRequestInfo) (*tls.Certificate, error)
+	if r.requireClientCert {
+		getClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.GetCertificate(nil)
+		}
+	}
+	return &tls.Config{
+		MinVersion:           tls.VersionTLS12,
+		GetClientCertificate: getClientCertificate,
+		// Verification is replaced by pinning, not skipped.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			r.mu.Lock()
+			leaf := r.cert.Certificate[0]
+			r.mu.Unlock()
+			if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], leaf) {
+				return fmt.Errorf("peer certificate is not the served certificate")
+			}
+			return nil
+		},
+	}
+}
+
+func (r *CertReloader) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+// load must be called with r.mu held, or before r is shared.
+func (r *CertReloader) load(certMod, keyMod time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert, r.certMod, r.keyMod = &cert, certMod, keyMod
+	return nil
+}
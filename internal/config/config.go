@@ -0,0 +1,1757 @@
+// Package config loads the server's configuration from environment variables
+// and an optional YAML file into a typed Config.
+package config
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/authz"
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"github.com/jt828/go-grpc-template/pkg/httpmiddleware"
+	"github.com/jt828/go-grpc-template/pkg/idcodec"
+	"github.com/jt828/go-grpc-template/pkg/locality"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/password"
+	"google.golang.org/grpc/keepalive"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// redactedSecret matches what url.URL.Redacted uses for passwords.
+	redactedSecret   = "xxxxx"
+	defaultRateLimit = 600
+)
+
+// Config is the server's configuration.
+type Config struct {
+	ServiceName string
+	Hostname    string
+	// Locality is the region and zone the pod runs in. When set, they label
+	// every metric and the trace resource.
+	Locality locality.Locality
+	// SnowflakeNodes is the range the pod's snowflake node id is drawn from.
+	SnowflakeNodes NodeRange
+	// File is the YAML file the configuration was read from, if any.
+	File string
+	// GRPCAddress and MetricsAddress are the listen addresses of the gRPC
+	// server and the Prometheus endpoint. OTLPEndpoint is the host:port that
+	// receives traces, as bounded by TraceExport. Propagators are the formats
+	// trace context and baggage cross process boundaries in.
+	GRPCAddress    string
+	MetricsAddress string
+	OTLPEndpoint   string
+	TraceExport    observability.TraceExportConfig
+	Propagators    []observability.Propagator
+	// TLS serves gRPC over TLS when its CertFile is set.
+	TLS         TLSConfig
+	Main        DatabaseConfig
+	Idempotency DatabaseConfig
+	Ledger      DatabaseConfig
+	// RateLimitPerMinute is the rate a caller's quota refills at and
+	// RateLimitBurst how many requests it holds, so callers idle for a
+	// while may briefly exceed the rate.
+	RateLimitPerMinute int
+	RateLimitBurst     int
+	LedgerPageSize     LedgerPageSizeConfig
+	// PublicIdMode selects whether clients see int64 ids, opaque string ids
+	// or both. PublicIdKey, when set, scrambles the opaque ids.
+	PublicIdMode idcodec.Mode
+	PublicIdKey  string
+	// SigningSecrets maps request signing key ids to their shared secrets.
+	// SignedMethods lists the methods, or service prefixes ending in "/",
+	// that reject unsigned requests.
+	SigningSecrets map[string]string
+	SignedMethods  []string
+	// FieldEncryptionKeys maps key ids to the AES-256 keys that encrypt
+	// sensitive columns (see pkg/fieldcrypto). New values are encrypted with
+	// FieldEncryptionPrimaryKey.
+	FieldEncryptionKeys       map[string][]byte
+	FieldEncryptionPrimaryKey string
+	// BucketPresets holds the histogram buckets, in seconds, of every
+	// observability.BucketPreset, with any METRIC_BUCKETS_* overrides applied.
+	BucketPresets map[observability.BucketPreset][]float64
+	// MetricTenants holds the tenants that get their own value on per-tenant
+	// metrics; every other tenant is recorded as observability.OtherTenant.
+	MetricTenants *observability.TenantLabels
+	// MetricNamespace and MetricSubsystem prefix every metric name, and
+	// MetricLabels, the service and, when set, its version and environment,
+	// are added to every metric, so one Prometheus can scrape several
+	// services.
+	MetricNamespace string
+	MetricSubsystem string
+	MetricLabels    []observability.Label
+	Log             observability.LogConfig
+	AccessLog       AccessLogConfig
+	Audit           AuditConfig
+	// ProbeInterval is how often the synthetic end-to-end probe runs; zero
+	// disables it. ProbeSigningKey names the SigningSecrets key the probe
+	// signs with, for when its methods are in SignedMethods.
+	ProbeInterval   time.Duration
+	ProbeSigningKey string
+	// IdempotencyScanInterval is how often a page of idempotency records is
+	// checked for responses that no longer decode, which are quarantined;
+	// zero disables the scan. With IdempotencyRederive the scan also
+	// rebuilds their responses from the entities they refer to.
+	IdempotencyScanInterval time.Duration
+	IdempotencyRederive     bool
+	// Keepalive and KeepalivePolicy configure how the server pings, ages out
+	// and reaps connections. Zero fields keep gRPC's defaults.
+	Keepalive       keepalive.ServerParameters
+	KeepalivePolicy keepalive.EnforcementPolicy
+	// DefaultDeadline bounds requests sent without a deadline, and
+	// MethodDeadlines overrides it for methods, or service prefixes ending
+	// in "/". Zero leaves such requests unbounded.
+	DefaultDeadline time.Duration
+	MethodDeadlines map[string]time.Duration
+	Concurrency     ConcurrencyConfig
+	Metadata        MetadataConfig
+	// StreamSendTimeout aborts streams whose client has not made room for
+	// a message within it. Zero lets sends wait as long as the stream lives.
+	StreamSendTimeout time.Duration
+	HTTP              HTTPConfig
+	// ShutdownGracePeriod bounds how long in-flight RPCs may finish once
+	// shutdown starts; ShutdownTimeout then bounds flushing telemetry.
+	ShutdownGracePeriod time.Duration
+	ShutdownTimeout     time.Duration
+	Password            PasswordConfig
+	Login               LoginConfig
+	TwoFactor           TwoFactorConfig
+	Email               EmailConfig
+	Authz               AuthzConfig
+	OIDC                OIDCConfig
+	Admin               AdminConfig
+}
+
+// DatabaseConfig describes one Postgres store. Name identifies the store's
+// circuit breaker and dependency probe.
+type DatabaseConfig struct {
+	Name    string
+	DSN     string
+	Schema  string
+	Retry   RetryConfig
+	Breaker BreakerConfig
+}
+
+// RetryConfig controls how repositories retry transient failures.
+type RetryConfig struct {
+	MaxRetries uint64
+	Interval   time.Duration
+}
+
+// BreakerConfig controls a store's circuit breaker. It opens after
+// ConsecutiveFailures transient failures in a row, stays open for Timeout,
+// then lets MaxRequests trial requests through.
+type BreakerConfig struct {
+	ConsecutiveFailures uint32
+	Timeout             time.Duration
+	MaxRequests         uint32
+}
+
+// PasswordConfig is the policy new passwords must meet and how they are
+// hashed. BreachAPIURL is a Pwned Passwords compatible range API, queried
+// when BreachCheck is set.
+type PasswordConfig struct {
+	MinLength      int
+	MaxLength      int
+	MinEntropyBits float64
+	BreachCheck    bool
+	BreachAPIURL   string
+	HashAlgorithm  password.Algorithm
+}
+
+// LoginConfig bounds password guessing. After MaxFailures consecutive failed
+// logins to one user from one IP, that IP is locked out for LockoutBase,
+// doubling with each further failure up to LockoutMax. After
+// AccountMaxFailures from any IPs, 0 disabling it, the whole account is
+// locked for AccountLockout. Counts restart once no failure has been seen
+// for FailureReset.
+type LoginConfig struct {
+	MaxFailures        int
+	LockoutBase        time.Duration
+	LockoutMax         time.Duration
+	FailureReset       time.Duration
+	AccountMaxFailures int
+	AccountLockout     time.Duration
+}
+
+// LedgerPageSizeConfig caps how many ledgers one ListLedgers response holds.
+// Callers get the largest cap of their roles in MaxByRole, or Max when they
+// hold none of them. Unpaginated requests matching more are rejected.
+type LedgerPageSizeConfig struct {
+	Max       int
+	MaxByRole map[string]int
+}
+
+// ConcurrencyConfig caps the requests the server handles at once. Methods,
+// or service prefixes ending in "/", listed in Methods share their own
+// limit; every other method shares Max. Zero leaves requests unbounded.
+type ConcurrencyConfig struct {
+	Max     int
+	Methods map[string]int
+}
+
+// MetadataConfig configures the request metadata guard. Requests whose
+// metadata is larger than MaxBytes are rejected, 0 leaves it unbounded.
+// AllowedKeys lists the keys kept besides those the server reads; an entry
+// ending in "*" keeps every key it prefixes.
+type MetadataConfig struct {
+	MaxBytes    int
+	AllowedKeys []string
+}
+
+// HTTPConfig configures the middleware of the HTTP surfaces: CORS for those
+// browsers call, and the security headers every one of them sends.
+type HTTPConfig struct {
+	CORS     httpmiddleware.CORSPolicy
+	Security httpmiddleware.SecurityPolicy
+}
+
+// AccessLogConfig configures the per-call access log. ExcludedMethods lists
+// the methods, or service prefixes ending in "/", that are not logged. With
+// Payloads set, requests and responses are logged too, at debug level, so
+// LOG_LEVEL or LOG_MODULE_LEVELS must enable debug for the access module.
+type AccessLogConfig struct {
+	ExcludedMethods []string
+	Payloads        bool
+}
+
+// Audit sinks.
+const (
+	AuditSinkLog      = "log"
+	AuditSinkDatabase = "database"
+	AuditSinkNone     = "none"
+)
+
+// DefaultAuditMethods audits every RPC that changes state, and the user data
+// export, with its request.
+var DefaultAuditMethods = map[string]audit.Level{
+	"/proto.v1.UserService/CreateUser":                   audit.LevelRequest,
+	"/proto.v1.UserService/UpdateUserStatus":             audit.LevelRequest,
+	"/proto.v1.UserService/UpdateUser":                   audit.LevelRequest,
+	"/proto.v1.UserService/Enroll2FA":                    audit.LevelRequest,
+	"/proto.v1.UserService/Verify2FA":                    audit.LevelRequest,
+	"/proto.v1.UserService/Disable2FA":                   audit.LevelRequest,
+	"/proto.v1.UserService/RevokeSession":                audit.LevelRequest,
+	"/proto.v1.UserService/ChangePassword":               audit.LevelRequest,
+	"/proto.v1.UserService/SendVerificationEmail":        audit.LevelRequest,
+	"/proto.v1.UserService/VerifyEmail":                  audit.LevelRequest,
+	"/proto.v1.UserService/RequestPasswordReset":         audit.LevelRequest,
+	"/proto.v1.UserService/ConfirmPasswordReset":         audit.LevelRequest,
+	"/proto.v1.UserService/ExportUserData":               audit.LevelRequest,
+	"/proto.v1.AdminService/ReplayDeadLetter":            audit.LevelRequest,
+	"/proto.v1.AdminService/SuspendUser":                 audit.LevelRequest,
+	"/proto.v1.AdminService/ReactivateUser":              audit.LevelRequest,
+	"/proto.v1.AdminService/UnlockUser":                  audit.LevelRequest,
+	"/proto.v1.AdminService/LinkUserIdentity":            audit.LevelRequest,
+	"/proto.v1.AdminService/UnlinkUserIdentity":          audit.LevelRequest,
+	"/proto.v1.AdminService/QuarantineIdempotencyRecord": audit.LevelRequest,
+	"/proto.v1.AdminService/RepairIdempotencyRecord":     audit.LevelRequest,
+	"/proto.v1.AdminService/PutRole":                     audit.LevelRequest,
+	"/proto.v1.AdminService/AssignRole":                  audit.LevelRequest,
+	"/proto.v1.AdminService/UnassignRole":                audit.LevelRequest,
+}
+
+// AuditConfig selects where audit records go and which calls produce them.
+// Sink is AuditSinkLog, writing them to the "audit" log module,
+// AuditSinkDatabase, writing them to the main store's audit_records table,
+// or AuditSinkNone. Methods maps methods, or service prefixes ending in
+// "/", to their audit.Level.
+type AuditConfig struct {
+	Sink    string
+	Methods map[string]audit.Level
+}
+
+// TwoFactorConfig configures TOTP two-factor authentication. Issuer names
+// the service in authenticator apps.
+type TwoFactorConfig struct {
+	Issuer string
+}
+
+// Email senders.
+const (
+	EmailSenderLog  = "log"
+	EmailSenderSMTP = "smtp"
+)
+
+// EmailConfig selects how email is sent: EmailSenderLog logs it, for
+// development, and EmailSenderSMTP sends it as From through the relay at
+// SMTPAddress, authenticating when SMTPUsername is set. Verification links
+// point at VerificationURL, which receives the token as its token query
+// parameter, and expire after VerificationTTL. Password reset links work the
+// same with PasswordResetURL and PasswordResetTTL.
+type EmailConfig struct {
+	Sender           string
+	From             string
+	SMTPAddress      string
+	SMTPUsername     string
+	SMTPPassword     string
+	VerificationURL  string
+	VerificationTTL  time.Duration
+	PasswordResetURL string
+	PasswordResetTTL time.Duration
+}
+
+// AuthzConfig is the per-method authorization policy (see internal/authz).
+// Policy maps methods, or service prefixes ending in "/", to the roles that
+// may call them; Roles maps callers, as "kind:id" or "kind:*", to the roles
+// they hold. Methods not in Policy are denied unless DenyUnlisted is unset.
+// Roles assigned to users in the database, and the permissions of every
+// role, are cached per caller for CacheTTL.
+type AuthzConfig struct {
+	Policy       map[string][]string
+	Roles        map[string][]string
+	DenyUnlisted bool
+	CacheTTL     time.Duration
+}
+
+// Roles DefaultAuthzRoles grants: RoleService to workloads and API keys,
+// whose credentials the operator issues, and RoleUser to end users.
+const (
+	RoleService = "service"
+	RoleUser    = "user"
+)
+
+// DefaultAuthzPolicy leaves health checks, reflection, sign-up and the
+// email-token flows public, lets users call the RPCs acting on their own
+// account, whose handlers check the user is the caller, and lets services
+// read users and ledgers. Every other method is denied.
+var DefaultAuthzPolicy = map[string][]string{
+	"/grpc.health.v1.Health/":                     {authz.AnyRole},
+	"/grpc.reflection.v1.ServerReflection/":       {authz.AnyRole},
+	"/grpc.reflection.v1alpha.ServerReflection/":  {authz.AnyRole},
+	"/proto.v1.UserService/CreateUser":            {authz.AnyRole},
+	"/proto.v1.UserService/VerifyEmail":           {authz.AnyRole},
+	"/proto.v1.UserService/RequestPasswordReset":  {authz.AnyRole},
+	"/proto.v1.UserService/ConfirmPasswordReset":  {authz.AnyRole},
+	"/proto.v1.UserService/GetUserById":           {RoleService},
+	"/proto.v1.UserService/GetUsersByIds":         {RoleService},
+	"/proto.v1.LedgerService/ListLedgers":         {RoleService},
+	"/proto.v1.UserService/UpdateUser":            {RoleUser},
+	"/proto.v1.UserService/Enroll2FA":             {RoleUser},
+	"/proto.v1.UserService/Verify2FA":             {RoleUser},
+	"/proto.v1.UserService/Disable2FA":            {RoleUser},
+	"/proto.v1.UserService/ListSessions":          {RoleUser},
+	"/proto.v1.UserService/RevokeSession":         {RoleUser},
+	"/proto.v1.UserService/ChangePassword":        {RoleUser},
+	"/proto.v1.UserService/SendVerificationEmail": {RoleUser},
+	"/proto.v1.UserService/ExportUserData":        {RoleUser},
+}
+
+// DefaultAuthzRoles grants RoleService to every workload and API key, and
+// RoleUser to every user.
+var DefaultAuthzRoles = map[string][]string{
+	"service:*": {RoleService},
+	"api_key:*": {RoleService},
+	"user:*":    {RoleUser},
+}
+
+// AdminServicePrefix is the method prefix of the admin RPCs.
+const AdminServicePrefix = "/proto.v1.AdminService/"
+
+// AdminUserMethods are the public UserService methods that act on any user
+// rather than the caller, so they require ADMIN_ROLE like AdminService.
+var AdminUserMethods = []string{"/proto.v1.UserService/UpdateUserStatus"}
+
+// AdminConfig serves AdminService on its own listener at Address, so the
+// admin surface can be firewalled separately. AdminService is never served on
+// the public listener, so it is not served at all while Address is empty. The
+// admin listener only accepts clients presenting a certificate issued by
+// TLS_CLIENT_CA_FILE, and every admin call requires Role.
+type AdminConfig struct {
+	Address string
+	Role    string
+}
+
+// OIDCConfig enables authentication with tokens from an external OpenID
+// Connect provider when Issuer is set. Tokens must name Audience, usually the
+// client id registered for this service. The provider's keys are read from
+// JWKSURL, or discovered from the issuer when it is empty, and re-fetched
+// every JWKSRefreshInterval.
+type OIDCConfig struct {
+	Issuer              string
+	Audience            string
+	JWKSURL             string
+	JWKSRefreshInterval time.Duration
+}
+
+// TLSConfig names the server's certificate and private key, PEM encoded.
+// The files are re-read when they change, so a rotated certificate is served
+// without a restart.
+//
+// ClientCAFile, when set, enables mutual TLS: client certificates must chain
+// to one of the PEM encoded CAs it holds. Clients without a certificate are
+// still accepted, to authenticate some other way, unless RequireClientCert is
+// set.
+type TLSConfig struct {
+	CertFile          string
+	KeyFile           string
+	ClientCAFile      string
+	RequireClientCert bool
+}
+
+// Load reads the configuration. When CONFIG_FILE names a YAML file, its keys
+// are the environment variable names below; environment variables take
+// precedence over the file, so one setting can be overridden per deployment.
+func Load(serviceName string) (Config, error) {
+	s, err := newSource(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return Config{}, err
+	}
+	cfg, err := s.load(serviceName)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := s.checkUnused(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (s *source) load(serviceName string) (Config, error) {
+	cfg := Config{
+		ServiceName:        serviceName,
+		Hostname:           s.get("HOSTNAME"),
+		File:               s.file,
+		GRPCAddress:        s.getOr("GRPC_ADDRESS", ":50051"),
+		MetricsAddress:     s.getOr("METRICS_ADDRESS", ":9090"),
+		RateLimitPerMinute: defaultRateLimit,
+		PublicIdKey:        s.get("PUBLIC_ID_KEY"),
+	}
+
+	retry, breaker, err := s.loadResilience()
+	if err != nil {
+		return Config{}, err
+	}
+	database := func(name, prefix string) DatabaseConfig {
+		return DatabaseConfig{
+			Name:    name,
+			DSN:     s.get(prefix + "DATABASE_DSN"),
+			Schema:  s.getOr(prefix+"DATABASE_SCHEMA", model.DefaultSchema),
+			Retry:   retry,
+			Breaker: breaker,
+		}
+	}
+	cfg.Main = database("postgresql", "")
+	cfg.Idempotency = database("postgresql_idempotency", "IDEMPOTENCY_")
+	cfg.Ledger = database("postgresql_ledger", "LEDGER_")
+
+	if cfg.TLS, err = s.loadTLS(); err != nil {
+		return Config{}, err
+	}
+	if cfg.OTLPEndpoint, err = s.loadOTLPEndpoint(); err != nil {
+		return Config{}, err
+	}
+	if cfg.TraceExport, err = s.loadTraceExport(); err != nil {
+		return Config{}, err
+	}
+	if cfg.Propagators, err = s.loadPropagators(); err != nil {
+		return Config{}, err
+	}
+
+	if value := s.get("RATE_LIMIT_PER_MINUTE"); value != "" {
+		rateLimit, err := strconv.Atoi(value)
+		if err != nil || rateLimit <= 0 {
+			return Config{}, fmt.Errorf("RATE_LIMIT_PER_MINUTE must be a positive integer, got %q", value)
+		}
+		cfg.RateLimitPerMinute = rateLimit
+	}
+	cfg.RateLimitBurst = cfg.RateLimitPerMinute
+	if value := s.get("RATE_LIMIT_BURST"); value != "" {
+		burst, err := strconv.Atoi(value)
+		if err != nil || burst <= 0 {
+			return Config{}, fmt.Errorf("RATE_LIMIT_BURST must be a positive integer, got %q", value)
+		}
+		cfg.RateLimitBurst = burst
+	}
+
+	mode, err := idcodec.ParseMode(s.getOr("PUBLIC_ID_MODE", string(idcodec.ModeInt64)))
+	if err != nil {
+		return Config{}, fmt.Errorf("PUBLIC_ID_MODE: %w", err)
+	}
+	cfg.PublicIdMode = mode
+
+	secrets, err := parseSigningSecrets(s.get("SIGNING_SECRETS"))
+	if err != nil {
+		return Config{}, fmt.Errorf("SIGNING_SECRETS: %w", err)
+	}
+	cfg.SigningSecrets = secrets
+	cfg.SignedMethods = splitList(s.get("SIGNED_METHODS"))
+
+	cfg.BucketPresets = observability.DefaultBucketPresets()
+	for _, preset := range observability.BucketPresets {
+		key := "METRIC_BUCKETS_" + strings.ToUpper(string(preset))
+		value := s.get(key)
+		if value == "" {
+			continue
+		}
+		buckets, err := parseBuckets(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", key, err)
+		}
+		cfg.BucketPresets[preset] = buckets
+	}
+
+	tenants := splitList(s.get("METRIC_TENANT_ALLOWLIST"))
+	if len(tenants) > observability.MaxTenantLabels {
+		return Config{}, fmt.Errorf("METRIC_TENANT_ALLOWLIST lists %d tenants, at most %d are allowed", len(tenants), observability.MaxTenantLabels)
+	}
+	if slices.Contains(tenants, observability.OtherTenant) {
+		return Config{}, fmt.Errorf("METRIC_TENANT_ALLOWLIST must not contain the reserved tenant %q", observability.OtherTenant)
+	}
+	cfg.MetricTenants = observability.NewTenantLabels(tenants)
+
+	cfg.MetricNamespace, cfg.MetricSubsystem = s.get("METRIC_NAMESPACE"), s.get("METRIC_SUBSYSTEM")
+	for _, key := range []string{"METRIC_NAMESPACE", "METRIC_SUBSYSTEM"} {
+		if value := s.get(key); value != "" && !metricName.MatchString(value) {
+			return Config{}, fmt.Errorf("%s must be letters, digits and underscores, not starting with a digit, got %q", key, value)
+		}
+	}
+	if cfg.Locality, err = s.loadLocality(); err != nil {
+		return Config{}, err
+	}
+	if cfg.SnowflakeNodes, err = s.loadNodeRange(); err != nil {
+		return Config{}, err
+	}
+
+	cfg.MetricLabels = []observability.Label{{Key: "service", Value: serviceName}}
+	if version := s.get("SERVICE_VERSION"); version != "" {
+		cfg.MetricLabels = append(cfg.MetricLabels, observability.Label{Key: "version", Value: version})
+	}
+	if env := s.get("ENVIRONMENT"); env != "" {
+		cfg.MetricLabels = append(cfg.MetricLabels, observability.Label{Key: "env", Value: env})
+	}
+	cfg.MetricLabels = append(cfg.MetricLabels, cfg.localityLabels()...)
+
+	if cfg.Log, err = s.loadLogConfig(); err != nil {
+		return Config{}, err
+	}
+	if cfg.AccessLog, err = s.loadAccessLog(); err != nil {
+		return Config{}, err
+	}
+	if cfg.Audit, err = s.loadAudit(); err != nil {
+		return Config{}, err
+	}
+
+	if cfg.ProbeInterval, err = s.duration("PROBE_INTERVAL", 0); err != nil {
+		return Config{}, err
+	}
+	cfg.ProbeSigningKey = s.get("PROBE_SIGNING_KEY")
+	if _, ok := cfg.SigningSecrets[cfg.ProbeSigningKey]; cfg.ProbeSigningKey != "" && !ok {
+		return Config{}, fmt.Errorf("PROBE_SIGNING_KEY %q is not in SIGNING_SECRETS", cfg.ProbeSigningKey)
+	}
+
+	if cfg.IdempotencyScanInterval, err = s.duration("IDEMPOTENCY_SCAN_INTERVAL", 0); err != nil {
+		return Config{}, err
+	}
+	value := s.getOr("IDEMPOTENCY_REPAIR_REDERIVE", "false")
+	if cfg.IdempotencyRederive, err = strconv.ParseBool(value); err != nil {
+		return Config{}, fmt.Errorf("IDEMPOTENCY_REPAIR_REDERIVE must be true or false, got %q", value)
+	}
+
+	if cfg.Keepalive, cfg.KeepalivePolicy, err = s.loadKeepalive(); err != nil {
+		return Config{}, err
+	}
+	if cfg.DefaultDeadline, cfg.MethodDeadlines, err = s.loadDeadlines(); err != nil {
+		return Config{}, err
+	}
+	if cfg.Concurrency, err = s.loadConcurrency(); err != nil {
+		return Config{}, err
+	}
+	if cfg.Metadata, err = s.loadMetadata(); err != nil {
+		return Config{}, err
+	}
+	if cfg.StreamSendTimeout, err = s.duration("GRPC_STREAM_SEND_TIMEOUT", 30*time.Second); err != nil {
+		return Config{}, err
+	}
+	if cfg.HTTP, err = s.loadHTTP(); err != nil {
+		return Config{}, err
+	}
+
+	if cfg.ShutdownGracePeriod, err = s.positiveDuration("SHUTDOWN_GRACE_PERIOD", 30*time.Second); err != nil {
+		return Config{}, err
+	}
+	if cfg.ShutdownTimeout, err = s.positiveDuration("SHUTDOWN_TIMEOUT", 5*time.Second); err != nil {
+		return Config{}, err
+	}
+
+	if cfg.Password, err = s.loadPassword(); err != nil {
+		return Config{}, err
+	}
+	if cfg.Login, err = s.loadLogin(); err != nil {
+		return Config{}, err
+	}
+	if cfg.FieldEncryptionKeys, cfg.FieldEncryptionPrimaryKey, err = s.loadFieldEncryption(); err != nil {
+		return Config{}, err
+	}
+	cfg.TwoFactor = TwoFactorConfig{Issuer: s.getOr("TWO_FACTOR_ISSUER", serviceName)}
+	if cfg.Email, err = s.loadEmail(); err != nil {
+		return Config{}, err
+	}
+	if cfg.Authz, err = s.loadAuthz(); err != nil {
+		return Config{}, err
+	}
+	if cfg.LedgerPageSize, err = s.loadLedgerPageSize(); err != nil {
+		return Config{}, err
+	}
+	if cfg.OIDC, err = s.loadOIDC(); err != nil {
+		return Config{}, err
+	}
+	cfg.Admin = AdminConfig{Address: s.get("ADMIN_GRPC_ADDRESS"), Role: s.getOr("ADMIN_ROLE", "admin")}
+	if cfg.Admin.Address != "" && cfg.TLS.ClientCAFile == "" {
+		return Config{}, fmt.Errorf("ADMIN_GRPC_ADDRESS requires TLS_CLIENT_CA_FILE, admin callers must present a client certificate")
+	}
+	for pattern := range cfg.Authz.Policy {
+		if strings.HasPrefix(pattern, AdminServicePrefix) || slices.Contains(AdminUserMethods, pattern) {
+			return Config{}, fmt.Errorf("AUTHZ_POLICY must not cover %s, ADMIN_ROLE applies to every admin method", pattern)
+		}
+	}
+	return cfg, nil
+}
+
+// loadOIDC reads OIDC_ISSUER, OIDC_AUDIENCE, OIDC_JWKS_URL and
+// OIDC_JWKS_REFRESH_INTERVAL. The other settings require OIDC_ISSUER.
+func (s *source) loadOIDC() (OIDCConfig, error) {
+	cfg := OIDCConfig{
+		Issuer:   s.get("OIDC_ISSUER"),
+		Audience: s.get("OIDC_AUDIENCE"),
+		JWKSURL:  s.get("OIDC_JWKS_URL"),
+	}
+	var err error
+	if cfg.JWKSRefreshInterval, err = s.positiveDuration("OIDC_JWKS_REFRESH_INTERVAL", time.Hour); err != nil {
+		return OIDCConfig{}, err
+	}
+	if cfg.Issuer == "" {
+		if cfg.Audience != "" || cfg.JWKSURL != "" {
+			return OIDCConfig{}, fmt.Errorf("OIDC_AUDIENCE and OIDC_JWKS_URL require OIDC_ISSUER")
+		}
+		return cfg, nil
+	}
+	if cfg.Audience == "" {
+		return OIDCConfig{}, fmt.Errorf("OIDC_ISSUER requires OIDC_AUDIENCE")
+	}
+	for _, setting := range []struct{ key, value string }{
+		{"OIDC_ISSUER", cfg.Issuer},
+		{"OIDC_JWKS_URL", cfg.JWKSURL},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		if u, err := url.Parse(setting.value); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return OIDCConfig{}, fmt.Errorf("%s must be an http or https URL, got %q", setting.key, setting.value)
+		}
+	}
+	return cfg, nil
+}
+
+// loadEmail reads EMAIL_SENDER, default log, EMAIL_FROM, the SMTP_* relay
+// settings the smtp sender requires, EMAIL_VERIFICATION_URL,
+// EMAIL_VERIFICATION_TTL, default 24h, EMAIL_PASSWORD_RESET_URL and
+// EMAIL_PASSWORD_RESET_TTL, default 1h.
+func (s *source) loadEmail() (EmailConfig, error) {
+	cfg := EmailConfig{
+		Sender:           s.getOr("EMAIL_SENDER", EmailSenderLog),
+		From:             s.get("EMAIL_FROM"),
+		SMTPAddress:      s.get("SMTP_ADDRESS"),
+		SMTPUsername:     s.get("SMTP_USERNAME"),
+		SMTPPassword:     s.get("SMTP_PASSWORD"),
+		VerificationURL:  s.get("EMAIL_VERIFICATION_URL"),
+		PasswordResetURL: s.get("EMAIL_PASSWORD_RESET_URL"),
+	}
+	var err error
+	if cfg.VerificationTTL, err = s.positiveDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour); err != nil {
+		return EmailConfig{}, err
+	}
+	if cfg.PasswordResetTTL, err = s.positiveDuration("EMAIL_PASSWORD_RESET_TTL", time.Hour); err != nil {
+		return EmailConfig{}, err
+	}
+	switch cfg.Sender {
+	case EmailSenderLog:
+	case EmailSenderSMTP:
+		if cfg.SMTPAddress == "" || cfg.From == "" {
+			return EmailConfig{}, fmt.Errorf("EMAIL_SENDER smtp requires SMTP_ADDRESS and EMAIL_FROM")
+		}
+		if _, _, err := net.SplitHostPort(cfg.SMTPAddress); err != nil {
+			return EmailConfig{}, fmt.Errorf("SMTP_ADDRESS must be host:port, got %q", cfg.SMTPAddress)
+		}
+	default:
+		return EmailConfig{}, fmt.Errorf("EMAIL_SENDER must be %s or %s, got %q", EmailSenderLog, EmailSenderSMTP, cfg.Sender)
+	}
+	if (cfg.SMTPUsername == "") != (cfg.SMTPPassword == "") {
+		return EmailConfig{}, fmt.Errorf("SMTP_USERNAME and SMTP_PASSWORD must be set together")
+	}
+	for key, link := range map[string]string{"EMAIL_VERIFICATION_URL": cfg.VerificationURL, "EMAIL_PASSWORD_RESET_URL": cfg.PasswordResetURL} {
+		if link == "" {
+			continue
+		}
+		if u, err := url.Parse(link); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return EmailConfig{}, fmt.Errorf("%s must be an http or https URL, got %q", key, link)
+		}
+	}
+	return cfg, nil
+}
+
+// loadAuthz reads AUTHZ_POLICY, method=role|role entries replacing
+// DefaultAuthzPolicy, AUTHZ_ROLES, caller=role|role entries replacing
+// DefaultAuthzRoles, AUTHZ_DENY_UNLISTED, default true, and AUTHZ_CACHE_TTL,
+// default 30s.
+func (s *source) loadAuthz() (AuthzConfig, error) {
+	cfg := AuthzConfig{Policy: maps.Clone(DefaultAuthzPolicy), Roles: maps.Clone(DefaultAuthzRoles)}
+	var err error
+	if value := s.get("AUTHZ_POLICY"); value != "" {
+		if cfg.Policy, err = parseRoleMap(value); err != nil {
+			return AuthzConfig{}, fmt.Errorf("AUTHZ_POLICY: %w", err)
+		}
+	}
+	for pattern := range cfg.Policy {
+		if !strings.HasPrefix(pattern, "/") {
+			return AuthzConfig{}, fmt.Errorf("AUTHZ_POLICY: method %q must start with /", pattern)
+		}
+	}
+	if value := s.get("AUTHZ_ROLES"); value != "" {
+		if cfg.Roles, err = parseRoleMap(value); err != nil {
+			return AuthzConfig{}, fmt.Errorf("AUTHZ_ROLES: %w", err)
+		}
+	}
+	value := s.getOr("AUTHZ_DENY_UNLISTED", "true")
+	if cfg.DenyUnlisted, err = strconv.ParseBool(value); err != nil {
+		return AuthzConfig{}, fmt.Errorf("AUTHZ_DENY_UNLISTED must be true or false, got %q", value)
+	}
+	if cfg.CacheTTL, err = s.duration("AUTHZ_CACHE_TTL", 30*time.Second); err != nil {
+		return AuthzConfig{}, err
+	}
+	return cfg, nil
+}
+
+// loadLedgerPageSize reads LEDGER_MAX_PAGE_SIZE, default 1000, and
+// LEDGER_MAX_PAGE_SIZE_BY_ROLE, role=size pairs.
+func (s *source) loadLedgerPageSize() (LedgerPageSizeConfig, error) {
+	limit, err := s.uint("LEDGER_MAX_PAGE_SIZE", 1000, 1)
+	if err != nil {
+		return LedgerPageSizeConfig{}, err
+	}
+	cfg := LedgerPageSizeConfig{Max: int(limit), MaxByRole: map[string]int{}}
+	for _, pair := range splitList(s.get("LEDGER_MAX_PAGE_SIZE_BY_ROLE")) {
+		role, value, ok := strings.Cut(pair, "=")
+		size, err := strconv.Atoi(value)
+		if !ok || role == "" || err != nil || size <= 0 {
+			return LedgerPageSizeConfig{}, fmt.Errorf("LEDGER_MAX_PAGE_SIZE_BY_ROLE: expected role=size pairs with positive sizes, got %q", pair)
+		}
+		if _, dup := cfg.MaxByRole[role]; dup {
+			return LedgerPageSizeConfig{}, fmt.Errorf("LEDGER_MAX_PAGE_SIZE_BY_ROLE: role %q is listed twice", role)
+		}
+		cfg.MaxByRole[role] = size
+	}
+	return cfg, nil
+}
+
+// loadFieldEncryption reads FIELD_ENCRYPTION_KEYS, keyId=key pairs with
+// base64 encoded 32-byte keys, and FIELD_ENCRYPTION_PRIMARY_KEY, which may be
+// left out when there is a single key.
+func (s *source) loadFieldEncryption() (map[string][]byte, string, error) {
+	pairs, err := parseSigningSecrets(s.get("FIELD_ENCRYPTION_KEYS"))
+	if err != nil {
+		return nil, "", fmt.Errorf("FIELD_ENCRYPTION_KEYS: %w", err)
+	}
+	keys := make(map[string][]byte, len(pairs))
+	for keyId, encoded := range pairs {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return nil, "", fmt.Errorf("FIELD_ENCRYPTION_KEYS: key %q must be 32 bytes, base64 encoded", keyId)
+		}
+		keys[keyId] = key
+	}
+
+	primary := s.get("FIELD_ENCRYPTION_PRIMARY_KEY")
+	if primary == "" && len(keys) == 1 {
+		for keyId := range keys {
+			primary = keyId
+		}
+	}
+	if _, ok := keys[primary]; len(keys) > 0 && !ok {
+		return nil, "", fmt.Errorf("FIELD_ENCRYPTION_PRIMARY_KEY %q must name one of FIELD_ENCRYPTION_KEYS", primary)
+	}
+	if len(keys) == 0 && primary != "" {
+		return nil, "", fmt.Errorf("FIELD_ENCRYPTION_PRIMARY_KEY requires FIELD_ENCRYPTION_KEYS")
+	}
+	return keys, primary, nil
+}
+
+// bcryptMaxBytes is the longest password bcrypt reads.
+const bcryptMaxBytes = 72
+
+func (s *source) loadPassword() (PasswordConfig, error) {
+	cfg := PasswordConfig{BreachAPIURL: s.getOr("PASSWORD_BREACH_API_URL", password.PwnedPasswordsURL)}
+	minLength, err := s.uint("PASSWORD_MIN_LENGTH", 12, 1)
+	if err != nil {
+		return PasswordConfig{}, err
+	}
+	maxLength, err := s.uint("PASSWORD_MAX_LENGTH", 128, minLength)
+	if err != nil {
+		return PasswordConfig{}, err
+	}
+	cfg.MinLength, cfg.MaxLength = int(minLength), int(maxLength)
+
+	value := s.getOr("PASSWORD_MIN_ENTROPY_BITS", "50")
+	if cfg.MinEntropyBits, err = strconv.ParseFloat(value, 64); err != nil || cfg.MinEntropyBits < 0 {
+		return PasswordConfig{}, fmt.Errorf("PASSWORD_MIN_ENTROPY_BITS must be a non-negative number, got %q", value)
+	}
+
+	value = s.getOr("PASSWORD_BREACH_CHECK", "true")
+	if cfg.BreachCheck, err = strconv.ParseBool(value); err != nil {
+		return PasswordConfig{}, fmt.Errorf("PASSWORD_BREACH_CHECK must be true or false, got %q", value)
+	}
+
+	if cfg.HashAlgorithm, err = password.ParseAlgorithm(s.getOr("PASSWORD_HASH_ALGORITHM", string(password.AlgorithmArgon2id))); err != nil {
+		return PasswordConfig{}, fmt.Errorf("PASSWORD_HASH_ALGORITHM: %w", err)
+	}
+	// bcrypt ignores bytes beyond the 72nd; a policy allowing longer
+	// passwords would let two passwords sharing a prefix match each other.
+	if cfg.HashAlgorithm == password.AlgorithmBcrypt && cfg.MaxLength > bcryptMaxBytes {
+		return PasswordConfig{}, fmt.Errorf("PASSWORD_MAX_LENGTH must be at most %d with PASSWORD_HASH_ALGORITHM=bcrypt, got %d", bcryptMaxBytes, cfg.MaxLength)
+	}
+	return cfg, nil
+}
+
+func (s *source) loadLogin() (LoginConfig, error) {
+	maxFailures, err := s.uint("LOGIN_MAX_FAILURES", 5, 1)
+	if err != nil {
+		return LoginConfig{}, err
+	}
+	cfg := LoginConfig{MaxFailures: int(maxFailures)}
+	if cfg.LockoutBase, err = s.positiveDuration("LOGIN_LOCKOUT_BASE", time.Minute); err != nil {
+		return LoginConfig{}, err
+	}
+	if cfg.LockoutMax, err = s.positiveDuration("LOGIN_LOCKOUT_MAX", time.Hour); err != nil {
+		return LoginConfig{}, err
+	}
+	if cfg.LockoutMax < cfg.LockoutBase {
+		return LoginConfig{}, fmt.Errorf("LOGIN_LOCKOUT_MAX %s must be at least LOGIN_LOCKOUT_BASE %s", cfg.LockoutMax, cfg.LockoutBase)
+	}
+	if cfg.FailureReset, err = s.positiveDuration("LOGIN_FAILURE_RESET", 24*time.Hour); err != nil {
+		return LoginConfig{}, err
+	}
+	accountMaxFailures, err := s.uint("LOGIN_ACCOUNT_MAX_FAILURES", 20, 0)
+	if err != nil {
+		return LoginConfig{}, err
+	}
+	cfg.AccountMaxFailures = int(accountMaxFailures)
+	if cfg.AccountLockout, err = s.positiveDuration("LOGIN_ACCOUNT_LOCKOUT", 15*time.Minute); err != nil {
+		return LoginConfig{}, err
+	}
+	return cfg, nil
+}
+
+// loadResilience reads the retry and circuit breaker settings shared by
+// every store. The defaults match gobreaker's.
+func (s *source) loadResilience() (RetryConfig, BreakerConfig, error) {
+	maxRetries, err := s.uint("DATABASE_MAX_RETRIES", 3, 0)
+	if err != nil {
+		return RetryConfig{}, BreakerConfig{}, err
+	}
+	interval, err := s.positiveDuration("DATABASE_RETRY_INTERVAL", 100*time.Millisecond)
+	if err != nil {
+		return RetryConfig{}, BreakerConfig{}, err
+	}
+	failures, err := s.uint("DATABASE_BREAKER_FAILURES", 6, 1)
+	if err != nil {
+		return RetryConfig{}, BreakerConfig{}, err
+	}
+	timeout, err := s.positiveDuration("DATABASE_BREAKER_TIMEOUT", time.Minute)
+	if err != nil {
+		return RetryConfig{}, BreakerConfig{}, err
+	}
+	maxRequests, err := s.uint("DATABASE_BREAKER_HALF_OPEN_REQUESTS", 1, 1)
+	if err != nil {
+		return RetryConfig{}, BreakerConfig{}, err
+	}
+	return RetryConfig{MaxRetries: maxRetries, Interval: interval},
+		BreakerConfig{ConsecutiveFailures: uint32(failures), Timeout: timeout, MaxRequests: uint32(maxRequests)},
+		nil
+}
+
+// loadTLS reads TLS_CERT_FILE and TLS_KEY_FILE, or TLS_CERT_DIR holding
+// tls.crt and tls.key as a Kubernetes TLS secret mounts them, and the client
+// certificate settings.
+func (s *source) loadTLS() (TLSConfig, error) {
+	cfg := TLSConfig{CertFile: s.get("TLS_CERT_FILE"), KeyFile: s.get("TLS_KEY_FILE")}
+	if dir := s.get("TLS_CERT_DIR"); dir != "" {
+		if cfg.CertFile != "" || cfg.KeyFile != "" {
+			return TLSConfig{}, fmt.Errorf("TLS_CERT_DIR cannot be combined with TLS_CERT_FILE or TLS_KEY_FILE")
+		}
+		cfg = TLSConfig{CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key")}
+	}
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return TLSConfig{}, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	cfg.ClientCAFile = s.get("TLS_CLIENT_CA_FILE")
+	if cfg.ClientCAFile != "" && cfg.CertFile == "" {
+		return TLSConfig{}, fmt.Errorf("TLS_CLIENT_CA_FILE requires a server certificate")
+	}
+	value := s.getOr("TLS_REQUIRE_CLIENT_CERT", "false")
+	var err error
+	if cfg.RequireClientCert, err = strconv.ParseBool(value); err != nil {
+		return TLSConfig{}, fmt.Errorf("TLS_REQUIRE_CLIENT_CERT must be true or false, got %q", value)
+	}
+	if cfg.RequireClientCert && cfg.ClientCAFile == "" {
+		return TLSConfig{}, fmt.Errorf("TLS_REQUIRE_CLIENT_CERT requires TLS_CLIENT_CA_FILE")
+	}
+	return cfg, nil
+}
+
+// loadKeepalive reads the server's keepalive settings. Load balancers drop
+// idle connections after their own timeout, so GRPC_MAX_CONNECTION_IDLE
+// should be shorter than it for the server to close them cleanly first.
+func (s *source) loadKeepalive() (keepalive.ServerParameters, keepalive.EnforcementPolicy, error) {
+	var params keepalive.ServerParameters
+	var policy keepalive.EnforcementPolicy
+	for _, setting := range []struct {
+		key   string
+		field *time.Duration
+	}{
+		{"GRPC_MAX_CONNECTION_IDLE", &params.MaxConnectionIdle},
+		{"GRPC_MAX_CONNECTION_AGE", &params.MaxConnectionAge},
+		{"GRPC_MAX_CONNECTION_AGE_GRACE", &params.MaxConnectionAgeGrace},
+		{"GRPC_KEEPALIVE_TIME", &params.Time},
+		{"GRPC_KEEPALIVE_TIMEOUT", &params.Timeout},
+		{"GRPC_KEEPALIVE_MIN_TIME", &policy.MinTime},
+	} {
+		value, err := s.duration(setting.key, 0)
+		if err != nil {
+			return keepalive.ServerParameters{}, keepalive.EnforcementPolicy{}, err
+		}
+		*setting.field = value
+	}
+	policy.PermitWithoutStream = s.get("GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM") == "true"
+	return params, policy, nil
+}
+
+// formatKeepalive reports an unset keepalive setting as gRPC's default.
+func formatKeepalive(d time.Duration) string {
+	if d == 0 {
+		return "default"
+	}
+	return d.String()
+}
+
+// Entries lists the effective configuration with secrets masked, followed by
+// the build the process is running. It is safe to log and to return to
+// operators.
+func (c Config) Entries() []model.ConfigEntry {
+	entries := []model.ConfigEntry{
+		{Key: "service.name", Value: c.ServiceName},
+		{Key: "hostname", Value: c.Hostname},
+		{Key: "config.file", Value: c.File},
+	}
+	entries = append(entries,
+		model.ConfigEntry{Key: "locality.region", Value: c.Locality.Region},
+		model.ConfigEntry{Key: "locality.zone", Value: c.Locality.Zone},
+		model.ConfigEntry{Key: "snowflake.node_range", Value: c.SnowflakeNodes.String()},
+	)
+	if nodeID, err := c.SnowflakeNodes.NodeID(c.Hostname); err == nil {
+		entries = append(entries, model.ConfigEntry{Key: "snowflake.node_id", Value: strconv.FormatInt(nodeID, 10)})
+	}
+	entries = append(entries,
+		model.ConfigEntry{Key: "server.grpc_address", Value: c.GRPCAddress},
+		model.ConfigEntry{Key: "server.metrics_address", Value: c.MetricsAddress},
+		model.ConfigEntry{Key: "server.tls_cert_file", Value: c.TLS.CertFile},
+		model.ConfigEntry{Key: "server.tls_client_ca_file", Value: c.TLS.ClientCAFile},
+		model.ConfigEntry{Key: "server.tls_require_client_cert", Value: strconv.FormatBool(c.TLS.RequireClientCert)},
+		model.ConfigEntry{Key: "server.shutdown_grace_period", Value: c.ShutdownGracePeriod.String()},
+		model.ConfigEntry{Key: "server.shutdown_timeout", Value: c.ShutdownTimeout.String()},
+		model.ConfigEntry{Key: "otlp.endpoint", Value: c.OTLPEndpoint},
+		model.ConfigEntry{Key: "otlp.queue_size", Value: strconv.Itoa(c.TraceExport.MaxQueueSize)},
+		model.ConfigEntry{Key: "otlp.batch_size", Value: strconv.Itoa(c.TraceExport.MaxBatchSize)},
+		model.ConfigEntry{Key: "otlp.export_interval", Value: c.TraceExport.BatchTimeout.String()},
+		model.ConfigEntry{Key: "otlp.export_timeout", Value: c.TraceExport.ExportTimeout.String()},
+		model.ConfigEntry{Key: "tracing.propagators", Value: formatPropagators(c.Propagators)},
+	)
+	for _, db := range []DatabaseConfig{c.Main, c.Idempotency, c.Ledger} {
+		dsn, redacted := RedactDSN(db.DSN)
+		entries = append(entries,
+			model.ConfigEntry{Key: db.Name + ".dsn", Value: dsn, Redacted: redacted},
+			model.ConfigEntry{Key: db.Name + ".schema", Value: db.Schema},
+		)
+	}
+	entries = append(entries,
+		model.ConfigEntry{Key: "database.retry.max_retries", Value: strconv.FormatUint(c.Main.Retry.MaxRetries, 10)},
+		model.ConfigEntry{Key: "database.retry.interval", Value: c.Main.Retry.Interval.String()},
+		model.ConfigEntry{Key: "database.breaker.consecutive_failures", Value: strconv.FormatUint(uint64(c.Main.Breaker.ConsecutiveFailures), 10)},
+		model.ConfigEntry{Key: "database.breaker.timeout", Value: c.Main.Breaker.Timeout.String()},
+		model.ConfigEntry{Key: "database.breaker.half_open_requests", Value: strconv.FormatUint(uint64(c.Main.Breaker.MaxRequests), 10)},
+		model.ConfigEntry{Key: "rate_limit.per_minute", Value: strconv.Itoa(c.RateLimitPerMinute)},
+		model.ConfigEntry{Key: "rate_limit.burst", Value: strconv.Itoa(c.RateLimitBurst)},
+		model.ConfigEntry{Key: "ledger.max_page_size", Value: strconv.Itoa(c.LedgerPageSize.Max)},
+		model.ConfigEntry{Key: "ledger.max_page_size_by_role", Value: formatIntMap(c.LedgerPageSize.MaxByRole)},
+		model.ConfigEntry{Key: "public_id.mode", Value: string(c.PublicIdMode)},
+	)
+	if c.PublicIdKey != "" {
+		entries = append(entries, model.ConfigEntry{Key: "public_id.key", Value: redactedSecret, Redacted: true})
+	}
+	keyIds := make([]string, 0, len(c.SigningSecrets))
+	for keyId := range c.SigningSecrets {
+		keyIds = append(keyIds, keyId)
+	}
+	slices.Sort(keyIds)
+	entries = append(entries,
+		model.ConfigEntry{Key: "signing.key_ids", Value: strings.Join(keyIds, ",")},
+		model.ConfigEntry{Key: "signing.required_methods", Value: strings.Join(c.SignedMethods, ",")},
+	)
+	fieldKeyIds := make([]string, 0, len(c.FieldEncryptionKeys))
+	for keyId := range c.FieldEncryptionKeys {
+		fieldKeyIds = append(fieldKeyIds, keyId)
+	}
+	slices.Sort(fieldKeyIds)
+	entries = append(entries,
+		model.ConfigEntry{Key: "field_encryption.key_ids", Value: strings.Join(fieldKeyIds, ",")},
+		model.ConfigEntry{Key: "field_encryption.primary_key", Value: c.FieldEncryptionPrimaryKey},
+	)
+	for _, preset := range observability.BucketPresets {
+		if buckets, ok := c.BucketPresets[preset]; ok {
+			entries = append(entries, model.ConfigEntry{Key: "metrics.buckets." + string(preset), Value: formatBuckets(buckets)})
+		}
+	}
+	entries = append(entries, model.ConfigEntry{Key: "metrics.tenant_allowlist", Value: strings.Join(c.MetricTenants.Allowed(), ",")})
+	labels := make([]string, len(c.MetricLabels))
+	for i, l := range c.MetricLabels {
+		labels[i] = l.Key + "=" + l.Value
+	}
+	entries = append(entries,
+		model.ConfigEntry{Key: "metrics.namespace", Value: c.MetricNamespace},
+		model.ConfigEntry{Key: "metrics.subsystem", Value: c.MetricSubsystem},
+		model.ConfigEntry{Key: "metrics.const_labels", Value: strings.Join(labels, ",")},
+	)
+
+	entries = append(entries, model.ConfigEntry{Key: "log.level", Value: string(c.Log.Level)})
+	modules := make([]string, 0, len(c.Log.Modules))
+	for module, level := range c.Log.Modules {
+		modules = append(modules, module+"="+string(level))
+	}
+	slices.Sort(modules)
+	entries = append(entries, model.ConfigEntry{Key: "log.modules", Value: strings.Join(modules, ",")})
+	sinks := make([]string, len(c.Log.Sinks))
+	for i, sink := range c.Log.Sinks {
+		sinks[i] = sink.Path
+		if sink.Level != "" {
+			sinks[i] += "=" + string(sink.Level)
+		}
+	}
+	entries = append(entries, model.ConfigEntry{Key: "log.sinks", Value: strings.Join(sinks, ",")})
+	entries = append(entries,
+		model.ConfigEntry{Key: "access_log.excluded_methods", Value: strings.Join(c.AccessLog.ExcludedMethods, ",")},
+		model.ConfigEntry{Key: "access_log.payloads", Value: strconv.FormatBool(c.AccessLog.Payloads)},
+		model.ConfigEntry{Key: "audit.sink", Value: c.Audit.Sink},
+		model.ConfigEntry{Key: "audit.methods", Value: formatAuditMethods(c.Audit.Methods)},
+	)
+	entries = append(entries,
+		model.ConfigEntry{Key: "probe.interval", Value: c.ProbeInterval.String()},
+		model.ConfigEntry{Key: "probe.signing_key", Value: c.ProbeSigningKey},
+		model.ConfigEntry{Key: "idempotency.scan_interval", Value: c.IdempotencyScanInterval.String()},
+		model.ConfigEntry{Key: "idempotency.repair_rederive", Value: strconv.FormatBool(c.IdempotencyRederive)},
+		model.ConfigEntry{Key: "grpc.max_connection_idle", Value: formatKeepalive(c.Keepalive.MaxConnectionIdle)},
+		model.ConfigEntry{Key: "grpc.max_connection_age", Value: formatKeepalive(c.Keepalive.MaxConnectionAge)},
+		model.ConfigEntry{Key: "grpc.max_connection_age_grace", Value: formatKeepalive(c.Keepalive.MaxConnectionAgeGrace)},
+		model.ConfigEntry{Key: "grpc.keepalive_time", Value: formatKeepalive(c.Keepalive.Time)},
+		model.ConfigEntry{Key: "grpc.keepalive_timeout", Value: formatKeepalive(c.Keepalive.Timeout)},
+		model.ConfigEntry{Key: "grpc.keepalive_min_time", Value: formatKeepalive(c.KeepalivePolicy.MinTime)},
+		model.ConfigEntry{Key: "grpc.keepalive_permit_without_stream", Value: strconv.FormatBool(c.KeepalivePolicy.PermitWithoutStream)},
+		model.ConfigEntry{Key: "grpc.default_deadline", Value: c.DefaultDeadline.String()},
+		model.ConfigEntry{Key: "grpc.method_deadlines", Value: formatDeadlines(c.MethodDeadlines)},
+		model.ConfigEntry{Key: "grpc.max_in_flight", Value: strconv.Itoa(c.Concurrency.Max)},
+		model.ConfigEntry{Key: "grpc.method_max_in_flight", Value: formatIntMap(c.Concurrency.Methods)},
+		model.ConfigEntry{Key: "grpc.metadata_max_bytes", Value: strconv.Itoa(c.Metadata.MaxBytes)},
+		model.ConfigEntry{Key: "grpc.metadata_allowed_keys", Value: strings.Join(c.Metadata.AllowedKeys, ",")},
+		model.ConfigEntry{Key: "grpc.stream_send_timeout", Value: c.StreamSendTimeout.String()},
+		model.ConfigEntry{Key: "http.cors_allowed_origins", Value: strings.Join(c.HTTP.CORS.AllowedOrigins, ",")},
+		model.ConfigEntry{Key: "http.cors_allowed_methods", Value: strings.Join(c.HTTP.CORS.AllowedMethods, ",")},
+		model.ConfigEntry{Key: "http.cors_allowed_headers", Value: strings.Join(c.HTTP.CORS.AllowedHeaders, ",")},
+		model.ConfigEntry{Key: "http.cors_exposed_headers", Value: strings.Join(c.HTTP.CORS.ExposedHeaders, ",")},
+		model.ConfigEntry{Key: "http.cors_allow_credentials", Value: strconv.FormatBool(c.HTTP.CORS.AllowCredentials)},
+		model.ConfigEntry{Key: "http.cors_max_age", Value: c.HTTP.CORS.MaxAge.String()},
+		model.ConfigEntry{Key: "http.hsts_max_age", Value: c.HTTP.Security.HSTSMaxAge.String()},
+		model.ConfigEntry{Key: "http.hsts_include_subdomains", Value: strconv.FormatBool(c.HTTP.Security.HSTSIncludeSubdomains)},
+		model.ConfigEntry{Key: "password.min_length", Value: strconv.Itoa(c.Password.MinLength)},
+		model.ConfigEntry{Key: "password.max_length", Value: strconv.Itoa(c.Password.MaxLength)},
+		model.ConfigEntry{Key: "password.min_entropy_bits", Value: strconv.FormatFloat(c.Password.MinEntropyBits, 'g', -1, 64)},
+		model.ConfigEntry{Key: "password.breach_check", Value: strconv.FormatBool(c.Password.BreachCheck)},
+		model.ConfigEntry{Key: "password.breach_api_url", Value: c.Password.BreachAPIURL},
+		model.ConfigEntry{Key: "password.hash_algorithm", Value: string(c.Password.HashAlgorithm)},
+		model.ConfigEntry{Key: "login.max_failures", Value: strconv.Itoa(c.Login.MaxFailures)},
+		model.ConfigEntry{Key: "login.lockout_base", Value: c.Login.LockoutBase.String()},
+		model.ConfigEntry{Key: "login.lockout_max", Value: c.Login.LockoutMax.String()},
+		model.ConfigEntry{Key: "login.failure_reset", Value: c.Login.FailureReset.String()},
+		model.ConfigEntry{Key: "login.account_max_failures", Value: strconv.Itoa(c.Login.AccountMaxFailures)},
+		model.ConfigEntry{Key: "login.account_lockout", Value: c.Login.AccountLockout.String()},
+		model.ConfigEntry{Key: "two_factor.issuer", Value: c.TwoFactor.Issuer},
+		model.ConfigEntry{Key: "email.sender", Value: c.Email.Sender},
+		model.ConfigEntry{Key: "email.from", Value: c.Email.From},
+		model.ConfigEntry{Key: "email.smtp_address", Value: c.Email.SMTPAddress},
+		model.ConfigEntry{Key: "email.smtp_username", Value: c.Email.SMTPUsername},
+		model.ConfigEntry{Key: "email.verification_url", Value: c.Email.VerificationURL},
+		model.ConfigEntry{Key: "email.verification_ttl", Value: c.Email.VerificationTTL.String()},
+		model.ConfigEntry{Key: "email.password_reset_url", Value: c.Email.PasswordResetURL},
+		model.ConfigEntry{Key: "email.password_reset_ttl", Value: c.Email.PasswordResetTTL.String()},
+		model.ConfigEntry{Key: "authz.policy", Value: formatRoleMap(c.Authz.Policy)},
+		model.ConfigEntry{Key: "authz.roles", Value: formatRoleMap(c.Authz.Roles)},
+		model.ConfigEntry{Key: "authz.deny_unlisted", Value: strconv.FormatBool(c.Authz.DenyUnlisted)},
+		model.ConfigEntry{Key: "authz.cache_ttl", Value: c.Authz.CacheTTL.String()},
+		model.ConfigEntry{Key: "oidc.issuer", Value: c.OIDC.Issuer},
+		model.ConfigEntry{Key: "oidc.audience", Value: c.OIDC.Audience},
+		model.ConfigEntry{Key: "oidc.jwks_url", Value: c.OIDC.JWKSURL},
+		model.ConfigEntry{Key: "oidc.jwks_refresh_interval", Value: c.OIDC.JWKSRefreshInterval.String()},
+		model.ConfigEntry{Key: "admin.grpc_address", Value: c.Admin.Address},
+		model.ConfigEntry{Key: "admin.role", Value: c.Admin.Role},
+	)
+	if c.Email.SMTPPassword != "" {
+		entries = append(entries, model.ConfigEntry{Key: "email.smtp_password", Value: redactedSecret, Redacted: true})
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		entries = append(entries, model.ConfigEntry{Key: "build.go_version", Value: info.GoVersion})
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision", "vcs.time", "vcs.modified":
+				entries = append(entries, model.ConfigEntry{Key: "build." + setting.Key, Value: setting.Value})
+			}
+		}
+	}
+	return entries
+}
+
+// localityLabels are the region and zone labels every metric carries, for
+// those of c.Locality that are set.
+func (c Config) localityLabels() []observability.Label {
+	var labels []observability.Label
+	if c.Locality.Region != "" {
+		labels = append(labels, observability.Label{Key: "region", Value: c.Locality.Region})
+	}
+	if c.Locality.Zone != "" {
+		labels = append(labels, observability.Label{Key: "zone", Value: c.Locality.Zone})
+	}
+	return labels
+}
+
+// ResourceAttributes are the OpenTelemetry resource attributes naming where
+// the pod runs, for those of c.Locality that are set.
+func (c Config) ResourceAttributes() []observability.Label {
+	var attrs []observability.Label
+	if c.Locality.Region != "" {
+		attrs = append(attrs, observability.Label{Key: "cloud.region", Value: c.Locality.Region})
+	}
+	if c.Locality.Zone != "" {
+		attrs = append(attrs, observability.Label{Key: "cloud.availability_zone", Value: c.Locality.Zone})
+	}
+	return attrs
+}
+
+// maxNodeID is the largest snowflake node id.
+const maxNodeID = 1023
+
+// NodeRange is the range of snowflake node ids, First to Last inclusive, a
+// deployment's pods draw from. Deployments writing to one database, such as
+// the regions of a multi-region setup, are given disjoint ranges so their
+// pods can never generate the same id.
+type NodeRange struct {
+	First int64
+	Last  int64
+}
+
+// AllNodes is the whole node id range, for a single deployment.
+var AllNodes = NodeRange{First: 0, Last: maxNodeID}
+
+func (r NodeRange) String() string {
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// NodeID derives a pod's snowflake node id within r from its hostname.
+func (r NodeRange) NodeID(hostname string) (int64, error) {
+	if hostname == "" {
+		return 0, fmt.Errorf("HOSTNAME is not set")
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(hostname))
+	return r.First + int64(binary.BigEndian.Uint64(h.Sum(nil))%uint64(r.Last-r.First+1)), nil
+}
+
+// NodeID derives a pod's snowflake node id, 0 to 1023, from its hostname.
+func NodeID(hostname string) (int64, error) {
+	return AllNodes.NodeID(hostname)
+}
+
+var (
+	metricName      = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	urlDSN          = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://`)
+	keywordPassword = regexp.MustCompile(`(?i)\b((?:ssl)?password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+)
+
+// RedactDSN masks the password in a URL or keyword/value connection string
+// and reports whether anything was masked. A URL that cannot be parsed is
+// masked entirely, since its password cannot be located.
+func RedactDSN(dsn string) (string, bool) {
+	if dsn == "" {
+		return "", false
+	}
+
+	if !urlDSN.MatchString(dsn) {
+		redacted := keywordPassword.ReplaceAllString(dsn, "${1}"+redactedSecret)
+		return redacted, redacted != dsn
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return redactedSecret, true
+	}
+	_, redacted := u.User.Password()
+	query := u.Query()
+	for _, key := range []string{"password", "sslpassword"} {
+		if query.Has(key) {
+			query.Set(key, redactedSecret)
+			u.RawQuery = query.Encode()
+			redacted = true
+		}
+	}
+	return u.Redacted(), redacted
+}
+
+// parseSigningSecrets reads comma-separated keyId=secret pairs.
+func parseSigningSecrets(value string) (map[string]string, error) {
+	secrets := map[string]string{}
+	for _, pair := range splitList(value) {
+		keyId, secret, ok := strings.Cut(pair, "=")
+		if !ok || keyId == "" || secret == "" {
+			return nil, fmt.Errorf("expected keyId=secret pairs")
+		}
+		if _, dup := secrets[keyId]; dup {
+			return nil, fmt.Errorf("key id %q is listed twice", keyId)
+		}
+		secrets[keyId] = secret
+	}
+	return secrets, nil
+}
+
+// parseRoleMap reads comma-separated key=role|role entries. Keys are split
+// from their roles at the last "=", since caller ids may contain one.
+func parseRoleMap(value string) (map[string][]string, error) {
+	roleMap := map[string][]string{}
+	for _, entry := range splitList(value) {
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("expected key=role|role entries")
+		}
+		key := entry[:i]
+		if _, dup := roleMap[key]; dup {
+			return nil, fmt.Errorf("%q is listed twice", key)
+		}
+		var roles []string
+		for _, role := range strings.Split(entry[i+1:], "|") {
+			if role = strings.TrimSpace(role); role != "" {
+				roles = append(roles, role)
+			}
+		}
+		if len(roles) == 0 {
+			return nil, fmt.Errorf("%q lists no roles", key)
+		}
+		roleMap[key] = roles
+	}
+	return roleMap, nil
+}
+
+// formatRoleMap lists roleMap's entries in the form parseRoleMap reads,
+// sorted by key.
+func formatRoleMap(roleMap map[string][]string) string {
+	entries := make([]string, 0, len(roleMap))
+	for key, roles := range roleMap {
+		entries = append(entries, key+"="+strings.Join(roles, "|"))
+	}
+	slices.Sort(entries)
+	return strings.Join(entries, ",")
+}
+
+func formatDeadlines(deadlines map[string]time.Duration) string {
+	entries := make([]string, 0, len(deadlines))
+	for method, timeout := range deadlines {
+		entries = append(entries, method+"="+timeout.String())
+	}
+	slices.Sort(entries)
+	return strings.Join(entries, ",")
+}
+
+func formatAuditMethods(methods map[string]audit.Level) string {
+	entries := make([]string, 0, len(methods))
+	for method, level := range methods {
+		entries = append(entries, method+"="+string(level))
+	}
+	slices.Sort(entries)
+	return strings.Join(entries, ",")
+}
+
+func formatIntMap(values map[string]int) string {
+	entries := make([]string, 0, len(values))
+	for key, value := range values {
+		entries = append(entries, key+"="+strconv.Itoa(value))
+	}
+	slices.Sort(entries)
+	return strings.Join(entries, ",")
+}
+
+// loadLogConfig reads LOG_LEVEL, LOG_MODULE_LEVELS (module=level pairs) and
+// LOG_SINKS (destinations, each optionally followed by =level).
+func (s *source) loadLogConfig() (observability.LogConfig, error) {
+	level, err := observability.ParseLevel(s.getOr("LOG_LEVEL", string(observability.LevelInfo)))
+	if err != nil {
+		return observability.LogConfig{}, fmt.Errorf("LOG_LEVEL: %w", err)
+	}
+	cfg := observability.LogConfig{Level: level, Modules: map[string]observability.Level{}}
+
+	for _, pair := range splitList(s.get("LOG_MODULE_LEVELS")) {
+		module, value, ok := strings.Cut(pair, "=")
+		if !ok || module == "" {
+			return observability.LogConfig{}, fmt.Errorf("LOG_MODULE_LEVELS: expected module=level pairs")
+		}
+		if cfg.Modules[module], err = observability.ParseLevel(value); err != nil {
+			return observability.LogConfig{}, fmt.Errorf("LOG_MODULE_LEVELS: %w", err)
+		}
+	}
+
+	for _, item := range splitList(s.getOr("LOG_SINKS", "stderr")) {
+		sink := observability.LogSink{Path: item}
+		if i := strings.LastIndex(item, "="); i >= 0 {
+			if sink.Level, err = observability.ParseLevel(item[i+1:]); err != nil {
+				return observability.LogConfig{}, fmt.Errorf("LOG_SINKS: %w", err)
+			}
+			sink.Path = item[:i]
+		}
+		if sink.Path == "" {
+			return observability.LogConfig{}, fmt.Errorf("LOG_SINKS: sink path is empty")
+		}
+		cfg.Sinks = append(cfg.Sinks, sink)
+	}
+	return cfg, nil
+}
+
+// loadOTLPEndpoint reads OTEL_EXPORTER_OTLP_ENDPOINT as the host:port that
+// both the trace exporter and the otlp_exporter dependency probe dial. The
+// http:// URL form the OpenTelemetry SDKs document is accepted too, its port
+// defaulting to 4317. Traces are exported without TLS, so https:// is
+// rejected rather than silently sent in plain text.
+func (s *source) loadOTLPEndpoint() (string, error) {
+	value := s.getOr("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317")
+	if !strings.Contains(value, "://") {
+		if host, port, err := net.SplitHostPort(value); err != nil || host == "" || port == "" {
+			return "", fmt.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT must be host:port or an http:// URL, got %q", value)
+		}
+		return value, nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme != "http" || u.Hostname() == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+		return "", fmt.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT must be host:port or an http:// URL without a path, got %q", value)
+	}
+	if u.Port() == "" {
+		return net.JoinHostPort(u.Hostname(), "4317"), nil
+	}
+	return u.Host, nil
+}
+
+// loadTraceExport reads TRACE_EXPORT_QUEUE_SIZE, TRACE_EXPORT_BATCH_SIZE,
+// TRACE_EXPORT_INTERVAL and TRACE_EXPORT_TIMEOUT, which default to
+// observability.DefaultTraceExportConfig.
+func (s *source) loadTraceExport() (observability.TraceExportConfig, error) {
+	cfg := observability.DefaultTraceExportConfig()
+	queueSize, err := s.uint("TRACE_EXPORT_QUEUE_SIZE", uint64(cfg.MaxQueueSize), 1)
+	if err != nil {
+		return observability.TraceExportConfig{}, err
+	}
+	batchSize, err := s.uint("TRACE_EXPORT_BATCH_SIZE", uint64(cfg.MaxBatchSize), 1)
+	if err != nil {
+		return observability.TraceExportConfig{}, err
+	}
+	if batchSize > queueSize {
+		return observability.TraceExportConfig{}, fmt.Errorf("TRACE_EXPORT_BATCH_SIZE %d must not exceed TRACE_EXPORT_QUEUE_SIZE %d", batchSize, queueSize)
+	}
+	cfg.MaxQueueSize, cfg.MaxBatchSize = int(queueSize), int(batchSize)
+	if cfg.BatchTimeout, err = s.positiveDuration("TRACE_EXPORT_INTERVAL", cfg.BatchTimeout); err != nil {
+		return observability.TraceExportConfig{}, err
+	}
+	if cfg.ExportTimeout, err = s.positiveDuration("TRACE_EXPORT_TIMEOUT", cfg.ExportTimeout); err != nil {
+		return observability.TraceExportConfig{}, err
+	}
+	return cfg, nil
+}
+
+// loadPropagators reads OTEL_PROPAGATORS, the comma-separated propagators
+// to use, by default observability.DefaultPropagators.
+func (s *source) loadPropagators() ([]observability.Propagator, error) {
+	names := splitList(s.get("OTEL_PROPAGATORS"))
+	if len(names) == 0 {
+		return observability.DefaultPropagators(), nil
+	}
+	propagators := make([]observability.Propagator, 0, len(names))
+	for _, name := range names {
+		p, err := observability.ParsePropagator(name)
+		if err != nil {
+			return nil, fmt.Errorf("OTEL_PROPAGATORS: %w", err)
+		}
+		if slices.Contains(propagators, p) {
+			return nil, fmt.Errorf("OTEL_PROPAGATORS: %s is listed twice", p)
+		}
+		propagators = append(propagators, p)
+	}
+	return propagators, nil
+}
+
+func formatPropagators(propagators []observability.Propagator) string {
+	names := make([]string, len(propagators))
+	for i, p := range propagators {
+		names[i] = string(p)
+	}
+	return strings.Join(names, ",")
+}
+
+// loadDeadlines reads GRPC_DEFAULT_DEADLINE, default 30s, and
+// GRPC_METHOD_DEADLINES, method=duration pairs.
+func (s *source) loadDeadlines() (time.Duration, map[string]time.Duration, error) {
+	fallback, err := s.duration("GRPC_DEFAULT_DEADLINE", 30*time.Second)
+	if err != nil {
+		return 0, nil, err
+	}
+	methods := map[string]time.Duration{}
+	for _, pair := range splitList(s.get("GRPC_METHOD_DEADLINES")) {
+		method, value, ok := strings.Cut(pair, "=")
+		if !ok || !strings.HasPrefix(method, "/") {
+			return 0, nil, fmt.Errorf("GRPC_METHOD_DEADLINES: expected /method=duration pairs, got %q", pair)
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return 0, nil, fmt.Errorf("GRPC_METHOD_DEADLINES: %q is not a non-negative duration", value)
+		}
+		if _, dup := methods[method]; dup {
+			return 0, nil, fmt.Errorf("GRPC_METHOD_DEADLINES: %q is listed twice", method)
+		}
+		methods[method] = timeout
+	}
+	return fallback, methods, nil
+}
+
+// loadConcurrency reads GRPC_MAX_IN_FLIGHT, default unbounded, and
+// GRPC_METHOD_MAX_IN_FLIGHT, method=limit pairs.
+func (s *source) loadConcurrency() (ConcurrencyConfig, error) {
+	limit, err := s.uint("GRPC_MAX_IN_FLIGHT", 0, 0)
+	if err != nil {
+		return ConcurrencyConfig{}, err
+	}
+	cfg := ConcurrencyConfig{Max: int(limit), Methods: map[string]int{}}
+	for _, pair := range splitList(s.get("GRPC_METHOD_MAX_IN_FLIGHT")) {
+		method, value, ok := strings.Cut(pair, "=")
+		if !ok || !strings.HasPrefix(method, "/") {
+			return ConcurrencyConfig{}, fmt.Errorf("GRPC_METHOD_MAX_IN_FLIGHT: expected /method=limit pairs, got %q", pair)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return ConcurrencyConfig{}, fmt.Errorf("GRPC_METHOD_MAX_IN_FLIGHT: %q is not a non-negative integer", value)
+		}
+		if _, dup := cfg.Methods[method]; dup {
+			return ConcurrencyConfig{}, fmt.Errorf("GRPC_METHOD_MAX_IN_FLIGHT: %q is listed twice", method)
+		}
+		cfg.Methods[method] = n
+	}
+	return cfg, nil
+}
+
+// loadMetadata reads GRPC_METADATA_MAX_BYTES, default 8 KiB, and
+// GRPC_METADATA_ALLOWED_KEYS, lower-cased.
+func (s *source) loadMetadata() (MetadataConfig, error) {
+	limit, err := s.uint("GRPC_METADATA_MAX_BYTES", 8<<10, 0)
+	if err != nil {
+		return MetadataConfig{}, err
+	}
+	cfg := MetadataConfig{MaxBytes: int(limit)}
+	for _, key := range splitList(s.get("GRPC_METADATA_ALLOWED_KEYS")) {
+		if strings.ContainsAny(strings.TrimSuffix(key, "*"), "* ") {
+			return MetadataConfig{}, fmt.Errorf("GRPC_METADATA_ALLOWED_KEYS: %q is not a key or a prefix ending in *", key)
+		}
+		cfg.AllowedKeys = append(cfg.AllowedKeys, strings.ToLower(key))
+	}
+	return cfg, nil
+}
+
+// loadHTTP reads the HTTP_CORS_* settings, which allow no origins by
+// default, and HTTP_HSTS_MAX_AGE, default a year, and
+// HTTP_HSTS_INCLUDE_SUBDOMAINS.
+func (s *source) loadHTTP() (HTTPConfig, error) {
+	cors := httpmiddleware.CORSPolicy{
+		AllowedOrigins: splitList(s.get("HTTP_CORS_ALLOWED_ORIGINS")),
+		AllowedMethods: splitList(s.getOr("HTTP_CORS_ALLOWED_METHODS", "GET,POST")),
+		AllowedHeaders: splitList(s.getOr("HTTP_CORS_ALLOWED_HEADERS", "authorization,content-type,x-request-id")),
+		ExposedHeaders: splitList(s.getOr("HTTP_CORS_EXPOSED_HEADERS", "x-request-id")),
+	}
+	for _, origin := range cors.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+			return HTTPConfig{}, fmt.Errorf("HTTP_CORS_ALLOWED_ORIGINS: %q is not an origin such as https://app.example.com, or *", origin)
+		}
+	}
+	value := s.getOr("HTTP_CORS_ALLOW_CREDENTIALS", "false")
+	var err error
+	if cors.AllowCredentials, err = strconv.ParseBool(value); err != nil {
+		return HTTPConfig{}, fmt.Errorf("HTTP_CORS_ALLOW_CREDENTIALS must be true or false, got %q", value)
+	}
+	if cors.AllowCredentials && slices.Contains(cors.AllowedOrigins, "*") {
+		return HTTPConfig{}, fmt.Errorf("HTTP_CORS_ALLOW_CREDENTIALS cannot be combined with the * origin in HTTP_CORS_ALLOWED_ORIGINS")
+	}
+	if cors.MaxAge, err = s.duration("HTTP_CORS_MAX_AGE", 10*time.Minute); err != nil {
+		return HTTPConfig{}, err
+	}
+
+	var security httpmiddleware.SecurityPolicy
+	if security.HSTSMaxAge, err = s.duration("HTTP_HSTS_MAX_AGE", 365*24*time.Hour); err != nil {
+		return HTTPConfig{}, err
+	}
+	value = s.getOr("HTTP_HSTS_INCLUDE_SUBDOMAINS", "false")
+	if security.HSTSIncludeSubdomains, err = strconv.ParseBool(value); err != nil {
+		return HTTPConfig{}, fmt.Errorf("HTTP_HSTS_INCLUDE_SUBDOMAINS must be true or false, got %q", value)
+	}
+	return HTTPConfig{CORS: cors, Security: security}, nil
+}
+
+// loadAccessLog reads ACCESS_LOG_EXCLUDED_METHODS, which defaults to the
+// health service, and ACCESS_LOG_PAYLOADS.
+func (s *source) loadAccessLog() (AccessLogConfig, error) {
+	cfg := AccessLogConfig{ExcludedMethods: splitList(s.getOr("ACCESS_LOG_EXCLUDED_METHODS", "/grpc.health.v1.Health/"))}
+	value := s.getOr("ACCESS_LOG_PAYLOADS", "false")
+	var err error
+	if cfg.Payloads, err = strconv.ParseBool(value); err != nil {
+		return AccessLogConfig{}, fmt.Errorf("ACCESS_LOG_PAYLOADS must be true or false, got %q", value)
+	}
+	return cfg, nil
+}
+
+// loadAudit reads AUDIT_SINK, default log, and AUDIT_METHODS, method=level
+// pairs replacing DefaultAuditMethods.
+func (s *source) loadAudit() (AuditConfig, error) {
+	cfg := AuditConfig{Sink: s.getOr("AUDIT_SINK", AuditSinkLog), Methods: maps.Clone(DefaultAuditMethods)}
+	switch cfg.Sink {
+	case AuditSinkLog, AuditSinkDatabase, AuditSinkNone:
+	default:
+		return AuditConfig{}, fmt.Errorf("AUDIT_SINK must be %s, %s or %s, got %q", AuditSinkLog, AuditSinkDatabase, AuditSinkNone, cfg.Sink)
+	}
+	value := s.get("AUDIT_METHODS")
+	if value == "" {
+		return cfg, nil
+	}
+	cfg.Methods = map[string]audit.Level{}
+	for _, pair := range splitList(value) {
+		method, name, ok := strings.Cut(pair, "=")
+		if !ok || !strings.HasPrefix(method, "/") {
+			return AuditConfig{}, fmt.Errorf("AUDIT_METHODS: expected /method=level pairs, got %q", pair)
+		}
+		level, err := audit.ParseLevel(name)
+		if err != nil {
+			return AuditConfig{}, fmt.Errorf("AUDIT_METHODS: %w", err)
+		}
+		if _, dup := cfg.Methods[method]; dup {
+			return AuditConfig{}, fmt.Errorf("AUDIT_METHODS: %q is listed twice", method)
+		}
+		cfg.Methods[method] = level
+	}
+	return cfg, nil
+}
+
+// parseBuckets reads comma-separated, strictly increasing positive bucket
+// bounds in seconds.
+func parseBuckets(value string) ([]float64, error) {
+	var buckets []float64
+	for _, item := range splitList(value) {
+		bound, err := strconv.ParseFloat(item, 64)
+		if err != nil || bound <= 0 {
+			return nil, fmt.Errorf("bucket %q is not a positive number of seconds", item)
+		}
+		if len(buckets) > 0 && bound <= buckets[len(buckets)-1] {
+			return nil, fmt.Errorf("buckets must be strictly increasing")
+		}
+		buckets = append(buckets, bound)
+	}
+	if len(buckets) == 0 {
+		return nil, fmt.Errorf("expected at least one bucket")
+	}
+	return buckets, nil
+}
+
+func formatBuckets(buckets []float64) string {
+	items := make([]string, len(buckets))
+	for i, b := range buckets {
+		items[i] = strconv.FormatFloat(b, 'g', -1, 64)
+	}
+	return strings.Join(items, ",")
+}
+
+func (s *source) loadLocality() (locality.Locality, error) {
+	l := locality.Locality{Region: s.get("REGION"), Zone: s.get("ZONE")}
+	if l.Zone != "" && l.Region == "" {
+		return locality.Locality{}, fmt.Errorf("ZONE %q is set without REGION", l.Zone)
+	}
+	return l, nil
+}
+
+// loadNodeRange parses SNOWFLAKE_NODE_RANGE, "first-last" such as "0-511".
+func (s *source) loadNodeRange() (NodeRange, error) {
+	value := s.get("SNOWFLAKE_NODE_RANGE")
+	if value == "" {
+		return AllNodes, nil
+	}
+	first, last, ok := strings.Cut(value, "-")
+	r := NodeRange{}
+	var errFirst, errLast error
+	r.First, errFirst = strconv.ParseInt(strings.TrimSpace(first), 10, 64)
+	r.Last, errLast = strconv.ParseInt(strings.TrimSpace(last), 10, 64)
+	if !ok || errFirst != nil || errLast != nil || r.First < 0 || r.First > r.Last || r.Last > maxNodeID {
+		return NodeRange{}, fmt.Errorf("SNOWFLAKE_NODE_RANGE must be first-last within 0-%d, got %q", maxNodeID, value)
+	}
+	return r, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// source resolves settings from the environment, then the config file.
+type source struct {
+	file   string
+	values map[string]string
+	used   map[string]bool
+}
+
+// newSource reads path, a YAML mapping of setting names to scalars or lists
+// of scalars. Lists are joined with commas, like the environment variables
+// they stand for. An empty path reads no file.
+func newSource(path string) (*source, error) {
+	s := &source{file: path, values: map[string]string{}, used: map[string]bool{}}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("CONFIG_FILE: %w", err)
+	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("CONFIG_FILE %s: %w", path, err)
+	}
+	for key, value := range raw {
+		switch v := value.(type) {
+		case nil:
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				if !isScalar(item) {
+					return nil, fmt.Errorf("CONFIG_FILE %s: %s must hold scalars", path, key)
+				}
+				items[i] = fmt.Sprint(item)
+			}
+			s.values[key] = strings.Join(items, ",")
+		default:
+			if !isScalar(v) {
+				return nil, fmt.Errorf("CONFIG_FILE %s: %s must be a scalar or a list", path, key)
+			}
+			s.values[key] = fmt.Sprint(v)
+		}
+	}
+	return s, nil
+}
+
+func isScalar(v any) bool {
+	switch v.(type) {
+	case string, bool, int, int64, uint64, float64:
+		return true
+	}
+	return false
+}
+
+func (s *source) get(key string) string {
+	s.used[key] = true
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return s.values[key]
+}
+
+func (s *source) getOr(key, fallback string) string {
+	if value := s.get(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// checkUnused rejects file keys that no setting read, which are most likely
+// typos that would otherwise be ignored silently.
+func (s *source) checkUnused() error {
+	var unknown []string
+	for key := range s.values {
+		if !s.used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return fmt.Errorf("CONFIG_FILE %s: unknown settings %v", s.file, unknown)
+	}
+	return nil
+}
+
+// duration reads a non-negative Go duration, or fallback when key is unset.
+func (s *source) duration(key string, fallback time.Duration) (time.Duration, error) {
+	value := s.get(key)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative duration, got %q", key, value)
+	}
+	return d, nil
+}
+
+func (s *source) positiveDuration(key string, fallback time.Duration) (time.Duration, error) {
+	d, err := s.duration(key, fallback)
+	if err == nil && d == 0 {
+		err = fmt.Errorf("%s must be a positive duration, got %q", key, s.get(key))
+	}
+	return d, err
+}
+
+// uint reads an integer of at least least, or fallback when key is unset.
+func (s *source) uint(key string, fallback, least uint64) (uint64, error) {
+	value := s.get(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || n < least {
+		return 0, fmt.Errorf("%s must be an integer of at least %d, got %q", key, least, value)
+	}
+	return n, nil
+}
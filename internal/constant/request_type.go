@@ -3,5 +3,7 @@ package constant
 type RequestType string
 
 const (
-	RequestTypeCreateUser RequestType = "create_user"
+	RequestTypeCreateUser     RequestType = "create_user"
+	RequestTypeSuspendUser    RequestType = "suspend_user"
+	RequestTypeReactivateUser RequestType = "reactivate_user"
 )
@@ -0,0 +1,234 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/event"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	"github.com/jt828/go-grpc-template/pkg/snowflake"
+	"github.com/jt828/go-grpc-template/pkg/workerpool"
+)
+
+// Handler applies one inbound event. It runs inside uow, which commits only
+// when Handle succeeds, together with the inbox row that marks the event as
+// processed. Writes through uow's main-store repositories therefore take
+// effect exactly once per handler, however often the event is redelivered.
+type Handler interface {
+	Handle(ctx context.Context, uow repository.UnitOfWork, envelope *event.Envelope) error
+}
+
+type HandlerFunc func(ctx context.Context, uow repository.UnitOfWork, envelope *event.Envelope) error
+
+func (f HandlerFunc) Handle(ctx context.Context, uow repository.UnitOfWork, envelope *event.Envelope) error {
+	return f(ctx, uow, envelope)
+}
+
+// Delivery is an event received on a topic.
+type Delivery struct {
+	Topic    string
+	Envelope *event.Envelope
+}
+
+// Source is the transport the consumer reads from, e.g. a broker
+// subscription. Receive blocks until a delivery is available or ctx is
+// cancelled. Deliveries that are not acked are expected to be redelivered.
+type Source interface {
+	Receive(ctx context.Context) (*Delivery, error)
+	Ack(ctx context.Context, delivery *Delivery) error
+}
+
+var errPermanent = errors.New("permanent failure")
+
+// Permanent marks a handler error as not worth retrying; the event goes
+// straight to the dead-letter queue.
+func Permanent(err error) error {
+	return fmt.Errorf("%w: %w", errPermanent, err)
+}
+
+type route struct {
+	topic   string
+	typeURL string
+}
+
+type Consumer struct {
+	uowFactory repository.UnitOfWorkFactory
+	retry      retry.Retry
+	snowflake  snowflake.Snowflake
+	log        observability.Logger
+
+	handlers map[route]Handler
+
+	duration     observability.Histogram
+	handled      observability.Counter
+	duplicates   observability.Counter
+	deadLettered observability.Counter
+	pool         *workerpool.Metrics
+}
+
+func NewConsumer(uowFactory repository.UnitOfWorkFactory, retry retry.Retry, snowflake snowflake.Snowflake, meter observability.Meter, log observability.Logger) *Consumer {
+	return &Consumer{
+		uowFactory: uowFactory,
+		retry:      retry,
+		snowflake:  snowflake,
+		log:        log,
+		handlers:   map[route]Handler{},
+		duration: meter.Histogram("consumer_handle_duration_seconds", observability.MetricOpt{
+			Help:      "Duration of event handling attempts in seconds",
+			Preset:    observability.BucketsRPC,
+			LabelKeys: []string{"handler"},
+		}),
+		handled: meter.Counter("consumer_events_handled_total", observability.MetricOpt{
+			Help:      "Total number of events handled successfully",
+			LabelKeys: []string{"handler"},
+		}),
+		duplicates: meter.Counter("consumer_events_duplicate_total", observability.MetricOpt{
+			Help:      "Total number of redelivered events skipped as already processed",
+			LabelKeys: []string{"handler"},
+		}),
+		deadLettered: meter.Counter("consumer_events_dead_lettered_total", observability.MetricOpt{
+			Help:      "Total number of events moved to the dead-letter queue",
+			LabelKeys: []string{"handler"},
+		}),
+		pool: workerpool.NewMetrics(meter),
+	}
+}
+
+// Register routes events of typeURL arriving on topic to handler. Register
+// all handlers before calling Run.
+func (c *Consumer) Register(topic, typeURL string, handler Handler) {
+	c.handlers[route{topic: topic, typeURL: typeURL}] = handler
+}
+
+// Process handles a single delivery, retrying failures and dead-lettering
+// the event once retries are exhausted. A nil error means the delivery can
+// be acked; an error means the dead letter could not be stored either.
+func (c *Consumer) Process(ctx context.Context, delivery *Delivery) error {
+	name := handlerName(delivery)
+	handler, ok := c.handlers[route{topic: delivery.Topic, typeURL: delivery.Envelope.TypeURL}]
+	if !ok {
+		return c.deadLetter(ctx, delivery, 0, fmt.Errorf("no handler for %s", name))
+	}
+
+	attempts := 0
+	var permanent error
+	err := c.retry.Execute(ctx, func() error {
+		attempts++
+		err := c.attempt(ctx, name, handler, delivery)
+		if errors.Is(err, errPermanent) {
+			permanent = err
+			return nil
+		}
+		return err
+	})
+	if permanent != nil {
+		err = permanent
+	}
+	if err != nil {
+		return c.deadLetter(ctx, delivery, attempts, err)
+	}
+	return nil
+}
+
+func (c *Consumer) attempt(ctx context.Context, name string, handler Handler, delivery *Delivery) error {
+	start := time.Now()
+	label := observability.Label{Key: "handler", Value: name}
+	defer func() { c.duration.Observe(time.Since(start).Seconds(), label) }()
+
+	uow, err := c.uowFactory.New(ctx)
+	if err != nil {
+		return err
+	}
+
+	duplicate, err := c.handle(ctx, uow, name, handler, delivery.Envelope)
+	if err != nil {
+		_ = uow.Abort(ctx)
+		return err
+	}
+
+	if err := uow.Commit(ctx); err != nil {
+		return err
+	}
+
+	if duplicate {
+		c.duplicates.Inc(1, label)
+	} else {
+		c.handled.Inc(1, label)
+	}
+	return nil
+}
+
+// handle records the event in the inbox and runs handler in the same
+// transaction. An event the inbox already holds for this handler is a
+// redelivery of one that committed, and is skipped.
+func (c *Consumer) handle(ctx context.Context, uow repository.UnitOfWork, name string, handler Handler, envelope *event.Envelope) (bool, error) {
+	inserted, err := uow.InboxRepository().Insert(ctx, &model.InboxMessage{
+		Handler:     name,
+		EventId:     envelope.Id,
+		EventType:   envelope.TypeURL,
+		ProcessedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return false, err
+	}
+	if !inserted {
+		return true, nil
+	}
+	return false, handler.Handle(ctx, uow, envelope)
+}
+
+// Run receives deliveries from source and processes up to concurrency of them
+// at a time on a worker pool; while every worker is busy it holds at most one
+// received delivery back. When ctx is cancelled it stops receiving, lets in-flight
+// deliveries finish and returns.
+func (c *Consumer) Run(ctx context.Context, source Source, concurrency int) error {
+	// In-flight work must not be cut short by shutdown, or a half-processed
+	// delivery would be retried and dead-lettered for no reason, so the pool
+	// is drained without a deadline.
+	pool := workerpool.New(workerpool.Config{Name: "consumer", Workers: concurrency}, c.pool, c.log)
+	defer pool.Close(context.Background())
+
+	for {
+		delivery, err := source.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		err = pool.Submit(ctx, func(ctx context.Context) {
+			if err := c.Process(ctx, delivery); err != nil {
+				c.log.Error("failed to process event, leaving it for redelivery",
+					observability.String("handler", handlerName(delivery)),
+					observability.Int64("event_id", delivery.Envelope.Id),
+					observability.Err(err),
+				)
+				return
+			}
+			if err := source.Ack(ctx, delivery); err != nil {
+				c.log.Error("failed to ack event",
+					observability.String("handler", handlerName(delivery)),
+					observability.Int64("event_id", delivery.Envelope.Id),
+					observability.Err(err),
+				)
+			}
+		})
+		if err != nil {
+			// Shutting down; the delivery is left unacked for redelivery.
+			return nil
+		}
+	}
+}
+
+func handlerName(delivery *Delivery) string {
+	if t, err := event.ParseTypeURL(delivery.Envelope.TypeURL); err == nil {
+		return delivery.Topic + "/" + string(t.FullName.Name())
+	}
+	return delivery.Topic + "/" + delivery.Envelope.TypeURL
+}
@@ -0,0 +1,96 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/event"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+)
+
+// deadLetterPayload is the JSON stored in dead_letters.payload for consumer
+// events. The proto payload is kept as-is (base64 encoded by encoding/json)
+// so events whose schema this binary does not know survive a replay.
+type deadLetterPayload struct {
+	Topic      string    `json:"topic"`
+	EventId    int64     `json:"event_id"`
+	TypeURL    string    `json:"type_url"`
+	Payload    []byte    `json:"payload"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func (c *Consumer) deadLetter(ctx context.Context, delivery *Delivery, attempts int, cause error) error {
+	payload, err := json.Marshal(deadLetterPayload{
+		Topic:      delivery.Topic,
+		EventId:    delivery.Envelope.Id,
+		TypeURL:    delivery.Envelope.TypeURL,
+		Payload:    delivery.Envelope.Payload,
+		OccurredAt: delivery.Envelope.OccurredAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	uow, err := c.uowFactory.New(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = uow.DeadLetterRepository().Insert(ctx, &model.DeadLetter{
+		Id:        c.snowflake.Generate(),
+		Source:    model.DeadLetterSourceConsumer,
+		EventType: delivery.Envelope.TypeURL,
+		Payload:   string(payload),
+		LastError: cause.Error(),
+		Attempts:  attempts,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		_ = uow.Abort(ctx)
+		return err
+	}
+
+	if err := uow.Commit(ctx); err != nil {
+		return err
+	}
+
+	name := handlerName(delivery)
+	c.deadLettered.Inc(1, observability.Label{Key: "handler", Value: name})
+	c.log.Error("event dead-lettered",
+		observability.String("handler", name),
+		observability.Int64("event_id", delivery.Envelope.Id),
+		observability.Int("attempts", attempts),
+		observability.Err(cause),
+	)
+	return nil
+}
+
+// Replay runs the registered handler for a dead-lettered consumer event
+// inside uow. It satisfies service.DeadLetterReplayer.
+func (c *Consumer) Replay(ctx context.Context, uow repository.UnitOfWork, deadLetter *model.DeadLetter) error {
+	var payload deadLetterPayload
+	if err := json.Unmarshal([]byte(deadLetter.Payload), &payload); err != nil {
+		return err
+	}
+
+	delivery := &Delivery{
+		Topic: payload.Topic,
+		Envelope: &event.Envelope{
+			Id:         payload.EventId,
+			TypeURL:    payload.TypeURL,
+			Payload:    payload.Payload,
+			OccurredAt: payload.OccurredAt,
+		},
+	}
+	handler, ok := c.handlers[route{topic: delivery.Topic, typeURL: delivery.Envelope.TypeURL}]
+	if !ok {
+		return fmt.Errorf("no handler for %s", handlerName(delivery))
+	}
+
+	_, err := c.handle(ctx, uow, handlerName(delivery), handler, delivery.Envelope)
+	return err
+}
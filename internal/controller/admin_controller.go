@@ -0,0 +1,356 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jt828/go-grpc-template/internal/controller/convert"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	v1 "github.com/jt828/go-grpc-template/proto"
+)
+
+// defaultDeadLetterPageSize applies when page_size is 0. The maximum is a
+// rule on ListDeadLettersRequest.page_size.
+const defaultDeadLetterPageSize = 50
+
+// defaultIdempotencyRecordPageSize applies when page_size is 0 in the
+// idempotency record listings.
+const defaultIdempotencyRecordPageSize = 100
+
+type AdminController struct {
+	v1.UnimplementedAdminServiceServer
+	dependencyService  service.DependencyService
+	deadLetterService  service.DeadLetterService
+	userService        service.UserService
+	configService      service.ConfigService
+	schemaDriftService service.SchemaDriftService
+	loginThrottle      service.LoginThrottleService
+	identityService    service.IdentityService
+	idempotencyRecords service.IdempotencyRecordService
+	roleService        service.RoleService
+}
+
+func NewAdminController(dependencyService service.DependencyService, deadLetterService service.DeadLetterService, userService service.UserService, configService service.ConfigService, schemaDriftService service.SchemaDriftService, loginThrottle service.LoginThrottleService, identityService service.IdentityService, idempotencyRecords service.IdempotencyRecordService, roleService service.RoleService) *AdminController {
+	return &AdminController{dependencyService: dependencyService, deadLetterService: deadLetterService, userService: userService, configService: configService, schemaDriftService: schemaDriftService, loginThrottle: loginThrottle, identityService: identityService, idempotencyRecords: idempotencyRecords, roleService: roleService}
+}
+
+func (ctrl *AdminController) GetDependencies(
+	ctx context.Context,
+	request *v1.GetDependenciesRequest,
+) (*v1.GetDependenciesResponse, error) {
+	statuses := ctrl.dependencyService.GetDependencies(ctx)
+
+	dependencies := make([]*v1.DependencyStatus, len(statuses))
+	for i, status := range statuses {
+		dependencies[i] = convert.DependencyStatus(status)
+	}
+
+	return &v1.GetDependenciesResponse{Dependencies: dependencies}, nil
+}
+
+func (ctrl *AdminController) ListDeadLetters(
+	ctx context.Context,
+	request *v1.ListDeadLettersRequest,
+) (*v1.ListDeadLettersResponse, error) {
+	source := model.DeadLetterSource(request.Source)
+	switch source {
+	case "", model.DeadLetterSourceOutbox, model.DeadLetterSourceWebhook, model.DeadLetterSourceConsumer:
+	default:
+		return nil, apperror.FieldError("source", "enum", fmt.Sprintf("%q is not a dead letter source", request.Source))
+	}
+
+	pageSize := int(request.PageSize)
+	if pageSize == 0 {
+		pageSize = defaultDeadLetterPageSize
+	}
+
+	deadLetters, err := ctrl.deadLetterService.ListDeadLetters(ctx, service.ListDeadLettersParams{
+		SourceEq:    source,
+		PendingOnly: request.PendingOnly,
+		AfterId:     request.AfterId,
+		PageSize:    pageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &v1.ListDeadLettersResponse{DeadLetters: make([]*v1.DeadLetter, len(deadLetters))}
+	for i, deadLetter := range deadLetters {
+		response.DeadLetters[i] = convert.DeadLetter(deadLetter)
+	}
+	if len(deadLetters) == pageSize {
+		response.NextAfterId = deadLetters[len(deadLetters)-1].Id
+	}
+	return response, nil
+}
+
+func (ctrl *AdminController) GetDeadLetter(
+	ctx context.Context,
+	request *v1.GetDeadLetterRequest,
+) (*v1.GetDeadLetterResponse, error) {
+	deadLetter, err := ctrl.deadLetterService.GetDeadLetter(ctx, request.Id)
+	if err != nil {
+		return nil, err
+	}
+	if deadLetter == nil {
+		return nil, apperror.NotFoundf("dead letter %d", request.Id)
+	}
+
+	return &v1.GetDeadLetterResponse{DeadLetter: convert.DeadLetter(deadLetter)}, nil
+}
+
+func (ctrl *AdminController) ReplayDeadLetter(
+	ctx context.Context,
+	request *v1.ReplayDeadLetterRequest,
+) (*v1.ReplayDeadLetterResponse, error) {
+	deadLetter, err := ctrl.deadLetterService.ReplayDeadLetter(ctx, request.Id)
+	if err != nil {
+		return nil, err
+	}
+	if deadLetter == nil {
+		return nil, apperror.NotFoundf("dead letter %d", request.Id)
+	}
+
+	return &v1.ReplayDeadLetterResponse{DeadLetter: convert.DeadLetter(deadLetter)}, nil
+}
+
+func (ctrl *AdminController) SuspendUser(
+	ctx context.Context,
+	request *v1.SuspendUserRequest,
+) (*v1.SuspendUserResponse, error) {
+	user, err := ctrl.userService.SuspendUser(ctx, request.IdempotencyId, request.UserId, request.Reason)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, apperror.UserNotFound(request.UserId)
+	}
+
+	return &v1.SuspendUserResponse{
+		UserId:    user.Id,
+		Status:    convert.UserStatus(user.Status),
+		UpdatedAt: convert.Timestamp(user.UpdatedAt),
+		CreatedBy: user.CreatedBy,
+		UpdatedBy: user.UpdatedBy,
+		Version:   user.Version,
+	}, nil
+}
+
+func (ctrl *AdminController) ReactivateUser(
+	ctx context.Context,
+	request *v1.ReactivateUserRequest,
+) (*v1.ReactivateUserResponse, error) {
+	user, err := ctrl.userService.ReactivateUser(ctx, request.IdempotencyId, request.UserId, request.Reason)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, apperror.UserNotFound(request.UserId)
+	}
+
+	return &v1.ReactivateUserResponse{
+		UserId:    user.Id,
+		Status:    convert.UserStatus(user.Status),
+		UpdatedAt: convert.Timestamp(user.UpdatedAt),
+		CreatedBy: user.CreatedBy,
+		UpdatedBy: user.UpdatedBy,
+		Version:   user.Version,
+	}, nil
+}
+
+func (ctrl *AdminController) UnlockUser(
+	ctx context.Context,
+	request *v1.UnlockUserRequest,
+) (*v1.UnlockUserResponse, error) {
+	cleared, err := ctrl.loginThrottle.Unlock(ctx, request.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.UnlockUserResponse{ClearedIps: cleared}, nil
+}
+
+func (ctrl *AdminController) LinkUserIdentity(
+	ctx context.Context,
+	request *v1.LinkUserIdentityRequest,
+) (*v1.LinkUserIdentityResponse, error) {
+	identity, err := ctrl.identityService.Link(ctx, request.UserId, request.Issuer, request.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.LinkUserIdentityResponse{Identity: convert.UserIdentity(identity)}, nil
+}
+
+func (ctrl *AdminController) UnlinkUserIdentity(
+	ctx context.Context,
+	request *v1.UnlinkUserIdentityRequest,
+) (*v1.UnlinkUserIdentityResponse, error) {
+	unlinked, err := ctrl.identityService.Unlink(ctx, request.Issuer, request.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.UnlinkUserIdentityResponse{Unlinked: unlinked}, nil
+}
+
+func (ctrl *AdminController) PutRole(
+	ctx context.Context,
+	request *v1.PutRoleRequest,
+) (*v1.PutRoleResponse, error) {
+	role, err := ctrl.roleService.PutRole(ctx, request.Name, request.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.PutRoleResponse{Role: convert.Role(role)}, nil
+}
+
+func (ctrl *AdminController) ListRoles(
+	ctx context.Context,
+	request *v1.ListRolesRequest,
+) (*v1.ListRolesResponse, error) {
+	roles, err := ctrl.roleService.ListRoles(ctx, request.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &v1.ListRolesResponse{Roles: make([]*v1.Role, len(roles))}
+	for i, role := range roles {
+		response.Roles[i] = convert.Role(role)
+	}
+	return response, nil
+}
+
+func (ctrl *AdminController) AssignRole(
+	ctx context.Context,
+	request *v1.AssignRoleRequest,
+) (*v1.AssignRoleResponse, error) {
+	assigned, err := ctrl.roleService.AssignRole(ctx, request.UserId, request.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.AssignRoleResponse{Assigned: assigned}, nil
+}
+
+func (ctrl *AdminController) UnassignRole(
+	ctx context.Context,
+	request *v1.UnassignRoleRequest,
+) (*v1.UnassignRoleResponse, error) {
+	unassigned, err := ctrl.roleService.UnassignRole(ctx, request.UserId, request.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.UnassignRoleResponse{Unassigned: unassigned}, nil
+}
+
+func (ctrl *AdminController) GetConfig(
+	ctx context.Context,
+	request *v1.GetConfigRequest,
+) (*v1.GetConfigResponse, error) {
+	config := ctrl.configService.GetConfig(ctx)
+
+	response := &v1.GetConfigResponse{
+		StartedAt: convert.Timestamp(config.StartedAt),
+		Entries:   make([]*v1.ConfigEntry, len(config.Entries)),
+	}
+	for i, entry := range config.Entries {
+		response.Entries[i] = convert.ConfigEntry(entry)
+	}
+	return response, nil
+}
+
+func (ctrl *AdminController) CheckSchemaDrift(
+	ctx context.Context,
+	request *v1.CheckSchemaDriftRequest,
+) (*v1.CheckSchemaDriftResponse, error) {
+	drifts, err := ctrl.schemaDriftService.CheckSchemaDrift(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &v1.CheckSchemaDriftResponse{Drifts: make([]*v1.SchemaDrift, len(drifts))}
+	for i, drift := range drifts {
+		response.Drifts[i] = convert.SchemaDrift(drift)
+	}
+	return response, nil
+}
+
+func (ctrl *AdminController) QuarantineIdempotencyRecord(
+	ctx context.Context,
+	request *v1.QuarantineIdempotencyRecordRequest,
+) (*v1.QuarantineIdempotencyRecordResponse, error) {
+	quarantined, err := ctrl.idempotencyRecords.Quarantine(ctx, request.Id, request.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.QuarantineIdempotencyRecordResponse{Quarantined: quarantined}, nil
+}
+
+func (ctrl *AdminController) ListCorruptIdempotencyRecords(
+	ctx context.Context,
+	request *v1.ListCorruptIdempotencyRecordsRequest,
+) (*v1.ListCorruptIdempotencyRecordsResponse, error) {
+	pageSize := int(request.PageSize)
+	if pageSize == 0 {
+		pageSize = defaultIdempotencyRecordPageSize
+	}
+
+	records, next, err := ctrl.idempotencyRecords.ListCorrupt(ctx, request.AfterId, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &v1.ListCorruptIdempotencyRecordsResponse{
+		Records:     make([]*v1.CorruptIdempotencyRecord, len(records)),
+		NextAfterId: next,
+	}
+	for i, record := range records {
+		response.Records[i] = convert.CorruptIdempotencyRecord(record)
+	}
+	return response, nil
+}
+
+func (ctrl *AdminController) ListQuarantinedIdempotencyRecords(
+	ctx context.Context,
+	request *v1.ListQuarantinedIdempotencyRecordsRequest,
+) (*v1.ListQuarantinedIdempotencyRecordsResponse, error) {
+	pageSize := int(request.PageSize)
+	if pageSize == 0 {
+		pageSize = defaultIdempotencyRecordPageSize
+	}
+
+	records, err := ctrl.idempotencyRecords.ListQuarantined(ctx, request.AfterId, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &v1.ListQuarantinedIdempotencyRecordsResponse{Records: make([]*v1.IdempotencyRecord, len(records))}
+	for i, record := range records {
+		response.Records[i] = convert.QuarantinedIdempotencyRecord(record)
+	}
+	if len(records) == pageSize {
+		response.NextAfterId = records[len(records)-1].Id
+	}
+	return response, nil
+}
+
+func (ctrl *AdminController) RepairIdempotencyRecord(
+	ctx context.Context,
+	request *v1.RepairIdempotencyRecordRequest,
+) (*v1.RepairIdempotencyRecordResponse, error) {
+	record, err := ctrl.idempotencyRecords.Repair(ctx, request.Id)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, apperror.NotFoundf("quarantined idempotency record %d", request.Id)
+	}
+
+	return &v1.RepairIdempotencyRecordResponse{Record: convert.IdempotencyRecord(record)}, nil
+}
@@ -0,0 +1,207 @@
+package convert
+
+import (
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/idempotency"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func DeadLetter(deadLetter *model.DeadLetter) *v1.DeadLetter {
+	return &v1.DeadLetter{
+		Id:         deadLetter.Id,
+		Source:     string(deadLetter.Source),
+		EventType:  deadLetter.EventType,
+		Payload:    deadLetter.Payload,
+		LastError:  deadLetter.LastError,
+		Attempts:   int32(deadLetter.Attempts),
+		CreatedAt:  Timestamp(deadLetter.CreatedAt),
+		ReplayedAt: OptionalTimestamp(deadLetter.ReplayedAt),
+	}
+}
+
+func FromDeadLetter(deadLetter *v1.DeadLetter) *model.DeadLetter {
+	return &model.DeadLetter{
+		Id:         deadLetter.Id,
+		Source:     model.DeadLetterSource(deadLetter.Source),
+		EventType:  deadLetter.EventType,
+		Payload:    deadLetter.Payload,
+		LastError:  deadLetter.LastError,
+		Attempts:   int(deadLetter.Attempts),
+		CreatedAt:  Time(deadLetter.CreatedAt),
+		ReplayedAt: OptionalTime(deadLetter.ReplayedAt),
+	}
+}
+
+func UserIdentity(identity *model.UserIdentity) *v1.UserIdentity {
+	return &v1.UserIdentity{
+		Issuer:    identity.Issuer,
+		Subject:   identity.Subject,
+		UserId:    identity.UserId,
+		CreatedAt: Timestamp(identity.CreatedAt),
+		CreatedBy: identity.CreatedBy,
+	}
+}
+
+func FromUserIdentity(identity *v1.UserIdentity) *model.UserIdentity {
+	return &model.UserIdentity{
+		Issuer:    identity.Issuer,
+		Subject:   identity.Subject,
+		UserId:    identity.UserId,
+		CreatedAt: Time(identity.CreatedAt),
+		CreatedBy: identity.CreatedBy,
+	}
+}
+
+func Role(role *model.Role) *v1.Role {
+	return &v1.Role{
+		Name:        role.Name,
+		Permissions: role.Permissions,
+		CreatedAt:   Timestamp(role.CreatedAt),
+		CreatedBy:   role.CreatedBy,
+	}
+}
+
+func FromRole(role *v1.Role) *model.Role {
+	return &model.Role{
+		Name:        role.Name,
+		Permissions: role.Permissions,
+		CreatedAt:   Time(role.CreatedAt),
+		CreatedBy:   role.CreatedBy,
+	}
+}
+
+func ConfigEntry(entry model.ConfigEntry) *v1.ConfigEntry {
+	return &v1.ConfigEntry{
+		Key:      entry.Key,
+		Value:    entry.Value,
+		Redacted: entry.Redacted,
+	}
+}
+
+func FromConfigEntry(entry *v1.ConfigEntry) model.ConfigEntry {
+	return model.ConfigEntry{
+		Key:      entry.Key,
+		Value:    entry.Value,
+		Redacted: entry.Redacted,
+	}
+}
+
+var schemaDriftKinds = map[model.SchemaDriftKind]v1.SchemaDriftKind{
+	model.SchemaDriftMissingTable:      v1.SchemaDriftKind_SCHEMA_DRIFT_KIND_MISSING_TABLE,
+	model.SchemaDriftMissingColumn:     v1.SchemaDriftKind_SCHEMA_DRIFT_KIND_MISSING_COLUMN,
+	model.SchemaDriftUnexpectedColumn:  v1.SchemaDriftKind_SCHEMA_DRIFT_KIND_UNEXPECTED_COLUMN,
+	model.SchemaDriftColumnType:        v1.SchemaDriftKind_SCHEMA_DRIFT_KIND_COLUMN_TYPE,
+	model.SchemaDriftColumnNullability: v1.SchemaDriftKind_SCHEMA_DRIFT_KIND_COLUMN_NULLABILITY,
+	model.SchemaDriftMissingIndex:      v1.SchemaDriftKind_SCHEMA_DRIFT_KIND_MISSING_INDEX,
+	model.SchemaDriftUnexpectedIndex:   v1.SchemaDriftKind_SCHEMA_DRIFT_KIND_UNEXPECTED_INDEX,
+}
+
+func SchemaDrift(drift *model.SchemaDrift) *v1.SchemaDrift {
+	return &v1.SchemaDrift{
+		Store:    drift.Store,
+		Object:   drift.Object,
+		Kind:     schemaDriftKinds[drift.Kind],
+		Expected: drift.Expected,
+		Actual:   drift.Actual,
+	}
+}
+
+func FromSchemaDrift(drift *v1.SchemaDrift) *model.SchemaDrift {
+	result := &model.SchemaDrift{
+		Store:    drift.Store,
+		Object:   drift.Object,
+		Expected: drift.Expected,
+		Actual:   drift.Actual,
+	}
+	for k, p := range schemaDriftKinds {
+		if p == drift.Kind {
+			result.Kind = k
+		}
+	}
+	return result
+}
+
+// DependencyStatus leaves the probe time and latency unset until the
+// dependency has been probed.
+func DependencyStatus(status *model.DependencyStatus) *v1.DependencyStatus {
+	result := &v1.DependencyStatus{
+		Name:                status.Name,
+		State:               DependencyState(status.State),
+		CircuitBreakerState: CircuitBreakerState(status.CircuitBreakerState),
+		LastError:           status.LastError,
+	}
+	if !status.LastProbeAt.IsZero() {
+		result.LastProbeAt = Timestamp(status.LastProbeAt)
+		result.LastProbeLatency = durationpb.New(status.LastProbeLatency)
+	}
+	return result
+}
+
+var dependencyStates = map[model.DependencyState]v1.DependencyState{
+	model.DependencyStateUnknown: v1.DependencyState_DEPENDENCY_STATE_UNSPECIFIED,
+	model.DependencyStateUp:      v1.DependencyState_DEPENDENCY_STATE_UP,
+	model.DependencyStateDown:    v1.DependencyState_DEPENDENCY_STATE_DOWN,
+}
+
+func DependencyState(state model.DependencyState) v1.DependencyState {
+	return dependencyStates[state]
+}
+
+func FromDependencyState(state v1.DependencyState) model.DependencyState {
+	for s, p := range dependencyStates {
+		if p == state {
+			return s
+		}
+	}
+	return model.DependencyStateUnknown
+}
+
+var circuitBreakerStates = map[circuitbreaker.State]v1.CircuitBreakerState{
+	circuitbreaker.Closed:   v1.CircuitBreakerState_CIRCUIT_BREAKER_STATE_CLOSED,
+	circuitbreaker.HalfOpen: v1.CircuitBreakerState_CIRCUIT_BREAKER_STATE_HALF_OPEN,
+	circuitbreaker.Open:     v1.CircuitBreakerState_CIRCUIT_BREAKER_STATE_OPEN,
+}
+
+// CircuitBreakerState maps a nil state, used for dependencies without a
+// breaker, to CIRCUIT_BREAKER_STATE_UNSPECIFIED.
+func CircuitBreakerState(state *circuitbreaker.State) v1.CircuitBreakerState {
+	if state == nil {
+		return v1.CircuitBreakerState_CIRCUIT_BREAKER_STATE_UNSPECIFIED
+	}
+	return circuitBreakerStates[*state]
+}
+
+func FromCircuitBreakerState(state v1.CircuitBreakerState) *circuitbreaker.State {
+	for s, p := range circuitBreakerStates {
+		if p == state {
+			return &s
+		}
+	}
+	return nil
+}
+
+func IdempotencyRecord(record *idempotency.Record) *v1.IdempotencyRecord {
+	return &v1.IdempotencyRecord{
+		Id:          record.Id,
+		RequestType: record.RequestType,
+		ReferenceId: record.ReferenceId,
+		CreatedAt:   Timestamp(record.CreatedAt),
+	}
+}
+
+func CorruptIdempotencyRecord(record *service.CorruptRecord) *v1.CorruptIdempotencyRecord {
+	return &v1.CorruptIdempotencyRecord{
+		Record: IdempotencyRecord(&record.Record),
+		Error:  record.Err.Error(),
+	}
+}
+
+func QuarantinedIdempotencyRecord(record *idempotency.QuarantinedRecord) *v1.IdempotencyRecord {
+	converted := IdempotencyRecord(&record.Record)
+	converted.QuarantinedAt = Timestamp(record.QuarantinedAt)
+	converted.Reason = record.Reason
+	return converted
+}
@@ -0,0 +1,41 @@
+// Package convert maps between domain models and their proto messages. All
+// controllers go through it so a field added to a model is mapped in one
+// place, and round-trip tests catch fields that one direction forgets.
+package convert
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Timestamp converts t to a proto timestamp in UTC.
+func Timestamp(t time.Time) *timestamppb.Timestamp {
+	return timestamppb.New(t)
+}
+
+// OptionalTimestamp converts t, returning nil when t is nil so the field is
+// left unset.
+func OptionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// Time converts ts to a UTC time. A nil ts gives the zero time.
+func Time(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// OptionalTime converts ts, returning nil when ts is unset.
+func OptionalTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
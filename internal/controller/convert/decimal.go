@@ -0,0 +1,53 @@
+package convert
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"github.com/shopspring/decimal"
+)
+
+// Amounts are stored as NUMERIC(36, 18), so inbound values may carry at most
+// 18 integer and 18 fraction digits. Larger values are rejected rather than
+// rounded.
+const (
+	MaxDecimalIntegerDigits = 18
+	MaxDecimalScale         = 18
+)
+
+var plainDecimal = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// Decimal converts d to its canonical string form. The conversion is exact.
+func Decimal(d decimal.Decimal) *v1.DecimalValue {
+	return &v1.DecimalValue{Value: d.String()}
+}
+
+// FromDecimal parses an inbound DecimalValue. Missing, malformed or
+// out-of-range values fail with apperror.ErrInvalidArgument; no value is
+// ever rounded.
+func FromDecimal(value *v1.DecimalValue) (decimal.Decimal, error) {
+	if value == nil || value.Value == "" {
+		return decimal.Decimal{}, apperror.InvalidArgumentf("decimal value is required")
+	}
+	if !plainDecimal.MatchString(value.Value) {
+		return decimal.Decimal{}, apperror.InvalidArgumentf("malformed decimal %q", value.Value)
+	}
+
+	integer, fraction, _ := strings.Cut(strings.TrimPrefix(value.Value, "-"), ".")
+	integer = strings.TrimLeft(integer, "0")
+	fraction = strings.TrimRight(fraction, "0")
+	if len(integer) > MaxDecimalIntegerDigits {
+		return decimal.Decimal{}, apperror.InvalidArgumentf("decimal %q has more than %d integer digits", value.Value, MaxDecimalIntegerDigits)
+	}
+	if len(fraction) > MaxDecimalScale {
+		return decimal.Decimal{}, apperror.InvalidArgumentf("decimal %q has more than %d fraction digits", value.Value, MaxDecimalScale)
+	}
+
+	d, err := decimal.NewFromString(value.Value)
+	if err != nil {
+		return decimal.Decimal{}, apperror.InvalidArgumentf("malformed decimal %q", value.Value)
+	}
+	return d, nil
+}
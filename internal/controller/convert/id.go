@@ -0,0 +1,89 @@
+package convert
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/idcodec"
+)
+
+// IDs maps internal ids to and from the representations clients see,
+// according to the configured idcodec.Mode.
+type IDs struct {
+	codec idcodec.Codec
+	mode  idcodec.Mode
+}
+
+func NewIDs(codec idcodec.Codec, mode idcodec.Mode) IDs {
+	return IDs{codec: codec, mode: mode}
+}
+
+// Out returns the values for a message's int64 id field and its public id
+// field. The field not used by the mode is left empty.
+func (m IDs) Out(id int64) (int64, string) {
+	switch m.mode {
+	case idcodec.ModeOpaque:
+		return 0, m.codec.Encode(id)
+	case idcodec.ModeDual:
+		return id, m.codec.Encode(id)
+	}
+	return id, ""
+}
+
+// String formats id for error messages the way clients refer to it, so
+// opaque mode does not leak internal ids through errors.
+func (m IDs) String(id int64) string {
+	if m.mode == idcodec.ModeOpaque {
+		return m.codec.Encode(id)
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// In resolves the id a request names through its int64 field, id, or its
+// public id field, publicId. field and publicField name the two fields in
+// the *apperror.ValidationError it fails with. It returns 0 when neither is
+// set.
+func (m IDs) In(field, publicField string, id int64, publicId string) (int64, error) {
+	switch m.mode {
+	case idcodec.ModeInt64:
+		if publicId != "" {
+			return 0, violation(publicField, "not_enabled", "is not enabled, use %s", field)
+		}
+		return id, nil
+	case idcodec.ModeOpaque:
+		if id != 0 {
+			return 0, violation(field, "not_accepted", "is not accepted, use %s", publicField)
+		}
+	}
+	if publicId == "" {
+		return id, nil
+	}
+
+	decoded, err := m.codec.Decode(publicId)
+	if err != nil {
+		return 0, violation(publicField, "malformed", "%q is not a valid id", publicId)
+	}
+	if id != 0 && id != decoded {
+		return 0, violation(field, "conflict", "and %s name different ids", publicField)
+	}
+	return decoded, nil
+}
+
+// Require is In for an id the request must name: a missing, invalid or
+// non-positive id is recorded in violations rather than returned, so the
+// caller can go on checking the request's other fields.
+func (m IDs) Require(violations *apperror.ValidationErrors, field, publicField string, id int64, publicId string) int64 {
+	resolved, err := m.In(field, publicField, id, publicId)
+	switch {
+	case err != nil:
+		violations.Merge(err)
+	case resolved <= 0:
+		violations.Add(field, "gt", "must be greater than 0")
+	}
+	return resolved
+}
+
+func violation(field, reason, format string, args ...any) error {
+	return apperror.FieldError(field, reason, fmt.Sprintf(format, args...))
+}
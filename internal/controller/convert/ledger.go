@@ -0,0 +1,70 @@
+package convert
+
+import (
+	"fmt"
+
+	"github.com/jt828/go-grpc-template/pkg/model"
+	v1 "github.com/jt828/go-grpc-template/proto"
+)
+
+var transactionTypes = map[model.TransactionType]v1.TransactionType{
+	model.TransactionTypeDeposit:  v1.TransactionType_TRANSACTION_TYPE_DEPOSIT,
+	model.TransactionTypeWithdraw: v1.TransactionType_TRANSACTION_TYPE_WITHDRAW,
+	model.TransactionTypeTransfer: v1.TransactionType_TRANSACTION_TYPE_TRANSFER,
+}
+
+func TransactionType(transactionType model.TransactionType) v1.TransactionType {
+	return transactionTypes[transactionType]
+}
+
+// FromTransactionType reports false for TRANSACTION_TYPE_UNSPECIFIED and
+// unknown values.
+func FromTransactionType(transactionType v1.TransactionType) (model.TransactionType, bool) {
+	for t, p := range transactionTypes {
+		if p == transactionType {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// Ledger maps ledger to its proto message, attaching user as the owner
+// summary when it is not nil.
+func Ledger(ledger *model.Ledger, user *model.User) *v1.Ledger {
+	result := &v1.Ledger{
+		Id:              ledger.Id,
+		UserId:          ledger.UserId,
+		TransactionType: string(ledger.TransactionType),
+		Type:            TransactionType(ledger.TransactionType),
+		Token:           ledger.Token,
+		Amount:          Decimal(ledger.Amount),
+		CreatedAt:       Timestamp(ledger.CreatedAt),
+	}
+	if user != nil {
+		result.User = UserSummary(user)
+	}
+	return result
+}
+
+// FromLedger maps the ledger fields of a proto message back to the domain
+// model. The owner summary is not part of the ledger and is ignored. The
+// transaction type is read from type, falling back to the deprecated
+// transaction_type string.
+func FromLedger(ledger *v1.Ledger) (*model.Ledger, error) {
+	amount, err := FromDecimal(ledger.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("amount: %w", err)
+	}
+	transactionType, ok := FromTransactionType(ledger.Type)
+	if !ok {
+		transactionType = model.TransactionType(ledger.TransactionType)
+	}
+	return &model.Ledger{
+		Id:              ledger.Id,
+		UserId:          ledger.UserId,
+		TransactionType: transactionType,
+		Token:           ledger.Token,
+		Amount:          amount,
+		CreatedAt:       Time(ledger.CreatedAt),
+	}, nil
+}
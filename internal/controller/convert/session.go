@@ -0,0 +1,30 @@
+package convert
+
+import (
+	"github.com/jt828/go-grpc-template/pkg/model"
+	v1 "github.com/jt828/go-grpc-template/proto"
+)
+
+// Session maps session to its proto message. Only active sessions are
+// listed, so revocation is not exposed.
+func Session(session *model.Session) *v1.Session {
+	return &v1.Session{
+		Id:         session.Id,
+		UserAgent:  session.UserAgent,
+		Ip:         session.Ip,
+		CreatedAt:  Timestamp(session.CreatedAt),
+		LastSeenAt: Timestamp(session.LastSeenAt),
+	}
+}
+
+// FromSession maps message back to an active session. The message does not
+// carry its user, so UserId is left zero.
+func FromSession(session *v1.Session) *model.Session {
+	return &model.Session{
+		Id:         session.Id,
+		UserAgent:  session.UserAgent,
+		Ip:         session.Ip,
+		CreatedAt:  Time(session.CreatedAt),
+		LastSeenAt: Time(session.LastSeenAt),
+	}
+}
@@ -0,0 +1,121 @@
+package convert
+
+import (
+	"github.com/jt828/go-grpc-template/pkg/model"
+	v1 "github.com/jt828/go-grpc-template/proto"
+)
+
+var userStatuses = map[model.UserStatus]v1.UserStatus{
+	model.UserStatusActive:    v1.UserStatus_USER_STATUS_ACTIVE,
+	model.UserStatusSuspended: v1.UserStatus_USER_STATUS_SUSPENDED,
+	model.UserStatusDeleted:   v1.UserStatus_USER_STATUS_DELETED,
+}
+
+func UserStatus(status model.UserStatus) v1.UserStatus {
+	return userStatuses[status]
+}
+
+// FromUserStatus reports false for USER_STATUS_UNSPECIFIED and unknown
+// values.
+func FromUserStatus(status v1.UserStatus) (model.UserStatus, bool) {
+	for s, p := range userStatuses {
+		if p == status {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// User maps user to its proto message. The password is never exposed.
+func User(user *model.User) *v1.User {
+	return &v1.User{
+		Id:            user.Id,
+		Email:         user.Email,
+		Username:      user.Username,
+		CreatedAt:     Timestamp(user.CreatedAt),
+		UpdatedAt:     Timestamp(user.UpdatedAt),
+		Status:        UserStatus(user.Status),
+		Version:       user.Version,
+		EmailVerified: user.EmailVerified(),
+	}
+}
+
+func FromUser(user *v1.User) *model.User {
+	status, _ := FromUserStatus(user.Status)
+	return &model.User{
+		Id:        user.Id,
+		Email:     user.Email,
+		Username:  user.Username,
+		CreatedAt: Time(user.CreatedAt),
+		UpdatedAt: Time(user.UpdatedAt),
+		Status:    status,
+		Version:   user.Version,
+	}
+}
+
+func UserSummary(user *model.User) *v1.UserSummary {
+	return &v1.UserSummary{
+		Id:       user.Id,
+		Username: user.Username,
+		Status:   UserStatus(user.Status),
+	}
+}
+
+// The single-user responses carry the same fields as User; they are built
+// from it so the two cannot drift apart.
+
+func GetUserByIdResponse(user *model.User) *v1.GetUserByIdResponse {
+	u := User(user)
+	return &v1.GetUserByIdResponse{
+		Id:            u.Id,
+		Email:         u.Email,
+		Username:      u.Username,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+		Status:        u.Status,
+		Version:       u.Version,
+		EmailVerified: u.EmailVerified,
+	}
+}
+
+func CreateUserResponse(user *model.User) *v1.CreateUserResponse {
+	u := User(user)
+	return &v1.CreateUserResponse{
+		Id:            u.Id,
+		Email:         u.Email,
+		Username:      u.Username,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+		Status:        u.Status,
+		Version:       u.Version,
+		EmailVerified: u.EmailVerified,
+	}
+}
+
+func UpdateUserStatusResponse(user *model.User) *v1.UpdateUserStatusResponse {
+	u := User(user)
+	return &v1.UpdateUserStatusResponse{
+		Id:            u.Id,
+		Email:         u.Email,
+		Username:      u.Username,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+		Status:        u.Status,
+		Version:       u.Version,
+		EmailVerified: u.EmailVerified,
+	}
+}
+
+func UpdateUserResponse(user *model.User) *v1.UpdateUserResponse {
+	u := User(user)
+	return &v1.UpdateUserResponse{
+		Id:            u.Id,
+		Email:         u.Email,
+		Username:      u.Username,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+		Status:        u.Status,
+		Version:       u.Version,
+		EmailVerified: u.EmailVerified,
+	}
+}
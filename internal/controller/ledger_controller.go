@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jt828/go-grpc-template/internal/authz"
+	"github.com/jt828/go-grpc-template/internal/controller/convert"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/pagination"
+	v1 "github.com/jt828/go-grpc-template/proto"
+)
+
+// PageSizeLimit caps how many entries one list response may hold. A caller
+// gets the largest cap of the roles it holds in ByRole, or Default when none
+// is listed, so e.g. reporting jobs can be allowed larger pages than
+// dashboards.
+type PageSizeLimit struct {
+	Default int
+	ByRole  map[string]int
+}
+
+// For returns the cap for a caller holding roles.
+func (l PageSizeLimit) For(roles []string) int {
+	limit, found := l.Default, false
+	for _, role := range roles {
+		if size, ok := l.ByRole[role]; ok && (!found || size > limit) {
+			limit, found = size, true
+		}
+	}
+	return limit
+}
+
+type LedgerController struct {
+	v1.UnimplementedLedgerServiceServer
+	ledgerService service.LedgerService
+	ids           convert.IDs
+	pageSize      PageSizeLimit
+}
+
+func NewLedgerController(ledgerService service.LedgerService, ids convert.IDs, pageSize PageSizeLimit) *LedgerController {
+	return &LedgerController{ledgerService: ledgerService, ids: ids, pageSize: pageSize}
+}
+
+func (ctrl *LedgerController) ListLedgers(
+	ctx context.Context,
+	request *v1.ListLedgersRequest,
+) (*v1.ListLedgersResponse, error) {
+	var violations apperror.ValidationErrors
+	userId, err := ctrl.ids.In("user_id", "user_public_id", request.UserId, request.UserPublicId)
+	if !violations.Merge(err) {
+		return nil, err
+	}
+	transactionType := transactionTypeFilter(&violations, request.Type, request.TransactionType)
+	maxPageSize := ctrl.pageSize.For(authz.RolesFromContext(ctx))
+	pageSize := int(request.PageSize)
+	if pageSize > maxPageSize {
+		violations.Add("page_size", "max_page_size", fmt.Sprintf("must be at most %d", maxPageSize))
+	}
+	params := service.GetParams{
+		UserIdEq:          userId,
+		TransactionTypeEq: transactionType,
+		TokenEq:           request.Token,
+		AfterId:           request.AfterId,
+		NewestFirst:       request.NewestFirst,
+		Limit:             pageSize,
+	}
+	pageFrom(&violations, &params, request)
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+	if pageSize == 0 {
+		// One more than allowed, to tell a full result from a truncated one
+		// without counting every match.
+		params.Limit = maxPageSize + 1
+	}
+
+	var response *v1.ListLedgersResponse
+	var lastId int64
+	if !request.IncludeUser {
+		ledgers, err := ctrl.ledgerService.GetLedgers(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		response = &v1.ListLedgersResponse{Ledgers: make([]*v1.Ledger, len(ledgers))}
+		for i, ledger := range ledgers {
+			response.Ledgers[i] = ctrl.ledger(ledger, nil)
+			lastId = ledger.Id
+		}
+	} else {
+		entries, err := ctrl.ledgerService.GetLedgersWithUsers(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		response = &v1.ListLedgersResponse{Ledgers: make([]*v1.Ledger, len(entries))}
+		for i, entry := range entries {
+			response.Ledgers[i] = ctrl.ledger(entry.Ledger, entry.User)
+			lastId = entry.Ledger.Id
+		}
+	}
+
+	switch {
+	case pageSize == 0 && len(response.Ledgers) > maxPageSize:
+		violations.Add("page_size", "max_page_size", fmt.Sprintf("is required, more than %d ledgers match: page through them with page_size and after_id, or narrow the filters", maxPageSize))
+		return nil, violations.Err()
+	case pageSize > 0 && len(response.Ledgers) == pageSize:
+		if !params.NewestFirst {
+			response.NextAfterId = lastId
+		}
+		response.NextPageToken = pagination.Cursor{LastId: lastId, Desc: params.NewestFirst}.Encode()
+	}
+	return response, nil
+}
+
+// pageFrom sets where params' page starts from the request's after_id or
+// page_token. Invalid or conflicting cursors are recorded in violations.
+func pageFrom(violations *apperror.ValidationErrors, params *service.GetParams, request *v1.ListLedgersRequest) {
+	if request.PageToken == "" {
+		if request.NewestFirst && request.AfterId != 0 {
+			violations.Add("after_id", "conflict", "only pages in id order; use page_token with newest_first")
+		}
+		return
+	}
+	cursor, err := pagination.Decode(request.PageToken)
+	switch {
+	case err != nil:
+		violations.Add("page_token", "malformed", "is not a token returned by ListLedgers")
+	case request.AfterId != 0:
+		violations.Add("page_token", "conflict", "cannot be combined with after_id")
+	case cursor.Desc != request.NewestFirst:
+		violations.Add("page_token", "conflict", "was issued for the other order: repeat newest_first as on the first page")
+	case cursor.Desc:
+		params.BeforeId = cursor.LastId
+	default:
+		params.AfterId = cursor.LastId
+	}
+}
+
+// transactionTypeFilter resolves the transaction type a request filters by
+// from its type field and the deprecated transaction_type string older
+// clients send. Either may be unset; when both are set they must agree.
+// Invalid values are recorded in violations.
+func transactionTypeFilter(violations *apperror.ValidationErrors, typ v1.TransactionType, legacy string) model.TransactionType {
+	var fromType model.TransactionType
+	if typ != v1.TransactionType_TRANSACTION_TYPE_UNSPECIFIED {
+		var ok bool
+		if fromType, ok = convert.FromTransactionType(typ); !ok {
+			violations.Add("type", "enum", fmt.Sprintf("%d is not a known transaction type", typ))
+			return ""
+		}
+	}
+	if legacy == "" {
+		return fromType
+	}
+
+	fromLegacy := model.TransactionType(legacy)
+	if !fromLegacy.IsValid() {
+		violations.Add("transaction_type", "enum", fmt.Sprintf("%q must be one of %v", legacy, model.TransactionTypes))
+		return ""
+	}
+	if fromType != "" && fromType != fromLegacy {
+		violations.Add("transaction_type", "conflict", "disagrees with type")
+		return ""
+	}
+	return fromLegacy
+}
+
+func (ctrl *LedgerController) ledger(ledger *model.Ledger, user *model.User) *v1.Ledger {
+	result := convert.Ledger(ledger, user)
+	result.Id, result.PublicId = ctrl.ids.Out(ledger.Id)
+	result.UserId, result.UserPublicId = ctrl.ids.Out(ledger.UserId)
+	if result.User != nil {
+		result.User.Id, result.User.PublicId = ctrl.ids.Out(user.Id)
+	}
+	return result
+}
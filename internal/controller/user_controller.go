@@ -3,62 +3,127 @@ package controller
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 
+	"github.com/jt828/go-grpc-template/internal/controller/convert"
+	"github.com/jt828/go-grpc-template/internal/interceptor"
 	"github.com/jt828/go-grpc-template/internal/service"
 	"github.com/jt828/go-grpc-template/pkg/apperror"
 	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/password"
 	v1 "github.com/jt828/go-grpc-template/proto"
-	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 )
 
+const maxGetUsersByIds = 100
+
 type UserController struct {
 	v1.UnimplementedUserServiceServer
 	userService service.UserService
+	ids         convert.IDs
+	passwords   password.Policy
+	// twoFactor is nil when no field encryption key is configured to store
+	// TOTP secrets with.
+	twoFactor    service.TwoFactorService
+	sessions     service.SessionService
+	verification service.EmailVerificationService
+	resets       service.PasswordResetService
+	exports      service.DataExportService
 }
 
-func NewUserController(userService service.UserService) *UserController {
-	return &UserController{userService: userService}
+func NewUserController(userService service.UserService, ids convert.IDs, passwords password.Policy, twoFactor service.TwoFactorService, sessions service.SessionService, verification service.EmailVerificationService, resets service.PasswordResetService, exports service.DataExportService) *UserController {
+	return &UserController{userService: userService, ids: ids, passwords: passwords, twoFactor: twoFactor, sessions: sessions, verification: verification, resets: resets, exports: exports}
 }
 
 func (ctrl *UserController) GetUserById(
 	ctx context.Context,
 	request *v1.GetUserByIdRequest,
 ) (*v1.GetUserByIdResponse, error) {
-	if request.Id <= 0 {
-		return nil, fmt.Errorf("id must be greater than 0: %w", apperror.ErrInvalidArgument)
+	var violations apperror.ValidationErrors
+	id := ctrl.ids.Require(&violations, "id", "public_id", request.Id, request.PublicId)
+	if err := violations.Err(); err != nil {
+		return nil, err
 	}
-	user, err := ctrl.userService.GetUser(ctx, request.Id)
+	user, err := ctrl.userService.GetUser(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
-		return nil, fmt.Errorf("user %d: %w", request.Id, apperror.ErrNotFound)
+		return nil, apperror.UserNotFound(ctrl.ids.String(id))
 	}
 
-	return &v1.GetUserByIdResponse{
-		Id:        user.Id,
-		Email:     user.Email,
-		Username:  user.Username,
-		CreatedAt: timestamppb.New(user.CreatedAt),
-		UpdatedAt: timestamppb.New(user.UpdatedAt),
-	}, nil
+	response := convert.GetUserByIdResponse(user)
+	response.Id, response.PublicId = ctrl.ids.Out(user.Id)
+	return response, nil
 }
 
-func (ctrl *UserController) CreateUser(
+func (ctrl *UserController) GetUsersByIds(
 	ctx context.Context,
-	request *v1.CreateUserRequest,
-) (*v1.CreateUserResponse, error) {
-	if request.IdempotencyId <= 0 {
-		return nil, fmt.Errorf("idempotency_id must be greater than 0: %w", apperror.ErrInvalidArgument)
+	request *v1.GetUsersByIdsRequest,
+) (*v1.GetUsersByIdsResponse, error) {
+	requested := len(request.Ids) + len(request.PublicIds)
+	if requested == 0 {
+		return nil, apperror.FieldError("ids", "required", "is required")
+	}
+	if requested > maxGetUsersByIds {
+		return nil, apperror.FieldError("ids", "max_items", fmt.Sprintf("together with public_ids must have at most %d items", maxGetUsersByIds))
+	}
+
+	var violations apperror.ValidationErrors
+	resolved := make([]int64, 0, requested)
+	for i, id := range request.Ids {
+		field := fmt.Sprintf("ids[%d]", i)
+		resolved = append(resolved, ctrl.ids.Require(&violations, field, "public_ids", id, ""))
+	}
+	for i, publicId := range request.PublicIds {
+		field := fmt.Sprintf("public_ids[%d]", i)
+		resolved = append(resolved, ctrl.ids.Require(&violations, "ids", field, 0, publicId))
+	}
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int64]struct{}, requested)
+	ids := make([]int64, 0, requested)
+	for _, id := range resolved {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	result, err := ctrl.userService.GetUsersByIds(ctx, ids)
+	if err != nil {
+		return nil, err
 	}
-	if request.Email == "" {
-		return nil, fmt.Errorf("email is required: %w", apperror.ErrInvalidArgument)
+
+	response := &v1.GetUsersByIdsResponse{Users: make([]*v1.User, len(result.Users))}
+	for i, user := range result.Users {
+		response.Users[i] = convert.User(user)
+		response.Users[i].Id, response.Users[i].PublicId = ctrl.ids.Out(user.Id)
 	}
-	if request.Username == "" {
-		return nil, fmt.Errorf("username is required: %w", apperror.ErrInvalidArgument)
+	for _, id := range result.MissingIds {
+		missingId, missingPublicId := ctrl.ids.Out(id)
+		if missingId != 0 {
+			response.MissingIds = append(response.MissingIds, missingId)
+		}
+		if missingPublicId != "" {
+			response.MissingPublicIds = append(response.MissingPublicIds, missingPublicId)
+		}
 	}
-	if request.Password == "" {
-		return nil, fmt.Errorf("password is required: %w", apperror.ErrInvalidArgument)
+	return response, nil
+}
+
+func (ctrl *UserController) CreateUser(
+	ctx context.Context,
+	request *v1.CreateUserRequest,
+) (*v1.CreateUserResponse, error) {
+	if violations := ctrl.passwords.Check(ctx, request.Password, request.Username, request.Email); len(violations) > 0 {
+		return nil, passwordViolations("password", violations)
 	}
 
 	user := &model.User{
@@ -72,11 +137,341 @@ func (ctrl *UserController) CreateUser(
 		return nil, err
 	}
 
-	return &v1.CreateUserResponse{
-		Id:        createdUser.Id,
-		Email:     createdUser.Email,
-		Username:  createdUser.Username,
-		CreatedAt: timestamppb.New(createdUser.CreatedAt),
-		UpdatedAt: timestamppb.New(createdUser.UpdatedAt),
-	}, nil
+	response := convert.CreateUserResponse(createdUser)
+	response.Id, response.PublicId = ctrl.ids.Out(createdUser.Id)
+	return response, nil
+}
+
+func (ctrl *UserController) UpdateUserStatus(
+	ctx context.Context,
+	request *v1.UpdateUserStatusRequest,
+) (*v1.UpdateUserStatusResponse, error) {
+	var violations apperror.ValidationErrors
+	id := ctrl.ids.Require(&violations, "id", "public_id", request.Id, request.PublicId)
+	status, ok := convert.FromUserStatus(request.Status)
+	if !ok {
+		violations.Add("status", "required", "is required")
+	}
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	user, err := ctrl.userService.UpdateUserStatus(ctx, id, status, request.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, apperror.UserNotFound(ctrl.ids.String(id))
+	}
+
+	response := convert.UpdateUserStatusResponse(user)
+	response.Id, response.PublicId = ctrl.ids.Out(user.Id)
+	return response, nil
+}
+
+// updatableUserFields are the update_mask paths UpdateUser accepts.
+var updatableUserFields = []string{"email", "username"}
+
+func (ctrl *UserController) UpdateUser(
+	ctx context.Context,
+	request *v1.UpdateUserRequest,
+) (*v1.UpdateUserResponse, error) {
+	id, err := ctrl.callerUser(ctx, request.Id, request.PublicId)
+	if err != nil {
+		return nil, err
+	}
+	var violations apperror.ValidationErrors
+	update := service.UserUpdate{ExpectedVersion: request.ExpectedVersion}
+	if request.ExpectedUpdatedAt != nil {
+		update.ExpectedUpdatedAt = convert.Time(request.ExpectedUpdatedAt)
+	}
+	if len(request.GetUpdateMask().GetPaths()) == 0 {
+		violations.Add("update_mask", "required", "is required")
+	}
+	for _, path := range request.GetUpdateMask().GetPaths() {
+		switch path {
+		case "email":
+			if request.Email == "" {
+				violations.Add("email", "required", "is required")
+			}
+			update.Email = &request.Email
+		case "username":
+			if request.Username == "" {
+				violations.Add("username", "required", "is required")
+			}
+			update.Username = &request.Username
+		default:
+			violations.Add("update_mask", "unknown_field", fmt.Sprintf("names %q, which is not one of %s", path, strings.Join(updatableUserFields, ", ")))
+		}
+	}
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	user, err := ctrl.userService.UpdateUser(ctx, id, update)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, apperror.UserNotFound(ctrl.ids.String(id))
+	}
+
+	response := convert.UpdateUserResponse(user)
+	response.Id, response.PublicId = ctrl.ids.Out(user.Id)
+	return response, nil
+}
+
+func (ctrl *UserController) Enroll2FA(
+	ctx context.Context,
+	request *v1.Enroll2FARequest,
+) (*v1.Enroll2FAResponse, error) {
+	id, err := ctrl.twoFactorUser(ctx, request.Id, request.PublicId)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollment, err := ctrl.twoFactor.Enroll(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment == nil {
+		return nil, apperror.UserNotFound(ctrl.ids.String(id))
+	}
+
+	return &v1.Enroll2FAResponse{Secret: enrollment.Secret, OtpauthUri: enrollment.URI}, nil
+}
+
+func (ctrl *UserController) Verify2FA(
+	ctx context.Context,
+	request *v1.Verify2FARequest,
+) (*v1.Verify2FAResponse, error) {
+	id, err := ctrl.twoFactorUser(ctx, request.Id, request.PublicId)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := ctrl.twoFactor.Verify(ctx, id, peerIP(ctx), request.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.Verify2FAResponse{RecoveryCodes: codes}, nil
+}
+
+func (ctrl *UserController) Disable2FA(
+	ctx context.Context,
+	request *v1.Disable2FARequest,
+) (*v1.Disable2FAResponse, error) {
+	id, err := ctrl.twoFactorUser(ctx, request.Id, request.PublicId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctrl.twoFactor.Disable(ctx, id, peerIP(ctx), request.Code); err != nil {
+		return nil, err
+	}
+
+	return &v1.Disable2FAResponse{}, nil
+}
+
+func (ctrl *UserController) ListSessions(
+	ctx context.Context,
+	request *v1.ListSessionsRequest,
+) (*v1.ListSessionsResponse, error) {
+	id, err := ctrl.callerUser(ctx, request.Id, request.PublicId)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := ctrl.sessions.List(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &v1.ListSessionsResponse{Sessions: make([]*v1.Session, len(sessions))}
+	for i, session := range sessions {
+		response.Sessions[i] = convert.Session(session)
+		response.Sessions[i].Id, response.Sessions[i].PublicId = ctrl.ids.Out(session.Id)
+	}
+	return response, nil
+}
+
+func (ctrl *UserController) RevokeSession(
+	ctx context.Context,
+	request *v1.RevokeSessionRequest,
+) (*v1.RevokeSessionResponse, error) {
+	id, err := ctrl.callerUser(ctx, request.Id, request.PublicId)
+	if err != nil {
+		return nil, err
+	}
+	var violations apperror.ValidationErrors
+	sessionId := ctrl.ids.Require(&violations, "session_id", "session_public_id", request.SessionId, request.SessionPublicId)
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	revoked, err := ctrl.sessions.Revoke(ctx, id, sessionId, requestDevice(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		return nil, apperror.NotFoundf("session %s", ctrl.ids.String(sessionId))
+	}
+
+	return &v1.RevokeSessionResponse{}, nil
+}
+
+func (ctrl *UserController) ChangePassword(
+	ctx context.Context,
+	request *v1.ChangePasswordRequest,
+) (*v1.ChangePasswordResponse, error) {
+	id, err := ctrl.callerUser(ctx, request.Id, request.PublicId)
+	if err != nil {
+		return nil, err
+	}
+	if request.NewPassword == request.CurrentPassword {
+		return nil, apperror.FieldError("new_password", "unchanged", "must differ from the current password")
+	}
+
+	// The policy rejects passwords built from the user's own name or email.
+	user, err := ctrl.userService.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, apperror.UserNotFound(ctrl.ids.String(id))
+	}
+	if violations := ctrl.passwords.Check(ctx, request.NewPassword, user.Username, user.Email); len(violations) > 0 {
+		return nil, passwordViolations("new_password", violations)
+	}
+
+	change, err := ctrl.userService.ChangePassword(ctx, id, request.CurrentPassword, request.NewPassword, requestDevice(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if change == nil {
+		return nil, apperror.UserNotFound(ctrl.ids.String(id))
+	}
+
+	return &v1.ChangePasswordResponse{RevokedSessions: int32(change.RevokedSessions)}, nil
+}
+
+func (ctrl *UserController) SendVerificationEmail(
+	ctx context.Context,
+	request *v1.SendVerificationEmailRequest,
+) (*v1.SendVerificationEmailResponse, error) {
+	id, err := ctrl.callerUser(ctx, request.Id, request.PublicId)
+	if err != nil {
+		return nil, err
+	}
+
+	sent, err := ctrl.verification.Send(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !sent {
+		return nil, apperror.UserNotFound(ctrl.ids.String(id))
+	}
+
+	return &v1.SendVerificationEmailResponse{}, nil
+}
+
+func (ctrl *UserController) VerifyEmail(
+	ctx context.Context,
+	request *v1.VerifyEmailRequest,
+) (*v1.VerifyEmailResponse, error) {
+	user, err := ctrl.verification.Verify(ctx, request.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &v1.VerifyEmailResponse{User: convert.User(user)}
+	response.User.Id, response.User.PublicId = ctrl.ids.Out(user.Id)
+	return response, nil
+}
+
+func (ctrl *UserController) RequestPasswordReset(
+	ctx context.Context,
+	request *v1.RequestPasswordResetRequest,
+) (*v1.RequestPasswordResetResponse, error) {
+	if err := ctrl.resets.Request(ctx, request.Email); err != nil {
+		return nil, err
+	}
+
+	return &v1.RequestPasswordResetResponse{}, nil
+}
+
+func (ctrl *UserController) ConfirmPasswordReset(
+	ctx context.Context,
+	request *v1.ConfirmPasswordResetRequest,
+) (*v1.ConfirmPasswordResetResponse, error) {
+	change, err := ctrl.resets.Confirm(ctx, request.Token, request.NewPassword, requestDevice(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.ConfirmPasswordResetResponse{RevokedSessions: int32(change.RevokedSessions)}, nil
+}
+
+// twoFactorUser resolves the user of a two-factor request, failing when
+// two-factor authentication is not configured.
+func (ctrl *UserController) twoFactorUser(ctx context.Context, id int64, publicId string) (int64, error) {
+	if ctrl.twoFactor == nil {
+		return 0, apperror.FailedPreconditionf("two-factor authentication is not configured")
+	}
+	return ctrl.callerUser(ctx, id, publicId)
+}
+
+// callerUser resolves the user a self-service request names and requires it
+// to be the authenticated user making the request, so users can only act on
+// their own account.
+func (ctrl *UserController) callerUser(ctx context.Context, id int64, publicId string) (int64, error) {
+	var violations apperror.ValidationErrors
+	id = ctrl.ids.Require(&violations, "id", "public_id", id, publicId)
+	if err := violations.Err(); err != nil {
+		return 0, err
+	}
+	caller, ok := interceptor.CallerFromContext(ctx)
+	if !ok || caller.Kind != interceptor.CallerKindUser {
+		return 0, apperror.Unauthenticatedf("acting on an account requires a signed-in user")
+	}
+	if caller.Id != strconv.FormatInt(id, 10) {
+		return 0, apperror.PermissionDeniedf("users can only act on their own account")
+	}
+	return id, nil
+}
+
+// peerIP is the caller's IP, which code checks are throttled by alongside
+// the user.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// requestDevice describes the device making the request for the session
+// audit trail.
+func requestDevice(ctx context.Context) service.Device {
+	device := service.Device{Ip: peerIP(ctx)}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if userAgent := md.Get("user-agent"); len(userAgent) > 0 {
+			device.UserAgent = userAgent[0]
+		}
+	}
+	return device
+}
+
+// passwordViolations reports a rejected password against field.
+func passwordViolations(field string, violations []password.Violation) error {
+	var err apperror.ValidationErrors
+	for _, v := range violations {
+		err.Add(field, v.Rule, v.Description)
+	}
+	return err.Err()
 }
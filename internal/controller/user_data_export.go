@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/jt828/go-grpc-template/internal/controller/convert"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/idempotency"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// exportChunkSize is how many bytes of an export ExportUserData buffers
+// before sending them as one response.
+const exportChunkSize = 64 << 10
+
+func (ctrl *UserController) ExportUserData(
+	request *v1.ExportUserDataRequest,
+	stream grpc.ServerStreamingServer[v1.ExportUserDataResponse],
+) error {
+	id, err := ctrl.callerUser(stream.Context(), request.Id, request.PublicId)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriterSize(chunkWriter{stream: stream}, exportChunkSize)
+	found, err := ctrl.exports.ExportUserData(stream.Context(), id, &userDataWriter{w: w, ids: ctrl.ids})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return apperror.UserNotFound(ctrl.ids.String(id))
+	}
+	return w.Flush()
+}
+
+// chunkWriter sends what is written to it as ExportUserDataResponse chunks.
+type chunkWriter struct {
+	stream grpc.ServerStreamingServer[v1.ExportUserDataResponse]
+}
+
+func (w chunkWriter) Write(p []byte) (int, error) {
+	// Send has serialized p by the time it returns, so the caller may
+	// reuse it.
+	if err := w.stream.Send(&v1.ExportUserDataResponse{Chunk: p}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// userDataWriter writes a user data export as newline-delimited JSON, one
+// {"<kind>": message} object per line, with ids given out as in every other
+// response.
+type userDataWriter struct {
+	w   io.Writer
+	ids convert.IDs
+}
+
+func (e *userDataWriter) Profile(user *model.User) error {
+	result := convert.User(user)
+	result.Id, result.PublicId = e.ids.Out(user.Id)
+	return e.line("user", result)
+}
+
+func (e *userDataWriter) Ledgers(ledgers []*model.Ledger) error {
+	for _, ledger := range ledgers {
+		result := convert.Ledger(ledger, nil)
+		result.Id, result.PublicId = e.ids.Out(ledger.Id)
+		result.UserId, result.UserPublicId = e.ids.Out(ledger.UserId)
+		if err := e.line("ledger", result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *userDataWriter) IdempotencyRecords(records []*idempotency.Record) error {
+	for _, record := range records {
+		result := convert.IdempotencyRecord(record)
+		result.ReferenceId, _ = e.ids.Out(record.ReferenceId)
+		if err := e.line("idempotency_record", result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *userDataWriter) line(kind string, message proto.Message) error {
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(e.w, "{%q:%s}\n", kind, data)
+	return err
+}
@@ -0,0 +1,184 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// redactedValue replaces string fields marked debug_redact in logged payloads.
+const redactedValue = "[REDACTED]"
+
+// AccessLogInterceptors return unary and stream interceptors that log an
+// "rpc completed" entry per call at info level, with its method, peer IP,
+// status code, latency and request and response sizes in bytes. Calls to the
+// methods, or service prefixes ending in "/", listed in excluded, such as
+// health checks, are not logged.
+//
+// With logPayloads set, requests and responses are also logged as JSON at
+// debug level, so they are only written while log's module is at debug.
+// Fields marked [debug_redact = true] in the proto definitions, such as
+// passwords and two-factor codes, are logged as "[REDACTED]" or omitted.
+//
+// Register them after RequestIdInterceptor, so entries carry the request id,
+// and before ErrorInterceptor, so they see the status code returned.
+func AccessLogInterceptors(log observability.Logger, excluded []string, logPayloads bool) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if methodMatches(info.FullMethod, excluded) {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		log := observability.LoggerFromContext(ctx, log)
+		log.Info("rpc completed",
+			observability.String("method", info.FullMethod),
+			observability.String("peer", peerHost(ctx)),
+			observability.String("code", status.Code(err).String()),
+			observability.Duration("duration", time.Since(start)),
+			observability.Int("request_bytes", messageSize(req)),
+			observability.Int("response_bytes", messageSize(resp)),
+		)
+		if logPayloads {
+			fields := []observability.Field{observability.String("method", info.FullMethod), observability.String("request", payload(req))}
+			if err == nil {
+				fields = append(fields, observability.String("response", payload(resp)))
+			}
+			log.Debug("rpc payload", fields...)
+		}
+		return resp, err
+	}
+
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if methodMatches(info.FullMethod, excluded) {
+			return handler(srv, ss)
+		}
+
+		ctx := ss.Context()
+		counted := &accessLogStream{
+			ServerStream: ss,
+			log:          observability.LoggerFromContext(ctx, log),
+			method:       info.FullMethod,
+			logPayloads:  logPayloads,
+		}
+		start := time.Now()
+		err := handler(srv, counted)
+		counted.log.Info("rpc completed",
+			observability.String("method", info.FullMethod),
+			observability.String("peer", peerHost(ctx)),
+			observability.String("code", status.Code(err).String()),
+			observability.Duration("duration", time.Since(start)),
+			observability.Int("messages_received", counted.received),
+			observability.Int("messages_sent", counted.sent),
+			observability.Int("request_bytes", counted.receivedBytes),
+			observability.Int("response_bytes", counted.sentBytes),
+		)
+		return err
+	}
+
+	return unary, stream
+}
+
+// accessLogStream counts the messages and bytes a stream carries, and logs
+// each message when logPayloads is set.
+type accessLogStream struct {
+	grpc.ServerStream
+	log           observability.Logger
+	method        string
+	logPayloads   bool
+	received      int
+	sent          int
+	receivedBytes int
+	sentBytes     int
+}
+
+func (s *accessLogStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	s.received++
+	s.receivedBytes += messageSize(m)
+	if s.logPayloads {
+		s.log.Debug("rpc message received", observability.String("method", s.method), observability.String("request", payload(m)))
+	}
+	return nil
+}
+
+func (s *accessLogStream) SendMsg(m any) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	s.sent++
+	s.sentBytes += messageSize(m)
+	if s.logPayloads {
+		s.log.Debug("rpc message sent", observability.String("method", s.method), observability.String("response", payload(m)))
+	}
+	return nil
+}
+
+func messageSize(m any) int {
+	if msg, ok := m.(proto.Message); ok {
+		return proto.Size(msg)
+	}
+	return 0
+}
+
+// payload returns m as JSON with its debug_redact fields redacted.
+func payload(m any) string {
+	msg, ok := m.(proto.Message)
+	if !ok || msg == nil {
+		return ""
+	}
+	msg = proto.Clone(msg)
+	redact(msg.ProtoReflect())
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// redact replaces the debug_redact fields of msg and its nested messages:
+// strings with redactedValue, anything else by clearing it.
+func redact(msg protoreflect.Message) {
+	var sensitive []protoreflect.FieldDescriptor
+	msg.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if opts, ok := fd.Options().(*descriptorpb.FieldOptions); ok && opts.GetDebugRedact() {
+			sensitive = append(sensitive, fd)
+			return true
+		}
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				value.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+					redact(v.Message())
+					return true
+				})
+			}
+		case fd.Message() == nil:
+		case fd.IsList():
+			list := value.List()
+			for i := range list.Len() {
+				redact(list.Get(i).Message())
+			}
+		default:
+			redact(value.Message())
+		}
+		return true
+	})
+
+	for _, fd := range sensitive {
+		if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+			msg.Set(fd, protoreflect.ValueOfString(redactedValue))
+		} else {
+			msg.Clear(fd)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package interceptor
+
+import (
+	"context"
+
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"google.golang.org/grpc"
+)
+
+// ActorInterceptor records the caller as the audit actor, so rows the request
+// writes are stamped with who wrote them (see pkg/audit). Authenticated
+// callers are recorded by identity and others by peer IP. Register it after
+// any authentication interceptor.
+func ActorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(audit.ContextWithActor(ctx, callerOf(ctx).String()), req)
+	}
+}
@@ -0,0 +1,74 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/observability/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// auditWriteTimeout bounds writing one audit record, which outlives the
+// call's own context so a canceled call is still recorded.
+const auditWriteTimeout = 5 * time.Second
+
+// AuditInterceptor writes an audit record to sink for every call to the
+// methods, or service prefixes ending in "/", in policy, an exact method
+// taking precedence: at audit.LevelCall who called which method and the
+// status code returned, at audit.LevelRequest also the request as JSON,
+// with its debug_redact fields redacted. Unlisted methods are not audited.
+//
+// Records are written once the handler returns, including calls that
+// failed or were denied. A record that cannot be written is logged to log
+// and does not fail the call, whose effects have already happened.
+//
+// Register it after the authentication interceptors, so records name the
+// authenticated caller, and before AuthzInterceptor, so denied calls are
+// recorded too.
+func AuditInterceptor(sink audit.Sink, policy map[string]audit.Level, log observability.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		_, level, ok := methodEntry(info.FullMethod, policy)
+		if !ok || level == audit.LevelNone {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		record := &model.AuditRecord{
+			Actor:     callerOf(ctx).String(),
+			Method:    info.FullMethod,
+			RequestId: observability.RequestIdFromContext(ctx),
+			Code:      auditCode(err).String(),
+			Duration:  time.Since(start).Milliseconds(),
+			CreatedAt: start,
+		}
+		if level == audit.LevelRequest {
+			record.Request = payload(req)
+		}
+
+		writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditWriteTimeout)
+		defer cancel()
+		if writeErr := sink.Write(writeCtx, record); writeErr != nil {
+			observability.LoggerFromContext(ctx, log).Error("failed to write audit record",
+				observability.Err(writeErr),
+				observability.String("method", info.FullMethod),
+				observability.String("actor", record.Actor),
+			)
+		}
+		return resp, err
+	}
+}
+
+// auditCode returns the status code ErrorInterceptor will return for err,
+// without logging it a second time.
+func auditCode(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	return status.Code(toStatusError(err, noop.NewLogger(), ""))
+}
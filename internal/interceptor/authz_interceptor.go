@@ -0,0 +1,61 @@
+package interceptor
+
+import (
+	"context"
+	"slices"
+
+	"github.com/jt828/go-grpc-template/internal/authz"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"google.golang.org/grpc"
+)
+
+// AuthzInterceptor enforces policy against the roles grants gives the caller
+// recorded by ContextWithCaller, together with the roles and permissions
+// resolver finds for it. They are resolved once per request and recorded
+// with authz.ContextWithRoles and authz.ContextWithPermissions for the
+// handler. A nil resolver leaves callers with their granted roles. Requests
+// to methods that are not public fail with apperror.ErrUnauthenticated when
+// no caller was authenticated, with apperror.ErrPermissionDenied when the
+// caller lacks every allowed role and permission, and with the resolver's
+// error when the caller's access cannot be looked up. Register it after
+// every authentication interceptor.
+func AuthzInterceptor(policy *authz.Policy, grants authz.Grants, resolver authz.Resolver, meter observability.Meter) grpc.UnaryServerInterceptor {
+	denied := meter.Counter("authz_requests_denied_total", observability.MetricOpt{
+		Help:      "Total number of requests rejected by the authorization policy",
+		LabelKeys: []string{"method"},
+	})
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		public := policy.Public(info.FullMethod)
+		caller, ok := CallerFromContext(ctx)
+		var access authz.Access
+		if ok {
+			access.Roles = grants.Roles(caller.String())
+			if resolver != nil {
+				resolved, err := resolver.Resolve(ctx, caller.String(), access.Roles)
+				switch {
+				case err == nil:
+					access = resolved
+				case !public:
+					return nil, err
+				}
+			}
+			ctx = authz.ContextWithRoles(ctx, access.Roles)
+			ctx = authz.ContextWithPermissions(ctx, access.Permissions)
+		}
+		if public {
+			return handler(ctx, req)
+		}
+
+		if !ok {
+			denied.Inc(1, observability.Label{Key: "method", Value: info.FullMethod})
+			return nil, apperror.Unauthenticatedf("%s requires an authenticated caller", info.FullMethod)
+		}
+		if err := policy.Authorize(info.FullMethod, append(slices.Clip(access.Roles), access.Permissions...)); err != nil {
+			denied.Inc(1, observability.Label{Key: "method", Value: info.FullMethod})
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
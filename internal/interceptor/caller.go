@@ -0,0 +1,40 @@
+package interceptor
+
+import "context"
+
+type CallerKind string
+
+const (
+	CallerKindAPIKey CallerKind = "api_key"
+	CallerKindUser   CallerKind = "user"
+	// CallerKindService is a workload authenticated by its TLS client
+	// certificate, identified by SPIFFE ID or common name.
+	CallerKindService CallerKind = "service"
+	// CallerKindPeer is an unauthenticated caller identified by its IP.
+	CallerKindPeer CallerKind = "peer"
+)
+
+// Caller identifies who made a request, for attributing quotas and usage.
+type Caller struct {
+	Kind CallerKind
+	Id   string
+}
+
+func (c Caller) String() string {
+	return string(c.Kind) + ":" + c.Id
+}
+
+type callerKey struct{}
+
+// ContextWithCaller records the authenticated caller. Authentication
+// interceptors call it once the API key or user token has been verified;
+// identities taken from unverified metadata must never be recorded, or a
+// client could pick a fresh identity per request to escape its quota.
+func ContextWithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	caller, ok := ctx.Value(callerKey{}).(Caller)
+	return caller, ok
+}
@@ -0,0 +1,46 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/x509"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// ClientCertInterceptor records the identity in a verified TLS client
+// certificate as a service caller with ContextWithCaller: its SPIFFE ID, or
+// its subject common name when it has none. Connections without a verified
+// certificate pass through unchanged, so they can authenticate with a request
+// signature instead; a verified certificate without an identity is rejected
+// with apperror.ErrUnauthenticated. Register it after ErrorInterceptor and
+// before SignatureInterceptor, so a signed request is attributed to its key.
+func ClientCertInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		p, ok := peer.FromContext(ctx)
+		if !ok {
+			return handler(ctx, req)
+		}
+		tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+		if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+			return handler(ctx, req)
+		}
+
+		identity := clientCertIdentity(tlsInfo.State.VerifiedChains[0][0])
+		if identity == "" {
+			return nil, apperror.Unauthenticatedf("client certificate has no SPIFFE ID or common name")
+		}
+		return handler(ContextWithCaller(ctx, Caller{Kind: CallerKindService, Id: identity}), req)
+	}
+}
+
+func clientCertIdentity(cert *x509.Certificate) string {
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "spiffe" {
+			return uri.String()
+		}
+	}
+	return cert.Subject.CommonName
+}
@@ -0,0 +1,126 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"google.golang.org/grpc"
+)
+
+// defaultConcurrencyGroup labels the requests of methods no limit is listed
+// for.
+const defaultConcurrencyGroup = "default"
+
+// ConcurrencyLimitInterceptors return unary and stream interceptors that cap
+// how many requests run at once and reject the excess immediately with
+// apperror.ErrResourceExhausted, so a burst is shed instead of queueing on
+// the database connection pool until every request times out. limits maps
+// methods, or service prefixes ending in "/", to the number of requests
+// they may have in flight together, an exact method taking precedence;
+// every other method shares fallback. A limit of zero leaves its requests
+// unbounded. Streams hold their slot until they end. Health checks are
+// never limited.
+//
+// The requests in flight are exported as the grpc_requests_in_flight gauge
+// and rejections as grpc_requests_shed_total, both labelled by group: the
+// limits key a method matched, or "default". Register them after
+// ErrorInterceptor and ErrorStreamInterceptor and before the authentication
+// interceptors, which may themselves query the database.
+func ConcurrencyLimitInterceptors(fallback int, limits map[string]int, meter observability.Meter) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	inFlight := meter.Gauge("grpc_requests_in_flight", observability.MetricOpt{
+		Help:      "Number of requests currently being handled",
+		LabelKeys: []string{"group"},
+	})
+	shed := meter.Counter("grpc_requests_shed_total", observability.MetricOpt{
+		Help:      "Total number of requests rejected because their group was at its concurrency limit",
+		LabelKeys: []string{"group"},
+	})
+
+	groups := map[string]*concurrencyGroup{
+		defaultConcurrencyGroup: newConcurrencyGroup(defaultConcurrencyGroup, fallback),
+	}
+	for key, limit := range limits {
+		groups[key] = newConcurrencyGroup(key, limit)
+	}
+
+	// acquire takes a slot of fullMethod's group, returning the function
+	// that releases it.
+	acquire := func(fullMethod string) (func(), error) {
+		group := groups[defaultConcurrencyGroup]
+		if key, _, ok := methodEntry(fullMethod, limits); ok {
+			group = groups[key]
+		}
+		label := observability.Label{Key: "group", Value: group.name}
+		if !group.tryAcquire() {
+			shed.Inc(1, label)
+			return nil, apperror.Wrapf(apperror.ErrResourceExhausted, "%d requests to %s are already in flight", group.limit, group.name)
+		}
+		inFlight.Add(1, label)
+		return func() {
+			inFlight.Add(-1, label)
+			group.release()
+		}, nil
+	}
+
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, healthServicePrefix) {
+			return handler(ctx, req)
+		}
+		release, err := acquire(info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, healthServicePrefix) {
+			return handler(srv, ss)
+		}
+		release, err := acquire(info.FullMethod)
+		if err != nil {
+			return err
+		}
+		defer release()
+		return handler(srv, ss)
+	}
+
+	return unary, stream
+}
+
+// concurrencyGroup is a semaphore of limit slots; a nil slots channel never
+// blocks.
+type concurrencyGroup struct {
+	name  string
+	limit int
+	slots chan struct{}
+}
+
+func newConcurrencyGroup(name string, limit int) *concurrencyGroup {
+	group := &concurrencyGroup{name: name, limit: limit}
+	if limit > 0 {
+		group.slots = make(chan struct{}, limit)
+	}
+	return group
+}
+
+func (g *concurrencyGroup) tryAcquire() bool {
+	if g.slots == nil {
+		return true
+	}
+	select {
+	case g.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (g *concurrencyGroup) release() {
+	if g.slots != nil {
+		<-g.slots
+	}
+}
@@ -0,0 +1,136 @@
+package interceptor
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/stats"
+)
+
+// Reasons a server connection closed, as inferred by ConnectionStatsHandler.
+// gRPC does not report why a connection ended, so the reason is derived from
+// the connection's age, its last RPC and the server's keepalive parameters.
+const (
+	// CloseReasonMaxAge means the server closed the connection for
+	// reaching MaxConnectionAge.
+	CloseReasonMaxAge = "max_age"
+	// CloseReasonMaxIdle means the server reaped the connection for having
+	// no RPCs for MaxConnectionIdle.
+	CloseReasonMaxIdle = "max_idle"
+	// CloseReasonPeerIdle means the connection closed while idle before any
+	// server limit, typically a client or load balancer idle timeout.
+	CloseReasonPeerIdle = "peer_idle"
+	// CloseReasonInFlight means the connection closed with RPCs still
+	// running: a network failure, a keepalive ping timeout or a client that
+	// went away. Clients see these as UNAVAILABLE.
+	CloseReasonInFlight = "in_flight"
+)
+
+// idleTolerance absorbs the delay between the server's idle timer starting
+// and the handler seeing the last RPC end.
+const idleTolerance = 100 * time.Millisecond
+
+type connectionStatsHandler struct {
+	params   keepalive.ServerParameters
+	accepted observability.Counter
+	active   observability.Gauge
+	closed   observability.Counter
+	lifetime observability.Histogram
+}
+
+type connState struct {
+	opened     time.Time
+	inFlight   atomic.Int64
+	lastActive atomic.Int64 // unix nanoseconds
+}
+
+type connStateKey struct{}
+
+// ConnectionStatsHandler records how many connections the server accepts,
+// how many are open and why they close, so idle timeouts and keepalive
+// misconfiguration show up next to the UNAVAILABLE errors they cause. params
+// must be the keepalive parameters the server runs with. Register it with
+// grpc.StatsHandler alongside otelgrpc's handler.
+func ConnectionStatsHandler(params keepalive.ServerParameters, meter observability.Meter) stats.Handler {
+	return &connectionStatsHandler{
+		params: params,
+		accepted: meter.Counter("grpc_server_connections_accepted_total", observability.MetricOpt{
+			Help: "Total number of accepted gRPC connections",
+		}),
+		active: meter.Gauge("grpc_server_connections_active", observability.MetricOpt{
+			Help: "Number of open gRPC connections",
+		}),
+		closed: meter.Counter("grpc_server_connections_closed_total", observability.MetricOpt{
+			Help:      "Total number of closed gRPC connections by inferred reason",
+			LabelKeys: []string{"reason"},
+		}),
+		lifetime: meter.Histogram("grpc_server_connection_duration_seconds", observability.MetricOpt{
+			Help:      "Lifetime of closed gRPC connections by inferred close reason",
+			Buckets:   []float64{1, 10, 30, 60, 300, 900, 1800, 3600, 7200, 21600},
+			LabelKeys: []string{"reason"},
+		}),
+	}
+}
+
+func (h *connectionStatsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	state := &connState{opened: time.Now()}
+	state.lastActive.Store(state.opened.UnixNano())
+	return context.WithValue(ctx, connStateKey{}, state)
+}
+
+func (h *connectionStatsHandler) HandleConn(ctx context.Context, s stats.ConnStats) {
+	state, ok := ctx.Value(connStateKey{}).(*connState)
+	if !ok {
+		return
+	}
+	switch s.(type) {
+	case *stats.ConnBegin:
+		h.accepted.Inc(1)
+		h.active.Add(1)
+	case *stats.ConnEnd:
+		h.active.Add(-1)
+		now := time.Now()
+		reason := observability.Label{Key: "reason", Value: h.closeReason(state, now)}
+		h.closed.Inc(1, reason)
+		h.lifetime.Observe(now.Sub(state.opened).Seconds(), reason)
+	}
+}
+
+// TagRPC leaves ctx alone: RPC contexts derive from the connection's, so
+// HandleRPC finds the state stored by TagConn.
+func (h *connectionStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (h *connectionStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	state, ok := ctx.Value(connStateKey{}).(*connState)
+	if !ok {
+		return
+	}
+	switch s.(type) {
+	case *stats.Begin:
+		state.inFlight.Add(1)
+		state.lastActive.Store(time.Now().UnixNano())
+	case *stats.End:
+		state.inFlight.Add(-1)
+		state.lastActive.Store(time.Now().UnixNano())
+	}
+}
+
+func (h *connectionStatsHandler) closeReason(state *connState, now time.Time) string {
+	// gRPC jitters MaxConnectionAge by up to 10% either way.
+	if maxAge := h.params.MaxConnectionAge; maxAge > 0 && now.Sub(state.opened) >= maxAge*9/10 {
+		return CloseReasonMaxAge
+	}
+	if state.inFlight.Load() > 0 {
+		return CloseReasonInFlight
+	}
+	idle := now.Sub(time.Unix(0, state.lastActive.Load()))
+	if maxIdle := h.params.MaxConnectionIdle; maxIdle > 0 && idle >= maxIdle-idleTolerance {
+		return CloseReasonMaxIdle
+	}
+	return CloseReasonPeerIdle
+}
@@ -0,0 +1,64 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// DeadlineInterceptor gives requests the client set no deadline for one of
+// timeout, or of the entry in methods for their method or service prefix
+// ending in "/", an exact method taking precedence. A zero timeout leaves
+// the request without a deadline. Units of work bound their transactions by
+// the deadline (see repository.WithDeadlineTimeouts), so a client can no
+// longer hold one open indefinitely.
+//
+// Once the deadline passes, the handler's error is replaced by one wrapping
+// context.DeadlineExceeded, whatever the layer it surfaced from, e.g. a
+// Postgres statement timeout, so ErrorInterceptor always returns
+// DEADLINE_EXCEEDED. Register it after ErrorInterceptor and before the
+// authentication interceptors, so they run under the deadline too.
+func DeadlineInterceptor(timeout time.Duration, methods map[string]time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			if timeout := methodTimeout(info.FullMethod, timeout, methods); timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("%w: %v", context.DeadlineExceeded, err)
+		}
+		return resp, err
+	}
+}
+
+func methodTimeout(fullMethod string, fallback time.Duration, methods map[string]time.Duration) time.Duration {
+	if _, timeout, ok := methodEntry(fullMethod, methods); ok {
+		return timeout
+	}
+	return fallback
+}
+
+// methodEntry looks fullMethod up in entries, keyed by methods or service
+// prefixes ending in "/", an exact method taking precedence. It returns the
+// key matched and its value.
+func methodEntry[V any](fullMethod string, entries map[string]V) (string, V, bool) {
+	if value, ok := entries[fullMethod]; ok {
+		return fullMethod, value, true
+	}
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		if value, ok := entries[fullMethod[:i+1]]; ok {
+			return fullMethod[:i+1], value, true
+		}
+	}
+	var zero V
+	return "", zero, false
+}
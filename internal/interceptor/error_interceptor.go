@@ -4,20 +4,32 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 
+	"github.com/jt828/go-grpc-template/internal/repository"
 	"github.com/jt828/go-grpc-template/pkg/apperror"
 	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/pgclass"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
+// ErrorInterceptor maps application errors to gRPC statuses and recovers
+// panics. It logs with the request's fields from
+// observability.LoggerFromContext, and attaches the request id, when
+// RequestIdInterceptor recorded one, as a RequestInfo detail so a client
+// reporting an error can quote the id its logs are under.
 func ErrorInterceptor(log observability.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		log := observability.LoggerFromContext(ctx, log)
 		defer func() {
 			if r := recover(); r != nil {
 				log.Error("panic recovered", observability.String("panic", fmt.Sprintf("%v", r)), observability.String("method", info.FullMethod))
-				err = status.Error(codes.Internal, "internal server error")
+				err = withRequestInfo(ctx, status.Error(codes.Internal, "internal server error"))
 			}
 		}()
 
@@ -25,15 +37,165 @@ func ErrorInterceptor(log observability.Logger) grpc.UnaryServerInterceptor {
 		if err == nil {
 			return resp, nil
 		}
+		return nil, withRequestInfo(ctx, toStatusError(err, log, info.FullMethod))
+	}
+}
+
+// ErrorStreamInterceptor is ErrorInterceptor for streaming RPCs: it maps the
+// error a stream handler returns to a gRPC status and recovers its panics.
+// Errors returned by SendMsg and RecvMsg are already statuses and pass
+// through unchanged when the handler returns them.
+func ErrorStreamInterceptor(log observability.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx := ss.Context()
+		log := observability.LoggerFromContext(ctx, log)
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error("panic recovered", observability.String("panic", fmt.Sprintf("%v", r)), observability.String("method", info.FullMethod))
+				err = withRequestInfo(ctx, status.Error(codes.Internal, "internal server error"))
+			}
+		}()
+
+		err = handler(srv, ss)
+		if err == nil {
+			return nil
+		}
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return withRequestInfo(ctx, toStatusError(err, log, info.FullMethod))
+	}
+}
+
+// statusCodes maps the apperror sentinels to the gRPC codes they are
+// returned with, message and details intact. An error wrapping several
+// sentinels gets the code of the first listed. Add a sentinel here, not to
+// toStatusError.
+var statusCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{apperror.ErrNotFound, codes.NotFound},
+	{apperror.ErrInvalidArgument, codes.InvalidArgument},
+	{apperror.ErrFailedPrecondition, codes.FailedPrecondition},
+	{apperror.ErrConflict, codes.Aborted},
+	{apperror.ErrAlreadyExists, codes.AlreadyExists},
+	{apperror.ErrResourceExhausted, codes.ResourceExhausted},
+	{apperror.ErrUnauthenticated, codes.Unauthenticated},
+	{apperror.ErrPermissionDenied, codes.PermissionDenied},
+	{apperror.ErrUnavailable, codes.Unavailable},
+}
 
-		switch {
-		case errors.Is(err, apperror.ErrNotFound):
-			return nil, status.Error(codes.NotFound, err.Error())
-		case errors.Is(err, apperror.ErrInvalidArgument):
-			return nil, status.Error(codes.InvalidArgument, err.Error())
-		default:
-			log.Error("unhandled error", observability.Err(err), observability.String("method", info.FullMethod))
-			return nil, status.Error(codes.Internal, "internal server error")
+func toStatusError(err error, log observability.Logger, method string) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "request canceled")
+	case errors.Is(err, repository.ErrPartialCommit):
+		// Some stores kept the request's writes, so whatever failed in the
+		// last one must not read as retryable to the client. The handler
+		// registered with repository.WithPartialCommitHandler has logged it.
+		return status.Error(codes.Internal, "request was partially applied and must not be retried")
+	}
+	for _, m := range statusCodes {
+		if errors.Is(err, m.err) {
+			return withDetails(m.code, err)
+		}
+	}
+	switch {
+	case pgclass.IsConflict(err):
+		return status.Error(codes.AlreadyExists, "resource already exists")
+	case errors.As(err, new(*repository.ErrTransient)):
+		log.Warn("transient error", observability.Err(err), observability.String("method", method))
+		return status.Error(codes.Unavailable, "service temporarily unavailable")
+	default:
+		log.Error("unhandled error", internalErrorFields(err, method)...)
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// internalErrorFields returns the log fields of an error returned as
+// Internal: the error and method, the fields of the apperror.Errors in its
+// chain, and the stack of the innermost one.
+func internalErrorFields(err error, method string) []observability.Field {
+	fields := []observability.Field{observability.Err(err), observability.String("method", method)}
+	for _, f := range apperror.FieldsOf(err) {
+		fields = append(fields, observability.Field{Key: f.Key, Value: f.Value})
+	}
+	if stack := apperror.StackTraceOf(err); stack != "" {
+		fields = append(fields, observability.String("stacktrace", stack))
+	}
+	return fields
+}
+
+// withRequestInfo attaches ctx's request id to the status err as a
+// RequestInfo detail.
+func withRequestInfo(ctx context.Context, err error) error {
+	id := observability.RequestIdFromContext(ctx)
+	if id == "" {
+		return err
+	}
+	st := status.Convert(err)
+	if withDetails, detailsErr := st.WithDetails(&errdetails.RequestInfo{RequestId: id}); detailsErr == nil {
+		return withDetails.Err()
+	}
+	return err
+}
+
+// withDetails returns a status with code and err's message, carrying the
+// google.rpc details of the errors in err's chain: BadRequest for an
+// apperror.ValidationError, ErrorInfo for an apperror.ReasonError or
+// VersionMismatchError, and RetryInfo for an apperror.RetryAfterError.
+func withDetails(code codes.Code, err error) error {
+	st := status.New(code, err.Error())
+	var details []protoadapt.MessageV1
+	var validationErr *apperror.ValidationError
+	if errors.As(err, &validationErr) {
+		badRequest := &errdetails.BadRequest{}
+		for _, v := range validationErr.Violations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Reason:      v.Reason,
+				Description: v.Description,
+			})
+		}
+		details = append(details, badRequest)
+	}
+	if info := errorInfo(err); info != nil {
+		details = append(details, info)
+	}
+	var retryErr *apperror.RetryAfterError
+	if errors.As(err, &retryErr) {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(retryErr.RetryAfter)})
+	}
+	if len(details) == 0 {
+		return st.Err()
+	}
+	if withDetails, detailsErr := st.WithDetails(details...); detailsErr == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// errorInfo returns the ErrorInfo of err's outermost apperror.ReasonError,
+// or of an apperror.VersionMismatchError with reason VERSION_MISMATCH and
+// the current version, or nil when err has neither.
+func errorInfo(err error) *errdetails.ErrorInfo {
+	var reasonErr *apperror.ReasonError
+	if errors.As(err, &reasonErr) {
+		return &errdetails.ErrorInfo{Reason: reasonErr.Reason, Domain: apperror.Domain, Metadata: reasonErr.Metadata}
+	}
+	var versionErr *apperror.VersionMismatchError
+	if errors.As(err, &versionErr) {
+		return &errdetails.ErrorInfo{
+			Reason: "VERSION_MISMATCH",
+			Domain: apperror.Domain,
+			Metadata: map[string]string{
+				"resource":        versionErr.Resource,
+				"current_version": strconv.FormatInt(versionErr.CurrentVersion, 10),
+			},
 		}
 	}
+	return nil
 }
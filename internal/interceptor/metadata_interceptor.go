@@ -0,0 +1,157 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// DefaultMetadataKeys are the request metadata keys MetadataInterceptors
+// always lets through: those gRPC sets, those the server's interceptors read
+// and the trace context headers. A key ending in "*" lets through every key
+// it prefixes.
+var DefaultMetadataKeys = []string{
+	":authority", "content-type", "user-agent",
+	AuthorizationHeader, RequestIdHeader,
+	SignatureKeyHeader, SignatureTimestampHeader, SignatureHeader,
+	"traceparent", "tracestate", "baggage", "b3", "x-b3-*",
+}
+
+// singleValueMetadataKeys are the keys whose first value the server acts on.
+// Sending one twice could make the server and a proxy in front of it act on
+// different values, so such requests are rejected.
+var singleValueMetadataKeys = []string{AuthorizationHeader, RequestIdHeader, SignatureKeyHeader, SignatureTimestampHeader, SignatureHeader}
+
+// MetadataInterceptors return unary and stream interceptors that guard the
+// request metadata before anything else reads it. Requests whose metadata,
+// keys and values together, exceeds maxBytes are rejected with
+// INVALID_ARGUMENT and reason METADATA_TOO_LARGE; 0 leaves the size
+// unbounded. So are requests repeating a credential or request id key, with
+// reason METADATA_REPEATED_KEY. Of the rest, only the keys in
+// DefaultMetadataKeys or allowed are kept, matched in lower case as gRPC
+// hands keys over, and values are trimmed of surrounding whitespace and
+// dropped when they hold control characters, except for binary "-bin" keys.
+// Handlers, and anything forwarding the incoming metadata downstream, see
+// only what is kept.
+//
+// What is stripped or rejected is counted by grpc_metadata_violations_total,
+// labelled by reason: unknown_key, invalid_value, too_large or repeated_key.
+// Register them before every interceptor that reads metadata; they return
+// their rejections as statuses themselves.
+func MetadataInterceptors(maxBytes int, allowed []string, meter observability.Meter) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	violations := meter.Counter("grpc_metadata_violations_total", observability.MetricOpt{
+		Help:      "Total number of request metadata keys and values stripped, or requests rejected, by the metadata guard",
+		LabelKeys: []string{"reason"},
+	})
+	allowed = append(DefaultMetadataKeys[:len(DefaultMetadataKeys):len(DefaultMetadataKeys)], allowed...)
+
+	guard := func(ctx context.Context) (context.Context, error) {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return ctx, nil
+		}
+		if size := metadataSize(md); maxBytes > 0 && size > maxBytes {
+			violations.Inc(1, observability.Label{Key: "reason", Value: "too_large"})
+			return nil, withDetails(codes.InvalidArgument, apperror.WithReason(
+				apperror.InvalidArgumentf("request metadata is %d bytes, more than the %d allowed", size, maxBytes),
+				"METADATA_TOO_LARGE", nil,
+			))
+		}
+
+		kept := metadata.MD{}
+		for key, values := range md {
+			if !metadataKeyAllowed(key, allowed) {
+				violations.Inc(1, observability.Label{Key: "reason", Value: "unknown_key"})
+				continue
+			}
+			for _, value := range values {
+				if !strings.HasSuffix(key, "-bin") {
+					value = strings.Trim(value, " \t")
+					if hasControlCharacter(value) {
+						violations.Inc(1, observability.Label{Key: "reason", Value: "invalid_value"})
+						continue
+					}
+				}
+				kept[key] = append(kept[key], value)
+			}
+		}
+		for _, key := range singleValueMetadataKeys {
+			if len(kept[key]) > 1 {
+				violations.Inc(1, observability.Label{Key: "reason", Value: "repeated_key"})
+				return nil, withDetails(codes.InvalidArgument, apperror.WithReason(
+					apperror.InvalidArgumentf("request metadata repeats %s", key),
+					"METADATA_REPEATED_KEY", map[string]string{"key": key},
+				))
+			}
+		}
+		return metadata.NewIncomingContext(ctx, kept), nil
+	}
+
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := guard(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := guard(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+	return unary, stream
+}
+
+// contextStream is a stream whose context an interceptor replaced, e.g. to
+// carry the guarded metadata or the request id.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
+
+// metadataSize returns the bytes md's keys and values take up.
+func metadataSize(md metadata.MD) int {
+	size := 0
+	for key, values := range md {
+		for _, value := range values {
+			size += len(key) + len(value)
+		}
+	}
+	return size
+}
+
+// metadataKeyAllowed reports whether key is listed in allowed, or prefixed
+// by an entry ending in "*".
+func metadataKeyAllowed(key string, allowed []string) bool {
+	for _, entry := range allowed {
+		if prefix, ok := strings.CutSuffix(entry, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// hasControlCharacter reports whether value holds an ASCII control
+// character other than tab, such as the CR and LF of a smuggled header.
+func hasControlCharacter(value string) bool {
+	for i := range len(value) {
+		if c := value[i]; (c < ' ' && c != '\t') || c == 0x7f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// DefaultMethodCost is the billable units charged for a method without an
+// entry in the cost table.
+const DefaultMethodCost = 1
+
+// UsageRecorder accumulates billable usage per caller and method.
+type UsageRecorder interface {
+	Record(at time.Time, caller, method string, units int64)
+}
+
+// MeteringInterceptor records the cost of every successful call against the
+// caller recorded by ContextWithCaller. costs maps full method names to
+// units; methods not listed cost DefaultMethodCost. Peer-identified callers
+// are recorded as "anonymous", so client IPs are never stored. Health checks
+// and failed calls are free. Register it after RateLimitInterceptor.
+func MeteringInterceptor(recorder UsageRecorder, costs map[string]int64) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, healthServicePrefix) {
+			return handler(ctx, req)
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+
+		caller := anonymousCaller
+		if c := callerOf(ctx); c.Kind != CallerKindPeer {
+			caller = c.String()
+		}
+		cost, ok := costs[info.FullMethod]
+		if !ok {
+			cost = DefaultMethodCost
+		}
+		recorder.Record(time.Now(), caller, info.FullMethod, cost)
+		return resp, nil
+	}
+}
@@ -0,0 +1,112 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/oidc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	AuthorizationHeader = "authorization"
+	bearerScheme        = "bearer "
+)
+
+// LinkedUsers resolves the local user an external issuer's subject is
+// linked to, failing with apperror.ErrUnauthenticated when it is not linked.
+type LinkedUsers interface {
+	UserId(ctx context.Context, issuer, subject string) (int64, error)
+}
+
+// Sessions tracks the provider sessions tokens are issued in, so users can
+// list and revoke them.
+type Sessions interface {
+	// Touch opens or refreshes userId's session that issuer knows as
+	// sessionId, as seen from the device with userAgent and ip. It fails
+	// with apperror.ErrUnauthenticated when the session was revoked.
+	Touch(ctx context.Context, userId int64, issuer, sessionId, userAgent, ip string) error
+}
+
+// OIDCInterceptor authenticates requests carrying an "authorization: Bearer"
+// token issued by an external OpenID Connect provider. The token is checked
+// by verifier, its subject resolved to a local user through users, and the
+// user recorded as a user caller with ContextWithCaller. A token with a sid
+// claim also opens or refreshes that session through sessions, so a revoked
+// session's tokens stop working. Requests without a bearer token pass
+// through unchanged, so they can authenticate another way. A token that
+// does not verify, whose subject is not linked to a user or whose session
+// was revoked fails with apperror.ErrUnauthenticated, and one of a
+// suspended or deleted user with apperror.ErrPermissionDenied; each
+// increments oidc_tokens_rejected_total by reason. When the provider's keys
+// cannot be fetched the request fails with apperror.ErrUnavailable.
+// Register it with the other authentication interceptors, before
+// AuthzInterceptor.
+func OIDCInterceptor(verifier oidc.Verifier, users LinkedUsers, sessions Sessions, meter observability.Meter) grpc.UnaryServerInterceptor {
+	rejected := meter.Counter("oidc_tokens_rejected_total", observability.MetricOpt{
+		Help:      "Total number of requests rejected for an invalid, unlinked or revoked OpenID Connect token",
+		LabelKeys: []string{"reason"},
+	})
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		token, ok := bearerToken(ctx)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		claims, err := verifier.Verify(ctx, token)
+		if err != nil {
+			if errors.Is(err, oidc.ErrInvalidToken) {
+				rejected.Inc(1, observability.Label{Key: "reason", Value: "invalid"})
+				return nil, apperror.Unauthenticatedf("bearer token: %v", err)
+			}
+			// The token may be valid; the provider is what failed.
+			return nil, apperror.Unavailablef("identity provider: %v", err)
+		}
+
+		userId, err := users.UserId(ctx, claims.Issuer, claims.Subject)
+		if err != nil {
+			switch {
+			case errors.Is(err, apperror.ErrUnauthenticated):
+				rejected.Inc(1, observability.Label{Key: "reason", Value: "unlinked"})
+			case errors.Is(err, apperror.ErrPermissionDenied):
+				rejected.Inc(1, observability.Label{Key: "reason", Value: "inactive"})
+			}
+			return nil, err
+		}
+		caller := Caller{Kind: CallerKindUser, Id: strconv.FormatInt(userId, 10)}
+		ctx = ContextWithCaller(ctx, caller)
+
+		if claims.SessionId != "" {
+			md, _ := metadata.FromIncomingContext(ctx)
+			// ActorInterceptor has not run yet, so name the user as the
+			// actor in the session's audit trail here.
+			actorCtx := audit.ContextWithActor(ctx, caller.String())
+			err := sessions.Touch(actorCtx, userId, claims.Issuer, claims.SessionId, firstValue(md, "user-agent"), peerHost(ctx))
+			if err != nil {
+				if errors.Is(err, apperror.ErrUnauthenticated) {
+					rejected.Inc(1, observability.Label{Key: "reason", Value: "revoked"})
+				}
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// bearerToken returns the token of an "authorization: Bearer" header. The
+// scheme is matched case-insensitively, as RFC 9110 requires.
+func bearerToken(ctx context.Context) (string, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	value := firstValue(md, AuthorizationHeader)
+	if len(value) <= len(bearerScheme) || !strings.EqualFold(value[:len(bearerScheme)], bearerScheme) {
+		return "", false
+	}
+	return strings.TrimSpace(value[len(bearerScheme):]), true
+}
@@ -0,0 +1,43 @@
+package interceptor
+
+import (
+	"context"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// QueryTagInterceptor tags the request's SQL statements with its method and
+// request id, taken from RequestIdInterceptor when it ran before and from the
+// x-request-id header otherwise. Use QueryTagInterceptors to tag streams as
+// well.
+func QueryTagInterceptor() grpc.UnaryServerInterceptor {
+	unary, _ := QueryTagInterceptors()
+	return unary
+}
+
+// QueryTagInterceptors returns QueryTagInterceptor together with its
+// streaming counterpart.
+func QueryTagInterceptors() (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withQueryTags(ctx, info.FullMethod), req)
+	}
+
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &contextStream{ServerStream: ss, ctx: withQueryTags(ss.Context(), info.FullMethod)})
+	}
+	return unary, stream
+}
+
+func withQueryTags(ctx context.Context, method string) context.Context {
+	tags := observability.QueryTags{Method: method, RequestId: observability.RequestIdFromContext(ctx)}
+	if tags.RequestId == "" {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(RequestIdHeader); len(values) > 0 {
+				tags.RequestId = values[0]
+			}
+		}
+	}
+	return observability.ContextWithQueryTags(ctx, tags)
+}
@@ -0,0 +1,152 @@
+package interceptor
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	rateLimitLimitTrailer     = "x-ratelimit-limit"
+	rateLimitRemainingTrailer = "x-ratelimit-remaining"
+	rateLimitResetTrailer     = "x-ratelimit-reset"
+
+	healthServicePrefix = "/grpc.health.v1.Health/"
+	// anonymousCaller is what usage of peer-identified callers is recorded
+	// under, so client IPs are not kept.
+	anonymousCaller = "anonymous"
+)
+
+// RateLimitInterceptor charges each request to the caller recorded by
+// ContextWithCaller, falling back to the peer IP, and rejects requests over
+// quota with apperror.ErrResourceExhausted, reason
+// apperror.ReasonRateLimited, wrapped in an apperror.RetryAfterError, which
+// ErrorInterceptor returns as ErrorInfo and RetryInfo details. Every
+// response, including rejections, carries x-ratelimit-limit,
+// x-ratelimit-remaining and x-ratelimit-reset (seconds until the quota
+// refills) trailers. If the limiter's store fails the request is let
+// through, so an outage of a shared store does not take the service down
+// with it. Register it after ErrorInterceptor and any authentication
+// interceptor. Use RateLimitInterceptors to limit streams as well.
+func RateLimitInterceptor(limiter ratelimit.Limiter, meter observability.Meter) grpc.UnaryServerInterceptor {
+	unary, _ := RateLimitInterceptors(limiter, meter)
+	return unary
+}
+
+// RateLimitInterceptors returns RateLimitInterceptor together with its
+// streaming counterpart, which share metrics. Streams are charged one
+// request when they open. No error interceptor runs on streams, so their
+// rejections are returned as RESOURCE_EXHAUSTED statuses directly.
+func RateLimitInterceptors(limiter ratelimit.Limiter, meter observability.Meter) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	charge := newRateLimitCharger(limiter, meter)
+
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, healthServicePrefix) {
+			return handler(ctx, req)
+		}
+
+		trailer, err := charge(ctx)
+		if trailer != nil {
+			_ = grpc.SetTrailer(ctx, trailer)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+
+	stream := func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, healthServicePrefix) {
+			return handler(srv, stream)
+		}
+
+		trailer, err := charge(stream.Context())
+		if trailer != nil {
+			stream.SetTrailer(trailer)
+		}
+		if err != nil {
+			return withDetails(codes.ResourceExhausted, err)
+		}
+		return handler(srv, stream)
+	}
+	return unary, stream
+}
+
+// newRateLimitCharger returns a function charging one request to the caller
+// in ctx. It returns the quota trailers, nil when the limiter failed, and
+// the rejection when the caller is over quota.
+func newRateLimitCharger(limiter ratelimit.Limiter, meter observability.Meter) func(ctx context.Context) (metadata.MD, error) {
+	allowed := meter.Counter("ratelimit_requests_allowed_total", observability.MetricOpt{
+		Help:      "Total number of requests within the caller's rate limit",
+		LabelKeys: []string{"kind"},
+	})
+	throttled := meter.Counter("ratelimit_requests_throttled_total", observability.MetricOpt{
+		Help:      "Total number of requests rejected by the caller's rate limit",
+		LabelKeys: []string{"kind"},
+	})
+	storeErrors := meter.Counter("ratelimit_store_errors_total", observability.MetricOpt{
+		Help: "Total number of requests let through unchecked because the rate limit store failed",
+	})
+
+	return func(ctx context.Context) (metadata.MD, error) {
+		caller := callerOf(ctx)
+		decision, err := limiter.Allow(ctx, caller.String())
+		if err != nil {
+			storeErrors.Inc(1)
+			return nil, nil
+		}
+
+		reset := int64(math.Ceil(decision.Reset.Seconds()))
+		trailer := metadata.Pairs(
+			rateLimitLimitTrailer, strconv.Itoa(decision.Limit),
+			rateLimitRemainingTrailer, strconv.Itoa(decision.Remaining),
+			rateLimitResetTrailer, strconv.FormatInt(reset, 10),
+		)
+
+		// Callers are counted by kind: one series per user or peer would
+		// grow without bound.
+		label := observability.Label{Key: "kind", Value: string(caller.Kind)}
+		if !decision.Allowed {
+			throttled.Inc(1, label)
+			return trailer, &apperror.RetryAfterError{
+				Err: apperror.WithReason(
+					apperror.Wrapf(apperror.ErrResourceExhausted, "rate limit of %d requests exceeded", decision.Limit),
+					apperror.ReasonRateLimited, map[string]string{"limit": strconv.Itoa(decision.Limit)},
+				),
+				RetryAfter: decision.RetryAfter,
+			}
+		}
+		allowed.Inc(1, label)
+		return trailer, nil
+	}
+}
+
+func callerOf(ctx context.Context) Caller {
+	if caller, ok := CallerFromContext(ctx); ok {
+		return caller
+	}
+	return Caller{Kind: CallerKindPeer, Id: peerHost(ctx)}
+}
+
+// peerHost returns the IP address of the client connection, or "unknown".
+func peerHost(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
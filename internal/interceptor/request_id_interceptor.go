@@ -0,0 +1,82 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	RequestIdHeader = "x-request-id"
+	// maxRequestIdLength bounds client-supplied ids, which end up in every
+	// log line and SQL comment of the request.
+	maxRequestIdLength = 128
+)
+
+// RequestIdInterceptor gives each request an id: the client's x-request-id
+// header when it is at most 128 printable ASCII characters, or a new random
+// one. The id is recorded with observability.ContextWithRequestId, echoed in
+// the x-request-id response header, and added as a request_id log field with
+// observability.ContextWithLogFields, so every logger obtained through
+// observability.LoggerFromContext while serving the request carries it.
+// Register it first, so every later interceptor sees the id. Use
+// RequestIdInterceptors to give streams ids as well.
+func RequestIdInterceptor() grpc.UnaryServerInterceptor {
+	unary, _ := RequestIdInterceptors()
+	return unary
+}
+
+// RequestIdInterceptors returns RequestIdInterceptor together with its
+// streaming counterpart, which gives each stream an id the same way when it
+// opens.
+func RequestIdInterceptors() (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, header := withRequestId(ctx)
+		_ = grpc.SetHeader(ctx, header)
+		return handler(ctx, req)
+	}
+
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, header := withRequestId(ss.Context())
+		_ = ss.SetHeader(header)
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+	return unary, stream
+}
+
+// withRequestId records the request's id in ctx and returns the header
+// echoing it.
+func withRequestId(ctx context.Context) (context.Context, metadata.MD) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := firstValue(md, RequestIdHeader)
+	if !validRequestId(id) {
+		id = newRequestId()
+	}
+
+	ctx = observability.ContextWithRequestId(ctx, id)
+	ctx = observability.ContextWithLogFields(ctx, observability.String(observability.RequestIdKey, id))
+	return ctx, metadata.Pairs(RequestIdHeader, id)
+}
+
+func validRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLength {
+		return false
+	}
+	for i := range len(id) {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestId returns 16 random bytes in hex, the size of a trace id.
+func newRequestId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,93 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// RPCMetricsInterceptors return unary and stream interceptors recording how
+// long every call took, as grpc_server_handling_seconds labelled by
+// grpc_type, grpc_service and grpc_method like go-grpc-prometheus does, and
+// counting the calls that fail, as grpc_server_errors_total labelled by
+// method and the status code returned, so alerts on error rates need
+// neither log parsing nor the per-method series of every successful call.
+// Both carry the trace and span id of the call as an exemplar when its span
+// is sampled, so an alert or a latency spike links to example traces.
+// Both are also labelled by the tenant named in the request's baggage, as
+// tenants maps it: allow-listed tenants get a value of their own and every
+// other tenant, or a request naming none, is recorded as
+// observability.OtherTenant.
+//
+// Register them first, ahead of ErrorInterceptor and ErrorStreamInterceptor,
+// so they count the status codes clients actually receive, including
+// rejections by the interceptors that run later. The span is started by the
+// otelgrpc stats handler, before any interceptor runs.
+func RPCMetricsInterceptors(meter observability.Meter, tenants *observability.TenantLabels) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	handlingSeconds := meter.Histogram("grpc_server_handling_seconds", observability.MetricOpt{
+		Help:      "Histogram of response latency (seconds) of gRPC that had been application-level handled by the server.",
+		Preset:    observability.BucketsRPC,
+		LabelKeys: []string{"grpc_type", "grpc_service", "grpc_method", observability.TenantLabelKey},
+	})
+	errorsTotal := meter.Counter("grpc_server_errors_total", observability.MetricOpt{
+		Help:      "Total number of RPCs that returned a status other than OK",
+		LabelKeys: []string{"method", "code", observability.TenantLabelKey},
+	})
+	record := func(ctx context.Context, rpcType, method string, start time.Time, err error) {
+		service, name := splitMethod(method)
+		tenant := tenants.Label(observability.TenantFromContext(ctx))
+		observability.ObserveWithTrace(ctx, handlingSeconds, time.Since(start).Seconds(),
+			observability.Label{Key: "grpc_type", Value: rpcType},
+			observability.Label{Key: "grpc_service", Value: service},
+			observability.Label{Key: "grpc_method", Value: name},
+			tenant,
+		)
+		if err == nil {
+			return
+		}
+		observability.IncWithTrace(ctx, errorsTotal, 1,
+			observability.Label{Key: "method", Value: method},
+			observability.Label{Key: "code", Value: status.Code(err).String()},
+			tenant,
+		)
+	}
+
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		record(ctx, "unary", info.FullMethod, start, err)
+		return resp, err
+	}
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		record(ss.Context(), streamType(info), info.FullMethod, start, err)
+		return err
+	}
+	return unary, stream
+}
+
+// splitMethod splits "/package.Service/Method" into its service and method.
+func splitMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "unknown", "unknown"
+	}
+	return service, method
+}
+
+// streamType names a streaming RPC's type as go-grpc-prometheus does.
+func streamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return "bidi_stream"
+	case info.IsClientStream:
+		return "client_stream"
+	default:
+		return "server_stream"
+	}
+}
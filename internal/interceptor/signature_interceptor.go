@@ -0,0 +1,197 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	SignatureKeyHeader       = "x-signature-key"
+	SignatureTimestampHeader = "x-signature-timestamp"
+	SignatureHeader          = "x-signature"
+
+	// signatureVersion prefixes the canonical request so the format can
+	// change without old signatures verifying under the new one.
+	signatureVersion = "v1"
+	// DefaultSignatureMaxSkew bounds how far a signature's timestamp may be
+	// from the server clock, limiting how long a captured request can be
+	// replayed.
+	DefaultSignatureMaxSkew = 5 * time.Minute
+)
+
+// SigningSecrets looks up the shared secret for a signing key id. It reports
+// false for unknown or revoked keys.
+type SigningSecrets interface {
+	Secret(keyId string) ([]byte, bool)
+}
+
+// StaticSigningSecrets holds secrets configured at startup, keyed by key id.
+type StaticSigningSecrets map[string][]byte
+
+func (s StaticSigningSecrets) Secret(keyId string) ([]byte, bool) {
+	secret, ok := s[keyId]
+	return secret, ok
+}
+
+type SignatureConfig struct {
+	MaxSkew time.Duration
+	Clock   func() time.Time
+}
+
+type SignatureOption func(*SignatureConfig)
+
+func WithSignatureMaxSkew(maxSkew time.Duration) SignatureOption {
+	return func(c *SignatureConfig) {
+		c.MaxSkew = maxSkew
+	}
+}
+
+// WithSignatureClock replaces time.Now, e.g. to test timestamp checks.
+func WithSignatureClock(clock func() time.Time) SignatureOption {
+	return func(c *SignatureConfig) {
+		c.Clock = clock
+	}
+}
+
+// CanonicalRequest is the byte string a request signature covers:
+//
+//	v1\n<full method>\n<unix timestamp>\n<hex sha256 of the request>
+//
+// The request is hashed in its deterministic protobuf encoding, which
+// every official runtime produces for messages without map fields.
+func CanonicalRequest(fullMethod string, timestamp int64, req proto.Message) ([]byte, error) {
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(body)
+	return []byte(signatureVersion + "\n" + fullMethod + "\n" + strconv.FormatInt(timestamp, 10) + "\n" + hex.EncodeToString(digest[:])), nil
+}
+
+// SignRequest returns the hex HMAC-SHA256 signature of the canonical
+// request, as a client sends it in x-signature.
+func SignRequest(secret []byte, fullMethod string, timestamp int64, req proto.Message) (string, error) {
+	canonical, err := CanonicalRequest(fullMethod, timestamp, req)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(canonical)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// SignatureInterceptor verifies HMAC request signatures for callers that
+// cannot use mTLS. A request carrying x-signature-key, x-signature-timestamp
+// and x-signature is always verified, and on success its key id is recorded
+// as an api_key caller with ContextWithCaller. Unsigned requests are rejected
+// only on methods matching required, each a full method name or a service
+// prefix ending in "/". Failures return apperror.ErrUnauthenticated and
+// increment request_signature_failures_total by reason. Register it after
+// ErrorInterceptor and before RateLimitInterceptor.
+func SignatureInterceptor(secrets SigningSecrets, required []string, meter observability.Meter, opts ...SignatureOption) grpc.UnaryServerInterceptor {
+	cfg := &SignatureConfig{MaxSkew: DefaultSignatureMaxSkew, Clock: time.Now}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	failures := meter.Counter("request_signature_failures_total", observability.MetricOpt{
+		Help:      "Total number of requests rejected for a missing or invalid signature",
+		LabelKeys: []string{"reason"},
+	})
+	reject := func(reason, msg string) error {
+		failures.Inc(1, observability.Label{Key: "reason", Value: reason})
+		return apperror.Unauthenticatedf("%s", msg)
+	}
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		keyId, timestamp, signature := firstValue(md, SignatureKeyHeader), firstValue(md, SignatureTimestampHeader), firstValue(md, SignatureHeader)
+		if keyId == "" && timestamp == "" && signature == "" {
+			if methodMatches(info.FullMethod, required) {
+				return nil, reject("missing", "request signature is required")
+			}
+			return handler(ctx, req)
+		}
+		if keyId == "" || timestamp == "" || signature == "" {
+			return nil, reject("malformed", "request signature is incomplete")
+		}
+
+		signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return nil, reject("malformed", "request signature timestamp is not a unix time")
+		}
+		if skew := cfg.Clock().Sub(time.Unix(signedAt, 0)); skew > cfg.MaxSkew || skew < -cfg.MaxSkew {
+			return nil, reject("expired", "request signature timestamp is outside the allowed window")
+		}
+
+		secret, ok := secrets.Secret(keyId)
+		if !ok {
+			return nil, reject("unknown_key", "request signature key is not recognised")
+		}
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return nil, reject("malformed", "request cannot be signed")
+		}
+		expected, err := SignRequest(secret, info.FullMethod, signedAt, msg)
+		if err != nil {
+			return nil, err
+		}
+		if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+			return nil, reject("mismatch", "request signature does not match")
+		}
+
+		return handler(ContextWithCaller(ctx, Caller{Kind: CallerKindAPIKey, Id: keyId}), req)
+	}
+}
+
+// SigningClientInterceptor signs every outgoing unary request with the
+// secret of keyId, for in-house clients such as the smoke test and the
+// synthetic probe that call methods listed in SIGNED_METHODS.
+func SigningClientInterceptor(keyId string, secret []byte) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return fmt.Errorf("cannot sign request of type %T", req)
+		}
+		timestamp := time.Now().Unix()
+		signature, err := SignRequest(secret, method, timestamp, msg)
+		if err != nil {
+			return err
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx,
+			SignatureKeyHeader, keyId,
+			SignatureTimestampHeader, strconv.FormatInt(timestamp, 10),
+			SignatureHeader, signature,
+		)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// methodMatches reports whether fullMethod is one of patterns, or is in a
+// service named by a pattern ending in "/".
+func methodMatches(fullMethod string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if pattern == fullMethod || (strings.HasSuffix(pattern, "/") && strings.HasPrefix(fullMethod, pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,141 @@
+package interceptor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// StreamSendInterceptor instruments what server-streaming and bidirectional
+// calls send, labelled by method:
+//
+//   - grpc_stream_sent_messages_total and grpc_stream_sent_bytes_total count
+//     what streams sent, and grpc_stream_throughput_bytes_per_second records
+//     each stream's average rate when it ends.
+//   - grpc_stream_buffered_messages gauges the messages handed to the stream
+//     that wait for the client's flow-control window.
+//   - grpc_stream_send_seconds records how long each send waited, which is
+//     how far behind the client reads.
+//
+// A send the client has not made room for within sendTimeout fails with
+// DEADLINE_EXCEEDED and reason STREAM_SEND_TIMEOUT, as do any after it,
+// counted by grpc_stream_send_timeouts_total. The handler's error then ends
+// the stream, releasing the send left waiting, so a stuck client no longer
+// holds the handler, and what it holds open, indefinitely. A zero
+// sendTimeout lets sends wait as long as the stream lives.
+func StreamSendInterceptor(sendTimeout time.Duration, meter observability.Meter) grpc.StreamServerInterceptor {
+	metrics := streamSendMetrics{
+		messages: meter.Counter("grpc_stream_sent_messages_total", observability.MetricOpt{
+			Help:      "Total number of messages sent on server streams",
+			LabelKeys: []string{"method"},
+		}),
+		bytes: meter.Counter("grpc_stream_sent_bytes_total", observability.MetricOpt{
+			Help:      "Total number of bytes sent on server streams",
+			LabelKeys: []string{"method"},
+		}),
+		throughput: meter.Histogram("grpc_stream_throughput_bytes_per_second", observability.MetricOpt{
+			Help:      "Average rate, in bytes per second, at which each server stream sent",
+			Buckets:   []float64{1 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20, 64 << 20, 256 << 20},
+			LabelKeys: []string{"method"},
+		}),
+		buffered: meter.Gauge("grpc_stream_buffered_messages", observability.MetricOpt{
+			Help:      "Messages sent on server streams that wait for the client to make room",
+			LabelKeys: []string{"method"},
+		}),
+		sendSeconds: meter.Histogram("grpc_stream_send_seconds", observability.MetricOpt{
+			Help:      "Time each message sent on a server stream waited for the client to make room",
+			Preset:    observability.BucketsExternal,
+			LabelKeys: []string{"method"},
+		}),
+		timeouts: meter.Counter("grpc_stream_send_timeouts_total", observability.MetricOpt{
+			Help:      "Total number of server streams aborted because their client stopped reading",
+			LabelKeys: []string{"method"},
+		}),
+	}
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !info.IsServerStream {
+			return handler(srv, ss)
+		}
+		stream := &sendTimedStream{
+			ServerStream: ss,
+			metrics:      metrics,
+			method:       observability.Label{Key: "method", Value: info.FullMethod},
+			timeout:      sendTimeout,
+		}
+		start := time.Now()
+		err := handler(srv, stream)
+		if elapsed := time.Since(start).Seconds(); stream.sentBytes > 0 && elapsed > 0 {
+			metrics.throughput.Observe(float64(stream.sentBytes)/elapsed, stream.method)
+		}
+		return err
+	}
+}
+
+type streamSendMetrics struct {
+	messages    observability.Counter
+	bytes       observability.Counter
+	throughput  observability.Histogram
+	buffered    observability.Gauge
+	sendSeconds observability.Histogram
+	timeouts    observability.Counter
+}
+
+// sendTimedStream times and counts the messages sent on it, failing sends
+// that wait longer than timeout.
+type sendTimedStream struct {
+	grpc.ServerStream
+	metrics   streamSendMetrics
+	method    observability.Label
+	timeout   time.Duration
+	sentBytes int
+	// err fails every send once one has timed out, since the one left
+	// waiting still holds the stream.
+	err error
+}
+
+func (s *sendTimedStream) SendMsg(m any) error {
+	if s.err != nil {
+		return s.err
+	}
+	start := time.Now()
+	s.metrics.buffered.Add(1, s.method)
+	err := s.send(m)
+	s.metrics.buffered.Add(-1, s.method)
+	s.metrics.sendSeconds.Observe(time.Since(start).Seconds(), s.method)
+	if err != nil {
+		return err
+	}
+	size := messageSize(m)
+	s.sentBytes += size
+	s.metrics.messages.Inc(1, s.method)
+	s.metrics.bytes.Inc(float64(size), s.method)
+	return nil
+}
+
+// send sends m, giving up after timeout. The send it gives up on is left
+// to finish, or fail, when the stream ends.
+func (s *sendTimedStream) send(m any) error {
+	if s.timeout <= 0 {
+		return s.ServerStream.SendMsg(m)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.ServerStream.SendMsg(m) }()
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		s.metrics.timeouts.Inc(1, s.method)
+		s.err = withDetails(codes.DeadlineExceeded, apperror.WithReason(
+			fmt.Errorf("client did not read the stream for %s", s.timeout),
+			"STREAM_SEND_TIMEOUT", nil,
+		))
+		return s.err
+	}
+}
@@ -0,0 +1,213 @@
+// Package migrationcheck guards cmd/migration against schema changes that
+// break the release still serving traffic during a blue/green deploy. It
+// flags destructive statements in pending migrations and splits them into
+// expand and contract phases, so columns are only dropped or tightened once
+// no running code needs them.
+package migrationcheck
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Phase is when a migration may run relative to the deploy of the code that
+// needs it.
+type Phase string
+
+const (
+	// PhaseExpand migrations only add to the schema, so the old and new
+	// releases both work against it. They run before the deploy. Migrations
+	// that declare no phase are expand migrations.
+	PhaseExpand Phase = "expand"
+	// PhaseContract migrations remove what only the old release used. They
+	// run once it is no longer serving.
+	PhaseContract Phase = "contract"
+)
+
+// ParsePhase parses the value of the -phase flag.
+func ParsePhase(s string) (Phase, error) {
+	switch p := Phase(s); p {
+	case PhaseExpand, PhaseContract:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown phase %q: want expand or contract", s)
+}
+
+// Migration is one up migration file.
+type Migration struct {
+	Version uint
+	Name    string
+	Phase   Phase
+	SQL     string
+}
+
+var (
+	fileName    = regexp.MustCompile(`^(\d+)_(.+)\.up\.sql$`)
+	phaseMarker = regexp.MustCompile(`(?m)^--\s*phase:\s*(\S+)\s*$`)
+)
+
+// Load reads the up migrations in fsys, ordered by version. A migration
+// declares its phase with a "-- phase: contract" comment line.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		sql, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		m := Migration{Version: uint(version), Name: match[2], Phase: PhaseExpand, SQL: string(sql)}
+		if marker := phaseMarker.FindStringSubmatch(m.SQL); marker != nil {
+			if m.Phase, err = ParsePhase(marker[1]); err != nil {
+				return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+			}
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// ErrOutOfOrder is returned by Plan for a contract run while expand
+// migrations ahead of the contract ones are still pending.
+var ErrOutOfOrder = errors.New("expand migrations are pending")
+
+// Plan returns the migrations after version current, -1 when none has run,
+// that a run of phase applies. An expand run stops before the first contract
+// migration, which must wait for the deploy that stops using what it
+// removes. A contract run applies only the contract migrations at the head
+// of the pending ones, and fails with ErrOutOfOrder if an expand migration
+// comes first, since that has to be applied and deployed before.
+func Plan(migrations []Migration, current int64, phase Phase) ([]Migration, error) {
+	var pending []Migration
+	for _, m := range migrations {
+		if int64(m.Version) > current {
+			pending = append(pending, m)
+		}
+	}
+	if phase == PhaseContract && len(pending) > 0 && pending[0].Phase != PhaseContract {
+		return nil, fmt.Errorf("%w: apply %d_%s with -phase expand and deploy before contracting", ErrOutOfOrder, pending[0].Version, pending[0].Name)
+	}
+	for i, m := range pending {
+		if m.Phase != phase {
+			return pending[:i], nil
+		}
+	}
+	return pending, nil
+}
+
+// Violation is a destructive statement found in a migration.
+type Violation struct {
+	Version   uint
+	Name      string
+	Statement string
+	Reason    string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%d_%s: %s: %s", v.Version, v.Name, v.Reason, v.Statement)
+}
+
+var destructive = []struct {
+	pattern *regexp.Regexp
+	reason  string
+}{
+	{regexp.MustCompile(`\bDROP\s+TABLE\b`), "drops a table"},
+	{regexp.MustCompile(`\bDROP\s+COLUMN\b`), "drops a column"},
+	{regexp.MustCompile(`\bRENAME\b`), "renames a table or column"},
+	{regexp.MustCompile(`\bALTER\s+COLUMN\s+\S+\s+(SET\s+DATA\s+)?TYPE\b`), "changes a column type"},
+	{regexp.MustCompile(`\bALTER\s+COLUMN\s+\S+\s+SET\s+NOT\s+NULL\b`), "makes an existing column NOT NULL"},
+}
+
+var (
+	alterTable    = regexp.MustCompile(`^ALTER\s+TABLE\b`)
+	addColumn     = regexp.MustCompile(`^(ALTER\s+TABLE\s+.*?\s+)?ADD\s+(COLUMN\s+)?`)
+	addConstraint = regexp.MustCompile(`^(ALTER\s+TABLE\s+.*?\s+)?ADD\s+(CONSTRAINT|PRIMARY|UNIQUE|CHECK|FOREIGN|EXCLUDE)\b`)
+	notNull       = regexp.MustCompile(`\bNOT\s+NULL\b`)
+	hasDefault    = regexp.MustCompile(`\bDEFAULT\b`)
+)
+
+// Check returns the statements in m that the release still serving during
+// the migration may not survive: dropping or renaming tables and columns,
+// changing column types, and making columns NOT NULL, including adding one
+// without a default, which fails the old release's inserts.
+//
+// Statements are split on semicolons, so one inside a string literal or a
+// function body splits it wrongly.
+func Check(m Migration) []Violation {
+	var violations []Violation
+	for _, stmt := range statements(m.SQL) {
+		upper := strings.ToUpper(stmt)
+		for _, d := range destructive {
+			if d.pattern.MatchString(upper) {
+				violations = append(violations, Violation{Version: m.Version, Name: m.Name, Statement: stmt, Reason: d.reason})
+			}
+		}
+		if !alterTable.MatchString(upper) {
+			continue
+		}
+		for _, clause := range splitClauses(upper) {
+			if addColumn.MatchString(clause) && !addConstraint.MatchString(clause) &&
+				notNull.MatchString(clause) && !hasDefault.MatchString(clause) {
+				violations = append(violations, Violation{Version: m.Version, Name: m.Name, Statement: stmt, Reason: "adds a NOT NULL column without a default"})
+			}
+		}
+	}
+	return violations
+}
+
+// statements returns the statements in sql with comments removed and
+// whitespace collapsed.
+func statements(sql string) []string {
+	var lines []string
+	for _, line := range strings.Split(sql, "\n") {
+		if i := strings.Index(line, "--"); i >= 0 {
+			line = line[:i]
+		}
+		lines = append(lines, line)
+	}
+	var stmts []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.Join(strings.Fields(stmt), " "); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+// splitClauses splits an ALTER TABLE statement on the commas between its
+// actions, leaving those inside parentheses.
+func splitClauses(stmt string) []string {
+	var clauses []string
+	depth, start := 0, 0
+	for i, r := range stmt {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				clauses = append(clauses, strings.TrimSpace(stmt[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(clauses, strings.TrimSpace(stmt[start:]))
+}
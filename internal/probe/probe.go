@@ -0,0 +1,137 @@
+// Package probe creates and reads back a synthetic user through the server's
+// real gRPC stack, so end-to-end latency and failures show up even when
+// every layer's own metrics look healthy.
+package probe
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"google.golang.org/grpc"
+)
+
+const (
+	StepCreate = "create"
+	StepGet    = "get"
+	StepTotal  = "total"
+)
+
+// IdempotencyId is the idempotency id of the probe user's creation, reserved
+// for the probe. Snowflake ids are far larger, so generated ones never
+// collide with it.
+const IdempotencyId int64 = 1
+
+// The probe user's email is under the reserved probe.invalid domain.
+const (
+	probeEmail    = "probe@probe.invalid"
+	probeUsername = "e2e-probe"
+)
+
+// Timeout bounds one probe run, so a hung server is reported as a failure
+// rather than stalling the loop.
+const Timeout = 10 * time.Second
+
+// Prober creates one probe user and reads it back. Every run creates it
+// with IdempotencyId, so the first run inserts the user and later ones
+// replay the stored response: the probe crosses the idempotent write path
+// without leaving a user behind per run.
+type Prober struct {
+	users    v1.UserServiceClient
+	log      observability.Logger
+	duration observability.Histogram
+	runs     observability.Counter
+	failures observability.Counter
+}
+
+// NewProber probes through conn, which should dial this server so requests
+// pass through every interceptor.
+func NewProber(conn grpc.ClientConnInterface, meter observability.Meter, log observability.Logger) *Prober {
+	return &Prober{
+		users: v1.NewUserServiceClient(conn),
+		log:   log,
+		duration: meter.Histogram("e2e_probe_duration_seconds", observability.MetricOpt{
+			Help:      "End-to-end latency of successful synthetic probe steps, through the full gRPC stack",
+			LabelKeys: []string{"step"},
+			Preset:    observability.BucketsRPC,
+		}),
+		runs: meter.Counter("e2e_probe_runs_total", observability.MetricOpt{
+			Help:      "Total number of synthetic probe runs by result",
+			LabelKeys: []string{"result"},
+		}),
+		failures: meter.Counter("e2e_probe_failures_total", observability.MetricOpt{
+			Help:      "Total number of failed synthetic probe runs by the step that failed",
+			LabelKeys: []string{"step"},
+		}),
+	}
+}
+
+// Probe runs the probe once and records its metrics. It returns the
+// first step's error, wrapped with the step name.
+func (p *Prober) Probe(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	start := time.Now()
+	step, err := p.probe(ctx)
+	if err != nil {
+		p.runs.Inc(1, observability.Label{Key: "result", Value: "failure"})
+		p.failures.Inc(1, observability.Label{Key: "step", Value: step})
+		return fmt.Errorf("%s: %w", step, err)
+	}
+	p.observe(StepTotal, start)
+	p.runs.Inc(1, observability.Label{Key: "result", Value: "success"})
+	return nil
+}
+
+// probe returns the step that failed along with its error.
+func (p *Prober) probe(ctx context.Context) (string, error) {
+	start := time.Now()
+	created, err := p.users.CreateUser(ctx, &v1.CreateUserRequest{
+		IdempotencyId: IdempotencyId,
+		Email:         probeEmail,
+		Username:      probeUsername,
+		// A random password passes the password policy, including its
+		// breach check. Replays ignore it.
+		Password: rand.Text(),
+	})
+	if err != nil {
+		return StepCreate, err
+	}
+	p.observe(StepCreate, start)
+
+	start = time.Now()
+	got, err := p.users.GetUserById(ctx, &v1.GetUserByIdRequest{Id: created.GetId(), PublicId: created.GetPublicId()})
+	if err != nil {
+		return StepGet, err
+	}
+	if got.GetEmail() != created.GetEmail() {
+		return StepGet, fmt.Errorf("read back email %q, created %q", got.GetEmail(), created.GetEmail())
+	}
+	p.observe(StepGet, start)
+	return "", nil
+}
+
+func (p *Prober) observe(step string, start time.Time) {
+	p.duration.Observe(time.Since(start).Seconds(), observability.Label{Key: "step", Value: step})
+}
+
+// Run probes immediately and then on every interval until ctx is cancelled.
+func (p *Prober) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Probe(ctx); err != nil && ctx.Err() == nil {
+			p.log.Warn("synthetic probe failed", observability.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	"gorm.io/gorm"
+)
+
+type AuditRecordRepositoryImpl struct {
+	db    *gorm.DB
+	cb    circuitbreaker.CircuitBreaker
+	retry retry.Retry
+}
+
+// NewAuditRecordRepository returns a repository that writes outside any unit
+// of work, so a record is kept even when the audited call's transaction
+// rolled back.
+func NewAuditRecordRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry) audit.RecordRepository {
+	return &AuditRecordRepositoryImpl{db: db, cb: cb, retry: retry}
+}
+
+func (r *AuditRecordRepositoryImpl) Insert(ctx context.Context, record *model.AuditRecord) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			entity := model.AuditRecordDataEntity(*record)
+			return r.db.WithContext(ctx).Create(&entity).Error
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jt828/go-grpc-template/pkg/idempotency"
+)
+
+const (
+	StoreMain        = "main"
+	StoreLedger      = "ledger"
+	StoreIdempotency = "idempotency"
+)
+
+// ErrPartialCommit matches a PartialCommitError with errors.Is.
+var ErrPartialCommit = errors.New("partial commit")
+
+// PartialCommitError reports that some stores committed before another one
+// failed. The committed writes are durable and must be compensated, not
+// retried blindly.
+type PartialCommitError struct {
+	Committed []string
+	Failed    string
+	Err       error
+}
+
+func (e *PartialCommitError) Error() string {
+	return fmt.Sprintf("partial commit: committed %v, %s failed: %v", e.Committed, e.Failed, e.Err)
+}
+
+func (e *PartialCommitError) Unwrap() []error {
+	return []error{ErrPartialCommit, e.Err}
+}
+
+type CompositeOption func(*compositeUnitOfWorkFactory)
+
+// WithPartialCommitHandler registers fn to run whenever Commit ends in a
+// PartialCommitError, e.g. to enqueue a compensating outbox event.
+func WithPartialCommitHandler(fn func(ctx context.Context, err *PartialCommitError)) CompositeOption {
+	return func(f *compositeUnitOfWorkFactory) {
+		f.onPartialCommit = fn
+	}
+}
+
+type compositeUnitOfWorkFactory struct {
+	main            UnitOfWorkFactory
+	ledger          UnitOfWorkFactory
+	idempotency     UnitOfWorkFactory
+	onPartialCommit func(ctx context.Context, err *PartialCommitError)
+}
+
+// NewCompositeUnitOfWorkFactory spans a UnitOfWork across stores that may
+// live in different databases. Factories passed more than once share a
+// single transaction.
+//
+// There is no distributed transaction: Commit is two-phase best-effort.
+// Stores commit in the order main, ledger, idempotency so the idempotency
+// record, which marks a request as done, is only written once the data it
+// describes is durable. If a later store fails after an earlier one has
+// committed, Commit returns a *PartialCommitError and invokes the handler
+// registered with WithPartialCommitHandler.
+func NewCompositeUnitOfWorkFactory(main, ledger, idempotency UnitOfWorkFactory, opts ...CompositeOption) UnitOfWorkFactory {
+	f := &compositeUnitOfWorkFactory{main: main, ledger: ledger, idempotency: idempotency}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+func (f *compositeUnitOfWorkFactory) New(ctx context.Context) (UnitOfWork, error) {
+	u := &compositeUnitOfWork{onPartialCommit: f.onPartialCommit}
+	opened := make(map[UnitOfWorkFactory]UnitOfWork, 3)
+
+	open := func(name string, factory UnitOfWorkFactory) (UnitOfWork, error) {
+		if uow, ok := opened[factory]; ok {
+			return uow, nil
+		}
+		uow, err := factory.New(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opened[factory] = uow
+		u.participants = append(u.participants, participant{name: name, uow: uow})
+		return uow, nil
+	}
+
+	var err error
+	if u.main, err = open(StoreMain, f.main); err != nil {
+		return nil, errors.Join(err, u.Abort(context.Background()))
+	}
+	if u.ledger, err = open(StoreLedger, f.ledger); err != nil {
+		return nil, errors.Join(err, u.Abort(context.Background()))
+	}
+	if u.idempotency, err = open(StoreIdempotency, f.idempotency); err != nil {
+		return nil, errors.Join(err, u.Abort(context.Background()))
+	}
+	return u, nil
+}
+
+type participant struct {
+	name string
+	uow  UnitOfWork
+}
+
+type compositeUnitOfWork struct {
+	main            UnitOfWork
+	ledger          UnitOfWork
+	idempotency     UnitOfWork
+	participants    []participant
+	onPartialCommit func(ctx context.Context, err *PartialCommitError)
+}
+
+func (u *compositeUnitOfWork) UserRepository() UserRepository {
+	return u.main.UserRepository()
+}
+
+func (u *compositeUnitOfWork) LedgerRepository() LedgerRepository {
+	return u.ledger.LedgerRepository()
+}
+
+func (u *compositeUnitOfWork) IdempotencyRecordRepository() idempotency.RecordRepository {
+	return u.idempotency.IdempotencyRecordRepository()
+}
+
+func (u *compositeUnitOfWork) IdempotencyQuarantineRepository() IdempotencyQuarantineRepository {
+	return u.idempotency.IdempotencyQuarantineRepository()
+}
+
+func (u *compositeUnitOfWork) DeadLetterRepository() DeadLetterRepository {
+	return u.main.DeadLetterRepository()
+}
+
+func (u *compositeUnitOfWork) InboxRepository() InboxRepository {
+	return u.main.InboxRepository()
+}
+
+func (u *compositeUnitOfWork) UserStatusChangeRepository() UserStatusChangeRepository {
+	return u.main.UserStatusChangeRepository()
+}
+
+func (u *compositeUnitOfWork) UsageRepository() UsageRepository {
+	return u.main.UsageRepository()
+}
+
+func (u *compositeUnitOfWork) LoginFailureRepository() LoginFailureRepository {
+	return u.main.LoginFailureRepository()
+}
+
+func (u *compositeUnitOfWork) TwoFactorRepository() TwoFactorRepository {
+	return u.main.TwoFactorRepository()
+}
+
+func (u *compositeUnitOfWork) SessionRepository() SessionRepository {
+	return u.main.SessionRepository()
+}
+
+func (u *compositeUnitOfWork) UserIdentityRepository() UserIdentityRepository {
+	return u.main.UserIdentityRepository()
+}
+
+func (u *compositeUnitOfWork) VerificationTokenRepository() VerificationTokenRepository {
+	return u.main.VerificationTokenRepository()
+}
+
+func (u *compositeUnitOfWork) PasswordResetTokenRepository() PasswordResetTokenRepository {
+	return u.main.PasswordResetTokenRepository()
+}
+
+func (u *compositeUnitOfWork) RoleRepository() RoleRepository {
+	return u.main.RoleRepository()
+}
+
+func (u *compositeUnitOfWork) Commit(ctx context.Context) error {
+	for i, p := range u.participants {
+		err := p.uow.Commit(ctx)
+		if err == nil {
+			continue
+		}
+
+		for _, rest := range u.participants[i+1:] {
+			_ = rest.uow.Abort(ctx)
+		}
+		if i == 0 {
+			return err
+		}
+
+		committed := make([]string, i)
+		for j := range committed {
+			committed[j] = u.participants[j].name
+		}
+		partialErr := &PartialCommitError{Committed: committed, Failed: p.name, Err: err}
+		if u.onPartialCommit != nil {
+			u.onPartialCommit(ctx, partialErr)
+		}
+		return partialErr
+	}
+	return nil
+}
+
+func (u *compositeUnitOfWork) Abort(ctx context.Context) error {
+	var errs []error
+	for _, p := range u.participants {
+		if err := p.uow.Abort(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	"gorm.io/gorm"
+)
+
+type DeadLetterRepository interface {
+	Get(ctx context.Context, id int64) (*model.DeadLetter, error)
+	List(ctx context.Context, query DeadLetterQuery) ([]*model.DeadLetter, error)
+	Insert(ctx context.Context, deadLetter *model.DeadLetter) error
+	MarkReplayed(ctx context.Context, id int64, replayedAt time.Time) error
+	CountPending(ctx context.Context) (map[model.DeadLetterSource]int64, error)
+}
+
+// DeadLetterQuery pages through dead letters in id order. IdGt is the cursor:
+// pass the last id of the previous page.
+type DeadLetterQuery struct {
+	SourceEq    model.DeadLetterSource
+	PendingOnly bool
+	IdGt        int64
+	Limit       int
+}
+
+const (
+	deadLetterId         Column[int64]                  = "id"
+	deadLetterSource     Column[model.DeadLetterSource] = "source"
+	deadLetterReplayedAt Column[*time.Time]             = "replayed_at"
+)
+
+func (q DeadLetterQuery) scopes() []Scope {
+	scopes := []Scope{
+		Eq(deadLetterSource, q.SourceEq),
+		Gt(deadLetterId, q.IdGt),
+	}
+	if q.PendingOnly {
+		scopes = append(scopes, IsNull(deadLetterReplayedAt))
+	}
+	return append(scopes, OrderBy(deadLetterId, false), Limit(q.Limit))
+}
+
+type DeadLetterRepositoryImpl struct {
+	db              *gorm.DB
+	cb              circuitbreaker.CircuitBreaker
+	retry           retry.Retry
+	notFoundAsError bool
+	ids             *idRegeneration
+}
+
+func NewDeadLetterRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry, notFoundAsError bool) DeadLetterRepository {
+	return newDeadLetterRepository(db, cb, retry, notFoundAsError, nil)
+}
+
+func newDeadLetterRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry, notFoundAsError bool, ids *idRegeneration) DeadLetterRepository {
+	return &DeadLetterRepositoryImpl{db: db, cb: cb, retry: retry, notFoundAsError: notFoundAsError, ids: ids}
+}
+
+func (r *DeadLetterRepositoryImpl) Get(ctx context.Context, id int64) (*model.DeadLetter, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var deadLetter *model.DeadLetter
+		err := r.retry.Execute(ctx, func() error {
+			var entity model.DeadLetterDataEntity
+			if err := r.db.WithContext(ctx).First(&entity, id).Error; err != nil {
+				if !r.notFoundAsError && errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			d := entity.ToDomain()
+			deadLetter = &d
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return deadLetter, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.(*model.DeadLetter), nil
+}
+
+func (r *DeadLetterRepositoryImpl) List(ctx context.Context, query DeadLetterQuery) ([]*model.DeadLetter, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var deadLetters []*model.DeadLetter
+		err := r.retry.Execute(ctx, func() error {
+			var entities []model.DeadLetterDataEntity
+			if err := r.db.WithContext(ctx).Scopes(query.scopes()...).Find(&entities).Error; err != nil {
+				return err
+			}
+			deadLetters = make([]*model.DeadLetter, len(entities))
+			for i := range entities {
+				d := entities[i].ToDomain()
+				deadLetters[i] = &d
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return deadLetters, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.([]*model.DeadLetter), nil
+}
+
+func (r *DeadLetterRepositoryImpl) Insert(ctx context.Context, deadLetter *model.DeadLetter) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			entity := model.DeadLetterDataEntity(*deadLetter)
+			if err := r.ids.create(r.db.WithContext(ctx), "dead_letters", &entity.Id, &entity); err != nil {
+				return err
+			}
+			deadLetter.Id = entity.Id
+			return nil
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
+
+func (r *DeadLetterRepositoryImpl) MarkReplayed(ctx context.Context, id int64, replayedAt time.Time) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			return r.db.WithContext(ctx).
+				Model(&model.DeadLetterDataEntity{}).
+				Where("id = ?", id).
+				Update("replayed_at", replayedAt).Error
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
+
+func (r *DeadLetterRepositoryImpl) CountPending(ctx context.Context) (map[model.DeadLetterSource]int64, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var counts map[model.DeadLetterSource]int64
+		err := r.retry.Execute(ctx, func() error {
+			var rows []struct {
+				Source model.DeadLetterSource
+				Count  int64
+			}
+			if err := r.db.WithContext(ctx).
+				Model(&model.DeadLetterDataEntity{}).
+				Select("source, COUNT(*) AS count").
+				Scopes(IsNull(deadLetterReplayedAt)).
+				Group("source").
+				Scan(&rows).Error; err != nil {
+				return err
+			}
+			counts = make(map[model.DeadLetterSource]int64, len(rows))
+			for _, row := range rows {
+				counts[row.Source] = row.Count
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return counts, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.(map[model.DeadLetterSource]int64), nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/pgclass"
+)
+
+// ErrTransient wraps a repository failure that may succeed if attempted
+// again later, e.g. a serialization failure, a dropped connection or an open
+// circuit breaker.
+type ErrTransient struct {
+	Cause error
+}
+
+func (e *ErrTransient) Error() string { return e.Cause.Error() }
+
+func (e *ErrTransient) Unwrap() error { return e.Cause }
+
+// ErrPermanent wraps a repository failure that will fail the same way if
+// attempted again, e.g. a constraint violation or a missing record.
+type ErrPermanent struct {
+	Cause error
+}
+
+func (e *ErrPermanent) Error() string { return e.Cause.Error() }
+
+func (e *ErrPermanent) Unwrap() error { return e.Cause }
+
+// IsTransient reports whether err may succeed if attempted again. Errors
+// already wrapped in ErrTransient or ErrPermanent keep their classification;
+// an open circuit breaker is transient; anything else is classified by
+// pgclass.IsRetryable.
+func IsTransient(err error) bool {
+	if errors.As(err, new(*ErrTransient)) {
+		return true
+	}
+	if errors.As(err, new(*ErrPermanent)) {
+		return false
+	}
+	if errors.Is(err, circuitbreaker.ErrOpen) {
+		return true
+	}
+	return pgclass.IsRetryable(err)
+}
+
+// classifyError wraps err in ErrTransient or ErrPermanent so callers can act
+// on it without inspecting driver errors themselves. Constraint violations
+// are translated to domain errors first.
+func classifyError(err error) error {
+	if err == nil || errors.As(err, new(*ErrTransient)) || errors.As(err, new(*ErrPermanent)) {
+		return err
+	}
+	if IsTransient(err) {
+		return &ErrTransient{Cause: err}
+	}
+	return &ErrPermanent{Cause: translateConstraintError(err)}
+}
+
+// ConstraintError is a write Postgres rejected for violating Constraint. It
+// unwraps both to Err, the domain error the violation means, and to Cause,
+// the driver error, so errors.Is matches the apperror sentinel and pgclass
+// still classifies it. Its message is Err's, which names no schema objects.
+// Field is the request field the constraint guards, or empty when it guards
+// none a client sets.
+type ConstraintError struct {
+	Constraint string
+	Field      string
+	Err        error
+	Cause      error
+}
+
+func (e *ConstraintError) Error() string { return e.Err.Error() }
+
+func (e *ConstraintError) Unwrap() []error { return []error{e.Err, e.Cause} }
+
+// constraintRule is what violating a constraint means to a client: the
+// field it guards and the domain error to return.
+type constraintRule struct {
+	field string
+	err   error
+}
+
+// constraintErrors are the domain errors for violating the constraints that
+// stand for a rule a client can act on. Unique violations carry an
+// ErrorInfo reason and check violations a field violation, so clients can
+// tell which field to fix without parsing the message. Add a constraint here
+// together with the migration that creates it.
+var constraintErrors = map[string]constraintRule{
+	"users_email_key": {
+		field: "email",
+		err: apperror.WithReason(apperror.AlreadyExistsf("email already registered"),
+			apperror.ReasonEmailTaken, map[string]string{"field": "email"}),
+	},
+	"ledgers_transaction_type_check": {
+		field: "transaction_type",
+		err:   apperror.FieldError("transaction_type", "invalid", "must be deposit, withdraw or transfer"),
+	},
+}
+
+// translateConstraintError returns err as a *ConstraintError when it is a
+// unique, foreign key or check violation, and err itself otherwise. A
+// constraint without an entry in constraintErrors gets a generic error:
+// apperror.ErrAlreadyExists for a unique violation,
+// apperror.ErrFailedPrecondition for a foreign key violation and
+// apperror.ErrInvalidArgument for a check violation.
+func translateConstraintError(err error) error {
+	var domainErr error
+	switch {
+	case pgclass.IsConflict(err):
+		domainErr = apperror.AlreadyExistsf("resource already exists")
+	case pgclass.IsForeignKeyViolation(err):
+		domainErr = apperror.FailedPreconditionf("referenced resource does not exist or is still referenced")
+	case pgclass.IsCheckViolation(err):
+		domainErr = apperror.InvalidArgumentf("value out of range")
+	default:
+		return err
+	}
+	constraintErr := &ConstraintError{Constraint: pgclass.Constraint(err), Err: domainErr, Cause: err}
+	if rule, ok := constraintErrors[constraintErr.Constraint]; ok {
+		constraintErr.Field, constraintErr.Err = rule.field, rule.err
+	}
+	return constraintErr
+}
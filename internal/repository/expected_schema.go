@@ -0,0 +1,268 @@
+package repository
+
+import "github.com/jt828/go-grpc-template/pkg/model"
+
+// ExpectedSchema is what migrations/ produces for the service's tables.
+// Update it together with every migration; the schema drift integration test
+// applies the migrations and fails if the two disagree.
+var ExpectedSchema = []model.TableSchema{
+	{
+		Name: "idempotency_records",
+		Columns: []model.ColumnSchema{
+			{Name: "id", Type: "bigint"},
+			{Name: "request_type", Type: "character varying(255)"},
+			{Name: "reference_id", Type: "bigint"},
+			{Name: "response_data", Type: "text"},
+			{Name: "created_at", Type: "timestamp with time zone"},
+		},
+		Indexes: []string{"idempotency_records_pkey", "idempotency_records_reference_id_idx"},
+	},
+	{
+		Name: "idempotency_quarantine",
+		Columns: []model.ColumnSchema{
+			{Name: "id", Type: "bigint"},
+			{Name: "request_type", Type: "character varying(255)"},
+			{Name: "reference_id", Type: "bigint"},
+			{Name: "response_data", Type: "text"},
+			{Name: "created_at", Type: "timestamp with time zone"},
+			{Name: "quarantined_at", Type: "timestamp with time zone"},
+			{Name: "reason", Type: "text"},
+		},
+		Indexes: []string{"idempotency_quarantine_pkey"},
+	},
+	{
+		Name: "users",
+		Columns: []model.ColumnSchema{
+			{Name: "id", Type: "bigint"},
+			{Name: "email", Type: "character varying(255)"},
+			{Name: "username", Type: "character varying(255)"},
+			{Name: "password", Type: "character varying(255)"},
+			{Name: "created_at", Type: "timestamp without time zone"},
+			{Name: "updated_at", Type: "timestamp without time zone"},
+			{Name: "status", Type: "character varying(16)"},
+			{Name: "created_by", Type: "character varying(255)"},
+			{Name: "updated_by", Type: "character varying(255)"},
+			{Name: "version", Type: "bigint"},
+			{Name: "email_verified_at", Type: "timestamp with time zone", Nullable: true},
+		},
+		Indexes: []string{"users_email_key", "users_pkey"},
+	},
+	{
+		Name: "ledgers",
+		Columns: []model.ColumnSchema{
+			{Name: "id", Type: "bigint"},
+			{Name: "user_id", Type: "bigint"},
+			{Name: "transaction_type", Type: "character varying(16)"},
+			{Name: "token", Type: "character varying(32)"},
+			{Name: "amount", Type: "numeric(36,18)"},
+			{Name: "created_at", Type: "timestamp with time zone"},
+			{Name: "created_by", Type: "character varying(255)"},
+		},
+		Indexes: []string{"ledgers_pkey"},
+	},
+	{
+		Name: "dead_letters",
+		Columns: []model.ColumnSchema{
+			{Name: "id", Type: "bigint"},
+			{Name: "source", Type: "character varying(32)"},
+			{Name: "event_type", Type: "character varying(255)"},
+			{Name: "payload", Type: "text"},
+			{Name: "last_error", Type: "text"},
+			{Name: "attempts", Type: "integer"},
+			{Name: "created_at", Type: "timestamp with time zone"},
+			{Name: "replayed_at", Type: "timestamp with time zone", Nullable: true},
+		},
+		Indexes: []string{"dead_letters_pending_idx", "dead_letters_pkey"},
+	},
+	{
+		Name: "inbox_messages",
+		Columns: []model.ColumnSchema{
+			{Name: "handler", Type: "character varying(255)"},
+			{Name: "event_id", Type: "bigint"},
+			{Name: "event_type", Type: "character varying(255)"},
+			{Name: "processed_at", Type: "timestamp with time zone"},
+		},
+		Indexes: []string{"inbox_messages_pkey"},
+	},
+	{
+		Name: "user_status_changes",
+		Columns: []model.ColumnSchema{
+			{Name: "id", Type: "bigint"},
+			{Name: "user_id", Type: "bigint"},
+			{Name: "from_status", Type: "character varying(16)"},
+			{Name: "to_status", Type: "character varying(16)"},
+			{Name: "reason", Type: "text"},
+			{Name: "changed_at", Type: "timestamp with time zone"},
+		},
+		Indexes: []string{"user_status_changes_pkey", "user_status_changes_user_id_idx"},
+	},
+	{
+		Name: "api_usage",
+		Columns: []model.ColumnSchema{
+			{Name: "usage_date", Type: "date"},
+			{Name: "caller", Type: "character varying(255)"},
+			{Name: "method", Type: "character varying(255)"},
+			{Name: "requests", Type: "bigint"},
+			{Name: "units", Type: "bigint"},
+		},
+		Indexes: []string{"api_usage_pkey"},
+	},
+	{
+		Name: "api_usage_reports",
+		Columns: []model.ColumnSchema{
+			{Name: "usage_date", Type: "date"},
+			{Name: "reported_at", Type: "timestamp with time zone"},
+		},
+		Indexes: []string{"api_usage_reports_pkey"},
+	},
+	{
+		Name: "login_failures",
+		Columns: []model.ColumnSchema{
+			{Name: "user_id", Type: "bigint"},
+			{Name: "ip", Type: "character varying(45)"},
+			{Name: "failures", Type: "integer"},
+			{Name: "locked_until", Type: "timestamp with time zone", Nullable: true},
+			{Name: "last_failed_at", Type: "timestamp with time zone"},
+		},
+		Indexes: []string{"login_failures_pkey"},
+	},
+	{
+		Name: "user_two_factor",
+		Columns: []model.ColumnSchema{
+			{Name: "user_id", Type: "bigint"},
+			{Name: "secret", Type: "text"},
+			{Name: "last_used_step", Type: "bigint"},
+			{Name: "created_at", Type: "timestamp with time zone"},
+			{Name: "enabled_at", Type: "timestamp with time zone", Nullable: true},
+		},
+		Indexes: []string{"user_two_factor_pkey"},
+	},
+	{
+		Name: "user_recovery_codes",
+		Columns: []model.ColumnSchema{
+			{Name: "user_id", Type: "bigint"},
+			{Name: "code_hash", Type: "character(64)"},
+			{Name: "used_at", Type: "timestamp with time zone", Nullable: true},
+		},
+		Indexes: []string{"user_recovery_codes_pkey"},
+	},
+	{
+		Name: "user_sessions",
+		Columns: []model.ColumnSchema{
+			{Name: "id", Type: "bigint"},
+			{Name: "user_id", Type: "bigint"},
+			{Name: "user_agent", Type: "character varying(512)"},
+			{Name: "ip", Type: "character varying(45)"},
+			{Name: "created_at", Type: "timestamp with time zone"},
+			{Name: "last_seen_at", Type: "timestamp with time zone"},
+			{Name: "revoked_at", Type: "timestamp with time zone", Nullable: true},
+			{Name: "issuer", Type: "character varying(255)"},
+			{Name: "external_id", Type: "character varying(255)"},
+		},
+		Indexes: []string{"user_sessions_pkey", "user_sessions_user_id_idx", "user_sessions_external_id_key"},
+	},
+	{
+		Name: "user_session_events",
+		Columns: []model.ColumnSchema{
+			{Name: "id", Type: "bigint"},
+			{Name: "session_id", Type: "bigint"},
+			{Name: "user_id", Type: "bigint"},
+			{Name: "event", Type: "character varying(16)"},
+			{Name: "user_agent", Type: "character varying(512)"},
+			{Name: "ip", Type: "character varying(45)"},
+			{Name: "created_at", Type: "timestamp with time zone"},
+			{Name: "created_by", Type: "character varying(255)"},
+		},
+		Indexes: []string{"user_session_events_pkey", "user_session_events_user_id_idx"},
+	},
+	{
+		Name: "user_identities",
+		Columns: []model.ColumnSchema{
+			{Name: "issuer", Type: "character varying(255)"},
+			{Name: "subject", Type: "character varying(255)"},
+			{Name: "user_id", Type: "bigint"},
+			{Name: "created_at", Type: "timestamp with time zone"},
+			{Name: "created_by", Type: "character varying(255)"},
+		},
+		Indexes: []string{"user_identities_pkey", "user_identities_user_id_idx"},
+	},
+	{
+		Name: "audit_records",
+		Columns: []model.ColumnSchema{
+			{Name: "id", Type: "bigint"},
+			{Name: "actor", Type: "character varying(255)"},
+			{Name: "method", Type: "character varying(255)"},
+			{Name: "request_id", Type: "character varying(255)"},
+			{Name: "request", Type: "text"},
+			{Name: "code", Type: "character varying(32)"},
+			{Name: "duration_ms", Type: "bigint"},
+			{Name: "created_at", Type: "timestamp with time zone"},
+		},
+		Indexes: []string{"audit_records_actor_idx", "audit_records_method_idx", "audit_records_pkey"},
+	},
+	{
+		Name: "verification_tokens",
+		Columns: []model.ColumnSchema{
+			{Name: "token_hash", Type: "character(64)"},
+			{Name: "user_id", Type: "bigint"},
+			{Name: "email", Type: "character varying(255)"},
+			{Name: "created_at", Type: "timestamp with time zone"},
+			{Name: "expires_at", Type: "timestamp with time zone"},
+			{Name: "used_at", Type: "timestamp with time zone", Nullable: true},
+		},
+		Indexes: []string{"verification_tokens_pkey", "verification_tokens_user_id_idx"},
+	},
+	{
+		Name: "password_reset_tokens",
+		Columns: []model.ColumnSchema{
+			{Name: "selector", Type: "character(26)"},
+			{Name: "verifier_hash", Type: "character(64)"},
+			{Name: "user_id", Type: "bigint"},
+			{Name: "created_at", Type: "timestamp with time zone"},
+			{Name: "expires_at", Type: "timestamp with time zone"},
+			{Name: "used_at", Type: "timestamp with time zone", Nullable: true},
+		},
+		Indexes: []string{"password_reset_tokens_pkey", "password_reset_tokens_user_id_idx"},
+	},
+	{
+		Name: "roles",
+		Columns: []model.ColumnSchema{
+			{Name: "name", Type: "character varying(64)"},
+			{Name: "created_at", Type: "timestamp with time zone"},
+			{Name: "created_by", Type: "character varying(255)"},
+		},
+		Indexes: []string{"roles_pkey"},
+	},
+	{
+		Name: "role_permissions",
+		Columns: []model.ColumnSchema{
+			{Name: "role", Type: "character varying(64)"},
+			{Name: "permission", Type: "character varying(128)"},
+		},
+		Indexes: []string{"role_permissions_pkey"},
+	},
+	{
+		Name: "user_roles",
+		Columns: []model.ColumnSchema{
+			{Name: "user_id", Type: "bigint"},
+			{Name: "role", Type: "character varying(64)"},
+			{Name: "created_at", Type: "timestamp with time zone"},
+			{Name: "created_by", Type: "character varying(255)"},
+		},
+		Indexes: []string{"user_roles_pkey", "user_roles_role_idx"},
+	},
+}
+
+// ExpectedTables returns the ExpectedSchema entries for the named tables, for
+// stores that only carry some of them.
+func ExpectedTables(names ...string) []model.TableSchema {
+	var tables []model.TableSchema
+	for _, table := range ExpectedSchema {
+		for _, name := range names {
+			if table.Name == name {
+				tables = append(tables, table)
+			}
+		}
+	}
+	return tables
+}
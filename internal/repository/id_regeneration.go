@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/pgclass"
+	"github.com/jt828/go-grpc-template/pkg/snowflake"
+	"gorm.io/gorm"
+)
+
+// DefaultIdRegenerationAttempts is how many ids WithIdRegeneration tries
+// per insert, the caller's included.
+const DefaultIdRegenerationAttempts = 3
+
+// idRegeneration retries inserts whose generated id is already taken.
+type idRegeneration struct {
+	ids        snowflake.Snowflake
+	attempts   int
+	collisions observability.Counter
+}
+
+// WithIdRegeneration makes inserts of rows keyed by a snowflake id survive
+// the id already being taken, which a clock rollback or two nodes sharing a
+// node id can cause. Such an insert is rolled back to a savepoint and tried
+// again with an id from ids, up to attempts ids in all, and the caller's
+// value is updated to the id written. Each collision is counted in
+// repository_id_collisions_total by table.
+//
+// Users, sessions, session events, user status changes and dead letters
+// are covered. Ledgers need not be: their ids derive from the event that
+// wrote them, so a taken one means the ledger was already applied.
+func WithIdRegeneration(ids snowflake.Snowflake, attempts int, meter observability.Meter) Option {
+	collisions := meter.Counter("repository_id_collisions_total", observability.MetricOpt{
+		Help:      "Total number of inserts whose generated id was already taken",
+		LabelKeys: []string{"table"},
+	})
+	return func(o *factoryOptions) {
+		o.ids = &idRegeneration{ids: ids, attempts: attempts, collisions: collisions}
+	}
+}
+
+// create inserts entity into table, regenerating *id while it collides with
+// the primary key of an existing row. A nil g inserts once.
+func (g *idRegeneration) create(db *gorm.DB, table string, id *int64, entity any) error {
+	if g == nil {
+		return db.Create(entity).Error
+	}
+	for attempt := 1; ; attempt++ {
+		// Transaction runs in a savepoint inside the unit of work's
+		// transaction, which the failed insert would otherwise abort.
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return tx.Create(entity).Error
+		})
+		if err == nil || attempt >= g.attempts || !isPrimaryKeyConflict(err, table) {
+			return err
+		}
+		g.collisions.Inc(1, observability.Label{Key: "table", Value: table})
+		*id = g.ids.Generate()
+	}
+}
+
+// isPrimaryKeyConflict reports whether err is a unique violation of the
+// primary key of table, named by the Postgres default.
+func isPrimaryKeyConflict(err error, table string) bool {
+	return pgclass.IsConflict(err) && pgclass.Constraint(err) == table+"_pkey"
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/idempotency"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyQuarantineRepository finds idempotency records whose response
+// no longer replays, and manages those moved aside with
+// idempotency.RecordRepository.Quarantine.
+type IdempotencyQuarantineRepository interface {
+	// ListRecords returns up to limit live records with ids above afterId,
+	// in id order, for checking that their responses decode.
+	ListRecords(ctx context.Context, afterId int64, limit int) ([]*idempotency.Record, error)
+	// ListRecordsByReference returns up to limit live records of referenceId,
+	// such as a user's, with ids above afterId, in id order.
+	ListRecordsByReference(ctx context.Context, referenceId, afterId int64, limit int) ([]*idempotency.Record, error)
+	// List returns up to limit quarantined records with ids above afterId,
+	// in id order.
+	List(ctx context.Context, afterId int64, limit int) ([]*idempotency.QuarantinedRecord, error)
+	// Get returns quarantined record id, or nil if there is none.
+	Get(ctx context.Context, id int64) (*idempotency.QuarantinedRecord, error)
+	// Restore moves quarantined record id back among the live records with
+	// responseData as its response. It reports false when there is no
+	// quarantined record id.
+	Restore(ctx context.Context, id int64, responseData string) (bool, error)
+}
+
+const (
+	idempotencyRecordId          Column[int64] = "id"
+	idempotencyRecordReferenceId Column[int64] = "reference_id"
+)
+
+type IdempotencyQuarantineRepositoryImpl struct {
+	db    *gorm.DB
+	cb    circuitbreaker.CircuitBreaker
+	retry retry.Retry
+}
+
+func NewIdempotencyQuarantineRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry) IdempotencyQuarantineRepository {
+	return &IdempotencyQuarantineRepositoryImpl{db: db, cb: cb, retry: retry}
+}
+
+func (r *IdempotencyQuarantineRepositoryImpl) ListRecords(ctx context.Context, afterId int64, limit int) ([]*idempotency.Record, error) {
+	return r.listRecords(ctx, 0, afterId, limit)
+}
+
+func (r *IdempotencyQuarantineRepositoryImpl) ListRecordsByReference(ctx context.Context, referenceId, afterId int64, limit int) ([]*idempotency.Record, error) {
+	return r.listRecords(ctx, referenceId, afterId, limit)
+}
+
+// listRecords lists live records, of referenceId unless it is 0.
+func (r *IdempotencyQuarantineRepositoryImpl) listRecords(ctx context.Context, referenceId, afterId int64, limit int) ([]*idempotency.Record, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var records []*idempotency.Record
+		err := r.retry.Execute(ctx, func() error {
+			var entities []model.IdempotencyRecordDataEntity
+			if err := r.db.WithContext(ctx).
+				Scopes(Eq(idempotencyRecordReferenceId, referenceId), Gt(idempotencyRecordId, afterId), OrderBy(idempotencyRecordId, false), Limit(limit)).
+				Find(&entities).Error; err != nil {
+				return err
+			}
+			records = make([]*idempotency.Record, len(entities))
+			for i := range entities {
+				record := entities[i].ToDomain()
+				records[i] = &record
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return records, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.([]*idempotency.Record), nil
+}
+
+func (r *IdempotencyQuarantineRepositoryImpl) List(ctx context.Context, afterId int64, limit int) ([]*idempotency.QuarantinedRecord, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var records []*idempotency.QuarantinedRecord
+		err := r.retry.Execute(ctx, func() error {
+			var entities []model.IdempotencyQuarantineDataEntity
+			if err := r.db.WithContext(ctx).
+				Scopes(Gt(idempotencyRecordId, afterId), OrderBy(idempotencyRecordId, false), Limit(limit)).
+				Find(&entities).Error; err != nil {
+				return err
+			}
+			records = make([]*idempotency.QuarantinedRecord, len(entities))
+			for i := range entities {
+				record := entities[i].ToDomain()
+				records[i] = &record
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return records, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.([]*idempotency.QuarantinedRecord), nil
+}
+
+func (r *IdempotencyQuarantineRepositoryImpl) Get(ctx context.Context, id int64) (*idempotency.QuarantinedRecord, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var record *idempotency.QuarantinedRecord
+		err := r.retry.Execute(ctx, func() error {
+			var entity model.IdempotencyQuarantineDataEntity
+			if err := r.db.WithContext(ctx).First(&entity, id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			domain := entity.ToDomain()
+			record = &domain
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return record, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.(*idempotency.QuarantinedRecord), nil
+}
+
+func (r *IdempotencyQuarantineRepositoryImpl) Restore(ctx context.Context, id int64, responseData string) (bool, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var restored bool
+		err := r.retry.Execute(ctx, func() error {
+			var entities []model.IdempotencyQuarantineDataEntity
+			db := r.db.WithContext(ctx)
+			if err := db.Clauses(clause.Returning{}).Delete(&entities, id).Error; err != nil {
+				return err
+			}
+			restored = len(entities) == 1
+			if !restored {
+				return nil
+			}
+			entity := entities[0]
+			return db.Create(&model.IdempotencyRecordDataEntity{
+				Id:           entity.Id,
+				RequestType:  entity.RequestType,
+				ReferenceId:  entity.ReferenceId,
+				ResponseData: responseData,
+				CreatedAt:    entity.CreatedAt,
+			}).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+		return restored, nil
+	})
+	if err != nil {
+		return false, classifyError(err)
+	}
+	return result.(bool), nil
+}
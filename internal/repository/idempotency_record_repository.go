@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/jt828/go-grpc-template/internal/constant"
 	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
@@ -10,6 +11,7 @@ import (
 	"github.com/jt828/go-grpc-template/pkg/model"
 	"github.com/jt828/go-grpc-template/pkg/retry"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type IdempotencyRecordRepositoryImpl struct {
@@ -23,6 +25,16 @@ func NewIdempotencyRecordRepository(db *gorm.DB, cb circuitbreaker.CircuitBreake
 	return &IdempotencyRecordRepositoryImpl{db: db, cb: cb, retry: retry, notFoundAsError: notFoundAsError}
 }
 
+func (r *IdempotencyRecordRepositoryImpl) Lock(ctx context.Context, id int64) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			return r.db.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?)", id).Error
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
+
 func (r *IdempotencyRecordRepositoryImpl) Get(ctx context.Context, id int64) (*idempotency.Record, error) {
 	result, err := r.cb.Execute(func() (any, error) {
 		var record *idempotency.Record
@@ -44,7 +56,7 @@ func (r *IdempotencyRecordRepositoryImpl) Get(ctx context.Context, id int64) (*i
 		return record, nil
 	})
 	if err != nil {
-		return nil, err
+		return nil, classifyError(err)
 	}
 	return result.(*idempotency.Record), nil
 }
@@ -63,5 +75,40 @@ func (r *IdempotencyRecordRepositoryImpl) Insert(ctx context.Context, record *id
 		})
 		return nil, err
 	})
-	return err
+	return classifyError(err)
+}
+
+func (r *IdempotencyRecordRepositoryImpl) Quarantine(ctx context.Context, id int64, reason string) (bool, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var quarantined bool
+		err := r.retry.Execute(ctx, func() error {
+			var entities []model.IdempotencyRecordDataEntity
+			db := r.db.WithContext(ctx)
+			if err := db.Clauses(clause.Returning{}).Delete(&entities, id).Error; err != nil {
+				return err
+			}
+			quarantined = len(entities) == 1
+			if !quarantined {
+				return nil
+			}
+			entity := entities[0]
+			return db.Create(&model.IdempotencyQuarantineDataEntity{
+				Id:            entity.Id,
+				RequestType:   entity.RequestType,
+				ReferenceId:   entity.ReferenceId,
+				ResponseData:  entity.ResponseData,
+				CreatedAt:     entity.CreatedAt,
+				QuarantinedAt: time.Now(),
+				Reason:        reason,
+			}).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+		return quarantined, nil
+	})
+	if err != nil {
+		return false, classifyError(err)
+	}
+	return result.(bool), nil
 }
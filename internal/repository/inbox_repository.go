@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InboxRepository interface {
+	// Insert records the message and reports whether it was new. A
+	// concurrent transaction inserting the same message blocks until the
+	// first one ends, so only one of them ever sees true.
+	Insert(ctx context.Context, message *model.InboxMessage) (bool, error)
+}
+
+type InboxRepositoryImpl struct {
+	db    *gorm.DB
+	cb    circuitbreaker.CircuitBreaker
+	retry retry.Retry
+}
+
+func NewInboxRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry) InboxRepository {
+	return &InboxRepositoryImpl{db: db, cb: cb, retry: retry}
+}
+
+func (r *InboxRepositoryImpl) Insert(ctx context.Context, message *model.InboxMessage) (bool, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var inserted bool
+		err := r.retry.Execute(ctx, func() error {
+			entity := model.InboxMessageDataEntity(*message)
+			tx := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&entity)
+			if tx.Error != nil {
+				return tx.Error
+			}
+			inserted = tx.RowsAffected == 1
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return inserted, nil
+	})
+	if err != nil {
+		return false, classifyError(err)
+	}
+	return result.(bool), nil
+}
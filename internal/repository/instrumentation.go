@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+)
+
+type instrumentation struct {
+	tracer   observability.Tracer
+	logger   observability.Logger
+	duration observability.Histogram
+	errors   observability.Counter
+}
+
+// WithMetrics records per-operation latency and error counts. The metrics
+// are registered when WithMetrics is called, so build the option once and
+// share it between factories using the same meter.
+func WithMetrics(meter observability.Meter) Option {
+	duration := meter.Histogram("repository_operation_duration_seconds", observability.MetricOpt{
+		Help:      "Duration of repository operations in seconds",
+		Preset:    observability.BucketsDB,
+		LabelKeys: []string{"operation"},
+	})
+	errors := meter.Counter("repository_operation_errors_total", observability.MetricOpt{
+		Help:      "Total number of failed repository operations",
+		LabelKeys: []string{"operation"},
+	})
+	return func(o *factoryOptions) {
+		i := o.instrumentation()
+		i.duration = duration
+		i.errors = errors
+	}
+}
+
+// WithTracing starts a span per repository operation.
+func WithTracing(tracer observability.Tracer) Option {
+	return func(o *factoryOptions) {
+		o.instrumentation().tracer = tracer
+	}
+}
+
+// WithLogging logs failed repository operations.
+func WithLogging(logger observability.Logger) Option {
+	return func(o *factoryOptions) {
+		o.instrumentation().logger = logger
+	}
+}
+
+func instrument[T any](ctx context.Context, i *instrumentation, operation string, fn func(ctx context.Context) (T, error)) (T, error) {
+	if i.tracer != nil {
+		var span observability.Span
+		ctx, span = i.tracer.Start(ctx, operation)
+		defer span.End()
+
+		result, err := observe(ctx, i, operation, fn)
+		if err != nil {
+			span.RecordError(err)
+		}
+		return result, err
+	}
+	return observe(ctx, i, operation, fn)
+}
+
+func observe[T any](ctx context.Context, i *instrumentation, operation string, fn func(ctx context.Context) (T, error)) (T, error) {
+	start := time.Now()
+	result, err := fn(ctx)
+	elapsed := time.Since(start)
+
+	label := observability.Label{Key: "operation", Value: operation}
+	if i.duration != nil {
+		i.duration.Observe(elapsed.Seconds(), label)
+	}
+	if err != nil {
+		if i.errors != nil {
+			i.errors.Inc(1, label)
+		}
+		if i.logger != nil {
+			observability.LoggerFromContext(ctx, i.logger).Error("repository operation failed",
+				observability.String("operation", operation),
+				observability.Int64("duration_ms", elapsed.Milliseconds()),
+				observability.Err(err),
+			)
+		}
+	}
+	return result, err
+}
@@ -0,0 +1,508 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/idempotency"
+	"github.com/jt828/go-grpc-template/pkg/model"
+)
+
+type instrumentedUserRepository struct {
+	next UserRepository
+	in   *instrumentation
+}
+
+func (r *instrumentedUserRepository) Get(ctx context.Context, id int64) (*model.User, error) {
+	return instrument(ctx, r.in, "UserRepository.Get", func(ctx context.Context) (*model.User, error) {
+		return r.next.Get(ctx, id)
+	})
+}
+
+func (r *instrumentedUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	return instrument(ctx, r.in, "UserRepository.GetByEmail", func(ctx context.Context) (*model.User, error) {
+		return r.next.GetByEmail(ctx, email)
+	})
+}
+
+func (r *instrumentedUserRepository) GetByIds(ctx context.Context, ids []int64, projection Projection) ([]*model.User, error) {
+	return instrument(ctx, r.in, "UserRepository.GetByIds", func(ctx context.Context) ([]*model.User, error) {
+		return r.next.GetByIds(ctx, ids, projection)
+	})
+}
+
+func (r *instrumentedUserRepository) Insert(ctx context.Context, user *model.User) error {
+	_, err := instrument(ctx, r.in, "UserRepository.Insert", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Insert(ctx, user)
+	})
+	return err
+}
+
+func (r *instrumentedUserRepository) UpdateStatus(ctx context.Context, user *model.User) (bool, error) {
+	return instrument(ctx, r.in, "UserRepository.UpdateStatus", func(ctx context.Context) (bool, error) {
+		return r.next.UpdateStatus(ctx, user)
+	})
+}
+
+func (r *instrumentedUserRepository) UpdateProfile(ctx context.Context, user *model.User) (bool, error) {
+	return instrument(ctx, r.in, "UserRepository.UpdateProfile", func(ctx context.Context) (bool, error) {
+		return r.next.UpdateProfile(ctx, user)
+	})
+}
+
+func (r *instrumentedUserRepository) UpdatePassword(ctx context.Context, user *model.User) (bool, error) {
+	return instrument(ctx, r.in, "UserRepository.UpdatePassword", func(ctx context.Context) (bool, error) {
+		return r.next.UpdatePassword(ctx, user)
+	})
+}
+
+func (r *instrumentedUserRepository) MarkEmailVerified(ctx context.Context, user *model.User) (bool, error) {
+	return instrument(ctx, r.in, "UserRepository.MarkEmailVerified", func(ctx context.Context) (bool, error) {
+		return r.next.MarkEmailVerified(ctx, user)
+	})
+}
+
+type instrumentedLedgerRepository struct {
+	next LedgerRepository
+	in   *instrumentation
+}
+
+func (r *instrumentedLedgerRepository) Get(ctx context.Context, query GetQuery) ([]*model.Ledger, error) {
+	return instrument(ctx, r.in, "LedgerRepository.Get", func(ctx context.Context) ([]*model.Ledger, error) {
+		return r.next.Get(ctx, query)
+	})
+}
+
+func (r *instrumentedLedgerRepository) Insert(ctx context.Context, ledger *model.Ledger) error {
+	_, err := instrument(ctx, r.in, "LedgerRepository.Insert", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Insert(ctx, ledger)
+	})
+	return err
+}
+
+type instrumentedIdempotencyRecordRepository struct {
+	next idempotency.RecordRepository
+	in   *instrumentation
+}
+
+func (r *instrumentedIdempotencyRecordRepository) Lock(ctx context.Context, id int64) error {
+	_, err := instrument(ctx, r.in, "IdempotencyRecordRepository.Lock", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Lock(ctx, id)
+	})
+	return err
+}
+
+func (r *instrumentedIdempotencyRecordRepository) Get(ctx context.Context, id int64) (*idempotency.Record, error) {
+	return instrument(ctx, r.in, "IdempotencyRecordRepository.Get", func(ctx context.Context) (*idempotency.Record, error) {
+		return r.next.Get(ctx, id)
+	})
+}
+
+func (r *instrumentedIdempotencyRecordRepository) Insert(ctx context.Context, record *idempotency.Record) error {
+	_, err := instrument(ctx, r.in, "IdempotencyRecordRepository.Insert", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Insert(ctx, record)
+	})
+	return err
+}
+
+func (r *instrumentedIdempotencyRecordRepository) Quarantine(ctx context.Context, id int64, reason string) (bool, error) {
+	return instrument(ctx, r.in, "IdempotencyRecordRepository.Quarantine", func(ctx context.Context) (bool, error) {
+		return r.next.Quarantine(ctx, id, reason)
+	})
+}
+
+type instrumentedDeadLetterRepository struct {
+	next DeadLetterRepository
+	in   *instrumentation
+}
+
+func (r *instrumentedDeadLetterRepository) Get(ctx context.Context, id int64) (*model.DeadLetter, error) {
+	return instrument(ctx, r.in, "DeadLetterRepository.Get", func(ctx context.Context) (*model.DeadLetter, error) {
+		return r.next.Get(ctx, id)
+	})
+}
+
+func (r *instrumentedDeadLetterRepository) List(ctx context.Context, query DeadLetterQuery) ([]*model.DeadLetter, error) {
+	return instrument(ctx, r.in, "DeadLetterRepository.List", func(ctx context.Context) ([]*model.DeadLetter, error) {
+		return r.next.List(ctx, query)
+	})
+}
+
+func (r *instrumentedDeadLetterRepository) Insert(ctx context.Context, deadLetter *model.DeadLetter) error {
+	_, err := instrument(ctx, r.in, "DeadLetterRepository.Insert", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Insert(ctx, deadLetter)
+	})
+	return err
+}
+
+func (r *instrumentedDeadLetterRepository) MarkReplayed(ctx context.Context, id int64, replayedAt time.Time) error {
+	_, err := instrument(ctx, r.in, "DeadLetterRepository.MarkReplayed", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.MarkReplayed(ctx, id, replayedAt)
+	})
+	return err
+}
+
+func (r *instrumentedDeadLetterRepository) CountPending(ctx context.Context) (map[model.DeadLetterSource]int64, error) {
+	return instrument(ctx, r.in, "DeadLetterRepository.CountPending", func(ctx context.Context) (map[model.DeadLetterSource]int64, error) {
+		return r.next.CountPending(ctx)
+	})
+}
+
+type instrumentedInboxRepository struct {
+	next InboxRepository
+	in   *instrumentation
+}
+
+func (r *instrumentedInboxRepository) Insert(ctx context.Context, message *model.InboxMessage) (bool, error) {
+	return instrument(ctx, r.in, "InboxRepository.Insert", func(ctx context.Context) (bool, error) {
+		return r.next.Insert(ctx, message)
+	})
+}
+
+type instrumentedUserStatusChangeRepository struct {
+	next UserStatusChangeRepository
+	in   *instrumentation
+}
+
+func (r *instrumentedUserStatusChangeRepository) Insert(ctx context.Context, change *model.UserStatusChange) error {
+	_, err := instrument(ctx, r.in, "UserStatusChangeRepository.Insert", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Insert(ctx, change)
+	})
+	return err
+}
+
+type instrumentedUsageRepository struct {
+	next UsageRepository
+	in   *instrumentation
+}
+
+func (r *instrumentedUsageRepository) Add(ctx context.Context, usage []*model.ApiUsage) error {
+	_, err := instrument(ctx, r.in, "UsageRepository.Add", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Add(ctx, usage)
+	})
+	return err
+}
+
+func (r *instrumentedUsageRepository) ListByDate(ctx context.Context, date time.Time) ([]*model.ApiUsage, error) {
+	return instrument(ctx, r.in, "UsageRepository.ListByDate", func(ctx context.Context) ([]*model.ApiUsage, error) {
+		return r.next.ListByDate(ctx, date)
+	})
+}
+
+func (r *instrumentedUsageRepository) UnreportedDates(ctx context.Context, before time.Time) ([]time.Time, error) {
+	return instrument(ctx, r.in, "UsageRepository.UnreportedDates", func(ctx context.Context) ([]time.Time, error) {
+		return r.next.UnreportedDates(ctx, before)
+	})
+}
+
+func (r *instrumentedUsageRepository) MarkReported(ctx context.Context, date time.Time, reportedAt time.Time) (bool, error) {
+	return instrument(ctx, r.in, "UsageRepository.MarkReported", func(ctx context.Context) (bool, error) {
+		return r.next.MarkReported(ctx, date, reportedAt)
+	})
+}
+
+type instrumentedLoginFailureRepository struct {
+	next LoginFailureRepository
+	in   *instrumentation
+}
+
+func (r *instrumentedLoginFailureRepository) Get(ctx context.Context, userId int64, ip string) (*model.LoginFailure, error) {
+	return instrument(ctx, r.in, "LoginFailureRepository.Get", func(ctx context.Context) (*model.LoginFailure, error) {
+		return r.next.Get(ctx, userId, ip)
+	})
+}
+
+func (r *instrumentedLoginFailureRepository) Fail(ctx context.Context, userId int64, ip string, failedAt time.Time, resetBefore time.Time) (*model.LoginFailure, error) {
+	return instrument(ctx, r.in, "LoginFailureRepository.Fail", func(ctx context.Context) (*model.LoginFailure, error) {
+		return r.next.Fail(ctx, userId, ip, failedAt, resetBefore)
+	})
+}
+
+func (r *instrumentedLoginFailureRepository) Lock(ctx context.Context, userId int64, ip string, until time.Time) error {
+	_, err := instrument(ctx, r.in, "LoginFailureRepository.Lock", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Lock(ctx, userId, ip, until)
+	})
+	return err
+}
+
+func (r *instrumentedLoginFailureRepository) Clear(ctx context.Context, userId int64, ip string) error {
+	_, err := instrument(ctx, r.in, "LoginFailureRepository.Clear", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Clear(ctx, userId, ip)
+	})
+	return err
+}
+
+func (r *instrumentedLoginFailureRepository) ClearUser(ctx context.Context, userId int64) (int64, error) {
+	return instrument(ctx, r.in, "LoginFailureRepository.ClearUser", func(ctx context.Context) (int64, error) {
+		return r.next.ClearUser(ctx, userId)
+	})
+}
+
+type instrumentedTwoFactorRepository struct {
+	next TwoFactorRepository
+	in   *instrumentation
+}
+
+func (r *instrumentedTwoFactorRepository) Get(ctx context.Context, userId int64) (*model.TwoFactor, error) {
+	return instrument(ctx, r.in, "TwoFactorRepository.Get", func(ctx context.Context) (*model.TwoFactor, error) {
+		return r.next.Get(ctx, userId)
+	})
+}
+
+func (r *instrumentedTwoFactorRepository) Upsert(ctx context.Context, twoFactor *model.TwoFactor) error {
+	_, err := instrument(ctx, r.in, "TwoFactorRepository.Upsert", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Upsert(ctx, twoFactor)
+	})
+	return err
+}
+
+func (r *instrumentedTwoFactorRepository) Enable(ctx context.Context, userId int64, step int64, enabledAt time.Time) (bool, error) {
+	return instrument(ctx, r.in, "TwoFactorRepository.Enable", func(ctx context.Context) (bool, error) {
+		return r.next.Enable(ctx, userId, step, enabledAt)
+	})
+}
+
+func (r *instrumentedTwoFactorRepository) UseStep(ctx context.Context, userId int64, step int64) (bool, error) {
+	return instrument(ctx, r.in, "TwoFactorRepository.UseStep", func(ctx context.Context) (bool, error) {
+		return r.next.UseStep(ctx, userId, step)
+	})
+}
+
+func (r *instrumentedTwoFactorRepository) Delete(ctx context.Context, userId int64) error {
+	_, err := instrument(ctx, r.in, "TwoFactorRepository.Delete", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Delete(ctx, userId)
+	})
+	return err
+}
+
+func (r *instrumentedTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userId int64, codeHashes []string) error {
+	_, err := instrument(ctx, r.in, "TwoFactorRepository.ReplaceRecoveryCodes", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.ReplaceRecoveryCodes(ctx, userId, codeHashes)
+	})
+	return err
+}
+
+func (r *instrumentedTwoFactorRepository) UseRecoveryCode(ctx context.Context, userId int64, codeHash string, usedAt time.Time) (bool, error) {
+	return instrument(ctx, r.in, "TwoFactorRepository.UseRecoveryCode", func(ctx context.Context) (bool, error) {
+		return r.next.UseRecoveryCode(ctx, userId, codeHash, usedAt)
+	})
+}
+
+type instrumentedSessionRepository struct {
+	next SessionRepository
+	in   *instrumentation
+}
+
+func (r *instrumentedSessionRepository) Insert(ctx context.Context, session *model.Session) error {
+	_, err := instrument(ctx, r.in, "SessionRepository.Insert", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Insert(ctx, session)
+	})
+	return err
+}
+
+func (r *instrumentedSessionRepository) GetByExternalId(ctx context.Context, issuer, externalId string) (*model.Session, error) {
+	return instrument(ctx, r.in, "SessionRepository.GetByExternalId", func(ctx context.Context) (*model.Session, error) {
+		return r.next.GetByExternalId(ctx, issuer, externalId)
+	})
+}
+
+func (r *instrumentedSessionRepository) ListActive(ctx context.Context, userId int64, limit int) ([]*model.Session, error) {
+	return instrument(ctx, r.in, "SessionRepository.ListActive", func(ctx context.Context) ([]*model.Session, error) {
+		return r.next.ListActive(ctx, userId, limit)
+	})
+}
+
+func (r *instrumentedSessionRepository) Touch(ctx context.Context, id int64, userAgent string, ip string, seenAt time.Time, staleBefore time.Time) error {
+	_, err := instrument(ctx, r.in, "SessionRepository.Touch", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Touch(ctx, id, userAgent, ip, seenAt, staleBefore)
+	})
+	return err
+}
+
+func (r *instrumentedSessionRepository) Revoke(ctx context.Context, id int64, userId int64, revokedAt time.Time) (bool, error) {
+	return instrument(ctx, r.in, "SessionRepository.Revoke", func(ctx context.Context) (bool, error) {
+		return r.next.Revoke(ctx, id, userId, revokedAt)
+	})
+}
+
+func (r *instrumentedSessionRepository) RevokeAll(ctx context.Context, userId int64, revokedAt time.Time) ([]int64, error) {
+	return instrument(ctx, r.in, "SessionRepository.RevokeAll", func(ctx context.Context) ([]int64, error) {
+		return r.next.RevokeAll(ctx, userId, revokedAt)
+	})
+}
+
+func (r *instrumentedSessionRepository) InsertEvent(ctx context.Context, event *model.SessionEvent) error {
+	_, err := instrument(ctx, r.in, "SessionRepository.InsertEvent", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.InsertEvent(ctx, event)
+	})
+	return err
+}
+
+type instrumentedUserIdentityRepository struct {
+	next UserIdentityRepository
+	in   *instrumentation
+}
+
+func (r *instrumentedUserIdentityRepository) Get(ctx context.Context, issuer, subject string) (*model.UserIdentity, error) {
+	return instrument(ctx, r.in, "UserIdentityRepository.Get", func(ctx context.Context) (*model.UserIdentity, error) {
+		return r.next.Get(ctx, issuer, subject)
+	})
+}
+
+func (r *instrumentedUserIdentityRepository) Insert(ctx context.Context, identity *model.UserIdentity) error {
+	_, err := instrument(ctx, r.in, "UserIdentityRepository.Insert", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Insert(ctx, identity)
+	})
+	return err
+}
+
+func (r *instrumentedUserIdentityRepository) Delete(ctx context.Context, issuer, subject string) (bool, error) {
+	return instrument(ctx, r.in, "UserIdentityRepository.Delete", func(ctx context.Context) (bool, error) {
+		return r.next.Delete(ctx, issuer, subject)
+	})
+}
+
+type instrumentedVerificationTokenRepository struct {
+	next VerificationTokenRepository
+	in   *instrumentation
+}
+
+func (r *instrumentedVerificationTokenRepository) Get(ctx context.Context, tokenHash string) (*model.VerificationToken, error) {
+	return instrument(ctx, r.in, "VerificationTokenRepository.Get", func(ctx context.Context) (*model.VerificationToken, error) {
+		return r.next.Get(ctx, tokenHash)
+	})
+}
+
+func (r *instrumentedVerificationTokenRepository) Insert(ctx context.Context, token *model.VerificationToken) error {
+	_, err := instrument(ctx, r.in, "VerificationTokenRepository.Insert", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Insert(ctx, token)
+	})
+	return err
+}
+
+func (r *instrumentedVerificationTokenRepository) Use(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error) {
+	return instrument(ctx, r.in, "VerificationTokenRepository.Use", func(ctx context.Context) (bool, error) {
+		return r.next.Use(ctx, tokenHash, usedAt)
+	})
+}
+
+func (r *instrumentedVerificationTokenRepository) DeleteUnused(ctx context.Context, userId int64) error {
+	_, err := instrument(ctx, r.in, "VerificationTokenRepository.DeleteUnused", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.DeleteUnused(ctx, userId)
+	})
+	return err
+}
+
+type instrumentedPasswordResetTokenRepository struct {
+	next PasswordResetTokenRepository
+	in   *instrumentation
+}
+
+func (r *instrumentedPasswordResetTokenRepository) Get(ctx context.Context, selector string) (*model.PasswordResetToken, error) {
+	return instrument(ctx, r.in, "PasswordResetTokenRepository.Get", func(ctx context.Context) (*model.PasswordResetToken, error) {
+		return r.next.Get(ctx, selector)
+	})
+}
+
+func (r *instrumentedPasswordResetTokenRepository) Insert(ctx context.Context, token *model.PasswordResetToken) error {
+	_, err := instrument(ctx, r.in, "PasswordResetTokenRepository.Insert", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Insert(ctx, token)
+	})
+	return err
+}
+
+func (r *instrumentedPasswordResetTokenRepository) Use(ctx context.Context, selector string, usedAt time.Time) (bool, error) {
+	return instrument(ctx, r.in, "PasswordResetTokenRepository.Use", func(ctx context.Context) (bool, error) {
+		return r.next.Use(ctx, selector, usedAt)
+	})
+}
+
+func (r *instrumentedPasswordResetTokenRepository) DeleteUnused(ctx context.Context, userId int64) error {
+	_, err := instrument(ctx, r.in, "PasswordResetTokenRepository.DeleteUnused", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.DeleteUnused(ctx, userId)
+	})
+	return err
+}
+
+type instrumentedIdempotencyQuarantineRepository struct {
+	next IdempotencyQuarantineRepository
+	in   *instrumentation
+}
+
+func (r *instrumentedIdempotencyQuarantineRepository) ListRecords(ctx context.Context, afterId int64, limit int) ([]*idempotency.Record, error) {
+	return instrument(ctx, r.in, "IdempotencyQuarantineRepository.ListRecords", func(ctx context.Context) ([]*idempotency.Record, error) {
+		return r.next.ListRecords(ctx, afterId, limit)
+	})
+}
+
+func (r *instrumentedIdempotencyQuarantineRepository) ListRecordsByReference(ctx context.Context, referenceId, afterId int64, limit int) ([]*idempotency.Record, error) {
+	return instrument(ctx, r.in, "IdempotencyQuarantineRepository.ListRecordsByReference", func(ctx context.Context) ([]*idempotency.Record, error) {
+		return r.next.ListRecordsByReference(ctx, referenceId, afterId, limit)
+	})
+}
+
+func (r *instrumentedIdempotencyQuarantineRepository) List(ctx context.Context, afterId int64, limit int) ([]*idempotency.QuarantinedRecord, error) {
+	return instrument(ctx, r.in, "IdempotencyQuarantineRepository.List", func(ctx context.Context) ([]*idempotency.QuarantinedRecord, error) {
+		return r.next.List(ctx, afterId, limit)
+	})
+}
+
+func (r *instrumentedIdempotencyQuarantineRepository) Get(ctx context.Context, id int64) (*idempotency.QuarantinedRecord, error) {
+	return instrument(ctx, r.in, "IdempotencyQuarantineRepository.Get", func(ctx context.Context) (*idempotency.QuarantinedRecord, error) {
+		return r.next.Get(ctx, id)
+	})
+}
+
+func (r *instrumentedIdempotencyQuarantineRepository) Restore(ctx context.Context, id int64, responseData string) (bool, error) {
+	return instrument(ctx, r.in, "IdempotencyQuarantineRepository.Restore", func(ctx context.Context) (bool, error) {
+		return r.next.Restore(ctx, id, responseData)
+	})
+}
+
+type instrumentedRoleRepository struct {
+	next RoleRepository
+	in   *instrumentation
+}
+
+func (r *instrumentedRoleRepository) Get(ctx context.Context, name string) (*model.Role, error) {
+	return instrument(ctx, r.in, "RoleRepository.Get", func(ctx context.Context) (*model.Role, error) {
+		return r.next.Get(ctx, name)
+	})
+}
+
+func (r *instrumentedRoleRepository) List(ctx context.Context) ([]*model.Role, error) {
+	return instrument(ctx, r.in, "RoleRepository.List", func(ctx context.Context) ([]*model.Role, error) {
+		return r.next.List(ctx)
+	})
+}
+
+func (r *instrumentedRoleRepository) ListByUser(ctx context.Context, userId int64) ([]*model.Role, error) {
+	return instrument(ctx, r.in, "RoleRepository.ListByUser", func(ctx context.Context) ([]*model.Role, error) {
+		return r.next.ListByUser(ctx, userId)
+	})
+}
+
+func (r *instrumentedRoleRepository) Put(ctx context.Context, role *model.Role) error {
+	_, err := instrument(ctx, r.in, "RoleRepository.Put", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Put(ctx, role)
+	})
+	return err
+}
+
+func (r *instrumentedRoleRepository) Permissions(ctx context.Context, roles []string) ([]string, error) {
+	return instrument(ctx, r.in, "RoleRepository.Permissions", func(ctx context.Context) ([]string, error) {
+		return r.next.Permissions(ctx, roles)
+	})
+}
+
+func (r *instrumentedRoleRepository) Assign(ctx context.Context, assignment *model.UserRole) (bool, error) {
+	return instrument(ctx, r.in, "RoleRepository.Assign", func(ctx context.Context) (bool, error) {
+		return r.next.Assign(ctx, assignment)
+	})
+}
+
+func (r *instrumentedRoleRepository) Unassign(ctx context.Context, userId int64, role string) (bool, error) {
+	return instrument(ctx, r.in, "RoleRepository.Unassign", func(ctx context.Context) (bool, error) {
+		return r.next.Unassign(ctx, userId, role)
+	})
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"gorm.io/gorm"
+)
+
+// LedgerProgressInterval is how many rows LedgerBulkLoader.Load sends between
+// progress callbacks.
+const LedgerProgressInterval = 10_000
+
+var errNotPgx = errors.New("bulk load requires the pgx driver")
+
+// LedgerSource yields the ledgers to bulk load one at a time. It returns
+// nil, nil once exhausted.
+type LedgerSource func() (*model.Ledger, error)
+
+// LedgerSlice returns a LedgerSource over ledgers.
+func LedgerSlice(ledgers []*model.Ledger) LedgerSource {
+	i := 0
+	return func() (*model.Ledger, error) {
+		if i == len(ledgers) {
+			return nil, nil
+		}
+		i++
+		return ledgers[i-1], nil
+	}
+}
+
+// LedgerBulkLoader streams ledgers into the ledgers table with COPY, for
+// imports and archive restores too large for row-by-row inserts. It runs
+// outside any unit of work.
+type LedgerBulkLoader interface {
+	// Load copies every ledger from source and returns the number of rows
+	// written. The copy is atomic: on error, including a duplicate id, no
+	// rows are written. progress, if not nil, is called with the running
+	// row count every LedgerProgressInterval rows and once at the end.
+	// Every row's created_by is the actor recorded in ctx.
+	Load(ctx context.Context, source LedgerSource, progress func(loaded int64)) (int64, error)
+}
+
+type LedgerBulkLoaderImpl struct {
+	db     *gorm.DB
+	schema string
+	cb     circuitbreaker.CircuitBreaker
+}
+
+// NewLedgerBulkLoader returns a loader writing to the ledgers table in
+// schema. Loads are not retried, since source cannot be rewound.
+func NewLedgerBulkLoader(db *gorm.DB, schema string, cb circuitbreaker.CircuitBreaker) LedgerBulkLoader {
+	return &LedgerBulkLoaderImpl{db: db, schema: schema, cb: cb}
+}
+
+var ledgerCopyColumns = []string{"id", "user_id", "transaction_type", "token", "amount", "created_at", "created_by"}
+
+func (l *LedgerBulkLoaderImpl) Load(ctx context.Context, source LedgerSource, progress func(loaded int64)) (int64, error) {
+	result, err := l.cb.Execute(func() (any, error) {
+		sqlDB, err := l.db.DB()
+		if err != nil {
+			return nil, err
+		}
+		conn, err := sqlDB.Conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+
+		var copied int64
+		err = conn.Raw(func(driverConn any) error {
+			pgxConn, ok := driverConn.(*stdlib.Conn)
+			if !ok {
+				return fmt.Errorf("%w, got %T", errNotPgx, driverConn)
+			}
+			copied, err = pgxConn.Conn().CopyFrom(ctx, pgx.Identifier{l.schema, "ledgers"}, ledgerCopyColumns, ledgerCopySource(source, audit.ActorFromContext(ctx), progress))
+			return err
+		})
+		return copied, err
+	})
+	if err != nil {
+		return 0, classifyError(err)
+	}
+
+	loaded := result.(int64)
+	if progress != nil {
+		progress(loaded)
+	}
+	return loaded, nil
+}
+
+// ledgerCopySource stamps every row with actor, since COPY bypasses the GORM
+// callbacks that do so for inserts.
+func ledgerCopySource(source LedgerSource, actor string, progress func(loaded int64)) pgx.CopyFromSource {
+	var sent int64
+	return pgx.CopyFromFunc(func() ([]any, error) {
+		ledger, err := source()
+		if err != nil || ledger == nil {
+			return nil, err
+		}
+		sent++
+		if progress != nil && sent%LedgerProgressInterval == 0 {
+			progress(sent)
+		}
+		amount := pgtype.Numeric{Int: ledger.Amount.Coefficient(), Exp: ledger.Amount.Exponent(), Valid: true}
+		return []any{ledger.Id, ledger.UserId, string(ledger.TransactionType), ledger.Token, amount, ledger.CreatedAt, actor}, nil
+	})
+}
@@ -7,18 +7,50 @@ import (
 	"github.com/jt828/go-grpc-template/pkg/model"
 	"github.com/jt828/go-grpc-template/pkg/retry"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type LedgerRepository interface {
 	Get(ctx context.Context, query GetQuery) ([]*model.Ledger, error)
+	// Insert writes ledger. Its id derives from the event that produced it,
+	// so a ledger whose id already exists has been applied and is skipped.
 	Insert(ctx context.Context, ledger *model.Ledger) error
 }
 
+// GetQuery filters ledgers. A positive Limit pages through them by keyset in
+// id order, with IdGt as the cursor: the last id of the previous page. With
+// Desc they are paged newest first, with IdLt as the cursor.
 type GetQuery struct {
 	IdEq              int64
 	UserIdEq          int64
-	TransactionTypeEq string
+	TransactionTypeEq model.TransactionType
 	TokenEq           string
+	IdGt              int64
+	IdLt              int64
+	Desc              bool
+	Limit             int
+}
+
+const (
+	ledgerId              Column[int64]                 = "id"
+	ledgerUserId          Column[int64]                 = "user_id"
+	ledgerTransactionType Column[model.TransactionType] = "transaction_type"
+	ledgerToken           Column[string]                = "token"
+)
+
+func (q GetQuery) scopes() []Scope {
+	scopes := []Scope{
+		Eq(ledgerId, q.IdEq),
+		Eq(ledgerUserId, q.UserIdEq),
+		Eq(ledgerTransactionType, q.TransactionTypeEq),
+		Eq(ledgerToken, q.TokenEq),
+		Gt(ledgerId, q.IdGt),
+		Lt(ledgerId, q.IdLt),
+	}
+	if q.Limit > 0 {
+		scopes = append(scopes, OrderBy(ledgerId, q.Desc), Limit(q.Limit))
+	}
+	return scopes
 }
 
 type LedgerRepositoryImpl struct {
@@ -37,20 +69,7 @@ func (r *LedgerRepositoryImpl) Get(ctx context.Context, query GetQuery) ([]*mode
 		var ledgers []*model.Ledger
 		err := r.retry.Execute(ctx, func() error {
 			var entities []model.LedgerDataEntity
-			db := r.db.WithContext(ctx)
-			if query.IdEq != 0 {
-				db = db.Where("id = ?", query.IdEq)
-			}
-			if query.UserIdEq != 0 {
-				db = db.Where("user_id = ?", query.UserIdEq)
-			}
-			if query.TransactionTypeEq != "" {
-				db = db.Where("transaction_type = ?", query.TransactionTypeEq)
-			}
-			if query.TokenEq != "" {
-				db = db.Where("token = ?", query.TokenEq)
-			}
-			if err := db.Find(&entities).Error; err != nil {
+			if err := r.db.WithContext(ctx).Scopes(query.scopes()...).Find(&entities).Error; err != nil {
 				return err
 			}
 			ledgers = make([]*model.Ledger, len(entities))
@@ -66,7 +85,7 @@ func (r *LedgerRepositoryImpl) Get(ctx context.Context, query GetQuery) ([]*mode
 		return ledgers, nil
 	})
 	if err != nil {
-		return nil, err
+		return nil, classifyError(err)
 	}
 	return result.([]*model.Ledger), nil
 }
@@ -81,10 +100,19 @@ func (r *LedgerRepositoryImpl) Insert(ctx context.Context, ledger *model.Ledger)
 				Token:           ledger.Token,
 				Amount:          ledger.Amount,
 				CreatedAt:       ledger.CreatedAt,
+				CreatedBy:       ledger.CreatedBy,
 			}
-			return r.db.WithContext(ctx).Create(&entity).Error
+			err := r.db.WithContext(ctx).
+				Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "id"}}, DoNothing: true}).
+				Create(&entity).Error
+			if err != nil {
+				return err
+			}
+			// Hand back the actor column the audit plugin stamped.
+			ledger.CreatedBy = entity.CreatedBy
+			return nil
 		})
 		return nil, err
 	})
-	return err
+	return classifyError(err)
 }
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginFailureRepository interface {
+	// Get returns the failures recorded for userId from ip, or nil if there
+	// are none.
+	Get(ctx context.Context, userId int64, ip string) (*model.LoginFailure, error)
+	// Fail records a failed login at failedAt in one statement and returns
+	// the updated row. The count restarts at 1 when the previous failure was
+	// before resetBefore.
+	Fail(ctx context.Context, userId int64, ip string, failedAt time.Time, resetBefore time.Time) (*model.LoginFailure, error)
+	Lock(ctx context.Context, userId int64, ip string, until time.Time) error
+	// Clear forgets the failures recorded for userId from ip.
+	Clear(ctx context.Context, userId int64, ip string) error
+	// ClearUser forgets the failures recorded for userId from every IP and
+	// returns how many IPs it cleared.
+	ClearUser(ctx context.Context, userId int64) (int64, error)
+}
+
+const (
+	loginFailureUserId Column[int64]  = "user_id"
+	loginFailureIp     Column[string] = "ip"
+)
+
+type LoginFailureRepositoryImpl struct {
+	db    *gorm.DB
+	cb    circuitbreaker.CircuitBreaker
+	retry retry.Retry
+}
+
+func NewLoginFailureRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry) LoginFailureRepository {
+	return &LoginFailureRepositoryImpl{db: db, cb: cb, retry: retry}
+}
+
+func (r *LoginFailureRepositoryImpl) Get(ctx context.Context, userId int64, ip string) (*model.LoginFailure, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var failure *model.LoginFailure
+		err := r.retry.Execute(ctx, func() error {
+			var entity model.LoginFailureDataEntity
+			if err := r.db.WithContext(ctx).
+				Scopes(Eq(loginFailureUserId, userId), Eq(loginFailureIp, ip)).
+				Take(&entity).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			f := entity.ToDomain()
+			failure = &f
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return failure, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.(*model.LoginFailure), nil
+}
+
+func (r *LoginFailureRepositoryImpl) Fail(ctx context.Context, userId int64, ip string, failedAt time.Time, resetBefore time.Time) (*model.LoginFailure, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var failure *model.LoginFailure
+		err := r.retry.Execute(ctx, func() error {
+			entity := model.LoginFailureDataEntity{UserId: userId, Ip: ip, Failures: 1, LastFailedAt: failedAt}
+			if err := r.db.WithContext(ctx).Clauses(
+				clause.OnConflict{
+					Columns: []clause.Column{{Name: string(loginFailureUserId)}, {Name: string(loginFailureIp)}},
+					DoUpdates: clause.Set{
+						{Column: clause.Column{Name: "failures"}, Value: gorm.Expr("CASE WHEN login_failures.last_failed_at < ? THEN 1 ELSE login_failures.failures + 1 END", resetBefore)},
+						{Column: clause.Column{Name: "last_failed_at"}, Value: gorm.Expr("excluded.last_failed_at")},
+					},
+				},
+				clause.Returning{},
+			).Create(&entity).Error; err != nil {
+				return err
+			}
+			f := entity.ToDomain()
+			failure = &f
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return failure, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.(*model.LoginFailure), nil
+}
+
+func (r *LoginFailureRepositoryImpl) Lock(ctx context.Context, userId int64, ip string, until time.Time) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			return r.db.WithContext(ctx).Model(&model.LoginFailureDataEntity{}).
+				Scopes(Eq(loginFailureUserId, userId), Eq(loginFailureIp, ip)).
+				Update("locked_until", until).Error
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
+
+func (r *LoginFailureRepositoryImpl) Clear(ctx context.Context, userId int64, ip string) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			return r.db.WithContext(ctx).
+				Scopes(Eq(loginFailureUserId, userId), Eq(loginFailureIp, ip)).
+				Delete(&model.LoginFailureDataEntity{}).Error
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
+
+func (r *LoginFailureRepositoryImpl) ClearUser(ctx context.Context, userId int64) (int64, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var cleared int64
+		err := r.retry.Execute(ctx, func() error {
+			tx := r.db.WithContext(ctx).
+				Scopes(Eq(loginFailureUserId, userId)).
+				Delete(&model.LoginFailureDataEntity{})
+			if tx.Error != nil {
+				return tx.Error
+			}
+			cleared = tx.RowsAffected
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return cleared, nil
+	})
+	if err != nil {
+		return 0, classifyError(err)
+	}
+	return result.(int64), nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	"gorm.io/gorm"
+)
+
+type PasswordResetTokenRepository interface {
+	// Get returns the token with selector, or nil if there is none.
+	Get(ctx context.Context, selector string) (*model.PasswordResetToken, error)
+	// Insert stores a new token.
+	Insert(ctx context.Context, token *model.PasswordResetToken) error
+	// Use marks an unused token as used. It reports false when the token
+	// was used already, i.e. it was redeemed concurrently.
+	Use(ctx context.Context, selector string, usedAt time.Time) (bool, error)
+	// DeleteUnused removes userId's unused tokens, so only the link sent
+	// last works and none outlives a reset.
+	DeleteUnused(ctx context.Context, userId int64) error
+}
+
+const (
+	passwordResetTokenSelector Column[string]    = "selector"
+	passwordResetTokenUserId   Column[int64]     = "user_id"
+	passwordResetTokenUsedAt   Column[time.Time] = "used_at"
+)
+
+type PasswordResetTokenRepositoryImpl struct {
+	db    *gorm.DB
+	cb    circuitbreaker.CircuitBreaker
+	retry retry.Retry
+}
+
+func NewPasswordResetTokenRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry) PasswordResetTokenRepository {
+	return &PasswordResetTokenRepositoryImpl{db: db, cb: cb, retry: retry}
+}
+
+func (r *PasswordResetTokenRepositoryImpl) Get(ctx context.Context, selector string) (*model.PasswordResetToken, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var token *model.PasswordResetToken
+		err := r.retry.Execute(ctx, func() error {
+			var entity model.PasswordResetTokenDataEntity
+			if err := r.db.WithContext(ctx).Scopes(Eq(passwordResetTokenSelector, selector)).Take(&entity).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			t := entity.ToDomain()
+			token = &t
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return token, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.(*model.PasswordResetToken), nil
+}
+
+func (r *PasswordResetTokenRepositoryImpl) Insert(ctx context.Context, token *model.PasswordResetToken) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			entity := model.PasswordResetTokenDataEntity(*token)
+			return r.db.WithContext(ctx).Create(&entity).Error
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
+
+func (r *PasswordResetTokenRepositoryImpl) Use(ctx context.Context, selector string, usedAt time.Time) (bool, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var used bool
+		err := r.retry.Execute(ctx, func() error {
+			tx := r.db.WithContext(ctx).
+				Model(&model.PasswordResetTokenDataEntity{}).
+				Scopes(Eq(passwordResetTokenSelector, selector), IsNull(passwordResetTokenUsedAt)).
+				Update(string(passwordResetTokenUsedAt), usedAt)
+			if tx.Error != nil {
+				return tx.Error
+			}
+			used = tx.RowsAffected == 1
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return used, nil
+	})
+	if err != nil {
+		return false, classifyError(err)
+	}
+	return result.(bool), nil
+}
+
+func (r *PasswordResetTokenRepositoryImpl) DeleteUnused(ctx context.Context, userId int64) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			return r.db.WithContext(ctx).
+				Scopes(Eq(passwordResetTokenUserId, userId), IsNull(passwordResetTokenUsedAt)).
+				Delete(&model.PasswordResetTokenDataEntity{}).Error
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
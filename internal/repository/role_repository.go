@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoleRepository interface {
+	// Get returns role name with its permissions, or nil if it is not
+	// defined.
+	Get(ctx context.Context, name string) (*model.Role, error)
+	// List returns every defined role with its permissions, by name.
+	List(ctx context.Context) ([]*model.Role, error)
+	// ListByUser returns the roles assigned to userId with their
+	// permissions, by name.
+	ListByUser(ctx context.Context, userId int64) ([]*model.Role, error)
+	// Put defines role, replacing the permissions of a role already defined
+	// under its name. CreatedAt and CreatedBy are set from the stored row.
+	Put(ctx context.Context, role *model.Role) error
+	// Permissions returns the distinct permissions the named roles carry,
+	// sorted. Roles that are not defined carry none.
+	Permissions(ctx context.Context, roles []string) ([]string, error)
+	// Assign assigns a role to a user. It reports false when the user
+	// already holds the role.
+	Assign(ctx context.Context, assignment *model.UserRole) (bool, error)
+	// Unassign takes role away from userId. It reports false when the user
+	// did not hold it.
+	Unassign(ctx context.Context, userId int64, role string) (bool, error)
+}
+
+const (
+	roleName           Column[string] = "name"
+	rolePermissionRole Column[string] = "role"
+	rolePermission     Column[string] = "permission"
+	userRoleUserId     Column[int64]  = "user_id"
+	userRoleRole       Column[string] = "role"
+)
+
+type RoleRepositoryImpl struct {
+	db    *gorm.DB
+	cb    circuitbreaker.CircuitBreaker
+	retry retry.Retry
+}
+
+func NewRoleRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry) RoleRepository {
+	return &RoleRepositoryImpl{db: db, cb: cb, retry: retry}
+}
+
+func (r *RoleRepositoryImpl) Get(ctx context.Context, name string) (*model.Role, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var role *model.Role
+		err := r.retry.Execute(ctx, func() error {
+			var entity model.RoleDataEntity
+			if err := r.db.WithContext(ctx).Scopes(Eq(roleName, name)).Take(&entity).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			roles, err := r.withPermissions(ctx, []model.RoleDataEntity{entity})
+			if err != nil {
+				return err
+			}
+			role = roles[0]
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return role, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.(*model.Role), nil
+}
+
+func (r *RoleRepositoryImpl) List(ctx context.Context) ([]*model.Role, error) {
+	return r.list(ctx, func(db *gorm.DB) *gorm.DB { return db })
+}
+
+func (r *RoleRepositoryImpl) ListByUser(ctx context.Context, userId int64) ([]*model.Role, error) {
+	return r.list(ctx, func(db *gorm.DB) *gorm.DB {
+		assigned := r.db.WithContext(ctx).Model(&model.UserRoleDataEntity{}).Scopes(Eq(userRoleUserId, userId)).Select(string(userRoleRole))
+		return db.Where(string(roleName)+" IN (?)", assigned)
+	})
+}
+
+// list returns the roles scope selects with their permissions, by name.
+func (r *RoleRepositoryImpl) list(ctx context.Context, scope Scope) ([]*model.Role, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var roles []*model.Role
+		err := r.retry.Execute(ctx, func() error {
+			var entities []model.RoleDataEntity
+			if err := r.db.WithContext(ctx).Scopes(scope, OrderBy(roleName, false)).Find(&entities).Error; err != nil {
+				return err
+			}
+			var err error
+			roles, err = r.withPermissions(ctx, entities)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return roles, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.([]*model.Role), nil
+}
+
+// withPermissions loads the permissions of the roles in entities.
+func (r *RoleRepositoryImpl) withPermissions(ctx context.Context, entities []model.RoleDataEntity) ([]*model.Role, error) {
+	roles := make([]*model.Role, len(entities))
+	if len(entities) == 0 {
+		return roles, nil
+	}
+	names := make([]string, len(entities))
+	byName := make(map[string]*model.Role, len(entities))
+	for i, entity := range entities {
+		roles[i] = &model.Role{Name: entity.Name, CreatedAt: entity.CreatedAt, CreatedBy: entity.CreatedBy}
+		names[i] = entity.Name
+		byName[entity.Name] = roles[i]
+	}
+
+	var permissions []model.RolePermissionDataEntity
+	if err := r.db.WithContext(ctx).
+		Scopes(In(rolePermissionRole, names), OrderBy(rolePermission, false)).
+		Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	for _, permission := range permissions {
+		role := byName[permission.Role]
+		role.Permissions = append(role.Permissions, permission.Permission)
+	}
+	return roles, nil
+}
+
+func (r *RoleRepositoryImpl) Put(ctx context.Context, role *model.Role) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			db := r.db.WithContext(ctx)
+			entity := model.RoleDataEntity{Name: role.Name, CreatedAt: role.CreatedAt}
+			if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity).Error; err != nil {
+				return err
+			}
+			// An existing role keeps when and by whom it was first defined.
+			if err := db.Scopes(Eq(roleName, role.Name)).Take(&entity).Error; err != nil {
+				return err
+			}
+			role.CreatedAt, role.CreatedBy = entity.CreatedAt, entity.CreatedBy
+
+			if err := db.Scopes(Eq(rolePermissionRole, role.Name)).Delete(&model.RolePermissionDataEntity{}).Error; err != nil {
+				return err
+			}
+			if len(role.Permissions) == 0 {
+				return nil
+			}
+			permissions := make([]model.RolePermissionDataEntity, len(role.Permissions))
+			for i, permission := range role.Permissions {
+				permissions[i] = model.RolePermissionDataEntity{Role: role.Name, Permission: permission}
+			}
+			return db.Create(&permissions).Error
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
+
+func (r *RoleRepositoryImpl) Permissions(ctx context.Context, roles []string) ([]string, error) {
+	// In leaves the query unfiltered for an empty list.
+	if len(roles) == 0 {
+		return nil, nil
+	}
+	result, err := r.cb.Execute(func() (any, error) {
+		var permissions []string
+		err := r.retry.Execute(ctx, func() error {
+			return r.db.WithContext(ctx).
+				Model(&model.RolePermissionDataEntity{}).
+				Scopes(In(rolePermissionRole, roles), OrderBy(rolePermission, false)).
+				Distinct(string(rolePermission)).
+				Pluck(string(rolePermission), &permissions).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+		return permissions, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.([]string), nil
+}
+
+func (r *RoleRepositoryImpl) Assign(ctx context.Context, assignment *model.UserRole) (bool, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var assigned bool
+		err := r.retry.Execute(ctx, func() error {
+			entity := model.UserRoleDataEntity(*assignment)
+			tx := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&entity)
+			if tx.Error != nil {
+				return tx.Error
+			}
+			assigned = tx.RowsAffected == 1
+			// Hand back the actor the audit plugin stamped.
+			assignment.CreatedBy = entity.CreatedBy
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return assigned, nil
+	})
+	if err != nil {
+		return false, classifyError(err)
+	}
+	return result.(bool), nil
+}
+
+func (r *RoleRepositoryImpl) Unassign(ctx context.Context, userId int64, role string) (bool, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var unassigned bool
+		err := r.retry.Execute(ctx, func() error {
+			tx := r.db.WithContext(ctx).Scopes(Eq(userRoleUserId, userId), Eq(userRoleRole, role)).Delete(&model.UserRoleDataEntity{})
+			if tx.Error != nil {
+				return tx.Error
+			}
+			unassigned = tx.RowsAffected == 1
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return unassigned, nil
+	})
+	if err != nil {
+		return false, classifyError(err)
+	}
+	return result.(bool), nil
+}
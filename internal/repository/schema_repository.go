@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	"gorm.io/gorm"
+)
+
+// SchemaRepository reads table definitions and statistics from the Postgres
+// catalog. It runs outside any unit of work.
+type SchemaRepository interface {
+	Describe(ctx context.Context, schemaName string, tables []string) ([]model.TableSchema, error)
+	TableStats(ctx context.Context, schemaName string, tables []string) ([]*model.TableStats, error)
+	// SlowStatements returns the limit statements with the highest mean
+	// execution time in the current database. It returns nil when the
+	// pg_stat_statements extension is not installed.
+	SlowStatements(ctx context.Context, limit int) ([]*model.StatementStats, error)
+}
+
+type SchemaRepositoryImpl struct {
+	db    *gorm.DB
+	cb    circuitbreaker.CircuitBreaker
+	retry retry.Retry
+}
+
+func NewSchemaRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry) SchemaRepository {
+	return &SchemaRepositoryImpl{db: db, cb: cb, retry: retry}
+}
+
+type columnRow struct {
+	TableName              string
+	ColumnName             string
+	DataType               string
+	CharacterMaximumLength *int
+	NumericPrecision       *int
+	NumericScale           *int
+	IsNullable             string
+}
+
+type indexRow struct {
+	Tablename string
+	Indexname string
+}
+
+// Describe returns the listed tables that exist in schemaName, with columns
+// in ordinal order and indexes sorted by name. Tables that do not exist are
+// omitted.
+func (r *SchemaRepositoryImpl) Describe(ctx context.Context, schemaName string, tables []string) ([]model.TableSchema, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var described []model.TableSchema
+		err := r.retry.Execute(ctx, func() error {
+			var columns []columnRow
+			if err := r.db.WithContext(ctx).Raw(
+				`SELECT table_name, column_name, data_type, character_maximum_length, numeric_precision, numeric_scale, is_nullable
+				FROM information_schema.columns
+				WHERE table_schema = ? AND table_name IN ?
+				ORDER BY table_name, ordinal_position`,
+				schemaName, tables,
+			).Scan(&columns).Error; err != nil {
+				return err
+			}
+
+			var indexes []indexRow
+			if err := r.db.WithContext(ctx).Raw(
+				`SELECT tablename, indexname FROM pg_indexes
+				WHERE schemaname = ? AND tablename IN ?
+				ORDER BY tablename, indexname`,
+				schemaName, tables,
+			).Scan(&indexes).Error; err != nil {
+				return err
+			}
+
+			described = buildTableSchemas(columns, indexes)
+			return nil
+		})
+		return described, err
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.([]model.TableSchema), nil
+}
+
+func (r *SchemaRepositoryImpl) TableStats(ctx context.Context, schemaName string, tables []string) ([]*model.TableStats, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var stats []*model.TableStats
+		err := r.retry.Execute(ctx, func() error {
+			stats = nil
+			return r.db.WithContext(ctx).Raw(
+				`SELECT relname AS "table", seq_scan AS seq_scans, seq_tup_read AS seq_tuples_read,
+					COALESCE(idx_scan, 0) AS index_scans, n_live_tup AS live_tuples
+				FROM pg_stat_user_tables
+				WHERE schemaname = ? AND relname IN ?
+				ORDER BY relname`,
+				schemaName, tables,
+			).Scan(&stats).Error
+		})
+		return stats, err
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.([]*model.TableStats), nil
+}
+
+type statementRow struct {
+	Queryid       int64
+	Query         string
+	Calls         int64
+	Rows          int64
+	MeanExecTime  float64
+	TotalExecTime float64
+}
+
+func (r *SchemaRepositoryImpl) SlowStatements(ctx context.Context, limit int) ([]*model.StatementStats, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var statements []*model.StatementStats
+		err := r.retry.Execute(ctx, func() error {
+			var installed bool
+			if err := r.db.WithContext(ctx).Raw(
+				`SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_stat_statements')`,
+			).Scan(&installed).Error; err != nil {
+				return err
+			}
+			if !installed {
+				statements = nil
+				return nil
+			}
+
+			var rows []statementRow
+			if err := r.db.WithContext(ctx).Raw(
+				`SELECT queryid, query, calls, rows, mean_exec_time, total_exec_time
+				FROM pg_stat_statements
+				WHERE dbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+				ORDER BY mean_exec_time DESC
+				LIMIT ?`,
+				limit,
+			).Scan(&rows).Error; err != nil {
+				return err
+			}
+
+			statements = make([]*model.StatementStats, len(rows))
+			for i, row := range rows {
+				statements[i] = &model.StatementStats{
+					QueryId:   row.Queryid,
+					Query:     row.Query,
+					Calls:     row.Calls,
+					Rows:      row.Rows,
+					MeanTime:  milliseconds(row.MeanExecTime),
+					TotalTime: milliseconds(row.TotalExecTime),
+				}
+			}
+			return nil
+		})
+		return statements, err
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.([]*model.StatementStats), nil
+}
+
+func milliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+func buildTableSchemas(columns []columnRow, indexes []indexRow) []model.TableSchema {
+	var tables []model.TableSchema
+	position := map[string]int{}
+	for _, c := range columns {
+		i, ok := position[c.TableName]
+		if !ok {
+			i = len(tables)
+			position[c.TableName] = i
+			tables = append(tables, model.TableSchema{Name: c.TableName})
+		}
+		tables[i].Columns = append(tables[i].Columns, model.ColumnSchema{
+			Name:     c.ColumnName,
+			Type:     columnType(c),
+			Nullable: c.IsNullable == "YES",
+		})
+	}
+	for _, idx := range indexes {
+		if i, ok := position[idx.Tablename]; ok {
+			tables[i].Indexes = append(tables[i].Indexes, idx.Indexname)
+		}
+	}
+	return tables
+}
+
+func columnType(c columnRow) string {
+	switch {
+	case c.CharacterMaximumLength != nil:
+		return fmt.Sprintf("%s(%d)", c.DataType, *c.CharacterMaximumLength)
+	case c.DataType == "numeric" && c.NumericPrecision != nil && c.NumericScale != nil:
+		return fmt.Sprintf("numeric(%d,%d)", *c.NumericPrecision, *c.NumericScale)
+	default:
+		return c.DataType
+	}
+}
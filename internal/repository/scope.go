@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+)
+
+// Scope narrows or orders a query. Scopes are applied with db.Scopes and
+// compose in the order given.
+type Scope = func(db *gorm.DB) *gorm.DB
+
+// Column is a column name bound to the Go type of its values, so predicates
+// built from it only accept values of that type.
+type Column[T comparable] string
+
+// Eq filters rows where column equals value. A zero value leaves the query
+// unchanged so optional filters can be passed unconditionally.
+func Eq[T comparable](column Column[T], value T) Scope {
+	return where(column, "=", value)
+}
+
+// Gt filters rows where column is strictly greater than value. A zero value
+// leaves the query unchanged.
+func Gt[T comparable](column Column[T], value T) Scope {
+	return where(column, ">", value)
+}
+
+// Gte filters rows where column is greater than or equal to value. A zero
+// value leaves the query unchanged.
+func Gte[T comparable](column Column[T], value T) Scope {
+	return where(column, ">=", value)
+}
+
+// Lt filters rows where column is strictly less than value. A zero value
+// leaves the query unchanged.
+func Lt[T comparable](column Column[T], value T) Scope {
+	return where(column, "<", value)
+}
+
+// IsNull filters rows where column is NULL.
+func IsNull[T comparable](column Column[T]) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(string(column) + " IS NULL")
+	}
+}
+
+// In filters rows where column matches any of values. An empty list leaves
+// the query unchanged.
+func In[T comparable](column Column[T], values []T) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(values) == 0 {
+			return db
+		}
+		return db.Where(string(column)+" IN ?", values)
+	}
+}
+
+// OrderBy sorts by column, descending when desc is true.
+func OrderBy[T comparable](column Column[T], desc bool) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if desc {
+			return db.Order(string(column) + " DESC")
+		}
+		return db.Order(string(column))
+	}
+}
+
+// Limit caps the number of rows returned. A non-positive n leaves the query
+// unchanged.
+func Limit(n int) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if n <= 0 {
+			return db
+		}
+		return db.Limit(n)
+	}
+}
+
+// Projection is the columns a query reads. Columns left out are zero in the
+// rows returned. A nil Projection reads every column.
+type Projection []string
+
+// Select reads only the columns in p. An empty p leaves the query unchanged.
+func Select(p Projection) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(p) == 0 {
+			return db
+		}
+		return db.Select([]string(p))
+	}
+}
+
+func where[T comparable](column Column[T], op string, value T) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		var zero T
+		if value == zero {
+			return db
+		}
+		return db.Where(string(column)+" "+op+" ?", value)
+	}
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SessionRepository interface {
+	Insert(ctx context.Context, session *model.Session) error
+	// GetByExternalId returns the session issuer knows as externalId, or nil
+	// if there is none.
+	GetByExternalId(ctx context.Context, issuer, externalId string) (*model.Session, error)
+	// ListActive returns up to limit of userId's unrevoked sessions, most
+	// recently seen first.
+	ListActive(ctx context.Context, userId int64, limit int) ([]*model.Session, error)
+	// Touch records the device an active session was seen with, unless it
+	// was already seen since staleBefore.
+	Touch(ctx context.Context, id int64, userAgent string, ip string, seenAt time.Time, staleBefore time.Time) error
+	// Revoke revokes userId's session id. It reports false when userId has
+	// no active session with that id.
+	Revoke(ctx context.Context, id int64, userId int64, revokedAt time.Time) (bool, error)
+	// RevokeAll revokes every active session of userId and returns their
+	// ids.
+	RevokeAll(ctx context.Context, userId int64, revokedAt time.Time) ([]int64, error)
+	InsertEvent(ctx context.Context, event *model.SessionEvent) error
+}
+
+const (
+	sessionId         Column[int64]     = "id"
+	sessionUserId     Column[int64]     = "user_id"
+	sessionLastSeenAt Column[time.Time] = "last_seen_at"
+	sessionRevokedAt  Column[time.Time] = "revoked_at"
+	sessionIssuer     Column[string]    = "issuer"
+	sessionExternalId Column[string]    = "external_id"
+)
+
+type SessionRepositoryImpl struct {
+	db    *gorm.DB
+	cb    circuitbreaker.CircuitBreaker
+	retry retry.Retry
+	ids   *idRegeneration
+}
+
+func NewSessionRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry) SessionRepository {
+	return newSessionRepository(db, cb, retry, nil)
+}
+
+func newSessionRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry, ids *idRegeneration) SessionRepository {
+	return &SessionRepositoryImpl{db: db, cb: cb, retry: retry, ids: ids}
+}
+
+func (r *SessionRepositoryImpl) Insert(ctx context.Context, session *model.Session) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			entity := model.SessionDataEntity(*session)
+			if err := r.ids.create(r.db.WithContext(ctx), "user_sessions", &entity.Id, &entity); err != nil {
+				return err
+			}
+			session.Id = entity.Id
+			return nil
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
+
+func (r *SessionRepositoryImpl) GetByExternalId(ctx context.Context, issuer, externalId string) (*model.Session, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var session *model.Session
+		err := r.retry.Execute(ctx, func() error {
+			var entity model.SessionDataEntity
+			if err := r.db.WithContext(ctx).Scopes(Eq(sessionIssuer, issuer), Eq(sessionExternalId, externalId)).Take(&entity).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			s := entity.ToDomain()
+			session = &s
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return session, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.(*model.Session), nil
+}
+
+func (r *SessionRepositoryImpl) ListActive(ctx context.Context, userId int64, limit int) ([]*model.Session, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var entities []model.SessionDataEntity
+		err := r.retry.Execute(ctx, func() error {
+			return r.db.WithContext(ctx).
+				Scopes(Eq(sessionUserId, userId), IsNull(sessionRevokedAt), OrderBy(sessionLastSeenAt, true), Limit(limit)).
+				Find(&entities).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+		sessions := make([]*model.Session, len(entities))
+		for i := range entities {
+			s := entities[i].ToDomain()
+			sessions[i] = &s
+		}
+		return sessions, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.([]*model.Session), nil
+}
+
+func (r *SessionRepositoryImpl) Touch(ctx context.Context, id int64, userAgent string, ip string, seenAt time.Time, staleBefore time.Time) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			return r.db.WithContext(ctx).Model(&model.SessionDataEntity{}).
+				Scopes(Eq(sessionId, id), IsNull(sessionRevokedAt), Lt(sessionLastSeenAt, staleBefore)).
+				Updates(map[string]any{"user_agent": userAgent, "ip": ip, string(sessionLastSeenAt): seenAt}).Error
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
+
+func (r *SessionRepositoryImpl) Revoke(ctx context.Context, id int64, userId int64, revokedAt time.Time) (bool, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var revoked bool
+		err := r.retry.Execute(ctx, func() error {
+			tx := r.db.WithContext(ctx).Model(&model.SessionDataEntity{}).
+				Scopes(Eq(sessionId, id), Eq(sessionUserId, userId), IsNull(sessionRevokedAt)).
+				Update(string(sessionRevokedAt), revokedAt)
+			if tx.Error != nil {
+				return tx.Error
+			}
+			revoked = tx.RowsAffected == 1
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return revoked, nil
+	})
+	if err != nil {
+		return false, classifyError(err)
+	}
+	return result.(bool), nil
+}
+
+func (r *SessionRepositoryImpl) RevokeAll(ctx context.Context, userId int64, revokedAt time.Time) ([]int64, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var entities []model.SessionDataEntity
+		err := r.retry.Execute(ctx, func() error {
+			entities = nil
+			return r.db.WithContext(ctx).Model(&entities).
+				Clauses(clause.Returning{Columns: []clause.Column{{Name: string(sessionId)}}}).
+				Scopes(Eq(sessionUserId, userId), IsNull(sessionRevokedAt)).
+				Update(string(sessionRevokedAt), revokedAt).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int64, len(entities))
+		for i := range entities {
+			ids[i] = entities[i].Id
+		}
+		return ids, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.([]int64), nil
+}
+
+func (r *SessionRepositoryImpl) InsertEvent(ctx context.Context, event *model.SessionEvent) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			entity := model.SessionEventDataEntity(*event)
+			if err := r.ids.create(r.db.WithContext(ctx), "user_session_events", &entity.Id, &entity); err != nil {
+				return err
+			}
+			event.Id = entity.Id
+			return nil
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
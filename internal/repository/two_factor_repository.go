@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TwoFactorRepository interface {
+	// Get returns userId's enrollment, or nil if there is none.
+	Get(ctx context.Context, userId int64) (*model.TwoFactor, error)
+	// Upsert stores a pending enrollment, replacing any previous one.
+	Upsert(ctx context.Context, twoFactor *model.TwoFactor) error
+	// Enable confirms the pending enrollment with the code used at step.
+	// It reports false when there is no pending enrollment left to confirm.
+	Enable(ctx context.Context, userId int64, step int64, enabledAt time.Time) (bool, error)
+	// UseStep records that the code for step was accepted. It reports false
+	// when step is not after the last used one, i.e. the code was replayed
+	// concurrently.
+	UseStep(ctx context.Context, userId int64, step int64) (bool, error)
+	// Delete removes userId's enrollment and recovery codes.
+	Delete(ctx context.Context, userId int64) error
+	// ReplaceRecoveryCodes discards userId's recovery codes and stores the
+	// hashes given.
+	ReplaceRecoveryCodes(ctx context.Context, userId int64, codeHashes []string) error
+	// UseRecoveryCode marks an unused recovery code as used. It reports
+	// false when userId has no unused code with that hash.
+	UseRecoveryCode(ctx context.Context, userId int64, codeHash string, usedAt time.Time) (bool, error)
+}
+
+const (
+	twoFactorUserId       Column[int64]     = "user_id"
+	twoFactorLastUsedStep Column[int64]     = "last_used_step"
+	twoFactorEnabledAt    Column[time.Time] = "enabled_at"
+	recoveryCodeHash      Column[string]    = "code_hash"
+	recoveryCodeUsedAt    Column[time.Time] = "used_at"
+)
+
+type TwoFactorRepositoryImpl struct {
+	db    *gorm.DB
+	cb    circuitbreaker.CircuitBreaker
+	retry retry.Retry
+}
+
+func NewTwoFactorRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry) TwoFactorRepository {
+	return &TwoFactorRepositoryImpl{db: db, cb: cb, retry: retry}
+}
+
+func (r *TwoFactorRepositoryImpl) Get(ctx context.Context, userId int64) (*model.TwoFactor, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var twoFactor *model.TwoFactor
+		err := r.retry.Execute(ctx, func() error {
+			var entity model.TwoFactorDataEntity
+			if err := r.db.WithContext(ctx).Scopes(Eq(twoFactorUserId, userId)).Take(&entity).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			t := entity.ToDomain()
+			twoFactor = &t
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return twoFactor, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.(*model.TwoFactor), nil
+}
+
+func (r *TwoFactorRepositoryImpl) Upsert(ctx context.Context, twoFactor *model.TwoFactor) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			entity := model.TwoFactorDataEntity(*twoFactor)
+			return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: string(twoFactorUserId)}},
+				UpdateAll: true,
+			}).Create(&entity).Error
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
+
+func (r *TwoFactorRepositoryImpl) Enable(ctx context.Context, userId int64, step int64, enabledAt time.Time) (bool, error) {
+	return r.update(ctx, func(db *gorm.DB) *gorm.DB {
+		return db.Model(&model.TwoFactorDataEntity{}).
+			Scopes(Eq(twoFactorUserId, userId), IsNull(twoFactorEnabledAt), Lt(twoFactorLastUsedStep, step)).
+			Updates(map[string]any{string(twoFactorEnabledAt): enabledAt, string(twoFactorLastUsedStep): step})
+	})
+}
+
+func (r *TwoFactorRepositoryImpl) UseStep(ctx context.Context, userId int64, step int64) (bool, error) {
+	return r.update(ctx, func(db *gorm.DB) *gorm.DB {
+		return db.Model(&model.TwoFactorDataEntity{}).
+			Scopes(Eq(twoFactorUserId, userId), Lt(twoFactorLastUsedStep, step)).
+			Update(string(twoFactorLastUsedStep), step)
+	})
+}
+
+func (r *TwoFactorRepositoryImpl) Delete(ctx context.Context, userId int64) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			db := r.db.WithContext(ctx)
+			if err := db.Scopes(Eq(twoFactorUserId, userId)).Delete(&model.RecoveryCodeDataEntity{}).Error; err != nil {
+				return err
+			}
+			return db.Scopes(Eq(twoFactorUserId, userId)).Delete(&model.TwoFactorDataEntity{}).Error
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
+
+func (r *TwoFactorRepositoryImpl) ReplaceRecoveryCodes(ctx context.Context, userId int64, codeHashes []string) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			db := r.db.WithContext(ctx)
+			if err := db.Scopes(Eq(twoFactorUserId, userId)).Delete(&model.RecoveryCodeDataEntity{}).Error; err != nil {
+				return err
+			}
+			if len(codeHashes) == 0 {
+				return nil
+			}
+			entities := make([]model.RecoveryCodeDataEntity, len(codeHashes))
+			for i, hash := range codeHashes {
+				entities[i] = model.RecoveryCodeDataEntity{UserId: userId, CodeHash: hash}
+			}
+			return db.Create(&entities).Error
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
+
+func (r *TwoFactorRepositoryImpl) UseRecoveryCode(ctx context.Context, userId int64, codeHash string, usedAt time.Time) (bool, error) {
+	return r.update(ctx, func(db *gorm.DB) *gorm.DB {
+		return db.Model(&model.RecoveryCodeDataEntity{}).
+			Scopes(Eq(twoFactorUserId, userId), Eq(recoveryCodeHash, codeHash), IsNull(recoveryCodeUsedAt)).
+			Update(string(recoveryCodeUsedAt), usedAt)
+	})
+}
+
+// update runs a conditional update and reports whether it changed a row.
+func (r *TwoFactorRepositoryImpl) update(ctx context.Context, run func(db *gorm.DB) *gorm.DB) (bool, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var updated bool
+		err := r.retry.Execute(ctx, func() error {
+			tx := run(r.db.WithContext(ctx))
+			if tx.Error != nil {
+				return tx.Error
+			}
+			updated = tx.RowsAffected == 1
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return updated, nil
+	})
+	if err != nil {
+		return false, classifyError(err)
+	}
+	return result.(bool), nil
+}
@@ -16,23 +16,64 @@ type UnitOfWork interface {
 	UserRepository() UserRepository
 	LedgerRepository() LedgerRepository
 	IdempotencyRecordRepository() idempotency.RecordRepository
+	IdempotencyQuarantineRepository() IdempotencyQuarantineRepository
+	DeadLetterRepository() DeadLetterRepository
+	InboxRepository() InboxRepository
+	UserStatusChangeRepository() UserStatusChangeRepository
+	UsageRepository() UsageRepository
+	LoginFailureRepository() LoginFailureRepository
+	TwoFactorRepository() TwoFactorRepository
+	SessionRepository() SessionRepository
+	UserIdentityRepository() UserIdentityRepository
+	VerificationTokenRepository() VerificationTokenRepository
+	PasswordResetTokenRepository() PasswordResetTokenRepository
+	RoleRepository() RoleRepository
 }
 
 type transactionDbUnitOfWork struct {
-	tx                              *gorm.DB
-	cb                              circuitbreaker.CircuitBreaker
-	retry                           retry.Retry
-	userRepository                  UserRepository
-	userRepositoryOnce              sync.Once
-	ledgerRepository                LedgerRepository
-	ledgerRepositoryOnce            sync.Once
-	idempotencyRecordRepository     idempotency.RecordRepository
-	idempotencyRecordRepositoryOnce sync.Once
+	tx                                  *gorm.DB
+	cb                                  circuitbreaker.CircuitBreaker
+	retry                               retry.Retry
+	in                                  *instrumentation
+	ids                                 *idRegeneration
+	userRepository                      UserRepository
+	userRepositoryOnce                  sync.Once
+	ledgerRepository                    LedgerRepository
+	ledgerRepositoryOnce                sync.Once
+	idempotencyRecordRepository         idempotency.RecordRepository
+	idempotencyRecordRepositoryOnce     sync.Once
+	idempotencyQuarantineRepository     IdempotencyQuarantineRepository
+	idempotencyQuarantineRepositoryOnce sync.Once
+	deadLetterRepository                DeadLetterRepository
+	deadLetterRepositoryOnce            sync.Once
+	inboxRepository                     InboxRepository
+	inboxRepositoryOnce                 sync.Once
+	userStatusChangeRepository          UserStatusChangeRepository
+	userStatusChangeRepositoryOnce      sync.Once
+	usageRepository                     UsageRepository
+	usageRepositoryOnce                 sync.Once
+	loginFailureRepository              LoginFailureRepository
+	loginFailureRepositoryOnce          sync.Once
+	twoFactorRepository                 TwoFactorRepository
+	twoFactorRepositoryOnce             sync.Once
+	sessionRepository                   SessionRepository
+	sessionRepositoryOnce               sync.Once
+	userIdentityRepository              UserIdentityRepository
+	userIdentityRepositoryOnce          sync.Once
+	verificationTokenRepository         VerificationTokenRepository
+	verificationTokenRepositoryOnce     sync.Once
+	passwordResetTokenRepository        PasswordResetTokenRepository
+	passwordResetTokenRepositoryOnce    sync.Once
+	roleRepository                      RoleRepository
+	roleRepositoryOnce                  sync.Once
 }
 
 func (u *transactionDbUnitOfWork) UserRepository() UserRepository {
 	u.userRepositoryOnce.Do(func() {
-		u.userRepository = NewUserRepository(u.tx, u.cb, u.retry, false)
+		u.userRepository = newUserRepository(u.tx, u.cb, u.retry, false, u.ids)
+		if u.in != nil {
+			u.userRepository = &instrumentedUserRepository{next: u.userRepository, in: u.in}
+		}
 	})
 	return u.userRepository
 }
@@ -40,6 +81,9 @@ func (u *transactionDbUnitOfWork) UserRepository() UserRepository {
 func (u *transactionDbUnitOfWork) LedgerRepository() LedgerRepository {
 	u.ledgerRepositoryOnce.Do(func() {
 		u.ledgerRepository = NewLedgerRepository(u.tx, u.cb, u.retry, false)
+		if u.in != nil {
+			u.ledgerRepository = &instrumentedLedgerRepository{next: u.ledgerRepository, in: u.in}
+		}
 	})
 	return u.ledgerRepository
 }
@@ -47,10 +91,133 @@ func (u *transactionDbUnitOfWork) LedgerRepository() LedgerRepository {
 func (u *transactionDbUnitOfWork) IdempotencyRecordRepository() idempotency.RecordRepository {
 	u.idempotencyRecordRepositoryOnce.Do(func() {
 		u.idempotencyRecordRepository = NewIdempotencyRecordRepository(u.tx, u.cb, u.retry, false)
+		if u.in != nil {
+			u.idempotencyRecordRepository = &instrumentedIdempotencyRecordRepository{next: u.idempotencyRecordRepository, in: u.in}
+		}
 	})
 	return u.idempotencyRecordRepository
 }
 
+func (u *transactionDbUnitOfWork) IdempotencyQuarantineRepository() IdempotencyQuarantineRepository {
+	u.idempotencyQuarantineRepositoryOnce.Do(func() {
+		u.idempotencyQuarantineRepository = NewIdempotencyQuarantineRepository(u.tx, u.cb, u.retry)
+		if u.in != nil {
+			u.idempotencyQuarantineRepository = &instrumentedIdempotencyQuarantineRepository{next: u.idempotencyQuarantineRepository, in: u.in}
+		}
+	})
+	return u.idempotencyQuarantineRepository
+}
+
+func (u *transactionDbUnitOfWork) DeadLetterRepository() DeadLetterRepository {
+	u.deadLetterRepositoryOnce.Do(func() {
+		u.deadLetterRepository = newDeadLetterRepository(u.tx, u.cb, u.retry, false, u.ids)
+		if u.in != nil {
+			u.deadLetterRepository = &instrumentedDeadLetterRepository{next: u.deadLetterRepository, in: u.in}
+		}
+	})
+	return u.deadLetterRepository
+}
+
+func (u *transactionDbUnitOfWork) InboxRepository() InboxRepository {
+	u.inboxRepositoryOnce.Do(func() {
+		u.inboxRepository = NewInboxRepository(u.tx, u.cb, u.retry)
+		if u.in != nil {
+			u.inboxRepository = &instrumentedInboxRepository{next: u.inboxRepository, in: u.in}
+		}
+	})
+	return u.inboxRepository
+}
+
+func (u *transactionDbUnitOfWork) UserStatusChangeRepository() UserStatusChangeRepository {
+	u.userStatusChangeRepositoryOnce.Do(func() {
+		u.userStatusChangeRepository = newUserStatusChangeRepository(u.tx, u.cb, u.retry, u.ids)
+		if u.in != nil {
+			u.userStatusChangeRepository = &instrumentedUserStatusChangeRepository{next: u.userStatusChangeRepository, in: u.in}
+		}
+	})
+	return u.userStatusChangeRepository
+}
+
+func (u *transactionDbUnitOfWork) UsageRepository() UsageRepository {
+	u.usageRepositoryOnce.Do(func() {
+		u.usageRepository = NewUsageRepository(u.tx, u.cb, u.retry)
+		if u.in != nil {
+			u.usageRepository = &instrumentedUsageRepository{next: u.usageRepository, in: u.in}
+		}
+	})
+	return u.usageRepository
+}
+
+func (u *transactionDbUnitOfWork) LoginFailureRepository() LoginFailureRepository {
+	u.loginFailureRepositoryOnce.Do(func() {
+		u.loginFailureRepository = NewLoginFailureRepository(u.tx, u.cb, u.retry)
+		if u.in != nil {
+			u.loginFailureRepository = &instrumentedLoginFailureRepository{next: u.loginFailureRepository, in: u.in}
+		}
+	})
+	return u.loginFailureRepository
+}
+
+func (u *transactionDbUnitOfWork) TwoFactorRepository() TwoFactorRepository {
+	u.twoFactorRepositoryOnce.Do(func() {
+		u.twoFactorRepository = NewTwoFactorRepository(u.tx, u.cb, u.retry)
+		if u.in != nil {
+			u.twoFactorRepository = &instrumentedTwoFactorRepository{next: u.twoFactorRepository, in: u.in}
+		}
+	})
+	return u.twoFactorRepository
+}
+
+func (u *transactionDbUnitOfWork) SessionRepository() SessionRepository {
+	u.sessionRepositoryOnce.Do(func() {
+		u.sessionRepository = newSessionRepository(u.tx, u.cb, u.retry, u.ids)
+		if u.in != nil {
+			u.sessionRepository = &instrumentedSessionRepository{next: u.sessionRepository, in: u.in}
+		}
+	})
+	return u.sessionRepository
+}
+
+func (u *transactionDbUnitOfWork) UserIdentityRepository() UserIdentityRepository {
+	u.userIdentityRepositoryOnce.Do(func() {
+		u.userIdentityRepository = NewUserIdentityRepository(u.tx, u.cb, u.retry)
+		if u.in != nil {
+			u.userIdentityRepository = &instrumentedUserIdentityRepository{next: u.userIdentityRepository, in: u.in}
+		}
+	})
+	return u.userIdentityRepository
+}
+
+func (u *transactionDbUnitOfWork) VerificationTokenRepository() VerificationTokenRepository {
+	u.verificationTokenRepositoryOnce.Do(func() {
+		u.verificationTokenRepository = NewVerificationTokenRepository(u.tx, u.cb, u.retry)
+		if u.in != nil {
+			u.verificationTokenRepository = &instrumentedVerificationTokenRepository{next: u.verificationTokenRepository, in: u.in}
+		}
+	})
+	return u.verificationTokenRepository
+}
+
+func (u *transactionDbUnitOfWork) PasswordResetTokenRepository() PasswordResetTokenRepository {
+	u.passwordResetTokenRepositoryOnce.Do(func() {
+		u.passwordResetTokenRepository = NewPasswordResetTokenRepository(u.tx, u.cb, u.retry)
+		if u.in != nil {
+			u.passwordResetTokenRepository = &instrumentedPasswordResetTokenRepository{next: u.passwordResetTokenRepository, in: u.in}
+		}
+	})
+	return u.passwordResetTokenRepository
+}
+
+func (u *transactionDbUnitOfWork) RoleRepository() RoleRepository {
+	u.roleRepositoryOnce.Do(func() {
+		u.roleRepository = NewRoleRepository(u.tx, u.cb, u.retry)
+		if u.in != nil {
+			u.roleRepository = &instrumentedRoleRepository{next: u.roleRepository, in: u.in}
+		}
+	})
+	return u.roleRepository
+}
+
 func (u *transactionDbUnitOfWork) Commit(ctx context.Context) error {
 	return u.tx.WithContext(ctx).Commit().Error
 }
@@ -1,29 +1,94 @@
 package repository
 
 import (
+	"context"
+	"strconv"
+	"time"
+
 	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
 	"github.com/jt828/go-grpc-template/pkg/retry"
 	"gorm.io/gorm"
 )
 
 type UnitOfWorkFactory interface {
-	New() (UnitOfWork, error)
+	// New begins a unit of work on behalf of ctx. The context is not kept;
+	// pass it again to every repository call, Commit and Abort.
+	New(ctx context.Context) (UnitOfWork, error)
+}
+
+// Option configures a UnitOfWorkFactory.
+type Option func(*factoryOptions)
+
+type factoryOptions struct {
+	in               *instrumentation
+	ids              *idRegeneration
+	deadlineTimeouts bool
+}
+
+// instrumentation returns the repository decorators' settings, creating
+// them on first use so that a factory without instrumentation options hands
+// out undecorated repositories.
+func (o *factoryOptions) instrumentation() *instrumentation {
+	if o.in == nil {
+		o.in = &instrumentation{}
+	}
+	return o.in
+}
+
+// WithDeadlineTimeouts bounds each transaction by the deadline of the
+// context passed to New. The transaction's statement_timeout and
+// idle_in_transaction_session_timeout are set to the time remaining, so once
+// the caller has given up, Postgres cancels the running statement or ends the
+// idle transaction and releases its locks and connection. Contexts without a
+// deadline leave the server's settings in place.
+func WithDeadlineTimeouts() Option {
+	return func(o *factoryOptions) {
+		o.deadlineTimeouts = true
+	}
 }
 
 type transactionDbUnitOfWorkFactory struct {
-	db    *gorm.DB
-	cb    circuitbreaker.CircuitBreaker
-	retry retry.Retry
+	db               *gorm.DB
+	cb               circuitbreaker.CircuitBreaker
+	retry            retry.Retry
+	in               *instrumentation
+	ids              *idRegeneration
+	deadlineTimeouts bool
 }
 
-func NewTransactionDbUnitOfWorkFactory(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry) UnitOfWorkFactory {
-	return &transactionDbUnitOfWorkFactory{db: db, cb: cb, retry: retry}
+// NewTransactionDbUnitOfWorkFactory creates units of work backed by a
+// database transaction. Options such as WithMetrics, WithTracing and
+// WithLogging decorate the repositories each unit of work hands out.
+func NewTransactionDbUnitOfWorkFactory(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry, opts ...Option) UnitOfWorkFactory {
+	o := &factoryOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &transactionDbUnitOfWorkFactory{db: db, cb: cb, retry: retry, in: o.in, ids: o.ids, deadlineTimeouts: o.deadlineTimeouts}
 }
 
-func (f *transactionDbUnitOfWorkFactory) New() (UnitOfWork, error) {
+func (f *transactionDbUnitOfWorkFactory) New(ctx context.Context) (UnitOfWork, error) {
+	deadline, hasDeadline := ctx.Deadline()
+	if f.deadlineTimeouts && hasDeadline && !time.Now().Before(deadline) {
+		return nil, context.DeadlineExceeded
+	}
+
 	tx := f.db.Begin()
 	if tx.Error != nil {
 		return nil, tx.Error
 	}
-	return &transactionDbUnitOfWork{tx: tx, cb: f.cb, retry: f.retry}, nil
+
+	if f.deadlineTimeouts && hasDeadline {
+		// Postgres treats a timeout of 0 as no timeout, so round up.
+		timeout := strconv.FormatInt(time.Until(deadline).Milliseconds()+1, 10)
+		err := tx.WithContext(ctx).Exec(
+			"SELECT set_config('statement_timeout', ?, true), set_config('idle_in_transaction_session_timeout', ?, true)",
+			timeout, timeout,
+		).Error
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return &transactionDbUnitOfWork{tx: tx, cb: f.cb, retry: f.retry, in: f.in, ids: f.ids}, nil
 }
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UsageRepository interface {
+	// Add adds the requests and units of each row to the stored totals for
+	// its day, caller and method, in one statement.
+	Add(ctx context.Context, usage []*model.ApiUsage) error
+	ListByDate(ctx context.Context, date time.Time) ([]*model.ApiUsage, error)
+	// UnreportedDates returns, oldest first, the days before before that
+	// have usage but no report.
+	UnreportedDates(ctx context.Context, before time.Time) ([]time.Time, error)
+	// MarkReported records that date's usage was reported and reports
+	// whether this call did so. A concurrent transaction marking the same
+	// day blocks until the first one ends, so only one of them sees true.
+	MarkReported(ctx context.Context, date time.Time, reportedAt time.Time) (bool, error)
+}
+
+const (
+	usageDate   Column[time.Time] = "usage_date"
+	usageCaller Column[string]    = "caller"
+	usageMethod Column[string]    = "method"
+)
+
+type UsageRepositoryImpl struct {
+	db    *gorm.DB
+	cb    circuitbreaker.CircuitBreaker
+	retry retry.Retry
+}
+
+func NewUsageRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry) UsageRepository {
+	return &UsageRepositoryImpl{db: db, cb: cb, retry: retry}
+}
+
+func (r *UsageRepositoryImpl) Add(ctx context.Context, usage []*model.ApiUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			entities := make([]model.ApiUsageDataEntity, len(usage))
+			for i, u := range usage {
+				entities[i] = model.ApiUsageDataEntity(*u)
+			}
+			return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: string(usageDate)}, {Name: string(usageCaller)}, {Name: string(usageMethod)}},
+				DoUpdates: clause.Set{
+					{Column: clause.Column{Name: "requests"}, Value: gorm.Expr("api_usage.requests + excluded.requests")},
+					{Column: clause.Column{Name: "units"}, Value: gorm.Expr("api_usage.units + excluded.units")},
+				},
+			}).Create(&entities).Error
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
+
+func (r *UsageRepositoryImpl) ListByDate(ctx context.Context, date time.Time) ([]*model.ApiUsage, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var usage []*model.ApiUsage
+		err := r.retry.Execute(ctx, func() error {
+			var entities []model.ApiUsageDataEntity
+			if err := r.db.WithContext(ctx).
+				Where(string(usageDate)+" = ?", date).
+				Scopes(OrderBy(usageCaller, false), OrderBy(usageMethod, false)).
+				Find(&entities).Error; err != nil {
+				return err
+			}
+			usage = make([]*model.ApiUsage, len(entities))
+			for i := range entities {
+				u := entities[i].ToDomain()
+				usage[i] = &u
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return usage, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.([]*model.ApiUsage), nil
+}
+
+func (r *UsageRepositoryImpl) UnreportedDates(ctx context.Context, before time.Time) ([]time.Time, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var dates []time.Time
+		err := r.retry.Execute(ctx, func() error {
+			db := r.db.WithContext(ctx)
+			return db.Model(&model.ApiUsageDataEntity{}).
+				Distinct(string(usageDate)).
+				Scopes(Lt(usageDate, before)).
+				Where(string(usageDate)+" NOT IN (?)", db.Model(&model.ApiUsageReportDataEntity{}).Select(string(usageDate))).
+				Scopes(OrderBy(usageDate, false)).
+				Pluck(string(usageDate), &dates).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+		return dates, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.([]time.Time), nil
+}
+
+func (r *UsageRepositoryImpl) MarkReported(ctx context.Context, date time.Time, reportedAt time.Time) (bool, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var marked bool
+		err := r.retry.Execute(ctx, func() error {
+			entity := model.ApiUsageReportDataEntity{UsageDate: date, ReportedAt: reportedAt}
+			tx := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&entity)
+			if tx.Error != nil {
+				return tx.Error
+			}
+			marked = tx.RowsAffected == 1
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return marked, nil
+	})
+	if err != nil {
+		return false, classifyError(err)
+	}
+	return result.(bool), nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	"gorm.io/gorm"
+)
+
+type UserIdentityRepository interface {
+	// Get returns the link of issuer's subject, or nil if it is not linked.
+	Get(ctx context.Context, issuer, subject string) (*model.UserIdentity, error)
+	// Insert links a subject. It fails with a unique violation when the
+	// subject is already linked.
+	Insert(ctx context.Context, identity *model.UserIdentity) error
+	// Delete unlinks issuer's subject. It reports false when it was not
+	// linked.
+	Delete(ctx context.Context, issuer, subject string) (bool, error)
+}
+
+const (
+	userIdentityIssuer  Column[string] = "issuer"
+	userIdentitySubject Column[string] = "subject"
+)
+
+type UserIdentityRepositoryImpl struct {
+	db    *gorm.DB
+	cb    circuitbreaker.CircuitBreaker
+	retry retry.Retry
+}
+
+func NewUserIdentityRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry) UserIdentityRepository {
+	return &UserIdentityRepositoryImpl{db: db, cb: cb, retry: retry}
+}
+
+func (r *UserIdentityRepositoryImpl) Get(ctx context.Context, issuer, subject string) (*model.UserIdentity, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var identity *model.UserIdentity
+		err := r.retry.Execute(ctx, func() error {
+			var entity model.UserIdentityDataEntity
+			if err := r.db.WithContext(ctx).Scopes(Eq(userIdentityIssuer, issuer), Eq(userIdentitySubject, subject)).Take(&entity).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			i := entity.ToDomain()
+			identity = &i
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return identity, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.(*model.UserIdentity), nil
+}
+
+func (r *UserIdentityRepositoryImpl) Insert(ctx context.Context, identity *model.UserIdentity) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			entity := model.UserIdentityDataEntity(*identity)
+			if err := r.db.WithContext(ctx).Create(&entity).Error; err != nil {
+				return err
+			}
+			// Hand back the actor the audit plugin stamped.
+			identity.CreatedBy = entity.CreatedBy
+			return nil
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
+
+func (r *UserIdentityRepositoryImpl) Delete(ctx context.Context, issuer, subject string) (bool, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var deleted bool
+		err := r.retry.Execute(ctx, func() error {
+			tx := r.db.WithContext(ctx).Scopes(Eq(userIdentityIssuer, issuer), Eq(userIdentitySubject, subject)).Delete(&model.UserIdentityDataEntity{})
+			if tx.Error != nil {
+				return tx.Error
+			}
+			deleted = tx.RowsAffected == 1
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return deleted, nil
+	})
+	if err != nil {
+		return false, classifyError(err)
+	}
+	return result.(bool), nil
+}
@@ -2,29 +2,75 @@ package repository
 
 import (
 	"context"
-
 	"errors"
+	"time"
 
 	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
 	"github.com/jt828/go-grpc-template/pkg/model"
 	"github.com/jt828/go-grpc-template/pkg/retry"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
 	Get(ctx context.Context, id int64) (*model.User, error)
+	// GetByEmail returns the user with email, or nil if there is none.
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	// GetByIds returns the users that exist among ids, in no particular
+	// order, reading only the columns in projection. An empty ids returns no
+	// users.
+	GetByIds(ctx context.Context, ids []int64, projection Projection) ([]*model.User, error)
+	// Insert inserts user and writes the row as stored back into it,
+	// including database defaults and stamped actors and times, and the id
+	// if a taken one was regenerated. A taken email fails with a
+	// *ConstraintError for field "email" wrapping apperror.ErrAlreadyExists.
 	Insert(ctx context.Context, user *model.User) error
+	// UpdateStatus writes user's status if the row is still at user.Version,
+	// and bumps its version. It reports false when the user is no longer at
+	// that version.
+	UpdateStatus(ctx context.Context, user *model.User) (bool, error)
+	// UpdateProfile writes user's email, username and email verification
+	// time if the row is still at user.Version, and bumps its version. It
+	// reports false when the user is no longer at that version.
+	UpdateProfile(ctx context.Context, user *model.User) (bool, error)
+	// UpdatePassword writes user's password hash if the row is still at
+	// user.Version, and bumps its version. It reports false when the user
+	// is no longer at that version.
+	UpdatePassword(ctx context.Context, user *model.User) (bool, error)
+	// MarkEmailVerified writes user's email verification time if the row is
+	// still at user.Version and still has user's email, and bumps its
+	// version. It reports false when the user has changed since.
+	MarkEmailVerified(ctx context.Context, user *model.User) (bool, error)
 }
 
+const (
+	userId    Column[int64]  = "id"
+	userEmail Column[string] = "email"
+)
+
+var (
+	// UserSummaryProjection reads what a model.User embedded in another
+	// resource shows: its id, username and status.
+	UserSummaryProjection = Projection{"id", "username", "status"}
+	// UserPublicProjection reads the columns a v1.User returns, leaving out
+	// the password hash and the actor columns.
+	UserPublicProjection = Projection{"id", "email", "username", "status", "created_at", "updated_at", "version", "email_verified_at"}
+)
+
 type UserRepositoryImpl struct {
 	db              *gorm.DB
 	cb              circuitbreaker.CircuitBreaker
 	retry           retry.Retry
 	notFoundAsError bool
+	ids             *idRegeneration
 }
 
 func NewUserRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry, notFoundAsError bool) UserRepository {
-	return &UserRepositoryImpl{db: db, cb: cb, retry: retry, notFoundAsError: notFoundAsError}
+	return newUserRepository(db, cb, retry, notFoundAsError, nil)
+}
+
+func newUserRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry, notFoundAsError bool, ids *idRegeneration) UserRepository {
+	return &UserRepositoryImpl{db: db, cb: cb, retry: retry, notFoundAsError: notFoundAsError, ids: ids}
 }
 
 func (r *UserRepositoryImpl) Get(ctx context.Context, id int64) (*model.User, error) {
@@ -48,11 +94,70 @@ func (r *UserRepositoryImpl) Get(ctx context.Context, id int64) (*model.User, er
 		return user, nil
 	})
 	if err != nil {
-		return nil, err
+		return nil, classifyError(err)
+	}
+	return result.(*model.User), nil
+}
+
+func (r *UserRepositoryImpl) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	// Eq ignores a zero value, which would match any user.
+	if email == "" {
+		return nil, nil
+	}
+	result, err := r.cb.Execute(func() (any, error) {
+		var user *model.User
+		err := r.retry.Execute(ctx, func() error {
+			var entity model.UserDataEntity
+			if err := r.db.WithContext(ctx).Scopes(Eq(userEmail, email)).Take(&entity).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			u := entity.ToDomain()
+			user = &u
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return user, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
 	}
 	return result.(*model.User), nil
 }
 
+func (r *UserRepositoryImpl) GetByIds(ctx context.Context, ids []int64, projection Projection) ([]*model.User, error) {
+	if len(ids) == 0 {
+		return []*model.User{}, nil
+	}
+	result, err := r.cb.Execute(func() (any, error) {
+		var users []*model.User
+		err := r.retry.Execute(ctx, func() error {
+			var entities []model.UserDataEntity
+			if err := r.db.WithContext(ctx).Scopes(Select(projection), In(userId, ids)).Find(&entities).Error; err != nil {
+				return err
+			}
+			users = make([]*model.User, len(entities))
+			for i := range entities {
+				u := entities[i].ToDomain()
+				users[i] = &u
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return users, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.([]*model.User), nil
+}
+
 func (r *UserRepositoryImpl) Insert(ctx context.Context, user *model.User) error {
 	_, err := r.cb.Execute(func() (any, error) {
 		err := r.retry.Execute(ctx, func() error {
@@ -61,12 +166,70 @@ func (r *UserRepositoryImpl) Insert(ctx context.Context, user *model.User) error
 				Email:     user.Email,
 				Username:  user.Username,
 				Password:  user.Password,
+				Status:    user.Status,
 				CreatedAt: user.CreatedAt,
 				UpdatedAt: user.UpdatedAt,
+				CreatedBy: user.CreatedBy,
+				UpdatedBy: user.UpdatedBy,
+				Version:   1,
 			}
-			return r.db.WithContext(ctx).Create(&entity).Error
+			if err := r.ids.create(r.db.WithContext(ctx).Clauses(clause.Returning{}), "users", &entity.Id, &entity); err != nil {
+				return err
+			}
+			*user = entity.ToDomain()
+			return nil
 		})
 		return nil, err
 	})
-	return err
+	return classifyError(err)
+}
+
+func (r *UserRepositoryImpl) UpdateStatus(ctx context.Context, user *model.User) (bool, error) {
+	return r.updateAtVersion(ctx, user, map[string]any{"status": user.Status})
+}
+
+func (r *UserRepositoryImpl) UpdateProfile(ctx context.Context, user *model.User) (bool, error) {
+	return r.updateAtVersion(ctx, user, map[string]any{"email": user.Email, "username": user.Username, "email_verified_at": user.EmailVerifiedAt})
+}
+
+func (r *UserRepositoryImpl) UpdatePassword(ctx context.Context, user *model.User) (bool, error) {
+	return r.updateAtVersion(ctx, user, map[string]any{"password": user.Password})
+}
+
+func (r *UserRepositoryImpl) MarkEmailVerified(ctx context.Context, user *model.User) (bool, error) {
+	return r.updateAtVersion(ctx, user, map[string]any{"email_verified_at": user.EmailVerifiedAt}, Eq(userEmail, user.Email))
+}
+
+// updateAtVersion writes values to user's row if it is still at
+// user.Version and matches scopes, bumping its version, and hands back the
+// updated_at the timestamp plugin stamped. It reports whether the row was
+// updated.
+func (r *UserRepositoryImpl) updateAtVersion(ctx context.Context, user *model.User, values map[string]any, scopes ...Scope) (bool, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var updated bool
+		err := r.retry.Execute(ctx, func() error {
+			values["version"] = gorm.Expr("version + 1")
+			tx := r.db.WithContext(ctx).
+				Model(&model.UserDataEntity{}).
+				Where("id = ? AND version = ?", user.Id, user.Version).
+				Scopes(scopes...).
+				Updates(values)
+			if tx.Error != nil {
+				return tx.Error
+			}
+			updated = tx.RowsAffected == 1
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return updated, nil
+	})
+	if err != nil {
+		return false, classifyError(err)
+	}
+	if at, ok := values["updated_at"].(time.Time); ok && result.(bool) {
+		user.UpdatedAt = at
+	}
+	return result.(bool), nil
 }
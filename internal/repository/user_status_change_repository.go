@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	"gorm.io/gorm"
+)
+
+type UserStatusChangeRepository interface {
+	Insert(ctx context.Context, change *model.UserStatusChange) error
+}
+
+type UserStatusChangeRepositoryImpl struct {
+	db    *gorm.DB
+	cb    circuitbreaker.CircuitBreaker
+	retry retry.Retry
+	ids   *idRegeneration
+}
+
+func NewUserStatusChangeRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry) UserStatusChangeRepository {
+	return newUserStatusChangeRepository(db, cb, retry, nil)
+}
+
+func newUserStatusChangeRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry, ids *idRegeneration) UserStatusChangeRepository {
+	return &UserStatusChangeRepositoryImpl{db: db, cb: cb, retry: retry, ids: ids}
+}
+
+func (r *UserStatusChangeRepositoryImpl) Insert(ctx context.Context, change *model.UserStatusChange) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			entity := model.UserStatusChangeDataEntity(*change)
+			if err := r.ids.create(r.db.WithContext(ctx), "user_status_changes", &entity.Id, &entity); err != nil {
+				return err
+			}
+			change.Id = entity.Id
+			return nil
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
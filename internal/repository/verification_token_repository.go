@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	"gorm.io/gorm"
+)
+
+type VerificationTokenRepository interface {
+	// Get returns the token with tokenHash, or nil if there is none.
+	Get(ctx context.Context, tokenHash string) (*model.VerificationToken, error)
+	// Insert stores a new token.
+	Insert(ctx context.Context, token *model.VerificationToken) error
+	// Use marks an unused token as used. It reports false when the token
+	// was used already, i.e. it was redeemed concurrently.
+	Use(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error)
+	// DeleteUnused removes userId's unused tokens, so only the link sent
+	// last works.
+	DeleteUnused(ctx context.Context, userId int64) error
+}
+
+const (
+	verificationTokenHash   Column[string]    = "token_hash"
+	verificationTokenUserId Column[int64]     = "user_id"
+	verificationTokenUsedAt Column[time.Time] = "used_at"
+)
+
+type VerificationTokenRepositoryImpl struct {
+	db    *gorm.DB
+	cb    circuitbreaker.CircuitBreaker
+	retry retry.Retry
+}
+
+func NewVerificationTokenRepository(db *gorm.DB, cb circuitbreaker.CircuitBreaker, retry retry.Retry) VerificationTokenRepository {
+	return &VerificationTokenRepositoryImpl{db: db, cb: cb, retry: retry}
+}
+
+func (r *VerificationTokenRepositoryImpl) Get(ctx context.Context, tokenHash string) (*model.VerificationToken, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var token *model.VerificationToken
+		err := r.retry.Execute(ctx, func() error {
+			var entity model.VerificationTokenDataEntity
+			if err := r.db.WithContext(ctx).Scopes(Eq(verificationTokenHash, tokenHash)).Take(&entity).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			t := entity.ToDomain()
+			token = &t
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return token, nil
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return result.(*model.VerificationToken), nil
+}
+
+func (r *VerificationTokenRepositoryImpl) Insert(ctx context.Context, token *model.VerificationToken) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			entity := model.VerificationTokenDataEntity(*token)
+			return r.db.WithContext(ctx).Create(&entity).Error
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
+
+func (r *VerificationTokenRepositoryImpl) Use(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error) {
+	result, err := r.cb.Execute(func() (any, error) {
+		var used bool
+		err := r.retry.Execute(ctx, func() error {
+			tx := r.db.WithContext(ctx).
+				Model(&model.VerificationTokenDataEntity{}).
+				Scopes(Eq(verificationTokenHash, tokenHash), IsNull(verificationTokenUsedAt)).
+				Update(string(verificationTokenUsedAt), usedAt)
+			if tx.Error != nil {
+				return tx.Error
+			}
+			used = tx.RowsAffected == 1
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return used, nil
+	})
+	if err != nil {
+		return false, classifyError(err)
+	}
+	return result.(bool), nil
+}
+
+func (r *VerificationTokenRepositoryImpl) DeleteUnused(ctx context.Context, userId int64) error {
+	_, err := r.cb.Execute(func() (any, error) {
+		err := r.retry.Execute(ctx, func() error {
+			return r.db.WithContext(ctx).
+				Scopes(Eq(verificationTokenUserId, userId), IsNull(verificationTokenUsedAt)).
+				Delete(&model.VerificationTokenDataEntity{}).Error
+		})
+		return nil, err
+	})
+	return classifyError(err)
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/model"
+)
+
+type ConfigService interface {
+	GetConfig(ctx context.Context) *model.EffectiveConfig
+}
+
+type configService struct {
+	config model.EffectiveConfig
+}
+
+// NewConfigService serves the configuration the process started with.
+// entries must already have secrets redacted.
+func NewConfigService(entries []model.ConfigEntry, startedAt time.Time) ConfigService {
+	return &configService{config: model.EffectiveConfig{StartedAt: startedAt, Entries: entries}}
+}
+
+func (s *configService) GetConfig(ctx context.Context) *model.EffectiveConfig {
+	config := s.config
+	config.Entries = append([]model.ConfigEntry(nil), s.config.Entries...)
+	return &config
+}
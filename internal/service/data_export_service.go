@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"github.com/jt828/go-grpc-template/pkg/idempotency"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+)
+
+// exportPageSize is how many ledger entries or idempotency records
+// ExportUserData reads per unit of work.
+const exportPageSize = 500
+
+// UserDataExport receives the parts of a user's data export, in order: the
+// profile, then the ledger entries, then the idempotency records, each page
+// by page in id order. An error stops the export and is returned by
+// ExportUserData.
+type UserDataExport interface {
+	Profile(user *model.User) error
+	Ledgers(ledgers []*model.Ledger) error
+	IdempotencyRecords(records []*idempotency.Record) error
+}
+
+// DataExportService assembles everything the service stores about a user,
+// for data portability requests.
+type DataExportService interface {
+	// ExportUserData hands export the profile, ledger entries and idempotency
+	// history of userId. Each page is read in its own unit of work and handed
+	// over once it has committed, so a retried transaction never repeats
+	// output, and a large export holds no transaction open while export
+	// sends it on. It reports false, exporting nothing, when the user does
+	// not exist.
+	ExportUserData(ctx context.Context, userId int64, export UserDataExport) (bool, error)
+}
+
+type dataExportService struct {
+	uowFactory repository.UnitOfWorkFactory
+	log        observability.Logger
+}
+
+// NewDataExportService returns a DataExportService reading through
+// uowFactory.
+func NewDataExportService(uowFactory repository.UnitOfWorkFactory, log observability.Logger) DataExportService {
+	return &dataExportService{uowFactory: uowFactory, log: log}
+}
+
+func (s *dataExportService) ExportUserData(ctx context.Context, userId int64, export UserDataExport) (bool, error) {
+	user, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (*model.User, error) {
+		return uow.UserRepository().Get(ctx, userId)
+	})
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return false, nil
+	}
+	if err := export.Profile(user); err != nil {
+		return false, err
+	}
+
+	ledgers, err := exportPages(ctx, s.uowFactory, export.Ledgers, func(uow repository.UnitOfWork, afterId int64) ([]*model.Ledger, error) {
+		return uow.LedgerRepository().Get(ctx, repository.GetQuery{UserIdEq: userId, IdGt: afterId, Limit: exportPageSize})
+	}, func(ledger *model.Ledger) int64 { return ledger.Id })
+	if err != nil {
+		return false, err
+	}
+	records, err := exportPages(ctx, s.uowFactory, export.IdempotencyRecords, func(uow repository.UnitOfWork, afterId int64) ([]*idempotency.Record, error) {
+		return uow.IdempotencyQuarantineRepository().ListRecordsByReference(ctx, userId, afterId, exportPageSize)
+	}, func(record *idempotency.Record) int64 { return record.Id })
+	if err != nil {
+		return false, err
+	}
+
+	observability.LoggerFromContext(ctx, s.log).Info("user data exported",
+		observability.Int64("user_id", userId),
+		observability.Int("ledgers", ledgers),
+		observability.Int("idempotency_records", records),
+		observability.String("actor", audit.ActorFromContext(ctx)),
+	)
+	return true, nil
+}
+
+// exportPages reads pages with read, each in its own unit of work, and
+// hands them to emit until a page comes back short. read continues after
+// the id idOf gives the last item of the previous page. It returns how many
+// items were exported.
+func exportPages[T any](
+	ctx context.Context,
+	uowFactory repository.UnitOfWorkFactory,
+	emit func([]T) error,
+	read func(uow repository.UnitOfWork, afterId int64) ([]T, error),
+	idOf func(T) int64,
+) (int, error) {
+	total := 0
+	var afterId int64
+	for {
+		page, err := RunInUnitOfWorkWithRetry(ctx, uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) ([]T, error) {
+			return read(uow, afterId)
+		})
+		if err != nil {
+			return total, err
+		}
+		if len(page) > 0 {
+			if err := emit(page); err != nil {
+				return total, err
+			}
+			total += len(page)
+		}
+		if len(page) < exportPageSize {
+			return total, nil
+		}
+		afterId = idOf(page[len(page)-1])
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+)
+
+// DeadLetterReplayer re-enqueues a dead-lettered event on the pipeline it
+// came from. It runs inside uow so the re-enqueue and the replayed mark
+// commit together.
+type DeadLetterReplayer interface {
+	Replay(ctx context.Context, uow repository.UnitOfWork, deadLetter *model.DeadLetter) error
+}
+
+type ListDeadLettersParams struct {
+	SourceEq    model.DeadLetterSource
+	PendingOnly bool
+	AfterId     int64
+	PageSize    int
+}
+
+type DeadLetterService interface {
+	ListDeadLetters(ctx context.Context, params ListDeadLettersParams) ([]*model.DeadLetter, error)
+	GetDeadLetter(ctx context.Context, id int64) (*model.DeadLetter, error)
+	ReplayDeadLetter(ctx context.Context, id int64) (*model.DeadLetter, error)
+	RecordDepth(ctx context.Context) error
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type deadLetterService struct {
+	uowFactory repository.UnitOfWorkFactory
+	replayers  map[model.DeadLetterSource]DeadLetterReplayer
+	depth      observability.Gauge
+	log        observability.Logger
+}
+
+func NewDeadLetterService(uowFactory repository.UnitOfWorkFactory, replayers map[model.DeadLetterSource]DeadLetterReplayer, meter observability.Meter, log observability.Logger) DeadLetterService {
+	return &deadLetterService{
+		uowFactory: uowFactory,
+		replayers:  replayers,
+		depth: meter.Gauge("dead_letter_queue_depth", observability.MetricOpt{
+			Help:      "Number of dead-lettered events awaiting replay",
+			LabelKeys: []string{"source"},
+		}),
+		log: log,
+	}
+}
+
+func (s *deadLetterService) ListDeadLetters(ctx context.Context, params ListDeadLettersParams) ([]*model.DeadLetter, error) {
+	uow, err := s.uowFactory.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters, err := uow.DeadLetterRepository().List(ctx, repository.DeadLetterQuery{
+		SourceEq:    params.SourceEq,
+		PendingOnly: params.PendingOnly,
+		IdGt:        params.AfterId,
+		Limit:       params.PageSize,
+	})
+	if err != nil {
+		_ = uow.Abort(ctx)
+		return nil, err
+	}
+
+	if err := uow.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return deadLetters, nil
+}
+
+func (s *deadLetterService) GetDeadLetter(ctx context.Context, id int64) (*model.DeadLetter, error) {
+	uow, err := s.uowFactory.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetter, err := uow.DeadLetterRepository().Get(ctx, id)
+	if err != nil {
+		_ = uow.Abort(ctx)
+		return nil, err
+	}
+
+	if err := uow.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return deadLetter, nil
+}
+
+// ReplayDeadLetter hands the event back to its source pipeline and marks it
+// replayed. It returns nil when the dead letter does not exist.
+func (s *deadLetterService) ReplayDeadLetter(ctx context.Context, id int64) (*model.DeadLetter, error) {
+	uow, err := s.uowFactory.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetter, err := uow.DeadLetterRepository().Get(ctx, id)
+	if err != nil {
+		_ = uow.Abort(ctx)
+		return nil, err
+	}
+	if deadLetter == nil {
+		_ = uow.Abort(ctx)
+		return nil, nil
+	}
+	if deadLetter.ReplayedAt != nil {
+		_ = uow.Abort(ctx)
+		return nil, apperror.WithReason(apperror.FailedPreconditionf("dead letter %d already replayed", id), "DEAD_LETTER_ALREADY_REPLAYED", nil)
+	}
+
+	replayer, ok := s.replayers[deadLetter.Source]
+	if !ok {
+		_ = uow.Abort(ctx)
+		return nil, apperror.WithReason(apperror.FailedPreconditionf("no replayer for source %q", deadLetter.Source), "DEAD_LETTER_NO_REPLAYER", map[string]string{"source": string(deadLetter.Source)})
+	}
+
+	if err := replayer.Replay(ctx, uow, deadLetter); err != nil {
+		_ = uow.Abort(ctx)
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if err := uow.DeadLetterRepository().MarkReplayed(ctx, id, now); err != nil {
+		_ = uow.Abort(ctx)
+		return nil, err
+	}
+
+	if err := uow.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	deadLetter.ReplayedAt = &now
+	return deadLetter, nil
+}
+
+// RecordDepth updates the dead_letter_queue_depth gauge for every known
+// source, reporting zero for sources with nothing pending.
+func (s *deadLetterService) RecordDepth(ctx context.Context) error {
+	uow, err := s.uowFactory.New(ctx)
+	if err != nil {
+		return err
+	}
+
+	counts, err := uow.DeadLetterRepository().CountPending(ctx)
+	if err != nil {
+		_ = uow.Abort(ctx)
+		return err
+	}
+
+	if err := uow.Commit(ctx); err != nil {
+		return err
+	}
+
+	for _, source := range []model.DeadLetterSource{model.DeadLetterSourceOutbox, model.DeadLetterSourceWebhook, model.DeadLetterSourceConsumer} {
+		if _, ok := counts[source]; !ok {
+			counts[source] = 0
+		}
+	}
+	for source, count := range counts {
+		s.depth.Set(float64(count), observability.Label{Key: "source", Value: string(source)})
+	}
+	return nil
+}
+
+// Run records the queue depth immediately and then on every interval until
+// ctx is cancelled.
+func (s *deadLetterService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.RecordDepth(ctx); err != nil && ctx.Err() == nil {
+			s.log.Error("failed to record dead letter queue depth", observability.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
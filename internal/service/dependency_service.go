@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+)
+
+const dependencyProbeTimeout = 2 * time.Second
+
+type Dependency struct {
+	Name           string
+	Probe          func(ctx context.Context) error
+	CircuitBreaker circuitbreaker.CircuitBreaker
+}
+
+type DependencyService interface {
+	GetDependencies(ctx context.Context) []*model.DependencyStatus
+	Probe(ctx context.Context)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type dependencyService struct {
+	dependencies []Dependency
+	mu           sync.RWMutex
+	statuses     map[string]model.DependencyStatus
+}
+
+func NewDependencyService(dependencies ...Dependency) DependencyService {
+	return &dependencyService{dependencies: dependencies, statuses: make(map[string]model.DependencyStatus)}
+}
+
+func (s *dependencyService) GetDependencies(ctx context.Context) []*model.DependencyStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*model.DependencyStatus, 0, len(s.dependencies))
+	for _, dep := range s.dependencies {
+		status, ok := s.statuses[dep.Name]
+		if !ok {
+			status = model.DependencyStatus{Name: dep.Name, State: model.DependencyStateUnknown}
+		}
+		if dep.CircuitBreaker != nil {
+			state := dep.CircuitBreaker.State()
+			status.CircuitBreakerState = &state
+		}
+		result = append(result, &status)
+	}
+	return result
+}
+
+// Probe checks every dependency once and records the outcome.
+func (s *dependencyService) Probe(ctx context.Context) {
+	for _, dep := range s.dependencies {
+		probeCtx, cancel := context.WithTimeout(ctx, dependencyProbeTimeout)
+		start := time.Now()
+		err := dep.Probe(probeCtx)
+		latency := time.Since(start)
+		cancel()
+
+		status := model.DependencyStatus{
+			Name:             dep.Name,
+			State:            model.DependencyStateUp,
+			LastProbeLatency: latency,
+			LastProbeAt:      start.UTC(),
+		}
+		if err != nil {
+			status.State = model.DependencyStateDown
+			status.LastError = err.Error()
+		}
+
+		s.mu.Lock()
+		s.statuses[dep.Name] = status
+		s.mu.Unlock()
+	}
+}
+
+// Run probes immediately and then on every interval until ctx is cancelled.
+func (s *dependencyService) Run(ctx context.Context, interval time.Duration) {
+	s.Probe(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Probe(ctx)
+		}
+	}
+}
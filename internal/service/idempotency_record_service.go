@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/constant"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/idempotency"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+)
+
+// idempotencyScanPageSize is how many records Run checks per interval.
+const idempotencyScanPageSize = 100
+
+// IdempotentRequest describes the response an idempotent request type
+// stores, so its records can be checked and, when corrupt, rebuilt.
+type IdempotentRequest struct {
+	// NewResult returns the pointer the stored response decodes into, as
+	// passed to idempotency.Idempotency.Execute.
+	NewResult func() any
+	// Rederive rebuilds the response by re-reading the entity referenceId
+	// refers to. It returns nil when the entity no longer exists. A nil
+	// Rederive means records of the type cannot be repaired.
+	Rederive func(ctx context.Context, uow repository.UnitOfWork, referenceId int64) (any, error)
+}
+
+// CorruptRecord is an idempotency record whose response does not decode.
+type CorruptRecord struct {
+	idempotency.Record
+	Err error
+}
+
+// IdempotencyRecordService lets operators deal with idempotency records
+// that fail every replay with *idempotency.ErrCorruptRecord.
+type IdempotencyRecordService interface {
+	// ListCorrupt checks up to pageSize records with ids above afterId and
+	// returns those whose response does not decode, with the id to continue
+	// from, which is 0 once every record has been checked. A page may hold
+	// no corrupt records yet not be the last. Records of request types
+	// without an IdempotentRequest are not checked.
+	ListCorrupt(ctx context.Context, afterId int64, pageSize int) ([]*CorruptRecord, int64, error)
+	// ListQuarantined returns up to pageSize quarantined records with ids
+	// above afterId.
+	ListQuarantined(ctx context.Context, afterId int64, pageSize int) ([]*idempotency.QuarantinedRecord, error)
+	// Quarantine moves record id into the quarantine table, so the next
+	// request with id runs again rather than failing. It reports false when
+	// there is no record id.
+	Quarantine(ctx context.Context, id int64, reason string) (bool, error)
+	// Repair rebuilds the response of quarantined record id from the entity
+	// it refers to and restores the record, so retries replay it again. It
+	// returns nil when there is no quarantined record id, and fails with
+	// apperror.ErrFailedPrecondition when the response cannot be rebuilt or
+	// a request with id has run again since.
+	Repair(ctx context.Context, id int64) (*idempotency.Record, error)
+	// Run checks one page of records every interval until ctx is cancelled,
+	// quarantining corrupt ones and, with rederive, repairing them. It
+	// starts again from the first record after reaching the last.
+	Run(ctx context.Context, interval time.Duration, rederive bool)
+}
+
+type idempotencyRecordService struct {
+	uowFactory  repository.UnitOfWorkFactory
+	requests    map[constant.RequestType]IdempotentRequest
+	quarantined observability.Counter
+	repaired    observability.Counter
+	log         observability.Logger
+}
+
+func NewIdempotencyRecordService(uowFactory repository.UnitOfWorkFactory, requests map[constant.RequestType]IdempotentRequest, meter observability.Meter, log observability.Logger) IdempotencyRecordService {
+	return &idempotencyRecordService{
+		uowFactory: uowFactory,
+		requests:   requests,
+		quarantined: meter.Counter("idempotency_records_quarantined_total", observability.MetricOpt{
+			Help:      "Total number of corrupt idempotency records quarantined by the background scan",
+			LabelKeys: []string{"request_type"},
+		}),
+		repaired: meter.Counter("idempotency_records_repaired_total", observability.MetricOpt{
+			Help:      "Total number of quarantined idempotency records restored with a rebuilt response",
+			LabelKeys: []string{"request_type"},
+		}),
+		log: log,
+	}
+}
+
+func (s *idempotencyRecordService) ListCorrupt(ctx context.Context, afterId int64, pageSize int) ([]*CorruptRecord, int64, error) {
+	records, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) ([]*idempotency.Record, error) {
+		return uow.IdempotencyQuarantineRepository().ListRecords(ctx, afterId, pageSize)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var corrupt []*CorruptRecord
+	for _, record := range records {
+		request, ok := s.requests[constant.RequestType(record.RequestType)]
+		if !ok {
+			continue
+		}
+		if err := record.Decode(request.NewResult()); err != nil {
+			corrupt = append(corrupt, &CorruptRecord{Record: *record, Err: err})
+		}
+	}
+	var next int64
+	if len(records) == pageSize {
+		next = records[len(records)-1].Id
+	}
+	return corrupt, next, nil
+}
+
+func (s *idempotencyRecordService) ListQuarantined(ctx context.Context, afterId int64, pageSize int) ([]*idempotency.QuarantinedRecord, error) {
+	return RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) ([]*idempotency.QuarantinedRecord, error) {
+		return uow.IdempotencyQuarantineRepository().List(ctx, afterId, pageSize)
+	})
+}
+
+func (s *idempotencyRecordService) Quarantine(ctx context.Context, id int64, reason string) (bool, error) {
+	quarantined, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (bool, error) {
+		return uow.IdempotencyRecordRepository().Quarantine(ctx, id, reason)
+	})
+	if err != nil {
+		return false, err
+	}
+	if quarantined {
+		s.log.Warn("quarantined idempotency record",
+			observability.Int64("idempotency_id", id),
+			observability.String("reason", reason),
+		)
+	}
+	return quarantined, nil
+}
+
+func (s *idempotencyRecordService) Repair(ctx context.Context, id int64) (*idempotency.Record, error) {
+	record, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (*idempotency.Record, error) {
+		quarantined, err := uow.IdempotencyQuarantineRepository().Get(ctx, id)
+		if err != nil || quarantined == nil {
+			return nil, err
+		}
+		request, ok := s.requests[constant.RequestType(quarantined.RequestType)]
+		if !ok || request.Rederive == nil {
+			return nil, apperror.WithReason(
+				apperror.FailedPreconditionf("idempotency record %d: request type %q cannot be rederived", id, quarantined.RequestType),
+				"IDEMPOTENCY_RECORD_NOT_REDERIVABLE",
+				map[string]string{"request_type": quarantined.RequestType},
+			)
+		}
+		live, err := uow.IdempotencyRecordRepository().Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if live != nil {
+			return nil, apperror.WithReason(
+				apperror.FailedPreconditionf("idempotency record %d has been recorded again", id),
+				"IDEMPOTENCY_RECORD_SUPERSEDED",
+				nil,
+			)
+		}
+
+		result, err := request.Rederive(ctx, uow, quarantined.ReferenceId)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			return nil, apperror.WithReason(
+				apperror.FailedPreconditionf("idempotency record %d: %s %d no longer exists", id, quarantined.RequestType, quarantined.ReferenceId),
+				"IDEMPOTENCY_RECORD_REFERENCE_GONE",
+				nil,
+			)
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		restored, err := uow.IdempotencyQuarantineRepository().Restore(ctx, id, string(data))
+		if err != nil || !restored {
+			return nil, err
+		}
+		record := quarantined.Record
+		record.ResponseData = string(data)
+		return &record, nil
+	})
+	if err != nil || record == nil {
+		return nil, err
+	}
+
+	s.repaired.Inc(1, observability.Label{Key: "request_type", Value: record.RequestType})
+	s.log.Info("repaired idempotency record",
+		observability.Int64("idempotency_id", id),
+		observability.String("request_type", record.RequestType),
+		observability.Int64("reference_id", record.ReferenceId),
+	)
+	return record, nil
+}
+
+func (s *idempotencyRecordService) Run(ctx context.Context, interval time.Duration, rederive bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var afterId int64
+	for {
+		next, err := s.scan(ctx, afterId, rederive)
+		if err != nil && ctx.Err() == nil {
+			s.log.Error("failed to scan idempotency records", observability.Err(err))
+		}
+		if err == nil {
+			afterId = next
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scan checks the page of records after afterId and returns the id the next
+// scan continues from.
+func (s *idempotencyRecordService) scan(ctx context.Context, afterId int64, rederive bool) (int64, error) {
+	corrupt, next, err := s.ListCorrupt(ctx, afterId, idempotencyScanPageSize)
+	if err != nil {
+		return 0, err
+	}
+	var errs []error
+	for _, record := range corrupt {
+		quarantined, err := s.Quarantine(ctx, record.Id, "response does not decode: "+record.Err.Error())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !quarantined {
+			continue
+		}
+		s.quarantined.Inc(1, observability.Label{Key: "request_type", Value: record.RequestType})
+		if !rederive {
+			continue
+		}
+		if _, err := s.Repair(ctx, record.Id); err != nil {
+			s.log.Warn("failed to repair idempotency record",
+				observability.Int64("idempotency_id", record.Id),
+				observability.String("request_type", record.RequestType),
+				observability.Err(err),
+			)
+		}
+	}
+	return next, errors.Join(errs...)
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+)
+
+// IdentityService links the subjects of external OpenID Connect issuers to
+// local users, so deployments that sign users in with Keycloak or Auth0 can
+// authenticate requests with their tokens. Subjects are linked by an
+// operator rather than matched by email, since emails are neither unique nor
+// necessarily verified by the provider.
+type IdentityService interface {
+	// UserId returns the user issuer's subject is linked to. It fails with
+	// apperror.ErrUnauthenticated when the subject is not linked, and with
+	// apperror.ErrPermissionDenied when the user is suspended or deleted.
+	UserId(ctx context.Context, issuer, subject string) (int64, error)
+	// Link links issuer's subject to userId; linking it to the same user
+	// again succeeds. It fails with apperror.ErrNotFound when the user does
+	// not exist and with apperror.ErrConflict when the subject is linked to
+	// another user.
+	Link(ctx context.Context, userId int64, issuer, subject string) (*model.UserIdentity, error)
+	// Unlink removes issuer's subject's link. It reports false when the
+	// subject was not linked.
+	Unlink(ctx context.Context, issuer, subject string) (bool, error)
+}
+
+type identityService struct {
+	uowFactory repository.UnitOfWorkFactory
+	log        observability.Logger
+}
+
+func NewIdentityService(uowFactory repository.UnitOfWorkFactory, log observability.Logger) IdentityService {
+	return &identityService{uowFactory: uowFactory, log: log}
+}
+
+func (s *identityService) UserId(ctx context.Context, issuer, subject string) (int64, error) {
+	return RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (int64, error) {
+		identity, err := uow.UserIdentityRepository().Get(ctx, issuer, subject)
+		if err != nil {
+			return 0, err
+		}
+		if identity == nil {
+			return 0, apperror.Unauthenticatedf("subject %q of %s is not linked to a user", subject, issuer)
+		}
+		user, err := uow.UserRepository().Get(ctx, identity.UserId)
+		if err != nil {
+			return 0, err
+		}
+		if user == nil {
+			return 0, apperror.Unauthenticatedf("subject %q of %s is linked to missing user %d", subject, issuer, identity.UserId)
+		}
+		if err := checkUserActive(user); err != nil {
+			return 0, err
+		}
+		return identity.UserId, nil
+	})
+}
+
+func (s *identityService) Link(ctx context.Context, userId int64, issuer, subject string) (*model.UserIdentity, error) {
+	identity, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (*model.UserIdentity, error) {
+		user, err := uow.UserRepository().Get(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, apperror.UserNotFound(userId)
+		}
+
+		existing, err := uow.UserIdentityRepository().Get(ctx, issuer, subject)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			if existing.UserId != userId {
+				return nil, apperror.Conflictf("subject %q of %s is linked to another user", subject, issuer)
+			}
+			return existing, nil
+		}
+
+		identity := &model.UserIdentity{
+			Issuer:    issuer,
+			Subject:   subject,
+			UserId:    userId,
+			CreatedAt: time.Now().UTC(),
+		}
+		if err := uow.UserIdentityRepository().Insert(ctx, identity); err != nil {
+			return nil, err
+		}
+		return identity, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	observability.LoggerFromContext(ctx, s.log).Info("external identity linked",
+		observability.Int64("user_id", userId),
+		observability.String("issuer", issuer),
+		observability.String("actor", audit.ActorFromContext(ctx)),
+	)
+	return identity, nil
+}
+
+func (s *identityService) Unlink(ctx context.Context, issuer, subject string) (bool, error) {
+	unlinked, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (bool, error) {
+		return uow.UserIdentityRepository().Delete(ctx, issuer, subject)
+	})
+	if err != nil || !unlinked {
+		return false, err
+	}
+	observability.LoggerFromContext(ctx, s.log).Info("external identity unlinked",
+		observability.String("issuer", issuer),
+		observability.String("actor", audit.ActorFromContext(ctx)),
+	)
+	return true, nil
+}
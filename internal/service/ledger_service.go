@@ -5,47 +5,148 @@ import (
 
 	"github.com/jt828/go-grpc-template/internal/repository"
 	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
 )
 
+// GetParams filters ledgers. A positive Limit returns at most that many in id
+// order, starting after AfterId, or with NewestFirst in descending id order,
+// starting before BeforeId.
 type GetParams struct {
 	IdEq              int64
 	UserIdEq          int64
-	TransactionTypeEq string
+	TransactionTypeEq model.TransactionType
 	TokenEq           string
+	AfterId           int64
+	BeforeId          int64
+	NewestFirst       bool
+	Limit             int
+}
+
+func (p GetParams) query() repository.GetQuery {
+	return repository.GetQuery{
+		IdEq:              p.IdEq,
+		UserIdEq:          p.UserIdEq,
+		TransactionTypeEq: p.TransactionTypeEq,
+		TokenEq:           p.TokenEq,
+		IdGt:              p.AfterId,
+		IdLt:              p.BeforeId,
+		Desc:              p.NewestFirst,
+		Limit:             p.Limit,
+	}
+}
+
+func (p GetParams) attributes() []observability.Field {
+	return []observability.Field{
+		observability.Int64("filter.id", p.IdEq),
+		observability.Int64("filter.user_id", p.UserIdEq),
+		observability.String("filter.transaction_type", string(p.TransactionTypeEq)),
+		observability.String("filter.token", p.TokenEq),
+		observability.Int64("page.after_id", p.AfterId),
+		observability.Int64("page.before_id", p.BeforeId),
+		observability.Bool("page.newest_first", p.NewestFirst),
+		observability.Int("page.limit", p.Limit),
+	}
+}
+
+// LedgerWithUser is a ledger entry with its owner attached. User is nil when
+// the owner no longer exists.
+type LedgerWithUser struct {
+	Ledger *model.Ledger
+	User   *model.User
 }
 
 type LedgerService interface {
 	GetLedgers(ctx context.Context, params GetParams) ([]*model.Ledger, error)
+	GetLedgersWithUsers(ctx context.Context, params GetParams) ([]*LedgerWithUser, error)
 }
 
 type ledgerService struct {
 	uowFactory repository.UnitOfWorkFactory
+	tracer     observability.Tracer
 }
 
-func NewLedgerService(uowFactory repository.UnitOfWorkFactory) LedgerService {
-	return &ledgerService{uowFactory: uowFactory}
+func NewLedgerService(uowFactory repository.UnitOfWorkFactory, tracer observability.Tracer) LedgerService {
+	return &ledgerService{uowFactory: uowFactory, tracer: tracer}
 }
 
 func (s *ledgerService) GetLedgers(ctx context.Context, params GetParams) ([]*model.Ledger, error) {
-	uow, err := s.uowFactory.New()
+	ctx, span := s.tracer.Start(ctx, "LedgerService.GetLedgers")
+	defer span.End()
+	span.SetAttributes(params.attributes()...)
+
+	uow, err := s.uowFactory.New(ctx)
 	if err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 
-	ledgers, err := uow.LedgerRepository().Get(ctx, repository.GetQuery{
-		IdEq:              params.IdEq,
-		UserIdEq:          params.UserIdEq,
-		TransactionTypeEq: params.TransactionTypeEq,
-		TokenEq:           params.TokenEq,
-	})
+	ledgers, err := uow.LedgerRepository().Get(ctx, params.query())
 	if err != nil {
+		span.RecordError(err)
 		_ = uow.Abort(ctx)
 		return nil, err
 	}
 
 	if err := uow.Commit(ctx); err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 
+	span.SetAttributes(observability.Int("result.count", len(ledgers)))
 	return ledgers, nil
 }
+
+// GetLedgersWithUsers returns ledgers with their owners fetched in one batch
+// query, however many rows match. Users are not joined in SQL because the
+// ledger store may be a separate database.
+func (s *ledgerService) GetLedgersWithUsers(ctx context.Context, params GetParams) ([]*LedgerWithUser, error) {
+	ctx, span := s.tracer.Start(ctx, "LedgerService.GetLedgersWithUsers")
+	defer span.End()
+	span.SetAttributes(params.attributes()...)
+
+	uow, err := s.uowFactory.New(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	ledgers, err := uow.LedgerRepository().Get(ctx, params.query())
+	if err != nil {
+		span.RecordError(err)
+		_ = uow.Abort(ctx)
+		return nil, err
+	}
+
+	var userIds []int64
+	seen := map[int64]struct{}{}
+	for _, ledger := range ledgers {
+		if _, ok := seen[ledger.UserId]; !ok {
+			seen[ledger.UserId] = struct{}{}
+			userIds = append(userIds, ledger.UserId)
+		}
+	}
+
+	users, err := uow.UserRepository().GetByIds(ctx, userIds, repository.UserSummaryProjection)
+	if err != nil {
+		span.RecordError(err)
+		_ = uow.Abort(ctx)
+		return nil, err
+	}
+
+	if err := uow.Commit(ctx); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	byId := make(map[int64]*model.User, len(users))
+	for _, user := range users {
+		byId[user.Id] = user
+	}
+	result := make([]*LedgerWithUser, len(ledgers))
+	for i, ledger := range ledgers {
+		result[i] = &LedgerWithUser{Ledger: ledger, User: byId[ledger.UserId]}
+	}
+
+	span.SetAttributes(observability.Int("result.count", len(result)), observability.Int("result.users", len(users)))
+	return result, nil
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+)
+
+// LoginLockout configures LoginThrottleService. After MaxFailures
+// consecutive failed logins to one user from one IP, that IP is locked out
+// for LockoutBase, doubling with each further failure up to LockoutMax.
+// After AccountMaxFailures from any IPs the account itself is locked for
+// AccountLockout, and again on every further failure; 0 disables this.
+// Counts restart once no failure has been seen for FailureReset.
+type LoginLockout struct {
+	MaxFailures        int
+	LockoutBase        time.Duration
+	LockoutMax         time.Duration
+	FailureReset       time.Duration
+	AccountMaxFailures int
+	AccountLockout     time.Duration
+}
+
+// accountIp is the ip a user's failures from every IP are also counted
+// under, for the account lockout. It is no valid IP, so it cannot collide
+// with a client's own count.
+const accountIp = "*"
+
+// LoginThrottleService slows password guessing by locking a user out from
+// an IP after repeated failed logins. A login handler calls Check before
+// verifying the password, then RecordFailure or RecordSuccess with the
+// outcome. Failures are counted per user and IP, so an attacker cannot lock
+// a user out from everywhere with a few guesses. Guessing spread over many
+// IPs still locks the account once the account lockout is reached.
+type LoginThrottleService interface {
+	// Check returns an apperror.RetryAfterError wrapping
+	// apperror.ErrPermissionDenied, with reason ACCOUNT_LOCKED, while
+	// userId's account is locked, and wrapping apperror.ErrResourceExhausted
+	// while userId is locked out from ip.
+	Check(ctx context.Context, userId int64, ip string) error
+	RecordFailure(ctx context.Context, userId int64, ip string) error
+	RecordSuccess(ctx context.Context, userId int64, ip string) error
+	// Unlock lifts userId's lockouts and forgets its failures from every IP,
+	// returning how many IPs it cleared.
+	Unlock(ctx context.Context, userId int64) (int64, error)
+}
+
+type loginThrottleService struct {
+	uowFactory      repository.UnitOfWorkFactory
+	lockout         LoginLockout
+	failures        observability.Counter
+	lockouts        observability.Counter
+	accountLockouts observability.Counter
+	rejected        observability.Counter
+	log             observability.Logger
+}
+
+func NewLoginThrottleService(uowFactory repository.UnitOfWorkFactory, lockout LoginLockout, meter observability.Meter, log observability.Logger) LoginThrottleService {
+	return &loginThrottleService{
+		uowFactory: uowFactory,
+		lockout:    lockout,
+		failures: meter.Counter("login_failures_total", observability.MetricOpt{
+			Help: "Total number of failed logins",
+		}),
+		lockouts: meter.Counter("login_lockouts_total", observability.MetricOpt{
+			Help: "Total number of lockouts imposed after repeated failed logins",
+		}),
+		accountLockouts: meter.Counter("login_account_lockouts_total", observability.MetricOpt{
+			Help: "Total number of account lockouts imposed after repeated failed logins from any IPs",
+		}),
+		rejected: meter.Counter("login_lockout_rejections_total", observability.MetricOpt{
+			Help:      "Total number of logins rejected while locked out",
+			LabelKeys: []string{"scope"},
+		}),
+		log: log,
+	}
+}
+
+func (s *loginThrottleService) Check(ctx context.Context, userId int64, ip string) error {
+	uow, err := s.uowFactory.New(ctx)
+	if err != nil {
+		return err
+	}
+
+	failure, err := uow.LoginFailureRepository().Get(ctx, userId, ip)
+	if err != nil {
+		_ = uow.Abort(ctx)
+		return err
+	}
+	var account *model.LoginFailure
+	if s.lockout.AccountMaxFailures > 0 {
+		if account, err = uow.LoginFailureRepository().Get(ctx, userId, accountIp); err != nil {
+			_ = uow.Abort(ctx)
+			return err
+		}
+	}
+
+	if err := uow.Commit(ctx); err != nil {
+		return err
+	}
+
+	if remaining := lockedFor(account); remaining > 0 {
+		s.rejected.Inc(1, observability.Label{Key: "scope", Value: "account"})
+		return &apperror.RetryAfterError{
+			Err: apperror.WithReason(
+				apperror.PermissionDeniedf("account is locked after too many failed login attempts"),
+				"ACCOUNT_LOCKED", nil,
+			),
+			RetryAfter: remaining,
+		}
+	}
+	if remaining := lockedFor(failure); remaining > 0 {
+		s.rejected.Inc(1, observability.Label{Key: "scope", Value: "ip"})
+		return &apperror.RetryAfterError{
+			Err:        apperror.Wrapf(apperror.ErrResourceExhausted, "too many failed login attempts"),
+			RetryAfter: remaining,
+		}
+	}
+	return nil
+}
+
+// lockedFor returns how long failure's lockout has left, rounded up to the
+// second, or 0 when it is not locked.
+func lockedFor(failure *model.LoginFailure) time.Duration {
+	if failure == nil || failure.LockedUntil == nil {
+		return 0
+	}
+	remaining := time.Until(*failure.LockedUntil)
+	if remaining <= 0 {
+		return 0
+	}
+	return remaining.Truncate(time.Second) + time.Second
+}
+
+func (s *loginThrottleService) RecordFailure(ctx context.Context, userId int64, ip string) error {
+	now := time.Now()
+	counts, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (failureCounts, error) {
+		failure, err := uow.LoginFailureRepository().Fail(ctx, userId, ip, now, now.Add(-s.lockout.FailureReset))
+		if err != nil {
+			return failureCounts{}, err
+		}
+		if failure.Failures >= s.lockout.MaxFailures {
+			if err := uow.LoginFailureRepository().Lock(ctx, userId, ip, now.Add(s.lockoutFor(failure.Failures))); err != nil {
+				return failureCounts{}, err
+			}
+		}
+		if s.lockout.AccountMaxFailures == 0 {
+			return failureCounts{ip: failure.Failures}, nil
+		}
+		account, err := uow.LoginFailureRepository().Fail(ctx, userId, accountIp, now, now.Add(-s.lockout.FailureReset))
+		if err != nil {
+			return failureCounts{}, err
+		}
+		if account.Failures >= s.lockout.AccountMaxFailures {
+			if err := uow.LoginFailureRepository().Lock(ctx, userId, accountIp, now.Add(s.lockout.AccountLockout)); err != nil {
+				return failureCounts{}, err
+			}
+		}
+		return failureCounts{ip: failure.Failures, account: account.Failures}, nil
+	})
+	if err != nil {
+		return err
+	}
+	failures, accountFailures := counts.ip, counts.account
+
+	s.failures.Inc(1)
+	if s.lockout.AccountMaxFailures > 0 && accountFailures >= s.lockout.AccountMaxFailures {
+		s.accountLockouts.Inc(1)
+		observability.LoggerFromContext(ctx, s.log).Warn("account locked",
+			observability.Int64("user_id", userId),
+			observability.Int("failures", accountFailures),
+			observability.Duration("lockout", s.lockout.AccountLockout))
+	}
+	if failures >= s.lockout.MaxFailures {
+		s.lockouts.Inc(1)
+		observability.LoggerFromContext(ctx, s.log).Warn("login locked out",
+			observability.Int64("user_id", userId),
+			observability.String("ip", ip),
+			observability.Int("failures", failures),
+			observability.Duration("lockout", s.lockoutFor(failures)))
+	}
+	return nil
+}
+
+// failureCounts are a user's consecutive failures from one IP and from
+// every IP.
+type failureCounts struct {
+	ip      int
+	account int
+}
+
+// lockoutFor doubles LockoutBase for every failure past MaxFailures, capped
+// at LockoutMax.
+func (s *loginThrottleService) lockoutFor(failures int) time.Duration {
+	lockout := s.lockout.LockoutBase
+	for i := s.lockout.MaxFailures; i < failures && lockout < s.lockout.LockoutMax; i++ {
+		lockout *= 2
+	}
+	return min(lockout, s.lockout.LockoutMax)
+}
+
+func (s *loginThrottleService) RecordSuccess(ctx context.Context, userId int64, ip string) error {
+	_, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (struct{}, error) {
+		if err := uow.LoginFailureRepository().Clear(ctx, userId, ip); err != nil {
+			return struct{}{}, err
+		}
+		if s.lockout.AccountMaxFailures == 0 {
+			return struct{}{}, nil
+		}
+		return struct{}{}, uow.LoginFailureRepository().Clear(ctx, userId, accountIp)
+	})
+	return err
+}
+
+func (s *loginThrottleService) Unlock(ctx context.Context, userId int64) (int64, error) {
+	cleared, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (int64, error) {
+		var account *model.LoginFailure
+		if s.lockout.AccountMaxFailures > 0 {
+			var err error
+			if account, err = uow.LoginFailureRepository().Get(ctx, userId, accountIp); err != nil {
+				return 0, err
+			}
+		}
+		cleared, err := uow.LoginFailureRepository().ClearUser(ctx, userId)
+		if err != nil || account == nil {
+			return cleared, err
+		}
+		// The account count is cleared too, but is no IP.
+		return cleared - 1, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	observability.LoggerFromContext(ctx, s.log).Info("login lockouts cleared", observability.Int64("user_id", userId), observability.Int64("ips", cleared))
+	return cleared, nil
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/email"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/password"
+	"github.com/jt828/go-grpc-template/pkg/snowflake"
+)
+
+const passwordResetEmailSubject = "Reset your password"
+
+// resetTokenPartLength is the length of a reset token's selector and of its
+// verifier, each a rand.Text.
+const resetTokenPartLength = 26
+
+// PasswordResetService lets users who forgot their password set a new one
+// through a single-use link mailed to them. A token is a selector, which
+// finds the stored token, followed by a verifier, of which only the SHA-256
+// hash is stored and which is compared in constant time.
+type PasswordResetService interface {
+	// Request mails the user with emailAddress a reset link, replacing any
+	// earlier unused one. It succeeds whether or not there is such a user,
+	// so callers cannot find out which addresses have an account.
+	Request(ctx context.Context, emailAddress string) error
+	// Confirm redeems token, sets the user's password to newPassword and
+	// signs out every session of the user. It fails with
+	// apperror.ErrInvalidArgument when token is unknown, used or expired,
+	// or newPassword breaks the password policy. Confirming a used token
+	// again with the password it set succeeds, so a retried request does
+	// not fail.
+	Confirm(ctx context.Context, token, newPassword string, device Device) (*PasswordChange, error)
+}
+
+type passwordResetService struct {
+	uowFactory repository.UnitOfWorkFactory
+	sender     email.Sender
+	policy     password.Policy
+	passwords  password.Hasher
+	snowflake  snowflake.Snowflake
+	linkURL    string
+	ttl        time.Duration
+	log        observability.Logger
+}
+
+// NewPasswordResetService sends links that expire after ttl. The token is
+// added to linkURL as its token query parameter; with no linkURL the email
+// carries the bare token.
+func NewPasswordResetService(uowFactory repository.UnitOfWorkFactory, sender email.Sender, policy password.Policy, passwords password.Hasher, snowflake snowflake.Snowflake, linkURL string, ttl time.Duration, log observability.Logger) PasswordResetService {
+	return &passwordResetService{uowFactory: uowFactory, sender: sender, policy: policy, passwords: passwords, snowflake: snowflake, linkURL: linkURL, ttl: ttl, log: log}
+}
+
+func (s *passwordResetService) Request(ctx context.Context, emailAddress string) error {
+	selector, verifier := rand.Text(), rand.Text()
+	now := time.Now().UTC()
+
+	user, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (*model.User, error) {
+		user, err := uow.UserRepository().GetByEmail(ctx, emailAddress)
+		if err != nil || user == nil || user.Status == model.UserStatusDeleted {
+			return nil, err
+		}
+		if err := uow.PasswordResetTokenRepository().DeleteUnused(ctx, user.Id); err != nil {
+			return nil, err
+		}
+		return user, uow.PasswordResetTokenRepository().Insert(ctx, &model.PasswordResetToken{
+			Selector:     selector,
+			VerifierHash: hashResetVerifier(verifier),
+			UserId:       user.Id,
+			CreatedAt:    now,
+			ExpiresAt:    now.Add(s.ttl),
+		})
+	})
+	if err != nil || user == nil {
+		return err
+	}
+
+	body, err := emailBody(s.linkURL, selector+verifier, "reset your password")
+	if err != nil {
+		return err
+	}
+	// A failed send is not reported to the caller, who would learn from it
+	// that the address has an account.
+	log := observability.LoggerFromContext(ctx, s.log)
+	if err := s.sender.Send(ctx, email.Message{To: user.Email, Subject: passwordResetEmailSubject, Body: body}); err != nil {
+		log.Warn("sending password reset email failed", observability.Int64("user_id", user.Id), observability.Err(err))
+		return nil
+	}
+	log.Info("password reset email sent", observability.Int64("user_id", user.Id))
+	return nil
+}
+
+func (s *passwordResetService) Confirm(ctx context.Context, token, newPassword string, device Device) (*PasswordChange, error) {
+	selector, verifier, ok := splitResetToken(token)
+	if !ok {
+		return nil, errInvalidResetToken
+	}
+	now := time.Now().UTC()
+
+	// The token is checked before the policy, which needs the user and may
+	// call out to the breach check, and before the slow hash, so neither
+	// holds the transaction that redeems it.
+	user, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (*model.User, error) {
+		return s.redeemable(ctx, uow, selector, verifier, newPassword, now)
+	})
+	if errors.Is(err, errResetTokenRedeemed) {
+		return &PasswordChange{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if violations := s.policy.Check(ctx, newPassword, user.Username, user.Email); len(violations) > 0 {
+		var err apperror.ValidationErrors
+		for _, v := range violations {
+			err.Add("new_password", v.Rule, v.Description)
+		}
+		return nil, err.Err()
+	}
+	hash, err := s.passwords.Hash(newPassword)
+	if err != nil {
+		return nil, err
+	}
+	device = truncateDevice(device)
+
+	change, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (*PasswordChange, error) {
+		user, err := s.redeemable(ctx, uow, selector, verifier, newPassword, now)
+		if err != nil {
+			return nil, err
+		}
+		used, err := uow.PasswordResetTokenRepository().Use(ctx, selector, now)
+		if err != nil {
+			return nil, err
+		}
+		if !used {
+			return nil, apperror.Conflictf("password reset token redeemed concurrently")
+		}
+
+		user.Password = hash
+		updated, err := uow.UserRepository().UpdatePassword(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if !updated {
+			return nil, apperror.Conflictf("user %d changed concurrently", user.Id)
+		}
+		if err := uow.PasswordResetTokenRepository().DeleteUnused(ctx, user.Id); err != nil {
+			return nil, err
+		}
+		revoked, err := revokeAllSessions(ctx, uow, s.snowflake, user.Id, device, user.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		return &PasswordChange{RevokedSessions: revoked}, nil
+	})
+	if errors.Is(err, errResetTokenRedeemed) {
+		return &PasswordChange{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	observability.LoggerFromContext(ctx, s.log).Info("password reset",
+		observability.Int64("user_id", user.Id),
+		observability.Int("revoked_sessions", change.RevokedSessions),
+	)
+	return change, nil
+}
+
+// redeemable returns the user of the token with selector once verifier
+// matches and the token can still be redeemed. A token already used to set
+// newPassword fails with errResetTokenRedeemed, which Confirm treats as
+// success.
+func (s *passwordResetService) redeemable(ctx context.Context, uow repository.UnitOfWork, selector, verifier, newPassword string, now time.Time) (*model.User, error) {
+	stored, err := uow.PasswordResetTokenRepository().Get(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || subtle.ConstantTimeCompare([]byte(stored.VerifierHash), []byte(hashResetVerifier(verifier))) != 1 {
+		return nil, errInvalidResetToken
+	}
+	user, err := uow.UserRepository().Get(ctx, stored.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.Status == model.UserStatusDeleted {
+		return nil, errInvalidResetToken
+	}
+	if stored.UsedAt != nil {
+		if ok, err := s.passwords.Verify(newPassword, user.Password); err == nil && ok {
+			return nil, errResetTokenRedeemed
+		}
+		return nil, errInvalidResetToken
+	}
+	if stored.Expired(now) {
+		return nil, apperror.WithReason(apperror.InvalidArgumentf("password reset token has expired"), "PASSWORD_RESET_TOKEN_EXPIRED", nil)
+	}
+	return user, nil
+}
+
+var (
+	errInvalidResetToken = apperror.WithReason(
+		apperror.InvalidArgumentf("password reset token is invalid"),
+		"PASSWORD_RESET_TOKEN_INVALID",
+		nil,
+	)
+	errResetTokenRedeemed = errors.New("password reset token already set this password")
+)
+
+// splitResetToken splits token into its selector and verifier, after
+// normalizing case and surrounding space like hashVerificationToken.
+func splitResetToken(token string) (selector, verifier string, ok bool) {
+	token = strings.ToUpper(strings.TrimSpace(token))
+	if len(token) != 2*resetTokenPartLength {
+		return "", "", false
+	}
+	return token[:resetTokenPartLength], token[resetTokenPartLength:], true
+}
+
+// hashResetVerifier hashes a reset token's verifier for storing. Verifiers
+// carry 130 random bits, so an unsalted hash does not expose them.
+func hashResetVerifier(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return hex.EncodeToString(sum[:])
+}
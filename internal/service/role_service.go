@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jt828/go-grpc-template/internal/authz"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+)
+
+const (
+	// maxPermissionLength is the width of role_permissions.permission.
+	maxPermissionLength = 128
+	// maxCachedAccess bounds how many callers' access RoleService keeps.
+	maxCachedAccess = 10000
+	// userCallerPrefix starts the caller of a request made as a user, see
+	// interceptor.CallerKindUser.
+	userCallerPrefix = "user:"
+)
+
+// RoleService manages roles, the permissions they carry and the users they
+// are assigned to. As an authz.Resolver it gives user callers their
+// assigned roles and every caller the permissions of its roles, caching the
+// result per caller for a while. Changes made through the service clear this
+// pod's cache at once; other pods pick them up when their entries expire.
+type RoleService interface {
+	authz.Resolver
+	// PutRole defines role name with permissions, replacing the permissions
+	// of a role already defined under the name.
+	PutRole(ctx context.Context, name string, permissions []string) (*model.Role, error)
+	// ListRoles returns every defined role, or the roles assigned to userId
+	// when it is not 0.
+	ListRoles(ctx context.Context, userId int64) ([]*model.Role, error)
+	// AssignRole assigns role to userId. It reports false when the user
+	// already holds the role, and fails with apperror.ErrNotFound when the
+	// user or the role does not exist.
+	AssignRole(ctx context.Context, userId int64, role string) (bool, error)
+	// UnassignRole takes role away from userId. It reports false when the
+	// user did not hold it.
+	UnassignRole(ctx context.Context, userId int64, role string) (bool, error)
+}
+
+type cachedAccess struct {
+	access    authz.Access
+	expiresAt time.Time
+}
+
+type roleService struct {
+	uowFactory repository.UnitOfWorkFactory
+	ttl        time.Duration
+	log        observability.Logger
+
+	mu    sync.Mutex
+	cache map[string]cachedAccess
+	// generation counts changes, so a lookup that raced one is not cached.
+	generation uint64
+}
+
+// NewRoleService returns a RoleService that caches each caller's access for
+// ttl; a ttl of 0 looks it up on every request.
+func NewRoleService(uowFactory repository.UnitOfWorkFactory, ttl time.Duration, log observability.Logger) RoleService {
+	return &roleService{uowFactory: uowFactory, ttl: ttl, log: log, cache: map[string]cachedAccess{}}
+}
+
+func (s *roleService) Resolve(ctx context.Context, caller string, roles []string) (authz.Access, error) {
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.cache[caller]
+	generation := s.generation
+	s.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.access, nil
+	}
+
+	var userId int64
+	if id, ok := strings.CutPrefix(caller, userCallerPrefix); ok {
+		userId, _ = strconv.ParseInt(id, 10, 64)
+	}
+	access, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (authz.Access, error) {
+		access := authz.Access{Roles: slices.Clone(roles)}
+		if userId > 0 {
+			assigned, err := uow.RoleRepository().ListByUser(ctx, userId)
+			if err != nil {
+				return authz.Access{}, err
+			}
+			for _, role := range assigned {
+				if !slices.Contains(access.Roles, role.Name) {
+					access.Roles = append(access.Roles, role.Name)
+				}
+			}
+		}
+		permissions, err := uow.RoleRepository().Permissions(ctx, access.Roles)
+		if err != nil {
+			return authz.Access{}, err
+		}
+		access.Permissions = permissions
+		return access, nil
+	})
+	if err != nil {
+		return authz.Access{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ttl > 0 && s.generation == generation {
+		if len(s.cache) >= maxCachedAccess {
+			maps.DeleteFunc(s.cache, func(_ string, cached cachedAccess) bool { return !now.Before(cached.expiresAt) })
+			if len(s.cache) >= maxCachedAccess {
+				clear(s.cache)
+			}
+		}
+		s.cache[caller] = cachedAccess{access: access, expiresAt: now.Add(s.ttl)}
+	}
+	return access, nil
+}
+
+// forget drops every cached access after a change to roles or assignments.
+func (s *roleService) forget() {
+	s.mu.Lock()
+	clear(s.cache)
+	s.generation++
+	s.mu.Unlock()
+}
+
+func (s *roleService) PutRole(ctx context.Context, name string, permissions []string) (*model.Role, error) {
+	role := &model.Role{Name: name, CreatedAt: time.Now().UTC()}
+	for _, permission := range permissions {
+		if permission == "" || utf8.RuneCountInString(permission) > maxPermissionLength {
+			return nil, apperror.FieldError("permissions", "invalid", fmt.Sprintf("permissions must be 1 to %d characters, got %q", maxPermissionLength, permission))
+		}
+		if !slices.Contains(role.Permissions, permission) {
+			role.Permissions = append(role.Permissions, permission)
+		}
+	}
+	slices.Sort(role.Permissions)
+
+	_, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (struct{}, error) {
+		return struct{}{}, uow.RoleRepository().Put(ctx, role)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.forget()
+	observability.LoggerFromContext(ctx, s.log).Info("role defined",
+		observability.String("role", name),
+		observability.String("permissions", strings.Join(role.Permissions, ",")),
+		observability.String("actor", audit.ActorFromContext(ctx)),
+	)
+	return role, nil
+}
+
+func (s *roleService) ListRoles(ctx context.Context, userId int64) ([]*model.Role, error) {
+	return RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) ([]*model.Role, error) {
+		if userId == 0 {
+			return uow.RoleRepository().List(ctx)
+		}
+		return uow.RoleRepository().ListByUser(ctx, userId)
+	})
+}
+
+func (s *roleService) AssignRole(ctx context.Context, userId int64, role string) (bool, error) {
+	assigned, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (bool, error) {
+		user, err := uow.UserRepository().Get(ctx, userId)
+		if err != nil {
+			return false, err
+		}
+		if user == nil {
+			return false, apperror.UserNotFound(userId)
+		}
+		defined, err := uow.RoleRepository().Get(ctx, role)
+		if err != nil {
+			return false, err
+		}
+		if defined == nil {
+			return false, apperror.NotFoundf("role %q", role)
+		}
+		return uow.RoleRepository().Assign(ctx, &model.UserRole{UserId: userId, Role: role, CreatedAt: time.Now().UTC()})
+	})
+	if err != nil || !assigned {
+		return false, err
+	}
+	s.forget()
+	observability.LoggerFromContext(ctx, s.log).Info("role assigned",
+		observability.Int64("user_id", userId),
+		observability.String("role", role),
+		observability.String("actor", audit.ActorFromContext(ctx)),
+	)
+	return true, nil
+}
+
+func (s *roleService) UnassignRole(ctx context.Context, userId int64, role string) (bool, error) {
+	unassigned, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (bool, error) {
+		return uow.RoleRepository().Unassign(ctx, userId, role)
+	})
+	if err != nil || !unassigned {
+		return false, err
+	}
+	s.forget()
+	observability.LoggerFromContext(ctx, s.log).Info("role unassigned",
+		observability.Int64("user_id", userId),
+		observability.String("role", role),
+		observability.String("actor", audit.ActorFromContext(ctx)),
+	)
+	return true, nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/model"
+)
+
+// SchemaStore is a database whose live schema is checked against the tables
+// its migrations create.
+type SchemaStore struct {
+	Name       string
+	Schema     string
+	Expected   []model.TableSchema
+	Repository repository.SchemaRepository
+}
+
+type SchemaDriftService interface {
+	// CheckSchemaDrift compares every store's live schema with its expected
+	// tables. Tables outside the expected set are ignored.
+	CheckSchemaDrift(ctx context.Context) ([]*model.SchemaDrift, error)
+}
+
+type schemaDriftService struct {
+	stores []SchemaStore
+}
+
+func NewSchemaDriftService(stores ...SchemaStore) SchemaDriftService {
+	return &schemaDriftService{stores: stores}
+}
+
+func (s *schemaDriftService) CheckSchemaDrift(ctx context.Context) ([]*model.SchemaDrift, error) {
+	var drifts []*model.SchemaDrift
+	for _, store := range s.stores {
+		names := make([]string, len(store.Expected))
+		for i, table := range store.Expected {
+			names[i] = table.Name
+		}
+
+		actual, err := store.Repository.Describe(ctx, store.Schema, names)
+		if err != nil {
+			return nil, fmt.Errorf("describe %s: %w", store.Name, err)
+		}
+		drifts = append(drifts, diffSchema(store.Name, store.Expected, actual)...)
+	}
+	return drifts, nil
+}
+
+func diffSchema(store string, expected, actual []model.TableSchema) []*model.SchemaDrift {
+	var drifts []*model.SchemaDrift
+	add := func(object string, kind model.SchemaDriftKind, expected, actual string) {
+		drifts = append(drifts, &model.SchemaDrift{Store: store, Object: object, Kind: kind, Expected: expected, Actual: actual})
+	}
+
+	live := make(map[string]model.TableSchema, len(actual))
+	for _, table := range actual {
+		live[table.Name] = table
+	}
+
+	for _, want := range expected {
+		got, ok := live[want.Name]
+		if !ok {
+			add(want.Name, model.SchemaDriftMissingTable, "", "")
+			continue
+		}
+
+		liveColumns := make(map[string]model.ColumnSchema, len(got.Columns))
+		for _, column := range got.Columns {
+			liveColumns[column.Name] = column
+		}
+		for _, column := range want.Columns {
+			object := want.Name + "." + column.Name
+			liveColumn, ok := liveColumns[column.Name]
+			if !ok {
+				add(object, model.SchemaDriftMissingColumn, column.Type, "")
+				continue
+			}
+			delete(liveColumns, column.Name)
+			if liveColumn.Type != column.Type {
+				add(object, model.SchemaDriftColumnType, column.Type, liveColumn.Type)
+			}
+			if liveColumn.Nullable != column.Nullable {
+				add(object, model.SchemaDriftColumnNullability, nullability(column.Nullable), nullability(liveColumn.Nullable))
+			}
+		}
+		for _, column := range got.Columns {
+			if _, ok := liveColumns[column.Name]; ok {
+				add(want.Name+"."+column.Name, model.SchemaDriftUnexpectedColumn, "", column.Type)
+			}
+		}
+
+		for _, index := range want.Indexes {
+			if !slices.Contains(got.Indexes, index) {
+				add(index, model.SchemaDriftMissingIndex, want.Name, "")
+			}
+		}
+		for _, index := range got.Indexes {
+			if !slices.Contains(want.Indexes, index) {
+				add(index, model.SchemaDriftUnexpectedIndex, "", want.Name)
+			}
+		}
+	}
+	return drifts
+}
+
+func nullability(nullable bool) string {
+	if nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/snowflake"
+)
+
+const (
+	// maxListedSessions bounds ListSessions; older sessions beyond it are
+	// still active and can be revoked by id.
+	maxListedSessions = 100
+	// sessionTouchInterval is how stale last_seen_at may get before Touch
+	// writes it again, so an active session costs one write a minute rather
+	// than one per request.
+	sessionTouchInterval = time.Minute
+	// maxUserAgentLength is the width of the user_agent columns.
+	maxUserAgentLength = 512
+)
+
+// Device is what a request reveals about the device making it.
+type Device struct {
+	UserAgent string
+	Ip        string
+}
+
+// SessionService tracks signed-in devices so users can review them and sign
+// out a lost or unrecognised one. Starting and revoking a session is
+// recorded in the user_session_events audit trail with the acting device
+// and actor.
+type SessionService interface {
+	// Touch is run by authentication for each request made with a token
+	// carrying a session id. The first request of issuer's session
+	// externalId opens it for userId; later ones record the device as last
+	// seen. It fails with apperror.ErrUnauthenticated when the session was
+	// revoked or belongs to another user.
+	Touch(ctx context.Context, userId int64, issuer, externalId, userAgent, ip string) error
+	// List returns userId's active sessions, most recently seen first.
+	List(ctx context.Context, userId int64) ([]*model.Session, error)
+	// Revoke signs userId's session out. It reports false when userId has no
+	// active session with that id.
+	Revoke(ctx context.Context, userId int64, sessionId int64, device Device) (bool, error)
+}
+
+type sessionService struct {
+	uowFactory repository.UnitOfWorkFactory
+	snowflake  snowflake.Snowflake
+	log        observability.Logger
+}
+
+func NewSessionService(uowFactory repository.UnitOfWorkFactory, snowflake snowflake.Snowflake, log observability.Logger) SessionService {
+	return &sessionService{uowFactory: uowFactory, snowflake: snowflake, log: log}
+}
+
+func (s *sessionService) Touch(ctx context.Context, userId int64, issuer, externalId, userAgent, ip string) error {
+	device := truncateDevice(Device{UserAgent: userAgent, Ip: ip})
+	now := time.Now().UTC()
+	started, err := s.touch(ctx, userId, issuer, externalId, device, now)
+	if errors.Is(err, apperror.ErrAlreadyExists) {
+		// A concurrent request opened the session first, and it has
+		// committed by the time the insert conflicts.
+		started, err = s.touch(ctx, userId, issuer, externalId, device, now)
+	}
+	if err != nil || started == nil {
+		return err
+	}
+	observability.LoggerFromContext(ctx, s.log).Info("session started",
+		observability.Int64("user_id", userId),
+		observability.Int64("session_id", started.Id),
+		observability.String("issuer", issuer),
+	)
+	return nil
+}
+
+// touch opens or refreshes the session in one transaction, returning the
+// session when it opened it.
+func (s *sessionService) touch(ctx context.Context, userId int64, issuer, externalId string, device Device, now time.Time) (*model.Session, error) {
+	return RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (*model.Session, error) {
+		session, err := uow.SessionRepository().GetByExternalId(ctx, issuer, externalId)
+		if err != nil {
+			return nil, err
+		}
+		if session != nil {
+			if session.UserId != userId || session.RevokedAt != nil {
+				return nil, apperror.Unauthenticatedf("session %d is not active", session.Id)
+			}
+			return nil, uow.SessionRepository().Touch(ctx, session.Id, device.UserAgent, device.Ip, now, now.Add(-sessionTouchInterval))
+		}
+
+		session = &model.Session{
+			Id:         s.snowflake.Generate(),
+			UserId:     userId,
+			UserAgent:  device.UserAgent,
+			Ip:         device.Ip,
+			CreatedAt:  now,
+			LastSeenAt: now,
+			Issuer:     issuer,
+			ExternalId: externalId,
+		}
+		if err := uow.SessionRepository().Insert(ctx, session); err != nil {
+			return nil, err
+		}
+		return session, s.recordEvent(ctx, uow, session, model.SessionEventStarted, device, now)
+	})
+}
+
+func (s *sessionService) List(ctx context.Context, userId int64) ([]*model.Session, error) {
+	return RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) ([]*model.Session, error) {
+		return uow.SessionRepository().ListActive(ctx, userId, maxListedSessions)
+	})
+}
+
+func (s *sessionService) Revoke(ctx context.Context, userId int64, sessionId int64, device Device) (bool, error) {
+	device = truncateDevice(device)
+	now := time.Now().UTC()
+	revoked, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (bool, error) {
+		revoked, err := uow.SessionRepository().Revoke(ctx, sessionId, userId, now)
+		if err != nil || !revoked {
+			return false, err
+		}
+		session := &model.Session{Id: sessionId, UserId: userId}
+		return true, s.recordEvent(ctx, uow, session, model.SessionEventRevoked, device, now)
+	})
+	if err != nil || !revoked {
+		return false, err
+	}
+	observability.LoggerFromContext(ctx, s.log).Info("session revoked",
+		observability.Int64("user_id", userId),
+		observability.Int64("session_id", sessionId),
+		observability.String("actor", audit.ActorFromContext(ctx)),
+	)
+	return true, nil
+}
+
+func (s *sessionService) recordEvent(ctx context.Context, uow repository.UnitOfWork, session *model.Session, event model.SessionEventType, device Device, at time.Time) error {
+	return recordSessionEvent(ctx, uow, s.snowflake, session, event, device, at)
+}
+
+func recordSessionEvent(ctx context.Context, uow repository.UnitOfWork, snowflake snowflake.Snowflake, session *model.Session, event model.SessionEventType, device Device, at time.Time) error {
+	return uow.SessionRepository().InsertEvent(ctx, &model.SessionEvent{
+		Id:        snowflake.Generate(),
+		SessionId: session.Id,
+		UserId:    session.UserId,
+		Event:     event,
+		UserAgent: device.UserAgent,
+		Ip:        device.Ip,
+		CreatedAt: at,
+	})
+}
+
+// revokeAllSessions revokes every active session of userId within uow,
+// recording each revocation as made from device at at, and returns how
+// many it revoked.
+func revokeAllSessions(ctx context.Context, uow repository.UnitOfWork, snowflake snowflake.Snowflake, userId int64, device Device, at time.Time) (int, error) {
+	ids, err := uow.SessionRepository().RevokeAll(ctx, userId, at)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		session := &model.Session{Id: id, UserId: userId}
+		if err := recordSessionEvent(ctx, uow, snowflake, session, model.SessionEventRevoked, device, at); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// truncateDevice cuts the client-supplied user agent to fit its column.
+func truncateDevice(device Device) Device {
+	if utf8.RuneCountInString(device.UserAgent) > maxUserAgentLength {
+		device.UserAgent = string([]rune(device.UserAgent)[:maxUserAgentLength])
+	}
+	return device
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+)
+
+const (
+	// Tables smaller than this are cheap to scan sequentially, and Postgres
+	// rightly prefers to, so they are never flagged.
+	seqScanWarnMinLiveTuples = 10_000
+	slowStatementLimit       = 5
+	slowStatementThreshold   = 100 * time.Millisecond
+	maxLoggedQueryLength     = 500
+)
+
+type TableStatsService interface {
+	// Sample exports each store's table access counters as metrics, warns
+	// about tables whose reads are mostly sequential scans since the
+	// previous sample, and logs the slowest statements.
+	Sample(ctx context.Context) error
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type tableStatsService struct {
+	stores []SchemaStore
+	log    observability.Logger
+
+	seqScans      observability.Gauge
+	seqTuplesRead observability.Gauge
+	indexScans    observability.Gauge
+	liveTuples    observability.Gauge
+
+	mu       sync.Mutex
+	previous map[string]model.TableStats
+}
+
+func NewTableStatsService(stores []SchemaStore, meter observability.Meter, log observability.Logger) TableStatsService {
+	labels := []string{"table", "store"}
+	return &tableStatsService{
+		stores: stores,
+		log:    log,
+		seqScans: meter.Gauge("db_table_seq_scans", observability.MetricOpt{
+			Help:      "Sequential scans started on the table since statistics were reset",
+			LabelKeys: labels,
+		}),
+		seqTuplesRead: meter.Gauge("db_table_seq_tuples_read", observability.MetricOpt{
+			Help:      "Rows read by sequential scans on the table since statistics were reset",
+			LabelKeys: labels,
+		}),
+		indexScans: meter.Gauge("db_table_index_scans", observability.MetricOpt{
+			Help:      "Index scans started on the table since statistics were reset",
+			LabelKeys: labels,
+		}),
+		liveTuples: meter.Gauge("db_table_live_tuples", observability.MetricOpt{
+			Help:      "Estimated number of live rows in the table",
+			LabelKeys: labels,
+		}),
+		previous: map[string]model.TableStats{},
+	}
+}
+
+func (s *tableStatsService) Sample(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, store := range s.stores {
+		names := make([]string, len(store.Expected))
+		for i, table := range store.Expected {
+			names[i] = table.Name
+		}
+
+		stats, err := store.Repository.TableStats(ctx, store.Schema, names)
+		if err != nil {
+			return err
+		}
+		for _, table := range stats {
+			s.record(store.Name, table)
+		}
+
+		statements, err := store.Repository.SlowStatements(ctx, slowStatementLimit)
+		if err != nil {
+			return err
+		}
+		for _, statement := range statements {
+			if statement.MeanTime < slowStatementThreshold {
+				break
+			}
+			query := statement.Query
+			if len(query) > maxLoggedQueryLength {
+				query = query[:maxLoggedQueryLength] + "..."
+			}
+			s.log.Warn("slow statement",
+				observability.String("store", store.Name),
+				observability.Int64("query_id", statement.QueryId),
+				observability.String("query", query),
+				observability.Int64("calls", statement.Calls),
+				observability.Duration("mean_time", statement.MeanTime),
+				observability.Duration("total_time", statement.TotalTime),
+			)
+		}
+	}
+	return nil
+}
+
+func (s *tableStatsService) record(store string, stats *model.TableStats) {
+	labels := []observability.Label{{Key: "table", Value: stats.Table}, {Key: "store", Value: store}}
+	s.seqScans.Set(float64(stats.SeqScans), labels...)
+	s.seqTuplesRead.Set(float64(stats.SeqTuplesRead), labels...)
+	s.indexScans.Set(float64(stats.IndexScans), labels...)
+	s.liveTuples.Set(float64(stats.LiveTuples), labels...)
+
+	key := store + "/" + stats.Table
+	previous, ok := s.previous[key]
+	s.previous[key] = *stats
+	// Counters go backwards when statistics are reset; skip that interval.
+	if !ok || stats.SeqScans < previous.SeqScans || stats.IndexScans < previous.IndexScans {
+		return
+	}
+
+	seqScans := stats.SeqScans - previous.SeqScans
+	indexScans := stats.IndexScans - previous.IndexScans
+	if stats.LiveTuples >= seqScanWarnMinLiveTuples && seqScans > indexScans {
+		s.log.Warn("sequential scans dominate reads on a large table, an index may be missing",
+			observability.String("store", store),
+			observability.String("table", stats.Table),
+			observability.Int64("seq_scans", seqScans),
+			observability.Int64("index_scans", indexScans),
+			observability.Int64("seq_tuples_read", stats.SeqTuplesRead-previous.SeqTuplesRead),
+			observability.Int64("live_tuples", stats.LiveTuples),
+		)
+	}
+}
+
+func (s *tableStatsService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Sample(ctx); err != nil && ctx.Err() == nil {
+			s.log.Error("failed to sample table statistics", observability.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
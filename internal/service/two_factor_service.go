@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/fieldcrypto"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/totp"
+)
+
+const (
+	recoveryCodeCount = 10
+	// recoveryCodeLength base32 characters carry 50 bits.
+	recoveryCodeLength = 10
+)
+
+// TwoFactorEnrollment is what a user adds to an authenticator app to start
+// two-factor authentication: the secret itself, to type in, and the
+// otpauth:// URI, to show as a QR code.
+type TwoFactorEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// TwoFactorService manages TOTP two-factor authentication. Secrets are
+// stored encrypted with pkg/fieldcrypto, bound to their user. Every code
+// check goes through LoginThrottleService, so codes cannot be guessed faster
+// than passwords.
+type TwoFactorService interface {
+	// Enroll starts a pending enrollment for userId, replacing any earlier
+	// pending one. It returns nil when the user does not exist and fails with
+	// apperror.ErrFailedPrecondition when two-factor authentication is
+	// already enabled.
+	Enroll(ctx context.Context, userId int64) (*TwoFactorEnrollment, error)
+	// Verify confirms the pending enrollment with a first code from the
+	// authenticator app, enables two-factor authentication and returns
+	// single-use recovery codes. They are only stored hashed, so this is the
+	// one time they can be shown.
+	Verify(ctx context.Context, userId int64, ip string, code string) ([]string, error)
+	// Disable turns two-factor authentication off after checking a current
+	// code or an unused recovery code.
+	Disable(ctx context.Context, userId int64, ip string, code string) error
+}
+
+type twoFactorService struct {
+	uowFactory repository.UnitOfWorkFactory
+	cipher     fieldcrypto.Cipher
+	throttle   LoginThrottleService
+	issuer     string
+	log        observability.Logger
+}
+
+func NewTwoFactorService(uowFactory repository.UnitOfWorkFactory, cipher fieldcrypto.Cipher, throttle LoginThrottleService, issuer string, log observability.Logger) TwoFactorService {
+	return &twoFactorService{uowFactory: uowFactory, cipher: cipher, throttle: throttle, issuer: issuer, log: log}
+}
+
+func (s *twoFactorService) Enroll(ctx context.Context, userId int64) (*TwoFactorEnrollment, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.cipher.Encrypt(secret, secretAssociatedData(userId))
+	if err != nil {
+		return nil, err
+	}
+
+	return RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (*TwoFactorEnrollment, error) {
+		user, err := uow.UserRepository().Get(ctx, userId)
+		if err != nil || user == nil {
+			return nil, err
+		}
+		current, err := uow.TwoFactorRepository().Get(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+		if current.Enabled() {
+			return nil, apperror.WithReason(apperror.FailedPreconditionf("two-factor authentication is already enabled"), "TWO_FACTOR_ALREADY_ENABLED", nil)
+		}
+		if err := uow.TwoFactorRepository().Upsert(ctx, &model.TwoFactor{UserId: userId, Secret: encrypted, CreatedAt: time.Now()}); err != nil {
+			return nil, err
+		}
+		return &TwoFactorEnrollment{Secret: totp.EncodeSecret(secret), URI: totp.URI(s.issuer, user.Username, secret)}, nil
+	})
+}
+
+func (s *twoFactorService) Verify(ctx context.Context, userId int64, ip string, code string) ([]string, error) {
+	if err := s.throttle.Check(ctx, userId, ip); err != nil {
+		return nil, err
+	}
+
+	codes, hashes := newRecoveryCodes()
+	now := time.Now()
+	accepted, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (bool, error) {
+		twoFactor, err := uow.TwoFactorRepository().Get(ctx, userId)
+		if err != nil {
+			return false, err
+		}
+		if twoFactor == nil || twoFactor.Enabled() {
+			return false, apperror.WithReason(apperror.FailedPreconditionf("no pending two-factor enrollment"), "TWO_FACTOR_NOT_ENROLLING", nil)
+		}
+		secret, err := s.cipher.Decrypt(twoFactor.Secret, secretAssociatedData(userId))
+		if err != nil {
+			return false, err
+		}
+		step, ok := totp.Validate(secret, code, now, twoFactor.LastUsedStep)
+		if !ok {
+			return false, nil
+		}
+		enabled, err := uow.TwoFactorRepository().Enable(ctx, userId, step, now)
+		if err != nil || !enabled {
+			return false, err
+		}
+		return true, uow.TwoFactorRepository().ReplaceRecoveryCodes(ctx, userId, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recordOutcome(ctx, userId, ip, accepted); err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, apperror.InvalidArgumentf("two-factor code is incorrect or expired")
+	}
+	observability.LoggerFromContext(ctx, s.log).Info("two-factor authentication enabled", observability.Int64("user_id", userId))
+	return codes, nil
+}
+
+func (s *twoFactorService) Disable(ctx context.Context, userId int64, ip string, code string) error {
+	if err := s.throttle.Check(ctx, userId, ip); err != nil {
+		return err
+	}
+
+	accepted, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (bool, error) {
+		twoFactor, err := uow.TwoFactorRepository().Get(ctx, userId)
+		if err != nil {
+			return false, err
+		}
+		if !twoFactor.Enabled() {
+			return false, apperror.WithReason(apperror.FailedPreconditionf("two-factor authentication is not enabled"), "TWO_FACTOR_NOT_ENABLED", nil)
+		}
+		accepted, err := s.checkCode(ctx, uow, twoFactor, code)
+		if err != nil || !accepted {
+			return false, err
+		}
+		return true, uow.TwoFactorRepository().Delete(ctx, userId)
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.recordOutcome(ctx, userId, ip, accepted); err != nil {
+		return err
+	}
+	if !accepted {
+		return apperror.Unauthenticatedf("two-factor code is incorrect")
+	}
+	observability.LoggerFromContext(ctx, s.log).Info("two-factor authentication disabled", observability.Int64("user_id", userId))
+	return nil
+}
+
+// checkCode accepts a six-digit code from the authenticator app, marking
+// its step used, or else an unused recovery code, marking it used.
+func (s *twoFactorService) checkCode(ctx context.Context, uow repository.UnitOfWork, twoFactor *model.TwoFactor, code string) (bool, error) {
+	now := time.Now()
+	if len(code) == totp.Digits {
+		if _, err := strconv.Atoi(code); err == nil {
+			secret, err := s.cipher.Decrypt(twoFactor.Secret, secretAssociatedData(twoFactor.UserId))
+			if err != nil {
+				return false, err
+			}
+			step, ok := totp.Validate(secret, code, now, twoFactor.LastUsedStep)
+			if !ok {
+				return false, nil
+			}
+			return uow.TwoFactorRepository().UseStep(ctx, twoFactor.UserId, step)
+		}
+	}
+	return uow.TwoFactorRepository().UseRecoveryCode(ctx, twoFactor.UserId, hashRecoveryCode(code), now)
+}
+
+// recordOutcome feeds a code check into the login throttle.
+func (s *twoFactorService) recordOutcome(ctx context.Context, userId int64, ip string, accepted bool) error {
+	if accepted {
+		return s.throttle.RecordSuccess(ctx, userId, ip)
+	}
+	return s.throttle.RecordFailure(ctx, userId, ip)
+}
+
+// secretAssociatedData binds an encrypted secret to its user, so it cannot
+// be copied to another user's row and used there.
+func secretAssociatedData(userId int64) []byte {
+	return []byte("user_two_factor:" + strconv.FormatInt(userId, 10))
+}
+
+// newRecoveryCodes returns recovery codes formatted as "xxxxx-xxxxx" and
+// their hashes, for storing.
+func newRecoveryCodes() ([]string, []string) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code := strings.ToLower(rand.Text()[:recoveryCodeLength])
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes
+}
+
+// hashRecoveryCode normalizes case and separators before hashing, so codes
+// typed in either case, with or without the dash, match. Recovery codes are
+// random and long enough that an unsalted hash does not expose them.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/pgclass"
+)
+
+// DefaultTransactionAttempts is how many times RunInUnitOfWorkWithRetry runs
+// a transaction that keeps losing serialization races.
+const DefaultTransactionAttempts = 3
+
+// RunInUnitOfWorkWithRetry runs fn in a new unit of work and commits it,
+// aborting it instead when fn fails. A serialization failure or deadlock
+// aborts the whole transaction, so retrying the failed statement cannot
+// succeed; instead fn runs again from the start in a fresh unit of work, up
+// to attempts times in total. fn must therefore keep all its effects inside
+// uow. A partial commit across stores is never retried.
+func RunInUnitOfWorkWithRetry[T any](ctx context.Context, factory repository.UnitOfWorkFactory, attempts int, fn func(uow repository.UnitOfWork) (T, error)) (T, error) {
+	var result T
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		result, err = runInUnitOfWork(ctx, factory, fn)
+		if err == nil || !pgclass.IsSerializationFailure(err) || errors.Is(err, repository.ErrPartialCommit) || ctx.Err() != nil {
+			return result, err
+		}
+	}
+	return result, err
+}
+
+func runInUnitOfWork[T any](ctx context.Context, factory repository.UnitOfWorkFactory, fn func(uow repository.UnitOfWork) (T, error)) (T, error) {
+	var zero T
+	uow, err := factory.New(ctx)
+	if err != nil {
+		return zero, err
+	}
+
+	result, err := fn(uow)
+	if err != nil {
+		_ = uow.Abort(ctx)
+		return zero, err
+	}
+
+	if err := uow.Commit(ctx); err != nil {
+		return zero, err
+	}
+	return result, nil
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/event"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+)
+
+const (
+	// UsageTopic is the topic daily usage reports are published to.
+	UsageTopic = "billing.usage"
+	// usageReportGrace delays reporting a day until requests still in flight
+	// at midnight have been flushed.
+	usageReportGrace = 10 * time.Minute
+)
+
+type UsageService interface {
+	// Record counts one successful call of method by caller costing units.
+	// It only updates memory; Flush writes the totals.
+	Record(at time.Time, caller, method string, units int64)
+	// Flush adds the usage recorded since the previous flush to the stored
+	// daily totals in one transaction.
+	Flush(ctx context.Context) error
+	// ReportUsage publishes an ApiUsageReportedV1 per caller for every day
+	// before the day containing before that has not been reported yet.
+	ReportUsage(ctx context.Context, before time.Time) error
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type usageKey struct {
+	date   time.Time
+	caller string
+	method string
+}
+
+type usageService struct {
+	uowFactory repository.UnitOfWorkFactory
+	publisher  event.Publisher
+	log        observability.Logger
+
+	mu      sync.Mutex
+	pending map[usageKey]*model.ApiUsage
+}
+
+// NewUsageService returns a usage service publishing reports through
+// publisher. With a nil publisher usage is still recorded but never
+// reported.
+func NewUsageService(uowFactory repository.UnitOfWorkFactory, publisher event.Publisher, log observability.Logger) UsageService {
+	return &usageService{
+		uowFactory: uowFactory,
+		publisher:  publisher,
+		log:        log,
+		pending:    map[usageKey]*model.ApiUsage{},
+	}
+}
+
+func (s *usageService) Record(at time.Time, caller, method string, units int64) {
+	key := usageKey{date: usageDay(at), caller: caller, method: method}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(key, 1, units)
+}
+
+// add must be called with mu held.
+func (s *usageService) add(key usageKey, requests, units int64) {
+	usage, ok := s.pending[key]
+	if !ok {
+		usage = &model.ApiUsage{UsageDate: key.date, Caller: key.caller, Method: key.method}
+		s.pending[key] = usage
+	}
+	usage.Requests += requests
+	usage.Units += units
+}
+
+func (s *usageService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = map[usageKey]*model.ApiUsage{}
+	s.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	usage := make([]*model.ApiUsage, 0, len(pending))
+	for _, u := range pending {
+		usage = append(usage, u)
+	}
+
+	if err := s.write(ctx, usage); err != nil {
+		// Keep the counts for the next flush rather than losing billable
+		// usage to a transient failure.
+		s.mu.Lock()
+		for key, u := range pending {
+			s.add(key, u.Requests, u.Units)
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (s *usageService) write(ctx context.Context, usage []*model.ApiUsage) error {
+	uow, err := s.uowFactory.New(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := uow.UsageRepository().Add(ctx, usage); err != nil {
+		_ = uow.Abort(ctx)
+		return err
+	}
+
+	return uow.Commit(ctx)
+}
+
+func (s *usageService) ReportUsage(ctx context.Context, before time.Time) error {
+	if s.publisher == nil {
+		return nil
+	}
+
+	dates, err := s.unreportedDates(ctx, usageDay(before))
+	if err != nil {
+		return err
+	}
+	for _, date := range dates {
+		if err := s.report(ctx, date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *usageService) unreportedDates(ctx context.Context, before time.Time) ([]time.Time, error) {
+	uow, err := s.uowFactory.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dates, err := uow.UsageRepository().UnreportedDates(ctx, before)
+	if err != nil {
+		_ = uow.Abort(ctx)
+		return nil, err
+	}
+
+	return dates, uow.Commit(ctx)
+}
+
+// report publishes date's usage and marks it reported in one transaction.
+// If the commit fails after publishing, the next run publishes the same
+// events again under the same ids, which consumers deduplicate.
+func (s *usageService) report(ctx context.Context, date time.Time) error {
+	uow, err := s.uowFactory.New(ctx)
+	if err != nil {
+		return err
+	}
+
+	marked, err := uow.UsageRepository().MarkReported(ctx, date, time.Now())
+	if err != nil {
+		_ = uow.Abort(ctx)
+		return err
+	}
+	if !marked {
+		// Another instance reported this day first.
+		return uow.Abort(ctx)
+	}
+
+	usage, err := uow.UsageRepository().ListByDate(ctx, date)
+	if err != nil {
+		_ = uow.Abort(ctx)
+		return err
+	}
+
+	byCaller := map[string][]*model.ApiUsage{}
+	var callers []string
+	for _, u := range usage {
+		if _, ok := byCaller[u.Caller]; !ok {
+			callers = append(callers, u.Caller)
+		}
+		byCaller[u.Caller] = append(byCaller[u.Caller], u)
+	}
+
+	now := time.Now()
+	for _, caller := range callers {
+		envelope, err := event.Pack(usageEventId(date, caller), event.ApiUsageReported(caller, date, byCaller[caller]), now)
+		if err == nil {
+			err = s.publisher.Publish(ctx, UsageTopic, envelope)
+		}
+		if err != nil {
+			_ = uow.Abort(ctx)
+			return err
+		}
+	}
+
+	if err := uow.Commit(ctx); err != nil {
+		return err
+	}
+	s.log.Info("reported api usage", observability.String("date", date.Format(time.DateOnly)), observability.Int("callers", len(callers)))
+	return nil
+}
+
+// Run flushes recorded usage and reports closed days every interval until
+// ctx is cancelled, then flushes once more so usage recorded during
+// shutdown is kept.
+func (s *usageService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.Flush(context.WithoutCancel(ctx)); err != nil {
+				s.log.Error("failed to flush api usage", observability.Err(err))
+			}
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Flush(ctx); err != nil && ctx.Err() == nil {
+			s.log.Error("failed to flush api usage", observability.Err(err))
+		}
+		if err := s.ReportUsage(ctx, time.Now().Add(-usageReportGrace)); err != nil && ctx.Err() == nil {
+			s.log.Error("failed to report api usage", observability.Err(err))
+		}
+	}
+}
+
+// usageDay truncates t to the start of its UTC day.
+func usageDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// usageEventId derives the report event id from its day and caller, so a
+// republished report carries the id of the original.
+func usageEventId(date time.Time, caller string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(date.Format(time.DateOnly)))
+	h.Write([]byte{0})
+	h.Write([]byte(caller))
+	return int64(h.Sum64() & math.MaxInt64)
+}
@@ -2,79 +2,509 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/jt828/go-grpc-template/internal/constant"
 	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/audit"
 	"github.com/jt828/go-grpc-template/pkg/idempotency"
 	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/password"
 	"github.com/jt828/go-grpc-template/pkg/snowflake"
+	"github.com/jt828/go-grpc-template/pkg/statemachine"
 )
 
 type UserService interface {
 	GetUser(ctx context.Context, id int64) (*model.User, error)
+	GetUsersByIds(ctx context.Context, ids []int64) (*GetUsersByIdsResult, error)
 	CreateUser(ctx context.Context, idempotencyId int64, user *model.User) (*model.User, error)
+	UpdateUserStatus(ctx context.Context, id int64, status model.UserStatus, expectedVersion int64) (*model.User, error)
+	UpdateUser(ctx context.Context, id int64, update UserUpdate) (*model.User, error)
+	SuspendUser(ctx context.Context, idempotencyId int64, id int64, reason string) (*model.User, error)
+	ReactivateUser(ctx context.Context, idempotencyId int64, id int64, reason string) (*model.User, error)
+	// ChangePassword sets the password of user id to newPassword once
+	// currentPassword verifies, and revokes every session of the user in
+	// the same transaction, recording device as the one that revoked them.
+	// The check goes through login throttling, so the current password
+	// cannot be guessed faster than at login. It returns nil when the user
+	// does not exist.
+	ChangePassword(ctx context.Context, id int64, currentPassword string, newPassword string, device Device) (*PasswordChange, error)
+}
+
+// GetUsersByIdsResult holds the users found, in the order their ids were
+// requested, and the requested ids that do not exist.
+type GetUsersByIdsResult struct {
+	Users      []*model.User
+	MissingIds []int64
+}
+
+// PasswordChange is the outcome of a password change: how many sessions it
+// signed out.
+type PasswordChange struct {
+	RevokedSessions int
+}
+
+// UserUpdate is a partial update of a user: nil fields are left as they are.
+// A non-zero ExpectedVersion or ExpectedUpdatedAt makes the update
+// conditional on the user not having changed since it was read.
+type UserUpdate struct {
+	Email             *string
+	Username          *string
+	ExpectedVersion   int64
+	ExpectedUpdatedAt time.Time
+}
+
+// UserStatusMachine declares the user lifecycle: active and suspended users
+// can move between each other, and either can be deleted. Deleted is final.
+func UserStatusMachine() *statemachine.Machine[model.UserStatus, *model.User] {
+	return statemachine.New(
+		func(u *model.User) model.UserStatus { return u.Status },
+		func(u *model.User, status model.UserStatus) { u.Status = status },
+		statemachine.Transition[model.UserStatus, *model.User]{From: model.UserStatusActive, To: model.UserStatusSuspended},
+		statemachine.Transition[model.UserStatus, *model.User]{From: model.UserStatusSuspended, To: model.UserStatusActive},
+		statemachine.Transition[model.UserStatus, *model.User]{From: model.UserStatusActive, To: model.UserStatusDeleted},
+		statemachine.Transition[model.UserStatus, *model.User]{From: model.UserStatusSuspended, To: model.UserStatusDeleted},
+	)
+}
+
+// checkUserActive fails with apperror.ErrPermissionDenied unless
+// user.IsActive. Authentication and password changes run it, so a
+// suspended or deleted user can neither sign in nor change credentials
+// until reactivated.
+func checkUserActive(user *model.User) error {
+	if user.IsActive() {
+		return nil
+	}
+	switch user.Status {
+	case model.UserStatusSuspended:
+		return apperror.WithReason(apperror.PermissionDeniedf("user %d is suspended", user.Id), "USER_SUSPENDED", nil)
+	case model.UserStatusDeleted:
+		return apperror.WithReason(apperror.PermissionDeniedf("user %d is deleted", user.Id), "USER_DELETED", nil)
+	}
+	return apperror.PermissionDeniedf("user %d is %s", user.Id, user.Status)
 }
 
 type userService struct {
 	uowFactory  repository.UnitOfWorkFactory
 	idempotency idempotency.Idempotency
 	snowflake   snowflake.Snowflake
+	passwords   password.Hasher
+	throttle    LoginThrottleService
+	tracer      observability.Tracer
+	status      *statemachine.Machine[model.UserStatus, *model.User]
 }
 
-func NewUserService(uowFactory repository.UnitOfWorkFactory, idempotency idempotency.Idempotency, snowflake snowflake.Snowflake) UserService {
-	return &userService{uowFactory: uowFactory, idempotency: idempotency, snowflake: snowflake}
+func NewUserService(uowFactory repository.UnitOfWorkFactory, idempotency idempotency.Idempotency, snowflake snowflake.Snowflake, passwords password.Hasher, throttle LoginThrottleService, tracer observability.Tracer) UserService {
+	return &userService{uowFactory: uowFactory, idempotency: idempotency, snowflake: snowflake, passwords: passwords, throttle: throttle, tracer: tracer, status: UserStatusMachine()}
 }
 
 func (s *userService) GetUser(ctx context.Context, id int64) (*model.User, error) {
-	uow, err := s.uowFactory.New()
+	ctx, span := s.tracer.Start(ctx, "UserService.GetUser")
+	defer span.End()
+	span.SetAttributes(observability.Int64("user_id", id))
+
+	uow, err := s.uowFactory.New(ctx)
 	if err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 
 	user, err := uow.UserRepository().Get(ctx, id)
 	if err != nil {
+		span.RecordError(err)
 		_ = uow.Abort(ctx)
 		return nil, err
 	}
 
 	if err := uow.Commit(ctx); err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 
+	span.SetAttributes(observability.Bool("found", user != nil))
 	return user, nil
 }
 
-func (s *userService) CreateUser(ctx context.Context, idempotencyId int64, user *model.User) (*model.User, error) {
-	uow, err := s.uowFactory.New()
+func (s *userService) GetUsersByIds(ctx context.Context, ids []int64) (*GetUsersByIdsResult, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.GetUsersByIds")
+	defer span.End()
+	span.SetAttributes(observability.Int("requested", len(ids)))
+
+	uow, err := s.uowFactory.New(ctx)
 	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	users, err := uow.UserRepository().GetByIds(ctx, ids, repository.UserPublicProjection)
+	if err != nil {
+		span.RecordError(err)
+		_ = uow.Abort(ctx)
 		return nil, err
 	}
 
-	now := time.Now().UTC()
+	if err := uow.Commit(ctx); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	byId := make(map[int64]*model.User, len(users))
+	for _, user := range users {
+		byId[user.Id] = user
+	}
+	result := &GetUsersByIdsResult{Users: make([]*model.User, 0, len(users))}
+	for _, id := range ids {
+		if user, ok := byId[id]; ok {
+			result.Users = append(result.Users, user)
+		} else {
+			result.MissingIds = append(result.MissingIds, id)
+		}
+	}
+
+	span.SetAttributes(observability.Int("missing", len(result.MissingIds)))
+	return result, nil
+}
+
+func (s *userService) CreateUser(ctx context.Context, idempotencyId int64, user *model.User) (*model.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+	span.SetAttributes(observability.Int64("idempotency_id", idempotencyId))
+
+	hash, err := s.passwords.Hash(user.Password)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	user.Password = hash
 	user.Id = s.snowflake.Generate()
-	user.CreatedAt = now
-	user.UpdatedAt = now
+	user.Status = model.UserStatusActive
+	span.SetAttributes(observability.Int64("user_id", user.Id))
+
+	result, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (any, error) {
+		return s.idempotency.Execute(ctx, uow.IdempotencyRecordRepository(), idempotencyId, constant.RequestTypeCreateUser, &user.Id, newUserResult, func() (any, error) {
+			if err := uow.UserRepository().Insert(ctx, user); err != nil {
+				return nil, err
+			}
+			return user, nil
+		})
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return result.(*model.User), nil
+}
+
+// verifyPassword reports whether pw is the password of user. It fails with
+// apperror.ErrPermissionDenied for a suspended or deleted user, and with
+// apperror.ErrFailedPrecondition for a user whose stored password is not a
+// hash, which must be reset before it can be verified.
+func (s *userService) verifyPassword(user *model.User, pw string) (bool, error) {
+	if err := checkUserActive(user); err != nil {
+		return false, err
+	}
+	ok, err := s.passwords.Verify(pw, user.Password)
+	if errors.Is(err, password.ErrUnknownHash) {
+		return false, apperror.FailedPreconditionf("user %d has no password hash and must reset the password", user.Id)
+	}
+	return ok, err
+}
+
+// ChangePassword fails with apperror.ErrUnauthenticated when
+// currentPassword is wrong, with the throttle's error while the user is
+// locked out, apperror.ErrPermissionDenied when the user is suspended or
+// deleted, apperror.ErrFailedPrecondition when it has no password hash to
+// verify against, and apperror.ErrConflict when another request changed the
+// user between the read and the write. newPassword is hashed before the
+// transaction starts, so a slow hash does not hold the row.
+func (s *userService) ChangePassword(ctx context.Context, id int64, currentPassword string, newPassword string, device Device) (*PasswordChange, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.ChangePassword")
+	defer span.End()
+	span.SetAttributes(observability.Int64("user_id", id))
+
+	if err := s.throttle.Check(ctx, id, device.Ip); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	hash, err := s.passwords.Hash(newPassword)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	device = truncateDevice(device)
 
-	result, err := s.idempotency.Execute(ctx, uow.IdempotencyRecordRepository(), idempotencyId, constant.RequestTypeCreateUser, user.Id, func() any { return &model.User{} }, func() (any, error) {
-		if err := uow.UserRepository().Insert(ctx, user); err != nil {
+	change, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (*PasswordChange, error) {
+		user, err := uow.UserRepository().Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errUserNotFound
+		}
+		ok, err := s.verifyPassword(user, currentPassword)
+		if err != nil {
 			return nil, err
 		}
+		if !ok {
+			return nil, apperror.Unauthenticatedf("current password is incorrect")
+		}
 
-		createdUser, err := uow.UserRepository().Get(ctx, user.Id)
+		user.Password = hash
+		updated, err := uow.UserRepository().UpdatePassword(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if !updated {
+			return nil, apperror.Conflictf("user %d changed concurrently", id)
+		}
+		revoked, err := revokeAllSessions(ctx, uow, s.snowflake, id, device, user.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
-		return createdUser, nil
+		return &PasswordChange{RevokedSessions: revoked}, nil
 	})
+	if errors.Is(err, errUserNotFound) {
+		return nil, nil
+	}
+	// Only a wrong current password is unauthenticated.
+	if errors.Is(err, apperror.ErrUnauthenticated) {
+		if recordErr := s.throttle.RecordFailure(ctx, id, device.Ip); recordErr != nil {
+			err = recordErr
+		}
+	}
 	if err != nil {
-		_ = uow.Abort(ctx)
+		span.RecordError(err)
+		return nil, err
+	}
+	if err := s.throttle.RecordSuccess(ctx, id, device.Ip); err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 
-	if err := uow.Commit(ctx); err != nil {
+	span.SetAttributes(observability.Int("revoked_sessions", change.RevokedSessions))
+	return change, nil
+}
+
+// UpdateUserStatus moves the user to status if the lifecycle allows it. A
+// non-zero expectedVersion makes the change conditional on the user still
+// being at that version, failing with an apperror.VersionMismatchError
+// otherwise. It returns nil when the user does not exist and
+// apperror.ErrConflict when the transition is not allowed or another request
+// changed the user first.
+func (s *userService) UpdateUserStatus(ctx context.Context, id int64, status model.UserStatus, expectedVersion int64) (*model.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.UpdateUserStatus")
+	defer span.End()
+	span.SetAttributes(observability.Int64("user_id", id), observability.String("status", string(status)), observability.Int64("expected_version", expectedVersion))
+
+	user, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (*model.User, error) {
+		user, err := s.changeStatus(ctx, uow, id, status, expectedVersion, "")
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errUserNotFound
+		}
+		return user, nil
+	})
+	if errors.Is(err, errUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// UpdateUser applies update to the user. Changing the email clears its
+// verification. It fails with an apperror.VersionMismatchError when the
+// user is not at the expected version, apperror.ErrFailedPrecondition when
+// it was not last updated at the expected time or is deleted, and
+// apperror.ErrConflict when another request changed it between the read and
+// the write. It returns nil when the user does not exist.
+func (s *userService) UpdateUser(ctx context.Context, id int64, update UserUpdate) (*model.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+	span.SetAttributes(observability.Int64("user_id", id), observability.Int64("expected_version", update.ExpectedVersion))
+
+	user, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (*model.User, error) {
+		user, err := uow.UserRepository().Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errUserNotFound
+		}
+		if err := checkPreconditions(user, update); err != nil {
+			return nil, err
+		}
+		if user.Status == model.UserStatusDeleted {
+			return nil, apperror.FailedPreconditionf("user %d is deleted", id)
+		}
+
+		if update.Email != nil {
+			if *update.Email != user.Email {
+				user.EmailVerifiedAt = nil
+			}
+			user.Email = *update.Email
+		}
+		if update.Username != nil {
+			user.Username = *update.Username
+		}
+
+		updated, err := uow.UserRepository().UpdateProfile(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if !updated {
+			return nil, apperror.Conflictf("user %d changed concurrently", id)
+		}
+		user.UpdatedBy = audit.ActorFromContext(ctx)
+		user.Version++
+		return user, nil
+	})
+	if errors.Is(err, errUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// checkPreconditions fails unless user is at the version and was last
+// updated at the time update expects, where it expects one. Timestamps are
+// compared to the microsecond, the precision Postgres stores.
+func checkPreconditions(user *model.User, update UserUpdate) error {
+	if update.ExpectedVersion != 0 && update.ExpectedVersion != user.Version {
+		return &apperror.VersionMismatchError{Resource: fmt.Sprintf("user %d", user.Id), ExpectedVersion: update.ExpectedVersion, CurrentVersion: user.Version}
+	}
+	if !update.ExpectedUpdatedAt.IsZero() && !update.ExpectedUpdatedAt.Truncate(time.Microsecond).Equal(user.UpdatedAt.Truncate(time.Microsecond)) {
+		return apperror.WithReason(
+			apperror.FailedPreconditionf("user %d was last updated at %s, not %s", user.Id, user.UpdatedAt.Format(time.RFC3339Nano), update.ExpectedUpdatedAt.Format(time.RFC3339Nano)),
+			"UPDATED_AT_MISMATCH",
+			map[string]string{
+				"current_updated_at": user.UpdatedAt.Format(time.RFC3339Nano),
+				"current_version":    strconv.FormatInt(user.Version, 10),
+			},
+		)
+	}
+	return nil
+}
+
+func (s *userService) SuspendUser(ctx context.Context, idempotencyId int64, id int64, reason string) (*model.User, error) {
+	return s.changeStatusOnce(ctx, "UserService.SuspendUser", constant.RequestTypeSuspendUser, idempotencyId, id, model.UserStatusSuspended, reason)
+}
+
+func (s *userService) ReactivateUser(ctx context.Context, idempotencyId int64, id int64, reason string) (*model.User, error) {
+	return s.changeStatusOnce(ctx, "UserService.ReactivateUser", constant.RequestTypeReactivateUser, idempotencyId, id, model.UserStatusActive, reason)
+}
+
+// changeStatusOnce runs changeStatus under an idempotency record, so a
+// retried request returns the first result instead of failing the
+// now-invalid transition.
+func (s *userService) changeStatusOnce(ctx context.Context, operation string, requestType constant.RequestType, idempotencyId int64, id int64, status model.UserStatus, reason string) (*model.User, error) {
+	ctx, span := s.tracer.Start(ctx, operation)
+	defer span.End()
+	span.SetAttributes(observability.Int64("idempotency_id", idempotencyId), observability.Int64("user_id", id))
+
+	result, err := RunInUnitOfWorkWithRetry(ctx, s.uowFactory, DefaultTransactionAttempts, func(uow repository.UnitOfWork) (any, error) {
+		return s.idempotency.Execute(ctx, uow.IdempotencyRecordRepository(), idempotencyId, requestType, &id, newUserResult, func() (any, error) {
+			user, err := s.changeStatus(ctx, uow, id, status, 0, reason)
+			if err != nil {
+				return nil, err
+			}
+			if user == nil {
+				return nil, errUserNotFound
+			}
+			return user, nil
+		})
+	})
+	if errors.Is(err, errUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 
 	return result.(*model.User), nil
 }
+
+// UserIdempotentRequests describes the idempotent requests of UserService.
+// Each stores the user as it was after the request; a rebuilt response is
+// the user as it is now.
+func UserIdempotentRequests() map[constant.RequestType]IdempotentRequest {
+	request := IdempotentRequest{NewResult: newUserResult, Rederive: rederiveUser}
+	return map[constant.RequestType]IdempotentRequest{
+		constant.RequestTypeCreateUser:     request,
+		constant.RequestTypeSuspendUser:    request,
+		constant.RequestTypeReactivateUser: request,
+	}
+}
+
+func newUserResult() any { return &model.User{} }
+
+func rederiveUser(ctx context.Context, uow repository.UnitOfWork, id int64) (any, error) {
+	user, err := uow.UserRepository().Get(ctx, id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// errUserNotFound aborts a status change of a missing user, so nothing is
+// committed and no idempotent result is recorded for it.
+var errUserNotFound = errors.New("user not found")
+
+// changeStatus fires the status transition, persists it conditionally on the
+// version it was read at, and writes the audit row, all in uow. A non-zero
+// expectedVersion must match the version read. It returns nil when the user
+// does not exist.
+func (s *userService) changeStatus(ctx context.Context, uow repository.UnitOfWork, id int64, status model.UserStatus, expectedVersion int64, reason string) (*model.User, error) {
+	user, err := uow.UserRepository().Get(ctx, id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	if expectedVersion != 0 && expectedVersion != user.Version {
+		return nil, &apperror.VersionMismatchError{Resource: fmt.Sprintf("user %d", id), ExpectedVersion: expectedVersion, CurrentVersion: user.Version}
+	}
+
+	from := user.Status
+	if err := s.status.Fire(ctx, user, status); err != nil {
+		return nil, fmt.Errorf("user %d: %w", id, err)
+	}
+
+	updated, err := uow.UserRepository().UpdateStatus(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, apperror.Conflictf("user %d changed concurrently", id)
+	}
+	user.UpdatedBy = audit.ActorFromContext(ctx)
+	user.Version++
+
+	err = uow.UserStatusChangeRepository().Insert(ctx, &model.UserStatusChange{
+		Id:         s.snowflake.Generate(),
+		UserId:     id,
+		FromStatus: from,
+		ToStatus:   user.Status,
+		Reason:     reason,
+		ChangedAt:  user.UpdatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
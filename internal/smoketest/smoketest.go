@@ -0,0 +1,140 @@
+// Package smoketest checks that a deployed server answers end to end: it
+// lists the services exposed via reflection, calls the health service and
+// looks up a known probe user.
+package smoketest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+)
+
+// RequiredServices are the services a healthy server exposes via reflection.
+// AdminService must not be among them: it is only served on the admin
+// listener.
+var RequiredServices = []string{
+	v1.UserService_ServiceDesc.ServiceName,
+	v1.LedgerService_ServiceDesc.ServiceName,
+	grpc_health_v1.Health_ServiceDesc.ServiceName,
+}
+
+type Config struct {
+	// ProbeUserId or ProbeUserPublicId names a user that always exists in
+	// the target environment. Use the public id when PUBLIC_ID_MODE is
+	// opaque.
+	ProbeUserId       int64
+	ProbeUserPublicId string
+}
+
+// Check is the outcome of one step of the smoke test.
+type Check struct {
+	Name       string `json:"name"`
+	Passed     bool   `json:"passed"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Report is the structured result printed by cmd/smoketest.
+type Report struct {
+	Target string  `json:"target"`
+	Passed bool    `json:"passed"`
+	Checks []Check `json:"checks"`
+}
+
+// Run executes every check against conn, even after one fails, so the report
+// shows everything that is broken.
+func Run(ctx context.Context, conn grpc.ClientConnInterface, target string, cfg Config) *Report {
+	report := &Report{Target: target, Passed: true}
+	run := func(name string, fn func(ctx context.Context) error) {
+		start := time.Now()
+		err := fn(ctx)
+		check := Check{Name: name, Passed: err == nil, DurationMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			check.Error = err.Error()
+			report.Passed = false
+		}
+		report.Checks = append(report.Checks, check)
+	}
+
+	run("reflection", func(ctx context.Context) error { return checkReflection(ctx, conn) })
+	run("health", func(ctx context.Context) error { return checkHealth(ctx, conn) })
+	run("get_user_by_id", func(ctx context.Context) error { return checkProbeUser(ctx, conn, cfg) })
+	return report
+}
+
+func checkReflection(ctx context.Context, conn grpc.ClientConnInterface) error {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return err
+	}
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	_ = stream.CloseSend()
+	if _, err := stream.Recv(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	listed := resp.GetListServicesResponse()
+	if listed == nil {
+		return fmt.Errorf("unexpected reflection response %T", resp.GetMessageResponse())
+	}
+	var names []string
+	for _, svc := range listed.GetService() {
+		names = append(names, svc.GetName())
+	}
+	if admin := v1.AdminService_ServiceDesc.ServiceName; slices.Contains(names, admin) {
+		return fmt.Errorf("%s is exposed on the public listener", admin)
+	}
+	var missing []string
+	for _, name := range RequiredServices {
+		if !slices.Contains(names, name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("services not exposed: %v", missing)
+	}
+	return nil
+}
+
+func checkHealth(ctx context.Context, conn grpc.ClientConnInterface) error {
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+		return fmt.Errorf("health status is %s", resp.GetStatus())
+	}
+	return nil
+}
+
+func checkProbeUser(ctx context.Context, conn grpc.ClientConnInterface, cfg Config) error {
+	req := &v1.GetUserByIdRequest{Id: cfg.ProbeUserId, PublicId: cfg.ProbeUserPublicId}
+	resp, err := v1.NewUserServiceClient(conn).GetUserById(ctx, req)
+	if err != nil {
+		return err
+	}
+	if cfg.ProbeUserId != 0 && resp.GetId() != cfg.ProbeUserId {
+		return fmt.Errorf("got user %d, want %d", resp.GetId(), cfg.ProbeUserId)
+	}
+	if cfg.ProbeUserPublicId != "" && resp.GetPublicId() != cfg.ProbeUserPublicId {
+		return fmt.Errorf("got user %q, want %q", resp.GetPublicId(), cfg.ProbeUserPublicId)
+	}
+	return nil
+}
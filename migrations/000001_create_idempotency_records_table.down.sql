@@ -1 +1 @@
-DROP TABLE IF EXISTS main.idempotency_records;
+DROP TABLE IF EXISTS idempotency_records;
@@ -1,4 +1,4 @@
-CREATE TABLE IF NOT EXISTS main.idempotency_records (
+CREATE TABLE IF NOT EXISTS idempotency_records (
     id BIGINT PRIMARY KEY,
     request_type VARCHAR(255) NOT NULL,
     reference_id BIGINT NOT NULL,
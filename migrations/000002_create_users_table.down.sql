@@ -1 +1 @@
-DROP TABLE IF EXISTS main.users;
+DROP TABLE IF EXISTS users;
@@ -1,4 +1,4 @@
-CREATE TABLE IF NOT EXISTS main.users (
+CREATE TABLE IF NOT EXISTS users (
     id BIGINT PRIMARY KEY,
     email VARCHAR(255) NOT NULL,
     username VARCHAR(255) NOT NULL,
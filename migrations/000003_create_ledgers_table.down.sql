@@ -1 +1 @@
-DROP TABLE IF EXISTS main.ledgers;
+DROP TABLE IF EXISTS ledgers;
@@ -1,4 +1,4 @@
-CREATE TABLE IF NOT EXISTS main.ledgers (
+CREATE TABLE IF NOT EXISTS ledgers (
     id BIGINT PRIMARY KEY,
     user_id BIGINT NOT NULL,
     transaction_type VARCHAR(16) NOT NULL,
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id BIGINT PRIMARY KEY,
+    source VARCHAR(32) NOT NULL,
+    event_type VARCHAR(255) NOT NULL,
+    payload TEXT NOT NULL,
+    last_error TEXT NOT NULL,
+    attempts INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    replayed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS dead_letters_pending_idx ON dead_letters (source, id) WHERE replayed_at IS NULL;
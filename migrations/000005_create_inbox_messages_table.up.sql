@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS inbox_messages (
+    handler VARCHAR(255) NOT NULL,
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR(255) NOT NULL,
+    processed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (handler, event_id)
+);
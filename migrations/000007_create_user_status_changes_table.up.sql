@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS user_status_changes (
+    id BIGINT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    from_status VARCHAR(16) NOT NULL,
+    to_status VARCHAR(16) NOT NULL,
+    reason TEXT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS user_status_changes_user_id_idx ON user_status_changes (user_id, changed_at);
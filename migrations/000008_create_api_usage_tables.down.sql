@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS api_usage_reports;
+DROP TABLE IF EXISTS api_usage;
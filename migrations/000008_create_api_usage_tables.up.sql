@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS api_usage (
+    usage_date DATE NOT NULL,
+    caller VARCHAR(255) NOT NULL,
+    method VARCHAR(255) NOT NULL,
+    requests BIGINT NOT NULL,
+    units BIGINT NOT NULL,
+    PRIMARY KEY (usage_date, caller, method)
+);
+
+CREATE TABLE IF NOT EXISTS api_usage_reports (
+    usage_date DATE PRIMARY KEY,
+    reported_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+ALTER TABLE ledgers DROP COLUMN IF EXISTS created_by;
+ALTER TABLE users DROP COLUMN IF EXISTS updated_by;
+ALTER TABLE users DROP COLUMN IF EXISTS created_by;
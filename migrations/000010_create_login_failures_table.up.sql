@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS login_failures (
+    user_id BIGINT NOT NULL,
+    ip VARCHAR(45) NOT NULL,
+    failures INT NOT NULL,
+    locked_until TIMESTAMPTZ,
+    last_failed_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, ip)
+);
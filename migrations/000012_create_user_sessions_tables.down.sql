@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_session_events;
+DROP TABLE IF EXISTS user_sessions;
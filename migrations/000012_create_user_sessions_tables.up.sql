@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id BIGINT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS user_sessions_user_id_idx ON user_sessions (user_id, last_seen_at);
+
+CREATE TABLE IF NOT EXISTS user_session_events (
+    id BIGINT PRIMARY KEY,
+    session_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    event VARCHAR(16) NOT NULL,
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by VARCHAR(255) NOT NULL DEFAULT 'system'
+);
+
+CREATE INDEX IF NOT EXISTS user_session_events_user_id_idx ON user_session_events (user_id, created_at);
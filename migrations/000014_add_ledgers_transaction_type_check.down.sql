@@ -0,0 +1 @@
+ALTER TABLE ledgers DROP CONSTRAINT IF EXISTS ledgers_transaction_type_check;
@@ -0,0 +1,7 @@
+-- NOT VALID adds the constraint without scanning the table under an
+-- exclusive lock; VALIDATE then checks existing rows under a weaker one.
+-- Rows with other transaction types must be fixed before migrating.
+ALTER TABLE ledgers DROP CONSTRAINT IF EXISTS ledgers_transaction_type_check;
+ALTER TABLE ledgers ADD CONSTRAINT ledgers_transaction_type_check
+    CHECK (transaction_type IN ('deposit', 'withdraw', 'transfer')) NOT VALID;
+ALTER TABLE ledgers VALIDATE CONSTRAINT ledgers_transaction_type_check;
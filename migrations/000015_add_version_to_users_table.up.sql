@@ -0,0 +1,3 @@
+-- version is bumped on every update, so clients can make a write
+-- conditional on the user not having changed since they read it.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS audit_records (
+    id BIGINT PRIMARY KEY,
+    actor VARCHAR(255) NOT NULL,
+    method VARCHAR(255) NOT NULL,
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    request TEXT NOT NULL DEFAULT '',
+    code VARCHAR(32) NOT NULL,
+    duration_ms BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS audit_records_actor_idx ON audit_records (actor, created_at);
+CREATE INDEX IF NOT EXISTS audit_records_method_idx ON audit_records (method, created_at);
@@ -0,0 +1,3 @@
+-- Duplicate emails must be merged or removed before migrating, or the index
+-- cannot be built.
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (email);
@@ -0,0 +1,17 @@
+-- email_verified_at is when the user proved they own their current email.
+-- Changing the email clears it.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+-- Only a hash of each token is stored; the token itself is only ever in the
+-- email sent. email is the address the token was sent to, so a token stops
+-- verifying anything once the user changes their email.
+CREATE TABLE IF NOT EXISTS verification_tokens (
+    token_hash CHAR(64) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS verification_tokens_user_id_idx ON verification_tokens (user_id);
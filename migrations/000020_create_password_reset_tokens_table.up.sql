@@ -0,0 +1,13 @@
+-- A reset token is the selector, which finds the row, followed by a
+-- verifier. Only the verifier's hash is stored, and it is compared in
+-- constant time.
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    selector CHAR(26) PRIMARY KEY,
+    verifier_hash CHAR(64) NOT NULL,
+    user_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS password_reset_tokens_user_id_idx ON password_reset_tokens (user_id);
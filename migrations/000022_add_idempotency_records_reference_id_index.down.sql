@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idempotency_records_reference_id_idx;
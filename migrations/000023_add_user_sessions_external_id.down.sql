@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS user_sessions_external_id_key;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS external_id;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS issuer;
@@ -0,0 +1,7 @@
+-- Sessions opened by an OpenID Connect token are keyed by the token's issuer
+-- and its sid claim, so every token of one provider session maps to the same
+-- row. Sessions opened any other way leave external_id empty.
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS issuer VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS external_id VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_sessions_external_id_key ON user_sessions (issuer, external_id) WHERE external_id <> '';
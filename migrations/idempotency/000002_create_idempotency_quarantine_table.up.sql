@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS idempotency_quarantine (
+    id BIGINT PRIMARY KEY,
+    request_type VARCHAR(255) NOT NULL,
+    reference_id BIGINT NOT NULL,
+    response_data TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    quarantined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reason TEXT NOT NULL DEFAULT ''
+);
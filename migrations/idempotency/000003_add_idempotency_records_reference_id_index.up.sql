@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idempotency_records_reference_id_idx ON idempotency_records (reference_id, id);
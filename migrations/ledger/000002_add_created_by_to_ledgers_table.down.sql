@@ -0,0 +1 @@
+ALTER TABLE ledgers DROP COLUMN IF EXISTS created_by;
@@ -0,0 +1 @@
+ALTER TABLE ledgers ADD COLUMN IF NOT EXISTS created_by VARCHAR(255) NOT NULL DEFAULT 'system';
@@ -1,8 +1,150 @@
 package apperror
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 var (
 	ErrNotFound        = errors.New("not found")
 	ErrInvalidArgument = errors.New("invalid argument")
-)
\ No newline at end of file
+	// ErrFailedPrecondition means the request is valid but the resource is
+	// not in a state that allows it.
+	ErrFailedPrecondition = errors.New("failed precondition")
+	// ErrConflict means the request raced with, or contradicts, the current
+	// state of the resource, e.g. an invalid state transition.
+	ErrConflict = errors.New("conflict")
+	// ErrResourceExhausted means the caller has used up a quota, e.g. its
+	// request rate limit.
+	ErrResourceExhausted = errors.New("resource exhausted")
+	// ErrUnauthenticated means the caller's credentials are missing or do
+	// not verify, e.g. a bad request signature.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrPermissionDenied means the caller is authenticated but not allowed
+	// to make the request.
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrAlreadyExists means the resource the request would create is
+	// already there, e.g. an account for a taken email address.
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrUnavailable means a dependency the request needs is down for now
+	// and the request can be retried as it is.
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// FieldViolation describes why one request field is invalid. Reason is a
+// stable, machine-readable code; Description is for people.
+type FieldViolation struct {
+	Field       string
+	Reason      string
+	Description string
+}
+
+// ValidationError is an ErrInvalidArgument listing every field violation
+// found, so clients can report them all at once. The error interceptor
+// returns them as google.rpc.BadRequest status details.
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	descriptions := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		descriptions[i] = v.Field + " " + v.Description
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+func (e *ValidationError) Unwrap() error { return ErrInvalidArgument }
+
+// FieldError returns a *ValidationError with the single violation of field,
+// for handlers that can only check one field.
+func FieldError(field, reason, description string) error {
+	return &ValidationError{Violations: []FieldViolation{{Field: field, Reason: reason, Description: description}}}
+}
+
+// ValidationErrors accumulates the field violations found while checking a
+// request, so a handler checks every field before failing instead of
+// returning on the first one. The zero value is ready to use.
+type ValidationErrors struct {
+	violations []FieldViolation
+}
+
+// Add records that field breaks the rule named by reason.
+func (v *ValidationErrors) Add(field, reason, description string) {
+	v.violations = append(v.violations, FieldViolation{Field: field, Reason: reason, Description: description})
+}
+
+// Merge records the violations of err when it is a *ValidationError. It
+// reports whether checking can go on, that is whether err was nil or a
+// *ValidationError; any other error should be returned as it is.
+func (v *ValidationErrors) Merge(err error) bool {
+	if err == nil {
+		return true
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+	v.violations = append(v.violations, validationErr.Violations...)
+	return true
+}
+
+// Err returns a *ValidationError listing every violation recorded, in the
+// order they were recorded, or nil when there were none.
+func (v *ValidationErrors) Err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: v.violations}
+}
+
+// VersionMismatchError is an ErrFailedPrecondition for a write that expected
+// Resource at a version it is no longer at. The error interceptor returns
+// CurrentVersion in a google.rpc.ErrorInfo status detail, so the client can
+// re-read the resource and decide whether to write again.
+type VersionMismatchError struct {
+	Resource        string
+	ExpectedVersion int64
+	CurrentVersion  int64
+}
+
+func (e *VersionMismatchError) Error() string {
+	return fmt.Sprintf("%s is at version %d, not %d", e.Resource, e.CurrentVersion, e.ExpectedVersion)
+}
+
+func (e *VersionMismatchError) Unwrap() error { return ErrFailedPrecondition }
+
+// ReasonError is Err annotated with a stable, machine-readable Reason in
+// UPPER_SNAKE_CASE, and Metadata about this occurrence. The error
+// interceptor returns them as a google.rpc.ErrorInfo status detail, whatever
+// Err's code, so clients can branch on Reason instead of parsing messages.
+type ReasonError struct {
+	Err      error
+	Reason   string
+	Metadata map[string]string
+}
+
+// WithReason wraps err in a *ReasonError.
+func WithReason(err error, reason string, metadata map[string]string) error {
+	return &ReasonError{Err: err, Reason: reason, Metadata: metadata}
+}
+
+func (e *ReasonError) Error() string { return e.Err.Error() }
+
+func (e *ReasonError) Unwrap() error { return e.Err }
+
+// RetryAfterError is Err annotated with how long the caller should wait
+// before retrying. The error interceptor returns RetryAfter as a
+// google.rpc.RetryInfo status detail.
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%v; retry after %s", e.Err, e.RetryAfter)
+}
+
+func (e *RetryAfterError) Unwrap() error { return e.Err }
@@ -0,0 +1,66 @@
+package apperror
+
+import (
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// Domain is the google.rpc.ErrorInfo domain of the reasons the service
+// returns.
+const Domain = "go-grpc-template"
+
+// Reasons shared across the service, which clients can branch on instead of
+// matching messages. Like every reason, they never change once shipped.
+const (
+	// ReasonUserNotFound: the user the request names does not exist.
+	ReasonUserNotFound = "USER_NOT_FOUND"
+	// ReasonEmailTaken: another user already registered the email address.
+	ReasonEmailTaken = "EMAIL_TAKEN"
+	// ReasonInsufficientBalance: the balance cannot cover the amount. The
+	// service keeps no balances itself; it is for the layers above it.
+	ReasonInsufficientBalance = "INSUFFICIENT_BALANCE"
+	// ReasonIdempotencyConflict: the idempotency key was already used for a
+	// request of another type.
+	ReasonIdempotencyConflict = "IDEMPOTENCY_CONFLICT"
+	// ReasonRateLimited: the caller is over its request rate limit.
+	ReasonRateLimited = "RATE_LIMITED"
+)
+
+// UserNotFound returns an ErrNotFound for user id with reason
+// ReasonUserNotFound, reading "user 42: not found".
+func UserNotFound(id any) error {
+	return WithReason(wrapf(ErrNotFound, "user %v", []any{id}), ReasonUserNotFound, nil)
+}
+
+// ReasonOf returns the reason err carries and its metadata: that of err's
+// outermost *ReasonError, or, for a gRPC status error a client received,
+// that of its ErrorInfo detail in Domain. It returns "" when there is
+// neither.
+//
+//	if reason, _ := apperror.ReasonOf(err); reason == apperror.ReasonEmailTaken {
+//		// ask for another address
+//	}
+func ReasonOf(err error) (string, map[string]string) {
+	var reasonErr *ReasonError
+	if errors.As(err, &reasonErr) {
+		return reasonErr.Reason, reasonErr.Metadata
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return "", nil
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Domain == Domain {
+			return info.Reason, info.Metadata
+		}
+	}
+	return "", nil
+}
+
+// HasReason reports whether err carries reason, see ReasonOf.
+func HasReason(err error, reason string) bool {
+	got, _ := ReasonOf(err)
+	return got != "" && got == reason
+}
@@ -0,0 +1,151 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// maxStackDepth bounds the frames an Error records.
+const maxStackDepth = 32
+
+// Field is a key/value pair of context about an error, such as the id of the
+// row it concerns.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Error is Err annotated with a message, the stack where it was created and
+// Fields of context. The error interceptor logs the stack and fields of
+// errors it returns as Internal; clients only see the message.
+type Error struct {
+	Err    error
+	Msg    string
+	Fields []Field
+	stack  []uintptr
+}
+
+// Wrapf returns err annotated with a message formatted from format and args,
+// reading "<message>: <err>", and the caller's stack. err is usually a
+// sentinel, whose status code the result keeps, or an unexpected error to be
+// logged with where it surfaced.
+func Wrapf(err error, format string, args ...any) *Error {
+	return wrapf(err, format, args)
+}
+
+// NotFoundf returns an ErrNotFound described by format and args, e.g.
+// NotFoundf("user %d", id) reads "user 42: not found".
+func NotFoundf(format string, args ...any) *Error { return wrapf(ErrNotFound, format, args) }
+
+// InvalidArgumentf returns an ErrInvalidArgument described by format and args.
+func InvalidArgumentf(format string, args ...any) *Error {
+	return wrapf(ErrInvalidArgument, format, args)
+}
+
+// FailedPreconditionf returns an ErrFailedPrecondition described by format
+// and args.
+func FailedPreconditionf(format string, args ...any) *Error {
+	return wrapf(ErrFailedPrecondition, format, args)
+}
+
+// Conflictf returns an ErrConflict described by format and args.
+func Conflictf(format string, args ...any) *Error { return wrapf(ErrConflict, format, args) }
+
+// AlreadyExistsf returns an ErrAlreadyExists described by format and args.
+func AlreadyExistsf(format string, args ...any) *Error {
+	return wrapf(ErrAlreadyExists, format, args)
+}
+
+// PermissionDeniedf returns an ErrPermissionDenied described by format and
+// args.
+func PermissionDeniedf(format string, args ...any) *Error {
+	return wrapf(ErrPermissionDenied, format, args)
+}
+
+// Unauthenticatedf returns an ErrUnauthenticated described by format and
+// args.
+func Unauthenticatedf(format string, args ...any) *Error {
+	return wrapf(ErrUnauthenticated, format, args)
+}
+
+// Unavailablef returns an ErrUnavailable described by format and args.
+func Unavailablef(format string, args ...any) *Error { return wrapf(ErrUnavailable, format, args) }
+
+// wrapf builds an Error recording the stack of the exported constructor's
+// caller.
+func wrapf(err error, format string, args []any) *Error {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(3, pcs[:])
+	return &Error{Err: err, Msg: fmt.Sprintf(format, args...), stack: pcs[:n]}
+}
+
+// With adds the field key=value to e and returns it, so context can be
+// chained onto a constructor: NotFoundf("user %d", id).With("tenant", t).
+func (e *Error) With(key string, value any) *Error {
+	e.Fields = append(e.Fields, Field{Key: key, Value: value})
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Msg == "" {
+		return e.Err.Error()
+	}
+	return e.Msg + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// StackTrace formats the stack where e was created, one "function\n\tfile:line"
+// entry per frame, innermost first.
+func (e *Error) StackTrace() string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// FieldsOf returns the fields of every Error in err's chain, outermost
+// first.
+func FieldsOf(err error) []Field {
+	var fields []Field
+	walk(err, func(e *Error) { fields = append(fields, e.Fields...) })
+	return fields
+}
+
+// StackTraceOf returns the stack of the innermost Error in err's chain, the
+// one created closest to where things went wrong, or "" when there is none.
+func StackTraceOf(err error) string {
+	var innermost *Error
+	walk(err, func(e *Error) { innermost = e })
+	if innermost == nil {
+		return ""
+	}
+	return innermost.StackTrace()
+}
+
+// walk calls fn with each Error in err's chain, outermost first, following
+// both Unwrap() error and Unwrap() []error.
+func walk(err error, fn func(*Error)) {
+	for err != nil {
+		if e, ok := err.(*Error); ok {
+			fn(e)
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, inner := range u.Unwrap() {
+				walk(inner, fn)
+			}
+			return
+		default:
+			err = errors.Unwrap(err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jt828/go-grpc-template/pkg/model"
+)
+
+// SystemActor is recorded for writes made outside any request, such as
+// background jobs and event handlers.
+const SystemActor = "system"
+
+type actorKey struct{}
+
+// ContextWithActor records who is making the writes issued with ctx, e.g.
+// "api_key:partner-a" or "user:42".
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor recorded in ctx, or SystemActor when
+// there is none.
+func ActorFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return SystemActor
+	}
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
+
+// Level is how much of a call an audit policy records.
+type Level string
+
+const (
+	// LevelNone records nothing.
+	LevelNone Level = "none"
+	// LevelCall records who called which method and its outcome.
+	LevelCall Level = "call"
+	// LevelRequest also records the request, with its debug_redact fields
+	// redacted.
+	LevelRequest Level = "request"
+)
+
+func ParseLevel(s string) (Level, error) {
+	switch level := Level(strings.ToLower(s)); level {
+	case LevelNone, LevelCall, LevelRequest:
+		return level, nil
+	}
+	return "", fmt.Errorf("unknown audit level %q, want none, call or request", s)
+}
+
+// Sink stores audit records. Write is called once the audited call has
+// returned, and must not modify record.
+type Sink interface {
+	Write(ctx context.Context, record *model.AuditRecord) error
+}
+
+// RecordRepository stores audit records.
+type RecordRepository interface {
+	Insert(ctx context.Context, record *model.AuditRecord) error
+}
@@ -0,0 +1,52 @@
+package implementation
+
+import (
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"gorm.io/gorm"
+)
+
+const (
+	createdByColumn = "created_by"
+	updatedByColumn = "updated_by"
+)
+
+// GormActorPlugin stamps the actor carried by the statement context into
+// created_by and updated_by on every insert, and into updated_by on every
+// update, for tables that have those columns. Values set by the caller are
+// overwritten, so the columns cannot be forged through the API.
+type GormActorPlugin struct{}
+
+func NewGormActorPlugin() *GormActorPlugin {
+	return &GormActorPlugin{}
+}
+
+func (p *GormActorPlugin) Name() string {
+	return "actor"
+}
+
+func (p *GormActorPlugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("actor:before_create", p.beforeCreate); err != nil {
+		return err
+	}
+	return db.Callback().Update().Before("gorm:update").Register("actor:before_update", p.beforeUpdate)
+}
+
+func (p *GormActorPlugin) beforeCreate(db *gorm.DB) {
+	p.stamp(db, createdByColumn, updatedByColumn)
+}
+
+func (p *GormActorPlugin) beforeUpdate(db *gorm.DB) {
+	p.stamp(db, updatedByColumn)
+}
+
+func (p *GormActorPlugin) stamp(db *gorm.DB, columns ...string) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	actor := audit.ActorFromContext(db.Statement.Context)
+	for _, column := range columns {
+		if db.Statement.Schema.LookUpField(column) != nil {
+			db.Statement.SetColumn(column, actor, true)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package implementation
+
+import (
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	createdAtColumn = "created_at"
+	updatedAtColumn = "updated_at"
+)
+
+// GormTimestampPlugin stamps the time from its clock into updated_at on
+// every insert and update, and into created_at on inserts that leave it
+// zero, for tables that have those columns. Times are UTC and truncated to
+// the microsecond Postgres stores, so the values written back into the
+// model equal the ones read later.
+type GormTimestampPlugin struct {
+	clock func() time.Time
+}
+
+// NewGormTimestampPlugin returns a plugin reading the time from clock, or
+// from time.Now when clock is nil.
+func NewGormTimestampPlugin(clock func() time.Time) *GormTimestampPlugin {
+	if clock == nil {
+		clock = time.Now
+	}
+	return &GormTimestampPlugin{clock: clock}
+}
+
+func (p *GormTimestampPlugin) Name() string {
+	return "timestamp"
+}
+
+func (p *GormTimestampPlugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("timestamp:before_create", p.beforeCreate); err != nil {
+		return err
+	}
+	return db.Callback().Update().Before("gorm:update").Register("timestamp:before_update", p.beforeUpdate)
+}
+
+func (p *GormTimestampPlugin) beforeCreate(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	now := p.now()
+	if field := db.Statement.Schema.LookUpField(createdAtColumn); field != nil {
+		switch rv := db.Statement.ReflectValue; rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				p.fillZero(db, field, reflect.Indirect(rv.Index(i)), now)
+			}
+		case reflect.Struct:
+			p.fillZero(db, field, rv, now)
+		}
+	}
+	p.stampUpdatedAt(db, now)
+}
+
+// fillZero sets field of row to now when it is zero.
+func (p *GormTimestampPlugin) fillZero(db *gorm.DB, field *schema.Field, row reflect.Value, now time.Time) {
+	if _, zero := field.ValueOf(db.Statement.Context, row); zero {
+		db.AddError(field.Set(db.Statement.Context, row, now))
+	}
+}
+
+func (p *GormTimestampPlugin) beforeUpdate(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	p.stampUpdatedAt(db, p.now())
+}
+
+func (p *GormTimestampPlugin) stampUpdatedAt(db *gorm.DB, now time.Time) {
+	if db.Statement.Schema.LookUpField(updatedAtColumn) != nil {
+		db.Statement.SetColumn(updatedAtColumn, now, true)
+	}
+}
+
+func (p *GormTimestampPlugin) now() time.Time {
+	return p.clock().UTC().Truncate(time.Microsecond)
+}
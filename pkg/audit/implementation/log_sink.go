@@ -0,0 +1,35 @@
+package implementation
+
+import (
+	"context"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+)
+
+// LogSink writes every audit record as an "rpc audited" info entry, for
+// deployments that ship logs to an append-only store.
+type LogSink struct {
+	log observability.Logger
+}
+
+func NewLogSink(log observability.Logger) audit.Sink {
+	return &LogSink{log: log}
+}
+
+func (s *LogSink) Write(ctx context.Context, record *model.AuditRecord) error {
+	fields := []observability.Field{
+		observability.String("actor", record.Actor),
+		observability.String("method", record.Method),
+		observability.String("request_id", record.RequestId),
+		observability.String("code", record.Code),
+		observability.Duration("duration", time.Duration(record.Duration)*time.Millisecond),
+	}
+	if record.Request != "" {
+		fields = append(fields, observability.String("request", record.Request))
+	}
+	s.log.Info("rpc audited", fields...)
+	return nil
+}
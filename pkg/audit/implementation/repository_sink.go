@@ -0,0 +1,26 @@
+package implementation
+
+import (
+	"context"
+
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/snowflake"
+)
+
+// RepositorySink inserts every audit record through repo, with an id from
+// snowflake.
+type RepositorySink struct {
+	repo      audit.RecordRepository
+	snowflake snowflake.Snowflake
+}
+
+func NewRepositorySink(repo audit.RecordRepository, snowflake snowflake.Snowflake) audit.Sink {
+	return &RepositorySink{repo: repo, snowflake: snowflake}
+}
+
+func (s *RepositorySink) Write(ctx context.Context, record *model.AuditRecord) error {
+	stored := *record
+	stored.Id = s.snowflake.Generate()
+	return s.repo.Insert(ctx, &stored)
+}
@@ -1,9 +1,15 @@
 package circuitbreaker
 
+import "errors"
+
+// ErrOpen is returned by Execute when the breaker rejects a call without
+// running it, because it is open or its half-open probe quota is used up.
+var ErrOpen = errors.New("circuit breaker open")
+
 type State int
 
 const (
-	Closed   State = iota
+	Closed State = iota
 	HalfOpen
 	Open
 )
@@ -1,6 +1,9 @@
 package implementation
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
 	"github.com/sony/gobreaker/v2"
 )
@@ -16,7 +19,11 @@ func NewCircuitBreaker(settings gobreaker.Settings) circuitbreaker.CircuitBreake
 }
 
 func (g *gobreakerCircuitBreaker) Execute(fn func() (any, error)) (any, error) {
-	return g.cb.Execute(fn)
+	result, err := g.cb.Execute(fn)
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return nil, fmt.Errorf("%w: %w", circuitbreaker.ErrOpen, err)
+	}
+	return result, err
 }
 
 func (g *gobreakerCircuitBreaker) State() circuitbreaker.State {
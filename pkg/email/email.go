@@ -0,0 +1,24 @@
+// Package email sends transactional email, such as verification links.
+package email
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInvalidHeader is returned for a recipient or subject containing a line
+// break, which would let it inject headers of its own.
+var ErrInvalidHeader = errors.New("email header contains a line break")
+
+// Message is a plain-text email to one recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers email. Send returns once the message has been handed to
+// the mail server; it is not a delivery receipt.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,26 @@
+package implementation
+
+import (
+	"context"
+
+	"github.com/jt828/go-grpc-template/pkg/email"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+)
+
+// LogSender logs email instead of sending it, for development and tests.
+// The recipient and subject are logged at info level; the body, which may
+// carry a verification link, only at debug level.
+type LogSender struct {
+	log observability.Logger
+}
+
+func NewLogSender(log observability.Logger) email.Sender {
+	return &LogSender{log: log}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg email.Message) error {
+	log := observability.LoggerFromContext(ctx, s.log)
+	log.Info("email sent", observability.String("to", msg.To), observability.String("subject", msg.Subject))
+	log.Debug("email body", observability.String("to", msg.To), observability.String("body", msg.Body))
+	return nil
+}
@@ -0,0 +1,54 @@
+package event
+
+import (
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/proto/events"
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// UserCreated builds the public UserCreatedV1 event. Credentials are never
+// part of an event payload.
+func UserCreated(user *model.User) *events.UserCreatedV1 {
+	return &events.UserCreatedV1{
+		UserId:    user.Id,
+		Email:     user.Email,
+		Username:  user.Username,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+	}
+}
+
+// LedgerEntryAdded builds the public LedgerEntryAddedV1 event.
+func LedgerEntryAdded(ledger *model.Ledger) *events.LedgerEntryAddedV1 {
+	return &events.LedgerEntryAddedV1{
+		LedgerId:        ledger.Id,
+		UserId:          ledger.UserId,
+		TransactionType: string(ledger.TransactionType),
+		Token:           ledger.Token,
+		Amount:          ledger.Amount.String(),
+		CreatedAt:       timestamppb.New(ledger.CreatedAt),
+	}
+}
+
+// ApiUsageReported builds the ApiUsageReportedV1 event for one caller's
+// usage rows, all of which must belong to the same day.
+func ApiUsageReported(caller string, date time.Time, usage []*model.ApiUsage) *events.ApiUsageReportedV1 {
+	e := &events.ApiUsageReportedV1{
+		Caller:    caller,
+		UsageDate: date.Format(time.DateOnly),
+		Methods:   make([]*events.ApiMethodUsage, len(usage)),
+	}
+	for i, u := range usage {
+		e.Methods[i] = &events.ApiMethodUsage{Method: u.Method, Requests: u.Requests, Units: u.Units}
+		e.TotalRequests += u.Requests
+		e.TotalUnits += u.Units
+	}
+	return e
+}
+
+// LedgerAmount parses the decimal amount carried by a LedgerEntryAddedV1.
+func LedgerAmount(e *events.LedgerEntryAddedV1) (decimal.Decimal, error) {
+	return decimal.NewFromString(e.GetAmount())
+}
@@ -0,0 +1,117 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const typeURLPrefix = "type.googleapis.com/"
+
+var (
+	// ErrUnknownType means the payload's schema is not linked into this
+	// binary, typically a newer event version than the consumer knows.
+	ErrUnknownType = errors.New("unknown event type")
+	// ErrTypeMismatch means the payload is a different event than requested.
+	ErrTypeMismatch = errors.New("event type mismatch")
+)
+
+// Envelope is a domain event as it travels between services. TypeURL names
+// the payload's proto message so consumers never depend on Go types.
+type Envelope struct {
+	Id         int64
+	TypeURL    string
+	Payload    []byte
+	OccurredAt time.Time
+}
+
+// Pack serialises msg into an envelope.
+func Pack(id int64, msg proto.Message, occurredAt time.Time) (*Envelope, error) {
+	payload, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		Id:         id,
+		TypeURL:    TypeURL(msg),
+		Payload:    payload,
+		OccurredAt: occurredAt,
+	}, nil
+}
+
+// Unpack decodes the payload into a new message of the type named by
+// TypeURL. It returns ErrUnknownType when that type is not registered.
+func Unpack(envelope *Envelope) (proto.Message, error) {
+	msg, err := anypb.UnmarshalNew(envelope.any(), proto.UnmarshalOptions{})
+	if errors.Is(err, protoregistry.NotFound) {
+		return nil, fmt.Errorf("%s: %w", envelope.TypeURL, ErrUnknownType)
+	}
+	return msg, err
+}
+
+// UnpackTo decodes the payload into msg. It returns ErrTypeMismatch when the
+// envelope carries a different message type.
+func UnpackTo(envelope *Envelope, msg proto.Message) error {
+	if envelope.TypeURL != TypeURL(msg) {
+		return fmt.Errorf("got %s, want %s: %w", envelope.TypeURL, TypeURL(msg), ErrTypeMismatch)
+	}
+	return proto.Unmarshal(envelope.Payload, msg)
+}
+
+func (e *Envelope) any() *anypb.Any {
+	return &anypb.Any{TypeUrl: e.TypeURL, Value: e.Payload}
+}
+
+// TypeURL returns the type URL identifying msg's schema.
+func TypeURL(msg proto.Message) string {
+	return typeURLPrefix + string(msg.ProtoReflect().Descriptor().FullName())
+}
+
+// Type is a type URL split into the event name and its schema version.
+type Type struct {
+	FullName protoreflect.FullName
+	Name     string
+	Version  int
+}
+
+// ParseTypeURL splits a type URL such as
+// "type.googleapis.com/proto.events.v1.UserCreatedV1" into its event name
+// ("UserCreated") and version (1). Messages without a V<n> suffix are
+// version 0.
+func ParseTypeURL(typeURL string) (Type, error) {
+	fullName := protoreflect.FullName(typeURL[strings.LastIndex(typeURL, "/")+1:])
+	if !fullName.IsValid() {
+		return Type{}, fmt.Errorf("invalid type url %q", typeURL)
+	}
+
+	name := string(fullName.Name())
+	t := Type{FullName: fullName, Name: name}
+	if i := strings.LastIndex(name, "V"); i > 0 {
+		if version, err := strconv.Atoi(name[i+1:]); err == nil && version > 0 {
+			t.Name = name[:i]
+			t.Version = version
+		}
+	}
+	return t, nil
+}
+
+// SameEvent reports whether two type URLs are versions of the same event,
+// e.g. UserCreatedV1 and UserCreatedV2.
+func SameEvent(a, b string) bool {
+	ta, err := ParseTypeURL(a)
+	if err != nil {
+		return false
+	}
+	tb, err := ParseTypeURL(b)
+	if err != nil {
+		return false
+	}
+	return ta.FullName.Parent() == tb.FullName.Parent() && ta.Name == tb.Name
+}
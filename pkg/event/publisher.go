@@ -0,0 +1,10 @@
+package event
+
+import "context"
+
+// Publisher delivers envelopes to a message broker. Publish returns once the
+// broker has accepted the envelope; consumers deduplicate by Envelope.Id, so
+// a retried publish with the same id is safe.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, envelope *Envelope) error
+}
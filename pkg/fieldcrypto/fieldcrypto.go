@@ -0,0 +1,51 @@
+package fieldcrypto
+
+// Cipher encrypts individual column values, so a database dump or backup
+// does not expose them. associatedData binds a ciphertext to where it is
+// stored, such as the table and row id, so it cannot be copied into another
+// row and decrypted there.
+type Cipher interface {
+	Encrypt(plaintext, associatedData []byte) (string, error)
+	// Decrypt fails for ciphertexts that were tampered with, were bound to
+	// other associated data, or were encrypted under a key no longer in the
+	// key ring.
+	Decrypt(ciphertext string, associatedData []byte) ([]byte, error)
+}
+
+type Config struct {
+	// Keys maps key ids to 32-byte AES-256 keys. New values are encrypted
+	// with PrimaryKeyId; the other keys only decrypt, so a key can be
+	// rotated without rewriting every stored value first.
+	Keys         map[string][]byte
+	PrimaryKeyId string
+}
+
+type Option func(*Config)
+
+// WithKey adds a key to the key ring. The first key added is the primary
+// key unless WithPrimaryKey names another.
+func WithKey(keyId string, key []byte) Option {
+	return func(c *Config) {
+		if c.Keys == nil {
+			c.Keys = map[string][]byte{}
+		}
+		if c.PrimaryKeyId == "" {
+			c.PrimaryKeyId = keyId
+		}
+		c.Keys[keyId] = key
+	}
+}
+
+func WithPrimaryKey(keyId string) Option {
+	return func(c *Config) {
+		c.PrimaryKeyId = keyId
+	}
+}
+
+func ApplyOptions(opts ...Option) *Config {
+	c := &Config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
@@ -0,0 +1,75 @@
+package implementation
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/jt828/go-grpc-template/pkg/fieldcrypto"
+)
+
+type aesGCM struct {
+	aeads     map[string]cipher.AEAD
+	primaryId string
+}
+
+// NewAESGCM encrypts with AES-256-GCM under a random nonce. Ciphertexts are
+// "<key id>:<base64url of nonce and sealed value>", so each names the key
+// that decrypts it.
+func NewAESGCM(opts ...fieldcrypto.Option) (fieldcrypto.Cipher, error) {
+	cfg := fieldcrypto.ApplyOptions(opts...)
+	if _, ok := cfg.Keys[cfg.PrimaryKeyId]; !ok {
+		return nil, fmt.Errorf("primary key %q is not in the key ring", cfg.PrimaryKeyId)
+	}
+
+	c := &aesGCM{aeads: make(map[string]cipher.AEAD, len(cfg.Keys)), primaryId: cfg.PrimaryKeyId}
+	for keyId, key := range cfg.Keys {
+		if keyId == "" || strings.Contains(keyId, ":") {
+			return nil, fmt.Errorf("key id %q must be non-empty and must not contain ':'", keyId)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key %q must be 32 bytes, got %d", keyId, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		if c.aeads[keyId], err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *aesGCM) Encrypt(plaintext, associatedData []byte) (string, error) {
+	aead := c.aeads[c.primaryId]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, associatedData)
+	return c.primaryId + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func (c *aesGCM) Decrypt(ciphertext string, associatedData []byte) ([]byte, error) {
+	keyId, encoded, ok := strings.Cut(ciphertext, ":")
+	if !ok {
+		return nil, fmt.Errorf("ciphertext does not name its key")
+	}
+	aead, ok := c.aeads[keyId]
+	if !ok {
+		return nil, fmt.Errorf("ciphertext key %q is not in the key ring", keyId)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("ciphertext is not base64url: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, associatedData)
+}
@@ -0,0 +1,139 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrEgressDenied means a request, redirect or connection was blocked by
+// the client's EgressPolicy.
+var ErrEgressDenied = errors.New("egress denied")
+
+type Client interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// EgressPolicy restricts where a client may connect, protecting against
+// server-side request forgery when target URLs are user-supplied.
+type EgressPolicy struct {
+	// AllowedSchemes and AllowedPorts list what request and redirect URLs
+	// may use. A URL without a port uses its scheme's default.
+	AllowedSchemes []string
+	AllowedPorts   []int
+	// DeniedPrefixes are checked against the address actually dialled, after
+	// DNS resolution, so a hostname cannot be rebound to a private address.
+	DeniedPrefixes []netip.Prefix
+	// MaxRedirects is the number of redirects followed before giving up.
+	MaxRedirects int
+}
+
+// DefaultEgressPolicy allows HTTPS on port 443 to public addresses only,
+// following at most 3 redirects.
+func DefaultEgressPolicy() EgressPolicy {
+	return EgressPolicy{
+		AllowedSchemes: []string{"https"},
+		AllowedPorts:   []int{443},
+		DeniedPrefixes: NonPublicPrefixes(),
+		MaxRedirects:   3,
+	}
+}
+
+// NonPublicPrefixes lists address ranges that are not reachable on the
+// public internet: loopback, private (RFC 1918, RFC 4193), link-local
+// (including cloud metadata endpoints), shared, multicast and reserved.
+func NonPublicPrefixes() []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, prefix := range []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.0.0.0/24",
+		"192.168.0.0/16",
+		"198.18.0.0/15",
+		"224.0.0.0/4",
+		"240.0.0.0/4",
+		"::/128",
+		"::1/128",
+		"64:ff9b::/96",
+		"fc00::/7",
+		"fe80::/10",
+		"ff00::/8",
+	} {
+		prefixes = append(prefixes, netip.MustParsePrefix(prefix))
+	}
+	return prefixes
+}
+
+// CheckURL reports whether u's scheme and port are allowed.
+func (p EgressPolicy) CheckURL(u *url.URL) error {
+	scheme := strings.ToLower(u.Scheme)
+	if !slices.Contains(p.AllowedSchemes, scheme) {
+		return fmt.Errorf("scheme %q is not allowed: %w", u.Scheme, ErrEgressDenied)
+	}
+	port := u.Port()
+	if port == "" {
+		switch scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		}
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || !slices.Contains(p.AllowedPorts, n) {
+		return fmt.Errorf("port %q is not allowed: %w", port, ErrEgressDenied)
+	}
+	return nil
+}
+
+// CheckAddr reports whether addr may be dialled. IPv4-mapped IPv6
+// addresses are checked as IPv4.
+func (p EgressPolicy) CheckAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, prefix := range p.DeniedPrefixes {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("address %s is in denied range %s: %w", addr, prefix, ErrEgressDenied)
+		}
+	}
+	return nil
+}
+
+type Config struct {
+	Timeout time.Duration
+	Egress  *EgressPolicy
+}
+
+type Option func(*Config)
+
+func WithTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.Timeout = d
+	}
+}
+
+// WithEgressPolicy enforces policy on every request, redirect and
+// connection. Proxies from the environment are ignored, since the policy
+// could only check the proxy's address.
+func WithEgressPolicy(policy EgressPolicy) Option {
+	return func(c *Config) {
+		c.Egress = &policy
+	}
+}
+
+func ApplyOptions(opts ...Option) *Config {
+	c := &Config{Timeout: 10 * time.Second}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
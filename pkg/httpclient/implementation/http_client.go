@@ -0,0 +1,62 @@
+package implementation
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/httpclient"
+)
+
+type httpClient struct {
+	client *http.Client
+	policy *httpclient.EgressPolicy
+}
+
+func NewHTTPClient(opts ...httpclient.Option) httpclient.Client {
+	c := httpclient.ApplyOptions(opts...)
+	if c.Egress == nil {
+		return &http.Client{Timeout: c.Timeout}
+	}
+
+	policy := *c.Egress
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		// Control runs after DNS resolution for each address tried, so the
+		// check applies to the IP actually connected to.
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("dial %s: %w", address, httpclient.ErrEgressDenied)
+			}
+			return policy.CheckAddr(addrPort.Addr())
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &httpClient{
+		policy: &policy,
+		client: &http.Client{
+			Timeout:   c.Timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > policy.MaxRedirects {
+					return fmt.Errorf("stopped after %d redirects: %w", policy.MaxRedirects, httpclient.ErrEgressDenied)
+				}
+				return policy.CheckURL(req.URL)
+			},
+		},
+	}
+}
+
+func (c *httpClient) Do(req *http.Request) (*http.Response, error) {
+	if err := c.policy.CheckURL(req.URL); err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
+}
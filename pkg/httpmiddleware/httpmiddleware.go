@@ -0,0 +1,166 @@
+// Package httpmiddleware is the middleware shared by the server's HTTP
+// surfaces, chained the way gRPC interceptors are.
+package httpmiddleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Middleware wraps an http.Handler, as an interceptor wraps a gRPC handler.
+type Middleware func(http.Handler) http.Handler
+
+// Chain returns h wrapped in middlewares. The first is outermost, so they
+// run in the order given, as with grpc.ChainUnaryInterceptor.
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// DefaultContentSecurityPolicy lets responses load nothing and be framed
+// nowhere, which suits endpoints serving data rather than pages.
+const DefaultContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+
+// SecurityPolicy configures SecurityHeaders.
+type SecurityPolicy struct {
+	// HSTSMaxAge is how long browsers should reach the host over HTTPS only.
+	// Zero sends no Strict-Transport-Security header.
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	// ContentSecurityPolicy defaults to DefaultContentSecurityPolicy.
+	ContentSecurityPolicy string
+}
+
+// SecurityHeaders returns middleware that sets the standard security
+// headers on every response: nosniff, framing denied, no referrer and the
+// policy's Content-Security-Policy. Strict-Transport-Security is only sent
+// over HTTPS, directly or as reported by X-Forwarded-Proto, since browsers
+// ignore it otherwise. Handlers may still override any of them.
+func SecurityHeaders(policy SecurityPolicy) Middleware {
+	csp := policy.ContentSecurityPolicy
+	if csp == "" {
+		csp = DefaultContentSecurityPolicy
+	}
+	var hsts string
+	if policy.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(policy.HSTSMaxAge/time.Second), 10)
+		if policy.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Referrer-Policy", "no-referrer")
+			h.Set("Content-Security-Policy", csp)
+			if hsts != "" && (r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")) {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CORSPolicy configures CORS.
+type CORSPolicy struct {
+	// AllowedOrigins lists the origins browsers may call from, such as
+	// "https://app.example.com", or "*" for any. Empty allows none.
+	AllowedOrigins []string
+	// AllowedMethods and AllowedHeaders list what a cross-origin request may
+	// use, and ExposedHeaders the response headers its script may read.
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	// AllowCredentials lets cross-origin requests carry cookies and HTTP
+	// authentication. It cannot be combined with the "*" origin.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight's answer.
+	MaxAge time.Duration
+}
+
+// CORS returns middleware applying policy to cross-origin requests.
+// Preflights are answered by the middleware itself: 204 with the allowed
+// methods and headers, or 403 when the origin, method or a header is not
+// allowed. Other requests from an allowed origin get the headers letting
+// the calling script read the response; those from any other origin are
+// served without them, so browsers withhold the response.
+func CORS(policy CORSPolicy) Middleware {
+	anyOrigin := slices.Contains(policy.AllowedOrigins, "*")
+	methods := strings.Join(policy.AllowedMethods, ", ")
+	headers := strings.Join(policy.AllowedHeaders, ", ")
+	exposed := strings.Join(policy.ExposedHeaders, ", ")
+	maxAge := strconv.FormatInt(int64(policy.MaxAge/time.Second), 10)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			origin := r.Header.Get("Origin")
+			method := r.Header.Get("Access-Control-Request-Method")
+			preflight := r.Method == http.MethodOptions && method != ""
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+			}
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			allowed := anyOrigin || containsFold(policy.AllowedOrigins, origin)
+			if preflight && (!allowed || !containsFold(policy.AllowedMethods, method) || !allowsHeaders(policy.AllowedHeaders, r.Header.Get("Access-Control-Request-Headers"))) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			if !allowed {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin && !policy.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if policy.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if preflight {
+				h.Set("Access-Control-Allow-Methods", methods)
+				if headers != "" {
+					h.Set("Access-Control-Allow-Headers", headers)
+				}
+				if policy.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", maxAge)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if exposed != "" {
+				h.Set("Access-Control-Expose-Headers", exposed)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allowsHeaders reports whether every header in requested, a preflight's
+// comma-separated Access-Control-Request-Headers, is listed in allowed.
+func allowsHeaders(allowed []string, requested string) bool {
+	for _, header := range strings.Split(requested, ",") {
+		if header = strings.TrimSpace(header); header != "" && !containsFold(allowed, header) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(list []string, s string) bool {
+	return slices.ContainsFunc(list, func(item string) bool { return strings.EqualFold(item, s) })
+}
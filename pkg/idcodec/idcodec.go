@@ -0,0 +1,56 @@
+package idcodec
+
+import "fmt"
+
+// Codec converts internal int64 ids to opaque strings for clients and back.
+type Codec interface {
+	// Encode expects a positive id, as every snowflake id is.
+	Encode(id int64) string
+	// Decode returns an apperror.ErrInvalidArgument error for strings that
+	// Encode could not have produced.
+	Decode(s string) (int64, error)
+}
+
+// Mode selects which id representations the API exposes and accepts.
+type Mode string
+
+const (
+	// ModeInt64 exposes and accepts only the int64 id fields.
+	ModeInt64 Mode = "int64"
+	// ModeDual fills both the int64 and the public id fields and accepts
+	// either, for migrating clients.
+	ModeDual Mode = "dual"
+	// ModeOpaque exposes and accepts only the public id fields.
+	ModeOpaque Mode = "opaque"
+)
+
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(s); mode {
+	case ModeInt64, ModeDual, ModeOpaque:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown id mode %q", s)
+}
+
+type Config struct {
+	Key []byte
+}
+
+type Option func(*Config)
+
+// WithKey scrambles ids with a keyed permutation before encoding so the
+// timestamp and sequence inside a snowflake id cannot be read back. Without
+// a key the encoding only shortens ids.
+func WithKey(key []byte) Option {
+	return func(c *Config) {
+		c.Key = key
+	}
+}
+
+func ApplyOptions(opts ...Option) *Config {
+	c := &Config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
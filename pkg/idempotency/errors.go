@@ -0,0 +1,19 @@
+package idempotency
+
+import "fmt"
+
+// ErrCorruptRecord is returned by Idempotency.Execute when record Id's
+// stored response cannot be decoded into the request's result, e.g. after a
+// deploy changed the result type. Every replay of Id fails the same way
+// until the record is quarantined with RecordRepository.Quarantine.
+type ErrCorruptRecord struct {
+	Id          int64
+	RequestType string
+	Err         error
+}
+
+func (e *ErrCorruptRecord) Error() string {
+	return fmt.Sprintf("idempotency record %d (%s) is corrupt: %v", e.Id, e.RequestType, e.Err)
+}
+
+func (e *ErrCorruptRecord) Unwrap() error { return e.Err }
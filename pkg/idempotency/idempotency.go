@@ -7,10 +7,26 @@ import (
 )
 
 type RecordRepository interface {
+	// Lock serialises concurrent requests sharing the same idempotency id
+	// until the surrounding transaction ends.
+	Lock(ctx context.Context, id int64) error
 	Get(ctx context.Context, id int64) (*Record, error)
 	Insert(ctx context.Context, record *Record) error
+	// Quarantine moves record id out of the way into a quarantine table,
+	// keeping its data for inspection, so the next request with id runs
+	// again instead of replaying it. It reports false when there is no
+	// record id.
+	Quarantine(ctx context.Context, id int64, reason string) (bool, error)
 }
 
 type Idempotency interface {
-	Execute(ctx context.Context, repo RecordRepository, id int64, requestType constant.RequestType, referenceId int64, newResult func() any, fn func() (any, error)) (any, error)
+	// Execute runs fn once per id. A repeated request replays the response
+	// stored by the first, decoded into the non-nil pointer newResult
+	// returns; fn must return a value of that same type. A stored response
+	// that cannot be decoded fails with *ErrCorruptRecord, and one stored
+	// for another requestType fails with apperror.ErrFailedPrecondition and
+	// reason apperror.ReasonIdempotencyConflict. The record refers to the
+	// row *referenceId names once fn has run, so fn may change it, e.g. when
+	// an insert regenerated a colliding id.
+	Execute(ctx context.Context, repo RecordRepository, id int64, requestType constant.RequestType, referenceId *int64, newResult func() any, fn func() (any, error)) (any, error)
 }
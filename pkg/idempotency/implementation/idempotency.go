@@ -3,9 +3,12 @@ package implementation
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/jt828/go-grpc-template/internal/constant"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
 	"github.com/jt828/go-grpc-template/pkg/idempotency"
 )
 
@@ -20,18 +23,33 @@ func (i *idempotencyImpl) Execute(
 	repo idempotency.RecordRepository,
 	id int64,
 	requestType constant.RequestType,
-	referenceId int64,
+	referenceId *int64,
 	newResult func() any,
 	fn func() (any, error),
 ) (any, error) {
+	if err := repo.Lock(ctx, id); err != nil {
+		return nil, err
+	}
+
 	record, err := repo.Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
 	if record != nil {
+		// A key reused for another kind of request must not replay a
+		// response that answers a different question.
+		if record.RequestType != string(requestType) {
+			return nil, apperror.WithReason(
+				apperror.FailedPreconditionf("idempotency key %d was already used for a %s request", id, record.RequestType),
+				apperror.ReasonIdempotencyConflict, map[string]string{"request_type": record.RequestType},
+			)
+		}
 		result := newResult()
-		if err := json.Unmarshal([]byte(record.ResponseData), result); err != nil {
+		if err := checkResultPointer(result); err != nil {
+			return nil, err
+		}
+		if err := record.Decode(result); err != nil {
 			return nil, err
 		}
 		return result, nil
@@ -46,11 +64,16 @@ func (i *idempotencyImpl) Execute(
 	if err != nil {
 		return nil, err
 	}
+	// A result of another type than newResult's would be stored but could
+	// never be replayed, so refuse it before the record is written.
+	if want := newResult(); reflect.TypeOf(result) != reflect.TypeOf(want) {
+		return nil, fmt.Errorf("idempotency: %s returned %T, want %T", requestType, result, want)
+	}
 
 	err = repo.Insert(ctx, &idempotency.Record{
 		Id:           id,
 		RequestType:  string(requestType),
-		ReferenceId:  referenceId,
+		ReferenceId:  *referenceId,
 		ResponseData: string(data),
 		CreatedAt:    time.Now(),
 	})
@@ -60,3 +83,12 @@ func (i *idempotencyImpl) Execute(
 
 	return result, nil
 }
+
+// checkResultPointer reports an error unless result, from newResult, is a
+// non-nil pointer json.Unmarshal can decode into.
+func checkResultPointer(result any) error {
+	if v := reflect.ValueOf(result); v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("idempotency: newResult returned %T, want a non-nil pointer", result)
+	}
+	return nil
+}
@@ -1,6 +1,11 @@
 package idempotency
 
-import "time"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+)
 
 type Record struct {
 	Id           int64
@@ -9,3 +14,26 @@ type Record struct {
 	ResponseData string
 	CreatedAt    time.Time
 }
+
+// Decode unmarshals r's stored response into result, failing with
+// *ErrCorruptRecord when it cannot. A null response, which json.Unmarshal
+// would accept and leave result zero, is corrupt too.
+func (r *Record) Decode(result any) error {
+	data := bytes.TrimSpace([]byte(r.ResponseData))
+	err := errors.New("response is empty")
+	if len(data) > 0 && !bytes.Equal(data, []byte("null")) {
+		err = json.Unmarshal(data, result)
+	}
+	if err != nil {
+		return &ErrCorruptRecord{Id: r.Id, RequestType: r.RequestType, Err: err}
+	}
+	return nil
+}
+
+// QuarantinedRecord is a Record moved aside because its response could not
+// be replayed, with when and why.
+type QuarantinedRecord struct {
+	Record
+	QuarantinedAt time.Time
+	Reason        string
+}
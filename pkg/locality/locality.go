@@ -0,0 +1,116 @@
+// Package locality describes where processes run and lets gRPC clients
+// prefer servers close to them.
+package locality
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// Locality is the region and zone a process runs in, e.g. "eu-west-1" and
+// "eu-west-1a". Either may be empty when unknown.
+type Locality struct {
+	Region string
+	Zone   string
+}
+
+// distance ranks other by how close it is to l: 0 in the same zone, 1 in
+// the same region, 2 anywhere else. An unknown region or zone never
+// matches.
+func (l Locality) distance(other Locality) int {
+	switch {
+	case l.Region == "" || l.Region != other.Region:
+		return 2
+	case l.Zone == "" || l.Zone != other.Zone:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Target is a server address and the locality it runs in.
+type Target struct {
+	Addr     string
+	Locality Locality
+}
+
+// BalancerName is the load balancing policy NewClient selects. It spreads
+// calls round robin over the ready servers closest to the client: those in
+// its zone, else those in its region, else all others.
+const BalancerName = "locality_round_robin"
+
+const scheme = "locality"
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(BalancerName, pickerBuilder{}, base.Config{HealthCheck: true}))
+}
+
+// NewClient connects to targets from a client running in local, sending
+// each call to the closest ready target. Calls fall back to farther targets
+// only while no closer one is ready, so a region outage does not fail
+// calls that another region can serve.
+func NewClient(local Locality, targets []Target, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("locality: no targets")
+	}
+	addresses := make([]resolver.Address, len(targets))
+	for i, target := range targets {
+		addresses[i] = resolver.Address{
+			Addr:               target.Addr,
+			BalancerAttributes: attributes.New(distanceKey{}, local.distance(target.Locality)),
+		}
+	}
+	r := manual.NewBuilderWithScheme(scheme)
+	r.InitialState(resolver.State{Addresses: addresses})
+	opts = append(opts,
+		grpc.WithResolvers(r),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig":[{%q:{}}]}`, BalancerName)),
+	)
+	return grpc.NewClient(scheme+":///targets", opts...)
+}
+
+// distanceKey keys an address's distance from the client in its balancer
+// attributes.
+type distanceKey struct{}
+
+func distanceOf(address resolver.Address) int {
+	distance, _ := address.BalancerAttributes.Value(distanceKey{}).(int)
+	return distance
+}
+
+type pickerBuilder struct{}
+
+func (pickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	closest := -1
+	var subConns []balancer.SubConn
+	for subConn, subConnInfo := range info.ReadySCs {
+		distance := distanceOf(subConnInfo.Address)
+		switch {
+		case closest == -1 || distance < closest:
+			closest, subConns = distance, []balancer.SubConn{subConn}
+		case distance == closest:
+			subConns = append(subConns, subConn)
+		}
+	}
+	return &picker{subConns: subConns}
+}
+
+type picker struct {
+	subConns []balancer.SubConn
+	next     atomic.Uint32
+}
+
+func (p *picker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	n := p.next.Add(1)
+	return balancer.PickResult{SubConn: p.subConns[int(n)%len(p.subConns)]}, nil
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+func (dataEntity *ApiUsageDataEntity) ToDomain() ApiUsage {
+	return ApiUsage(*dataEntity)
+}
+
+type ApiUsageDataEntity struct {
+	UsageDate time.Time `gorm:"column:usage_date"`
+	Caller    string    `gorm:"column:caller"`
+	Method    string    `gorm:"column:method"`
+	Requests  int64     `gorm:"column:requests"`
+	Units     int64     `gorm:"column:units"`
+}
+
+func (dataEntity *ApiUsageDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("api_usage")
+}
+
+// ApiUsage is the billable usage of one method by one caller on one UTC day.
+type ApiUsage struct {
+	UsageDate time.Time
+	Caller    string
+	Method    string
+	Requests  int64
+	Units     int64
+}
+
+type ApiUsageReportDataEntity struct {
+	UsageDate  time.Time `gorm:"column:usage_date"`
+	ReportedAt time.Time `gorm:"column:reported_at"`
+}
+
+func (dataEntity *ApiUsageReportDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("api_usage_reports")
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+func (dataEntity *AuditRecordDataEntity) ToDomain() AuditRecord {
+	return AuditRecord(*dataEntity)
+}
+
+type AuditRecordDataEntity struct {
+	Id        int64     `gorm:"column:id"`
+	Actor     string    `gorm:"column:actor"`
+	Method    string    `gorm:"column:method"`
+	RequestId string    `gorm:"column:request_id"`
+	Request   string    `gorm:"column:request"`
+	Code      string    `gorm:"column:code"`
+	Duration  int64     `gorm:"column:duration_ms"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (dataEntity *AuditRecordDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("audit_records")
+}
+
+// AuditRecord is one audited call: who made it, to which method, with what
+// request and outcome. Request is the redacted request as JSON, empty when
+// the method's audit level is call; Code is the gRPC status code name and
+// Duration the call's latency in milliseconds.
+type AuditRecord struct {
+	Id        int64
+	Actor     string
+	Method    string
+	RequestId string
+	Request   string
+	Code      string
+	Duration  int64
+	CreatedAt time.Time
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// ConfigEntry is one effective configuration value. Redacted is set when
+// secrets were masked out of Value.
+type ConfigEntry struct {
+	Key      string
+	Value    string
+	Redacted bool
+}
+
+// EffectiveConfig is the configuration a server process started with.
+type EffectiveConfig struct {
+	StartedAt time.Time
+	Entries   []ConfigEntry
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// DeadLetterSource identifies the delivery pipeline that gave up on an event.
+type DeadLetterSource string
+
+const (
+	DeadLetterSourceOutbox   DeadLetterSource = "outbox"
+	DeadLetterSourceWebhook  DeadLetterSource = "webhook"
+	DeadLetterSourceConsumer DeadLetterSource = "consumer"
+)
+
+func (dataEntity *DeadLetterDataEntity) ToDomain() DeadLetter {
+	return DeadLetter(*dataEntity)
+}
+
+type DeadLetterDataEntity struct {
+	Id         int64            `gorm:"column:id"`
+	Source     DeadLetterSource `gorm:"column:source"`
+	EventType  string           `gorm:"column:event_type"`
+	Payload    string           `gorm:"column:payload"`
+	LastError  string           `gorm:"column:last_error"`
+	Attempts   int              `gorm:"column:attempts"`
+	CreatedAt  time.Time        `gorm:"column:created_at"`
+	ReplayedAt *time.Time       `gorm:"column:replayed_at"`
+}
+
+func (dataEntity *DeadLetterDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("dead_letters")
+}
+
+type DeadLetter struct {
+	Id         int64
+	Source     DeadLetterSource
+	EventType  string
+	Payload    string
+	LastError  string
+	Attempts   int
+	CreatedAt  time.Time
+	ReplayedAt *time.Time
+}
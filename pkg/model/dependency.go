@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+)
+
+type DependencyState string
+
+const (
+	DependencyStateUnknown DependencyState = "unknown"
+	DependencyStateUp      DependencyState = "up"
+	DependencyStateDown    DependencyState = "down"
+)
+
+type DependencyStatus struct {
+	Name             string
+	State            DependencyState
+	LastProbeLatency time.Duration
+	LastProbeAt      time.Time
+	LastError        string
+	// CircuitBreakerState is nil for dependencies that are not guarded by a breaker.
+	CircuitBreakerState *circuitbreaker.State
+}
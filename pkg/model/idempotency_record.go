@@ -5,6 +5,7 @@ import (
 
 	"github.com/jt828/go-grpc-template/internal/constant"
 	"github.com/jt828/go-grpc-template/pkg/idempotency"
+	"gorm.io/gorm/schema"
 )
 
 func (dataEntity *IdempotencyRecordDataEntity) ToDomain() idempotency.Record {
@@ -25,8 +26,8 @@ type IdempotencyRecordDataEntity struct {
 	CreatedAt    time.Time            `gorm:"column:created_at"`
 }
 
-func (dataEntity *IdempotencyRecordDataEntity) TableName() string {
-	return "main.idempotency_records"
+func (dataEntity *IdempotencyRecordDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("idempotency_records")
 }
 
 type IdempotencyRecord struct {
@@ -36,3 +37,33 @@ type IdempotencyRecord struct {
 	ResponseData string
 	CreatedAt    time.Time
 }
+
+// IdempotencyQuarantineDataEntity is an idempotency record moved aside
+// because its response could not be replayed.
+type IdempotencyQuarantineDataEntity struct {
+	Id            int64                `gorm:"column:id"`
+	RequestType   constant.RequestType `gorm:"column:request_type"`
+	ReferenceId   int64                `gorm:"column:reference_id"`
+	ResponseData  string               `gorm:"column:response_data"`
+	CreatedAt     time.Time            `gorm:"column:created_at"`
+	QuarantinedAt time.Time            `gorm:"column:quarantined_at"`
+	Reason        string               `gorm:"column:reason"`
+}
+
+func (dataEntity *IdempotencyQuarantineDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("idempotency_quarantine")
+}
+
+func (dataEntity *IdempotencyQuarantineDataEntity) ToDomain() idempotency.QuarantinedRecord {
+	return idempotency.QuarantinedRecord{
+		Record: idempotency.Record{
+			Id:           dataEntity.Id,
+			RequestType:  string(dataEntity.RequestType),
+			ReferenceId:  dataEntity.ReferenceId,
+			ResponseData: dataEntity.ResponseData,
+			CreatedAt:    dataEntity.CreatedAt,
+		},
+		QuarantinedAt: dataEntity.QuarantinedAt,
+		Reason:        dataEntity.Reason,
+	}
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+func (dataEntity *InboxMessageDataEntity) ToDomain() InboxMessage {
+	return InboxMessage(*dataEntity)
+}
+
+type InboxMessageDataEntity struct {
+	Handler     string    `gorm:"column:handler"`
+	EventId     int64     `gorm:"column:event_id"`
+	EventType   string    `gorm:"column:event_type"`
+	ProcessedAt time.Time `gorm:"column:processed_at"`
+}
+
+func (dataEntity *InboxMessageDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("inbox_messages")
+}
+
+// InboxMessage records that Handler has applied the event EventId.
+type InboxMessage struct {
+	Handler     string
+	EventId     int64
+	EventType   string
+	ProcessedAt time.Time
+}
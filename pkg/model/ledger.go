@@ -4,8 +4,36 @@ import (
 	"time"
 
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm/schema"
 )
 
+// TransactionType is the kind of a ledger entry. The ledgers table's
+// ledgers_transaction_type_check constraint allows only these values.
+type TransactionType string
+
+const (
+	TransactionTypeDeposit  TransactionType = "deposit"
+	TransactionTypeWithdraw TransactionType = "withdraw"
+	TransactionTypeTransfer TransactionType = "transfer"
+)
+
+// TransactionTypes lists every valid transaction type.
+var TransactionTypes = []TransactionType{
+	TransactionTypeDeposit,
+	TransactionTypeWithdraw,
+	TransactionTypeTransfer,
+}
+
+// IsValid reports whether t is one of TransactionTypes.
+func (t TransactionType) IsValid() bool {
+	for _, valid := range TransactionTypes {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
 func (dataEntity *LedgerDataEntity) ToDomain() Ledger {
 	return Ledger(*dataEntity)
 }
@@ -13,21 +41,25 @@ func (dataEntity *LedgerDataEntity) ToDomain() Ledger {
 type LedgerDataEntity struct {
 	Id              int64           `gorm:"column:id"`
 	UserId          int64           `gorm:"column:user_id"`
-	TransactionType string          `gorm:"column:transaction_type"`
+	TransactionType TransactionType `gorm:"column:transaction_type"`
 	Token           string          `gorm:"column:token"`
 	Amount          decimal.Decimal `gorm:"column:amount"`
 	CreatedAt       time.Time       `gorm:"column:created_at"`
+	CreatedBy       string          `gorm:"column:created_by"`
 }
 
-func (dataEntity *LedgerDataEntity) TableName() string {
-	return "main.ledgers"
+func (dataEntity *LedgerDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("ledgers")
 }
 
 type Ledger struct {
 	Id              int64
 	UserId          int64
-	TransactionType string
+	TransactionType TransactionType
 	Token           string
 	Amount          decimal.Decimal
 	CreatedAt       time.Time
+	// CreatedBy is the actor that wrote the entry, stamped from the request
+	// context on insert; see pkg/audit.
+	CreatedBy string
 }
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+func (dataEntity *LoginFailureDataEntity) ToDomain() LoginFailure {
+	return LoginFailure(*dataEntity)
+}
+
+type LoginFailureDataEntity struct {
+	UserId       int64      `gorm:"column:user_id"`
+	Ip           string     `gorm:"column:ip"`
+	Failures     int        `gorm:"column:failures"`
+	LockedUntil  *time.Time `gorm:"column:locked_until"`
+	LastFailedAt time.Time  `gorm:"column:last_failed_at"`
+}
+
+func (dataEntity *LoginFailureDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("login_failures")
+}
+
+// LoginFailure counts consecutive failed logins to one user from one IP, or
+// from every IP when Ip is "*". LockedUntil is set once the count reaches
+// the lockout threshold.
+type LoginFailure struct {
+	UserId       int64
+	Ip           string
+	Failures     int
+	LockedUntil  *time.Time
+	LastFailedAt time.Time
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+func (dataEntity *PasswordResetTokenDataEntity) ToDomain() PasswordResetToken {
+	return PasswordResetToken(*dataEntity)
+}
+
+type PasswordResetTokenDataEntity struct {
+	Selector     string     `gorm:"column:selector"`
+	VerifierHash string     `gorm:"column:verifier_hash"`
+	UserId       int64      `gorm:"column:user_id"`
+	CreatedAt    time.Time  `gorm:"column:created_at"`
+	ExpiresAt    time.Time  `gorm:"column:expires_at"`
+	UsedAt       *time.Time `gorm:"column:used_at"`
+}
+
+func (dataEntity *PasswordResetTokenDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("password_reset_tokens")
+}
+
+// PasswordResetToken is a password reset link sent to a user. The token is
+// the Selector, which finds the row, followed by a verifier of which only
+// the SHA-256 hash is stored. It resets the password once, until ExpiresAt.
+type PasswordResetToken struct {
+	Selector     string
+	VerifierHash string
+	UserId       int64
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	UsedAt       *time.Time
+}
+
+// Expired reports whether the token has expired at now.
+func (t *PasswordResetToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+type RoleDataEntity struct {
+	Name      string    `gorm:"column:name"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	CreatedBy string    `gorm:"column:created_by"`
+}
+
+func (dataEntity *RoleDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("roles")
+}
+
+type RolePermissionDataEntity struct {
+	Role       string `gorm:"column:role"`
+	Permission string `gorm:"column:permission"`
+}
+
+func (dataEntity *RolePermissionDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("role_permissions")
+}
+
+func (dataEntity *UserRoleDataEntity) ToDomain() UserRole {
+	return UserRole(*dataEntity)
+}
+
+type UserRoleDataEntity struct {
+	UserId    int64     `gorm:"column:user_id"`
+	Role      string    `gorm:"column:role"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	CreatedBy string    `gorm:"column:created_by"`
+}
+
+func (dataEntity *UserRoleDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("user_roles")
+}
+
+// Role is a named set of permissions. Authorization policy entries name a
+// role or a permission, so a method can require either.
+type Role struct {
+	Name        string
+	Permissions []string
+	CreatedAt   time.Time
+	// CreatedBy is the actor that defined the role, stamped from the request
+	// context.
+	CreatedBy string
+}
+
+// UserRole assigns a role to a user, on top of the roles the configuration
+// grants every caller.
+type UserRole struct {
+	UserId    int64
+	Role      string
+	CreatedAt time.Time
+	// CreatedBy is the actor that assigned the role, stamped from the
+	// request context.
+	CreatedBy string
+}
@@ -0,0 +1,17 @@
+package model
+
+import "gorm.io/gorm/schema"
+
+// DefaultSchema is the Postgres schema owning the service's tables when none
+// is configured.
+const DefaultSchema = "main"
+
+// NamingStrategy qualifies every entity table with schemaName. An empty
+// schemaName leaves tables unqualified so they resolve through search_path.
+func NamingStrategy(schemaName string) schema.Namer {
+	prefix := ""
+	if schemaName != "" {
+		prefix = schemaName + "."
+	}
+	return schema.NamingStrategy{TablePrefix: prefix, SingularTable: true}
+}
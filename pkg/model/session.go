@@ -0,0 +1,85 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// SessionEventType is what happened to a session, as recorded in its audit
+// trail.
+type SessionEventType string
+
+const (
+	SessionEventStarted SessionEventType = "started"
+	SessionEventRevoked SessionEventType = "revoked"
+)
+
+func (dataEntity *SessionDataEntity) ToDomain() Session {
+	return Session(*dataEntity)
+}
+
+type SessionDataEntity struct {
+	Id         int64      `gorm:"column:id"`
+	UserId     int64      `gorm:"column:user_id"`
+	UserAgent  string     `gorm:"column:user_agent"`
+	Ip         string     `gorm:"column:ip"`
+	CreatedAt  time.Time  `gorm:"column:created_at"`
+	LastSeenAt time.Time  `gorm:"column:last_seen_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+	Issuer     string     `gorm:"column:issuer"`
+	ExternalId string     `gorm:"column:external_id"`
+}
+
+func (dataEntity *SessionDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("user_sessions")
+}
+
+// Session is a signed-in device. UserAgent and Ip are those it was last seen
+// with. A session is active until RevokedAt is set. A session opened by an
+// OpenID Connect token has the token's issuer and its sid claim as Issuer
+// and ExternalId.
+type Session struct {
+	Id         int64
+	UserId     int64
+	UserAgent  string
+	Ip         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	RevokedAt  *time.Time
+	Issuer     string
+	ExternalId string
+}
+
+func (dataEntity *SessionEventDataEntity) ToDomain() SessionEvent {
+	return SessionEvent(*dataEntity)
+}
+
+type SessionEventDataEntity struct {
+	Id        int64            `gorm:"column:id"`
+	SessionId int64            `gorm:"column:session_id"`
+	UserId    int64            `gorm:"column:user_id"`
+	Event     SessionEventType `gorm:"column:event"`
+	UserAgent string           `gorm:"column:user_agent"`
+	Ip        string           `gorm:"column:ip"`
+	CreatedAt time.Time        `gorm:"column:created_at"`
+	CreatedBy string           `gorm:"column:created_by"`
+}
+
+func (dataEntity *SessionEventDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("user_session_events")
+}
+
+// SessionEvent is the audit record of a session starting or being revoked.
+// UserAgent and Ip are the device that made the change, and CreatedBy its
+// actor, which the GORM actor plugin stamps on insert.
+type SessionEvent struct {
+	Id        int64
+	SessionId int64
+	UserId    int64
+	Event     SessionEventType
+	UserAgent string
+	Ip        string
+	CreatedAt time.Time
+	CreatedBy string
+}
@@ -0,0 +1,38 @@
+package model
+
+// TableSchema describes a table as Postgres reports it: column types use
+// information_schema spelling, e.g. "character varying(255)" or
+// "timestamp with time zone".
+type TableSchema struct {
+	Name    string
+	Columns []ColumnSchema
+	Indexes []string
+}
+
+type ColumnSchema struct {
+	Name     string
+	Type     string
+	Nullable bool
+}
+
+type SchemaDriftKind string
+
+const (
+	SchemaDriftMissingTable      SchemaDriftKind = "missing_table"
+	SchemaDriftMissingColumn     SchemaDriftKind = "missing_column"
+	SchemaDriftUnexpectedColumn  SchemaDriftKind = "unexpected_column"
+	SchemaDriftColumnType        SchemaDriftKind = "column_type"
+	SchemaDriftColumnNullability SchemaDriftKind = "column_nullability"
+	SchemaDriftMissingIndex      SchemaDriftKind = "missing_index"
+	SchemaDriftUnexpectedIndex   SchemaDriftKind = "unexpected_index"
+)
+
+// SchemaDrift is one difference between a store's live schema and the one
+// its migrations produce. Object is the table, "table.column" or index name.
+type SchemaDrift struct {
+	Store    string
+	Object   string
+	Kind     SchemaDriftKind
+	Expected string
+	Actual   string
+}
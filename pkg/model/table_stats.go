@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// TableStats are a table's cumulative access counters from
+// pg_stat_user_tables, since the statistics were last reset.
+type TableStats struct {
+	Table         string
+	SeqScans      int64
+	SeqTuplesRead int64
+	IndexScans    int64
+	LiveTuples    int64
+}
+
+// StatementStats is one normalized statement from pg_stat_statements.
+type StatementStats struct {
+	QueryId   int64
+	Query     string
+	Calls     int64
+	Rows      int64
+	MeanTime  time.Duration
+	TotalTime time.Duration
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+func (dataEntity *TwoFactorDataEntity) ToDomain() TwoFactor {
+	return TwoFactor(*dataEntity)
+}
+
+type TwoFactorDataEntity struct {
+	UserId       int64      `gorm:"column:user_id"`
+	Secret       string     `gorm:"column:secret"`
+	LastUsedStep int64      `gorm:"column:last_used_step"`
+	CreatedAt    time.Time  `gorm:"column:created_at"`
+	EnabledAt    *time.Time `gorm:"column:enabled_at"`
+}
+
+func (dataEntity *TwoFactorDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("user_two_factor")
+}
+
+// TwoFactor is a user's TOTP enrollment. Secret is encrypted with
+// pkg/fieldcrypto. The enrollment is pending until EnabledAt is set by the
+// user confirming a first code. LastUsedStep is the time step of the last
+// accepted code, which may not be used again.
+type TwoFactor struct {
+	UserId       int64
+	Secret       string
+	LastUsedStep int64
+	CreatedAt    time.Time
+	EnabledAt    *time.Time
+}
+
+// Enabled reports whether the enrollment has been confirmed. A nil
+// enrollment is not.
+func (t *TwoFactor) Enabled() bool {
+	return t != nil && t.EnabledAt != nil
+}
+
+type RecoveryCodeDataEntity struct {
+	UserId   int64      `gorm:"column:user_id"`
+	CodeHash string     `gorm:"column:code_hash"`
+	UsedAt   *time.Time `gorm:"column:used_at"`
+}
+
+func (dataEntity *RecoveryCodeDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("user_recovery_codes")
+}
@@ -1,22 +1,40 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// UserStatus is the lifecycle state of a user.
+type UserStatus string
+
+const (
+	UserStatusActive    UserStatus = "active"
+	UserStatusSuspended UserStatus = "suspended"
+	UserStatusDeleted   UserStatus = "deleted"
+)
 
 func (dataEntity *UserDataEntity) ToDomain() User {
 	return User(*dataEntity)
 }
 
 type UserDataEntity struct {
-	Id        int64     `gorm:"column:id"`
-	Email     string    `gorm:"column:email"`
-	Username  string    `gorm:"column:username"`
-	Password  string    `gorm:"column:password"`
-	CreatedAt time.Time `gorm:"column:created_at"`
-	UpdatedAt time.Time `gorm:"column:updated_at"`
+	Id              int64      `gorm:"column:id"`
+	Email           string     `gorm:"column:email"`
+	Username        string     `gorm:"column:username"`
+	Password        string     `gorm:"column:password"`
+	Status          UserStatus `gorm:"column:status"`
+	CreatedAt       time.Time  `gorm:"column:created_at"`
+	UpdatedAt       time.Time  `gorm:"column:updated_at"`
+	CreatedBy       string     `gorm:"column:created_by"`
+	UpdatedBy       string     `gorm:"column:updated_by"`
+	Version         int64      `gorm:"column:version"`
+	EmailVerifiedAt *time.Time `gorm:"column:email_verified_at"`
 }
 
-func (dataEntity *UserDataEntity) TableName() string {
-	return "main.users"
+func (dataEntity *UserDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("users")
 }
 
 type User struct {
@@ -24,6 +42,30 @@ type User struct {
 	Email     string
 	Username  string
 	Password  string
+	Status    UserStatus
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// CreatedBy and UpdatedBy are the actors that created and last updated
+	// the user. They are stamped from the request context on write; see
+	// pkg/audit.
+	CreatedBy string
+	UpdatedBy string
+	// Version starts at 1 and is bumped by every update, so writes can be
+	// made conditional on the version a client read.
+	Version int64
+	// EmailVerifiedAt is when the user proved they own Email, or nil while
+	// they have not. Changing Email clears it.
+	EmailVerifiedAt *time.Time
+}
+
+// IsActive reports whether the user may sign in and transact. Suspended and
+// deleted users may not.
+func (u *User) IsActive() bool {
+	return u.Status == UserStatusActive
+}
+
+// EmailVerified reports whether the user has proved they own their email.
+// RPCs that must only reach verified addresses gate on it.
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
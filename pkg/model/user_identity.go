@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+func (dataEntity *UserIdentityDataEntity) ToDomain() UserIdentity {
+	return UserIdentity(*dataEntity)
+}
+
+type UserIdentityDataEntity struct {
+	Issuer    string    `gorm:"column:issuer"`
+	Subject   string    `gorm:"column:subject"`
+	UserId    int64     `gorm:"column:user_id"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	CreatedBy string    `gorm:"column:created_by"`
+}
+
+func (dataEntity *UserIdentityDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("user_identities")
+}
+
+// UserIdentity links the subject an external OpenID Connect issuer knows a
+// person by to their local user. A subject is linked to at most one user; a
+// user may be linked from several issuers.
+type UserIdentity struct {
+	Issuer    string
+	Subject   string
+	UserId    int64
+	CreatedAt time.Time
+	// CreatedBy is the actor that made the link, stamped from the request
+	// context.
+	CreatedBy string
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+func (dataEntity *UserStatusChangeDataEntity) ToDomain() UserStatusChange {
+	return UserStatusChange(*dataEntity)
+}
+
+type UserStatusChangeDataEntity struct {
+	Id         int64      `gorm:"column:id"`
+	UserId     int64      `gorm:"column:user_id"`
+	FromStatus UserStatus `gorm:"column:from_status"`
+	ToStatus   UserStatus `gorm:"column:to_status"`
+	Reason     string     `gorm:"column:reason"`
+	ChangedAt  time.Time  `gorm:"column:changed_at"`
+}
+
+func (dataEntity *UserStatusChangeDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("user_status_changes")
+}
+
+// UserStatusChange is the audit record of one user status transition.
+type UserStatusChange struct {
+	Id         int64
+	UserId     int64
+	FromStatus UserStatus
+	ToStatus   UserStatus
+	Reason     string
+	ChangedAt  time.Time
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+func (dataEntity *VerificationTokenDataEntity) ToDomain() VerificationToken {
+	return VerificationToken(*dataEntity)
+}
+
+type VerificationTokenDataEntity struct {
+	TokenHash string     `gorm:"column:token_hash"`
+	UserId    int64      `gorm:"column:user_id"`
+	Email     string     `gorm:"column:email"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
+	ExpiresAt time.Time  `gorm:"column:expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+}
+
+func (dataEntity *VerificationTokenDataEntity) TableName(namer schema.Namer) string {
+	return namer.TableName("verification_tokens")
+}
+
+// VerificationToken is an email verification link sent to Email. Only the
+// SHA-256 hash of the token is stored. It verifies Email once, until
+// ExpiresAt, and only while Email is still the user's address.
+type VerificationToken struct {
+	TokenHash string
+	UserId    int64
+	Email     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// Expired reports whether the token has expired at now.
+func (t *VerificationToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
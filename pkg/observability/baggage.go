@@ -0,0 +1,43 @@
+package observability
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// BaggageTenantKey is the baggage member naming the tenant a request is made
+// on behalf of.
+const BaggageTenantKey = "tenant_id"
+
+// ContextWithBaggage returns ctx with the baggage member key set to value,
+// replacing any previous value. Baggage travels with the request to every
+// service it calls, so it must hold nothing secret: callers and
+// intermediaries can read it, and forge it.
+func ContextWithBaggage(ctx context.Context, key, value string) (context.Context, error) {
+	member, err := baggage.NewMemberRaw(key, value)
+	if err != nil {
+		return ctx, err
+	}
+	b, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx, err
+	}
+	return baggage.ContextWithBaggage(ctx, b), nil
+}
+
+// BaggageFromContext returns the value of ctx's baggage member key, or ""
+// when it has none.
+func BaggageFromContext(ctx context.Context, key string) string {
+	return baggage.FromContext(ctx).Member(key).Value()
+}
+
+// ContextWithTenant returns ctx with tenant as its BaggageTenantKey member.
+func ContextWithTenant(ctx context.Context, tenant string) (context.Context, error) {
+	return ContextWithBaggage(ctx, BaggageTenantKey, tenant)
+}
+
+// TenantFromContext returns the tenant named in ctx's baggage, or "".
+func TenantFromContext(ctx context.Context) string {
+	return BaggageFromContext(ctx, BaggageTenantKey)
+}
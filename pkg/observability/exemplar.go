@@ -0,0 +1,58 @@
+package observability
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ExemplarCounter is a Counter that can attach an exemplar to an increment:
+// labels identifying one example of what was counted, such as the trace it
+// happened in, so a spike on a graph links to a trace to look at.
+type ExemplarCounter interface {
+	IncWithExemplar(v float64, exemplar []Label, labels ...Label)
+}
+
+// ExemplarHistogram is a Histogram that can attach an exemplar to an
+// observation.
+type ExemplarHistogram interface {
+	ObserveWithExemplar(v float64, exemplar []Label, labels ...Label)
+}
+
+// TraceExemplar returns the trace_id and span_id of ctx's span as exemplar
+// labels, or nil when ctx has no sampled span: unsampled spans are never
+// exported, so an exemplar naming one would lead nowhere.
+func TraceExemplar(ctx context.Context) []Label {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() || !sc.IsSampled() {
+		return nil
+	}
+	return []Label{
+		{Key: "trace_id", Value: sc.TraceID().String()},
+		{Key: "span_id", Value: sc.SpanID().String()},
+	}
+}
+
+// IncWithTrace increments c by v, attaching ctx's TraceExemplar when c
+// supports exemplars and ctx has a sampled span.
+func IncWithTrace(ctx context.Context, c Counter, v float64, labels ...Label) {
+	if ec, ok := c.(ExemplarCounter); ok {
+		if exemplar := TraceExemplar(ctx); exemplar != nil {
+			ec.IncWithExemplar(v, exemplar, labels...)
+			return
+		}
+	}
+	c.Inc(v, labels...)
+}
+
+// ObserveWithTrace records v in h, attaching ctx's TraceExemplar when h
+// supports exemplars and ctx has a sampled span.
+func ObserveWithTrace(ctx context.Context, h Histogram, v float64, labels ...Label) {
+	if eh, ok := h.(ExemplarHistogram); ok {
+		if exemplar := TraceExemplar(ctx); exemplar != nil {
+			eh.ObserveWithExemplar(v, exemplar, labels...)
+			return
+		}
+	}
+	h.Observe(v, labels...)
+}
@@ -3,30 +3,207 @@ package implementation
 import (
 	"context"
 
+	"github.com/jt828/go-grpc-template/pkg/httpmiddleware"
 	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/observability/noop"
+	"go.opentelemetry.io/otel"
 )
 
-type Config struct {
-	ServiceName string
+const (
+	defaultMetricsAddress = ":9090"
+	defaultOTLPEndpoint   = "localhost:4317"
+)
+
+type builder struct {
+	logConfig         observability.LogConfig
+	logger            observability.Logger
+	meterOpts         []MeterOption
+	meter             observability.Meter
+	tracer            observability.Tracer
+	noTracing         bool
+	traceExport       observability.TraceExportConfig
+	propagators       []observability.Propagator
+	resource          []observability.Label
+	metricsAddress    string
+	metricsMiddleware []httpmiddleware.Middleware
+	otlpEndpoint      string
 }
 
-func NewObservability(cfg Config) (observability.Observability, error) {
-	log, err := NewZapLogger()
-	if err != nil {
-		return nil, err
+// Option configures NewObservability.
+type Option func(*builder)
+
+// WithLogConfig configures the zap logger built when WithLogger is not
+// given.
+func WithLogConfig(cfg observability.LogConfig) Option {
+	return func(b *builder) {
+		b.logConfig = cfg
 	}
+}
 
-	meter := NewPrometheusMeter()
+// WithLogger uses log instead of building a zap logger.
+func WithLogger(log observability.Logger) Option {
+	return func(b *builder) {
+		b.logger = log
+	}
+}
 
-	tracer, shutdown, err := NewOtelTracer(context.Background(), cfg.ServiceName)
-	if err != nil {
-		return nil, err
+// WithMeterOptions configures the Prometheus meter built when WithMeter is
+// not given, e.g. with WithBucketPresets.
+func WithMeterOptions(opts ...MeterOption) Option {
+	return func(b *builder) {
+		b.meterOpts = append(b.meterOpts, opts...)
+	}
+}
+
+// WithMeter uses meter instead of building a Prometheus meter. Start only
+// serves /metrics for meters built by NewPrometheusMeter.
+func WithMeter(meter observability.Meter) Option {
+	return func(b *builder) {
+		b.meter = meter
+	}
+}
+
+// WithMetricsAddress sets where Start serves /metrics, ":9090" by default.
+func WithMetricsAddress(addr string) Option {
+	return func(b *builder) {
+		b.metricsAddress = addr
+	}
+}
+
+// WithMetricsMiddleware serves /metrics and /healthz through middlewares,
+// the first outermost.
+func WithMetricsMiddleware(middlewares ...httpmiddleware.Middleware) Option {
+	return func(b *builder) {
+		b.metricsMiddleware = append(b.metricsMiddleware, middlewares...)
+	}
+}
+
+// WithTracer uses tracer instead of building an OpenTelemetry tracer.
+func WithTracer(tracer observability.Tracer) Option {
+	return func(b *builder) {
+		b.tracer = tracer
+	}
+}
+
+// WithOTLPEndpoint sets the OTLP gRPC collector traces are exported to,
+// "localhost:4317" by default.
+func WithOTLPEndpoint(endpoint string) Option {
+	return func(b *builder) {
+		b.otlpEndpoint = endpoint
+	}
+}
+
+// WithTraceExport bounds the span export queue and batches; zero fields
+// keep observability.DefaultTraceExportConfig's values.
+func WithTraceExport(cfg observability.TraceExportConfig) Option {
+	return func(b *builder) {
+		b.traceExport = cfg
+	}
+}
+
+// WithPropagators sets the formats trace context and baggage are read from
+// incoming requests and written to outgoing ones in,
+// observability.DefaultPropagators by default.
+func WithPropagators(propagators ...observability.Propagator) Option {
+	return func(b *builder) {
+		b.propagators = propagators
+	}
+}
+
+// WithResourceAttributes adds attributes, such as the cloud region, to the
+// resource every exported span is attributed to.
+func WithResourceAttributes(attrs ...observability.Label) Option {
+	return func(b *builder) {
+		b.resource = append(b.resource, attrs...)
+	}
+}
+
+// WithDisabledTracing discards spans instead of exporting them, for tools
+// and environments without a collector.
+func WithDisabledTracing() Option {
+	return func(b *builder) {
+		b.noTracing = true
+	}
+}
+
+// NewObservability builds the logger, meter and tracer for serviceName: by
+// default a zap logger, a Prometheus meter and an OpenTelemetry tracer
+// exporting over OTLP, each of which can be replaced with an option. It also
+// installs the global OpenTelemetry propagator the gRPC stats handlers read
+// and write trace context and baggage with; it does so even with tracing
+// disabled, so baggage and the callers' trace context still reach the
+// services this one calls.
+//
+// Only an invalid logger configuration is an error. The exporter connects
+// in the background, so an unreachable collector neither blocks nor fails
+// startup; should the exporter not even be created, a warning is logged and
+// spans are discarded, so the service runs without traces rather than not
+// at all.
+func NewObservability(serviceName string, opts ...Option) (observability.Observability, error) {
+	b := &builder{
+		propagators:    observability.DefaultPropagators(),
+		metricsAddress: defaultMetricsAddress,
+		otlpEndpoint:   defaultOTLPEndpoint,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	otel.SetTextMapPropagator(NewPropagator(b.propagators))
+
+	log := b.logger
+	if log == nil {
+		var err error
+		if log, err = NewZapLogger(b.logConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	meter := b.meter
+	if meter == nil {
+		meter = NewPrometheusMeter(b.meterOpts...)
+	}
+
+	tracer := b.tracer
+	var traceClose func(context.Context) error
+	switch {
+	case tracer != nil:
+	case b.noTracing:
+		tracer = noop.NewTracer()
+	default:
+		var err error
+		export := withTraceExportDefaults(b.traceExport)
+		if tracer, traceClose, err = NewOtelTracer(context.Background(), serviceName, b.otlpEndpoint, export, meter, log, b.resource...); err != nil {
+			log.Warn("tracing disabled, failed to create the OTLP exporter",
+				observability.Err(err),
+				observability.String("endpoint", b.otlpEndpoint),
+			)
+			tracer = noop.NewTracer()
+		}
 	}
 
 	return &observabilityImplementation{
-		log:        log,
-		meter:      meter,
-		tracer:     tracer,
-		traceClose: shutdown,
+		log:               log,
+		meter:             meter,
+		tracer:            tracer,
+		traceClose:        traceClose,
+		metricsAddr:       b.metricsAddress,
+		metricsMiddleware: b.metricsMiddleware,
 	}, nil
 }
+
+func withTraceExportDefaults(cfg observability.TraceExportConfig) observability.TraceExportConfig {
+	defaults := observability.DefaultTraceExportConfig()
+	if cfg.MaxQueueSize == 0 {
+		cfg.MaxQueueSize = defaults.MaxQueueSize
+	}
+	if cfg.MaxBatchSize == 0 {
+		cfg.MaxBatchSize = defaults.MaxBatchSize
+	}
+	if cfg.BatchTimeout == 0 {
+		cfg.BatchTimeout = defaults.BatchTimeout
+	}
+	if cfg.ExportTimeout == 0 {
+		cfg.ExportTimeout = defaults.ExportTimeout
+	}
+	return cfg
+}
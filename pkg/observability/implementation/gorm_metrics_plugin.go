@@ -20,7 +20,7 @@ func NewGormMetricsPlugin(meter observability.Meter) *GormMetricsPlugin {
 	return &GormMetricsPlugin{
 		queryLatency: meter.Histogram("gorm_query_duration_seconds", observability.MetricOpt{
 			Help:      "Duration of GORM queries in seconds",
-			Buckets:   []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+			Preset:    observability.BucketsDB,
 			LabelKeys: []string{"operation"},
 		}),
 		queryTotal: meter.Counter("gorm_query_total", observability.MetricOpt{
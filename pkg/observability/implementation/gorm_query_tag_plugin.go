@@ -0,0 +1,108 @@
+package implementation
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"strings"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"gorm.io/gorm"
+)
+
+// GormQueryTagPlugin appends a sqlcommenter-style comment
+// (/*application='..',request_id='..',rpc='..'*/) to every statement, built
+// from the QueryTags carried by the statement context.
+type GormQueryTagPlugin struct {
+	application string
+}
+
+func NewGormQueryTagPlugin(application string) *GormQueryTagPlugin {
+	return &GormQueryTagPlugin{application: application}
+}
+
+func (p *GormQueryTagPlugin) Name() string {
+	return "query_tag"
+}
+
+func (p *GormQueryTagPlugin) Initialize(db *gorm.DB) error {
+	db.Callback().Create().Before("gorm:create").After("gorm:begin_transaction").Register("query_tag:before_create", p.before)
+	db.Callback().Create().After("gorm:create").Before("gorm:commit_or_rollback_transaction").Register("query_tag:after_create", p.after)
+
+	db.Callback().Query().Before("gorm:query").Register("query_tag:before_query", p.before)
+	db.Callback().Query().After("gorm:query").Register("query_tag:after_query", p.after)
+
+	db.Callback().Update().Before("gorm:update").After("gorm:begin_transaction").Register("query_tag:before_update", p.before)
+	db.Callback().Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").Register("query_tag:after_update", p.after)
+
+	db.Callback().Delete().Before("gorm:delete").After("gorm:begin_transaction").Register("query_tag:before_delete", p.before)
+	db.Callback().Delete().After("gorm:delete").Before("gorm:commit_or_rollback_transaction").Register("query_tag:after_delete", p.after)
+
+	db.Callback().Row().Before("gorm:row").Register("query_tag:before_row", p.before)
+	db.Callback().Row().After("gorm:row").Register("query_tag:after_row", p.after)
+
+	db.Callback().Raw().Before("gorm:raw").Register("query_tag:before_raw", p.before)
+	db.Callback().Raw().After("gorm:raw").Register("query_tag:after_raw", p.after)
+
+	return nil
+}
+
+// before swaps the statement's connection pool for one that tags outgoing SQL.
+// after restores the original pool so transaction commit/rollback callbacks
+// still see the concrete *sql.Tx.
+func (p *GormQueryTagPlugin) before(db *gorm.DB) {
+	comment := p.comment(observability.QueryTagsFromContext(db.Statement.Context))
+	if comment == "" {
+		return
+	}
+	db.Statement.ConnPool = &taggedConnPool{ConnPool: db.Statement.ConnPool, comment: comment}
+}
+
+func (p *GormQueryTagPlugin) after(db *gorm.DB) {
+	if pool, ok := db.Statement.ConnPool.(*taggedConnPool); ok {
+		db.Statement.ConnPool = pool.ConnPool
+	}
+}
+
+func (p *GormQueryTagPlugin) comment(tags observability.QueryTags) string {
+	// keys are kept in lexical order as required by the sqlcommenter spec
+	pairs := make([]string, 0, 3)
+	if p.application != "" {
+		pairs = append(pairs, "application='"+url.QueryEscape(p.application)+"'")
+	}
+	if tags.RequestId != "" {
+		pairs = append(pairs, "request_id='"+url.QueryEscape(tags.RequestId)+"'")
+	}
+	if tags.Method != "" {
+		pairs = append(pairs, "rpc='"+url.QueryEscape(tags.Method)+"'")
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "/*" + strings.Join(pairs, ",") + "*/"
+}
+
+type taggedConnPool struct {
+	gorm.ConnPool
+	comment string
+}
+
+func (c *taggedConnPool) tag(query string) string {
+	return query + " " + c.comment
+}
+
+func (c *taggedConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return c.ConnPool.PrepareContext(ctx, c.tag(query))
+}
+
+func (c *taggedConnPool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return c.ConnPool.ExecContext(ctx, c.tag(query), args...)
+}
+
+func (c *taggedConnPool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return c.ConnPool.QueryContext(ctx, c.tag(query), args...)
+}
+
+func (c *taggedConnPool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return c.ConnPool.QueryRowContext(ctx, c.tag(query), args...)
+}
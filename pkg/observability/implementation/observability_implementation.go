@@ -2,8 +2,9 @@ package implementation
 
 import (
 	"context"
-	"net/http"
+	"fmt"
 
+	"github.com/jt828/go-grpc-template/pkg/httpmiddleware"
 	"github.com/jt828/go-grpc-template/pkg/observability"
 )
 
@@ -12,10 +13,18 @@ type observabilityImplementation struct {
 	meter  observability.Meter
 	tracer observability.Tracer
 
-	metricsServer *http.Server
-	traceClose    func(context.Context) error
+	metricsAddr       string
+	metricsMiddleware []httpmiddleware.Middleware
+	metricsServer     *MetricsServer
+	traceClose        func(context.Context) error
 }
 
+func (o *observabilityImplementation) Check(ctx context.Context) error {
+	if o.metricsServer == nil {
+		return nil
+	}
+	return o.metricsServer.Check(ctx)
+}
 func (o *observabilityImplementation) Close(ctx context.Context) error {
 	var err error
 	if o.metricsServer != nil {
@@ -31,9 +40,15 @@ func (o *observabilityImplementation) Close(ctx context.Context) error {
 func (o *observabilityImplementation) Logger() observability.Logger { return o.log }
 func (o *observabilityImplementation) Meter() observability.Meter   { return o.meter }
 func (o *observabilityImplementation) Start(ctx context.Context) error {
-	if pm, ok := o.meter.(*prometheusMeter); ok {
-		o.metricsServer = StartMetricsServer(":9090", pm.Registry())
+	pm, ok := o.meter.(*prometheusMeter)
+	if !ok {
+		return nil
+	}
+	srv, err := StartMetricsServer(o.metricsAddr, pm.Registry(), o.metricsMiddleware...)
+	if err != nil {
+		return fmt.Errorf("metrics server: %w", err)
 	}
+	o.metricsServer = srv
 	return nil
 }
 func (o *observabilityImplementation) Tracer() observability.Tracer { return o.tracer }
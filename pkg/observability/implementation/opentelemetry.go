@@ -2,11 +2,13 @@ package implementation
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/jt828/go-grpc-template/pkg/observability"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
@@ -22,8 +24,37 @@ type otelSpan struct {
 	span trace.Span
 }
 
-func (s otelSpan) End()                  { s.span.End() }
-func (s otelSpan) RecordError(err error) { s.span.RecordError(err) }
+func (s otelSpan) End() { s.span.End() }
+
+func (s otelSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s otelSpan) SetAttributes(fields ...observability.Field) {
+	s.span.SetAttributes(toAttributes(fields)...)
+}
+
+func toAttributes(fields []observability.Field) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(fields))
+	for _, f := range fields {
+		switch v := f.Value.(type) {
+		case string:
+			out = append(out, attribute.String(f.Key, v))
+		case int:
+			out = append(out, attribute.Int(f.Key, v))
+		case int64:
+			out = append(out, attribute.Int64(f.Key, v))
+		case bool:
+			out = append(out, attribute.Bool(f.Key, v))
+		case float64:
+			out = append(out, attribute.Float64(f.Key, v))
+		default:
+			out = append(out, attribute.String(f.Key, fmt.Sprint(v)))
+		}
+	}
+	return out
+}
 
 func (t otelTracer) Start(
 	ctx context.Context,
@@ -33,36 +64,70 @@ func (t otelTracer) Start(
 	return ctx, otelSpan{span}
 }
 
+// NewOtelTracer returns a tracer exporting spans to the OTLP gRPC collector
+// at endpoint, batched and bounded by export, and the function that flushes
+// and stops it. Retryable export errors are retried with backoff within
+// export.ExportTimeout. Spans dropped on the way are counted by
+// trace_spans_dropped_total on meter, and export failures and recoveries are
+// logged to log. Spans are attributed to a resource naming serviceName and
+// carrying attrs.
 func NewOtelTracer(
 	ctx context.Context,
 	serviceName string,
+	endpoint string,
+	export observability.TraceExportConfig,
+	meter observability.Meter,
+	log observability.Logger,
+	attrs ...observability.Label,
 ) (observability.Tracer, func(ctx context.Context) error, error) {
 	exp, err := otlptracegrpc.New(
 		ctx,
-		otlptracegrpc.WithEndpoint("localhost:4317"),
+		otlptracegrpc.WithEndpoint(endpoint),
 		otlptracegrpc.WithInsecure(),
+		otlptracegrpc.WithTimeout(export.ExportTimeout),
+		otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Second,
+			MaxInterval:     export.ExportTimeout / 2,
+			MaxElapsedTime:  export.ExportTimeout,
+		}),
 	)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	res, err := resource.New(
-		ctx,
-		resource.WithAttributes(
-			semconv.ServiceName(serviceName),
-			attribute.String("service.version", "0.0.1"),
-		),
-	)
+	resourceAttrs := []attribute.KeyValue{
+		semconv.ServiceName(serviceName),
+		attribute.String("service.version", "0.0.1"),
+	}
+	for _, attr := range attrs {
+		resourceAttrs = append(resourceAttrs, attribute.String(attr.Key, attr.Value))
+	}
+	res, err := resource.New(ctx, resource.WithAttributes(resourceAttrs...))
 	if err != nil {
 		return nil, nil, err
 	}
 
+	metrics := newTraceExportMetrics(meter)
+	batcher := sdktrace.NewBatchSpanProcessor(
+		&instrumentedExporter{SpanExporter: exp, metrics: metrics, log: log},
+		sdktrace.WithMaxQueueSize(export.MaxQueueSize),
+		sdktrace.WithMaxExportBatchSize(export.MaxBatchSize),
+		sdktrace.WithBatchTimeout(export.BatchTimeout),
+		sdktrace.WithExportTimeout(export.ExportTimeout),
+	)
 	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exp),
+		sdktrace.WithSpanProcessor(&boundedSpanProcessor{SpanProcessor: batcher, limit: int64(export.MaxQueueSize), metrics: metrics}),
 		sdktrace.WithResource(res),
 	)
 
 	otel.SetTracerProvider(tp)
+	// The SDK reports every failed export here, which would otherwise print
+	// to stderr once per batch throughout an outage; instrumentedExporter
+	// already logs when exports start failing and when they recover.
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.Debug("opentelemetry error", observability.Err(err))
+	}))
 
 	return otelTracer{tracer: otel.Tracer(serviceName)},
 		func(ctx context.Context) error {
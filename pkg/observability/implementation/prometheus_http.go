@@ -1,25 +1,96 @@
 package implementation
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"sync"
 
+	"github.com/jt828/go-grpc-template/pkg/httpmiddleware"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// MetricsServer is the HTTP server StartMetricsServer starts. It remembers
+// why it stopped serving, so Check can tell a server that died from one
+// still running.
+type MetricsServer struct {
+	*http.Server
+	addr string
+
+	mu      sync.Mutex
+	stopped bool
+	err     error
+}
+
+// StartMetricsServer serves reg on /metrics, and a liveness check on
+// /healthz, at addr. Scrapers that ask for OpenMetrics get it, with the
+// trace exemplars recorded by IncWithTrace and ObserveWithTrace. It returns
+// once the address is bound, so a port already in use is reported rather
+// than lost. Both endpoints are served through middlewares, the first
+// outermost.
 func StartMetricsServer(
 	addr string,
 	reg *prometheus.Registry,
-) *http.Server {
+	middlewares ...httpmiddleware.Middleware,
+) (*MetricsServer, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok\n")
+	})
 
-	srv := &http.Server{
-		Addr:    addr,
-		Handler: mux,
+	srv := &MetricsServer{
+		Server: &http.Server{
+			Addr:    addr,
+			Handler: httpmiddleware.Chain(mux, middlewares...),
+		},
+		addr: lis.Addr().String(),
 	}
 
-	go func() { _ = srv.ListenAndServe() }()
+	go func() {
+		err := srv.Serve(lis)
+		srv.mu.Lock()
+		srv.stopped, srv.err = true, err
+		srv.mu.Unlock()
+	}()
+
+	return srv, nil
+}
 
-	return srv
+// ListenAddr returns the address the server is bound to, with the port
+// chosen when addr asked for any.
+func (s *MetricsServer) ListenAddr() string { return s.addr }
+
+// Check reports whether the server is still serving: an error if it has
+// stopped, or if /healthz does not answer before ctx ends, as when its
+// handlers are deadlocked or its connections exhausted.
+func (s *MetricsServer) Check(ctx context.Context) error {
+	s.mu.Lock()
+	stopped, stopErr := s.stopped, s.err
+	s.mu.Unlock()
+	if stopped {
+		return fmt.Errorf("metrics server stopped: %w", stopErr)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+s.addr+"/healthz", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("metrics server: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("metrics server: /healthz returned %s", resp.Status)
+	}
+	return nil
 }
@@ -1,6 +1,7 @@
 package implementation
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/jt828/go-grpc-template/pkg/observability"
@@ -8,20 +9,75 @@ import (
 )
 
 type prometheusMeter struct {
-	registry    *prometheus.Registry
+	registry *prometheus.Registry
+	// registerer registers into registry, prefixing names with namespace
+	// and subsystem and adding constLabels.
+	registerer  prometheus.Registerer
+	namespace   string
+	subsystem   string
 	constLabels []observability.Label
+	presets     map[observability.BucketPreset][]float64
 }
 
-func NewPrometheusMeter() observability.Meter {
-	return &prometheusMeter{
+type MeterOption func(*prometheusMeter)
+
+// WithBucketPresets overrides the buckets of the presets present in presets.
+// The others keep observability.DefaultBucketPresets.
+func WithBucketPresets(presets map[observability.BucketPreset][]float64) MeterOption {
+	return func(m *prometheusMeter) {
+		for preset, buckets := range presets {
+			m.presets[preset] = buckets
+		}
+	}
+}
+
+// WithNamespace prefixes every metric name with namespace and subsystem,
+// joined by underscores, as in <namespace>_<subsystem>_grpc_server_errors_total.
+// Either may be empty.
+func WithNamespace(namespace, subsystem string) MeterOption {
+	return func(m *prometheusMeter) {
+		m.namespace, m.subsystem = namespace, subsystem
+	}
+}
+
+// WithConstLabels adds labels to every metric, such as the service, version
+// and environment, so series from several services scraped into one
+// Prometheus can be told apart.
+func WithConstLabels(labels ...observability.Label) MeterOption {
+	return func(m *prometheusMeter) {
+		m.constLabels = append(m.constLabels, labels...)
+	}
+}
+
+func NewPrometheusMeter(opts ...MeterOption) observability.Meter {
+	m := &prometheusMeter{
 		registry: prometheus.NewRegistry(),
+		presets:  observability.DefaultBucketPresets(),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.registerer = m.registry
+	// Each wrapper prefixes before handing on to the one it wraps, so the
+	// namespace, added last, is wrapped first.
+	if m.namespace != "" {
+		m.registerer = prometheus.WrapRegistererWithPrefix(m.namespace+"_", m.registerer)
+	}
+	if m.subsystem != "" {
+		m.registerer = prometheus.WrapRegistererWithPrefix(m.subsystem+"_", m.registerer)
 	}
+	if len(m.constLabels) > 0 {
+		m.registerer = prometheus.WrapRegistererWith(toPromConstLabels(m.constLabels), m.registerer)
+	}
+	return m
 }
 
 func (m *prometheusMeter) Registry() *prometheus.Registry {
 	return m.registry
 }
 
+// PromRegistry returns the registry m's metrics are gathered from, or nil
+// when m is not a Prometheus meter.
 func PromRegistry(m observability.Meter) *prometheus.Registry {
 	if pm, ok := m.(*prometheusMeter); ok {
 		return pm.Registry()
@@ -29,6 +85,16 @@ func PromRegistry(m observability.Meter) *prometheus.Registry {
 	return nil
 }
 
+// PromRegisterer returns a registerer applying m's namespace and const
+// labels to the collectors registered with it, for metrics that do not go
+// through the Meter, or nil when m is not a Prometheus meter.
+func PromRegisterer(m observability.Meter) prometheus.Registerer {
+	if pm, ok := m.(*prometheusMeter); ok {
+		return pm.registerer
+	}
+	return nil
+}
+
 // -------------------- Counter --------------------
 
 type promCounter struct {
@@ -48,16 +114,25 @@ func (m *prometheusMeter) Counter(name string, opts ...observability.MetricOpt)
 		labelKeys,
 	)
 
-	m.registry.MustRegister(vec)
+	m.registerer.MustRegister(vec)
 	return &promCounter{vec: vec}
 }
 
 func (c *promCounter) Inc(v float64, labels ...observability.Label) {
+	c.counter(labels).Add(v)
+}
+
+// IncWithExemplar implements observability.ExemplarCounter. Exemplars are
+// only exposed in the OpenMetrics format.
+func (c *promCounter) IncWithExemplar(v float64, exemplar []observability.Label, labels ...observability.Label) {
+	c.counter(labels).(prometheus.ExemplarAdder).AddWithExemplar(v, toPromLabelsMap(exemplar))
+}
+
+func (c *promCounter) counter(labels []observability.Label) prometheus.Counter {
 	if len(labels) == 0 {
-		c.vec.WithLabelValues().Add(v)
-		return
+		return c.vec.WithLabelValues()
 	}
-	c.vec.With(toPromLabelsMap(labels)).Add(v)
+	return c.vec.With(toPromLabelsMap(labels))
 }
 
 // -------------------- Histogram --------------------
@@ -74,22 +149,31 @@ func (m *prometheusMeter) Histogram(name string, opts ...observability.MetricOpt
 		prometheus.HistogramOpts{
 			Name:        name,
 			Help:        opt.Help,
-			Buckets:     opt.Buckets,
+			Buckets:     m.buckets(name, opt),
 			ConstLabels: toPromConstLabels(opt.ConstLabels),
 		},
 		labelKeys,
 	)
 
-	m.registry.MustRegister(vec)
+	m.registerer.MustRegister(vec)
 	return &promHistogram{vec: vec}
 }
 
 func (h *promHistogram) Observe(v float64, labels ...observability.Label) {
+	h.observer(labels).Observe(v)
+}
+
+// ObserveWithExemplar implements observability.ExemplarHistogram.
+// Exemplars are only exposed in the OpenMetrics format.
+func (h *promHistogram) ObserveWithExemplar(v float64, exemplar []observability.Label, labels ...observability.Label) {
+	h.observer(labels).(prometheus.ExemplarObserver).ObserveWithExemplar(v, toPromLabelsMap(exemplar))
+}
+
+func (h *promHistogram) observer(labels []observability.Label) prometheus.Observer {
 	if len(labels) == 0 {
-		h.vec.WithLabelValues().Observe(v)
-		return
+		return h.vec.WithLabelValues()
 	}
-	h.vec.With(toPromLabelsMap(labels)).Observe(v)
+	return h.vec.With(toPromLabelsMap(labels))
 }
 
 // -------------------- Gauge --------------------
@@ -100,7 +184,7 @@ type promGauge struct {
 
 func (m *prometheusMeter) Gauge(name string, opts ...observability.MetricOpt) observability.Gauge {
 	opt := firstOpt(opts)
-	labelKeys := getLabelKeys(opt.ConstLabels)
+	labelKeys := opt.LabelKeys
 
 	vec := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -111,7 +195,7 @@ func (m *prometheusMeter) Gauge(name string, opts ...observability.MetricOpt) ob
 		labelKeys,
 	)
 
-	m.registry.MustRegister(vec)
+	m.registerer.MustRegister(vec)
 	return &promGauge{vec: vec}
 }
 
@@ -134,42 +218,70 @@ func (g *promGauge) Add(v float64, labels ...observability.Label) {
 // -------------------- Timer --------------------
 
 type promTimer struct {
-	histogram   *prometheus.HistogramVec
-	constLabels []observability.Label
+	histogram *prometheus.HistogramVec
+	scale     float64
 }
 
+// Timer records durations in opt.Unit, seconds unless set to
+// observability.UnitMilliseconds.
 func (m *prometheusMeter) Timer(name string, opts ...observability.MetricOpt) observability.Timer {
 	opt := firstOpt(opts)
-	labelKeys := getLabelKeys(opt.ConstLabels)
+	labelKeys := opt.LabelKeys
 
 	vec := prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:        name,
 			Help:        opt.Help,
-			Buckets:     opt.Buckets,
+			Buckets:     m.buckets(name, opt),
 			ConstLabels: toPromConstLabels(opt.ConstLabels),
 		},
 		labelKeys,
 	)
 
-	m.registry.MustRegister(vec)
+	m.registerer.MustRegister(vec)
 
 	return &promTimer{
-		histogram:   vec,
-		constLabels: opt.ConstLabels,
+		histogram: vec,
+		scale:     mustUnitScale(name, opt.Unit),
 	}
 }
 
 func (t *promTimer) Start(labels ...observability.Label) func() {
 	start := time.Now()
 	return func() {
-		merged := mergeLabels(t.constLabels, labels)
-		t.histogram.With(merged).Observe(time.Since(start).Seconds())
+		t.histogram.With(toPromLabelsMap(labels)).Observe(time.Since(start).Seconds() * t.scale)
 	}
 }
 
 // -------------------- Helpers --------------------
 
+// buckets resolves a histogram's buckets: explicit Buckets win, then the
+// Preset scaled to the Unit, then Prometheus' DefBuckets. Misconfiguration
+// panics, like registering a metric twice does.
+func (m *prometheusMeter) buckets(name string, opt observability.MetricOpt) []float64 {
+	scale := mustUnitScale(name, opt.Unit)
+	if len(opt.Buckets) > 0 || opt.Preset == "" {
+		return opt.Buckets
+	}
+	preset, ok := m.presets[opt.Preset]
+	if !ok {
+		panic(fmt.Sprintf("metric %s: unknown bucket preset %q", name, opt.Preset))
+	}
+	buckets := make([]float64, len(preset))
+	for i, b := range preset {
+		buckets[i] = b * scale
+	}
+	return buckets
+}
+
+func mustUnitScale(name, unit string) float64 {
+	scale, err := observability.UnitScale(unit)
+	if err != nil {
+		panic(fmt.Sprintf("metric %s: %v", name, err))
+	}
+	return scale
+}
+
 func firstOpt(opts []observability.MetricOpt) observability.MetricOpt {
 	if len(opts) == 0 {
 		return observability.MetricOpt{}
@@ -177,14 +289,6 @@ func firstOpt(opts []observability.MetricOpt) observability.MetricOpt {
 	return opts[0]
 }
 
-func getLabelKeys(labels []observability.Label) []string {
-	keys := make([]string, len(labels))
-	for i, l := range labels {
-		keys[i] = l.Key
-	}
-	return keys
-}
-
 func toPromLabelsMap(labels []observability.Label) prometheus.Labels {
 	m := make(prometheus.Labels, len(labels))
 	for _, l := range labels {
@@ -196,14 +300,3 @@ func toPromLabelsMap(labels []observability.Label) prometheus.Labels {
 func toPromConstLabels(labels []observability.Label) prometheus.Labels {
 	return toPromLabelsMap(labels)
 }
-
-func mergeLabels(constLabels, dynamicLabels []observability.Label) prometheus.Labels {
-	m := make(prometheus.Labels, len(constLabels)+len(dynamicLabels))
-	for _, l := range constLabels {
-		m[l.Key] = l.Value
-	}
-	for _, l := range dynamicLabels {
-		m[l.Key] = l.Value
-	}
-	return m
-}
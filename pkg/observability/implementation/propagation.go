@@ -0,0 +1,112 @@
+package implementation
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewPropagator returns a propagator injecting every one of names and
+// extracting them in order, so where a request carries several formats the
+// last listed wins.
+func NewPropagator(names []observability.Propagator) propagation.TextMapPropagator {
+	propagators := make([]propagation.TextMapPropagator, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case observability.PropagatorTraceContext:
+			propagators = append(propagators, propagation.TraceContext{})
+		case observability.PropagatorBaggage:
+			propagators = append(propagators, propagation.Baggage{})
+		case observability.PropagatorB3:
+			propagators = append(propagators, b3Propagator{single: true})
+		case observability.PropagatorB3Multi:
+			propagators = append(propagators, b3Propagator{})
+		}
+	}
+	return propagation.NewCompositeTextMapPropagator(propagators...)
+}
+
+// Zipkin B3 headers, lower case as gRPC metadata keys are.
+const (
+	b3Header        = "b3"
+	b3TraceIdHeader = "x-b3-traceid"
+	b3SpanIdHeader  = "x-b3-spanid"
+	b3SampledHeader = "x-b3-sampled"
+	b3FlagsHeader   = "x-b3-flags"
+)
+
+// b3Propagator carries trace context in Zipkin's B3 headers: the single b3
+// header when single is set, else the X-B3-* headers. It extracts either
+// form, so a service can switch without breaking its callers. Only 128-bit
+// and 64-bit trace ids are understood, and debug sampling is treated as
+// sampled.
+type b3Propagator struct {
+	single bool
+}
+
+func (p b3Propagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	sampled := "0"
+	if sc.IsSampled() {
+		sampled = "1"
+	}
+	if p.single {
+		carrier.Set(b3Header, sc.TraceID().String()+"-"+sc.SpanID().String()+"-"+sampled)
+		return
+	}
+	carrier.Set(b3TraceIdHeader, sc.TraceID().String())
+	carrier.Set(b3SpanIdHeader, sc.SpanID().String())
+	carrier.Set(b3SampledHeader, sampled)
+}
+
+func (p b3Propagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	var traceId, spanId, sampled string
+	if single := carrier.Get(b3Header); single != "" {
+		parts := strings.Split(single, "-")
+		if len(parts) < 2 {
+			// A bare sampling decision ("0", "1" or "d") carries no context.
+			return ctx
+		}
+		traceId, spanId = parts[0], parts[1]
+		if len(parts) > 2 {
+			sampled = parts[2]
+		}
+	} else {
+		traceId, spanId = carrier.Get(b3TraceIdHeader), carrier.Get(b3SpanIdHeader)
+		sampled = carrier.Get(b3SampledHeader)
+		if carrier.Get(b3FlagsHeader) == "1" {
+			sampled = "d"
+		}
+	}
+
+	if len(traceId) == 16 {
+		traceId = strings.Repeat("0", 16) + traceId
+	}
+	tid, err := trace.TraceIDFromHex(traceId)
+	if err != nil {
+		return ctx
+	}
+	sid, err := trace.SpanIDFromHex(spanId)
+	if err != nil {
+		return ctx
+	}
+	cfg := trace.SpanContextConfig{TraceID: tid, SpanID: sid, Remote: true}
+	switch sampled {
+	case "1", "true", "d":
+		cfg.TraceFlags = trace.FlagsSampled
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(cfg))
+}
+
+func (p b3Propagator) Fields() []string {
+	if p.single {
+		return []string{b3Header}
+	}
+	return []string{b3TraceIdHeader, b3SpanIdHeader, b3SampledHeader}
+}
@@ -0,0 +1,95 @@
+package implementation
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Reasons spans are dropped, the values of trace_spans_dropped_total's reason
+// label.
+const (
+	dropReasonQueueFull    = "queue_full"
+	dropReasonExportFailed = "export_failed"
+)
+
+// traceExportMetrics counts spans through the export pipeline. pending is
+// the number of spans accepted but not yet exported or dropped, shared by
+// boundedSpanProcessor, which admits spans, and instrumentedExporter, which
+// sees them leave.
+type traceExportMetrics struct {
+	pending  atomic.Int64
+	queued   observability.Gauge
+	exported observability.Counter
+	dropped  observability.Counter
+}
+
+func newTraceExportMetrics(meter observability.Meter) *traceExportMetrics {
+	return &traceExportMetrics{
+		queued: meter.Gauge("trace_spans_queued", observability.MetricOpt{
+			Help: "Number of finished spans waiting to be exported",
+		}),
+		exported: meter.Counter("trace_spans_exported_total", observability.MetricOpt{
+			Help: "Total number of spans exported",
+		}),
+		dropped: meter.Counter("trace_spans_dropped_total", observability.MetricOpt{
+			Help:      "Total number of spans dropped, because the export queue was full or an export failed",
+			LabelKeys: []string{"reason"},
+		}),
+	}
+}
+
+// boundedSpanProcessor admits at most limit pending spans into the batch
+// processor it wraps and drops the rest. The batch processor drops spans on
+// a full queue too, but without saying so; since everything it holds is
+// pending, capping pending at its queue size means it never has to, and
+// every dropped span is counted here.
+type boundedSpanProcessor struct {
+	sdktrace.SpanProcessor
+	limit   int64
+	metrics *traceExportMetrics
+}
+
+func (p *boundedSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if !s.SpanContext().IsSampled() {
+		return
+	}
+	if p.metrics.pending.Add(1) > p.limit {
+		p.metrics.pending.Add(-1)
+		p.metrics.dropped.Inc(1, observability.Label{Key: "reason", Value: dropReasonQueueFull})
+		return
+	}
+	p.metrics.queued.Add(1)
+	p.SpanProcessor.OnEnd(s)
+}
+
+// instrumentedExporter counts the spans each export sends or loses, and logs
+// when exports start failing and when they recover, rather than on every
+// failed batch.
+type instrumentedExporter struct {
+	sdktrace.SpanExporter
+	metrics *traceExportMetrics
+	log     observability.Logger
+	failing atomic.Bool
+}
+
+func (e *instrumentedExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	n := len(spans)
+	e.metrics.pending.Add(-int64(n))
+	e.metrics.queued.Add(-float64(n))
+	if err != nil {
+		e.metrics.dropped.Inc(float64(n), observability.Label{Key: "reason", Value: dropReasonExportFailed})
+		if !e.failing.Swap(true) {
+			e.log.Warn("trace export failing, spans are being dropped", observability.Err(err), observability.Int("spans", n))
+		}
+		return err
+	}
+	e.metrics.exported.Inc(float64(n))
+	if e.failing.Swap(false) {
+		e.log.Info("trace export recovered")
+	}
+	return nil
+}
@@ -1,22 +1,109 @@
 package implementation
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/jt828/go-grpc-template/pkg/observability"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type zapLogger struct {
 	l *zap.Logger
 }
 
-func NewZapLogger() (observability.Logger, error) {
-	l, err := zap.NewProduction()
+// NewZapLogger returns a JSON logger writing to the sinks in cfg, or to
+// stderr when there are none. It keeps zap's production defaults: sampling
+// and stack traces on errors.
+func NewZapLogger(cfg observability.LogConfig) (observability.Logger, error) {
+	level, err := zapLevel(cfg.Level, zapcore.InfoLevel)
 	if err != nil {
 		return nil, err
 	}
+	modules := make(map[string]zapcore.Level, len(cfg.Modules))
+	for name, moduleLevel := range cfg.Modules {
+		if modules[name], err = zapLevel(moduleLevel, level); err != nil {
+			return nil, fmt.Errorf("module %s: %w", name, err)
+		}
+	}
+
+	sinks := cfg.Sinks
+	if len(sinks) == 0 {
+		sinks = []observability.LogSink{{Path: "stderr"}}
+	}
+	cores := make([]zapcore.Core, len(sinks))
+	for i, sink := range sinks {
+		sinkLevel, err := zapLevel(sink.Level, zapcore.DebugLevel)
+		if err != nil {
+			return nil, fmt.Errorf("sink %s: %w", sink.Path, err)
+		}
+		out, _, err := zap.Open(sink.Path)
+		if err != nil {
+			return nil, fmt.Errorf("sink %s: %w", sink.Path, err)
+		}
+		cores[i] = zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), out, sinkLevel)
+	}
+
+	core := &moduleCore{Core: zapcore.NewTee(cores...), level: level, defaultLevel: level, modules: modules}
+	l := zap.New(
+		zapcore.NewSamplerWithOptions(core, time.Second, 100, 100),
+		zap.AddCaller(),
+		zap.AddCallerSkip(1),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+	)
 	return &zapLogger{l: l}, nil
 }
 
+func zapLevel(level observability.Level, fallback zapcore.Level) (zapcore.Level, error) {
+	if level == "" {
+		return fallback, nil
+	}
+	if _, err := observability.ParseLevel(string(level)); err != nil {
+		return 0, err
+	}
+	return zapcore.ParseLevel(string(level))
+}
+
+// moduleCore drops entries below the level of the logger's module, set by
+// an observability.Module field, before the sinks apply their own levels.
+type moduleCore struct {
+	zapcore.Core
+	level        zapcore.Level
+	defaultLevel zapcore.Level
+	modules      map[string]zapcore.Level
+}
+
+func (c *moduleCore) Enabled(level zapcore.Level) bool {
+	return level >= c.level && c.Core.Enabled(level)
+}
+
+func (c *moduleCore) Level() zapcore.Level {
+	return max(c.level, zapcore.LevelOf(c.Core))
+}
+
+func (c *moduleCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	for _, f := range fields {
+		if f.Key == observability.ModuleKey && f.Type == zapcore.StringType {
+			if moduleLevel, ok := c.modules[f.String]; ok {
+				clone.level = moduleLevel
+			} else {
+				clone.level = c.defaultLevel
+			}
+		}
+	}
+	clone.Core = c.Core.With(fields)
+	return &clone
+}
+
+func (c *moduleCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level < c.level {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
+
 func toZap(fields []observability.Field) []zap.Field {
 	if len(fields) == 0 {
 		return nil
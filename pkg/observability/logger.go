@@ -1,5 +1,11 @@
 package observability
 
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
 type Logger interface {
 	Debug(msg string, fields ...Field)
 	Error(msg string, fields ...Field)
@@ -8,3 +14,72 @@ type Logger interface {
 	Warn(msg string, fields ...Field)
 	With(fields ...Field) Logger
 }
+
+// Level is a minimum log severity.
+type Level string
+
+const (
+	LevelDebug Level = "debug"
+	LevelInfo  Level = "info"
+	LevelWarn  Level = "warn"
+	LevelError Level = "error"
+)
+
+func ParseLevel(s string) (Level, error) {
+	switch level := Level(strings.ToLower(s)); level {
+	case LevelDebug, LevelInfo, LevelWarn, LevelError:
+		return level, nil
+	}
+	return "", fmt.Errorf("unknown log level %q, want debug, info, warn or error", s)
+}
+
+// LogConfig routes logs to sinks. An entry is written when its level is at
+// least its module's level (Modules, else Level), and then to every sink
+// whose own level it also meets. The zero value logs info and above to
+// stderr.
+type LogConfig struct {
+	Level Level
+	// Modules overrides Level for loggers tagged with Module.
+	Modules map[string]Level
+	Sinks   []LogSink
+}
+
+// LogSink is a log destination: "stdout", "stderr" or a file path. An
+// empty Level accepts every entry that passes the module level.
+type LogSink struct {
+	Path  string
+	Level Level
+}
+
+// Module tags a logger with the component it belongs to, so
+// LogConfig.Modules can set its level:
+//
+//	log = log.With(observability.Module("repository"))
+func Module(name string) Field {
+	return Field{Key: ModuleKey, Value: name}
+}
+
+// ModuleKey is the field key Module uses.
+const ModuleKey = "module"
+
+type logFieldsKey struct{}
+
+// ContextWithLogFields adds fields, e.g. the id of the request being served,
+// to those LoggerFromContext attaches for ctx.
+func ContextWithLogFields(ctx context.Context, fields ...Field) context.Context {
+	existing, _ := ctx.Value(logFieldsKey{}).([]Field)
+	return context.WithValue(ctx, logFieldsKey{}, append(existing[:len(existing):len(existing)], fields...))
+}
+
+// LoggerFromContext returns log With the fields ContextWithLogFields added
+// to ctx, so a component logs under its own module with the fields of the
+// request it is serving. Outside a request it returns log unchanged.
+func LoggerFromContext(ctx context.Context, log Logger) Logger {
+	if ctx == nil {
+		return log
+	}
+	if fields, _ := ctx.Value(logFieldsKey{}).([]Field); len(fields) > 0 {
+		return log.With(fields...)
+	}
+	return log
+}
@@ -1,14 +1,63 @@
 package observability
 
+import "fmt"
+
 type Label struct {
 	Key   string
 	Value string
 }
 
 type MetricOpt struct {
-	Help        string
-	Buckets     []float64
+	Help    string
+	Buckets []float64
+	// Preset selects named histogram buckets when Buckets is empty. With
+	// neither set, Prometheus' DefBuckets apply.
+	Preset      BucketPreset
 	ConstLabels []Label
 	LabelKeys   []string
-	Unit        string
+	// Unit is UnitSeconds (the default) or UnitMilliseconds. It scales
+	// preset buckets, and Timer observations are recorded in it.
+	Unit string
+}
+
+const (
+	UnitSeconds      = "seconds"
+	UnitMilliseconds = "milliseconds"
+)
+
+// BucketPreset names a set of histogram buckets suited to one kind of
+// latency. Preset buckets are always given in seconds.
+type BucketPreset string
+
+const (
+	// BucketsDB covers single queries, from sub-millisecond index lookups
+	// to one second.
+	BucketsDB BucketPreset = "db"
+	// BucketsRPC covers whole requests handled by this service.
+	BucketsRPC BucketPreset = "rpc"
+	// BucketsExternal covers calls to other services over the network.
+	BucketsExternal BucketPreset = "external"
+)
+
+// BucketPresets lists every preset, in a stable order.
+var BucketPresets = []BucketPreset{BucketsDB, BucketsRPC, BucketsExternal}
+
+// DefaultBucketPresets returns the built-in buckets for every preset.
+func DefaultBucketPresets() map[BucketPreset][]float64 {
+	return map[BucketPreset][]float64{
+		BucketsDB:       {0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+		BucketsRPC:      {0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		BucketsExternal: {0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}
+}
+
+// UnitScale returns the factor that converts seconds to unit.
+func UnitScale(unit string) (float64, error) {
+	switch unit {
+	case "", UnitSeconds:
+		return 1, nil
+	case UnitMilliseconds:
+		return 1000, nil
+	}
+	return 0, fmt.Errorf("unsupported metric unit %q", unit)
 }
@@ -0,0 +1,99 @@
+// Package noop provides observability implementations that discard
+// everything, for unit tests and tools that need the interfaces without
+// zap, Prometheus or OpenTelemetry.
+package noop
+
+import (
+	"context"
+	"os"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+)
+
+// NewNoopObservability returns an Observability whose logger, meter and
+// tracer discard everything and whose Start, Check and Close do nothing.
+func NewNoopObservability() observability.Observability {
+	return noopObservability{}
+}
+
+type noopObservability struct{}
+
+func (noopObservability) Check(ctx context.Context) error { return nil }
+func (noopObservability) Close(ctx context.Context) error { return nil }
+func (noopObservability) Logger() observability.Logger    { return NewLogger() }
+func (noopObservability) Meter() observability.Meter      { return NewMeter() }
+func (noopObservability) Start(ctx context.Context) error { return nil }
+func (noopObservability) Tracer() observability.Tracer    { return NewTracer() }
+
+// -------------------- Logger --------------------
+
+// NewLogger returns a logger that discards every entry. Fatal still exits
+// the process, since callers rely on it not returning.
+func NewLogger() observability.Logger {
+	return logger{}
+}
+
+type logger struct{}
+
+func (logger) Debug(msg string, fields ...observability.Field) {}
+func (logger) Error(msg string, fields ...observability.Field) {}
+func (logger) Fatal(msg string, fields ...observability.Field) { os.Exit(1) }
+func (logger) Info(msg string, fields ...observability.Field)  {}
+func (logger) Warn(msg string, fields ...observability.Field)  {}
+func (l logger) With(fields ...observability.Field) observability.Logger {
+	return l
+}
+
+// -------------------- Meter --------------------
+
+// NewMeter returns a meter whose metrics record nothing. Unlike the
+// Prometheus meter, registering the same name twice is allowed.
+func NewMeter() observability.Meter {
+	return meter{}
+}
+
+type meter struct{}
+
+func (meter) Counter(name string, opts ...observability.MetricOpt) observability.Counter {
+	return metric{}
+}
+
+func (meter) Histogram(name string, opts ...observability.MetricOpt) observability.Histogram {
+	return metric{}
+}
+
+func (meter) Gauge(name string, opts ...observability.MetricOpt) observability.Gauge {
+	return metric{}
+}
+
+func (meter) Timer(name string, opts ...observability.MetricOpt) observability.Timer {
+	return metric{}
+}
+
+type metric struct{}
+
+func (metric) Inc(v float64, labels ...observability.Label)     {}
+func (metric) Observe(v float64, labels ...observability.Label) {}
+func (metric) Set(v float64, labels ...observability.Label)     {}
+func (metric) Add(v float64, labels ...observability.Label)     {}
+func (metric) Start(labels ...observability.Label) func()       { return func() {} }
+
+// -------------------- Tracer --------------------
+
+// NewTracer returns a tracer whose spans record nothing. Start returns ctx
+// unchanged.
+func NewTracer() observability.Tracer {
+	return tracer{}
+}
+
+type tracer struct{}
+
+func (tracer) Start(ctx context.Context, name string) (context.Context, observability.Span) {
+	return ctx, span{}
+}
+
+type span struct{}
+
+func (span) End()                                        {}
+func (span) RecordError(err error)                       {}
+func (span) SetAttributes(fields ...observability.Field) {}
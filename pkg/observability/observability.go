@@ -3,6 +3,9 @@ package observability
 import "context"
 
 type Observability interface {
+	// Check reports whether what Start serves in the background is still
+	// serving, nil when it serves nothing.
+	Check(ctx context.Context) error
 	Close(ctx context.Context) error
 	Logger() Logger
 	Meter() Meter
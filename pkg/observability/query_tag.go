@@ -0,0 +1,25 @@
+package observability
+
+import "context"
+
+type queryTagsKey struct{}
+
+// QueryTags identifies the caller of a SQL statement. They are rendered as a
+// trailing SQL comment so statements in pg_stat_activity/pg_stat_statements
+// can be traced back to the RPC that issued them.
+type QueryTags struct {
+	Method    string
+	RequestId string
+}
+
+func ContextWithQueryTags(ctx context.Context, tags QueryTags) context.Context {
+	return context.WithValue(ctx, queryTagsKey{}, tags)
+}
+
+func QueryTagsFromContext(ctx context.Context) QueryTags {
+	if ctx == nil {
+		return QueryTags{}
+	}
+	tags, _ := ctx.Value(queryTagsKey{}).(QueryTags)
+	return tags
+}
@@ -0,0 +1,25 @@
+package observability
+
+import "context"
+
+// RequestIdKey is the log field key and metadata header carrying the id of
+// the request being served.
+const RequestIdKey = "request_id"
+
+type requestIdKey struct{}
+
+// ContextWithRequestId records the id of the request being served, so logs,
+// errors and SQL statements issued for it can be correlated.
+func ContextWithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, id)
+}
+
+// RequestIdFromContext returns the id ContextWithRequestId recorded, or ""
+// outside a request.
+func RequestIdFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
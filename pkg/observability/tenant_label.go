@@ -0,0 +1,53 @@
+package observability
+
+import "slices"
+
+const (
+	// TenantLabelKey is the label key of per-tenant metrics.
+	TenantLabelKey = "tenant"
+	// OtherTenant is recorded for every tenant outside the allow-list.
+	OtherTenant = "other"
+	// MaxTenantLabels bounds the allow-list, so a per-tenant metric has at
+	// most MaxTenantLabels+1 series per combination of its other labels.
+	MaxTenantLabels = 50
+)
+
+// TenantLabels maps tenant ids to tenant label values. Only allow-listed
+// tenants, typically the top N by traffic, get a value of their own; the rest
+// are bucketed as OtherTenant so a metric's cardinality stays bounded however
+// many tenants there are. A nil *TenantLabels buckets every tenant.
+type TenantLabels struct {
+	allowed map[string]struct{}
+}
+
+func NewTenantLabels(allowed []string) *TenantLabels {
+	t := &TenantLabels{allowed: make(map[string]struct{}, len(allowed))}
+	for _, tenant := range allowed {
+		t.allowed[tenant] = struct{}{}
+	}
+	return t
+}
+
+// Label returns the tenant label for tenant. Metrics labelled with it must
+// declare TenantLabelKey in their LabelKeys.
+func (t *TenantLabels) Label(tenant string) Label {
+	if t != nil {
+		if _, ok := t.allowed[tenant]; ok {
+			return Label{Key: TenantLabelKey, Value: tenant}
+		}
+	}
+	return Label{Key: TenantLabelKey, Value: OtherTenant}
+}
+
+// Allowed returns the allow-listed tenants, sorted.
+func (t *TenantLabels) Allowed() []string {
+	if t == nil {
+		return nil
+	}
+	tenants := make([]string, 0, len(t.allowed))
+	for tenant := range t.allowed {
+		tenants = append(tenants, tenant)
+	}
+	slices.Sort(tenants)
+	return tenants
+}
@@ -1,6 +1,11 @@
 package observability
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
 
 type Tracer interface {
 	Start(ctx context.Context, name string) (context.Context, Span)
@@ -9,4 +14,58 @@ type Tracer interface {
 type Span interface {
 	End()
 	RecordError(err error)
+	SetAttributes(fields ...Field)
+}
+
+// TraceExportConfig bounds how finished spans are exported. Up to
+// MaxQueueSize spans wait for export; spans ending while it is full are
+// dropped rather than slowing the request that ended them. Spans are sent in
+// batches of up to MaxBatchSize, at least every BatchTimeout, and an export,
+// retries included, is abandoned after ExportTimeout. Zero fields keep
+// DefaultTraceExportConfig's values.
+type TraceExportConfig struct {
+	MaxQueueSize  int
+	MaxBatchSize  int
+	BatchTimeout  time.Duration
+	ExportTimeout time.Duration
+}
+
+// DefaultTraceExportConfig returns the OpenTelemetry SDK's batching defaults,
+// with a 10s export timeout so a stalled collector is given up on sooner.
+func DefaultTraceExportConfig() TraceExportConfig {
+	return TraceExportConfig{
+		MaxQueueSize:  2048,
+		MaxBatchSize:  512,
+		BatchTimeout:  5 * time.Second,
+		ExportTimeout: 10 * time.Second,
+	}
+}
+
+// Propagator is a format trace context or baggage is carried in across
+// process boundaries, named as in the OTEL_PROPAGATORS convention.
+type Propagator string
+
+const (
+	// PropagatorTraceContext is W3C Trace Context, the traceparent and
+	// tracestate headers.
+	PropagatorTraceContext Propagator = "tracecontext"
+	// PropagatorBaggage is W3C Baggage, the baggage header.
+	PropagatorBaggage Propagator = "baggage"
+	// PropagatorB3 is Zipkin's single b3 header, for legacy clients.
+	PropagatorB3 Propagator = "b3"
+	// PropagatorB3Multi is Zipkin's X-B3-* headers, for legacy clients.
+	PropagatorB3Multi Propagator = "b3multi"
+)
+
+func ParsePropagator(s string) (Propagator, error) {
+	switch p := Propagator(strings.ToLower(s)); p {
+	case PropagatorTraceContext, PropagatorBaggage, PropagatorB3, PropagatorB3Multi:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown propagator %q, want tracecontext, baggage, b3 or b3multi", s)
+}
+
+// DefaultPropagators returns W3C Trace Context and Baggage.
+func DefaultPropagators() []Propagator {
+	return []Propagator{PropagatorTraceContext, PropagatorBaggage}
 }
@@ -0,0 +1,536 @@
+package implementation
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/httpclient"
+	"github.com/jt828/go-grpc-template/pkg/oidc"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+)
+
+const (
+	// maxDocumentSize bounds the discovery document and key set read from
+	// the provider.
+	maxDocumentSize = 1 << 20
+	// minRSAKeyBits rejects keys too short to be trusted.
+	minRSAKeyBits = 2048
+)
+
+// signingAlgorithm is a JWS algorithm and the hash it signs.
+type signingAlgorithm struct {
+	hash crypto.Hash
+	pss  bool
+}
+
+// signingAlgorithms lists the asymmetric algorithms accepted. "none" and the
+// HMAC algorithms are absent, so a token cannot be signed with the public key
+// or not at all.
+var signingAlgorithms = map[string]signingAlgorithm{
+	"RS256": {hash: crypto.SHA256},
+	"RS384": {hash: crypto.SHA384},
+	"RS512": {hash: crypto.SHA512},
+	"PS256": {hash: crypto.SHA256, pss: true},
+	"PS384": {hash: crypto.SHA384, pss: true},
+	"PS512": {hash: crypto.SHA512, pss: true},
+	"ES256": {hash: crypto.SHA256},
+	"ES384": {hash: crypto.SHA384},
+	"ES512": {hash: crypto.SHA512},
+	"EdDSA": {},
+}
+
+// jwk is one verified key from the provider's key set.
+type jwk struct {
+	key crypto.PublicKey
+	// alg, when the key set names one, is the only algorithm the key may
+	// verify.
+	alg string
+}
+
+type jwksVerifier struct {
+	issuer   string
+	audience string
+	client   httpclient.Client
+	cb       circuitbreaker.CircuitBreaker
+	retry    retry.Retry
+	cfg      *oidc.Config
+
+	// fetchMu serialises fetches, so concurrent requests signed with a new
+	// key trigger one fetch between them.
+	fetchMu sync.Mutex
+	mu      sync.RWMutex
+	jwksURL string
+	keys    map[string]jwk
+	// fetchedAt is when keys were last fetched; attemptedAt and fetchErr
+	// record the last attempt, successful or not.
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	fetchErr    error
+}
+
+// NewJWKSVerifier verifies tokens issued by issuer for audience against the
+// issuer's JSON Web Key Set. Keys are fetched on first use, cached for
+// RefreshInterval and fetched early when a token names an unknown key id, so
+// a rotated signing key is picked up without a restart. While the provider is
+// unreachable the last keys fetched stay in use.
+func NewJWKSVerifier(issuer, audience string, client httpclient.Client, cb circuitbreaker.CircuitBreaker, retry retry.Retry, opts ...oidc.Option) oidc.Verifier {
+	cfg := oidc.ApplyOptions(opts...)
+	return &jwksVerifier{
+		issuer:   issuer,
+		audience: audience,
+		client:   client,
+		cb:       cb,
+		retry:    retry,
+		cfg:      cfg,
+		jwksURL:  cfg.JWKSURL,
+	}
+}
+
+// tokenHeader is the JOSE header of a signed token.
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// tokenClaims are the registered and OpenID claims read from a token.
+type tokenClaims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      audience `json:"aud"`
+	ExpiresAt     *int64   `json:"exp"`
+	NotBefore     *int64   `json:"nbf"`
+	IssuedAt      *int64   `json:"iat"`
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"email_verified"`
+	SessionId     string   `json:"sid"`
+}
+
+// audience is the aud claim, which is either one string or an array.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+func (v *jwksVerifier) Verify(ctx context.Context, rawToken string) (*oidc.Claims, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token is not a signed JWT: %w", oidc.ErrInvalidToken)
+	}
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("token header: %w", err)
+	}
+	alg, ok := signingAlgorithms[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("signing algorithm %q is not accepted: %w", header.Alg, oidc.ErrInvalidToken)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("token signature is not base64url: %w", oidc.ErrInvalidToken)
+	}
+
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return nil, fmt.Errorf("key %q is for %s, token is signed with %s: %w", header.Kid, key.alg, header.Alg, oidc.ErrInvalidToken)
+	}
+	if err := verifySignature(header.Alg, alg, key.key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var claims tokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("token claims: %w", err)
+	}
+	return v.checkClaims(claims)
+}
+
+func (v *jwksVerifier) checkClaims(claims tokenClaims) (*oidc.Claims, error) {
+	if claims.Issuer != v.issuer {
+		return nil, fmt.Errorf("token issuer %q is not %q: %w", claims.Issuer, v.issuer, oidc.ErrInvalidToken)
+	}
+	if !slices.Contains(claims.Audience, v.audience) {
+		return nil, fmt.Errorf("token audience does not include %q: %w", v.audience, oidc.ErrInvalidToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("token has no subject: %w", oidc.ErrInvalidToken)
+	}
+	if claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("token has no expiry: %w", oidc.ErrInvalidToken)
+	}
+
+	now := v.cfg.Clock()
+	expiresAt := time.Unix(*claims.ExpiresAt, 0)
+	if !now.Before(expiresAt.Add(v.cfg.Leeway)) {
+		return nil, fmt.Errorf("token expired at %s: %w", expiresAt.UTC().Format(time.RFC3339), oidc.ErrInvalidToken)
+	}
+	if claims.NotBefore != nil {
+		if notBefore := time.Unix(*claims.NotBefore, 0); now.Add(v.cfg.Leeway).Before(notBefore) {
+			return nil, fmt.Errorf("token is not valid before %s: %w", notBefore.UTC().Format(time.RFC3339), oidc.ErrInvalidToken)
+		}
+	}
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = time.Unix(*claims.IssuedAt, 0)
+		if now.Add(v.cfg.Leeway).Before(issuedAt) {
+			return nil, fmt.Errorf("token is issued in the future: %w", oidc.ErrInvalidToken)
+		}
+	}
+
+	return &oidc.Claims{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Audience:      claims.Audience,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		ExpiresAt:     expiresAt.UTC(),
+		IssuedAt:      issuedAt.UTC(),
+		SessionId:     claims.SessionId,
+	}, nil
+}
+
+// key returns the key kid names, fetching the key set when it is stale or
+// does not hold kid. An unknown kid triggers at most one fetch per
+// MinRefreshInterval.
+func (v *jwksVerifier) key(ctx context.Context, kid string) (jwk, error) {
+	if key, ok, fresh := v.cached(kid); ok && fresh {
+		return key, nil
+	}
+
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+	// Another request may have fetched the keys while this one waited.
+	key, ok, fresh := v.cached(kid)
+	if ok && fresh {
+		return key, nil
+	}
+
+	v.mu.RLock()
+	attemptedAt, fetchErr := v.attemptedAt, v.fetchErr
+	v.mu.RUnlock()
+	if attemptedAt.IsZero() || v.cfg.Clock().Sub(attemptedAt) >= v.cfg.MinRefreshInterval {
+		fetchErr = v.refresh(ctx)
+		if fetchErr == nil {
+			key, ok, _ = v.cached(kid)
+		}
+	}
+	if ok {
+		return key, nil
+	}
+	if fetchErr != nil {
+		return jwk{}, fmt.Errorf("fetch signing keys of %s: %w", v.issuer, fetchErr)
+	}
+	return jwk{}, fmt.Errorf("unknown signing key %q: %w", kid, oidc.ErrInvalidToken)
+}
+
+// cached looks kid up in the cached keys and reports whether they are still
+// within RefreshInterval. A token without a kid may only use a key set of
+// one key.
+func (v *jwksVerifier) cached(kid string) (jwk, bool, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	fresh := !v.fetchedAt.IsZero() && v.cfg.Clock().Sub(v.fetchedAt) < v.cfg.RefreshInterval
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true, fresh
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok, fresh
+}
+
+// refresh fetches the key set, replacing the cached keys only when the fetch
+// succeeds.
+func (v *jwksVerifier) refresh(ctx context.Context) error {
+	now := v.cfg.Clock()
+	keys, err := v.fetchKeys(ctx)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.attemptedAt, v.fetchErr = now, err
+	if err != nil {
+		return err
+	}
+	v.keys, v.fetchedAt = keys, now
+	return nil
+}
+
+func (v *jwksVerifier) fetchKeys(ctx context.Context) (map[string]jwk, error) {
+	result, err := v.cb.Execute(func() (any, error) {
+		var keys map[string]jwk
+		err := v.retry.Execute(ctx, func() error {
+			jwksURL, err := v.discover(ctx)
+			if err != nil {
+				return err
+			}
+			var set struct {
+				Keys []json.RawMessage `json:"keys"`
+			}
+			if err := v.getJSON(ctx, jwksURL, &set); err != nil {
+				return err
+			}
+			keys = parseKeySet(set.Keys)
+			if len(keys) == 0 {
+				return fmt.Errorf("key set at %s holds no usable signing keys", jwksURL)
+			}
+			return nil
+		})
+		return keys, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]jwk), nil
+}
+
+// discover returns the key set URL, reading it from the issuer's discovery
+// document the first time.
+func (v *jwksVerifier) discover(ctx context.Context) (string, error) {
+	v.mu.RLock()
+	jwksURL := v.jwksURL
+	v.mu.RUnlock()
+	if jwksURL != "" {
+		return jwksURL, nil
+	}
+
+	var document struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := v.getJSON(ctx, strings.TrimSuffix(v.issuer, "/")+"/.well-known/openid-configuration", &document); err != nil {
+		return "", err
+	}
+	if document.Issuer != v.issuer {
+		return "", fmt.Errorf("discovery document is for issuer %q, not %q", document.Issuer, v.issuer)
+	}
+	if document.JWKSURI == "" {
+		return "", fmt.Errorf("discovery document of %s has no jwks_uri", v.issuer)
+	}
+
+	v.mu.Lock()
+	v.jwksURL = document.JWKSURI
+	v.mu.Unlock()
+	return document.JWKSURI, nil
+}
+
+func (v *jwksVerifier) getJSON(ctx context.Context, url string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDocumentSize)).Decode(target); err != nil {
+		return fmt.Errorf("%s: %w", url, err)
+	}
+	return nil
+}
+
+// rawJWK holds the members of RFC 7517 keys used to verify signatures.
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseKeySet returns the signing keys of a key set by kid. Encryption keys
+// and keys of unsupported types are skipped, so a provider publishing them
+// does not break verification.
+func parseKeySet(raw []json.RawMessage) map[string]jwk {
+	keys := map[string]jwk{}
+	for _, message := range raw {
+		var key rawJWK
+		if err := json.Unmarshal(message, &key); err != nil {
+			continue
+		}
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if _, ok := signingAlgorithms[key.Alg]; key.Alg != "" && !ok {
+			continue
+		}
+		public, err := key.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[key.Kid] = jwk{key: public, alg: key.Alg}
+	}
+	return keys
+}
+
+func (k rawJWK) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if n.BitLen() < minRSAKeyBits || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("unacceptable RSA key")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("malformed EC point")
+		}
+		return ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("malformed Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// verifySignature checks signature over signed with key, which must be of
+// the type name calls for.
+func verifySignature(name string, alg signingAlgorithm, key crypto.PublicKey, signed, signature []byte) error {
+	var digest []byte
+	if alg.hash != 0 {
+		h := alg.hash.New()
+		h.Write(signed)
+		digest = h.Sum(nil)
+	}
+
+	var valid bool
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		switch {
+		case strings.HasPrefix(name, "RS"):
+			valid = rsa.VerifyPKCS1v15(key, alg.hash, digest, signature) == nil
+		case strings.HasPrefix(name, "PS"):
+			valid = rsa.VerifyPSS(key, alg.hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		default:
+			return fmt.Errorf("RSA key cannot verify %s: %w", name, oidc.ErrInvalidToken)
+		}
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(name, "ES") || curveHash(key.Curve) != alg.hash {
+			return fmt.Errorf("EC key cannot verify %s: %w", name, oidc.ErrInvalidToken)
+		}
+		// JWS encodes an ECDSA signature as r and s, each padded to the
+		// curve's size, rather than in ASN.1.
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) == 2*size {
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			valid = ecdsa.Verify(key, digest, r, s)
+		}
+	case ed25519.PublicKey:
+		if name != "EdDSA" {
+			return fmt.Errorf("Ed25519 key cannot verify %s: %w", name, oidc.ErrInvalidToken)
+		}
+		valid = ed25519.Verify(key, signed, signature)
+	}
+	if !valid {
+		return fmt.Errorf("token signature does not verify: %w", oidc.ErrInvalidToken)
+	}
+	return nil
+}
+
+// curveHash is the hash each ES algorithm pairs with its curve.
+func curveHash(curve elliptic.Curve) crypto.Hash {
+	switch curve {
+	case elliptic.P256():
+		return crypto.SHA256
+	case elliptic.P384():
+		return crypto.SHA384
+	case elliptic.P521():
+		return crypto.SHA512
+	}
+	return 0
+}
+
+func decodeSegment(segment string, target any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("not base64url: %w", oidc.ErrInvalidToken)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("not a JSON object: %w", oidc.ErrInvalidToken)
+	}
+	return nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("empty integer")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,94 @@
+// Package oidc verifies ID and access tokens issued by an external OpenID
+// Connect provider such as Keycloak or Auth0, so deployments can delegate
+// authentication to it.
+package oidc
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInvalidToken means a token is malformed, is not signed by one of the
+// issuer's keys, or fails the issuer, audience or lifetime checks.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims are the verified claims of a token that identify its subject.
+// SessionId is the provider's session the token was issued in, from the sid
+// claim, and is empty when the provider does not send one.
+type Claims struct {
+	Issuer        string
+	Subject       string
+	Audience      []string
+	Email         string
+	EmailVerified bool
+	ExpiresAt     time.Time
+	IssuedAt      time.Time
+	SessionId     string
+}
+
+// Verifier checks bearer tokens issued by one provider.
+type Verifier interface {
+	// Verify returns rawToken's claims. It fails with an error wrapping
+	// ErrInvalidToken when the token must be rejected, and with any other
+	// error when the provider's keys could not be fetched.
+	Verify(ctx context.Context, rawToken string) (*Claims, error)
+}
+
+type Config struct {
+	// JWKSURL is the provider's key set. When empty it is discovered from
+	// the issuer's /.well-known/openid-configuration.
+	JWKSURL string
+	// RefreshInterval is how long fetched keys are trusted before they are
+	// fetched again. MinRefreshInterval bounds how often a token signed with
+	// an unknown key id may trigger an early fetch, so a flood of forged
+	// tokens cannot hammer the provider.
+	RefreshInterval    time.Duration
+	MinRefreshInterval time.Duration
+	// Leeway is the clock skew allowed when checking exp, nbf and iat.
+	Leeway time.Duration
+	Clock  func() time.Time
+}
+
+type Option func(*Config)
+
+func WithJWKSURL(url string) Option {
+	return func(c *Config) {
+		c.JWKSURL = url
+	}
+}
+
+func WithRefreshInterval(refresh, minRefresh time.Duration) Option {
+	return func(c *Config) {
+		c.RefreshInterval = refresh
+		c.MinRefreshInterval = minRefresh
+	}
+}
+
+func WithLeeway(leeway time.Duration) Option {
+	return func(c *Config) {
+		c.Leeway = leeway
+	}
+}
+
+// WithClock replaces time.Now, e.g. to test expiry checks.
+func WithClock(clock func() time.Time) Option {
+	return func(c *Config) {
+		c.Clock = clock
+	}
+}
+
+// ApplyOptions defaults to refreshing keys hourly, at most once a minute
+// early, with a minute of leeway.
+func ApplyOptions(opts ...Option) *Config {
+	c := &Config{
+		RefreshInterval:    time.Hour,
+		MinRefreshInterval: time.Minute,
+		Leeway:             time.Minute,
+		Clock:              time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
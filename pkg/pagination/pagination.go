@@ -0,0 +1,55 @@
+// Package pagination encodes the cursors of lists paged by keyset on
+// snowflake ids. A keyset page reads "WHERE id > cursor ORDER BY id LIMIT n",
+// or "id < cursor ... DESC" newest first, so every page costs one index range
+// scan however deep it is, where OFFSET reads and discards every row before
+// the page.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+)
+
+// version is the first byte of an encoded cursor, so the format can change
+// without misreading tokens handed out before.
+const version = 1
+
+// encodedLen is the length of a decoded cursor: version, direction, id.
+const encodedLen = 1 + 1 + 8
+
+// Cursor is the position after which the next page starts: the id of the
+// last row returned, and the order the list is walked in.
+type Cursor struct {
+	LastId int64
+	// Desc walks the list newest first.
+	Desc bool
+}
+
+// Encode returns c as an opaque URL-safe token. Clients pass it back as is;
+// they should not rely on its contents.
+func (c Cursor) Encode() string {
+	var buf [encodedLen]byte
+	buf[0] = version
+	if c.Desc {
+		buf[1] = 1
+	}
+	binary.BigEndian.PutUint64(buf[2:], uint64(c.LastId))
+	return base64.RawURLEncoding.EncodeToString(buf[:])
+}
+
+// Decode parses a token returned by Encode. It returns an
+// apperror.ErrInvalidArgument error for tokens Encode could not have
+// produced.
+func Decode(token string) (Cursor, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(buf) != encodedLen || buf[0] != version || buf[1] > 1 {
+		return Cursor{}, apperror.InvalidArgumentf("malformed page token %q", token)
+	}
+	id := binary.BigEndian.Uint64(buf[2:])
+	if id == 0 || int64(id) < 0 {
+		return Cursor{}, apperror.InvalidArgumentf("malformed page token %q", token)
+	}
+	return Cursor{LastId: int64(id), Desc: buf[1] == 1}, nil
+}
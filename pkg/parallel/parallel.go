@@ -0,0 +1,136 @@
+// Package parallel runs independent tasks concurrently with a bound on how
+// many run at once, for service methods that fan out reads such as one query
+// per shard or per token.
+package parallel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"golang.org/x/sync/errgroup"
+)
+
+// TaskError is returned, joined with the others, for each task that failed
+// when partial results are enabled.
+type TaskError struct {
+	Index int
+	Err   error
+}
+
+func (e *TaskError) Error() string { return fmt.Sprintf("task %d: %v", e.Index, e.Err) }
+
+func (e *TaskError) Unwrap() error { return e.Err }
+
+type Config struct {
+	// Limit bounds how many tasks run at once. Zero or less means no bound.
+	Limit int
+	// Partial keeps the remaining tasks running after one fails.
+	Partial bool
+	// Tracer, when set, starts a span named SpanName for every task.
+	Tracer   observability.Tracer
+	SpanName string
+}
+
+type Option func(*Config)
+
+func WithLimit(n int) Option {
+	return func(c *Config) {
+		c.Limit = n
+	}
+}
+
+// WithPartialResults makes Map return every result that succeeded alongside
+// the failures, instead of cancelling the rest on the first error.
+func WithPartialResults() Option {
+	return func(c *Config) {
+		c.Partial = true
+	}
+}
+
+func WithTracing(tracer observability.Tracer, spanName string) Option {
+	return func(c *Config) {
+		c.Tracer = tracer
+		c.SpanName = spanName
+	}
+}
+
+func ApplyOptions(opts ...Option) *Config {
+	c := &Config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Map calls fn for every item concurrently and returns the results in the
+// order of items. Each call gets a context that is cancelled when ctx is.
+//
+// By default the first error cancels the context of the calls still running
+// and Map returns that error with no results. With WithPartialResults every
+// call runs to completion; results of failed calls are left as the zero
+// value and the error joins a *TaskError for each of them.
+func Map[In, Out any](ctx context.Context, items []In, fn func(ctx context.Context, item In) (Out, error), opts ...Option) ([]Out, error) {
+	cfg := ApplyOptions(opts...)
+	limit := cfg.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	results := make([]Out, len(items))
+
+	if cfg.Partial {
+		var g errgroup.Group
+		g.SetLimit(limit)
+		errs := make([]error, len(items))
+		for i, item := range items {
+			g.Go(func() error {
+				result, err := run(ctx, cfg, i, item, fn)
+				if err != nil {
+					errs[i] = &TaskError{Index: i, Err: err}
+					return nil
+				}
+				results[i] = result
+				return nil
+			})
+		}
+		_ = g.Wait()
+		return results, errors.Join(errs...)
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(limit)
+	for i, item := range items {
+		g.Go(func() error {
+			// Tasks still waiting for a slot when another fails are skipped.
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			result, err := run(gctx, cfg, i, item, fn)
+			if err != nil {
+				return err
+			}
+			results[i] = result
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func run[In, Out any](ctx context.Context, cfg *Config, index int, item In, fn func(ctx context.Context, item In) (Out, error)) (Out, error) {
+	if cfg.Tracer == nil {
+		return fn(ctx, item)
+	}
+	ctx, span := cfg.Tracer.Start(ctx, cfg.SpanName)
+	defer span.End()
+	span.SetAttributes(observability.Int("task.index", index))
+
+	result, err := fn(ctx, item)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return result, err
+}
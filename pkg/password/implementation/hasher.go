@@ -0,0 +1,114 @@
+package implementation
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/password"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// argon2Params are the parameters of an Argon2id hash. Memory is in KiB.
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+var defaultArgon2 = argon2Params{memory: 19 * 1024, time: 2, threads: 1}
+
+const (
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+	bcryptCost    = 12
+)
+
+type hasher struct {
+	algorithm password.Algorithm
+}
+
+// NewHasher returns a password.Hasher hashing with algorithm. Argon2id
+// hashes are encoded in the PHC string format,
+// "$argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>", and bcrypt hashes in the
+// usual "$2b$12$..." form.
+func NewHasher(algorithm password.Algorithm) password.Hasher {
+	return &hasher{algorithm: algorithm}
+}
+
+func (h *hasher) Hash(pw string) (string, error) {
+	if h.algorithm == password.AlgorithmBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(pw), bcryptCost)
+		if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+			return "", apperror.InvalidArgumentf("password is longer than the 72 bytes bcrypt reads")
+		}
+		return string(hash), err
+	}
+
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	p := defaultArgon2
+	key := argon2.IDKey([]byte(pw), salt, p.time, p.memory, p.threads, argon2KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.memory, p.time, p.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *hasher) Verify(pw, hash string) (bool, error) {
+	if isBcrypt(hash) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(pw))
+		switch {
+		case err == nil:
+			return true, nil
+		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+			return false, nil
+		default:
+			return false, fmt.Errorf("%w: %v", password.ErrUnknownHash, err)
+		}
+	}
+
+	p, salt, key, err := parseArgon2(hash)
+	if err != nil {
+		return false, err
+	}
+	got := argon2.IDKey([]byte(pw), salt, p.time, p.memory, p.threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(got, key) == 1, nil
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// parseArgon2 decodes a PHC-format Argon2id hash.
+func parseArgon2(hash string) (argon2Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return argon2Params{}, nil, nil, password.ErrUnknownHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return argon2Params{}, nil, nil, fmt.Errorf("%w: argon2 version %q", password.ErrUnknownHash, parts[2])
+	}
+	var p argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil || p.time == 0 || p.threads == 0 {
+		return argon2Params{}, nil, nil, fmt.Errorf("%w: argon2 parameters %q", password.ErrUnknownHash, parts[3])
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return argon2Params{}, nil, nil, fmt.Errorf("%w: argon2 salt", password.ErrUnknownHash)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return argon2Params{}, nil, nil, fmt.Errorf("%w: argon2 key", password.ErrUnknownHash)
+	}
+	return p, salt, key, nil
+}
@@ -0,0 +1,122 @@
+package implementation
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jt828/go-grpc-template/pkg/password"
+)
+
+type policy struct {
+	cfg *password.Config
+}
+
+func NewPolicy(opts ...password.Option) password.Policy {
+	return &policy{cfg: password.ApplyOptions(opts...)}
+}
+
+// Check runs the breach check only for passwords that pass every other rule,
+// sparing the external call for passwords that are rejected anyway.
+func (p *policy) Check(ctx context.Context, pw string, userInfo ...string) []password.Violation {
+	var violations []password.Violation
+	violate := func(rule, description string) {
+		violations = append(violations, password.Violation{Rule: rule, Description: description})
+	}
+
+	length := utf8.RuneCountInString(pw)
+	if length < p.cfg.MinLength {
+		violate(password.RuleMinLength, fmt.Sprintf("must be at least %d characters", p.cfg.MinLength))
+	}
+	if p.cfg.MaxLength > 0 && length > p.cfg.MaxLength {
+		violate(password.RuleMaxLength, fmt.Sprintf("must be at most %d characters", p.cfg.MaxLength))
+	}
+	if p.cfg.MinEntropyBits > 0 && estimateEntropy(pw) < p.cfg.MinEntropyBits {
+		violate(password.RuleEntropy, "is too predictable; use a longer password or mix in other kinds of characters")
+	}
+	if containsUserInfo(pw, userInfo) {
+		violate(password.RuleUserInfo, "must not contain the username or email")
+	}
+
+	if len(violations) > 0 || p.cfg.Breaches == nil {
+		return violations
+	}
+	breached, err := p.cfg.Breaches.Breached(ctx, pw)
+	if err != nil {
+		if p.cfg.OnBreachCheckError != nil {
+			p.cfg.OnBreachCheckError(ctx, err)
+		}
+		return nil
+	}
+	if breached {
+		violate(password.RuleBreached, "has appeared in a data breach; choose a different password")
+	}
+	return violations
+}
+
+// estimateEntropy approximates a password's entropy in bits from the size of
+// the character classes it draws on. Characters that repeat or continue a
+// sequence of their predecessor ("aaa", "abc", "321") add a single bit.
+func estimateEntropy(pw string) float64 {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range pw {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < utf8.RuneSelf && (unicode.IsPunct(r) || unicode.IsSymbol(r) || r == ' '):
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	for _, class := range []struct {
+		present bool
+		size    int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.present {
+			pool += class.size
+		}
+	}
+
+	perChar := math.Log2(float64(pool))
+	bits := 0.0
+	prev := rune(-2)
+	for _, r := range pw {
+		if r == prev || r == prev+1 || r == prev-1 {
+			bits++
+		} else {
+			bits += perChar
+		}
+		prev = r
+	}
+	return bits
+}
+
+// containsUserInfo reports whether pw contains any of userInfo, ignoring
+// case. An email's local part is checked on its own as well. Values shorter
+// than three characters are ignored.
+func containsUserInfo(pw string, userInfo []string) bool {
+	pw = strings.ToLower(pw)
+	for _, info := range userInfo {
+		info = strings.ToLower(strings.TrimSpace(info))
+		candidates := []string{info}
+		if local, _, ok := strings.Cut(info, "@"); ok {
+			candidates = append(candidates, local)
+		}
+		for _, candidate := range candidates {
+			if utf8.RuneCountInString(candidate) >= 3 && strings.Contains(pw, candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package implementation
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/httpclient"
+	"github.com/jt828/go-grpc-template/pkg/password"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+)
+
+type pwnedPasswords struct {
+	baseURL string
+	client  httpclient.Client
+	cb      circuitbreaker.CircuitBreaker
+	retry   retry.Retry
+}
+
+// NewPwnedPasswords checks passwords against a Pwned Passwords range API at
+// baseURL using k-anonymity: only the first five hex characters of the
+// password's SHA-1 leave the process, and the response lists every breached
+// hash sharing them. Responses are padded so their size does not narrow the
+// prefix down either.
+func NewPwnedPasswords(baseURL string, client httpclient.Client, cb circuitbreaker.CircuitBreaker, retry retry.Retry) password.BreachChecker {
+	return &pwnedPasswords{baseURL: baseURL, client: client, cb: cb, retry: retry}
+}
+
+func (p *pwnedPasswords) Breached(ctx context.Context, pw string) (bool, error) {
+	sum := sha1.Sum([]byte(pw))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	result, err := p.cb.Execute(func() (any, error) {
+		var breached bool
+		err := p.retry.Execute(ctx, func() error {
+			var err error
+			breached, err = p.lookup(ctx, prefix, suffix)
+			return err
+		})
+		return breached, err
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.(bool), nil
+}
+
+func (p *pwnedPasswords) lookup(ctx context.Context, prefix, suffix string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+prefix, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("pwned passwords range %s: unexpected status %d", prefix, resp.StatusCode)
+	}
+
+	// Each line is "<hash suffix>:<count>". Padding lines have a count of 0.
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		hashSuffix, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if ok && strings.EqualFold(hashSuffix, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
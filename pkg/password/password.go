@@ -0,0 +1,123 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Rules a password can violate, reported as Violation.Rule.
+const (
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleEntropy   = "entropy"
+	RuleUserInfo  = "user_info"
+	RuleBreached  = "breached"
+)
+
+// PwnedPasswordsURL is the Have I Been Pwned range API.
+const PwnedPasswordsURL = "https://api.pwnedpasswords.com/range/"
+
+// Violation is one rule a password breaks. Description is safe to show to
+// the user and never contains the password.
+type Violation struct {
+	Rule        string
+	Description string
+}
+
+// Policy decides whether a new password is acceptable.
+type Policy interface {
+	// Check returns every rule password violates, or none if it is
+	// acceptable. userInfo lists values the password must not contain, such
+	// as the username and email.
+	Check(ctx context.Context, password string, userInfo ...string) []Violation
+}
+
+// BreachChecker reports whether a password appears in known data breaches.
+type BreachChecker interface {
+	Breached(ctx context.Context, password string) (bool, error)
+}
+
+type Config struct {
+	// MinLength and MaxLength bound the password's length in characters.
+	MinLength int
+	MaxLength int
+	// MinEntropyBits is the least estimated entropy accepted; zero disables
+	// the check.
+	MinEntropyBits float64
+	// Breaches, when set, rejects breached passwords. A check that fails is
+	// passed to OnBreachCheckError, if set, and the password is accepted, so
+	// an outage of the breach service does not block sign-ups.
+	Breaches           BreachChecker
+	OnBreachCheckError func(ctx context.Context, err error)
+}
+
+type Option func(*Config)
+
+func WithLength(minLength, maxLength int) Option {
+	return func(c *Config) {
+		c.MinLength = minLength
+		c.MaxLength = maxLength
+	}
+}
+
+func WithMinEntropy(bits float64) Option {
+	return func(c *Config) {
+		c.MinEntropyBits = bits
+	}
+}
+
+// WithBreachChecker rejects passwords checker reports as breached. onError
+// may be nil.
+func WithBreachChecker(checker BreachChecker, onError func(ctx context.Context, err error)) Option {
+	return func(c *Config) {
+		c.Breaches = checker
+		c.OnBreachCheckError = onError
+	}
+}
+
+// ApplyOptions defaults to 12 to 128 characters and 50 bits of estimated
+// entropy, with no breach check.
+func ApplyOptions(opts ...Option) *Config {
+	c := &Config{MinLength: 12, MaxLength: 128, MinEntropyBits: 50}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Algorithm is a password hashing algorithm.
+type Algorithm string
+
+const (
+	// AlgorithmArgon2id is Argon2id with the OWASP baseline parameters:
+	// 19 MiB of memory, 2 iterations and 1 lane.
+	AlgorithmArgon2id Algorithm = "argon2id"
+	// AlgorithmBcrypt is bcrypt at cost 12. It reads at most 72 bytes of a
+	// password and rejects longer ones.
+	AlgorithmBcrypt Algorithm = "bcrypt"
+)
+
+func ParseAlgorithm(s string) (Algorithm, error) {
+	switch algorithm := Algorithm(s); algorithm {
+	case AlgorithmArgon2id, AlgorithmBcrypt:
+		return algorithm, nil
+	}
+	return "", fmt.Errorf("unknown password hash algorithm %q", s)
+}
+
+// ErrUnknownHash is returned for a stored hash in no format a Hasher reads,
+// such as a password stored in plain text before hashing was introduced.
+var ErrUnknownHash = errors.New("unrecognised password hash")
+
+// Hasher turns passwords into the hashes stored for them and checks
+// passwords against those hashes.
+type Hasher interface {
+	// Hash returns password hashed with a fresh random salt, encoded with
+	// its algorithm and parameters.
+	Hash(password string) (string, error)
+	// Verify reports whether password matches hash. It reads hashes of
+	// every Algorithm, whichever Hash uses, and returns ErrUnknownHash for
+	// others.
+	Verify(password, hash string) (bool, error)
+}
@@ -0,0 +1,92 @@
+// Package pgclass classifies Postgres errors by SQLSTATE code so callers
+// never switch on pgconn.PgError codes themselves.
+package pgclass
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	codeUniqueViolation      = "23505"
+	codeForeignKeyViolation  = "23503"
+	codeCheckViolation       = "23514"
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+	codeConnectionFailure    = "08006"
+	codeUnableToConnect      = "08001" // sqlclient_unable_to_establish_sqlconnection
+	codeConnectionRejected   = "08004" // sqlserver_rejected_establishment_of_sqlconnection
+)
+
+// Code returns the SQLSTATE code of the first Postgres error in err's chain,
+// or "" if there is none.
+func Code(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// IsRetryable reports whether err is transient and the statement may succeed
+// if attempted again. Context cancellation and deadlines are never
+// retryable: the caller has already given up.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return IsSerializationFailure(err) || IsConnectionError(err)
+}
+
+// IsConflict reports whether err is a unique constraint violation, i.e. the
+// row being written already exists.
+func IsConflict(err error) bool {
+	return Code(err) == codeUniqueViolation
+}
+
+// IsForeignKeyViolation reports whether err is a foreign key violation, i.e.
+// the row being written refers to one that does not exist, or the row being
+// deleted is still referred to.
+func IsForeignKeyViolation(err error) bool {
+	return Code(err) == codeForeignKeyViolation
+}
+
+// IsCheckViolation reports whether err is a check constraint violation, i.e.
+// a value being written is out of its column's allowed range.
+func IsCheckViolation(err error) bool {
+	return Code(err) == codeCheckViolation
+}
+
+// Constraint returns the name of the constraint the first Postgres error in
+// err's chain violated, or "" if there is none.
+func Constraint(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+	return ""
+}
+
+// IsSerializationFailure reports whether the transaction lost a race with a
+// concurrent one, either a serialization failure or a deadlock.
+func IsSerializationFailure(err error) bool {
+	switch Code(err) {
+	case codeSerializationFailure, codeDeadlockDetected:
+		return true
+	}
+	return false
+}
+
+// IsConnectionError reports whether the connection to Postgres failed, was
+// refused or dropped, as reported by either the server or the network.
+func IsConnectionError(err error) bool {
+	switch Code(err) {
+	case codeConnectionFailure, codeUnableToConnect, codeConnectionRejected:
+		return true
+	}
+	var netErr *net.OpError
+	return errors.As(err, &netErr)
+}
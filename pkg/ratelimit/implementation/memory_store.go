@@ -0,0 +1,82 @@
+package implementation
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/ratelimit"
+)
+
+// memorySweepInterval is how often the memory store drops buckets that have
+// refilled completely.
+const memorySweepInterval = time.Minute
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket will have refilled, after which it is
+	// indistinguishable from a new one and can be dropped.
+	full time.Time
+}
+
+// memoryStore holds token buckets in memory, so each replica enforces its
+// own quota.
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() ratelimit.Store {
+	return &memoryStore{buckets: map[string]*memoryBucket{}}
+}
+
+func (s *memoryStore) Take(ctx context.Context, key string, bucket ratelimit.Bucket, now time.Time) (ratelimit.Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(bucket.Capacity), updated: now}
+		s.buckets[key] = b
+	}
+	// A clock that steps backwards refills nothing rather than draining.
+	if now.After(b.updated) {
+		b.tokens = math.Min(float64(bucket.Capacity), b.tokens+float64(now.Sub(b.updated))/float64(bucket.Interval))
+		b.updated = now
+	}
+
+	decision := ratelimit.Decision{Limit: bucket.Capacity}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = tokensDuration(1-b.tokens, bucket.Interval)
+	}
+	decision.Remaining = int(b.tokens)
+	decision.Reset = tokensDuration(float64(bucket.Capacity)-b.tokens, bucket.Interval)
+	b.full = b.updated.Add(decision.Reset)
+	return decision, nil
+}
+
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// tokensDuration is the time it takes to refill tokens, rounded up so
+// callers told to wait that long find the tokens there.
+func tokensDuration(tokens float64, interval time.Duration) time.Duration {
+	return time.Duration(math.Ceil(tokens * float64(interval)))
+}
@@ -0,0 +1,94 @@
+package implementation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/ratelimit"
+)
+
+// RedisEvaluator runs a Lua script on Redis and returns its reply, with
+// integers as int64 and arrays as []any. A go-redis client satisfies it
+// through a one-line adapter:
+//
+//	func (a redisAdapter) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+//		return a.client.Eval(ctx, script, keys, args...).Result()
+//	}
+type RedisEvaluator interface {
+	Eval(ctx context.Context, script string, keys []string, args ...any) (any, error)
+}
+
+// takeScript refills and takes from the bucket at KEYS[1] in one round trip;
+// Redis runs scripts atomically, so replicas cannot race on the same bucket.
+// Times are in microseconds. The key expires once the bucket has refilled,
+// since a missing bucket is treated as full.
+const takeScript = `
+local capacity = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1])
+local updated = tonumber(state[2])
+if tokens == nil or updated == nil then
+	tokens = capacity
+	updated = now
+end
+if now > updated then
+	tokens = math.min(capacity, tokens + (now - updated) / interval)
+	updated = now
+end
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * interval)
+end
+local reset = math.ceil((capacity - tokens) * interval)
+
+-- tostring keeps 14 digits, too few for a timestamp in microseconds.
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', string.format('%.0f', updated))
+redis.call('PEXPIRE', KEYS[1], math.max(1, math.ceil(reset / 1000)))
+return {allowed, math.floor(tokens), reset, retry}
+`
+
+// redisStore holds token buckets in Redis under prefix, so every replica
+// using the same Redis shares each caller's quota.
+type redisStore struct {
+	client RedisEvaluator
+	prefix string
+}
+
+func NewRedisStore(client RedisEvaluator, prefix string) ratelimit.Store {
+	return &redisStore{client: client, prefix: prefix}
+}
+
+func (s *redisStore) Take(ctx context.Context, key string, bucket ratelimit.Bucket, now time.Time) (ratelimit.Decision, error) {
+	reply, err := s.client.Eval(ctx, takeScript, []string{s.prefix + key},
+		bucket.Capacity, bucket.Interval.Microseconds(), now.UnixMicro())
+	if err != nil {
+		return ratelimit.Decision{}, fmt.Errorf("take token from redis: %w", err)
+	}
+
+	values, ok := reply.([]any)
+	if !ok || len(values) != 4 {
+		return ratelimit.Decision{}, fmt.Errorf("take token from redis: unexpected reply %v", reply)
+	}
+	var ints [4]int64
+	for i, value := range values {
+		if ints[i], ok = value.(int64); !ok {
+			return ratelimit.Decision{}, fmt.Errorf("take token from redis: unexpected reply %v", reply)
+		}
+	}
+	return ratelimit.Decision{
+		Allowed:    ints[0] == 1,
+		Limit:      bucket.Capacity,
+		Remaining:  int(ints[1]),
+		Reset:      time.Duration(ints[2]) * time.Microsecond,
+		RetryAfter: time.Duration(ints[3]) * time.Microsecond,
+	}, nil
+}
@@ -0,0 +1,30 @@
+package implementation
+
+import (
+	"context"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/ratelimit"
+)
+
+// tokenBucket lets each key make bursts of up to capacity requests, refilled
+// at one request per interval. Bucket state lives in store, so the limit is
+// per replica with a memory store and shared with a Redis store.
+type tokenBucket struct {
+	bucket ratelimit.Bucket
+	store  ratelimit.Store
+	clock  func() time.Time
+}
+
+func NewTokenBucket(capacity int, interval time.Duration, store ratelimit.Store, opts ...ratelimit.Option) ratelimit.Limiter {
+	c := ratelimit.ApplyOptions(opts...)
+	return &tokenBucket{
+		bucket: ratelimit.Bucket{Capacity: capacity, Interval: interval},
+		store:  store,
+		clock:  c.Clock,
+	}
+}
+
+func (l *tokenBucket) Allow(ctx context.Context, key string) (ratelimit.Decision, error) {
+	return l.store.Take(ctx, key, l.bucket, l.clock())
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Decision is the outcome of one request against a caller's quota.
+type Decision struct {
+	Allowed bool
+	// Limit is the number of requests the caller may make in a burst.
+	Limit int
+	// Remaining is the number of requests the caller may still make now.
+	Remaining int
+	// Reset is the time until the quota is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request would be allowed. It is
+	// zero when the request was allowed.
+	RetryAfter time.Duration
+}
+
+type Limiter interface {
+	// Allow consumes one request from key's quota. It fails only when the
+	// quota's store cannot be reached.
+	Allow(ctx context.Context, key string) (Decision, error)
+}
+
+// Bucket is a token bucket: it holds up to Capacity tokens, gains one every
+// Interval, and each request takes one.
+type Bucket struct {
+	Capacity int
+	Interval time.Duration
+}
+
+// Store holds token bucket state, so replicas sharing a store share quotas.
+type Store interface {
+	// Take refills key's bucket for the time elapsed up to now and takes one
+	// token from it, if one is left, as a single atomic step.
+	Take(ctx context.Context, key string, bucket Bucket, now time.Time) (Decision, error)
+}
+
+type Config struct {
+	Clock func() time.Time
+}
+
+type Option func(*Config)
+
+// WithClock replaces time.Now, e.g. to drive window expiry in tests.
+func WithClock(clock func() time.Time) Option {
+	return func(c *Config) {
+		c.Clock = clock
+	}
+}
+
+func ApplyOptions(opts ...Option) *Config {
+	c := &Config{Clock: time.Now}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
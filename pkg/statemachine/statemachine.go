@@ -0,0 +1,91 @@
+package statemachine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+)
+
+// ErrInvalidTransition is returned for a transition the machine does not
+// declare. It wraps apperror.ErrConflict.
+var ErrInvalidTransition = fmt.Errorf("invalid state transition: %w", apperror.ErrConflict)
+
+// Guard vetoes a declared transition for a particular subject by returning
+// an error.
+type Guard[T any] func(ctx context.Context, subject T) error
+
+// Hook runs after the subject's state has been set, e.g. to emit an event or
+// write an audit row in the same unit of work. An error fails the transition.
+type Hook[S comparable, T any] func(ctx context.Context, subject T, from, to S) error
+
+// Transition declares that a subject may move From one state To another.
+type Transition[S comparable, T any] struct {
+	From  S
+	To    S
+	Guard Guard[T]
+	Hooks []Hook[S, T]
+}
+
+type Machine[S comparable, T any] struct {
+	state       func(T) S
+	setState    func(T, S)
+	transitions map[S]map[S]Transition[S, T]
+	hooks       []Hook[S, T]
+}
+
+// New builds a machine that reads and writes the subject's state through
+// state and setState. Transitions not listed are rejected.
+func New[S comparable, T any](state func(T) S, setState func(T, S), transitions ...Transition[S, T]) *Machine[S, T] {
+	m := &Machine[S, T]{
+		state:       state,
+		setState:    setState,
+		transitions: map[S]map[S]Transition[S, T]{},
+	}
+	for _, t := range transitions {
+		if m.transitions[t.From] == nil {
+			m.transitions[t.From] = map[S]Transition[S, T]{}
+		}
+		m.transitions[t.From][t.To] = t
+	}
+	return m
+}
+
+// OnTransition registers a hook that runs after every transition, following
+// the transition's own hooks.
+func (m *Machine[S, T]) OnTransition(hook Hook[S, T]) {
+	m.hooks = append(m.hooks, hook)
+}
+
+// Can reports whether the machine declares a transition from one state to
+// another. Guards are not evaluated.
+func (m *Machine[S, T]) Can(from, to S) bool {
+	_, ok := m.transitions[from][to]
+	return ok
+}
+
+// Fire moves subject to state to. It checks the transition is declared,
+// runs its guard, sets the new state and runs the hooks. On error the
+// subject's state is restored.
+func (m *Machine[S, T]) Fire(ctx context.Context, subject T, to S) error {
+	from := m.state(subject)
+	t, ok := m.transitions[from][to]
+	if !ok {
+		return fmt.Errorf("%v -> %v: %w", from, to, ErrInvalidTransition)
+	}
+
+	if t.Guard != nil {
+		if err := t.Guard(ctx, subject); err != nil {
+			return err
+		}
+	}
+
+	m.setState(subject, to)
+	for _, hook := range append(t.Hooks[:len(t.Hooks):len(t.Hooks)], m.hooks...) {
+		if err := hook(ctx, subject, from, to); err != nil {
+			m.setState(subject, from)
+			return err
+		}
+	}
+	return nil
+}
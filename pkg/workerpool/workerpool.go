@@ -0,0 +1,177 @@
+// Package workerpool runs fire-and-forget tasks, such as event deliveries,
+// on a fixed number of workers fed by a bounded queue, so background work
+// cannot grow without limit and is finished rather than cut short on
+// shutdown.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+)
+
+// ErrClosed is returned by Submit once Close has been called.
+var ErrClosed = errors.New("worker pool closed")
+
+// Task is a unit of work. Its context carries the values of the context it
+// was submitted with but is never cancelled, so a task started before
+// shutdown runs to completion; tasks bound their own work with a timeout.
+type Task func(ctx context.Context)
+
+type Config struct {
+	// Name labels the pool's metrics and log lines.
+	Name string
+	// Workers is how many tasks run at once. Zero or less means one.
+	Workers int
+	// QueueSize is how many submitted tasks may wait for a worker before
+	// Submit blocks. Zero hands every task straight to an idle worker.
+	QueueSize int
+}
+
+// Metrics are the instruments every Pool records to, labelled by pool name.
+// Create them once per meter and share them between pools, since a metric
+// name can only be registered once.
+type Metrics struct {
+	queueDepth   observability.Gauge
+	queueWait    observability.Histogram
+	taskDuration observability.Histogram
+	panics       observability.Counter
+}
+
+func NewMetrics(meter observability.Meter) *Metrics {
+	return &Metrics{
+		queueDepth: meter.Gauge("workerpool_queue_depth", observability.MetricOpt{
+			Help:      "Number of tasks waiting for a worker",
+			LabelKeys: []string{"pool"},
+		}),
+		queueWait: meter.Histogram("workerpool_queue_wait_seconds", observability.MetricOpt{
+			Help:      "Time tasks waited in the queue before a worker picked them up, in seconds",
+			Preset:    observability.BucketsRPC,
+			LabelKeys: []string{"pool"},
+		}),
+		taskDuration: meter.Histogram("workerpool_task_duration_seconds", observability.MetricOpt{
+			Help:      "Duration of tasks in seconds",
+			Preset:    observability.BucketsRPC,
+			LabelKeys: []string{"pool"},
+		}),
+		panics: meter.Counter("workerpool_task_panics_total", observability.MetricOpt{
+			Help:      "Number of tasks that panicked",
+			LabelKeys: []string{"pool"},
+		}),
+	}
+}
+
+type job struct {
+	ctx    context.Context
+	task   Task
+	queued time.Time
+}
+
+// Pool runs submitted tasks on a fixed set of workers. A panicking task is
+// recovered and logged without taking its worker, or the process, down.
+type Pool struct {
+	label   observability.Label
+	metrics *Metrics
+	log     observability.Logger
+
+	// mu guards closed and the send side of jobs: Submit sends under the
+	// read lock, Close closes jobs under the write lock.
+	mu     sync.RWMutex
+	closed bool
+	jobs   chan job
+
+	workers sync.WaitGroup
+	done    chan struct{}
+}
+
+// New starts cfg.Workers workers and returns the pool feeding them.
+func New(cfg Config, metrics *Metrics, log observability.Logger) *Pool {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	queueSize := cfg.QueueSize
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	p := &Pool{
+		label:   observability.Label{Key: "pool", Value: cfg.Name},
+		metrics: metrics,
+		log:     log.With(observability.String("pool", cfg.Name)),
+		jobs:    make(chan job, queueSize),
+		done:    make(chan struct{}),
+	}
+	p.workers.Add(workers)
+	for range workers {
+		go p.work()
+	}
+	go func() {
+		p.workers.Wait()
+		close(p.done)
+	}()
+	return p
+}
+
+// Submit queues task, blocking while the queue is full so producers slow
+// down to the rate the workers keep up with. It returns ctx's error if ctx
+// ends first, and ErrClosed once the pool is closing; in both cases task
+// will not run.
+func (p *Pool) Submit(ctx context.Context, task Task) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	p.metrics.queueDepth.Add(1, p.label)
+	select {
+	case p.jobs <- job{ctx: context.WithoutCancel(ctx), task: task, queued: time.Now()}:
+		return nil
+	case <-ctx.Done():
+		p.metrics.queueDepth.Add(-1, p.label)
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting tasks and waits for the queued and running ones to
+// finish. If ctx ends first it returns ctx's error and the remaining tasks
+// finish in the background. Close may be called more than once.
+func (p *Pool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("draining worker pool: %w", ctx.Err())
+	}
+}
+
+func (p *Pool) work() {
+	defer p.workers.Done()
+	for j := range p.jobs {
+		p.metrics.queueDepth.Add(-1, p.label)
+		p.metrics.queueWait.Observe(time.Since(j.queued).Seconds(), p.label)
+		p.run(j)
+	}
+}
+
+func (p *Pool) run(j job) {
+	start := time.Now()
+	defer func() {
+		p.metrics.taskDuration.Observe(time.Since(start).Seconds(), p.label)
+		if r := recover(); r != nil {
+			p.metrics.panics.Inc(1, p.label)
+			p.log.Error("task panicked", observability.String("panic", fmt.Sprintf("%v", r)))
+		}
+	}()
+	j.task(j.ctx)
+}
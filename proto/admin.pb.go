@@ -0,0 +1,3099 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.4
+// source: admin.proto
+
+package v1
+
+import (
+	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DependencyState int32
+
+const (
+	DependencyState_DEPENDENCY_STATE_UNSPECIFIED DependencyState = 0
+	DependencyState_DEPENDENCY_STATE_UP          DependencyState = 1
+	DependencyState_DEPENDENCY_STATE_DOWN        DependencyState = 2
+)
+
+// Enum value maps for DependencyState.
+var (
+	DependencyState_name = map[int32]string{
+		0: "DEPENDENCY_STATE_UNSPECIFIED",
+		1: "DEPENDENCY_STATE_UP",
+		2: "DEPENDENCY_STATE_DOWN",
+	}
+	DependencyState_value = map[string]int32{
+		"DEPENDENCY_STATE_UNSPECIFIED": 0,
+		"DEPENDENCY_STATE_UP":          1,
+		"DEPENDENCY_STATE_DOWN":        2,
+	}
+)
+
+func (x DependencyState) Enum() *DependencyState {
+	p := new(DependencyState)
+	*p = x
+	return p
+}
+
+func (x DependencyState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DependencyState) Descriptor() protoreflect.EnumDescriptor {
+	return file_admin_proto_enumTypes[0].Descriptor()
+}
+
+func (DependencyState) Type() protoreflect.EnumType {
+	return &file_admin_proto_enumTypes[0]
+}
+
+func (x DependencyState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DependencyState.Descriptor instead.
+func (DependencyState) EnumDescriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{0}
+}
+
+type CircuitBreakerState int32
+
+const (
+	CircuitBreakerState_CIRCUIT_BREAKER_STATE_UNSPECIFIED CircuitBreakerState = 0
+	CircuitBreakerState_CIRCUIT_BREAKER_STATE_CLOSED      CircuitBreakerState = 1
+	CircuitBreakerState_CIRCUIT_BREAKER_STATE_HALF_OPEN   CircuitBreakerState = 2
+	CircuitBreakerState_CIRCUIT_BREAKER_STATE_OPEN        CircuitBreakerState = 3
+)
+
+// Enum value maps for CircuitBreakerState.
+var (
+	CircuitBreakerState_name = map[int32]string{
+		0: "CIRCUIT_BREAKER_STATE_UNSPECIFIED",
+		1: "CIRCUIT_BREAKER_STATE_CLOSED",
+		2: "CIRCUIT_BREAKER_STATE_HALF_OPEN",
+		3: "CIRCUIT_BREAKER_STATE_OPEN",
+	}
+	CircuitBreakerState_value = map[string]int32{
+		"CIRCUIT_BREAKER_STATE_UNSPECIFIED": 0,
+		"CIRCUIT_BREAKER_STATE_CLOSED":      1,
+		"CIRCUIT_BREAKER_STATE_HALF_OPEN":   2,
+		"CIRCUIT_BREAKER_STATE_OPEN":        3,
+	}
+)
+
+func (x CircuitBreakerState) Enum() *CircuitBreakerState {
+	p := new(CircuitBreakerState)
+	*p = x
+	return p
+}
+
+func (x CircuitBreakerState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CircuitBreakerState) Descriptor() protoreflect.EnumDescriptor {
+	return file_admin_proto_enumTypes[1].Descriptor()
+}
+
+func (CircuitBreakerState) Type() protoreflect.EnumType {
+	return &file_admin_proto_enumTypes[1]
+}
+
+func (x CircuitBreakerState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CircuitBreakerState.Descriptor instead.
+func (CircuitBreakerState) EnumDescriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{1}
+}
+
+type SchemaDriftKind int32
+
+const (
+	SchemaDriftKind_SCHEMA_DRIFT_KIND_UNSPECIFIED        SchemaDriftKind = 0
+	SchemaDriftKind_SCHEMA_DRIFT_KIND_MISSING_TABLE      SchemaDriftKind = 1
+	SchemaDriftKind_SCHEMA_DRIFT_KIND_MISSING_COLUMN     SchemaDriftKind = 2
+	SchemaDriftKind_SCHEMA_DRIFT_KIND_UNEXPECTED_COLUMN  SchemaDriftKind = 3
+	SchemaDriftKind_SCHEMA_DRIFT_KIND_COLUMN_TYPE        SchemaDriftKind = 4
+	SchemaDriftKind_SCHEMA_DRIFT_KIND_COLUMN_NULLABILITY SchemaDriftKind = 5
+	SchemaDriftKind_SCHEMA_DRIFT_KIND_MISSING_INDEX      SchemaDriftKind = 6
+	SchemaDriftKind_SCHEMA_DRIFT_KIND_UNEXPECTED_INDEX   SchemaDriftKind = 7
+)
+
+// Enum value maps for SchemaDriftKind.
+var (
+	SchemaDriftKind_name = map[int32]string{
+		0: "SCHEMA_DRIFT_KIND_UNSPECIFIED",
+		1: "SCHEMA_DRIFT_KIND_MISSING_TABLE",
+		2: "SCHEMA_DRIFT_KIND_MISSING_COLUMN",
+		3: "SCHEMA_DRIFT_KIND_UNEXPECTED_COLUMN",
+		4: "SCHEMA_DRIFT_KIND_COLUMN_TYPE",
+		5: "SCHEMA_DRIFT_KIND_COLUMN_NULLABILITY",
+		6: "SCHEMA_DRIFT_KIND_MISSING_INDEX",
+		7: "SCHEMA_DRIFT_KIND_UNEXPECTED_INDEX",
+	}
+	SchemaDriftKind_value = map[string]int32{
+		"SCHEMA_DRIFT_KIND_UNSPECIFIED":        0,
+		"SCHEMA_DRIFT_KIND_MISSING_TABLE":      1,
+		"SCHEMA_DRIFT_KIND_MISSING_COLUMN":     2,
+		"SCHEMA_DRIFT_KIND_UNEXPECTED_COLUMN":  3,
+		"SCHEMA_DRIFT_KIND_COLUMN_TYPE":        4,
+		"SCHEMA_DRIFT_KIND_COLUMN_NULLABILITY": 5,
+		"SCHEMA_DRIFT_KIND_MISSING_INDEX":      6,
+		"SCHEMA_DRIFT_KIND_UNEXPECTED_INDEX":   7,
+	}
+)
+
+func (x SchemaDriftKind) Enum() *SchemaDriftKind {
+	p := new(SchemaDriftKind)
+	*p = x
+	return p
+}
+
+func (x SchemaDriftKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SchemaDriftKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_admin_proto_enumTypes[2].Descriptor()
+}
+
+func (SchemaDriftKind) Type() protoreflect.EnumType {
+	return &file_admin_proto_enumTypes[2]
+}
+
+func (x SchemaDriftKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SchemaDriftKind.Descriptor instead.
+func (SchemaDriftKind) EnumDescriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{2}
+}
+
+type GetDependenciesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDependenciesRequest) Reset() {
+	*x = GetDependenciesRequest{}
+	mi := &file_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDependenciesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDependenciesRequest) ProtoMessage() {}
+
+func (x *GetDependenciesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDependenciesRequest.ProtoReflect.Descriptor instead.
+func (*GetDependenciesRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{0}
+}
+
+type GetDependenciesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dependencies  []*DependencyStatus    `protobuf:"bytes,1,rep,name=dependencies,proto3" json:"dependencies,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDependenciesResponse) Reset() {
+	*x = GetDependenciesResponse{}
+	mi := &file_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDependenciesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDependenciesResponse) ProtoMessage() {}
+
+func (x *GetDependenciesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDependenciesResponse.ProtoReflect.Descriptor instead.
+func (*GetDependenciesResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetDependenciesResponse) GetDependencies() []*DependencyStatus {
+	if x != nil {
+		return x.Dependencies
+	}
+	return nil
+}
+
+type DependencyStatus struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Name                string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	State               DependencyState        `protobuf:"varint,2,opt,name=state,proto3,enum=proto.v1.DependencyState" json:"state,omitempty"`
+	LastProbeLatency    *durationpb.Duration   `protobuf:"bytes,3,opt,name=last_probe_latency,json=lastProbeLatency,proto3" json:"last_probe_latency,omitempty"`
+	LastProbeAt         *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_probe_at,json=lastProbeAt,proto3" json:"last_probe_at,omitempty"`
+	CircuitBreakerState CircuitBreakerState    `protobuf:"varint,5,opt,name=circuit_breaker_state,json=circuitBreakerState,proto3,enum=proto.v1.CircuitBreakerState" json:"circuit_breaker_state,omitempty"`
+	LastError           string                 `protobuf:"bytes,6,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *DependencyStatus) Reset() {
+	*x = DependencyStatus{}
+	mi := &file_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DependencyStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DependencyStatus) ProtoMessage() {}
+
+func (x *DependencyStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DependencyStatus.ProtoReflect.Descriptor instead.
+func (*DependencyStatus) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DependencyStatus) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DependencyStatus) GetState() DependencyState {
+	if x != nil {
+		return x.State
+	}
+	return DependencyState_DEPENDENCY_STATE_UNSPECIFIED
+}
+
+func (x *DependencyStatus) GetLastProbeLatency() *durationpb.Duration {
+	if x != nil {
+		return x.LastProbeLatency
+	}
+	return nil
+}
+
+func (x *DependencyStatus) GetLastProbeAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastProbeAt
+	}
+	return nil
+}
+
+func (x *DependencyStatus) GetCircuitBreakerState() CircuitBreakerState {
+	if x != nil {
+		return x.CircuitBreakerState
+	}
+	return CircuitBreakerState_CIRCUIT_BREAKER_STATE_UNSPECIFIED
+}
+
+func (x *DependencyStatus) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+type DeadLetter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Source        string                 `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	EventType     string                 `protobuf:"bytes,3,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	Payload       string                 `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	LastError     string                 `protobuf:"bytes,5,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	Attempts      int32                  `protobuf:"varint,6,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ReplayedAt    *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=replayed_at,json=replayedAt,proto3" json:"replayed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeadLetter) Reset() {
+	*x = DeadLetter{}
+	mi := &file_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetter) ProtoMessage() {}
+
+func (x *DeadLetter) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetter.ProtoReflect.Descriptor instead.
+func (*DeadLetter) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DeadLetter) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeadLetter) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetPayload() string {
+	if x != nil {
+		return x.Payload
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *DeadLetter) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *DeadLetter) GetReplayedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReplayedAt
+	}
+	return nil
+}
+
+type ListDeadLettersRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Filters by delivery pipeline, e.g. "outbox", "webhook" or "consumer". Empty lists all.
+	Source      string `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	PendingOnly bool   `protobuf:"varint,2,opt,name=pending_only,json=pendingOnly,proto3" json:"pending_only,omitempty"`
+	// Cursor: the next_after_id of the previous page.
+	AfterId       int64 `protobuf:"varint,3,opt,name=after_id,json=afterId,proto3" json:"after_id,omitempty"`
+	PageSize      int32 `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeadLettersRequest) Reset() {
+	*x = ListDeadLettersRequest{}
+	mi := &file_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeadLettersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeadLettersRequest) ProtoMessage() {}
+
+func (x *ListDeadLettersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeadLettersRequest.ProtoReflect.Descriptor instead.
+func (*ListDeadLettersRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListDeadLettersRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ListDeadLettersRequest) GetPendingOnly() bool {
+	if x != nil {
+		return x.PendingOnly
+	}
+	return false
+}
+
+func (x *ListDeadLettersRequest) GetAfterId() int64 {
+	if x != nil {
+		return x.AfterId
+	}
+	return 0
+}
+
+func (x *ListDeadLettersRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+type ListDeadLettersResponse struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	DeadLetters []*DeadLetter          `protobuf:"bytes,1,rep,name=dead_letters,json=deadLetters,proto3" json:"dead_letters,omitempty"`
+	// Zero when there are no further pages.
+	NextAfterId   int64 `protobuf:"varint,2,opt,name=next_after_id,json=nextAfterId,proto3" json:"next_after_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeadLettersResponse) Reset() {
+	*x = ListDeadLettersResponse{}
+	mi := &file_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeadLettersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeadLettersResponse) ProtoMessage() {}
+
+func (x *ListDeadLettersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeadLettersResponse.ProtoReflect.Descriptor instead.
+func (*ListDeadLettersResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListDeadLettersResponse) GetDeadLetters() []*DeadLetter {
+	if x != nil {
+		return x.DeadLetters
+	}
+	return nil
+}
+
+func (x *ListDeadLettersResponse) GetNextAfterId() int64 {
+	if x != nil {
+		return x.NextAfterId
+	}
+	return 0
+}
+
+type GetDeadLetterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeadLetterRequest) Reset() {
+	*x = GetDeadLetterRequest{}
+	mi := &file_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeadLetterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeadLetterRequest) ProtoMessage() {}
+
+func (x *GetDeadLetterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeadLetterRequest.ProtoReflect.Descriptor instead.
+func (*GetDeadLetterRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetDeadLetterRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type GetDeadLetterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeadLetter    *DeadLetter            `protobuf:"bytes,1,opt,name=dead_letter,json=deadLetter,proto3" json:"dead_letter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeadLetterResponse) Reset() {
+	*x = GetDeadLetterResponse{}
+	mi := &file_admin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeadLetterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeadLetterResponse) ProtoMessage() {}
+
+func (x *GetDeadLetterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeadLetterResponse.ProtoReflect.Descriptor instead.
+func (*GetDeadLetterResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetDeadLetterResponse) GetDeadLetter() *DeadLetter {
+	if x != nil {
+		return x.DeadLetter
+	}
+	return nil
+}
+
+type ReplayDeadLetterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplayDeadLetterRequest) Reset() {
+	*x = ReplayDeadLetterRequest{}
+	mi := &file_admin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayDeadLetterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayDeadLetterRequest) ProtoMessage() {}
+
+func (x *ReplayDeadLetterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayDeadLetterRequest.ProtoReflect.Descriptor instead.
+func (*ReplayDeadLetterRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReplayDeadLetterRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ReplayDeadLetterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeadLetter    *DeadLetter            `protobuf:"bytes,1,opt,name=dead_letter,json=deadLetter,proto3" json:"dead_letter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplayDeadLetterResponse) Reset() {
+	*x = ReplayDeadLetterResponse{}
+	mi := &file_admin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayDeadLetterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayDeadLetterResponse) ProtoMessage() {}
+
+func (x *ReplayDeadLetterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayDeadLetterResponse.ProtoReflect.Descriptor instead.
+func (*ReplayDeadLetterResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ReplayDeadLetterResponse) GetDeadLetter() *DeadLetter {
+	if x != nil {
+		return x.DeadLetter
+	}
+	return nil
+}
+
+type SuspendUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IdempotencyId int64                  `protobuf:"varint,1,opt,name=idempotency_id,json=idempotencyId,proto3" json:"idempotency_id,omitempty"`
+	UserId        int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuspendUserRequest) Reset() {
+	*x = SuspendUserRequest{}
+	mi := &file_admin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuspendUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuspendUserRequest) ProtoMessage() {}
+
+func (x *SuspendUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuspendUserRequest.ProtoReflect.Descriptor instead.
+func (*SuspendUserRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SuspendUserRequest) GetIdempotencyId() int64 {
+	if x != nil {
+		return x.IdempotencyId
+	}
+	return 0
+}
+
+func (x *SuspendUserRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *SuspendUserRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type SuspendUserResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	UserId    int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status    UserStatus             `protobuf:"varint,2,opt,name=status,proto3,enum=proto.v1.UserStatus" json:"status,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Actors that created and last updated the user, e.g. "api_key:partner-a".
+	// Set by the server from the authenticated caller.
+	CreatedBy     string `protobuf:"bytes,4,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	UpdatedBy     string `protobuf:"bytes,5,opt,name=updated_by,json=updatedBy,proto3" json:"updated_by,omitempty"`
+	Version       int64  `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuspendUserResponse) Reset() {
+	*x = SuspendUserResponse{}
+	mi := &file_admin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuspendUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuspendUserResponse) ProtoMessage() {}
+
+func (x *SuspendUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuspendUserResponse.ProtoReflect.Descriptor instead.
+func (*SuspendUserResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SuspendUserResponse) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *SuspendUserResponse) GetStatus() UserStatus {
+	if x != nil {
+		return x.Status
+	}
+	return UserStatus_USER_STATUS_UNSPECIFIED
+}
+
+func (x *SuspendUserResponse) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *SuspendUserResponse) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *SuspendUserResponse) GetUpdatedBy() string {
+	if x != nil {
+		return x.UpdatedBy
+	}
+	return ""
+}
+
+func (x *SuspendUserResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ReactivateUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IdempotencyId int64                  `protobuf:"varint,1,opt,name=idempotency_id,json=idempotencyId,proto3" json:"idempotency_id,omitempty"`
+	UserId        int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReactivateUserRequest) Reset() {
+	*x = ReactivateUserRequest{}
+	mi := &file_admin_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReactivateUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReactivateUserRequest) ProtoMessage() {}
+
+func (x *ReactivateUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReactivateUserRequest.ProtoReflect.Descriptor instead.
+func (*ReactivateUserRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ReactivateUserRequest) GetIdempotencyId() int64 {
+	if x != nil {
+		return x.IdempotencyId
+	}
+	return 0
+}
+
+func (x *ReactivateUserRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ReactivateUserRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ReactivateUserResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	UserId    int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status    UserStatus             `protobuf:"varint,2,opt,name=status,proto3,enum=proto.v1.UserStatus" json:"status,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Actors that created and last updated the user, e.g. "api_key:partner-a".
+	// Set by the server from the authenticated caller.
+	CreatedBy     string `protobuf:"bytes,4,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	UpdatedBy     string `protobuf:"bytes,5,opt,name=updated_by,json=updatedBy,proto3" json:"updated_by,omitempty"`
+	Version       int64  `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReactivateUserResponse) Reset() {
+	*x = ReactivateUserResponse{}
+	mi := &file_admin_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReactivateUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReactivateUserResponse) ProtoMessage() {}
+
+func (x *ReactivateUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReactivateUserResponse.ProtoReflect.Descriptor instead.
+func (*ReactivateUserResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ReactivateUserResponse) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ReactivateUserResponse) GetStatus() UserStatus {
+	if x != nil {
+		return x.Status
+	}
+	return UserStatus_USER_STATUS_UNSPECIFIED
+}
+
+func (x *ReactivateUserResponse) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *ReactivateUserResponse) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *ReactivateUserResponse) GetUpdatedBy() string {
+	if x != nil {
+		return x.UpdatedBy
+	}
+	return ""
+}
+
+func (x *ReactivateUserResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type UnlockUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnlockUserRequest) Reset() {
+	*x = UnlockUserRequest{}
+	mi := &file_admin_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnlockUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnlockUserRequest) ProtoMessage() {}
+
+func (x *UnlockUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnlockUserRequest.ProtoReflect.Descriptor instead.
+func (*UnlockUserRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UnlockUserRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type UnlockUserResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of IPs the user had failed logins from.
+	ClearedIps    int64 `protobuf:"varint,1,opt,name=cleared_ips,json=clearedIps,proto3" json:"cleared_ips,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnlockUserResponse) Reset() {
+	*x = UnlockUserResponse{}
+	mi := &file_admin_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnlockUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnlockUserResponse) ProtoMessage() {}
+
+func (x *UnlockUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnlockUserResponse.ProtoReflect.Descriptor instead.
+func (*UnlockUserResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *UnlockUserResponse) GetClearedIps() int64 {
+	if x != nil {
+		return x.ClearedIps
+	}
+	return 0
+}
+
+type UserIdentity struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Must match the token's iss claim exactly.
+	Issuer        string                 `protobuf:"bytes,1,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	Subject       string                 `protobuf:"bytes,2,opt,name=subject,proto3" json:"subject,omitempty"`
+	UserId        int64                  `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	CreatedBy     string                 `protobuf:"bytes,5,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserIdentity) Reset() {
+	*x = UserIdentity{}
+	mi := &file_admin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserIdentity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserIdentity) ProtoMessage() {}
+
+func (x *UserIdentity) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserIdentity.ProtoReflect.Descriptor instead.
+func (*UserIdentity) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *UserIdentity) GetIssuer() string {
+	if x != nil {
+		return x.Issuer
+	}
+	return ""
+}
+
+func (x *UserIdentity) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *UserIdentity) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *UserIdentity) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *UserIdentity) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+type LinkUserIdentityRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Issuer        string                 `protobuf:"bytes,2,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	Subject       string                 `protobuf:"bytes,3,opt,name=subject,proto3" json:"subject,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkUserIdentityRequest) Reset() {
+	*x = LinkUserIdentityRequest{}
+	mi := &file_admin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkUserIdentityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkUserIdentityRequest) ProtoMessage() {}
+
+func (x *LinkUserIdentityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkUserIdentityRequest.ProtoReflect.Descriptor instead.
+func (*LinkUserIdentityRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *LinkUserIdentityRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *LinkUserIdentityRequest) GetIssuer() string {
+	if x != nil {
+		return x.Issuer
+	}
+	return ""
+}
+
+func (x *LinkUserIdentityRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+type LinkUserIdentityResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Identity      *UserIdentity          `protobuf:"bytes,1,opt,name=identity,proto3" json:"identity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkUserIdentityResponse) Reset() {
+	*x = LinkUserIdentityResponse{}
+	mi := &file_admin_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkUserIdentityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkUserIdentityResponse) ProtoMessage() {}
+
+func (x *LinkUserIdentityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkUserIdentityResponse.ProtoReflect.Descriptor instead.
+func (*LinkUserIdentityResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *LinkUserIdentityResponse) GetIdentity() *UserIdentity {
+	if x != nil {
+		return x.Identity
+	}
+	return nil
+}
+
+type UnlinkUserIdentityRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Issuer        string                 `protobuf:"bytes,1,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	Subject       string                 `protobuf:"bytes,2,opt,name=subject,proto3" json:"subject,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnlinkUserIdentityRequest) Reset() {
+	*x = UnlinkUserIdentityRequest{}
+	mi := &file_admin_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnlinkUserIdentityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnlinkUserIdentityRequest) ProtoMessage() {}
+
+func (x *UnlinkUserIdentityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnlinkUserIdentityRequest.ProtoReflect.Descriptor instead.
+func (*UnlinkUserIdentityRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *UnlinkUserIdentityRequest) GetIssuer() string {
+	if x != nil {
+		return x.Issuer
+	}
+	return ""
+}
+
+func (x *UnlinkUserIdentityRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+type UnlinkUserIdentityResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// False when the subject was not linked.
+	Unlinked      bool `protobuf:"varint,1,opt,name=unlinked,proto3" json:"unlinked,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnlinkUserIdentityResponse) Reset() {
+	*x = UnlinkUserIdentityResponse{}
+	mi := &file_admin_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnlinkUserIdentityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnlinkUserIdentityResponse) ProtoMessage() {}
+
+func (x *UnlinkUserIdentityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnlinkUserIdentityResponse.ProtoReflect.Descriptor instead.
+func (*UnlinkUserIdentityResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *UnlinkUserIdentityResponse) GetUnlinked() bool {
+	if x != nil {
+		return x.Unlinked
+	}
+	return false
+}
+
+type GetConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetConfigRequest) Reset() {
+	*x = GetConfigRequest{}
+	mi := &file_admin_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConfigRequest) ProtoMessage() {}
+
+func (x *GetConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConfigRequest.ProtoReflect.Descriptor instead.
+func (*GetConfigRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{21}
+}
+
+type GetConfigResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartedAt     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	Entries       []*ConfigEntry         `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetConfigResponse) Reset() {
+	*x = GetConfigResponse{}
+	mi := &file_admin_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConfigResponse) ProtoMessage() {}
+
+func (x *GetConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConfigResponse.ProtoReflect.Descriptor instead.
+func (*GetConfigResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *GetConfigResponse) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *GetConfigResponse) GetEntries() []*ConfigEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type ConfigEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// Set when secrets were masked out of value.
+	Redacted      bool `protobuf:"varint,3,opt,name=redacted,proto3" json:"redacted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfigEntry) Reset() {
+	*x = ConfigEntry{}
+	mi := &file_admin_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigEntry) ProtoMessage() {}
+
+func (x *ConfigEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigEntry.ProtoReflect.Descriptor instead.
+func (*ConfigEntry) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ConfigEntry) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ConfigEntry) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *ConfigEntry) GetRedacted() bool {
+	if x != nil {
+		return x.Redacted
+	}
+	return false
+}
+
+type CheckSchemaDriftRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckSchemaDriftRequest) Reset() {
+	*x = CheckSchemaDriftRequest{}
+	mi := &file_admin_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckSchemaDriftRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckSchemaDriftRequest) ProtoMessage() {}
+
+func (x *CheckSchemaDriftRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckSchemaDriftRequest.ProtoReflect.Descriptor instead.
+func (*CheckSchemaDriftRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{24}
+}
+
+type CheckSchemaDriftResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Empty when every store matches its migrations.
+	Drifts        []*SchemaDrift `protobuf:"bytes,1,rep,name=drifts,proto3" json:"drifts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckSchemaDriftResponse) Reset() {
+	*x = CheckSchemaDriftResponse{}
+	mi := &file_admin_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckSchemaDriftResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckSchemaDriftResponse) ProtoMessage() {}
+
+func (x *CheckSchemaDriftResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckSchemaDriftResponse.ProtoReflect.Descriptor instead.
+func (*CheckSchemaDriftResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *CheckSchemaDriftResponse) GetDrifts() []*SchemaDrift {
+	if x != nil {
+		return x.Drifts
+	}
+	return nil
+}
+
+type SchemaDrift struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Store string                 `protobuf:"bytes,1,opt,name=store,proto3" json:"store,omitempty"`
+	// Table, "table.column" or index name.
+	Object        string          `protobuf:"bytes,2,opt,name=object,proto3" json:"object,omitempty"`
+	Kind          SchemaDriftKind `protobuf:"varint,3,opt,name=kind,proto3,enum=proto.v1.SchemaDriftKind" json:"kind,omitempty"`
+	Expected      string          `protobuf:"bytes,4,opt,name=expected,proto3" json:"expected,omitempty"`
+	Actual        string          `protobuf:"bytes,5,opt,name=actual,proto3" json:"actual,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SchemaDrift) Reset() {
+	*x = SchemaDrift{}
+	mi := &file_admin_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SchemaDrift) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SchemaDrift) ProtoMessage() {}
+
+func (x *SchemaDrift) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SchemaDrift.ProtoReflect.Descriptor instead.
+func (*SchemaDrift) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *SchemaDrift) GetStore() string {
+	if x != nil {
+		return x.Store
+	}
+	return ""
+}
+
+func (x *SchemaDrift) GetObject() string {
+	if x != nil {
+		return x.Object
+	}
+	return ""
+}
+
+func (x *SchemaDrift) GetKind() SchemaDriftKind {
+	if x != nil {
+		return x.Kind
+	}
+	return SchemaDriftKind_SCHEMA_DRIFT_KIND_UNSPECIFIED
+}
+
+func (x *SchemaDrift) GetExpected() string {
+	if x != nil {
+		return x.Expected
+	}
+	return ""
+}
+
+func (x *SchemaDrift) GetActual() string {
+	if x != nil {
+		return x.Actual
+	}
+	return ""
+}
+
+type QuarantineIdempotencyRecordRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Why the record is quarantined, kept with it.
+	Reason        string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuarantineIdempotencyRecordRequest) Reset() {
+	*x = QuarantineIdempotencyRecordRequest{}
+	mi := &file_admin_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuarantineIdempotencyRecordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuarantineIdempotencyRecordRequest) ProtoMessage() {}
+
+func (x *QuarantineIdempotencyRecordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuarantineIdempotencyRecordRequest.ProtoReflect.Descriptor instead.
+func (*QuarantineIdempotencyRecordRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *QuarantineIdempotencyRecordRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *QuarantineIdempotencyRecordRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type QuarantineIdempotencyRecordResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// False when there was no record with the id.
+	Quarantined   bool `protobuf:"varint,1,opt,name=quarantined,proto3" json:"quarantined,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuarantineIdempotencyRecordResponse) Reset() {
+	*x = QuarantineIdempotencyRecordResponse{}
+	mi := &file_admin_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuarantineIdempotencyRecordResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuarantineIdempotencyRecordResponse) ProtoMessage() {}
+
+func (x *QuarantineIdempotencyRecordResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuarantineIdempotencyRecordResponse.ProtoReflect.Descriptor instead.
+func (*QuarantineIdempotencyRecordResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *QuarantineIdempotencyRecordResponse) GetQuarantined() bool {
+	if x != nil {
+		return x.Quarantined
+	}
+	return false
+}
+
+type IdempotencyRecord struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	RequestType string                 `protobuf:"bytes,2,opt,name=request_type,json=requestType,proto3" json:"request_type,omitempty"`
+	ReferenceId int64                  `protobuf:"varint,3,opt,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
+	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Set on quarantined records only.
+	QuarantinedAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=quarantined_at,json=quarantinedAt,proto3" json:"quarantined_at,omitempty"`
+	Reason        string                 `protobuf:"bytes,7,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IdempotencyRecord) Reset() {
+	*x = IdempotencyRecord{}
+	mi := &file_admin_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IdempotencyRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IdempotencyRecord) ProtoMessage() {}
+
+func (x *IdempotencyRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IdempotencyRecord.ProtoReflect.Descriptor instead.
+func (*IdempotencyRecord) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *IdempotencyRecord) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *IdempotencyRecord) GetRequestType() string {
+	if x != nil {
+		return x.RequestType
+	}
+	return ""
+}
+
+func (x *IdempotencyRecord) GetReferenceId() int64 {
+	if x != nil {
+		return x.ReferenceId
+	}
+	return 0
+}
+
+func (x *IdempotencyRecord) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *IdempotencyRecord) GetQuarantinedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.QuarantinedAt
+	}
+	return nil
+}
+
+func (x *IdempotencyRecord) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type CorruptIdempotencyRecord struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Record *IdempotencyRecord     `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`
+	// Why the response does not decode.
+	Error         string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CorruptIdempotencyRecord) Reset() {
+	*x = CorruptIdempotencyRecord{}
+	mi := &file_admin_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CorruptIdempotencyRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CorruptIdempotencyRecord) ProtoMessage() {}
+
+func (x *CorruptIdempotencyRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CorruptIdempotencyRecord.ProtoReflect.Descriptor instead.
+func (*CorruptIdempotencyRecord) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *CorruptIdempotencyRecord) GetRecord() *IdempotencyRecord {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *CorruptIdempotencyRecord) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ListCorruptIdempotencyRecordsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Cursor: the next_after_id of the previous page.
+	AfterId int64 `protobuf:"varint,1,opt,name=after_id,json=afterId,proto3" json:"after_id,omitempty"`
+	// How many records to check, not how many to return.
+	PageSize      int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCorruptIdempotencyRecordsRequest) Reset() {
+	*x = ListCorruptIdempotencyRecordsRequest{}
+	mi := &file_admin_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCorruptIdempotencyRecordsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCorruptIdempotencyRecordsRequest) ProtoMessage() {}
+
+func (x *ListCorruptIdempotencyRecordsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCorruptIdempotencyRecordsRequest.ProtoReflect.Descriptor instead.
+func (*ListCorruptIdempotencyRecordsRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ListCorruptIdempotencyRecordsRequest) GetAfterId() int64 {
+	if x != nil {
+		return x.AfterId
+	}
+	return 0
+}
+
+func (x *ListCorruptIdempotencyRecordsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+type ListCorruptIdempotencyRecordsResponse struct {
+	state   protoimpl.MessageState      `protogen:"open.v1"`
+	Records []*CorruptIdempotencyRecord `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	// Zero when every record has been checked.
+	NextAfterId   int64 `protobuf:"varint,2,opt,name=next_after_id,json=nextAfterId,proto3" json:"next_after_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCorruptIdempotencyRecordsResponse) Reset() {
+	*x = ListCorruptIdempotencyRecordsResponse{}
+	mi := &file_admin_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCorruptIdempotencyRecordsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCorruptIdempotencyRecordsResponse) ProtoMessage() {}
+
+func (x *ListCorruptIdempotencyRecordsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCorruptIdempotencyRecordsResponse.ProtoReflect.Descriptor instead.
+func (*ListCorruptIdempotencyRecordsResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ListCorruptIdempotencyRecordsResponse) GetRecords() []*CorruptIdempotencyRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+func (x *ListCorruptIdempotencyRecordsResponse) GetNextAfterId() int64 {
+	if x != nil {
+		return x.NextAfterId
+	}
+	return 0
+}
+
+type ListQuarantinedIdempotencyRecordsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Cursor: the next_after_id of the previous page.
+	AfterId       int64 `protobuf:"varint,1,opt,name=after_id,json=afterId,proto3" json:"after_id,omitempty"`
+	PageSize      int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListQuarantinedIdempotencyRecordsRequest) Reset() {
+	*x = ListQuarantinedIdempotencyRecordsRequest{}
+	mi := &file_admin_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListQuarantinedIdempotencyRecordsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListQuarantinedIdempotencyRecordsRequest) ProtoMessage() {}
+
+func (x *ListQuarantinedIdempotencyRecordsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListQuarantinedIdempotencyRecordsRequest.ProtoReflect.Descriptor instead.
+func (*ListQuarantinedIdempotencyRecordsRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ListQuarantinedIdempotencyRecordsRequest) GetAfterId() int64 {
+	if x != nil {
+		return x.AfterId
+	}
+	return 0
+}
+
+func (x *ListQuarantinedIdempotencyRecordsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+type ListQuarantinedIdempotencyRecordsResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Records []*IdempotencyRecord   `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	// Zero when there are no further pages.
+	NextAfterId   int64 `protobuf:"varint,2,opt,name=next_after_id,json=nextAfterId,proto3" json:"next_after_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListQuarantinedIdempotencyRecordsResponse) Reset() {
+	*x = ListQuarantinedIdempotencyRecordsResponse{}
+	mi := &file_admin_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListQuarantinedIdempotencyRecordsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListQuarantinedIdempotencyRecordsResponse) ProtoMessage() {}
+
+func (x *ListQuarantinedIdempotencyRecordsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListQuarantinedIdempotencyRecordsResponse.ProtoReflect.Descriptor instead.
+func (*ListQuarantinedIdempotencyRecordsResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ListQuarantinedIdempotencyRecordsResponse) GetRecords() []*IdempotencyRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+func (x *ListQuarantinedIdempotencyRecordsResponse) GetNextAfterId() int64 {
+	if x != nil {
+		return x.NextAfterId
+	}
+	return 0
+}
+
+type RepairIdempotencyRecordRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RepairIdempotencyRecordRequest) Reset() {
+	*x = RepairIdempotencyRecordRequest{}
+	mi := &file_admin_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RepairIdempotencyRecordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RepairIdempotencyRecordRequest) ProtoMessage() {}
+
+func (x *RepairIdempotencyRecordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RepairIdempotencyRecordRequest.ProtoReflect.Descriptor instead.
+func (*RepairIdempotencyRecordRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *RepairIdempotencyRecordRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type RepairIdempotencyRecordResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The restored record with its rebuilt response.
+	Record        *IdempotencyRecord `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RepairIdempotencyRecordResponse) Reset() {
+	*x = RepairIdempotencyRecordResponse{}
+	mi := &file_admin_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RepairIdempotencyRecordResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RepairIdempotencyRecordResponse) ProtoMessage() {}
+
+func (x *RepairIdempotencyRecordResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RepairIdempotencyRecordResponse.ProtoReflect.Descriptor instead.
+func (*RepairIdempotencyRecordResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *RepairIdempotencyRecordResponse) GetRecord() *IdempotencyRecord {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+type Role struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Permissions   []string               `protobuf:"bytes,2,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	CreatedBy     string                 `protobuf:"bytes,4,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Role) Reset() {
+	*x = Role{}
+	mi := &file_admin_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Role) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Role) ProtoMessage() {}
+
+func (x *Role) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Role.ProtoReflect.Descriptor instead.
+func (*Role) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *Role) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Role) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+func (x *Role) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Role) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+type PutRoleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Permissions   []string               `protobuf:"bytes,2,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PutRoleRequest) Reset() {
+	*x = PutRoleRequest{}
+	mi := &file_admin_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutRoleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutRoleRequest) ProtoMessage() {}
+
+func (x *PutRoleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutRoleRequest.ProtoReflect.Descriptor instead.
+func (*PutRoleRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *PutRoleRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PutRoleRequest) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+type PutRoleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Role          *Role                  `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PutRoleResponse) Reset() {
+	*x = PutRoleResponse{}
+	mi := &file_admin_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutRoleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutRoleResponse) ProtoMessage() {}
+
+func (x *PutRoleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutRoleResponse.ProtoReflect.Descriptor instead.
+func (*PutRoleResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *PutRoleResponse) GetRole() *Role {
+	if x != nil {
+		return x.Role
+	}
+	return nil
+}
+
+type ListRolesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 0 lists every defined role.
+	UserId        int64 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRolesRequest) Reset() {
+	*x = ListRolesRequest{}
+	mi := &file_admin_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRolesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRolesRequest) ProtoMessage() {}
+
+func (x *ListRolesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRolesRequest.ProtoReflect.Descriptor instead.
+func (*ListRolesRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ListRolesRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type ListRolesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Roles         []*Role                `protobuf:"bytes,1,rep,name=roles,proto3" json:"roles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRolesResponse) Reset() {
+	*x = ListRolesResponse{}
+	mi := &file_admin_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRolesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRolesResponse) ProtoMessage() {}
+
+func (x *ListRolesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRolesResponse.ProtoReflect.Descriptor instead.
+func (*ListRolesResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ListRolesResponse) GetRoles() []*Role {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+type AssignRoleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Role          string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssignRoleRequest) Reset() {
+	*x = AssignRoleRequest{}
+	mi := &file_admin_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssignRoleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssignRoleRequest) ProtoMessage() {}
+
+func (x *AssignRoleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssignRoleRequest.ProtoReflect.Descriptor instead.
+func (*AssignRoleRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *AssignRoleRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *AssignRoleRequest) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+type AssignRoleResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// False when the user already held the role.
+	Assigned      bool `protobuf:"varint,1,opt,name=assigned,proto3" json:"assigned,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssignRoleResponse) Reset() {
+	*x = AssignRoleResponse{}
+	mi := &file_admin_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssignRoleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssignRoleResponse) ProtoMessage() {}
+
+func (x *AssignRoleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssignRoleResponse.ProtoReflect.Descriptor instead.
+func (*AssignRoleResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *AssignRoleResponse) GetAssigned() bool {
+	if x != nil {
+		return x.Assigned
+	}
+	return false
+}
+
+type UnassignRoleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Role          string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnassignRoleRequest) Reset() {
+	*x = UnassignRoleRequest{}
+	mi := &file_admin_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnassignRoleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnassignRoleRequest) ProtoMessage() {}
+
+func (x *UnassignRoleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnassignRoleRequest.ProtoReflect.Descriptor instead.
+func (*UnassignRoleRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *UnassignRoleRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *UnassignRoleRequest) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+type UnassignRoleResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// False when the user did not hold the role.
+	Unassigned    bool `protobuf:"varint,1,opt,name=unassigned,proto3" json:"unassigned,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnassignRoleResponse) Reset() {
+	*x = UnassignRoleResponse{}
+	mi := &file_admin_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnassignRoleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnassignRoleResponse) ProtoMessage() {}
+
+func (x *UnassignRoleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnassignRoleResponse.ProtoReflect.Descriptor instead.
+func (*UnassignRoleResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *UnassignRoleResponse) GetUnassigned() bool {
+	if x != nil {
+		return x.Unassigned
+	}
+	return false
+}
+
+var File_admin_proto protoreflect.FileDescriptor
+
+const file_admin_proto_rawDesc = "" +
+	"\n" +
+	"\vadmin.proto\x12\bproto.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\n" +
+	"user.proto\"\x18\n" +
+	"\x16GetDependenciesRequest\"Y\n" +
+	"\x17GetDependenciesResponse\x12>\n" +
+	"\fdependencies\x18\x01 \x03(\v2\x1a.proto.v1.DependencyStatusR\fdependencies\"\xd2\x02\n" +
+	"\x10DependencyStatus\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12/\n" +
+	"\x05state\x18\x02 \x01(\x0e2\x19.proto.v1.DependencyStateR\x05state\x12G\n" +
+	"\x12last_probe_latency\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\x10lastProbeLatency\x12>\n" +
+	"\rlast_probe_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vlastProbeAt\x12Q\n" +
+	"\x15circuit_breaker_state\x18\x05 \x01(\x0e2\x1d.proto.v1.CircuitBreakerStateR\x13circuitBreakerState\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x06 \x01(\tR\tlastError\"\xa0\x02\n" +
+	"\n" +
+	"DeadLetter\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x03 \x01(\tR\teventType\x12\x18\n" +
+	"\apayload\x18\x04 \x01(\tR\apayload\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x05 \x01(\tR\tlastError\x12\x1a\n" +
+	"\battempts\x18\x06 \x01(\x05R\battempts\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12;\n" +
+	"\vreplayed_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"replayedAt\"\xa0\x01\n" +
+	"\x16ListDeadLettersRequest\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12!\n" +
+	"\fpending_only\x18\x02 \x01(\bR\vpendingOnly\x12\"\n" +
+	"\bafter_id\x18\x03 \x01(\x03B\a\xbaH\x04\"\x02(\x00R\aafterId\x12'\n" +
+	"\tpage_size\x18\x04 \x01(\x05B\n" +
+	"\xbaH\a\x1a\x05\x18\xf4\x03(\x00R\bpageSize\"v\n" +
+	"\x17ListDeadLettersResponse\x127\n" +
+	"\fdead_letters\x18\x01 \x03(\v2\x14.proto.v1.DeadLetterR\vdeadLetters\x12\"\n" +
+	"\rnext_after_id\x18\x02 \x01(\x03R\vnextAfterId\"/\n" +
+	"\x14GetDeadLetterRequest\x12\x17\n" +
+	"\x02id\x18\x01 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\x02id\"N\n" +
+	"\x15GetDeadLetterResponse\x125\n" +
+	"\vdead_letter\x18\x01 \x01(\v2\x14.proto.v1.DeadLetterR\n" +
+	"deadLetter\"2\n" +
+	"\x17ReplayDeadLetterRequest\x12\x17\n" +
+	"\x02id\x18\x01 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\x02id\"Q\n" +
+	"\x18ReplayDeadLetterResponse\x125\n" +
+	"\vdead_letter\x18\x01 \x01(\v2\x14.proto.v1.DeadLetterR\n" +
+	"deadLetter\"\x86\x01\n" +
+	"\x12SuspendUserRequest\x12.\n" +
+	"\x0eidempotency_id\x18\x01 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\ridempotencyId\x12 \n" +
+	"\auser_id\x18\x02 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\x06userId\x12\x1e\n" +
+	"\x06reason\x18\x03 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x06reason\"\xef\x01\n" +
+	"\x13SuspendUserResponse\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12,\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x14.proto.v1.UserStatusR\x06status\x129\n" +
+	"\n" +
+	"updated_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x04 \x01(\tR\tcreatedBy\x12\x1d\n" +
+	"\n" +
+	"updated_by\x18\x05 \x01(\tR\tupdatedBy\x12\x18\n" +
+	"\aversion\x18\x06 \x01(\x03R\aversion\"\x89\x01\n" +
+	"\x15ReactivateUserRequest\x12.\n" +
+	"\x0eidempotency_id\x18\x01 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\ridempotencyId\x12 \n" +
+	"\auser_id\x18\x02 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\x06userId\x12\x1e\n" +
+	"\x06reason\x18\x03 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x06reason\"\xf2\x01\n" +
+	"\x16ReactivateUserResponse\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12,\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x14.proto.v1.UserStatusR\x06status\x129\n" +
+	"\n" +
+	"updated_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x04 \x01(\tR\tcreatedBy\x12\x1d\n" +
+	"\n" +
+	"updated_by\x18\x05 \x01(\tR\tupdatedBy\x12\x18\n" +
+	"\aversion\x18\x06 \x01(\x03R\aversion\"5\n" +
+	"\x11UnlockUserRequest\x12 \n" +
+	"\auser_id\x18\x01 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\x06userId\"5\n" +
+	"\x12UnlockUserResponse\x12\x1f\n" +
+	"\vcleared_ips\x18\x01 \x01(\x03R\n" +
+	"clearedIps\"\xb3\x01\n" +
+	"\fUserIdentity\x12\x16\n" +
+	"\x06issuer\x18\x01 \x01(\tR\x06issuer\x12\x18\n" +
+	"\asubject\x18\x02 \x01(\tR\asubject\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x03R\x06userId\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x05 \x01(\tR\tcreatedBy\"\x87\x01\n" +
+	"\x17LinkUserIdentityRequest\x12 \n" +
+	"\auser_id\x18\x01 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\x06userId\x12#\n" +
+	"\x06issuer\x18\x02 \x01(\tB\v\xbaH\b\xc8\x01\x01r\x03\x18\xff\x01R\x06issuer\x12%\n" +
+	"\asubject\x18\x03 \x01(\tB\v\xbaH\b\xc8\x01\x01r\x03\x18\xff\x01R\asubject\"N\n" +
+	"\x18LinkUserIdentityResponse\x122\n" +
+	"\bidentity\x18\x01 \x01(\v2\x16.proto.v1.UserIdentityR\bidentity\"g\n" +
+	"\x19UnlinkUserIdentityRequest\x12#\n" +
+	"\x06issuer\x18\x01 \x01(\tB\v\xbaH\b\xc8\x01\x01r\x03\x18\xff\x01R\x06issuer\x12%\n" +
+	"\asubject\x18\x02 \x01(\tB\v\xbaH\b\xc8\x01\x01r\x03\x18\xff\x01R\asubject\"8\n" +
+	"\x1aUnlinkUserIdentityResponse\x12\x1a\n" +
+	"\bunlinked\x18\x01 \x01(\bR\bunlinked\"\x12\n" +
+	"\x10GetConfigRequest\"\x7f\n" +
+	"\x11GetConfigResponse\x129\n" +
+	"\n" +
+	"started_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12/\n" +
+	"\aentries\x18\x02 \x03(\v2\x15.proto.v1.ConfigEntryR\aentries\"Q\n" +
+	"\vConfigEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x1a\n" +
+	"\bredacted\x18\x03 \x01(\bR\bredacted\"\x19\n" +
+	"\x17CheckSchemaDriftRequest\"I\n" +
+	"\x18CheckSchemaDriftResponse\x12-\n" +
+	"\x06drifts\x18\x01 \x03(\v2\x15.proto.v1.SchemaDriftR\x06drifts\"\x9e\x01\n" +
+	"\vSchemaDrift\x12\x14\n" +
+	"\x05store\x18\x01 \x01(\tR\x05store\x12\x16\n" +
+	"\x06object\x18\x02 \x01(\tR\x06object\x12-\n" +
+	"\x04kind\x18\x03 \x01(\x0e2\x19.proto.v1.SchemaDriftKindR\x04kind\x12\x1a\n" +
+	"\bexpected\x18\x04 \x01(\tR\bexpected\x12\x16\n" +
+	"\x06actual\x18\x05 \x01(\tR\x06actual\"_\n" +
+	"\"QuarantineIdempotencyRecordRequest\x12\x17\n" +
+	"\x02id\x18\x01 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\x02id\x12 \n" +
+	"\x06reason\x18\x02 \x01(\tB\b\xbaH\x05r\x03\x18\x80\bR\x06reason\"G\n" +
+	"#QuarantineIdempotencyRecordResponse\x12 \n" +
+	"\vquarantined\x18\x01 \x01(\bR\vquarantined\"\x94\x02\n" +
+	"\x11IdempotencyRecord\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12!\n" +
+	"\frequest_type\x18\x02 \x01(\tR\vrequestType\x12!\n" +
+	"\freference_id\x18\x03 \x01(\x03R\vreferenceId\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12A\n" +
+	"\x0equarantined_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\rquarantinedAt\x12\x16\n" +
+	"\x06reason\x18\a \x01(\tR\x06reasonJ\x04\b\x04\x10\x05R\rresponse_data\"e\n" +
+	"\x18CorruptIdempotencyRecord\x123\n" +
+	"\x06record\x18\x01 \x01(\v2\x1b.proto.v1.IdempotencyRecordR\x06record\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"s\n" +
+	"$ListCorruptIdempotencyRecordsRequest\x12\"\n" +
+	"\bafter_id\x18\x01 \x01(\x03B\a\xbaH\x04\"\x02(\x00R\aafterId\x12'\n" +
+	"\tpage_size\x18\x02 \x01(\x05B\n" +
+	"\xbaH\a\x1a\x05\x18\xf4\x03(\x00R\bpageSize\"\x89\x01\n" +
+	"%ListCorruptIdempotencyRecordsResponse\x12<\n" +
+	"\arecords\x18\x01 \x03(\v2\".proto.v1.CorruptIdempotencyRecordR\arecords\x12\"\n" +
+	"\rnext_after_id\x18\x02 \x01(\x03R\vnextAfterId\"w\n" +
+	"(ListQuarantinedIdempotencyRecordsRequest\x12\"\n" +
+	"\bafter_id\x18\x01 \x01(\x03B\a\xbaH\x04\"\x02(\x00R\aafterId\x12'\n" +
+	"\tpage_size\x18\x02 \x01(\x05B\n" +
+	"\xbaH\a\x1a\x05\x18\xf4\x03(\x00R\bpageSize\"\x86\x01\n" +
+	")ListQuarantinedIdempotencyRecordsResponse\x125\n" +
+	"\arecords\x18\x01 \x03(\v2\x1b.proto.v1.IdempotencyRecordR\arecords\x12\"\n" +
+	"\rnext_after_id\x18\x02 \x01(\x03R\vnextAfterId\"9\n" +
+	"\x1eRepairIdempotencyRecordRequest\x12\x17\n" +
+	"\x02id\x18\x01 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\x02id\"V\n" +
+	"\x1fRepairIdempotencyRecordResponse\x123\n" +
+	"\x06record\x18\x01 \x01(\v2\x1b.proto.v1.IdempotencyRecordR\x06record\"\x96\x01\n" +
+	"\x04Role\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vpermissions\x18\x02 \x03(\tR\vpermissions\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x04 \x01(\tR\tcreatedBy\"\\\n" +
+	"\x0ePutRoleRequest\x12\x1e\n" +
+	"\x04name\x18\x01 \x01(\tB\n" +
+	"\xbaH\a\xc8\x01\x01r\x02\x18@R\x04name\x12*\n" +
+	"\vpermissions\x18\x02 \x03(\tB\b\xbaH\x05\x92\x01\x02\x10dR\vpermissions\"5\n" +
+	"\x0fPutRoleResponse\x12\"\n" +
+	"\x04role\x18\x01 \x01(\v2\x0e.proto.v1.RoleR\x04role\"4\n" +
+	"\x10ListRolesRequest\x12 \n" +
+	"\auser_id\x18\x01 \x01(\x03B\a\xbaH\x04\"\x02(\x00R\x06userId\"9\n" +
+	"\x11ListRolesResponse\x12$\n" +
+	"\x05roles\x18\x01 \x03(\v2\x0e.proto.v1.RoleR\x05roles\"U\n" +
+	"\x11AssignRoleRequest\x12 \n" +
+	"\auser_id\x18\x01 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\x06userId\x12\x1e\n" +
+	"\x04role\x18\x02 \x01(\tB\n" +
+	"\xbaH\a\xc8\x01\x01r\x02\x18@R\x04role\"0\n" +
+	"\x12AssignRoleResponse\x12\x1a\n" +
+	"\bassigned\x18\x01 \x01(\bR\bassigned\"W\n" +
+	"\x13UnassignRoleRequest\x12 \n" +
+	"\auser_id\x18\x01 \x01(\x03B\a\xbaH\x04\"\x02 \x00R\x06userId\x12\x1e\n" +
+	"\x04role\x18\x02 \x01(\tB\n" +
+	"\xbaH\a\xc8\x01\x01r\x02\x18@R\x04role\"6\n" +
+	"\x14UnassignRoleResponse\x12\x1e\n" +
+	"\n" +
+	"unassigned\x18\x01 \x01(\bR\n" +
+	"unassigned*g\n" +
+	"\x0fDependencyState\x12 \n" +
+	"\x1cDEPENDENCY_STATE_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13DEPENDENCY_STATE_UP\x10\x01\x12\x19\n" +
+	"\x15DEPENDENCY_STATE_DOWN\x10\x02*\xa3\x01\n" +
+	"\x13CircuitBreakerState\x12%\n" +
+	"!CIRCUIT_BREAKER_STATE_UNSPECIFIED\x10\x00\x12 \n" +
+	"\x1cCIRCUIT_BREAKER_STATE_CLOSED\x10\x01\x12#\n" +
+	"\x1fCIRCUIT_BREAKER_STATE_HALF_OPEN\x10\x02\x12\x1e\n" +
+	"\x1aCIRCUIT_BREAKER_STATE_OPEN\x10\x03*\xc2\x02\n" +
+	"\x0fSchemaDriftKind\x12!\n" +
+	"\x1dSCHEMA_DRIFT_KIND_UNSPECIFIED\x10\x00\x12#\n" +
+	"\x1fSCHEMA_DRIFT_KIND_MISSING_TABLE\x10\x01\x12$\n" +
+	" SCHEMA_DRIFT_KIND_MISSING_COLUMN\x10\x02\x12'\n" +
+	"#SCHEMA_DRIFT_KIND_UNEXPECTED_COLUMN\x10\x03\x12!\n" +
+	"\x1dSCHEMA_DRIFT_KIND_COLUMN_TYPE\x10\x04\x12(\n" +
+	"$SCHEMA_DRIFT_KIND_COLUMN_NULLABILITY\x10\x05\x12#\n" +
+	"\x1fSCHEMA_DRIFT_KIND_MISSING_INDEX\x10\x06\x12&\n" +
+	"\"SCHEMA_DRIFT_KIND_UNEXPECTED_INDEX\x10\a2\xf4\r\n" +
+	"\fAdminService\x12X\n" +
+	"\x0fGetDependencies\x12 .proto.v1.GetDependenciesRequest\x1a!.proto.v1.GetDependenciesResponse\"\x00\x12X\n" +
+	"\x0fListDeadLetters\x12 .proto.v1.ListDeadLettersRequest\x1a!.proto.v1.ListDeadLettersResponse\"\x00\x12R\n" +
+	"\rGetDeadLetter\x12\x1e.proto.v1.GetDeadLetterRequest\x1a\x1f.proto.v1.GetDeadLetterResponse\"\x00\x12[\n" +
+	"\x10ReplayDeadLetter\x12!.proto.v1.ReplayDeadLetterRequest\x1a\".proto.v1.ReplayDeadLetterResponse\"\x00\x12L\n" +
+	"\vSuspendUser\x12\x1c.proto.v1.SuspendUserRequest\x1a\x1d.proto.v1.SuspendUserResponse\"\x00\x12U\n" +
+	"\x0eReactivateUser\x12\x1f.proto.v1.ReactivateUserRequest\x1a .proto.v1.ReactivateUserResponse\"\x00\x12I\n" +
+	"\n" +
+	"UnlockUser\x12\x1b.proto.v1.UnlockUserRequest\x1a\x1c.proto.v1.UnlockUserResponse\"\x00\x12[\n" +
+	"\x10LinkUserIdentity\x12!.proto.v1.LinkUserIdentityRequest\x1a\".proto.v1.LinkUserIdentityResponse\"\x00\x12a\n" +
+	"\x12UnlinkUserIdentity\x12#.proto.v1.UnlinkUserIdentityRequest\x1a$.proto.v1.UnlinkUserIdentityResponse\"\x00\x12F\n" +
+	"\tGetConfig\x12\x1a.proto.v1.GetConfigRequest\x1a\x1b.proto.v1.GetConfigResponse\"\x00\x12[\n" +
+	"\x10CheckSchemaDrift\x12!.proto.v1.CheckSchemaDriftRequest\x1a\".proto.v1.CheckSchemaDriftResponse\"\x00\x12|\n" +
+	"\x1bQuarantineIdempotencyRecord\x12,.proto.v1.QuarantineIdempotencyRecordRequest\x1a-.proto.v1.QuarantineIdempotencyRecordResponse\"\x00\x12\x82\x01\n" +
+	"\x1dListCorruptIdempotencyRecords\x12..proto.v1.ListCorruptIdempotencyRecordsRequest\x1a/.proto.v1.ListCorruptIdempotencyRecordsResponse\"\x00\x12\x8e\x01\n" +
+	"!ListQuarantinedIdempotencyRecords\x122.proto.v1.ListQuarantinedIdempotencyRecordsRequest\x1a3.proto.v1.ListQuarantinedIdempotencyRecordsResponse\"\x00\x12p\n" +
+	"\x17RepairIdempotencyRecord\x12(.proto.v1.RepairIdempotencyRecordRequest\x1a).proto.v1.RepairIdempotencyRecordResponse\"\x00\x12@\n" +
+	"\aPutRole\x12\x18.proto.v1.PutRoleRequest\x1a\x19.proto.v1.PutRoleResponse\"\x00\x12F\n" +
+	"\tListRoles\x12\x1a.proto.v1.ListRolesRequest\x1a\x1b.proto.v1.ListRolesResponse\"\x00\x12I\n" +
+	"\n" +
+	"AssignRole\x12\x1b.proto.v1.AssignRoleRequest\x1a\x1c.proto.v1.AssignRoleResponse\"\x00\x12O\n" +
+	"\fUnassignRole\x12\x1d.proto.v1.UnassignRoleRequest\x1a\x1e.proto.v1.UnassignRoleResponse\"\x00B/Z-github.com/jt828/go-grpc-template/proto/v1;v1b\x06proto3"
+
+var (
+	file_admin_proto_rawDescOnce sync.Once
+	file_admin_proto_rawDescData []byte
+)
+
+func file_admin_proto_rawDescGZIP() []byte {
+	file_admin_proto_rawDescOnce.Do(func() {
+		file_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_admin_proto_rawDesc), len(file_admin_proto_rawDesc)))
+	})
+	return file_admin_proto_rawDescData
+}
+
+var file_admin_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 46)
+var file_admin_proto_goTypes = []any{
+	(DependencyState)(0),                              // 0: proto.v1.DependencyState
+	(CircuitBreakerState)(0),                          // 1: proto.v1.CircuitBreakerState
+	(SchemaDriftKind)(0),                              // 2: proto.v1.SchemaDriftKind
+	(*GetDependenciesRequest)(nil),                    // 3: proto.v1.GetDependenciesRequest
+	(*GetDependenciesResponse)(nil),                   // 4: proto.v1.GetDependenciesResponse
+	(*DependencyStatus)(nil),                          // 5: proto.v1.DependencyStatus
+	(*DeadLetter)(nil),                                // 6: proto.v1.DeadLetter
+	(*ListDeadLettersRequest)(nil),                    // 7: proto.v1.ListDeadLettersRequest
+	(*ListDeadLettersResponse)(nil),                   // 8: proto.v1.ListDeadLettersResponse
+	(*GetDeadLetterRequest)(nil),                      // 9: proto.v1.GetDeadLetterRequest
+	(*GetDeadLetterResponse)(nil),                     // 10: proto.v1.GetDeadLetterResponse
+	(*ReplayDeadLetterRequest)(nil),                   // 11: proto.v1.ReplayDeadLetterRequest
+	(*ReplayDeadLetterResponse)(nil),                  // 12: proto.v1.ReplayDeadLetterResponse
+	(*SuspendUserRequest)(nil),                        // 13: proto.v1.SuspendUserRequest
+	(*SuspendUserResponse)(nil),                       // 14: proto.v1.SuspendUserResponse
+	(*ReactivateUserRequest)(nil),                     // 15: proto.v1.ReactivateUserRequest
+	(*ReactivateUserResponse)(nil),                    // 16: proto.v1.ReactivateUserResponse
+	(*UnlockUserRequest)(nil),                         // 17: proto.v1.UnlockUserRequest
+	(*UnlockUserResponse)(nil),                        // 18: proto.v1.UnlockUserResponse
+	(*UserIdentity)(nil),                              // 19: proto.v1.UserIdentity
+	(*LinkUserIdentityRequest)(nil),                   // 20: proto.v1.LinkUserIdentityRequest
+	(*LinkUserIdentityResponse)(nil),                  // 21: proto.v1.LinkUserIdentityResponse
+	(*UnlinkUserIdentityRequest)(nil),                 // 22: proto.v1.UnlinkUserIdentityRequest
+	(*UnlinkUserIdentityResponse)(nil),                // 23: proto.v1.UnlinkUserIdentityResponse
+	(*GetConfigRequest)(nil),                          // 24: proto.v1.GetConfigRequest
+	(*GetConfigResponse)(nil),                         // 25: proto.v1.GetConfigResponse
+	(*ConfigEntry)(nil),                               // 26: proto.v1.ConfigEntry
+	(*CheckSchemaDriftRequest)(nil),                   // 27: proto.v1.CheckSchemaDriftRequest
+	(*CheckSchemaDriftResponse)(nil),                  // 28: proto.v1.CheckSchemaDriftResponse
+	(*SchemaDrift)(nil),                               // 29: proto.v1.SchemaDrift
+	(*QuarantineIdempotencyRecordRequest)(nil),        // 30: proto.v1.QuarantineIdempotencyRecordRequest
+	(*QuarantineIdempotencyRecordResponse)(nil),       // 31: proto.v1.QuarantineIdempotencyRecordResponse
+	(*IdempotencyRecord)(nil),                         // 32: proto.v1.IdempotencyRecord
+	(*CorruptIdempotencyRecord)(nil),                  // 33: proto.v1.CorruptIdempotencyRecord
+	(*ListCorruptIdempotencyRecordsRequest)(nil),      // 34: proto.v1.ListCorruptIdempotencyRecordsRequest
+	(*ListCorruptIdempotencyRecordsResponse)(nil),     // 35: proto.v1.ListCorruptIdempotencyRecordsResponse
+	(*ListQuarantinedIdempotencyRecordsRequest)(nil),  // 36: proto.v1.ListQuarantinedIdempotencyRecordsRequest
+	(*ListQuarantinedIdempotencyRecordsResponse)(nil), // 37: proto.v1.ListQuarantinedIdempotencyRecordsResponse
+	(*RepairIdempotencyRecordRequest)(nil),            // 38: proto.v1.RepairIdempotencyRecordRequest
+	(*RepairIdempotencyRecordResponse)(nil),           // 39: proto.v1.RepairIdempotencyRecordResponse
+	(*Role)(nil),                                      // 40: proto.v1.Role
+	(*PutRoleRequest)(nil),                            // 41: proto.v1.PutRoleRequest
+	(*PutRoleResponse)(nil),                           // 42: proto.v1.PutRoleResponse
+	(*ListRolesRequest)(nil),                          // 43: proto.v1.ListRolesRequest
+	(*ListRolesResponse)(nil),                         // 44: proto.v1.ListRolesResponse
+	(*AssignRoleRequest)(nil),                         // 45: proto.v1.AssignRoleRequest
+	(*AssignRoleResponse)(nil),                        // 46: proto.v1.AssignRoleResponse
+	(*UnassignRoleRequest)(nil),                       // 47: proto.v1.UnassignRoleRequest
+	(*UnassignRoleResponse)(nil),                      // 48: proto.v1.UnassignRoleResponse
+	(*durationpb.Duration)(nil),                       // 49: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil),                     // 50: google.protobuf.Timestamp
+	(UserStatus)(0),                                   // 51: proto.v1.UserStatus
+}
+var file_admin_proto_depIdxs = []int32{
+	5,  // 0: proto.v1.GetDependenciesResponse.dependencies:type_name -> proto.v1.DependencyStatus
+	0,  // 1: proto.v1.DependencyStatus.state:type_name -> proto.v1.DependencyState
+	49, // 2: proto.v1.DependencyStatus.last_probe_latency:type_name -> google.protobuf.Duration
+	50, // 3: proto.v1.DependencyStatus.last_probe_at:type_name -> google.protobuf.Timestamp
+	1,  // 4: proto.v1.DependencyStatus.circuit_breaker_state:type_name -> proto.v1.CircuitBreakerState
+	50, // 5: proto.v1.DeadLetter.created_at:type_name -> google.protobuf.Timestamp
+	50, // 6: proto.v1.DeadLetter.replayed_at:type_name -> google.protobuf.Timestamp
+	6,  // 7: proto.v1.ListDeadLettersResponse.dead_letters:type_name -> proto.v1.DeadLetter
+	6,  // 8: proto.v1.GetDeadLetterResponse.dead_letter:type_name -> proto.v1.DeadLetter
+	6,  // 9: proto.v1.ReplayDeadLetterResponse.dead_letter:type_name -> proto.v1.DeadLetter
+	51, // 10: proto.v1.SuspendUserResponse.status:type_name -> proto.v1.UserStatus
+	50, // 11: proto.v1.SuspendUserResponse.updated_at:type_name -> google.protobuf.Timestamp
+	51, // 12: proto.v1.ReactivateUserResponse.status:type_name -> proto.v1.UserStatus
+	50, // 13: proto.v1.ReactivateUserResponse.updated_at:type_name -> google.protobuf.Timestamp
+	50, // 14: proto.v1.UserIdentity.created_at:type_name -> google.protobuf.Timestamp
+	19, // 15: proto.v1.LinkUserIdentityResponse.identity:type_name -> proto.v1.UserIdentity
+	50, // 16: proto.v1.GetConfigResponse.started_at:type_name -> google.protobuf.Timestamp
+	26, // 17: proto.v1.GetConfigResponse.entries:type_name -> proto.v1.ConfigEntry
+	29, // 18: proto.v1.CheckSchemaDriftResponse.drifts:type_name -> proto.v1.SchemaDrift
+	2,  // 19: proto.v1.SchemaDrift.kind:type_name -> proto.v1.SchemaDriftKind
+	50, // 20: proto.v1.IdempotencyRecord.created_at:type_name -> google.protobuf.Timestamp
+	50, // 21: proto.v1.IdempotencyRecord.quarantined_at:type_name -> google.protobuf.Timestamp
+	32, // 22: proto.v1.CorruptIdempotencyRecord.record:type_name -> proto.v1.IdempotencyRecord
+	33, // 23: proto.v1.ListCorruptIdempotencyRecordsResponse.records:type_name -> proto.v1.CorruptIdempotencyRecord
+	32, // 24: proto.v1.ListQuarantinedIdempotencyRecordsResponse.records:type_name -> proto.v1.IdempotencyRecord
+	32, // 25: proto.v1.RepairIdempotencyRecordResponse.record:type_name -> proto.v1.IdempotencyRecord
+	50, // 26: proto.v1.Role.created_at:type_name -> google.protobuf.Timestamp
+	40, // 27: proto.v1.PutRoleResponse.role:type_name -> proto.v1.Role
+	40, // 28: proto.v1.ListRolesResponse.roles:type_name -> proto.v1.Role
+	3,  // 29: proto.v1.AdminService.GetDependencies:input_type -> proto.v1.GetDependenciesRequest
+	7,  // 30: proto.v1.AdminService.ListDeadLetters:input_type -> proto.v1.ListDeadLettersRequest
+	9,  // 31: proto.v1.AdminService.GetDeadLetter:input_type -> proto.v1.GetDeadLetterRequest
+	11, // 32: proto.v1.AdminService.ReplayDeadLetter:input_type -> proto.v1.ReplayDeadLetterRequest
+	13, // 33: proto.v1.AdminService.SuspendUser:input_type -> proto.v1.SuspendUserRequest
+	15, // 34: proto.v1.AdminService.ReactivateUser:input_type -> proto.v1.ReactivateUserRequest
+	17, // 35: proto.v1.AdminService.UnlockUser:input_type -> proto.v1.UnlockUserRequest
+	20, // 36: proto.v1.AdminService.LinkUserIdentity:input_type -> proto.v1.LinkUserIdentityRequest
+	22, // 37: proto.v1.AdminService.UnlinkUserIdentity:input_type -> proto.v1.UnlinkUserIdentityRequest
+	24, // 38: proto.v1.AdminService.GetConfig:input_type -> proto.v1.GetConfigRequest
+	27, // 39: proto.v1.AdminService.CheckSchemaDrift:input_type -> proto.v1.CheckSchemaDriftRequest
+	30, // 40: proto.v1.AdminService.QuarantineIdempotencyRecord:input_type -> proto.v1.QuarantineIdempotencyRecordRequest
+	34, // 41: proto.v1.AdminService.ListCorruptIdempotencyRecords:input_type -> proto.v1.ListCorruptIdempotencyRecordsRequest
+	36, // 42: proto.v1.AdminService.ListQuarantinedIdempotencyRecords:input_type -> proto.v1.ListQuarantinedIdempotencyRecordsRequest
+	38, // 43: proto.v1.AdminService.RepairIdempotencyRecord:input_type -> proto.v1.RepairIdempotencyRecordRequest
+	41, // 44: proto.v1.AdminService.PutRole:input_type -> proto.v1.PutRoleRequest
+	43, // 45: proto.v1.AdminService.ListRoles:input_type -> proto.v1.ListRolesRequest
+	45, // 46: proto.v1.AdminService.AssignRole:input_type -> proto.v1.AssignRoleRequest
+	47, // 47: proto.v1.AdminService.UnassignRole:input_type -> proto.v1.UnassignRoleRequest
+	4,  // 48: proto.v1.AdminService.GetDependencies:output_type -> proto.v1.GetDependenciesResponse
+	8,  // 49: proto.v1.AdminService.ListDeadLetters:output_type -> proto.v1.ListDeadLettersResponse
+	10, // 50: proto.v1.AdminService.GetDeadLetter:output_type -> proto.v1.GetDeadLetterResponse
+	12, // 51: proto.v1.AdminService.ReplayDeadLetter:output_type -> proto.v1.ReplayDeadLetterResponse
+	14, // 52: proto.v1.AdminService.SuspendUser:output_type -> proto.v1.SuspendUserResponse
+	16, // 53: proto.v1.AdminService.ReactivateUser:output_type -> proto.v1.ReactivateUserResponse
+	18, // 54: proto.v1.AdminService.UnlockUser:output_type -> proto.v1.UnlockUserResponse
+	21, // 55: proto.v1.AdminService.LinkUserIdentity:output_type -> proto.v1.LinkUserIdentityResponse
+	23, // 56: proto.v1.AdminService.UnlinkUserIdentity:output_type -> proto.v1.UnlinkUserIdentityResponse
+	25, // 57: proto.v1.AdminService.GetConfig:output_type -> proto.v1.GetConfigResponse
+	28, // 58: proto.v1.AdminService.CheckSchemaDrift:output_type -> proto.v1.CheckSchemaDriftResponse
+	31, // 59: proto.v1.AdminService.QuarantineIdempotencyRecord:output_type -> proto.v1.QuarantineIdempotencyRecordResponse
+	35, // 60: proto.v1.AdminService.ListCorruptIdempotencyRecords:output_type -> proto.v1.ListCorruptIdempotencyRecordsResponse
+	37, // 61: proto.v1.AdminService.ListQuarantinedIdempotencyRecords:output_type -> proto.v1.ListQuarantinedIdempotencyRecordsResponse
+	39, // 62: proto.v1.AdminService.RepairIdempotencyRecord:output_type -> proto.v1.RepairIdempotencyRecordResponse
+	42, // 63: proto.v1.AdminService.PutRole:output_type -> proto.v1.PutRoleResponse
+	44, // 64: proto.v1.AdminService.ListRoles:output_type -> proto.v1.ListRolesResponse
+	46, // 65: proto.v1.AdminService.AssignRole:output_type -> proto.v1.AssignRoleResponse
+	48, // 66: proto.v1.AdminService.UnassignRole:output_type -> proto.v1.UnassignRoleResponse
+	48, // [48:67] is the sub-list for method output_type
+	29, // [29:48] is the sub-list for method input_type
+	29, // [29:29] is the sub-list for extension type_name
+	29, // [29:29] is the sub-list for extension extendee
+	0,  // [0:29] is the sub-list for field type_name
+}
+
+func init() { file_admin_proto_init() }
+func file_admin_proto_init() {
+	if File_admin_proto != nil {
+		return
+	}
+	file_user_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_admin_proto_rawDesc), len(file_admin_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   46,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_admin_proto_goTypes,
+		DependencyIndexes: file_admin_proto_depIdxs,
+		EnumInfos:         file_admin_proto_enumTypes,
+		MessageInfos:      file_admin_proto_msgTypes,
+	}.Build()
+	File_admin_proto = out.File
+	file_admin_proto_goTypes = nil
+	file_admin_proto_depIdxs = nil
+}
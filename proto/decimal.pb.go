@@ -0,0 +1,129 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.4
+// source: decimal.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// DecimalValue is an exact decimal number. Monetary and token amounts use it
+// instead of float or double, which cannot represent most decimal fractions.
+type DecimalValue struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Plain decimal string: an optional leading "-", integer digits and an
+	// optional "." followed by fraction digits, e.g. "-1.5" or "0.000001".
+	// Exponents, "+", NaN and infinities are rejected. Responses are
+	// canonical: no leading zeros in the integer part and no trailing zeros in
+	// the fraction.
+	Value         string `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DecimalValue) Reset() {
+	*x = DecimalValue{}
+	mi := &file_decimal_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DecimalValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecimalValue) ProtoMessage() {}
+
+func (x *DecimalValue) ProtoReflect() protoreflect.Message {
+	mi := &file_decimal_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecimalValue.ProtoReflect.Descriptor instead.
+func (*DecimalValue) Descriptor() ([]byte, []int) {
+	return file_decimal_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DecimalValue) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+var File_decimal_proto protoreflect.FileDescriptor
+
+const file_decimal_proto_rawDesc = "" +
+	"\n" +
+	"\rdecimal.proto\x12\bproto.v1\"$\n" +
+	"\fDecimalValue\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05valueB/Z-github.com/jt828/go-grpc-template/proto/v1;v1b\x06proto3"
+
+var (
+	file_decimal_proto_rawDescOnce sync.Once
+	file_decimal_proto_rawDescData []byte
+)
+
+func file_decimal_proto_rawDescGZIP() []byte {
+	file_decimal_proto_rawDescOnce.Do(func() {
+		file_decimal_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_decimal_proto_rawDesc), len(file_decimal_proto_rawDesc)))
+	})
+	return file_decimal_proto_rawDescData
+}
+
+var file_decimal_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_decimal_proto_goTypes = []any{
+	(*DecimalValue)(nil), // 0: proto.v1.DecimalValue
+}
+var file_decimal_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_decimal_proto_init() }
+func file_decimal_proto_init() {
+	if File_decimal_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_decimal_proto_rawDesc), len(file_decimal_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_decimal_proto_goTypes,
+		DependencyIndexes: file_decimal_proto_depIdxs,
+		MessageInfos:      file_decimal_proto_msgTypes,
+	}.Build()
+	File_decimal_proto = out.File
+	file_decimal_proto_goTypes = nil
+	file_decimal_proto_depIdxs = nil
+}
@@ -0,0 +1,172 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.4
+// source: ledger_events.proto
+
+package events
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type LedgerEntryAddedV1 struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	LedgerId        int64                  `protobuf:"varint,1,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	UserId          int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	TransactionType string                 `protobuf:"bytes,3,opt,name=transaction_type,json=transactionType,proto3" json:"transaction_type,omitempty"`
+	Token           string                 `protobuf:"bytes,4,opt,name=token,proto3" json:"token,omitempty"`
+	// Decimal amount in canonical string form, e.g. "1.5".
+	Amount        string                 `protobuf:"bytes,5,opt,name=amount,proto3" json:"amount,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LedgerEntryAddedV1) Reset() {
+	*x = LedgerEntryAddedV1{}
+	mi := &file_ledger_events_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LedgerEntryAddedV1) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LedgerEntryAddedV1) ProtoMessage() {}
+
+func (x *LedgerEntryAddedV1) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_events_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LedgerEntryAddedV1.ProtoReflect.Descriptor instead.
+func (*LedgerEntryAddedV1) Descriptor() ([]byte, []int) {
+	return file_ledger_events_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LedgerEntryAddedV1) GetLedgerId() int64 {
+	if x != nil {
+		return x.LedgerId
+	}
+	return 0
+}
+
+func (x *LedgerEntryAddedV1) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *LedgerEntryAddedV1) GetTransactionType() string {
+	if x != nil {
+		return x.TransactionType
+	}
+	return ""
+}
+
+func (x *LedgerEntryAddedV1) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *LedgerEntryAddedV1) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *LedgerEntryAddedV1) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+var File_ledger_events_proto protoreflect.FileDescriptor
+
+const file_ledger_events_proto_rawDesc = "" +
+	"\n" +
+	"\x13ledger_events.proto\x12\x0fproto.events.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xde\x01\n" +
+	"\x12LedgerEntryAddedV1\x12\x1b\n" +
+	"\tledger_id\x18\x01 \x01(\x03R\bledgerId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\x12)\n" +
+	"\x10transaction_type\x18\x03 \x01(\tR\x0ftransactionType\x12\x14\n" +
+	"\x05token\x18\x04 \x01(\tR\x05token\x12\x16\n" +
+	"\x06amount\x18\x05 \x01(\tR\x06amount\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAtB:Z8github.com/jt828/go-grpc-template/proto/events/v1;eventsb\x06proto3"
+
+var (
+	file_ledger_events_proto_rawDescOnce sync.Once
+	file_ledger_events_proto_rawDescData []byte
+)
+
+func file_ledger_events_proto_rawDescGZIP() []byte {
+	file_ledger_events_proto_rawDescOnce.Do(func() {
+		file_ledger_events_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ledger_events_proto_rawDesc), len(file_ledger_events_proto_rawDesc)))
+	})
+	return file_ledger_events_proto_rawDescData
+}
+
+var file_ledger_events_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_ledger_events_proto_goTypes = []any{
+	(*LedgerEntryAddedV1)(nil),    // 0: proto.events.v1.LedgerEntryAddedV1
+	(*timestamppb.Timestamp)(nil), // 1: google.protobuf.Timestamp
+}
+var file_ledger_events_proto_depIdxs = []int32{
+	1, // 0: proto.events.v1.LedgerEntryAddedV1.created_at:type_name -> google.protobuf.Timestamp
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_ledger_events_proto_init() }
+func file_ledger_events_proto_init() {
+	if File_ledger_events_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ledger_events_proto_rawDesc), len(file_ledger_events_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_ledger_events_proto_goTypes,
+		DependencyIndexes: file_ledger_events_proto_depIdxs,
+		MessageInfos:      file_ledger_events_proto_msgTypes,
+	}.Build()
+	File_ledger_events_proto = out.File
+	file_ledger_events_proto_goTypes = nil
+	file_ledger_events_proto_depIdxs = nil
+}
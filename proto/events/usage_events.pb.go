@@ -0,0 +1,229 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.4
+// source: usage_events.proto
+
+package events
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ApiUsageReportedV1 carries one caller's billable usage for one UTC day.
+// It is published once per caller and day after the day has closed.
+type ApiUsageReportedV1 struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Caller string                 `protobuf:"bytes,1,opt,name=caller,proto3" json:"caller,omitempty"`
+	// YYYY-MM-DD, in UTC.
+	UsageDate     string            `protobuf:"bytes,2,opt,name=usage_date,json=usageDate,proto3" json:"usage_date,omitempty"`
+	Methods       []*ApiMethodUsage `protobuf:"bytes,3,rep,name=methods,proto3" json:"methods,omitempty"`
+	TotalRequests int64             `protobuf:"varint,4,opt,name=total_requests,json=totalRequests,proto3" json:"total_requests,omitempty"`
+	TotalUnits    int64             `protobuf:"varint,5,opt,name=total_units,json=totalUnits,proto3" json:"total_units,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApiUsageReportedV1) Reset() {
+	*x = ApiUsageReportedV1{}
+	mi := &file_usage_events_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApiUsageReportedV1) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApiUsageReportedV1) ProtoMessage() {}
+
+func (x *ApiUsageReportedV1) ProtoReflect() protoreflect.Message {
+	mi := &file_usage_events_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApiUsageReportedV1.ProtoReflect.Descriptor instead.
+func (*ApiUsageReportedV1) Descriptor() ([]byte, []int) {
+	return file_usage_events_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ApiUsageReportedV1) GetCaller() string {
+	if x != nil {
+		return x.Caller
+	}
+	return ""
+}
+
+func (x *ApiUsageReportedV1) GetUsageDate() string {
+	if x != nil {
+		return x.UsageDate
+	}
+	return ""
+}
+
+func (x *ApiUsageReportedV1) GetMethods() []*ApiMethodUsage {
+	if x != nil {
+		return x.Methods
+	}
+	return nil
+}
+
+func (x *ApiUsageReportedV1) GetTotalRequests() int64 {
+	if x != nil {
+		return x.TotalRequests
+	}
+	return 0
+}
+
+func (x *ApiUsageReportedV1) GetTotalUnits() int64 {
+	if x != nil {
+		return x.TotalUnits
+	}
+	return 0
+}
+
+type ApiMethodUsage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Method        string                 `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	Requests      int64                  `protobuf:"varint,2,opt,name=requests,proto3" json:"requests,omitempty"`
+	Units         int64                  `protobuf:"varint,3,opt,name=units,proto3" json:"units,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApiMethodUsage) Reset() {
+	*x = ApiMethodUsage{}
+	mi := &file_usage_events_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApiMethodUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApiMethodUsage) ProtoMessage() {}
+
+func (x *ApiMethodUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_usage_events_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApiMethodUsage.ProtoReflect.Descriptor instead.
+func (*ApiMethodUsage) Descriptor() ([]byte, []int) {
+	return file_usage_events_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ApiMethodUsage) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *ApiMethodUsage) GetRequests() int64 {
+	if x != nil {
+		return x.Requests
+	}
+	return 0
+}
+
+func (x *ApiMethodUsage) GetUnits() int64 {
+	if x != nil {
+		return x.Units
+	}
+	return 0
+}
+
+var File_usage_events_proto protoreflect.FileDescriptor
+
+const file_usage_events_proto_rawDesc = "" +
+	"\n" +
+	"\x12usage_events.proto\x12\x0fproto.events.v1\"\xce\x01\n" +
+	"\x12ApiUsageReportedV1\x12\x16\n" +
+	"\x06caller\x18\x01 \x01(\tR\x06caller\x12\x1d\n" +
+	"\n" +
+	"usage_date\x18\x02 \x01(\tR\tusageDate\x129\n" +
+	"\amethods\x18\x03 \x03(\v2\x1f.proto.events.v1.ApiMethodUsageR\amethods\x12%\n" +
+	"\x0etotal_requests\x18\x04 \x01(\x03R\rtotalRequests\x12\x1f\n" +
+	"\vtotal_units\x18\x05 \x01(\x03R\n" +
+	"totalUnits\"Z\n" +
+	"\x0eApiMethodUsage\x12\x16\n" +
+	"\x06method\x18\x01 \x01(\tR\x06method\x12\x1a\n" +
+	"\brequests\x18\x02 \x01(\x03R\brequests\x12\x14\n" +
+	"\x05units\x18\x03 \x01(\x03R\x05unitsB:Z8github.com/jt828/go-grpc-template/proto/events/v1;eventsb\x06proto3"
+
+var (
+	file_usage_events_proto_rawDescOnce sync.Once
+	file_usage_events_proto_rawDescData []byte
+)
+
+func file_usage_events_proto_rawDescGZIP() []byte {
+	file_usage_events_proto_rawDescOnce.Do(func() {
+		file_usage_events_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_usage_events_proto_rawDesc), len(file_usage_events_proto_rawDesc)))
+	})
+	return file_usage_events_proto_rawDescData
+}
+
+var file_usage_events_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_usage_events_proto_goTypes = []any{
+	(*ApiUsageReportedV1)(nil), // 0: proto.events.v1.ApiUsageReportedV1
+	(*ApiMethodUsage)(nil),     // 1: proto.events.v1.ApiMethodUsage
+}
+var file_usage_events_proto_depIdxs = []int32{
+	1, // 0: proto.events.v1.ApiUsageReportedV1.methods:type_name -> proto.events.v1.ApiMethodUsage
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_usage_events_proto_init() }
+func file_usage_events_proto_init() {
+	if File_usage_events_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_usage_events_proto_rawDesc), len(file_usage_events_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_usage_events_proto_goTypes,
+		DependencyIndexes: file_usage_events_proto_depIdxs,
+		MessageInfos:      file_usage_events_proto_msgTypes,
+	}.Build()
+	File_usage_events_proto = out.File
+	file_usage_events_proto_goTypes = nil
+	file_usage_events_proto_depIdxs = nil
+}
@@ -0,0 +1,153 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.4
+// source: user_events.proto
+
+package events
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type UserCreatedV1 struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Username      string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserCreatedV1) Reset() {
+	*x = UserCreatedV1{}
+	mi := &file_user_events_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserCreatedV1) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserCreatedV1) ProtoMessage() {}
+
+func (x *UserCreatedV1) ProtoReflect() protoreflect.Message {
+	mi := &file_user_events_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserCreatedV1.ProtoReflect.Descriptor instead.
+func (*UserCreatedV1) Descriptor() ([]byte, []int) {
+	return file_user_events_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *UserCreatedV1) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *UserCreatedV1) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *UserCreatedV1) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *UserCreatedV1) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+var File_user_events_proto protoreflect.FileDescriptor
+
+const file_user_events_proto_rawDesc = "" +
+	"\n" +
+	"\x11user_events.proto\x12\x0fproto.events.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x95\x01\n" +
+	"\rUserCreatedV1\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x1a\n" +
+	"\busername\x18\x03 \x01(\tR\busername\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAtB:Z8github.com/jt828/go-grpc-template/proto/events/v1;eventsb\x06proto3"
+
+var (
+	file_user_events_proto_rawDescOnce sync.Once
+	file_user_events_proto_rawDescData []byte
+)
+
+func file_user_events_proto_rawDescGZIP() []byte {
+	file_user_events_proto_rawDescOnce.Do(func() {
+		file_user_events_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_user_events_proto_rawDesc), len(file_user_events_proto_rawDesc)))
+	})
+	return file_user_events_proto_rawDescData
+}
+
+var file_user_events_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_user_events_proto_goTypes = []any{
+	(*UserCreatedV1)(nil),         // 0: proto.events.v1.UserCreatedV1
+	(*timestamppb.Timestamp)(nil), // 1: google.protobuf.Timestamp
+}
+var file_user_events_proto_depIdxs = []int32{
+	1, // 0: proto.events.v1.UserCreatedV1.created_at:type_name -> google.protobuf.Timestamp
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_user_events_proto_init() }
+func file_user_events_proto_init() {
+	if File_user_events_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_user_events_proto_rawDesc), len(file_user_events_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_user_events_proto_goTypes,
+		DependencyIndexes: file_user_events_proto_depIdxs,
+		MessageInfos:      file_user_events_proto_msgTypes,
+	}.Build()
+	File_user_events_proto = out.File
+	file_user_events_proto_goTypes = nil
+	file_user_events_proto_depIdxs = nil
+}
@@ -0,0 +1,578 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.4
+// source: ledger.proto
+
+package v1
+
+import (
+	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TransactionType int32
+
+const (
+	TransactionType_TRANSACTION_TYPE_UNSPECIFIED TransactionType = 0
+	TransactionType_TRANSACTION_TYPE_DEPOSIT     TransactionType = 1
+	TransactionType_TRANSACTION_TYPE_WITHDRAW    TransactionType = 2
+	TransactionType_TRANSACTION_TYPE_TRANSFER    TransactionType = 3
+)
+
+// Enum value maps for TransactionType.
+var (
+	TransactionType_name = map[int32]string{
+		0: "TRANSACTION_TYPE_UNSPECIFIED",
+		1: "TRANSACTION_TYPE_DEPOSIT",
+		2: "TRANSACTION_TYPE_WITHDRAW",
+		3: "TRANSACTION_TYPE_TRANSFER",
+	}
+	TransactionType_value = map[string]int32{
+		"TRANSACTION_TYPE_UNSPECIFIED": 0,
+		"TRANSACTION_TYPE_DEPOSIT":     1,
+		"TRANSACTION_TYPE_WITHDRAW":    2,
+		"TRANSACTION_TYPE_TRANSFER":    3,
+	}
+)
+
+func (x TransactionType) Enum() *TransactionType {
+	p := new(TransactionType)
+	*p = x
+	return p
+}
+
+func (x TransactionType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TransactionType) Descriptor() protoreflect.EnumDescriptor {
+	return file_ledger_proto_enumTypes[0].Descriptor()
+}
+
+func (TransactionType) Type() protoreflect.EnumType {
+	return &file_ledger_proto_enumTypes[0]
+}
+
+func (x TransactionType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TransactionType.Descriptor instead.
+func (TransactionType) EnumDescriptor() ([]byte, []int) {
+	return file_ledger_proto_rawDescGZIP(), []int{0}
+}
+
+type ListLedgersRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Use type. Still accepted for older clients, as one of "deposit",
+	// "withdraw" or "transfer"; when both are set they must agree.
+	//
+	// Deprecated: Marked as deprecated in ledger.proto.
+	TransactionType string `protobuf:"bytes,2,opt,name=transaction_type,json=transactionType,proto3" json:"transaction_type,omitempty"`
+	Token           string `protobuf:"bytes,3,opt,name=token,proto3" json:"token,omitempty"`
+	// Embeds a summary of each entry's owner, fetched in one batch query.
+	IncludeUser bool `protobuf:"varint,4,opt,name=include_user,json=includeUser,proto3" json:"include_user,omitempty"`
+	// Opaque form of user_id; see PUBLIC_ID_MODE.
+	UserPublicId string `protobuf:"bytes,5,opt,name=user_public_id,json=userPublicId,proto3" json:"user_public_id,omitempty"`
+	// Filters by transaction type. Unspecified lists every type.
+	Type TransactionType `protobuf:"varint,6,opt,name=type,proto3,enum=proto.v1.TransactionType" json:"type,omitempty"`
+	// Cursor: the next_after_id of the previous page.
+	AfterId int64 `protobuf:"varint,7,opt,name=after_id,json=afterId,proto3" json:"after_id,omitempty"`
+	// At most the caller's maximum page size. Zero lists every match in one
+	// response, and fails with INVALID_ARGUMENT when there are more than the
+	// maximum.
+	PageSize int32 `protobuf:"varint,8,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Cursor: the next_page_token of the previous page. Replaces after_id and
+	// works in either order; repeat newest_first alongside it.
+	PageToken string `protobuf:"bytes,9,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Lists the newest entries first.
+	NewestFirst   bool `protobuf:"varint,10,opt,name=newest_first,json=newestFirst,proto3" json:"newest_first,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLedgersRequest) Reset() {
+	*x = ListLedgersRequest{}
+	mi := &file_ledger_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLedgersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLedgersRequest) ProtoMessage() {}
+
+func (x *ListLedgersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLedgersRequest.ProtoReflect.Descriptor instead.
+func (*ListLedgersRequest) Descriptor() ([]byte, []int) {
+	return file_ledger_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ListLedgersRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+// Deprecated: Marked as deprecated in ledger.proto.
+func (x *ListLedgersRequest) GetTransactionType() string {
+	if x != nil {
+		return x.TransactionType
+	}
+	return ""
+}
+
+func (x *ListLedgersRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *ListLedgersRequest) GetIncludeUser() bool {
+	if x != nil {
+		return x.IncludeUser
+	}
+	return false
+}
+
+func (x *ListLedgersRequest) GetUserPublicId() string {
+	if x != nil {
+		return x.UserPublicId
+	}
+	return ""
+}
+
+func (x *ListLedgersRequest) GetType() TransactionType {
+	if x != nil {
+		return x.Type
+	}
+	return TransactionType_TRANSACTION_TYPE_UNSPECIFIED
+}
+
+func (x *ListLedgersRequest) GetAfterId() int64 {
+	if x != nil {
+		return x.AfterId
+	}
+	return 0
+}
+
+func (x *ListLedgersRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListLedgersRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListLedgersRequest) GetNewestFirst() bool {
+	if x != nil {
+		return x.NewestFirst
+	}
+	return false
+}
+
+type ListLedgersResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Ledgers []*Ledger              `protobuf:"bytes,1,rep,name=ledgers,proto3" json:"ledgers,omitempty"`
+	// Zero when there are no further pages, or page_size was zero. Only set
+	// in id order.
+	NextAfterId int64 `protobuf:"varint,2,opt,name=next_after_id,json=nextAfterId,proto3" json:"next_after_id,omitempty"`
+	// Empty when there are no further pages, or page_size was zero.
+	NextPageToken string `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLedgersResponse) Reset() {
+	*x = ListLedgersResponse{}
+	mi := &file_ledger_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLedgersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLedgersResponse) ProtoMessage() {}
+
+func (x *ListLedgersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLedgersResponse.ProtoReflect.Descriptor instead.
+func (*ListLedgersResponse) Descriptor() ([]byte, []int) {
+	return file_ledger_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListLedgersResponse) GetLedgers() []*Ledger {
+	if x != nil {
+		return x.Ledgers
+	}
+	return nil
+}
+
+func (x *ListLedgersResponse) GetNextAfterId() int64 {
+	if x != nil {
+		return x.NextAfterId
+	}
+	return 0
+}
+
+func (x *ListLedgersResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type Ledger struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Use type. Still set for older clients.
+	//
+	// Deprecated: Marked as deprecated in ledger.proto.
+	TransactionType string                 `protobuf:"bytes,3,opt,name=transaction_type,json=transactionType,proto3" json:"transaction_type,omitempty"`
+	Token           string                 `protobuf:"bytes,4,opt,name=token,proto3" json:"token,omitempty"`
+	Amount          *DecimalValue          `protobuf:"bytes,5,opt,name=amount,proto3" json:"amount,omitempty"`
+	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Set only when include_user is true and the owner exists.
+	User *UserSummary `protobuf:"bytes,7,opt,name=user,proto3" json:"user,omitempty"`
+	// Opaque forms of id and user_id, set when PUBLIC_ID_MODE is dual or
+	// opaque.
+	PublicId      string          `protobuf:"bytes,8,opt,name=public_id,json=publicId,proto3" json:"public_id,omitempty"`
+	UserPublicId  string          `protobuf:"bytes,9,opt,name=user_public_id,json=userPublicId,proto3" json:"user_public_id,omitempty"`
+	Type          TransactionType `protobuf:"varint,10,opt,name=type,proto3,enum=proto.v1.TransactionType" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ledger) Reset() {
+	*x = Ledger{}
+	mi := &file_ledger_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ledger) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ledger) ProtoMessage() {}
+
+func (x *Ledger) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ledger.ProtoReflect.Descriptor instead.
+func (*Ledger) Descriptor() ([]byte, []int) {
+	return file_ledger_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Ledger) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Ledger) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+// Deprecated: Marked as deprecated in ledger.proto.
+func (x *Ledger) GetTransactionType() string {
+	if x != nil {
+		return x.TransactionType
+	}
+	return ""
+}
+
+func (x *Ledger) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *Ledger) GetAmount() *DecimalValue {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *Ledger) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Ledger) GetUser() *UserSummary {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *Ledger) GetPublicId() string {
+	if x != nil {
+		return x.PublicId
+	}
+	return ""
+}
+
+func (x *Ledger) GetUserPublicId() string {
+	if x != nil {
+		return x.UserPublicId
+	}
+	return ""
+}
+
+func (x *Ledger) GetType() TransactionType {
+	if x != nil {
+		return x.Type
+	}
+	return TransactionType_TRANSACTION_TYPE_UNSPECIFIED
+}
+
+type UserSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Status        UserStatus             `protobuf:"varint,3,opt,name=status,proto3,enum=proto.v1.UserStatus" json:"status,omitempty"`
+	PublicId      string                 `protobuf:"bytes,4,opt,name=public_id,json=publicId,proto3" json:"public_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserSummary) Reset() {
+	*x = UserSummary{}
+	mi := &file_ledger_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserSummary) ProtoMessage() {}
+
+func (x *UserSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserSummary.ProtoReflect.Descriptor instead.
+func (*UserSummary) Descriptor() ([]byte, []int) {
+	return file_ledger_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UserSummary) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UserSummary) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *UserSummary) GetStatus() UserStatus {
+	if x != nil {
+		return x.Status
+	}
+	return UserStatus_USER_STATUS_UNSPECIFIED
+}
+
+func (x *UserSummary) GetPublicId() string {
+	if x != nil {
+		return x.PublicId
+	}
+	return ""
+}
+
+var File_ledger_proto protoreflect.FileDescriptor
+
+const file_ledger_proto_rawDesc = "" +
+	"\n" +
+	"\fledger.proto\x12\bproto.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\rdecimal.proto\x1a\n" +
+	"user.proto\"\xf6\x02\n" +
+	"\x12ListLedgersRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12-\n" +
+	"\x10transaction_type\x18\x02 \x01(\tB\x02\x18\x01R\x0ftransactionType\x12\x14\n" +
+	"\x05token\x18\x03 \x01(\tR\x05token\x12!\n" +
+	"\finclude_user\x18\x04 \x01(\bR\vincludeUser\x12$\n" +
+	"\x0euser_public_id\x18\x05 \x01(\tR\fuserPublicId\x12-\n" +
+	"\x04type\x18\x06 \x01(\x0e2\x19.proto.v1.TransactionTypeR\x04type\x12\"\n" +
+	"\bafter_id\x18\a \x01(\x03B\a\xbaH\x04\"\x02(\x00R\aafterId\x12$\n" +
+	"\tpage_size\x18\b \x01(\x05B\a\xbaH\x04\x1a\x02(\x00R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\t \x01(\tR\tpageToken\x12!\n" +
+	"\fnewest_first\x18\n" +
+	" \x01(\bR\vnewestFirst\"\x8d\x01\n" +
+	"\x13ListLedgersResponse\x12*\n" +
+	"\aledgers\x18\x01 \x03(\v2\x10.proto.v1.LedgerR\aledgers\x12\"\n" +
+	"\rnext_after_id\x18\x02 \x01(\x03R\vnextAfterId\x12&\n" +
+	"\x0fnext_page_token\x18\x03 \x01(\tR\rnextPageToken\"\xfe\x02\n" +
+	"\x06Ledger\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\x12-\n" +
+	"\x10transaction_type\x18\x03 \x01(\tB\x02\x18\x01R\x0ftransactionType\x12\x14\n" +
+	"\x05token\x18\x04 \x01(\tR\x05token\x12.\n" +
+	"\x06amount\x18\x05 \x01(\v2\x16.proto.v1.DecimalValueR\x06amount\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12)\n" +
+	"\x04user\x18\a \x01(\v2\x15.proto.v1.UserSummaryR\x04user\x12\x1b\n" +
+	"\tpublic_id\x18\b \x01(\tR\bpublicId\x12$\n" +
+	"\x0euser_public_id\x18\t \x01(\tR\fuserPublicId\x12-\n" +
+	"\x04type\x18\n" +
+	" \x01(\x0e2\x19.proto.v1.TransactionTypeR\x04type\"\x84\x01\n" +
+	"\vUserSummary\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12,\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x14.proto.v1.UserStatusR\x06status\x12\x1b\n" +
+	"\tpublic_id\x18\x04 \x01(\tR\bpublicId*\x8f\x01\n" +
+	"\x0fTransactionType\x12 \n" +
+	"\x1cTRANSACTION_TYPE_UNSPECIFIED\x10\x00\x12\x1c\n" +
+	"\x18TRANSACTION_TYPE_DEPOSIT\x10\x01\x12\x1d\n" +
+	"\x19TRANSACTION_TYPE_WITHDRAW\x10\x02\x12\x1d\n" +
+	"\x19TRANSACTION_TYPE_TRANSFER\x10\x032]\n" +
+	"\rLedgerService\x12L\n" +
+	"\vListLedgers\x12\x1c.proto.v1.ListLedgersRequest\x1a\x1d.proto.v1.ListLedgersResponse\"\x00B/Z-github.com/jt828/go-grpc-template/proto/v1;v1b\x06proto3"
+
+var (
+	file_ledger_proto_rawDescOnce sync.Once
+	file_ledger_proto_rawDescData []byte
+)
+
+func file_ledger_proto_rawDescGZIP() []byte {
+	file_ledger_proto_rawDescOnce.Do(func() {
+		file_ledger_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ledger_proto_rawDesc), len(file_ledger_proto_rawDesc)))
+	})
+	return file_ledger_proto_rawDescData
+}
+
+var file_ledger_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_ledger_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_ledger_proto_goTypes = []any{
+	(TransactionType)(0),          // 0: proto.v1.TransactionType
+	(*ListLedgersRequest)(nil),    // 1: proto.v1.ListLedgersRequest
+	(*ListLedgersResponse)(nil),   // 2: proto.v1.ListLedgersResponse
+	(*Ledger)(nil),                // 3: proto.v1.Ledger
+	(*UserSummary)(nil),           // 4: proto.v1.UserSummary
+	(*DecimalValue)(nil),          // 5: proto.v1.DecimalValue
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+	(UserStatus)(0),               // 7: proto.v1.UserStatus
+}
+var file_ledger_proto_depIdxs = []int32{
+	0, // 0: proto.v1.ListLedgersRequest.type:type_name -> proto.v1.TransactionType
+	3, // 1: proto.v1.ListLedgersResponse.ledgers:type_name -> proto.v1.Ledger
+	5, // 2: proto.v1.Ledger.amount:type_name -> proto.v1.DecimalValue
+	6, // 3: proto.v1.Ledger.created_at:type_name -> google.protobuf.Timestamp
+	4, // 4: proto.v1.Ledger.user:type_name -> proto.v1.UserSummary
+	0, // 5: proto.v1.Ledger.type:type_name -> proto.v1.TransactionType
+	7, // 6: proto.v1.UserSummary.status:type_name -> proto.v1.UserStatus
+	1, // 7: proto.v1.LedgerService.ListLedgers:input_type -> proto.v1.ListLedgersRequest
+	2, // 8: proto.v1.LedgerService.ListLedgers:output_type -> proto.v1.ListLedgersResponse
+	8, // [8:9] is the sub-list for method output_type
+	7, // [7:8] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_ledger_proto_init() }
+func file_ledger_proto_init() {
+	if File_ledger_proto != nil {
+		return
+	}
+	file_decimal_proto_init()
+	file_user_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ledger_proto_rawDesc), len(file_ledger_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ledger_proto_goTypes,
+		DependencyIndexes: file_ledger_proto_depIdxs,
+		EnumInfos:         file_ledger_proto_enumTypes,
+		MessageInfos:      file_ledger_proto_msgTypes,
+	}.Build()
+	File_ledger_proto = out.File
+	file_ledger_proto_goTypes = nil
+	file_ledger_proto_depIdxs = nil
+}
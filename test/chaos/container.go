@@ -0,0 +1,65 @@
+package chaos
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// Pause freezes every process in the container. The returned resume func is
+// idempotent, safe to call from any goroutine, and is also registered with
+// t.Cleanup, so a failing test never leaves a paused container behind. A call
+// made while another is unpausing waits for it to finish.
+func Pause(t testing.TB, ctr testcontainers.Container) (resume func()) {
+	t.Helper()
+	ctx := context.Background()
+
+	dockerClient := newDockerClient(t)
+	require.NoError(t, dockerClient.ContainerPause(ctx, ctr.GetContainerID()))
+
+	var once sync.Once
+	resume = func() {
+		once.Do(func() {
+			_ = dockerClient.ContainerUnpause(ctx, ctr.GetContainerID())
+		})
+	}
+	t.Cleanup(resume)
+	return resume
+}
+
+// PauseFor pauses the container and resumes it in the background after d.
+func PauseFor(t testing.TB, ctr testcontainers.Container, d time.Duration) {
+	t.Helper()
+
+	resume := Pause(t, ctr)
+	timer := time.AfterFunc(d, resume)
+	t.Cleanup(func() { timer.Stop() })
+}
+
+// KillAndRestart sends SIGKILL to the container and starts it again after
+// downtime. Docker may assign new host ports on restart, so clients should
+// reach the container through a Proxy rather than a mapped port.
+func KillAndRestart(t testing.TB, ctr testcontainers.Container, downtime time.Duration) {
+	t.Helper()
+	ctx := context.Background()
+
+	dockerClient := newDockerClient(t)
+	require.NoError(t, dockerClient.ContainerKill(ctx, ctr.GetContainerID(), "SIGKILL"))
+
+	time.Sleep(downtime)
+	require.NoError(t, dockerClient.ContainerStart(ctx, ctr.GetContainerID(), container.StartOptions{}))
+}
+
+func newDockerClient(t testing.TB) *testcontainers.DockerClient {
+	t.Helper()
+
+	dockerClient, err := testcontainers.NewDockerClientWithOpts(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dockerClient.Close() })
+	return dockerClient
+}
@@ -0,0 +1,182 @@
+package chaos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/network"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	toxiproxyImage   = "ghcr.io/shopify/toxiproxy:2.12.0"
+	toxiproxyApiPort = "8474/tcp"
+	// proxies listen on consecutive ports starting here; only the first
+	// toxiproxyMaxProxies are exposed on the host.
+	toxiproxyFirstProxyPort = 8666
+	toxiproxyMaxProxies     = 4
+)
+
+// Toxiproxy is a toxiproxy container attached to the test network. It is
+// driven through its HTTP API so no client library is needed.
+type Toxiproxy struct {
+	container testcontainers.Container
+	apiUrl    string
+	proxies   int
+}
+
+func StartToxiproxy(t testing.TB, nw *testcontainers.DockerNetwork) *Toxiproxy {
+	t.Helper()
+	ctx := context.Background()
+
+	exposed := []string{toxiproxyApiPort}
+	for i := 0; i < toxiproxyMaxProxies; i++ {
+		exposed = append(exposed, fmt.Sprintf("%d/tcp", toxiproxyFirstProxyPort+i))
+	}
+
+	req := testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        toxiproxyImage,
+			ExposedPorts: exposed,
+			WaitingFor:   wait.ForHTTP("/version").WithPort(toxiproxyApiPort),
+		},
+		Started: true,
+	}
+	require.NoError(t, network.WithNetwork([]string{"toxiproxy"}, nw).Customize(&req))
+
+	ctr, err := testcontainers.GenericContainer(ctx, req)
+	testcontainers.CleanupContainer(t, ctr)
+	require.NoError(t, err)
+
+	host, err := ctr.Host(ctx)
+	require.NoError(t, err)
+	port, err := ctr.MappedPort(ctx, toxiproxyApiPort)
+	require.NoError(t, err)
+
+	return &Toxiproxy{container: ctr, apiUrl: fmt.Sprintf("http://%s:%s", host, port.Port())}
+}
+
+// Proxy forwards Addr (reachable from the test process) to an upstream
+// address resolved inside the docker network.
+type Proxy struct {
+	toxiproxy *Toxiproxy
+	name      string
+	Addr      string
+}
+
+func (tp *Toxiproxy) CreateProxy(t testing.TB, name, upstream string) *Proxy {
+	t.Helper()
+	require.Less(t, tp.proxies, toxiproxyMaxProxies, "toxiproxy exposes at most %d proxies", toxiproxyMaxProxies)
+	ctx := context.Background()
+
+	listenPort := toxiproxyFirstProxyPort + tp.proxies
+	tp.proxies++
+
+	tp.request(t, http.MethodPost, "/proxies", map[string]any{
+		"name":     name,
+		"listen":   fmt.Sprintf("0.0.0.0:%d", listenPort),
+		"upstream": upstream,
+		"enabled":  true,
+	})
+
+	host, err := tp.container.Host(ctx)
+	require.NoError(t, err)
+	port, err := tp.container.MappedPort(ctx, nat.Port(fmt.Sprintf("%d/tcp", listenPort)))
+	require.NoError(t, err)
+
+	return &Proxy{toxiproxy: tp, name: name, Addr: fmt.Sprintf("%s:%s", host, port.Port())}
+}
+
+// AddToxic installs a toxic on the proxy and returns a func removing it. The
+// toxic is also removed on test cleanup.
+func (p *Proxy) AddToxic(t testing.TB, name, toxicType, stream string, attributes map[string]any) (remove func()) {
+	t.Helper()
+
+	p.toxiproxy.request(t, http.MethodPost, "/proxies/"+p.name+"/toxics", map[string]any{
+		"name":       name,
+		"type":       toxicType,
+		"stream":     stream,
+		"toxicity":   1.0,
+		"attributes": attributes,
+	})
+
+	var removed bool
+	remove = func() {
+		if removed {
+			return
+		}
+		removed = true
+		p.toxiproxy.request(t, http.MethodDelete, "/proxies/"+p.name+"/toxics/"+name, nil)
+	}
+	t.Cleanup(remove)
+	return remove
+}
+
+// SetEnabled enables or disables the proxy. Disabling closes all open
+// connections and refuses new ones.
+func (p *Proxy) SetEnabled(t testing.TB, enabled bool) {
+	t.Helper()
+	p.toxiproxy.request(t, http.MethodPost, "/proxies/"+p.name, map[string]any{"enabled": enabled})
+}
+
+// AddNetworkLatency delays every response from upstream by latency (± jitter).
+func AddNetworkLatency(t testing.TB, proxy *Proxy, latency, jitter time.Duration) (remove func()) {
+	t.Helper()
+	return proxy.AddToxic(t, "latency_downstream", "latency", "downstream", map[string]any{
+		"latency": latency.Milliseconds(),
+		"jitter":  jitter.Milliseconds(),
+	})
+}
+
+// LimitBandwidth caps the throughput of responses from upstream to rateKB
+// kilobytes per second.
+func LimitBandwidth(t testing.TB, proxy *Proxy, rateKB int) (remove func()) {
+	t.Helper()
+	return proxy.AddToxic(t, "bandwidth_downstream", "bandwidth", "downstream", map[string]any{
+		"rate": rateKB,
+	})
+}
+
+// ResetConnections closes connections with a TCP RST after timeout has
+// elapsed. A zero timeout resets every connection as soon as data arrives.
+func ResetConnections(t testing.TB, proxy *Proxy, timeout time.Duration) (remove func()) {
+	t.Helper()
+	return proxy.AddToxic(t, "reset_peer_upstream", "reset_peer", "upstream", map[string]any{
+		"timeout": timeout.Milliseconds(),
+	})
+}
+
+// Blackhole stops all data from reaching upstream without closing the
+// connection, simulating a network partition that never surfaces an error.
+func Blackhole(t testing.TB, proxy *Proxy) (remove func()) {
+	t.Helper()
+	return proxy.AddToxic(t, "timeout_upstream", "timeout", "upstream", map[string]any{
+		"timeout": 0,
+	})
+}
+
+func (tp *Toxiproxy) request(t testing.TB, method, path string, body any) {
+	t.Helper()
+
+	var payload bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&payload).Encode(body))
+	}
+
+	req, err := http.NewRequest(method, tp.apiUrl+path, &payload)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Less(t, resp.StatusCode, 300, "toxiproxy %s %s returned %d", method, path, resp.StatusCode)
+}
@@ -0,0 +1,178 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	cbImpl "github.com/jt828/go-grpc-template/pkg/circuitbreaker/implementation"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	retryImpl "github.com/jt828/go-grpc-template/pkg/retry/implementation"
+	"github.com/jt828/go-grpc-template/test/chaos"
+	"github.com/sony/gobreaker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/network"
+	"github.com/testcontainers/testcontainers-go/wait"
+	pgdriver "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type chaosDB struct {
+	db        *gorm.DB
+	container *tcpostgres.PostgresContainer
+	proxy     *chaos.Proxy
+}
+
+// setupChaosDB starts Postgres behind toxiproxy on a private network. The
+// test process only ever talks to the proxy, so the DSN survives container
+// restarts and network faults can be injected on the proxy.
+func setupChaosDB(t *testing.T) *chaosDB {
+	t.Helper()
+	ctx := context.Background()
+
+	nw, err := network.New(ctx)
+	testcontainers.CleanupNetwork(t, nw)
+	require.NoError(t, err)
+
+	pgContainer, err := tcpostgres.Run(ctx,
+		"postgres:16-alpine",
+		tcpostgres.WithDatabase("testdb"),
+		tcpostgres.WithUsername("test"),
+		tcpostgres.WithPassword("test"),
+		tcpostgres.WithInitScripts("testdata/init_schema.sql"),
+		network.WithNetwork([]string{"postgres"}, nw),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second),
+		),
+	)
+	testcontainers.CleanupContainer(t, pgContainer)
+	require.NoError(t, err)
+
+	proxy := chaos.StartToxiproxy(t, nw).CreateProxy(t, "postgres", "postgres:5432")
+
+	dsn := fmt.Sprintf("postgres://test:test@%s/testdb?sslmode=disable", proxy.Addr)
+	db, err := gorm.Open(pgdriver.Open(dsn), &gorm.Config{NamingStrategy: model.NamingStrategy(model.DefaultSchema)})
+	require.NoError(t, err)
+
+	now := time.Now().Truncate(time.Second)
+	seedUser(t, db, &model.UserDataEntity{
+		Id:        1,
+		Email:     "test@example.com",
+		Username:  "testuser",
+		Password:  "hashed_password",
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+
+	return &chaosDB{db: db, container: pgContainer, proxy: proxy}
+}
+
+func TestChaos_CircuitBreakerOpensWhileDBPaused(t *testing.T) {
+	cdb := setupChaosDB(t)
+
+	cb := cbImpl.NewCircuitBreaker(gobreaker.Settings{
+		Name: "test",
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= 3
+		},
+		Timeout: time.Minute,
+	})
+	r := retryImpl.NewRetry(0, retry.WithInterval(100*time.Millisecond), retry.WithRetryable(func(err error) bool { return false }))
+	repo := repository.NewUserRepository(cdb.db, cb, r, false)
+
+	resume := chaos.Pause(t, cdb.container)
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		_, err := repo.Get(ctx, 1)
+		cancel()
+		require.Error(t, err)
+	}
+	assert.Equal(t, circuitbreaker.Open, cb.State())
+
+	// an open breaker fails fast without touching the database
+	start := time.Now()
+	_, err := repo.Get(context.Background(), 1)
+	assert.ErrorIs(t, err, gobreaker.ErrOpenState)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	resume()
+}
+
+func TestChaos_RetryExhaustedWhileDBUnreachable(t *testing.T) {
+	cdb := setupChaosDB(t)
+
+	cb := cbImpl.NewCircuitBreaker(gobreaker.Settings{
+		Name: "test",
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return false
+		},
+	})
+	interval := 100 * time.Millisecond
+	r := retryImpl.NewRetry(2, retry.WithInterval(interval), retry.WithRetryable(func(err error) bool {
+		return true
+	}))
+	repo := repository.NewUserRepository(cdb.db, cb, r, false)
+
+	cdb.proxy.SetEnabled(t, false)
+
+	// two retries with exponential backoff wait at least interval + 2*interval
+	start := time.Now()
+	user, err := repo.Get(context.Background(), 1)
+	require.Error(t, err)
+	assert.Nil(t, user)
+	assert.GreaterOrEqual(t, time.Since(start), 3*interval)
+
+	cdb.proxy.SetEnabled(t, true)
+
+	user, err = repo.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), user.Id)
+}
+
+func TestChaos_RecoversAfterKillAndRestart(t *testing.T) {
+	cdb := setupChaosDB(t)
+
+	cb := cbImpl.NewCircuitBreaker(gobreaker.Settings{
+		Name: "test",
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return false
+		},
+	})
+	r := retryImpl.NewRetry(10, retry.WithInterval(500*time.Millisecond), retry.WithRetryable(func(err error) bool {
+		return true
+	}))
+	repo := repository.NewUserRepository(cdb.db, cb, r, false)
+
+	chaos.KillAndRestart(t, cdb.container, time.Second)
+
+	user, err := repo.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), user.Id)
+}
+
+func TestChaos_NetworkLatency(t *testing.T) {
+	cdb := setupChaosDB(t)
+
+	cb := cbImpl.NewCircuitBreaker(gobreaker.Settings{Name: "test"})
+	r := retryImpl.NewRetry(0, retry.WithInterval(100*time.Millisecond), retry.WithRetryable(func(err error) bool { return false }))
+	repo := repository.NewUserRepository(cdb.db, cb, r, false)
+
+	latency := 300 * time.Millisecond
+	chaos.AddNetworkLatency(t, cdb.proxy, latency, 0)
+
+	start := time.Now()
+	user, err := repo.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), user.Id)
+	assert.GreaterOrEqual(t, time.Since(start), latency)
+}
@@ -0,0 +1,93 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	cbImpl "github.com/jt828/go-grpc-template/pkg/circuitbreaker/implementation"
+	idempotencyImpl "github.com/jt828/go-grpc-template/pkg/idempotency/implementation"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/password"
+	passwordImpl "github.com/jt828/go-grpc-template/pkg/password/implementation"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	retryImpl "github.com/jt828/go-grpc-template/pkg/retry/implementation"
+	snowflakeImpl "github.com/jt828/go-grpc-template/pkg/snowflake/implementation"
+	"github.com/sony/gobreaker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateUser_ConcurrentIdempotency(t *testing.T) {
+	db := setupCreateUserTestDB(t)
+	ctx := context.Background()
+
+	cb := cbImpl.NewCircuitBreaker(gobreaker.Settings{Name: "test"})
+	r := retryImpl.NewRetry(3, retry.WithInterval(100*time.Millisecond), retry.WithRetryable(func(err error) bool {
+		return false
+	}))
+
+	uowFactory := repository.NewTransactionDbUnitOfWorkFactory(db, cb, r)
+	sf, err := snowflakeImpl.NewSnowflake(1)
+	require.NoError(t, err)
+
+	userSvc := service.NewUserService(uowFactory, idempotencyImpl.NewIdempotency(), sf, passwordImpl.NewHasher(password.AlgorithmArgon2id), nil, &noopTracer{})
+
+	const concurrency = 20
+
+	for round, idempotencyId := range []int64{3001, 3002, 3003} {
+		t.Run(fmt.Sprintf("round %d", round), func(t *testing.T) {
+			var (
+				wg      sync.WaitGroup
+				start   = make(chan struct{})
+				results = make([]*model.User, concurrency)
+				errs    = make([]error, concurrency)
+			)
+
+			for i := 0; i < concurrency; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					user := &model.User{
+						Email:    fmt.Sprintf("user%d-%d@example.com", idempotencyId, i),
+						Username: fmt.Sprintf("user%d-%d", idempotencyId, i),
+						Password: "hashed_password",
+					}
+					<-start
+					results[i], errs[i] = userSvc.CreateUser(ctx, idempotencyId, user)
+				}(i)
+			}
+
+			// Release every goroutine at once to maximise contention on the key.
+			close(start)
+			wg.Wait()
+
+			for i := 0; i < concurrency; i++ {
+				require.NoError(t, errs[i], "request %d", i)
+			}
+
+			first := results[0]
+			for i := 1; i < concurrency; i++ {
+				assert.Equal(t, first.Id, results[i].Id, "request %d", i)
+				assert.Equal(t, first.Email, results[i].Email, "request %d", i)
+				assert.Equal(t, first.Username, results[i].Username, "request %d", i)
+			}
+
+			var userCount int64
+			require.NoError(t, db.Model(&model.UserDataEntity{}).Where("id = ?", first.Id).Count(&userCount).Error)
+			assert.Equal(t, int64(1), userCount)
+
+			var prefixCount int64
+			require.NoError(t, db.Model(&model.UserDataEntity{}).Where("username LIKE ?", fmt.Sprintf("user%d-%%", idempotencyId)).Count(&prefixCount).Error)
+			assert.Equal(t, int64(1), prefixCount, "exactly one user row per idempotency key")
+
+			var recordCount int64
+			require.NoError(t, db.Model(&model.IdempotencyRecordDataEntity{}).Where("id = ?", idempotencyId).Count(&recordCount).Error)
+			assert.Equal(t, int64(1), recordCount)
+		})
+	}
+}
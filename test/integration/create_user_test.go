@@ -5,11 +5,14 @@ import (
 	"testing"
 	"time"
 
-	"github.com/jt828/go-grpc-template/pkg/model"
 	"github.com/jt828/go-grpc-template/internal/repository"
 	"github.com/jt828/go-grpc-template/internal/service"
 	cbImpl "github.com/jt828/go-grpc-template/pkg/circuitbreaker/implementation"
 	idempotencyImpl "github.com/jt828/go-grpc-template/pkg/idempotency/implementation"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/password"
+	passwordImpl "github.com/jt828/go-grpc-template/pkg/password/implementation"
 	"github.com/jt828/go-grpc-template/pkg/retry"
 	retryImpl "github.com/jt828/go-grpc-template/pkg/retry/implementation"
 	snowflakeImpl "github.com/jt828/go-grpc-template/pkg/snowflake/implementation"
@@ -23,6 +26,18 @@ import (
 	"gorm.io/gorm"
 )
 
+type noopSpan struct{}
+
+func (n *noopSpan) End()                                        {}
+func (n *noopSpan) RecordError(err error)                       {}
+func (n *noopSpan) SetAttributes(fields ...observability.Field) {}
+
+type noopTracer struct{}
+
+func (n *noopTracer) Start(ctx context.Context, name string) (context.Context, observability.Span) {
+	return ctx, &noopSpan{}
+}
+
 func setupCreateUserTestDB(t *testing.T) *gorm.DB {
 	t.Helper()
 	ctx := context.Background()
@@ -50,7 +65,7 @@ func setupCreateUserTestDB(t *testing.T) *gorm.DB {
 	dsn, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
 	require.NoError(t, err)
 
-	db, err := gorm.Open(pgdriver.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(pgdriver.Open(dsn), &gorm.Config{NamingStrategy: model.NamingStrategy(model.DefaultSchema)})
 	require.NoError(t, err)
 
 	return db
@@ -70,7 +85,7 @@ func TestCreateUser_Idempotency(t *testing.T) {
 	sf, err := snowflakeImpl.NewSnowflake(1)
 	require.NoError(t, err)
 
-	userSvc := service.NewUserService(uowFactory, idem, sf)
+	userSvc := service.NewUserService(uowFactory, idem, sf, passwordImpl.NewHasher(password.AlgorithmArgon2id), nil, &noopTracer{})
 
 	t.Run("create new user with new idempotency key", func(t *testing.T) {
 		user := &model.User{
@@ -0,0 +1,79 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	cbImpl "github.com/jt828/go-grpc-template/pkg/circuitbreaker/implementation"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/pgclass"
+	"github.com/shopspring/decimal"
+	"github.com/sony/gobreaker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerBulkLoader_Load(t *testing.T) {
+	ctx := context.Background()
+	db := migratedDB(t, "../../migrations")
+	loader := repository.NewLedgerBulkLoader(db, model.DefaultSchema, cbImpl.NewCircuitBreaker(gobreaker.Settings{Name: "test"}))
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("copies every row and reports progress", func(t *testing.T) {
+		const rows = 2*repository.LedgerProgressInterval + 5
+		ledgers := make([]*model.Ledger, rows)
+		for i := range ledgers {
+			ledgers[i] = &model.Ledger{
+				Id:              int64(i + 1),
+				UserId:          10,
+				TransactionType: "deposit",
+				Token:           "ETH",
+				Amount:          decimal.RequireFromString("12.3456789012345678"),
+				CreatedAt:       createdAt,
+			}
+		}
+
+		var progress []int64
+		loaded, err := loader.Load(audit.ContextWithActor(ctx, "user:7"), repository.LedgerSlice(ledgers), func(n int64) { progress = append(progress, n) })
+		require.NoError(t, err)
+		assert.Equal(t, int64(rows), loaded)
+		assert.Equal(t, []int64{repository.LedgerProgressInterval, 2 * repository.LedgerProgressInterval, rows}, progress)
+
+		var count int64
+		require.NoError(t, db.Model(&model.LedgerDataEntity{}).Count(&count).Error)
+		assert.Equal(t, int64(rows), count)
+
+		var stored model.LedgerDataEntity
+		require.NoError(t, db.Where("id = ?", rows).Take(&stored).Error)
+		assert.True(t, ledgers[rows-1].Amount.Equal(stored.Amount))
+		assert.True(t, createdAt.Equal(stored.CreatedAt))
+		assert.Equal(t, "user:7", stored.CreatedBy)
+	})
+
+	t.Run("duplicate id aborts the whole copy", func(t *testing.T) {
+		ledgers := []*model.Ledger{
+			{Id: 1_000_000, UserId: 10, TransactionType: "deposit", Token: "ETH", Amount: decimal.NewFromInt(1), CreatedAt: createdAt},
+			{Id: 1, UserId: 10, TransactionType: "deposit", Token: "ETH", Amount: decimal.NewFromInt(1), CreatedAt: createdAt},
+		}
+
+		_, err := loader.Load(ctx, repository.LedgerSlice(ledgers), nil)
+		require.Error(t, err)
+
+		var count int64
+		require.NoError(t, db.Model(&model.LedgerDataEntity{}).Where("id = ?", 1_000_000).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("unknown transaction type violates the check constraint", func(t *testing.T) {
+		ledgers := []*model.Ledger{
+			{Id: 2_000_000, UserId: 10, TransactionType: "refund", Token: "ETH", Amount: decimal.NewFromInt(1), CreatedAt: createdAt},
+		}
+
+		_, err := loader.Load(ctx, repository.LedgerSlice(ledgers), nil)
+		require.Error(t, err)
+		assert.Equal(t, "23514", pgclass.Code(err))
+	})
+}
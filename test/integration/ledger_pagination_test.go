@@ -0,0 +1,57 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	cbImpl "github.com/jt828/go-grpc-template/pkg/circuitbreaker/implementation"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	retryImpl "github.com/jt828/go-grpc-template/pkg/retry/implementation"
+	"github.com/sony/gobreaker/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// ledgerBenchRows is the size of the table the pagination benchmark pages
+// through. Seeding takes a minute or two.
+const ledgerBenchRows = 10_000_000
+
+// BenchmarkLedgerPagination compares reading a newest-first page at growing
+// depths with OFFSET against keyset pagination on the id cursor.
+// Run with: go test ./test/integration/ -run '^$' -bench LedgerPagination -timeout 30m
+func BenchmarkLedgerPagination(b *testing.B) {
+	const pageSize = 100
+	ctx := context.Background()
+	db := migratedDB(b, "../../migrations")
+	// Ids are spaced like snowflake ids, which put a timestamp above 22 bits
+	// of node and sequence.
+	require.NoError(b, db.Exec(fmt.Sprintf(`INSERT INTO ledgers (id, user_id, transaction_type, token, amount)
+		SELECT n << 22, n %% 1000, 'deposit', 'ETH', 1 FROM generate_series(1, %d) AS n`, ledgerBenchRows)).Error)
+	require.NoError(b, db.Exec("ANALYZE ledgers").Error)
+
+	repo := repository.NewLedgerRepository(db,
+		cbImpl.NewCircuitBreaker(gobreaker.Settings{Name: "bench"}),
+		retryImpl.NewRetry(0),
+		false)
+
+	for _, depth := range []int{0, 10_000, 1_000_000, ledgerBenchRows - pageSize} {
+		b.Run(fmt.Sprintf("offset/depth=%d", depth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var entities []model.LedgerDataEntity
+				require.NoError(b, db.WithContext(ctx).Order("id DESC").Offset(depth).Limit(pageSize).Find(&entities).Error)
+				require.Len(b, entities, pageSize)
+			}
+		})
+
+		// The cursor is the id of the last row of the page before.
+		cursor := int64(ledgerBenchRows-depth+1) << 22
+		b.Run(fmt.Sprintf("keyset/depth=%d", depth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ledgers, err := repo.Get(ctx, repository.GetQuery{IdLt: cursor, Desc: true, Limit: pageSize})
+				require.NoError(b, err)
+				require.Len(b, ledgers, pageSize)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	cbImpl "github.com/jt828/go-grpc-template/pkg/circuitbreaker/implementation"
+	"github.com/jt828/go-grpc-template/pkg/pgclass"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	retryImpl "github.com/jt828/go-grpc-template/pkg/retry/implementation"
+	"github.com/jt828/go-grpc-template/test/chaos"
+	"github.com/sony/gobreaker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// classifier wraps pgclass.IsRetryable and records every decision so
+// tests can assert how network faults were classified.
+type classifier struct {
+	decisions []bool
+	onRetry   func()
+}
+
+func (c *classifier) retryable(err error) bool {
+	ok := pgclass.IsRetryable(err)
+	c.decisions = append(c.decisions, ok)
+	if ok && c.onRetry != nil {
+		c.onRetry()
+	}
+	return ok
+}
+
+func newFaultRepo(cdb *chaosDB, maxRetries uint64, c *classifier) repository.UserRepository {
+	cb := cbImpl.NewCircuitBreaker(gobreaker.Settings{Name: "test"})
+	r := retryImpl.NewRetry(maxRetries, retry.WithInterval(100*time.Millisecond), retry.WithRetryable(c.retryable))
+	return repository.NewUserRepository(cdb.db, cb, r, false)
+}
+
+func TestNetworkFault_ConnectionResetIsRetried(t *testing.T) {
+	cdb := setupChaosDB(t)
+
+	remove := chaos.ResetConnections(t, cdb.proxy, 0)
+	c := &classifier{onRetry: remove}
+	repo := newFaultRepo(cdb, 3, c)
+
+	user, err := repo.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), user.Id)
+	require.NotEmpty(t, c.decisions)
+	assert.True(t, c.decisions[0], "connection reset should be classified as retryable")
+}
+
+func TestNetworkFault_DeadlineUnderLatencyIsNotRetried(t *testing.T) {
+	cdb := setupChaosDB(t)
+
+	c := &classifier{}
+	repo := newFaultRepo(cdb, 3, c)
+
+	chaos.AddNetworkLatency(t, cdb.proxy, 2*time.Second, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := repo.Get(ctx, 1)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+	assert.Less(t, time.Since(start), 2*time.Second, "query should give up at the deadline, not wait for the response")
+	assert.Equal(t, []bool{false}, c.decisions)
+}
+
+func TestNetworkFault_DeadlineUnderBlackhole(t *testing.T) {
+	cdb := setupChaosDB(t)
+
+	c := &classifier{}
+	repo := newFaultRepo(cdb, 3, c)
+
+	chaos.Blackhole(t, cdb.proxy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := repo.Get(ctx, 1)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.Equal(t, []bool{false}, c.decisions)
+}
+
+func TestNetworkFault_BandwidthCap(t *testing.T) {
+	cdb := setupChaosDB(t)
+
+	// 64 KiB response at 16 KB/s takes roughly four seconds to arrive.
+	const query = "SELECT repeat('x', 65536)"
+	chaos.LimitBandwidth(t, cdb.proxy, 16)
+
+	t.Run("slow response exceeds deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		var payload string
+		err := cdb.db.WithContext(ctx).Raw(query).Scan(&payload).Error
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+		assert.False(t, pgclass.IsRetryable(err))
+	})
+
+	t.Run("slow response completes without deadline", func(t *testing.T) {
+		start := time.Now()
+		var payload string
+		err := cdb.db.Raw(query).Scan(&payload).Error
+		require.NoError(t, err)
+		assert.Len(t, payload, 65536)
+		assert.GreaterOrEqual(t, time.Since(start), 2*time.Second)
+	})
+}
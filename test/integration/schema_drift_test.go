@@ -0,0 +1,122 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	cbImpl "github.com/jt828/go-grpc-template/pkg/circuitbreaker/implementation"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	retryImpl "github.com/jt828/go-grpc-template/pkg/retry/implementation"
+	"github.com/sony/gobreaker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	pgdriver "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// migratedDB applies the migrations in dir to a fresh database in the main
+// schema, the way cmd/migration does.
+func migratedDB(t testing.TB, dir string) *gorm.DB {
+	t.Helper()
+	ctx := context.Background()
+
+	pgContainer, err := tcpostgres.Run(ctx,
+		"postgres:16-alpine",
+		tcpostgres.WithDatabase("testdb"),
+		tcpostgres.WithUsername("test"),
+		tcpostgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second),
+		),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, pgContainer.Terminate(ctx))
+	})
+
+	dsn, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	sqlDB, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	conn, err := sqlDB.Conn(ctx)
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+model.DefaultSchema)
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "SET search_path TO "+model.DefaultSchema)
+	require.NoError(t, err)
+
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{SchemaName: model.DefaultSchema})
+	require.NoError(t, err)
+	m, err := migrate.NewWithDatabaseInstance("file://"+dir, "postgres", driver)
+	require.NoError(t, err)
+	require.NoError(t, m.Up())
+
+	db, err := gorm.Open(pgdriver.Open(dsn), &gorm.Config{NamingStrategy: model.NamingStrategy(model.DefaultSchema)})
+	require.NoError(t, err)
+	return db
+}
+
+func TestSchemaDrift_MigrationsMatchExpectedSchema(t *testing.T) {
+	cb := cbImpl.NewCircuitBreaker(gobreaker.Settings{Name: "test"})
+	r := retryImpl.NewRetry(0)
+
+	tests := []struct {
+		name     string
+		dir      string
+		expected []model.TableSchema
+	}{
+		{"main", "../../migrations", repository.ExpectedSchema},
+		{"ledger", "../../migrations/ledger", repository.ExpectedTables("ledgers")},
+		{"idempotency", "../../migrations/idempotency", repository.ExpectedTables("idempotency_records")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := migratedDB(t, tt.dir)
+			svc := service.NewSchemaDriftService(service.SchemaStore{
+				Name:       tt.name,
+				Schema:     model.DefaultSchema,
+				Expected:   tt.expected,
+				Repository: repository.NewSchemaRepository(db, cb, r),
+			})
+
+			drifts, err := svc.CheckSchemaDrift(context.Background())
+			require.NoError(t, err)
+			assert.Empty(t, drifts, "update repository.ExpectedSchema to match the migrations")
+		})
+	}
+}
+
+func TestSchemaDrift_DetectsHotfix(t *testing.T) {
+	db := migratedDB(t, "../../migrations")
+	require.NoError(t, db.Exec("ALTER TABLE main.ledgers ADD COLUMN hotfix_flag BOOLEAN").Error)
+	require.NoError(t, db.Exec("CREATE INDEX ledgers_user_id_idx ON main.ledgers (user_id)").Error)
+
+	svc := service.NewSchemaDriftService(service.SchemaStore{
+		Name:       "postgresql",
+		Schema:     model.DefaultSchema,
+		Expected:   repository.ExpectedSchema,
+		Repository: repository.NewSchemaRepository(db, cbImpl.NewCircuitBreaker(gobreaker.Settings{Name: "test"}), retryImpl.NewRetry(0)),
+	})
+
+	drifts, err := svc.CheckSchemaDrift(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*model.SchemaDrift{
+		{Store: "postgresql", Object: "ledgers.hotfix_flag", Kind: model.SchemaDriftUnexpectedColumn, Actual: "boolean"},
+		{Store: "postgresql", Object: "ledgers_user_id_idx", Kind: model.SchemaDriftUnexpectedIndex, Actual: "ledgers"},
+	}, drifts)
+}
@@ -8,11 +8,161 @@ CREATE TABLE IF NOT EXISTS main.idempotency_records (
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
+CREATE TABLE IF NOT EXISTS main.idempotency_quarantine (
+    id BIGINT PRIMARY KEY,
+    request_type VARCHAR(255) NOT NULL,
+    reference_id BIGINT NOT NULL,
+    response_data TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    quarantined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reason TEXT NOT NULL DEFAULT ''
+);
+
 CREATE TABLE IF NOT EXISTS main.users (
     id BIGINT PRIMARY KEY,
     email VARCHAR(255) NOT NULL,
     username VARCHAR(255) NOT NULL,
     password VARCHAR(255) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    updated_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    email_verified_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS main.dead_letters (
+    id BIGINT PRIMARY KEY,
+    source VARCHAR(32) NOT NULL,
+    event_type VARCHAR(255) NOT NULL,
+    payload TEXT NOT NULL,
+    last_error TEXT NOT NULL,
+    attempts INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    replayed_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS main.inbox_messages (
+    handler VARCHAR(255) NOT NULL,
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR(255) NOT NULL,
+    processed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (handler, event_id)
+);
+
+CREATE TABLE IF NOT EXISTS main.user_status_changes (
+    id BIGINT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    from_status VARCHAR(16) NOT NULL,
+    to_status VARCHAR(16) NOT NULL,
+    reason TEXT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS main.api_usage (
+    usage_date DATE NOT NULL,
+    caller VARCHAR(255) NOT NULL,
+    method VARCHAR(255) NOT NULL,
+    requests BIGINT NOT NULL,
+    units BIGINT NOT NULL,
+    PRIMARY KEY (usage_date, caller, method)
+);
+
+CREATE TABLE IF NOT EXISTS main.api_usage_reports (
+    usage_date DATE PRIMARY KEY,
+    reported_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS main.login_failures (
+    user_id BIGINT NOT NULL,
+    ip VARCHAR(45) NOT NULL,
+    failures INT NOT NULL,
+    locked_until TIMESTAMPTZ,
+    last_failed_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, ip)
+);
+
+CREATE TABLE IF NOT EXISTS main.user_two_factor (
+    user_id BIGINT PRIMARY KEY,
+    secret TEXT NOT NULL,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    enabled_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS main.user_recovery_codes (
+    user_id BIGINT NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMPTZ,
+    PRIMARY KEY (user_id, code_hash)
+);
+
+CREATE TABLE IF NOT EXISTS main.user_sessions (
+    id BIGINT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ,
+    issuer VARCHAR(255) NOT NULL DEFAULT '',
+    external_id VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS main.user_session_events (
+    id BIGINT PRIMARY KEY,
+    session_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    event VARCHAR(16) NOT NULL,
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by VARCHAR(255) NOT NULL DEFAULT 'system'
+);
+
+CREATE TABLE IF NOT EXISTS main.user_identities (
+    issuer VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    user_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE TABLE IF NOT EXISTS main.verification_tokens (
+    token_hash CHAR(64) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS main.password_reset_tokens (
+    selector CHAR(26) PRIMARY KEY,
+    verifier_hash CHAR(64) NOT NULL,
+    user_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS main.roles (
+    name VARCHAR(64) PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by VARCHAR(255) NOT NULL DEFAULT 'system'
+);
+
+CREATE TABLE IF NOT EXISTS main.role_permissions (
+    role VARCHAR(64) NOT NULL,
+    permission VARCHAR(128) NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE IF NOT EXISTS main.user_roles (
+    user_id BIGINT NOT NULL,
+    role VARCHAR(64) NOT NULL,
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    created_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    PRIMARY KEY (user_id, role)
 );
@@ -5,12 +5,15 @@ import (
 	"testing"
 	"time"
 
-	"github.com/docker/docker/client"
-	"github.com/jt828/go-grpc-template/pkg/model"
 	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	auditImpl "github.com/jt828/go-grpc-template/pkg/audit/implementation"
 	cbImpl "github.com/jt828/go-grpc-template/pkg/circuitbreaker/implementation"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/pgclass"
 	"github.com/jt828/go-grpc-template/pkg/retry"
 	retryImpl "github.com/jt828/go-grpc-template/pkg/retry/implementation"
+	"github.com/jt828/go-grpc-template/test/chaos"
 	"github.com/sony/gobreaker/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -51,7 +54,7 @@ func setupTestDB(t *testing.T) *testDB {
 	dsn, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
 	require.NoError(t, err)
 
-	db, err := gorm.Open(pgdriver.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(pgdriver.Open(dsn), &gorm.Config{NamingStrategy: model.NamingStrategy(model.DefaultSchema)})
 	require.NoError(t, err)
 
 	return &testDB{db: db, container: pgContainer}
@@ -97,6 +100,19 @@ func TestUserRepository_Get(t *testing.T) {
 		assert.Nil(t, user)
 	})
 
+	t.Run("batch lookup returns existing users only", func(t *testing.T) {
+		users, err := repo.GetByIds(context.Background(), []int64{999, 1}, nil)
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, int64(1), users[0].Id)
+	})
+
+	t.Run("batch lookup with no ids returns nothing", func(t *testing.T) {
+		users, err := repo.GetByIds(context.Background(), nil, nil)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+	})
+
 	t.Run("non-existing user with notFoundAsError", func(t *testing.T) {
 		repoWithError := repository.NewUserRepository(tdb.db, cb, r, true)
 		user, err := repoWithError.Get(context.Background(), 999)
@@ -105,6 +121,81 @@ func TestUserRepository_Get(t *testing.T) {
 	})
 }
 
+func TestUserRepository_Insert(t *testing.T) {
+	tdb := setupTestDB(t)
+	cb := cbImpl.NewCircuitBreaker(gobreaker.Settings{Name: "test"})
+	r := retryImpl.NewRetry(3, retry.WithInterval(100*time.Millisecond), retry.WithRetryable(func(err error) bool {
+		return false
+	}))
+	repo := repository.NewUserRepository(tdb.db, cb, r, false)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	created := &model.User{
+		Id: 1, Email: "new@example.com", Username: "newuser", Password: "hashed",
+		Status: model.UserStatusActive, CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, repo.Insert(context.Background(), created))
+	assert.Equal(t, int64(1), created.Id)
+	assert.Equal(t, "new@example.com", created.Email)
+	assert.Equal(t, model.UserStatusActive, created.Status)
+	assert.True(t, now.Equal(created.CreatedAt))
+
+	stored, err := repo.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, stored, created)
+
+	err = repo.Insert(context.Background(), &model.User{Id: 1, Email: "dup@example.com", Status: model.UserStatusActive, CreatedAt: now, UpdatedAt: now})
+	assert.True(t, pgclass.IsConflict(err))
+
+	err = repo.Insert(context.Background(), &model.User{Id: 2, Email: "new@example.com", Status: model.UserStatusActive, CreatedAt: now, UpdatedAt: now})
+	assert.ErrorIs(t, err, apperror.ErrAlreadyExists)
+	var constraintErr *repository.ConstraintError
+	require.ErrorAs(t, err, &constraintErr)
+	assert.Equal(t, "email", constraintErr.Field)
+}
+
+func TestUserRepository_UpdateStatus(t *testing.T) {
+	tdb := setupTestDB(t)
+	cb := cbImpl.NewCircuitBreaker(gobreaker.Settings{Name: "test"})
+
+	now := time.Now().Truncate(time.Second)
+	seedUser(t, tdb.db, &model.UserDataEntity{
+		Id:        1,
+		Email:     "test@example.com",
+		Username:  "testuser",
+		Password:  "hashed_password",
+		Status:    model.UserStatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Version:   1,
+	})
+
+	later := now.Add(time.Minute)
+	require.NoError(t, tdb.db.Use(auditImpl.NewGormTimestampPlugin(func() time.Time { return later })))
+	r := retryImpl.NewRetry(0)
+	repo := repository.NewUserRepository(tdb.db, cb, r, false)
+
+	t.Run("matching current version is updated and bumped", func(t *testing.T) {
+		user := &model.User{Id: 1, Status: model.UserStatusSuspended, Version: 1}
+		updated, err := repo.UpdateStatus(context.Background(), user)
+		require.NoError(t, err)
+		assert.True(t, updated)
+		assert.True(t, later.Equal(user.UpdatedAt))
+
+		stored, err := repo.Get(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, model.UserStatusSuspended, stored.Status)
+		assert.True(t, later.Equal(stored.UpdatedAt))
+		assert.Equal(t, int64(2), stored.Version)
+	})
+
+	t.Run("stale version is not updated", func(t *testing.T) {
+		updated, err := repo.UpdateStatus(context.Background(), &model.User{Id: 1, Status: model.UserStatusDeleted, Version: 1})
+		require.NoError(t, err)
+		assert.False(t, updated)
+	})
+}
+
 func TestUserRepository_RetryOnDBRestart(t *testing.T) {
 	tdb := setupTestDB(t)
 	ctx := context.Background()
@@ -132,21 +223,8 @@ func TestUserRepository_RetryOnDBRestart(t *testing.T) {
 
 	repo := repository.NewUserRepository(tdb.db, cb, r, false)
 
-	// get docker client and container ID for pause/unpause
-	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	require.NoError(t, err)
-
-	containerID := tdb.container.GetContainerID()
-
-	// pause DB container to simulate connection loss
-	err = dockerClient.ContainerPause(ctx, containerID)
-	require.NoError(t, err)
-
-	// unpause after a short delay in background
-	go func() {
-		time.Sleep(2 * time.Second)
-		_ = dockerClient.ContainerUnpause(ctx, containerID)
-	}()
+	// pause DB container to simulate connection loss, resuming after a short delay
+	chaos.PauseFor(t, tdb.container, 2*time.Second)
 
 	// query should fail initially but succeed after unpause via retry
 	user, err := repo.Get(ctx, 1)
@@ -0,0 +1,179 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type logEntry struct {
+	level  string
+	msg    string
+	fields map[string]any
+}
+
+// entryLogger records every entry, with the fields it was derived With.
+type entryLogger struct {
+	entries *[]logEntry
+	fields  []observability.Field
+}
+
+func newEntryLogger() *entryLogger {
+	return &entryLogger{entries: &[]logEntry{}}
+}
+
+func (l *entryLogger) record(level, msg string, fields []observability.Field) {
+	entry := logEntry{level: level, msg: msg, fields: map[string]any{}}
+	for _, f := range append(append([]observability.Field{}, l.fields...), fields...) {
+		entry.fields[f.Key] = f.Value
+	}
+	*l.entries = append(*l.entries, entry)
+}
+
+func (l *entryLogger) Debug(msg string, fields ...observability.Field) {
+	l.record("debug", msg, fields)
+}
+func (l *entryLogger) Error(msg string, fields ...observability.Field) {
+	l.record("error", msg, fields)
+}
+func (l *entryLogger) Fatal(msg string, fields ...observability.Field) {
+	l.record("fatal", msg, fields)
+}
+func (l *entryLogger) Info(msg string, fields ...observability.Field) { l.record("info", msg, fields) }
+func (l *entryLogger) Warn(msg string, fields ...observability.Field) { l.record("warn", msg, fields) }
+func (l *entryLogger) With(fields ...observability.Field) observability.Logger {
+	return &entryLogger{entries: l.entries, fields: append(append([]observability.Field{}, l.fields...), fields...)}
+}
+
+// messageStream serves recv to RecvMsg, then io.EOF, and drops sent messages.
+type messageStream struct {
+	trailerServerStream
+	recv []proto.Message
+}
+
+func (s *messageStream) RecvMsg(m any) error {
+	if len(s.recv) == 0 {
+		return io.EOF
+	}
+	proto.Merge(m.(proto.Message), s.recv[0])
+	s.recv = s.recv[1:]
+	return nil
+}
+
+func (s *messageStream) SendMsg(m any) error { return nil }
+
+func TestAccessLogInterceptors(t *testing.T) {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.7"), Port: 51234}})
+	ctx = observability.ContextWithLogFields(ctx, observability.String(observability.RequestIdKey, "req-1"))
+	req := &v1.GetUserByIdRequest{Id: 42}
+	resp := &v1.GetUserByIdResponse{Id: 42, Email: "alice@example.com"}
+	info := &grpc.UnaryServerInfo{FullMethod: v1.UserService_GetUserById_FullMethodName}
+
+	t.Run("logs a completed unary call", func(t *testing.T) {
+		log := newEntryLogger()
+		unary, _ := interceptor.AccessLogInterceptors(log, nil, false)
+
+		_, err := unary(ctx, req, info, func(ctx context.Context, req any) (any, error) { return resp, nil })
+		require.NoError(t, err)
+
+		require.Len(t, *log.entries, 1)
+		entry := (*log.entries)[0]
+		assert.Equal(t, "info", entry.level)
+		assert.Equal(t, "rpc completed", entry.msg)
+		assert.Equal(t, info.FullMethod, entry.fields["method"])
+		assert.Equal(t, "10.0.0.7", entry.fields["peer"])
+		assert.Equal(t, "OK", entry.fields["code"])
+		assert.Equal(t, "req-1", entry.fields["request_id"])
+		assert.Equal(t, proto.Size(req), entry.fields["request_bytes"])
+		assert.Equal(t, proto.Size(resp), entry.fields["response_bytes"])
+		assert.Contains(t, entry.fields, "duration")
+	})
+
+	t.Run("logs the status code of a failed call", func(t *testing.T) {
+		log := newEntryLogger()
+		unary, _ := interceptor.AccessLogInterceptors(log, nil, false)
+
+		_, err := unary(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		})
+		require.Error(t, err)
+
+		require.Len(t, *log.entries, 1)
+		assert.Equal(t, "NotFound", (*log.entries)[0].fields["code"])
+		assert.Equal(t, 0, (*log.entries)[0].fields["response_bytes"])
+	})
+
+	t.Run("skips excluded methods and services", func(t *testing.T) {
+		log := newEntryLogger()
+		unary, _ := interceptor.AccessLogInterceptors(log, []string{"/grpc.health.v1.Health/", info.FullMethod}, false)
+
+		for _, method := range []string{"/grpc.health.v1.Health/Check", info.FullMethod} {
+			_, err := unary(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) { return resp, nil })
+			require.NoError(t, err)
+		}
+		assert.Empty(t, *log.entries)
+	})
+
+	t.Run("logs payloads at debug level with sensitive fields redacted", func(t *testing.T) {
+		log := newEntryLogger()
+		unary, _ := interceptor.AccessLogInterceptors(log, nil, true)
+		create := &v1.CreateUserRequest{IdempotencyId: 1, Email: "alice@example.com", Username: "alice", Password: "hunter2-hunter2"}
+
+		_, err := unary(ctx, create, &grpc.UnaryServerInfo{FullMethod: v1.UserService_CreateUser_FullMethodName}, func(ctx context.Context, req any) (any, error) {
+			return &v1.Verify2FAResponse{RecoveryCodes: []string{"code-1", "code-2"}}, nil
+		})
+		require.NoError(t, err)
+
+		require.Len(t, *log.entries, 2)
+		entry := (*log.entries)[1]
+		assert.Equal(t, "debug", entry.level)
+		assert.Equal(t, "rpc payload", entry.msg)
+		request := entry.fields["request"].(string)
+		assert.Contains(t, request, "alice@example.com")
+		assert.Contains(t, request, "[REDACTED]")
+		assert.NotContains(t, request, "hunter2")
+		assert.NotContains(t, entry.fields["response"], "code-1")
+		assert.Equal(t, "hunter2-hunter2", create.Password, "the request itself is left intact")
+	})
+
+	t.Run("logs message counts and sizes of a stream", func(t *testing.T) {
+		log := newEntryLogger()
+		_, stream := interceptor.AccessLogInterceptors(log, nil, false)
+		ss := &messageStream{trailerServerStream: trailerServerStream{ctx: ctx}, recv: []proto.Message{req, req}}
+
+		err := stream(nil, ss, &grpc.StreamServerInfo{FullMethod: "/proto.v1.UserService/Watch"}, func(srv any, ss grpc.ServerStream) error {
+			for {
+				var m v1.GetUserByIdRequest
+				if err := ss.RecvMsg(&m); err == io.EOF {
+					break
+				}
+				if err := ss.SendMsg(resp); err != nil {
+					return err
+				}
+			}
+			return status.Error(codes.Canceled, "client went away")
+		})
+		require.Error(t, err)
+
+		require.Len(t, *log.entries, 1)
+		entry := (*log.entries)[0]
+		assert.Equal(t, "Canceled", entry.fields["code"])
+		assert.Equal(t, 2, entry.fields["messages_received"])
+		assert.Equal(t, 2, entry.fields["messages_sent"])
+		assert.Equal(t, 2*proto.Size(req), entry.fields["request_bytes"])
+		assert.Equal(t, 2*proto.Size(resp), entry.fields["response_bytes"])
+		assert.Equal(t, "/proto.v1.UserService/Watch", entry.fields["method"])
+	})
+}
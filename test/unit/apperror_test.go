@@ -0,0 +1,74 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAppError(t *testing.T) {
+	t.Run("constructors wrap their sentinel", func(t *testing.T) {
+		for err, sentinel := range map[error]error{
+			apperror.NotFoundf("user %d", 42):          apperror.ErrNotFound,
+			apperror.InvalidArgumentf("bad %s", "x"):   apperror.ErrInvalidArgument,
+			apperror.FailedPreconditionf("not ready"):  apperror.ErrFailedPrecondition,
+			apperror.Conflictf("raced"):                apperror.ErrConflict,
+			apperror.AlreadyExistsf("taken"):           apperror.ErrAlreadyExists,
+			apperror.PermissionDeniedf("not yours"):    apperror.ErrPermissionDenied,
+			apperror.Unavailablef("down for a moment"): apperror.ErrUnavailable,
+		} {
+			assert.ErrorIs(t, err, sentinel, err.Error())
+		}
+		assert.EqualError(t, apperror.NotFoundf("user %d", 42), "user 42: not found")
+	})
+
+	t.Run("stack trace starts at the caller", func(t *testing.T) {
+		err := apperror.NotFoundf("user %d", 42)
+		assert.Regexp(t, `^github.com/jt828/go-grpc-template/test/unit\.TestAppError\.func2\n\t.*apperror_test.go:\d+\n`, err.StackTrace())
+	})
+
+	t.Run("fields and the innermost stack are found through the chain", func(t *testing.T) {
+		inner := apperror.Wrapf(errors.New("boom"), "inner").With("a", 1)
+		outer := apperror.Wrapf(fmt.Errorf("middle: %w", inner), "outer").With("b", 2)
+
+		assert.Equal(t, []apperror.Field{{Key: "b", Value: 2}, {Key: "a", Value: 1}}, apperror.FieldsOf(outer))
+		assert.Equal(t, inner.StackTrace(), apperror.StackTraceOf(outer))
+		assert.Equal(t, "outer: middle: inner: boom", outer.Error())
+		assert.Empty(t, apperror.StackTraceOf(errors.New("plain")))
+		assert.Empty(t, apperror.FieldsOf(errors.Join(errors.New("plain"))))
+	})
+
+	t.Run("user not found carries its reason", func(t *testing.T) {
+		err := apperror.UserNotFound(42)
+		assert.EqualError(t, err, "user 42: not found")
+		assert.ErrorIs(t, err, apperror.ErrNotFound)
+		assert.True(t, apperror.HasReason(err, apperror.ReasonUserNotFound))
+		assert.Contains(t, apperror.StackTraceOf(err), "apperror_test.go", "the stack starts at the caller")
+	})
+
+	t.Run("reasons are read from errors and received statuses", func(t *testing.T) {
+		reason, metadata := apperror.ReasonOf(fmt.Errorf("create: %w", apperror.WithReason(apperror.ErrAlreadyExists, apperror.ReasonEmailTaken, map[string]string{"field": "email"})))
+		assert.Equal(t, apperror.ReasonEmailTaken, reason)
+		assert.Equal(t, map[string]string{"field": "email"}, metadata)
+
+		received := func(domain string) error {
+			st, err := status.New(codes.ResourceExhausted, "slow down").WithDetails(&errdetails.ErrorInfo{Reason: apperror.ReasonRateLimited, Domain: domain})
+			require.NoError(t, err)
+			return st.Err()
+		}
+		assert.True(t, apperror.HasReason(received(apperror.Domain), apperror.ReasonRateLimited))
+		assert.False(t, apperror.HasReason(received("other.example.com"), apperror.ReasonRateLimited), "other services' reasons are not ours")
+
+		reason, metadata = apperror.ReasonOf(errors.New("plain"))
+		assert.Empty(t, reason)
+		assert.Nil(t, metadata)
+		assert.False(t, apperror.HasReason(status.Error(codes.NotFound, "gone"), ""))
+	})
+}
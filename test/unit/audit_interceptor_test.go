@@ -0,0 +1,124 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"github.com/jt828/go-grpc-template/pkg/audit/implementation"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// recordingSink keeps the records written to it, failing with err if set.
+type recordingSink struct {
+	records []*model.AuditRecord
+	err     error
+}
+
+func (s *recordingSink) Write(ctx context.Context, record *model.AuditRecord) error {
+	s.records = append(s.records, record)
+	return s.err
+}
+
+func TestAuditInterceptor(t *testing.T) {
+	const (
+		createUser = "/proto.v1.UserService/CreateUser"
+		getUser    = "/proto.v1.UserService/GetUserById"
+	)
+	policy := map[string]audit.Level{
+		createUser:                         audit.LevelRequest,
+		"/proto.v1.AdminService/":          audit.LevelCall,
+		"/proto.v1.AdminService/GetConfig": audit.LevelNone,
+	}
+	ctx := observability.ContextWithRequestId(
+		interceptor.ContextWithCaller(context.Background(), interceptor.Caller{Kind: interceptor.CallerKindAPIKey, Id: "partner-a"}),
+		"req-1",
+	)
+	call := func(i grpc.UnaryServerInterceptor, method string, req any, err error) error {
+		_, got := i(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+			return nil, err
+		})
+		return got
+	}
+
+	t.Run("records the caller, method, redacted request and outcome", func(t *testing.T) {
+		sink := &recordingSink{}
+		i := interceptor.AuditInterceptor(sink, policy, &mockLogger{})
+
+		req := &v1.CreateUserRequest{IdempotencyId: 1, Email: "a@b.com", Username: "alice", Password: "hunter22"}
+		require.NoError(t, call(i, createUser, req, nil))
+
+		require.Len(t, sink.records, 1)
+		record := sink.records[0]
+		assert.Equal(t, "api_key:partner-a", record.Actor)
+		assert.Equal(t, createUser, record.Method)
+		assert.Equal(t, "req-1", record.RequestId)
+		assert.Equal(t, "OK", record.Code)
+		assert.Contains(t, record.Request, `"email":"a@b.com"`)
+		assert.Contains(t, record.Request, `"password":"[REDACTED]"`)
+		assert.NotContains(t, record.Request, "hunter22")
+		assert.Equal(t, "hunter22", req.Password, "the request itself is left untouched")
+	})
+
+	t.Run("failed calls are recorded with the status code they return", func(t *testing.T) {
+		sink := &recordingSink{}
+		i := interceptor.AuditInterceptor(sink, policy, &mockLogger{})
+
+		err := call(i, "/proto.v1.AdminService/SuspendUser", &v1.SuspendUserRequest{}, fmt.Errorf("not allowed: %w", apperror.ErrPermissionDenied))
+		assert.ErrorIs(t, err, apperror.ErrPermissionDenied)
+
+		require.Len(t, sink.records, 1)
+		assert.Equal(t, "PermissionDenied", sink.records[0].Code)
+		assert.Empty(t, sink.records[0].Request, "call level records no request")
+	})
+
+	t.Run("unlisted methods and level none are not audited", func(t *testing.T) {
+		sink := &recordingSink{}
+		i := interceptor.AuditInterceptor(sink, policy, &mockLogger{})
+
+		require.NoError(t, call(i, getUser, &v1.GetUserByIdRequest{}, nil))
+		require.NoError(t, call(i, "/proto.v1.AdminService/GetConfig", &v1.GetConfigRequest{}, nil))
+		assert.Empty(t, sink.records)
+	})
+
+	t.Run("a record that cannot be written is logged and the call still succeeds", func(t *testing.T) {
+		sink := &recordingSink{err: errors.New("disk full")}
+		log := &mockLogger{}
+		i := interceptor.AuditInterceptor(sink, policy, log)
+
+		require.NoError(t, call(i, createUser, &v1.CreateUserRequest{}, nil))
+		require.Len(t, log.errorCalls, 1)
+		assert.Equal(t, "failed to write audit record", log.errorCalls[0].msg)
+	})
+}
+
+func TestRepositorySink(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	repo := repository.NewAuditRecordRepository(gormDB, &passthroughCB{}, &passthroughRetry{})
+	sink := implementation.NewRepositorySink(repo, &mockSnowflake{id: 7})
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "main"."audit_records" ("actor","method","request_id","request","code","duration_ms","created_at","id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING "id"`)).
+		WithArgs("user:42", "/proto.v1.UserService/CreateUser", "req-1", "{}", "OK", int64(3), now, int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectCommit()
+
+	record := &model.AuditRecord{Actor: "user:42", Method: "/proto.v1.UserService/CreateUser", RequestId: "req-1", Request: "{}", Code: "OK", Duration: 3, CreatedAt: now}
+	require.NoError(t, sink.Write(context.Background(), record))
+	assert.Zero(t, record.Id, "the caller's record is not modified")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,201 @@
+package unit
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"github.com/jt828/go-grpc-template/pkg/audit/implementation"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+func TestGormActorPlugin(t *testing.T) {
+	cb := &passthroughCB{}
+	r := &passthroughRetry{}
+	now := time.Now().Truncate(time.Second)
+	userInsertSQL := regexp.QuoteMeta(`INSERT INTO "main"."users" ("email","username","password","status","created_at","updated_at","created_by","updated_by","version","email_verified_at","id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) RETURNING *`)
+
+	t.Run("insert stamps created_by and updated_by from context", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		require.NoError(t, gormDB.Use(implementation.NewGormActorPlugin()))
+		repo := repository.NewUserRepository(gormDB, cb, r, false)
+		ctx := audit.ContextWithActor(context.Background(), "api_key:partner-a")
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(userInsertSQL).
+			WithArgs("a@b.com", "alice", "", model.UserStatusActive, now, now, "api_key:partner-a", "api_key:partner-a", int64(1), nil, int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectCommit()
+
+		// A caller-supplied actor is overwritten, so it cannot be forged.
+		user := &model.User{Id: 1, Email: "a@b.com", Username: "alice", Status: model.UserStatusActive, CreatedAt: now, UpdatedAt: now, CreatedBy: "user:forged"}
+		require.NoError(t, repo.Insert(ctx, user))
+		assert.Equal(t, "api_key:partner-a", user.CreatedBy)
+		assert.Equal(t, "api_key:partner-a", user.UpdatedBy)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("writes without an actor are stamped as system", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		require.NoError(t, gormDB.Use(implementation.NewGormActorPlugin()))
+		repo := repository.NewLedgerRepository(gormDB, cb, r, false)
+		amt := decimal.NewFromInt(1)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "main"."ledgers" ("user_id","transaction_type","token","amount","created_at","created_by","id") VALUES ($1,$2,$3,$4,$5,$6,$7) ON CONFLICT ("id") DO NOTHING RETURNING "id"`)).
+			WithArgs(int64(10), "deposit", "ETH", amt, now, audit.SystemActor, int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectCommit()
+
+		ledger := &model.Ledger{Id: 1, UserId: 10, TransactionType: "deposit", Token: "ETH", Amount: amt, CreatedAt: now}
+		require.NoError(t, repo.Insert(context.Background(), ledger))
+		assert.Equal(t, audit.SystemActor, ledger.CreatedBy)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("update stamps updated_by only", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		require.NoError(t, gormDB.Use(implementation.NewGormActorPlugin()))
+		repo := repository.NewUserRepository(gormDB, cb, r, false)
+		ctx := audit.ContextWithActor(context.Background(), "user:42")
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "main"."users" SET "status"=$1,"updated_by"=$2,"version"=version + 1,"updated_at"=$3 WHERE id = $4 AND version = $5`)).
+			WithArgs(model.UserStatusSuspended, "user:42", sqlmock.AnyArg(), int64(1), int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		updated, err := repo.UpdateStatus(ctx, &model.User{Id: 1, Status: model.UserStatusSuspended, Version: 3})
+		require.NoError(t, err)
+		assert.True(t, updated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("tables without actor columns are untouched", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		require.NoError(t, gormDB.Use(implementation.NewGormActorPlugin()))
+		repo := repository.NewInboxRepository(gormDB, cb, r)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "main"."inbox_messages" ("handler","event_id","event_type","processed_at") VALUES ($1,$2,$3,$4) ON CONFLICT DO NOTHING`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		_, err := repo.Insert(audit.ContextWithActor(context.Background(), "user:42"), &model.InboxMessage{Handler: "h", EventId: 1, EventType: "t", ProcessedAt: now})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormTimestampPlugin(t *testing.T) {
+	cb := &passthroughCB{}
+	r := &passthroughRetry{}
+	now := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.FixedZone("UTC+8", 8*3600))
+	stamped := now.UTC().Truncate(time.Microsecond)
+	clock := func() time.Time { return now }
+	userInsertSQL := regexp.QuoteMeta(`INSERT INTO "main"."users" ("email","username","password","status","created_at","updated_at","created_by","updated_by","version","email_verified_at","id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) RETURNING *`)
+
+	t.Run("insert fills a zero created_at and stamps updated_at", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		require.NoError(t, gormDB.Use(implementation.NewGormTimestampPlugin(clock)))
+		repo := repository.NewUserRepository(gormDB, cb, r, false)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(userInsertSQL).
+			WithArgs("a@b.com", "alice", "", model.UserStatusActive, stamped, stamped, "", "", int64(1), nil, int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectCommit()
+
+		user := &model.User{Id: 1, Email: "a@b.com", Username: "alice", Status: model.UserStatusActive}
+		require.NoError(t, repo.Insert(context.Background(), user))
+		assert.Equal(t, stamped, user.CreatedAt)
+		assert.Equal(t, stamped, user.UpdatedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("insert keeps a caller-supplied created_at", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		require.NoError(t, gormDB.Use(implementation.NewGormTimestampPlugin(clock)))
+		repo := repository.NewLedgerRepository(gormDB, cb, r, false)
+		amt := decimal.NewFromInt(1)
+		imported := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "main"."ledgers"`)).
+			WithArgs(int64(10), "deposit", "ETH", amt, imported, "", int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectCommit()
+
+		ledger := &model.Ledger{Id: 1, UserId: 10, TransactionType: "deposit", Token: "ETH", Amount: amt, CreatedAt: imported}
+		require.NoError(t, repo.Insert(context.Background(), ledger))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("update stamps updated_at and hands it back", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		require.NoError(t, gormDB.Use(implementation.NewGormTimestampPlugin(clock)))
+		repo := repository.NewUserRepository(gormDB, cb, r, false)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "main"."users" SET "status"=$1,"updated_at"=$2,"version"=version + 1 WHERE id = $3 AND version = $4`)).
+			WithArgs(model.UserStatusSuspended, stamped, int64(1), int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		user := &model.User{Id: 1, Status: model.UserStatusSuspended, Version: 3}
+		updated, err := repo.UpdateStatus(context.Background(), user)
+		require.NoError(t, err)
+		assert.True(t, updated)
+		assert.Equal(t, stamped, user.UpdatedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("tables without timestamp columns are untouched", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		require.NoError(t, gormDB.Use(implementation.NewGormTimestampPlugin(clock)))
+		repo := repository.NewInboxRepository(gormDB, cb, r)
+		processedAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "main"."inbox_messages" ("handler","event_id","event_type","processed_at") VALUES ($1,$2,$3,$4) ON CONFLICT DO NOTHING`)).
+			WithArgs("h", int64(1), "t", processedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		_, err := repo.Insert(context.Background(), &model.InboxMessage{Handler: "h", EventId: 1, EventType: "t", ProcessedAt: processedAt})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestActorInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/proto.v1.UserService/CreateUser"}
+	var actor string
+	handler := func(ctx context.Context, req any) (any, error) {
+		actor = audit.ActorFromContext(ctx)
+		return nil, nil
+	}
+	peerCtx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.7"), Port: 51234}})
+	i := interceptor.ActorInterceptor()
+
+	_, err := i(interceptor.ContextWithCaller(peerCtx, interceptor.Caller{Kind: interceptor.CallerKindAPIKey, Id: "partner-a"}), nil, info, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "api_key:partner-a", actor)
+
+	_, err = i(peerCtx, nil, info, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "peer:10.0.0.7", actor)
+
+	assert.Equal(t, audit.SystemActor, audit.ActorFromContext(context.Background()))
+}
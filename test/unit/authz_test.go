@@ -0,0 +1,170 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/authz"
+	"github.com/jt828/go-grpc-template/internal/config"
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestAuthzPolicy(t *testing.T) {
+	policy, err := authz.NewPolicy(map[string][]string{
+		"/proto.v1.AdminService/":                {"admin"},
+		"/proto.v1.AdminService/GetDependencies": {"admin", "oncall"},
+		"/grpc.health.v1.Health/":                {authz.AnyRole},
+	}, false)
+	require.NoError(t, err)
+
+	t.Run("an exact method takes precedence over its service", func(t *testing.T) {
+		assert.NoError(t, policy.Authorize("/proto.v1.AdminService/GetDependencies", []string{"oncall"}))
+		assert.ErrorIs(t, policy.Authorize("/proto.v1.AdminService/UnlockUser", []string{"oncall"}), apperror.ErrPermissionDenied)
+		assert.NoError(t, policy.Authorize("/proto.v1.AdminService/UnlockUser", []string{"oncall", "admin"}))
+	})
+
+	t.Run("unlisted methods follow the deny unlisted setting", func(t *testing.T) {
+		assert.True(t, policy.Public("/proto.v1.UserService/GetUserById"))
+
+		strict, err := authz.NewPolicy(map[string][]string{"/grpc.health.v1.Health/": {authz.AnyRole}}, true)
+		require.NoError(t, err)
+		assert.False(t, strict.Public("/proto.v1.UserService/GetUserById"))
+		assert.ErrorIs(t, strict.Authorize("/proto.v1.UserService/GetUserById", []string{"admin"}), apperror.ErrPermissionDenied)
+		assert.True(t, strict.Public("/grpc.health.v1.Health/Check"))
+	})
+
+	t.Run("the default policy denies what it does not list", func(t *testing.T) {
+		defaults, err := authz.NewPolicy(config.DefaultAuthzPolicy, true)
+		require.NoError(t, err)
+		grants := authz.Grants(config.DefaultAuthzRoles)
+		assert.True(t, defaults.Public("/grpc.health.v1.Health/Check"))
+		assert.True(t, defaults.Public(v1.UserService_CreateUser_FullMethodName))
+		assert.False(t, defaults.Public(v1.UserService_UpdateUser_FullMethodName))
+		assert.NoError(t, defaults.Authorize(v1.UserService_UpdateUser_FullMethodName, grants.Roles("user:42")))
+		assert.ErrorIs(t, defaults.Authorize(v1.UserService_GetUsersByIds_FullMethodName, grants.Roles("user:42")), apperror.ErrPermissionDenied)
+		assert.NoError(t, defaults.Authorize(v1.UserService_GetUsersByIds_FullMethodName, grants.Roles("api_key:partner")))
+		assert.ErrorIs(t, defaults.Authorize(v1.AdminService_GetConfig_FullMethodName, grants.Roles("service:billing")), apperror.ErrPermissionDenied)
+		assert.ErrorIs(t, defaults.Authorize(v1.UserService_UpdateUserStatus_FullMethodName, grants.Roles("service:billing")), apperror.ErrPermissionDenied)
+		assert.ErrorIs(t, defaults.Authorize("/proto.v1.UserService/DeleteEverything", grants.Roles("service:billing")), apperror.ErrPermissionDenied)
+	})
+
+	t.Run("invalid policies are rejected", func(t *testing.T) {
+		_, err := authz.NewPolicy(map[string][]string{"proto.v1.AdminService/": {"admin"}}, false)
+		assert.Error(t, err)
+		_, err = authz.NewPolicy(map[string][]string{"/proto.v1.AdminService/": nil}, false)
+		assert.Error(t, err)
+	})
+
+	t.Run("grants combine caller and kind roles", func(t *testing.T) {
+		grants := authz.Grants{
+			"service:spiffe://example.org/ops": {"admin"},
+			"service:*":                        {"internal"},
+		}
+		assert.Equal(t, []string{"admin", "internal"}, grants.Roles("service:spiffe://example.org/ops"))
+		assert.Equal(t, []string{"internal"}, grants.Roles("service:billing"))
+		assert.Empty(t, grants.Roles("api_key:partner"))
+	})
+}
+
+type resolverFunc func(ctx context.Context, caller string, roles []string) (authz.Access, error)
+
+func (f resolverFunc) Resolve(ctx context.Context, caller string, roles []string) (authz.Access, error) {
+	return f(ctx, caller, roles)
+}
+
+func TestAuthzInterceptor(t *testing.T) {
+	policy, err := authz.NewPolicy(map[string][]string{"/proto.v1.AdminService/": {"admin"}}, false)
+	require.NoError(t, err)
+	grants := authz.Grants{"service:ops": {"admin"}}
+	call := func(ctx context.Context, method string) (bool, error, *mockMeter) {
+		meter := &mockMeter{}
+		called := false
+		_, err := interceptor.AuthzInterceptor(policy, grants, nil, meter)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+			called = true
+			return nil, nil
+		})
+		return called, err, meter
+	}
+	service := func(id string) context.Context {
+		return interceptor.ContextWithCaller(context.Background(), interceptor.Caller{Kind: interceptor.CallerKindService, Id: id})
+	}
+
+	t.Run("caller with an allowed role passes", func(t *testing.T) {
+		called, err, _ := call(service("ops"), "/proto.v1.AdminService/UnlockUser")
+		require.NoError(t, err)
+		assert.True(t, called)
+	})
+
+	t.Run("caller without an allowed role is denied", func(t *testing.T) {
+		called, err, meter := call(service("billing"), "/proto.v1.AdminService/UnlockUser")
+		assert.ErrorIs(t, err, apperror.ErrPermissionDenied)
+		assert.False(t, called)
+		assert.Equal(t, 1, meter.metrics["authz_requests_denied_total"].observations["/proto.v1.AdminService/UnlockUser"])
+	})
+
+	t.Run("unauthenticated caller is told to authenticate", func(t *testing.T) {
+		called, err, _ := call(context.Background(), "/proto.v1.AdminService/UnlockUser")
+		assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+		assert.False(t, called)
+	})
+
+	t.Run("public methods need no caller", func(t *testing.T) {
+		called, err, _ := call(context.Background(), "/proto.v1.UserService/GetUserById")
+		require.NoError(t, err)
+		assert.True(t, called)
+	})
+
+	t.Run("records the caller's roles, on public methods too", func(t *testing.T) {
+		var roles []string
+		_, err := interceptor.AuthzInterceptor(policy, grants, nil, &mockMeter{})(service("ops"), nil, &grpc.UnaryServerInfo{FullMethod: "/proto.v1.LedgerService/ListLedgers"}, func(ctx context.Context, req any) (any, error) {
+			roles = authz.RolesFromContext(ctx)
+			return nil, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin"}, roles)
+	})
+
+	t.Run("resolved permissions satisfy the policy and reach the handler", func(t *testing.T) {
+		policy, err := authz.NewPolicy(map[string][]string{"/proto.v1.AdminService/UnlockUser": {"users.unlock"}}, true)
+		require.NoError(t, err)
+		calls := 0
+		resolver := resolverFunc(func(ctx context.Context, caller string, roles []string) (authz.Access, error) {
+			calls++
+			assert.Equal(t, "user:7", caller)
+			return authz.Access{Roles: append(roles, "support"), Permissions: []string{"users.unlock"}}, nil
+		})
+		ctx := interceptor.ContextWithCaller(context.Background(), interceptor.Caller{Kind: interceptor.CallerKindUser, Id: "7"})
+
+		var allowed bool
+		_, err = interceptor.AuthzInterceptor(policy, grants, resolver, &mockMeter{})(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/proto.v1.AdminService/UnlockUser"}, func(ctx context.Context, req any) (any, error) {
+			assert.Equal(t, []string{"support"}, authz.RolesFromContext(ctx))
+			allowed = authz.HasPermission(ctx, "users.unlock")
+			return nil, nil
+		})
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, 1, calls, "access is resolved once per request")
+	})
+
+	t.Run("a failed lookup fails protected methods only", func(t *testing.T) {
+		lookupErr := errors.New("database unavailable")
+		resolver := resolverFunc(func(ctx context.Context, caller string, roles []string) (authz.Access, error) {
+			return authz.Access{}, lookupErr
+		})
+		run := func(method string) error {
+			_, err := interceptor.AuthzInterceptor(policy, grants, resolver, &mockMeter{})(service("ops"), nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+				assert.Equal(t, []string{"admin"}, authz.RolesFromContext(ctx), "public methods see the granted roles")
+				return nil, nil
+			})
+			return err
+		}
+		assert.ErrorIs(t, run("/proto.v1.AdminService/UnlockUser"), lookupErr)
+		assert.NoError(t, run("/proto.v1.LedgerService/ListLedgers"))
+	})
+}
@@ -0,0 +1,203 @@
+package unit
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/bootstrap"
+	"github.com/jt828/go-grpc-template/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertPair writes a fresh self-signed certificate and key with the given
+// modification time and returns the certificate's DER encoding.
+func writeCertPair(t *testing.T, cfg config.TLSConfig, modTime time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, &x509.Certificate{SerialNumber: serial, Subject: pkix.Name{CommonName: "localhost"}}, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(cfg.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.Chtimes(cfg.CertFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(cfg.KeyFile, modTime, modTime))
+	return der
+}
+
+func TestCertReloader(t *testing.T) {
+	newPair := func(t *testing.T) config.TLSConfig {
+		dir := t.TempDir()
+		return config.TLSConfig{CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key")}
+	}
+	served := func(t *testing.T, r *bootstrap.CertReloader) []byte {
+		cert, err := r.GetCertificate(nil)
+		require.NoError(t, err)
+		return cert.Certificate[0]
+	}
+	start := time.Now().Add(-time.Minute)
+
+	t.Run("missing files fail to load", func(t *testing.T) {
+		_, err := bootstrap.NewCertReloader(newPair(t), &mockLogger{})
+		assert.Error(t, err)
+	})
+
+	t.Run("rotated certificate is served on the next handshake", func(t *testing.T) {
+		cfg := newPair(t)
+		first := writeCertPair(t, cfg, start)
+		r, err := bootstrap.NewCertReloader(cfg, &mockLogger{})
+		require.NoError(t, err)
+		assert.Equal(t, first, served(t, r))
+
+		second := writeCertPair(t, cfg, start.Add(time.Second))
+		assert.Equal(t, second, served(t, r))
+	})
+
+	t.Run("unloadable rotation keeps the previous certificate", func(t *testing.T) {
+		cfg := newPair(t)
+		first := writeCertPair(t, cfg, start)
+		log := &mockLogger{}
+		r, err := bootstrap.NewCertReloader(cfg, log)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(cfg.KeyFile, []byte("not a key"), 0o600))
+		require.NoError(t, os.Chtimes(cfg.KeyFile, start.Add(time.Second), start.Add(time.Second)))
+		assert.Equal(t, first, served(t, r))
+		assert.Equal(t, first, served(t, r))
+		assert.Len(t, log.warnCalls, 1)
+	})
+
+	t.Run("pinned client config trusts only the served certificate", func(t *testing.T) {
+		cfg := newPair(t)
+		first := writeCertPair(t, cfg, start)
+		r, err := bootstrap.NewCertReloader(cfg, &mockLogger{})
+		require.NoError(t, err)
+		verify := r.PinnedClientConfig().VerifyPeerCertificate
+
+		assert.NoError(t, verify([][]byte{first}, nil))
+		assert.Error(t, verify([][]byte{[]byte("other")}, nil))
+		assert.Error(t, verify(nil, nil))
+	})
+
+	t.Run("server and pinned client complete a handshake", func(t *testing.T) {
+		cfg := newPair(t)
+		writeCertPair(t, cfg, start)
+		r, err := bootstrap.NewCertReloader(cfg, &mockLogger{})
+		require.NoError(t, err)
+
+		lis, err := tls.Listen("tcp", "127.0.0.1:0", r.ServerConfig())
+		require.NoError(t, err)
+		defer lis.Close()
+		go func() {
+			conn, err := lis.Accept()
+			if err == nil {
+				_ = conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}
+		}()
+
+		conn, err := tls.Dial("tcp", lis.Addr().String(), r.PinnedClientConfig())
+		require.NoError(t, err)
+		conn.Close()
+	})
+
+	t.Run("client certificates are verified against the client CAs", func(t *testing.T) {
+		cfg := newPair(t)
+		writeCertPair(t, cfg, start)
+		client := newPair(t)
+		writeCertPair(t, client, start)
+		cfg.ClientCAFile = client.CertFile
+		r, err := bootstrap.NewCertReloader(cfg, &mockLogger{})
+		require.NoError(t, err)
+		trusted, err := tls.LoadX509KeyPair(client.CertFile, client.KeyFile)
+		require.NoError(t, err)
+		untrustedPair := newPair(t)
+		writeCertPair(t, untrustedPair, start)
+		untrusted, err := tls.LoadX509KeyPair(untrustedPair.CertFile, untrustedPair.KeyFile)
+		require.NoError(t, err)
+
+		clientConfig := func(certs ...tls.Certificate) *tls.Config {
+			c := r.PinnedClientConfig()
+			c.Certificates, c.GetClientCertificate = certs, nil
+			return c
+		}
+		assert.NoError(t, serverHandshake(t, r.ServerConfig(), clientConfig(trusted)))
+		assert.NoError(t, serverHandshake(t, r.ServerConfig(), clientConfig()))
+		assert.Error(t, serverHandshake(t, r.ServerConfig(), clientConfig(untrusted)))
+		assert.NoError(t, serverHandshake(t, r.MutualServerConfig(), clientConfig(trusted)))
+		assert.Error(t, serverHandshake(t, r.MutualServerConfig(), clientConfig()), "the mutual config requires a certificate")
+
+		cfg.RequireClientCert = true
+		r, err = bootstrap.NewCertReloader(cfg, &mockLogger{})
+		require.NoError(t, err)
+		assert.NoError(t, serverHandshake(t, r.ServerConfig(), clientConfig(trusted)))
+		assert.Error(t, serverHandshake(t, r.ServerConfig(), clientConfig()))
+	})
+
+	t.Run("pinned client presents the served certificate when client certificates are required", func(t *testing.T) {
+		cfg := newPair(t)
+		writeCertPair(t, cfg, start)
+		cfg.ClientCAFile = cfg.CertFile
+		cfg.RequireClientCert = true
+		r, err := bootstrap.NewCertReloader(cfg, &mockLogger{})
+		require.NoError(t, err)
+
+		assert.NoError(t, serverHandshake(t, r.ServerConfig(), r.PinnedClientConfig()))
+	})
+
+	t.Run("unreadable client CAs fail to load", func(t *testing.T) {
+		cfg := newPair(t)
+		writeCertPair(t, cfg, start)
+		cfg.ClientCAFile = cfg.KeyFile
+		_, err := bootstrap.NewCertReloader(cfg, &mockLogger{})
+		assert.ErrorContains(t, err, "no PEM encoded certificates")
+	})
+}
+
+// serverHandshake connects a client with clientConfig to a server with
+// serverConfig and returns the server's handshake error. Under TLS 1.3 the
+// client finishes its handshake before the server has checked its
+// certificate, so only the server side reliably sees a rejection.
+func serverHandshake(t *testing.T, serverConfig, clientConfig *tls.Config) error {
+	t.Helper()
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	require.NoError(t, err)
+	defer lis.Close()
+	result := make(chan error, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			result <- err
+			return
+		}
+		defer conn.Close()
+		result <- conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", lis.Addr().String(), clientConfig)
+	if err == nil {
+		defer conn.Close()
+	}
+	return <-result
+}
@@ -57,7 +57,8 @@ func TestCircuitBreaker_Execute(t *testing.T) {
 		})
 
 		assert.Nil(t, result)
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, circuitbreaker.ErrOpen)
+		assert.ErrorIs(t, err, gobreaker.ErrOpenState)
 	})
 }
 
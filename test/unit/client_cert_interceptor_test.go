@@ -0,0 +1,73 @@
+package unit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestClientCertInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/user.v1.UserService/GetUser"}
+	withCert := func(cert *x509.Certificate) context.Context {
+		state := tls.ConnectionState{}
+		if cert != nil {
+			state.VerifiedChains = [][]*x509.Certificate{{cert}}
+		}
+		return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+	}
+	call := func(ctx context.Context) (interceptor.Caller, bool, error) {
+		var caller interceptor.Caller
+		var ok bool
+		_, err := interceptor.ClientCertInterceptor()(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+			caller, ok = interceptor.CallerFromContext(ctx)
+			return nil, nil
+		})
+		return caller, ok, err
+	}
+
+	t.Run("spiffe id identifies the caller", func(t *testing.T) {
+		spiffeId, err := url.Parse("spiffe://example.org/ns/billing/sa/worker")
+		require.NoError(t, err)
+		caller, ok, err := call(withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "worker"}, URIs: []*url.URL{spiffeId}}))
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, "service:spiffe://example.org/ns/billing/sa/worker", caller.String())
+	})
+
+	t.Run("common name identifies a caller without a spiffe id", func(t *testing.T) {
+		other, err := url.Parse("https://example.org/worker")
+		require.NoError(t, err)
+		caller, ok, err := call(withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "billing-worker"}, URIs: []*url.URL{other}}))
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, interceptor.Caller{Kind: interceptor.CallerKindService, Id: "billing-worker"}, caller)
+	})
+
+	t.Run("certificate without an identity is rejected", func(t *testing.T) {
+		_, _, err := call(withCert(&x509.Certificate{}))
+		assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+	})
+
+	t.Run("connections without a verified certificate pass through", func(t *testing.T) {
+		for _, ctx := range []context.Context{
+			context.Background(),
+			peer.NewContext(context.Background(), &peer.Peer{}),
+			withCert(nil),
+		} {
+			_, ok, err := call(ctx)
+			require.NoError(t, err)
+			assert.False(t, ok)
+		}
+	})
+}
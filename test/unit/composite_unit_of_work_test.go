@@ -0,0 +1,141 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingStore struct {
+	name      string
+	calls     *[]string
+	commitErr error
+	userRepo  repository.UserRepository
+}
+
+func (s *recordingStore) factory() *mockUnitOfWorkFactory {
+	return &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) {
+		*s.calls = append(*s.calls, s.name+".begin")
+		return &mockUnitOfWork{
+			userRepo: s.userRepo,
+			commitFunc: func(ctx context.Context) error {
+				*s.calls = append(*s.calls, s.name+".commit")
+				return s.commitErr
+			},
+			abortFunc: func(ctx context.Context) error {
+				*s.calls = append(*s.calls, s.name+".abort")
+				return nil
+			},
+		}, nil
+	}}
+}
+
+func TestCompositeUnitOfWork(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("commits stores in main, ledger, idempotency order", func(t *testing.T) {
+		var calls []string
+		main := (&recordingStore{name: "main", calls: &calls}).factory()
+		ledger := (&recordingStore{name: "ledger", calls: &calls}).factory()
+		idem := (&recordingStore{name: "idempotency", calls: &calls}).factory()
+
+		uow, err := repository.NewCompositeUnitOfWorkFactory(main, ledger, idem).New(ctx)
+		require.NoError(t, err)
+		require.NoError(t, uow.Commit(ctx))
+
+		assert.Equal(t, []string{
+			"main.begin", "ledger.begin", "idempotency.begin",
+			"main.commit", "ledger.commit", "idempotency.commit",
+		}, calls)
+	})
+
+	t.Run("shared factory opens a single transaction", func(t *testing.T) {
+		var calls []string
+		userRepo := &mockUserRepository{}
+		main := (&recordingStore{name: "main", calls: &calls, userRepo: userRepo}).factory()
+		idem := (&recordingStore{name: "idempotency", calls: &calls}).factory()
+
+		uow, err := repository.NewCompositeUnitOfWorkFactory(main, main, idem).New(ctx)
+		require.NoError(t, err)
+		assert.Same(t, userRepo, uow.UserRepository())
+		require.NoError(t, uow.Commit(ctx))
+
+		assert.Equal(t, []string{"main.begin", "idempotency.begin", "main.commit", "idempotency.commit"}, calls)
+	})
+
+	t.Run("first store failing is a plain error and aborts the rest", func(t *testing.T) {
+		var calls []string
+		commitErr := errors.New("main down")
+		main := (&recordingStore{name: "main", calls: &calls, commitErr: commitErr}).factory()
+		idem := (&recordingStore{name: "idempotency", calls: &calls}).factory()
+
+		var handled bool
+		uow, err := repository.NewCompositeUnitOfWorkFactory(main, main, idem,
+			repository.WithPartialCommitHandler(func(ctx context.Context, err *repository.PartialCommitError) { handled = true }),
+		).New(ctx)
+		require.NoError(t, err)
+
+		err = uow.Commit(ctx)
+		assert.ErrorIs(t, err, commitErr)
+		assert.NotErrorIs(t, err, repository.ErrPartialCommit)
+		assert.False(t, handled)
+		assert.Equal(t, []string{"main.begin", "idempotency.begin", "main.commit", "idempotency.abort"}, calls)
+	})
+
+	t.Run("later store failing reports partial commit", func(t *testing.T) {
+		var calls []string
+		commitErr := errors.New("idempotency down")
+		main := (&recordingStore{name: "main", calls: &calls}).factory()
+		ledger := (&recordingStore{name: "ledger", calls: &calls}).factory()
+		idem := (&recordingStore{name: "idempotency", calls: &calls, commitErr: commitErr}).factory()
+
+		var handled *repository.PartialCommitError
+		uow, err := repository.NewCompositeUnitOfWorkFactory(main, ledger, idem,
+			repository.WithPartialCommitHandler(func(ctx context.Context, err *repository.PartialCommitError) { handled = err }),
+		).New(ctx)
+		require.NoError(t, err)
+
+		err = uow.Commit(ctx)
+		assert.ErrorIs(t, err, repository.ErrPartialCommit)
+		assert.ErrorIs(t, err, commitErr)
+
+		var partialErr *repository.PartialCommitError
+		require.ErrorAs(t, err, &partialErr)
+		assert.Equal(t, []string{repository.StoreMain, repository.StoreLedger}, partialErr.Committed)
+		assert.Equal(t, repository.StoreIdempotency, partialErr.Failed)
+		assert.Same(t, partialErr, handled)
+	})
+
+	t.Run("abort rolls back every store", func(t *testing.T) {
+		var calls []string
+		main := (&recordingStore{name: "main", calls: &calls}).factory()
+		ledger := (&recordingStore{name: "ledger", calls: &calls}).factory()
+		idem := (&recordingStore{name: "idempotency", calls: &calls}).factory()
+
+		uow, err := repository.NewCompositeUnitOfWorkFactory(main, ledger, idem).New(ctx)
+		require.NoError(t, err)
+		require.NoError(t, uow.Abort(ctx))
+
+		assert.Equal(t, []string{
+			"main.begin", "ledger.begin", "idempotency.begin",
+			"main.abort", "ledger.abort", "idempotency.abort",
+		}, calls)
+	})
+
+	t.Run("begin failure aborts already opened stores", func(t *testing.T) {
+		var calls []string
+		beginErr := errors.New("ledger unreachable")
+		main := (&recordingStore{name: "main", calls: &calls}).factory()
+		ledger := &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return nil, beginErr }}
+		idem := (&recordingStore{name: "idempotency", calls: &calls}).factory()
+
+		uow, err := repository.NewCompositeUnitOfWorkFactory(main, ledger, idem).New(ctx)
+		assert.Nil(t, uow)
+		assert.ErrorIs(t, err, beginErr)
+		assert.Equal(t, []string{"main.begin", "main.abort"}, calls)
+	})
+}
@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestConcurrencyLimitInterceptors(t *testing.T) {
+	ctx := context.Background()
+	const (
+		getUser     = "/proto.v1.UserService/GetUserById"
+		listLedgers = "/proto.v1.LedgerService/ListLedgers"
+		getConfig   = "/proto.v1.AdminService/GetConfig"
+		checkDrift  = "/proto.v1.AdminService/CheckSchemaDrift"
+	)
+	newInterceptors := func() (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor, *mockMeter) {
+		meter := &mockMeter{}
+		unary, stream := interceptor.ConcurrencyLimitInterceptors(1, map[string]int{
+			"/proto.v1.LedgerService/": 2,
+			"/proto.v1.AdminService/":  0,
+			checkDrift:                 1,
+		}, meter)
+		return unary, stream, meter
+	}
+
+	// hold starts a call to method that stays in flight until the returned
+	// function is called, and returns the error of a call that was rejected
+	// without running.
+	hold := func(t *testing.T, unary grpc.UnaryServerInterceptor, method string) (func(), error) {
+		t.Helper()
+		started, done := make(chan struct{}), make(chan struct{})
+		result := make(chan error, 1)
+		go func() {
+			_, err := unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+				close(started)
+				<-done
+				return nil, nil
+			})
+			result <- err
+		}()
+		select {
+		case <-started:
+			return func() { close(done); require.NoError(t, <-result) }, nil
+		case err := <-result:
+			return nil, err
+		}
+	}
+
+	t.Run("rejects requests beyond their group's limit", func(t *testing.T) {
+		unary, _, meter := newInterceptors()
+
+		release, err := hold(t, unary, getUser)
+		require.NoError(t, err)
+		_, err = hold(t, unary, "/proto.v1.UserService/CreateUser")
+		assert.ErrorIs(t, err, apperror.ErrResourceExhausted)
+		assert.Equal(t, 1, meter.metrics["grpc_requests_shed_total"].observations["default"])
+		assert.Equal(t, float64(1), meter.metrics["grpc_requests_in_flight"].values["default"])
+
+		release()
+		assert.Equal(t, float64(0), meter.metrics["grpc_requests_in_flight"].values["default"])
+		release, err = hold(t, unary, getUser)
+		require.NoError(t, err)
+		release()
+	})
+
+	t.Run("groups are limited independently", func(t *testing.T) {
+		unary, _, meter := newInterceptors()
+
+		releaseUser, err := hold(t, unary, getUser)
+		require.NoError(t, err)
+		releaseFirst, err := hold(t, unary, listLedgers)
+		require.NoError(t, err)
+		releaseSecond, err := hold(t, unary, listLedgers)
+		require.NoError(t, err)
+		_, err = hold(t, unary, listLedgers)
+		assert.ErrorIs(t, err, apperror.ErrResourceExhausted)
+		assert.Equal(t, 1, meter.metrics["grpc_requests_shed_total"].observations["/proto.v1.LedgerService/"])
+
+		releaseUser()
+		releaseFirst()
+		releaseSecond()
+	})
+
+	t.Run("an exact method has its own limit and zero is unbounded", func(t *testing.T) {
+		unary, _, _ := newInterceptors()
+
+		releaseDrift, err := hold(t, unary, checkDrift)
+		require.NoError(t, err)
+		_, err = hold(t, unary, checkDrift)
+		assert.ErrorIs(t, err, apperror.ErrResourceExhausted)
+
+		var releases []func()
+		for range 5 {
+			release, err := hold(t, unary, getConfig)
+			require.NoError(t, err)
+			releases = append(releases, release)
+		}
+		for _, release := range releases {
+			release()
+		}
+		releaseDrift()
+	})
+
+	t.Run("health checks are never limited", func(t *testing.T) {
+		unary, _, _ := newInterceptors()
+
+		release, err := hold(t, unary, getUser)
+		require.NoError(t, err)
+		releaseHealth, err := hold(t, unary, "/grpc.health.v1.Health/Check")
+		require.NoError(t, err)
+		releaseHealth()
+		release()
+	})
+
+	t.Run("streams hold their slot until they end", func(t *testing.T) {
+		unary, stream, _ := newInterceptors()
+		ss := &trailerServerStream{ctx: ctx}
+
+		err := stream(nil, ss, &grpc.StreamServerInfo{FullMethod: "/proto.v1.UserService/Watch"}, func(srv any, ss grpc.ServerStream) error {
+			_, err := hold(t, unary, getUser)
+			assert.ErrorIs(t, err, apperror.ErrResourceExhausted)
+			return nil
+		})
+		require.NoError(t, err)
+
+		release, err := hold(t, unary, getUser)
+		require.NoError(t, err)
+		release()
+	})
+}
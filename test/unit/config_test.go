@@ -0,0 +1,1134 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/config"
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	"github.com/jt828/go-grpc-template/pkg/httpmiddleware"
+	"github.com/jt828/go-grpc-template/pkg/idcodec"
+	"github.com/jt828/go-grpc-template/pkg/locality"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/password"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/keepalive"
+)
+
+func TestRedactDSN(t *testing.T) {
+	tests := []struct {
+		name     string
+		dsn      string
+		want     string
+		redacted bool
+	}{
+		{"empty", "", "", false},
+		{"url password", "postgres://app:s3cret@db:5432/app?sslmode=disable", "postgres://app:xxxxx@db:5432/app?sslmode=disable", true},
+		{"url without password", "postgres://app@db:5432/app", "postgres://app@db:5432/app", false},
+		{"url query password", "postgres://db/app?user=app&password=s3cret", "postgres://db/app?password=xxxxx&user=app", true},
+		{"keyword password", "host=db user=app password=s3cret dbname=app", "host=db user=app password=xxxxx dbname=app", true},
+		{"quoted keyword password", "host=db password='s3 cret' sslpassword=key", "host=db password=xxxxx sslpassword=xxxxx", true},
+		{"keyword without password", "host=db user=app", "host=db user=app", false},
+		{"unparseable url", "postgres://app:s3cret@db:port/app", "xxxxx", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, redacted := config.RedactDSN(tt.dsn)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.redacted, redacted)
+			assert.NotContains(t, got, "s3cret")
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("entries mask secrets", func(t *testing.T) {
+		t.Setenv("HOSTNAME", "pod-1")
+		t.Setenv("DATABASE_DSN", "postgres://app:s3cret@db:5432/app")
+		t.Setenv("LEDGER_DATABASE_DSN", "host=ledger password=s3cret")
+		t.Setenv("LEDGER_DATABASE_SCHEMA", "ledger")
+		t.Setenv("RATE_LIMIT_PER_MINUTE", "120")
+		t.Setenv("PUBLIC_ID_MODE", "opaque")
+		t.Setenv("PUBLIC_ID_KEY", "s3cret")
+		t.Setenv("SIGNING_SECRETS", "partner-b=s3cret, partner-a=s3cret")
+		t.Setenv("SIGNED_METHODS", "/proto.v1.LedgerService/")
+
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, 120, cfg.RateLimitPerMinute)
+		assert.Equal(t, "postgres://app:s3cret@db:5432/app", cfg.Main.DSN)
+
+		entries := map[string]model.ConfigEntry{}
+		for _, entry := range cfg.Entries() {
+			assert.NotContains(t, entry.Value, "s3cret", entry.Key)
+			entries[entry.Key] = entry
+		}
+		assert.Equal(t, "svc", entries["service.name"].Value)
+		assert.Equal(t, "pod-1", entries["hostname"].Value)
+		assert.Contains(t, entries, "snowflake.node_id")
+		assert.True(t, entries["postgresql.dsn"].Redacted)
+		assert.True(t, entries["postgresql_ledger.dsn"].Redacted)
+		assert.Equal(t, "ledger", entries["postgresql_ledger.schema"].Value)
+		assert.Equal(t, "", entries["postgresql_idempotency.dsn"].Value)
+		assert.Equal(t, model.DefaultSchema, entries["postgresql_idempotency.schema"].Value)
+		assert.Equal(t, "120", entries["rate_limit.per_minute"].Value)
+		assert.Equal(t, "opaque", entries["public_id.mode"].Value)
+		assert.True(t, entries["public_id.key"].Redacted)
+		assert.Equal(t, map[string]string{"partner-a": "s3cret", "partner-b": "s3cret"}, cfg.SigningSecrets)
+		assert.Equal(t, "partner-a,partner-b", entries["signing.key_ids"].Value)
+		assert.Equal(t, "/proto.v1.LedgerService/", entries["signing.required_methods"].Value)
+		assert.True(t, strings.HasPrefix(entries["build.go_version"].Value, "go"))
+	})
+
+	t.Run("rate limit defaults", func(t *testing.T) {
+		t.Setenv("RATE_LIMIT_PER_MINUTE", "")
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, 600, cfg.RateLimitPerMinute)
+		assert.Equal(t, 600, cfg.RateLimitBurst)
+	})
+
+	t.Run("rate limit burst defaults to the per-minute rate", func(t *testing.T) {
+		t.Setenv("RATE_LIMIT_PER_MINUTE", "120")
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, 120, cfg.RateLimitBurst)
+
+		t.Setenv("RATE_LIMIT_BURST", "30")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, 30, cfg.RateLimitBurst)
+		entries := map[string]string{}
+		for _, entry := range cfg.Entries() {
+			entries[entry.Key] = entry.Value
+		}
+		assert.Equal(t, "30", entries["rate_limit.burst"])
+	})
+
+	t.Run("public ids default to int64", func(t *testing.T) {
+		t.Setenv("PUBLIC_ID_MODE", "")
+		t.Setenv("PUBLIC_ID_KEY", "")
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, idcodec.ModeInt64, cfg.PublicIdMode)
+		for _, entry := range cfg.Entries() {
+			assert.NotEqual(t, "public_id.key", entry.Key)
+		}
+	})
+
+	t.Run("invalid signing secrets are rejected", func(t *testing.T) {
+		for _, value := range []string{"partner", "partner=", "=s3cret", "a=1,a=2"} {
+			t.Setenv("SIGNING_SECRETS", value)
+			_, err := config.Load("svc")
+			assert.ErrorContains(t, err, "SIGNING_SECRETS", value)
+		}
+	})
+
+	t.Run("invalid public id mode is rejected", func(t *testing.T) {
+		t.Setenv("PUBLIC_ID_MODE", "hex")
+		_, err := config.Load("svc")
+		assert.ErrorContains(t, err, "PUBLIC_ID_MODE")
+	})
+
+	t.Run("bucket presets default and can be overridden", func(t *testing.T) {
+		t.Setenv("METRIC_BUCKETS_DB", "0.0001, 0.001,0.01")
+
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, []float64{0.0001, 0.001, 0.01}, cfg.BucketPresets[observability.BucketsDB])
+		assert.Equal(t, observability.DefaultBucketPresets()[observability.BucketsRPC], cfg.BucketPresets[observability.BucketsRPC])
+
+		entries := map[string]string{}
+		for _, entry := range cfg.Entries() {
+			entries[entry.Key] = entry.Value
+		}
+		assert.Equal(t, "0.0001,0.001,0.01", entries["metrics.buckets.db"])
+		assert.Contains(t, entries, "metrics.buckets.external")
+	})
+
+	t.Run("invalid buckets are rejected", func(t *testing.T) {
+		for _, value := range []string{"abc", "0,1", "-1", "0.1,0.1", "1,0.5", ","} {
+			t.Setenv("METRIC_BUCKETS_RPC", value)
+			_, err := config.Load("svc")
+			assert.ErrorContains(t, err, "METRIC_BUCKETS_RPC", value)
+		}
+	})
+
+	t.Run("log sinks and module levels", func(t *testing.T) {
+		t.Setenv("LOG_LEVEL", "DEBUG")
+		t.Setenv("LOG_MODULE_LEVELS", "repository=debug, interceptor=warn")
+		t.Setenv("LOG_SINKS", "stdout=info,/var/log/app.log")
+
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, observability.LogConfig{
+			Level:   observability.LevelDebug,
+			Modules: map[string]observability.Level{"repository": observability.LevelDebug, "interceptor": observability.LevelWarn},
+			Sinks:   []observability.LogSink{{Path: "stdout", Level: observability.LevelInfo}, {Path: "/var/log/app.log"}},
+		}, cfg.Log)
+
+		entries := map[string]string{}
+		for _, entry := range cfg.Entries() {
+			entries[entry.Key] = entry.Value
+		}
+		assert.Equal(t, "debug", entries["log.level"])
+		assert.Equal(t, "interceptor=warn,repository=debug", entries["log.modules"])
+		assert.Equal(t, "stdout=info,/var/log/app.log", entries["log.sinks"])
+	})
+
+	t.Run("logs default to info on stderr", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, observability.LevelInfo, cfg.Log.Level)
+		assert.Equal(t, []observability.LogSink{{Path: "stderr"}}, cfg.Log.Sinks)
+	})
+
+	t.Run("invalid log settings are rejected", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"LOG_LEVEL":         "verbose",
+			"LOG_MODULE_LEVELS": "repository",
+			"LOG_SINKS":         "stdout=loud",
+		} {
+			t.Run(key, func(t *testing.T) {
+				t.Setenv(key, value)
+				_, err := config.Load("svc")
+				assert.ErrorContains(t, err, key)
+			})
+		}
+	})
+
+	t.Run("ledger page sizes", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.LedgerPageSizeConfig{Max: 1000, MaxByRole: map[string]int{}}, cfg.LedgerPageSize)
+
+		t.Setenv("LEDGER_MAX_PAGE_SIZE", "200")
+		t.Setenv("LEDGER_MAX_PAGE_SIZE_BY_ROLE", "reporting=20000, dashboard=500")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.LedgerPageSizeConfig{Max: 200, MaxByRole: map[string]int{"reporting": 20000, "dashboard": 500}}, cfg.LedgerPageSize)
+
+		entries := map[string]string{}
+		for _, entry := range cfg.Entries() {
+			entries[entry.Key] = entry.Value
+		}
+		assert.Equal(t, "dashboard=500,reporting=20000", entries["ledger.max_page_size_by_role"])
+
+		for key, value := range map[string]string{
+			"LEDGER_MAX_PAGE_SIZE":         "0",
+			"LEDGER_MAX_PAGE_SIZE_BY_ROLE": "reporting",
+		} {
+			t.Run(key, func(t *testing.T) {
+				t.Setenv(key, value)
+				_, err := config.Load("svc")
+				assert.ErrorContains(t, err, key)
+			})
+		}
+		t.Setenv("LEDGER_MAX_PAGE_SIZE_BY_ROLE", "reporting=-1")
+		_, err = config.Load("svc")
+		assert.ErrorContains(t, err, "LEDGER_MAX_PAGE_SIZE_BY_ROLE")
+	})
+
+	t.Run("access log", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.AccessLogConfig{ExcludedMethods: []string{"/grpc.health.v1.Health/"}}, cfg.AccessLog)
+
+		t.Setenv("ACCESS_LOG_EXCLUDED_METHODS", "/grpc.health.v1.Health/, /proto.v1.UserService/GetUserById")
+		t.Setenv("ACCESS_LOG_PAYLOADS", "true")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.AccessLogConfig{
+			ExcludedMethods: []string{"/grpc.health.v1.Health/", "/proto.v1.UserService/GetUserById"},
+			Payloads:        true,
+		}, cfg.AccessLog)
+
+		t.Setenv("ACCESS_LOG_PAYLOADS", "sometimes")
+		_, err = config.Load("svc")
+		assert.ErrorContains(t, err, "ACCESS_LOG_PAYLOADS")
+	})
+
+	t.Run("metric tenant allow-list", func(t *testing.T) {
+		t.Setenv("METRIC_TENANT_ALLOWLIST", "beta, acme")
+
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, "acme", cfg.MetricTenants.Label("acme").Value)
+		assert.Equal(t, observability.OtherTenant, cfg.MetricTenants.Label("globex").Value)
+
+		entries := map[string]string{}
+		for _, entry := range cfg.Entries() {
+			entries[entry.Key] = entry.Value
+		}
+		assert.Equal(t, "acme,beta", entries["metrics.tenant_allowlist"])
+	})
+
+	t.Run("invalid metric tenant allow-list is rejected", func(t *testing.T) {
+		tooMany := make([]string, observability.MaxTenantLabels+1)
+		for i := range tooMany {
+			tooMany[i] = fmt.Sprintf("tenant-%d", i)
+		}
+		for _, value := range []string{strings.Join(tooMany, ","), "acme,other"} {
+			t.Setenv("METRIC_TENANT_ALLOWLIST", value)
+			_, err := config.Load("svc")
+			assert.ErrorContains(t, err, "METRIC_TENANT_ALLOWLIST")
+		}
+	})
+
+	t.Run("metric namespace and const labels", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Empty(t, cfg.MetricNamespace)
+		assert.Equal(t, []observability.Label{{Key: "service", Value: "svc"}}, cfg.MetricLabels)
+
+		t.Setenv("METRIC_NAMESPACE", "acme")
+		t.Setenv("METRIC_SUBSYSTEM", "users")
+		t.Setenv("SERVICE_VERSION", "1.4.2")
+		t.Setenv("ENVIRONMENT", "staging")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, "acme", cfg.MetricNamespace)
+		assert.Equal(t, "users", cfg.MetricSubsystem)
+
+		entries := map[string]string{}
+		for _, entry := range cfg.Entries() {
+			entries[entry.Key] = entry.Value
+		}
+		assert.Equal(t, "service=svc,version=1.4.2,env=staging", entries["metrics.const_labels"])
+
+		t.Setenv("METRIC_SUBSYSTEM", "user-service")
+		_, err = config.Load("svc")
+		assert.ErrorContains(t, err, "METRIC_SUBSYSTEM")
+	})
+
+	t.Run("region and zone label metrics and traces", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Empty(t, cfg.ResourceAttributes())
+
+		t.Setenv("REGION", "eu-west-1")
+		t.Setenv("ZONE", "eu-west-1a")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, locality.Locality{Region: "eu-west-1", Zone: "eu-west-1a"}, cfg.Locality)
+		assert.Equal(t, []observability.Label{
+			{Key: "service", Value: "svc"},
+			{Key: "region", Value: "eu-west-1"},
+			{Key: "zone", Value: "eu-west-1a"},
+		}, cfg.MetricLabels)
+		assert.Equal(t, []observability.Label{
+			{Key: "cloud.region", Value: "eu-west-1"},
+			{Key: "cloud.availability_zone", Value: "eu-west-1a"},
+		}, cfg.ResourceAttributes())
+
+		entries := map[string]string{}
+		for _, entry := range cfg.Entries() {
+			entries[entry.Key] = entry.Value
+		}
+		assert.Equal(t, "eu-west-1", entries["locality.region"])
+		assert.Equal(t, "eu-west-1a", entries["locality.zone"])
+
+		t.Setenv("REGION", "")
+		_, err = config.Load("svc")
+		assert.ErrorContains(t, err, "ZONE")
+	})
+
+	t.Run("snowflake node range", func(t *testing.T) {
+		t.Setenv("HOSTNAME", "svc-7f8b9c6d4-x2k9p")
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.AllNodes, cfg.SnowflakeNodes)
+
+		t.Setenv("SNOWFLAKE_NODE_RANGE", "512-767")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.NodeRange{First: 512, Last: 767}, cfg.SnowflakeNodes)
+
+		entries := map[string]string{}
+		for _, entry := range cfg.Entries() {
+			entries[entry.Key] = entry.Value
+		}
+		assert.Equal(t, "512-767", entries["snowflake.node_range"])
+		nodeID, err := strconv.ParseInt(entries["snowflake.node_id"], 10, 64)
+		require.NoError(t, err)
+		assert.True(t, nodeID >= 512 && nodeID <= 767, nodeID)
+
+		for _, value := range []string{"512", "a-b", "-1-5", "700-600", "0-1024"} {
+			t.Setenv("SNOWFLAKE_NODE_RANGE", value)
+			_, err := config.Load("svc")
+			assert.ErrorContains(t, err, "SNOWFLAKE_NODE_RANGE", value)
+		}
+	})
+
+	t.Run("keepalive defaults to grpc's", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, keepalive.ServerParameters{}, cfg.Keepalive)
+		assert.Equal(t, keepalive.EnforcementPolicy{}, cfg.KeepalivePolicy)
+
+		entries := map[string]string{}
+		for _, entry := range cfg.Entries() {
+			entries[entry.Key] = entry.Value
+		}
+		assert.Equal(t, "default", entries["grpc.max_connection_idle"])
+		assert.Equal(t, "false", entries["grpc.keepalive_permit_without_stream"])
+	})
+
+	t.Run("keepalive settings", func(t *testing.T) {
+		t.Setenv("GRPC_MAX_CONNECTION_IDLE", "4m")
+		t.Setenv("GRPC_MAX_CONNECTION_AGE", "30m")
+		t.Setenv("GRPC_MAX_CONNECTION_AGE_GRACE", "10s")
+		t.Setenv("GRPC_KEEPALIVE_TIME", "1m")
+		t.Setenv("GRPC_KEEPALIVE_TIMEOUT", "5s")
+		t.Setenv("GRPC_KEEPALIVE_MIN_TIME", "30s")
+		t.Setenv("GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM", "true")
+
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, keepalive.ServerParameters{
+			MaxConnectionIdle:     4 * time.Minute,
+			MaxConnectionAge:      30 * time.Minute,
+			MaxConnectionAgeGrace: 10 * time.Second,
+			Time:                  time.Minute,
+			Timeout:               5 * time.Second,
+		}, cfg.Keepalive)
+		assert.Equal(t, keepalive.EnforcementPolicy{MinTime: 30 * time.Second, PermitWithoutStream: true}, cfg.KeepalivePolicy)
+
+		entries := map[string]string{}
+		for _, entry := range cfg.Entries() {
+			entries[entry.Key] = entry.Value
+		}
+		assert.Equal(t, "4m0s", entries["grpc.max_connection_idle"])
+		assert.Equal(t, "30s", entries["grpc.keepalive_min_time"])
+	})
+
+	t.Run("invalid keepalive settings are rejected", func(t *testing.T) {
+		t.Setenv("GRPC_KEEPALIVE_TIME", "-1s")
+		_, err := config.Load("svc")
+		assert.ErrorContains(t, err, "GRPC_KEEPALIVE_TIME")
+	})
+
+	t.Run("request deadlines", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, cfg.DefaultDeadline)
+		assert.Empty(t, cfg.MethodDeadlines)
+
+		t.Setenv("GRPC_DEFAULT_DEADLINE", "10s")
+		t.Setenv("GRPC_METHOD_DEADLINES", "/proto.v1.AdminService/=2m, /proto.v1.AdminService/GetDependencies=5s")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, 10*time.Second, cfg.DefaultDeadline)
+		assert.Equal(t, map[string]time.Duration{
+			"/proto.v1.AdminService/":                2 * time.Minute,
+			"/proto.v1.AdminService/GetDependencies": 5 * time.Second,
+		}, cfg.MethodDeadlines)
+
+		for _, value := range []string{"GetDependencies=5s", "/proto.v1.AdminService/=soon", "/proto.v1.AdminService/=-1s"} {
+			t.Setenv("GRPC_METHOD_DEADLINES", value)
+			_, err = config.Load("svc")
+			assert.ErrorContains(t, err, "GRPC_METHOD_DEADLINES", value)
+		}
+	})
+
+	t.Run("concurrency limits", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.ConcurrencyConfig{Methods: map[string]int{}}, cfg.Concurrency)
+
+		t.Setenv("GRPC_MAX_IN_FLIGHT", "200")
+		t.Setenv("GRPC_METHOD_MAX_IN_FLIGHT", "/proto.v1.LedgerService/=50, /proto.v1.AdminService/CheckSchemaDrift=1")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.ConcurrencyConfig{Max: 200, Methods: map[string]int{
+			"/proto.v1.LedgerService/":                50,
+			"/proto.v1.AdminService/CheckSchemaDrift": 1,
+		}}, cfg.Concurrency)
+
+		for _, value := range []string{"ListLedgers=5", "/proto.v1.LedgerService/=many", "/proto.v1.LedgerService/=-1"} {
+			t.Setenv("GRPC_METHOD_MAX_IN_FLIGHT", value)
+			_, err = config.Load("svc")
+			assert.ErrorContains(t, err, "GRPC_METHOD_MAX_IN_FLIGHT", value)
+		}
+	})
+
+	t.Run("metadata guard defaults and settings", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.MetadataConfig{MaxBytes: 8192}, cfg.Metadata)
+
+		t.Setenv("GRPC_METADATA_MAX_BYTES", "0")
+		t.Setenv("GRPC_METADATA_ALLOWED_KEYS", "X-Forwarded-For, grpcgateway-*")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.MetadataConfig{AllowedKeys: []string{"x-forwarded-for", "grpcgateway-*"}}, cfg.Metadata)
+
+		for _, value := range []string{"x-*-id", "x forwarded"} {
+			t.Setenv("GRPC_METADATA_ALLOWED_KEYS", value)
+			_, err = config.Load("svc")
+			assert.ErrorContains(t, err, "GRPC_METADATA_ALLOWED_KEYS", value)
+		}
+	})
+
+	t.Run("stream send timeout", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, cfg.StreamSendTimeout)
+
+		t.Setenv("GRPC_STREAM_SEND_TIMEOUT", "0")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Zero(t, cfg.StreamSendTimeout)
+
+		t.Setenv("GRPC_STREAM_SEND_TIMEOUT", "-1s")
+		_, err = config.Load("svc")
+		assert.ErrorContains(t, err, "GRPC_STREAM_SEND_TIMEOUT")
+	})
+
+	t.Run("http middleware defaults and settings", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.HTTPConfig{
+			CORS: httpmiddleware.CORSPolicy{
+				AllowedMethods: []string{"GET", "POST"},
+				AllowedHeaders: []string{"authorization", "content-type", "x-request-id"},
+				ExposedHeaders: []string{"x-request-id"},
+				MaxAge:         10 * time.Minute,
+			},
+			Security: httpmiddleware.SecurityPolicy{HSTSMaxAge: 365 * 24 * time.Hour},
+		}, cfg.HTTP)
+
+		t.Setenv("HTTP_CORS_ALLOWED_ORIGINS", "https://app.example.com, http://localhost:3000")
+		t.Setenv("HTTP_CORS_ALLOW_CREDENTIALS", "true")
+		t.Setenv("HTTP_HSTS_MAX_AGE", "0")
+		t.Setenv("HTTP_HSTS_INCLUDE_SUBDOMAINS", "true")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://app.example.com", "http://localhost:3000"}, cfg.HTTP.CORS.AllowedOrigins)
+		assert.True(t, cfg.HTTP.CORS.AllowCredentials)
+		assert.Equal(t, httpmiddleware.SecurityPolicy{HSTSIncludeSubdomains: true}, cfg.HTTP.Security)
+	})
+
+	t.Run("invalid http middleware settings rejected", func(t *testing.T) {
+		for _, origin := range []string{"app.example.com", "https://app.example.com/", "ftp://files.example.com"} {
+			t.Setenv("HTTP_CORS_ALLOWED_ORIGINS", origin)
+			_, err := config.Load("svc")
+			assert.ErrorContains(t, err, "HTTP_CORS_ALLOWED_ORIGINS", origin)
+		}
+
+		t.Setenv("HTTP_CORS_ALLOWED_ORIGINS", "*")
+		t.Setenv("HTTP_CORS_ALLOW_CREDENTIALS", "true")
+		_, err := config.Load("svc")
+		assert.ErrorContains(t, err, "HTTP_CORS_ALLOW_CREDENTIALS")
+	})
+
+	t.Run("audit defaults and settings", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.AuditSinkLog, cfg.Audit.Sink)
+		assert.Equal(t, config.DefaultAuditMethods, cfg.Audit.Methods)
+
+		t.Setenv("AUDIT_SINK", "database")
+		t.Setenv("AUDIT_METHODS", "/proto.v1.AdminService/=call, /proto.v1.AdminService/GetConfig=none")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.AuditConfig{Sink: config.AuditSinkDatabase, Methods: map[string]audit.Level{
+			"/proto.v1.AdminService/":          audit.LevelCall,
+			"/proto.v1.AdminService/GetConfig": audit.LevelNone,
+		}}, cfg.Audit)
+
+		entries := map[string]string{}
+		for _, entry := range cfg.Entries() {
+			entries[entry.Key] = entry.Value
+		}
+		assert.Equal(t, "database", entries["audit.sink"])
+		assert.Equal(t, "/proto.v1.AdminService/=call,/proto.v1.AdminService/GetConfig=none", entries["audit.methods"])
+	})
+
+	t.Run("invalid audit settings are rejected", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"AUDIT_SINK":    "kafka",
+			"AUDIT_METHODS": "CreateUser=request",
+		} {
+			t.Run(key, func(t *testing.T) {
+				t.Setenv(key, value)
+				_, err := config.Load("svc")
+				assert.ErrorContains(t, err, key)
+			})
+		}
+		t.Setenv("AUDIT_METHODS", "/proto.v1.UserService/CreateUser=everything")
+		_, err := config.Load("svc")
+		assert.ErrorContains(t, err, "unknown audit level")
+	})
+
+	t.Run("probe is disabled by default", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Zero(t, cfg.ProbeInterval)
+		assert.Empty(t, cfg.ProbeSigningKey)
+	})
+
+	t.Run("probe interval and signing key", func(t *testing.T) {
+		t.Setenv("PROBE_INTERVAL", "30s")
+		t.Setenv("SIGNING_SECRETS", "probe=s3cret")
+		t.Setenv("PROBE_SIGNING_KEY", "probe")
+
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, cfg.ProbeInterval)
+		assert.Equal(t, "probe", cfg.ProbeSigningKey)
+
+		entries := map[string]string{}
+		for _, entry := range cfg.Entries() {
+			entries[entry.Key] = entry.Value
+		}
+		assert.Equal(t, "30s", entries["probe.interval"])
+		assert.Equal(t, "probe", entries["probe.signing_key"])
+	})
+
+	t.Run("invalid probe settings are rejected", func(t *testing.T) {
+		for _, value := range []string{"often", "-1m"} {
+			t.Setenv("PROBE_INTERVAL", value)
+			_, err := config.Load("svc")
+			assert.ErrorContains(t, err, "PROBE_INTERVAL", value)
+		}
+		t.Setenv("PROBE_INTERVAL", "")
+		t.Setenv("PROBE_SIGNING_KEY", "missing")
+		_, err := config.Load("svc")
+		assert.ErrorContains(t, err, "PROBE_SIGNING_KEY")
+	})
+
+	t.Run("idempotency scan", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Zero(t, cfg.IdempotencyScanInterval, "disabled by default")
+		assert.False(t, cfg.IdempotencyRederive)
+
+		t.Setenv("IDEMPOTENCY_SCAN_INTERVAL", "5m")
+		t.Setenv("IDEMPOTENCY_REPAIR_REDERIVE", "true")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Minute, cfg.IdempotencyScanInterval)
+		assert.True(t, cfg.IdempotencyRederive)
+
+		entries := map[string]string{}
+		for _, entry := range cfg.Entries() {
+			entries[entry.Key] = entry.Value
+		}
+		assert.Equal(t, "5m0s", entries["idempotency.scan_interval"])
+		assert.Equal(t, "true", entries["idempotency.repair_rederive"])
+
+		t.Setenv("IDEMPOTENCY_REPAIR_REDERIVE", "sometimes")
+		_, err = config.Load("svc")
+		assert.ErrorContains(t, err, "IDEMPOTENCY_REPAIR_REDERIVE")
+		t.Setenv("IDEMPOTENCY_REPAIR_REDERIVE", "")
+		t.Setenv("IDEMPOTENCY_SCAN_INTERVAL", "-1m")
+		_, err = config.Load("svc")
+		assert.ErrorContains(t, err, "IDEMPOTENCY_SCAN_INTERVAL")
+	})
+
+	t.Run("server addresses, shutdown and resilience defaults", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, ":50051", cfg.GRPCAddress)
+		assert.Equal(t, ":9090", cfg.MetricsAddress)
+		assert.Equal(t, "localhost:4317", cfg.OTLPEndpoint)
+		assert.Equal(t, observability.DefaultTraceExportConfig(), cfg.TraceExport)
+		assert.Equal(t, observability.DefaultPropagators(), cfg.Propagators)
+		assert.Equal(t, 30*time.Second, cfg.ShutdownGracePeriod)
+		assert.Equal(t, 5*time.Second, cfg.ShutdownTimeout)
+		assert.Equal(t, config.TLSConfig{}, cfg.TLS)
+		for _, db := range []config.DatabaseConfig{cfg.Main, cfg.Idempotency, cfg.Ledger} {
+			assert.Equal(t, config.RetryConfig{MaxRetries: 3, Interval: 100 * time.Millisecond}, db.Retry, db.Name)
+			assert.Equal(t, config.BreakerConfig{ConsecutiveFailures: 6, Timeout: time.Minute, MaxRequests: 1}, db.Breaker, db.Name)
+		}
+	})
+
+	t.Run("server addresses, shutdown and resilience settings", func(t *testing.T) {
+		t.Setenv("GRPC_ADDRESS", ":8443")
+		t.Setenv("METRICS_ADDRESS", "127.0.0.1:9100")
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "collector:4317")
+		t.Setenv("TRACE_EXPORT_QUEUE_SIZE", "8192")
+		t.Setenv("TRACE_EXPORT_BATCH_SIZE", "1024")
+		t.Setenv("TRACE_EXPORT_INTERVAL", "2s")
+		t.Setenv("TRACE_EXPORT_TIMEOUT", "3s")
+		t.Setenv("OTEL_PROPAGATORS", "tracecontext, baggage, B3Multi")
+		t.Setenv("SHUTDOWN_GRACE_PERIOD", "20s")
+		t.Setenv("SHUTDOWN_TIMEOUT", "2s")
+		t.Setenv("DATABASE_MAX_RETRIES", "0")
+		t.Setenv("DATABASE_RETRY_INTERVAL", "50ms")
+		t.Setenv("DATABASE_BREAKER_FAILURES", "10")
+		t.Setenv("DATABASE_BREAKER_TIMEOUT", "15s")
+		t.Setenv("DATABASE_BREAKER_HALF_OPEN_REQUESTS", "3")
+
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, ":8443", cfg.GRPCAddress)
+		assert.Equal(t, "127.0.0.1:9100", cfg.MetricsAddress)
+		assert.Equal(t, "collector:4317", cfg.OTLPEndpoint)
+		assert.Equal(t, observability.TraceExportConfig{MaxQueueSize: 8192, MaxBatchSize: 1024, BatchTimeout: 2 * time.Second, ExportTimeout: 3 * time.Second}, cfg.TraceExport)
+		assert.Equal(t, []observability.Propagator{observability.PropagatorTraceContext, observability.PropagatorBaggage, observability.PropagatorB3Multi}, cfg.Propagators)
+		assert.Equal(t, 20*time.Second, cfg.ShutdownGracePeriod)
+		assert.Equal(t, 2*time.Second, cfg.ShutdownTimeout)
+		assert.Equal(t, config.RetryConfig{MaxRetries: 0, Interval: 50 * time.Millisecond}, cfg.Ledger.Retry)
+		assert.Equal(t, config.BreakerConfig{ConsecutiveFailures: 10, Timeout: 15 * time.Second, MaxRequests: 3}, cfg.Ledger.Breaker)
+
+		entries := map[string]string{}
+		for _, entry := range cfg.Entries() {
+			entries[entry.Key] = entry.Value
+		}
+		assert.Equal(t, ":8443", entries["server.grpc_address"])
+		assert.Equal(t, "collector:4317", entries["otlp.endpoint"])
+		assert.Equal(t, "8192", entries["otlp.queue_size"])
+		assert.Equal(t, "3s", entries["otlp.export_timeout"])
+		assert.Equal(t, "tracecontext,baggage,b3multi", entries["tracing.propagators"])
+		assert.Equal(t, "20s", entries["server.shutdown_grace_period"])
+		assert.Equal(t, "10", entries["database.breaker.consecutive_failures"])
+	})
+
+	t.Run("the OTLP endpoint is read as host and port", func(t *testing.T) {
+		for value, endpoint := range map[string]string{
+			"collector:4317":             "collector:4317",
+			"http://collector:4317":      "collector:4317",
+			"http://collector/":          "collector:4317",
+			"http://[::1]:14317":         "[::1]:14317",
+			"otel.observability.svc:443": "otel.observability.svc:443",
+		} {
+			t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", value)
+			cfg, err := config.Load("svc")
+			require.NoError(t, err, value)
+			assert.Equal(t, endpoint, cfg.OTLPEndpoint, value)
+		}
+		for _, value := range []string{"collector", "https://collector:4317", "http://collector:4317/v1/traces", "grpc://collector:4317"} {
+			t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", value)
+			_, err := config.Load("svc")
+			assert.ErrorContains(t, err, "OTEL_EXPORTER_OTLP_ENDPOINT", value)
+		}
+	})
+
+	t.Run("invalid shutdown and resilience settings are rejected", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"SHUTDOWN_GRACE_PERIOD":               "0s",
+			"SHUTDOWN_TIMEOUT":                    "soon",
+			"DATABASE_MAX_RETRIES":                "-1",
+			"DATABASE_RETRY_INTERVAL":             "0",
+			"DATABASE_BREAKER_FAILURES":           "0",
+			"DATABASE_BREAKER_TIMEOUT":            "-1m",
+			"DATABASE_BREAKER_HALF_OPEN_REQUESTS": "many",
+			"TRACE_EXPORT_QUEUE_SIZE":             "0",
+			"TRACE_EXPORT_BATCH_SIZE":             "4096",
+			"TRACE_EXPORT_INTERVAL":               "0s",
+			"TRACE_EXPORT_TIMEOUT":                "never",
+			"OTEL_PROPAGATORS":                    "tracecontext,jaeger",
+		} {
+			t.Run(key, func(t *testing.T) {
+				t.Setenv(key, value)
+				_, err := config.Load("svc")
+				assert.ErrorContains(t, err, key)
+			})
+		}
+	})
+
+	t.Run("tls from a certificate directory", func(t *testing.T) {
+		t.Setenv("TLS_CERT_DIR", "/etc/tls")
+
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.TLSConfig{CertFile: "/etc/tls/tls.crt", KeyFile: "/etc/tls/tls.key"}, cfg.TLS)
+	})
+
+	t.Run("invalid tls settings are rejected", func(t *testing.T) {
+		t.Setenv("TLS_CERT_FILE", "/etc/tls/tls.crt")
+		_, err := config.Load("svc")
+		assert.ErrorContains(t, err, "TLS_KEY_FILE")
+
+		t.Setenv("TLS_KEY_FILE", "/etc/tls/tls.key")
+		t.Setenv("TLS_CERT_DIR", "/etc/tls")
+		_, err = config.Load("svc")
+		assert.ErrorContains(t, err, "TLS_CERT_DIR")
+	})
+
+	t.Run("client certificate settings", func(t *testing.T) {
+		t.Setenv("TLS_CERT_DIR", "/etc/tls")
+		t.Setenv("TLS_CLIENT_CA_FILE", "/etc/tls/client-ca.crt")
+		t.Setenv("TLS_REQUIRE_CLIENT_CERT", "true")
+
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.TLSConfig{CertFile: "/etc/tls/tls.crt", KeyFile: "/etc/tls/tls.key", ClientCAFile: "/etc/tls/client-ca.crt", RequireClientCert: true}, cfg.TLS)
+	})
+
+	t.Run("admin listener settings", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.AdminConfig{Role: "admin"}, cfg.Admin)
+
+		t.Setenv("TLS_CERT_DIR", "/etc/tls")
+		t.Setenv("TLS_CLIENT_CA_FILE", "/etc/tls/client-ca.crt")
+		t.Setenv("ADMIN_GRPC_ADDRESS", ":9443")
+		t.Setenv("ADMIN_ROLE", "operator")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.AdminConfig{Address: ":9443", Role: "operator"}, cfg.Admin)
+	})
+
+	t.Run("invalid admin listener settings are rejected", func(t *testing.T) {
+		t.Setenv("ADMIN_GRPC_ADDRESS", ":9443")
+		_, err := config.Load("svc")
+		assert.ErrorContains(t, err, "ADMIN_GRPC_ADDRESS requires TLS_CLIENT_CA_FILE")
+
+		t.Setenv("ADMIN_GRPC_ADDRESS", "")
+		t.Setenv("AUTHZ_POLICY", config.AdminServicePrefix+"=support")
+		_, err = config.Load("svc")
+		assert.ErrorContains(t, err, "AUTHZ_POLICY must not cover "+config.AdminServicePrefix)
+
+		t.Setenv("AUTHZ_POLICY", config.AdminUserMethods[0]+"=support")
+		_, err = config.Load("svc")
+		assert.ErrorContains(t, err, "AUTHZ_POLICY must not cover "+config.AdminUserMethods[0])
+	})
+
+	t.Run("invalid client certificate settings are rejected", func(t *testing.T) {
+		t.Setenv("TLS_CLIENT_CA_FILE", "/etc/tls/client-ca.crt")
+		_, err := config.Load("svc")
+		assert.ErrorContains(t, err, "TLS_CLIENT_CA_FILE")
+
+		t.Setenv("TLS_CERT_DIR", "/etc/tls")
+		t.Setenv("TLS_REQUIRE_CLIENT_CERT", "always")
+		_, err = config.Load("svc")
+		assert.ErrorContains(t, err, "TLS_REQUIRE_CLIENT_CERT")
+
+		t.Setenv("TLS_CLIENT_CA_FILE", "")
+		t.Setenv("TLS_REQUIRE_CLIENT_CERT", "true")
+		_, err = config.Load("svc")
+		assert.ErrorContains(t, err, "TLS_REQUIRE_CLIENT_CERT requires TLS_CLIENT_CA_FILE")
+	})
+
+	t.Run("settings are read from the config file", func(t *testing.T) {
+		t.Setenv("CONFIG_FILE", writeConfigFile(t, `
+GRPC_ADDRESS: ":7000"
+RATE_LIMIT_PER_MINUTE: 90
+GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM: true
+METRIC_TENANT_ALLOWLIST: [acme, beta]
+DATABASE_SCHEMA:
+`))
+
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, ":7000", cfg.GRPCAddress)
+		assert.Equal(t, 90, cfg.RateLimitPerMinute)
+		assert.True(t, cfg.KeepalivePolicy.PermitWithoutStream)
+		assert.Equal(t, []string{"acme", "beta"}, cfg.MetricTenants.Allowed())
+		assert.Equal(t, model.DefaultSchema, cfg.Main.Schema)
+
+		entries := map[string]string{}
+		for _, entry := range cfg.Entries() {
+			entries[entry.Key] = entry.Value
+		}
+		assert.Equal(t, os.Getenv("CONFIG_FILE"), entries["config.file"])
+	})
+
+	t.Run("environment overrides the config file", func(t *testing.T) {
+		t.Setenv("CONFIG_FILE", writeConfigFile(t, "GRPC_ADDRESS: \":7000\"\nMETRICS_ADDRESS: \":7001\"\n"))
+		t.Setenv("GRPC_ADDRESS", ":8000")
+
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, ":8000", cfg.GRPCAddress)
+		assert.Equal(t, ":7001", cfg.MetricsAddress)
+	})
+
+	t.Run("invalid config files are rejected", func(t *testing.T) {
+		for name, content := range map[string]string{
+			"unknown setting": "GRPC_ADRESS: \":7000\"\n",
+			"nested mapping":  "LOG_MODULE_LEVELS:\n  repository: debug\n",
+			"nested list":     "SIGNED_METHODS: [[a]]\n",
+			"not a mapping":   "- GRPC_ADDRESS\n",
+			"invalid value":   "RATE_LIMIT_PER_MINUTE: lots\n",
+		} {
+			t.Run(name, func(t *testing.T) {
+				t.Setenv("CONFIG_FILE", writeConfigFile(t, content))
+				_, err := config.Load("svc")
+				assert.Error(t, err)
+			})
+		}
+
+		t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+		_, err := config.Load("svc")
+		assert.ErrorContains(t, err, "CONFIG_FILE")
+	})
+
+	t.Run("password policy defaults and settings", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.PasswordConfig{MinLength: 12, MaxLength: 128, MinEntropyBits: 50, BreachCheck: true, BreachAPIURL: password.PwnedPasswordsURL, HashAlgorithm: password.AlgorithmArgon2id}, cfg.Password)
+
+		t.Setenv("PASSWORD_MIN_LENGTH", "10")
+		t.Setenv("PASSWORD_MAX_LENGTH", "64")
+		t.Setenv("PASSWORD_MIN_ENTROPY_BITS", "0")
+		t.Setenv("PASSWORD_BREACH_CHECK", "false")
+		t.Setenv("PASSWORD_HASH_ALGORITHM", "bcrypt")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.PasswordConfig{MinLength: 10, MaxLength: 64, BreachAPIURL: password.PwnedPasswordsURL, HashAlgorithm: password.AlgorithmBcrypt}, cfg.Password)
+	})
+
+	t.Run("bcrypt needs passwords it reads in full", func(t *testing.T) {
+		t.Setenv("PASSWORD_HASH_ALGORITHM", "bcrypt")
+		_, err := config.Load("svc")
+		assert.ErrorContains(t, err, "PASSWORD_MAX_LENGTH must be at most 72")
+	})
+
+	t.Run("invalid password policy settings are rejected", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"PASSWORD_MIN_LENGTH":       "0",
+			"PASSWORD_MAX_LENGTH":       "4",
+			"PASSWORD_MIN_ENTROPY_BITS": "-1",
+			"PASSWORD_BREACH_CHECK":     "sometimes",
+			"PASSWORD_HASH_ALGORITHM":   "md5",
+		} {
+			t.Run(key, func(t *testing.T) {
+				t.Setenv(key, value)
+				_, err := config.Load("svc")
+				assert.ErrorContains(t, err, key)
+			})
+		}
+	})
+
+	t.Run("login throttling defaults and settings", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.LoginConfig{MaxFailures: 5, LockoutBase: time.Minute, LockoutMax: time.Hour, FailureReset: 24 * time.Hour, AccountMaxFailures: 20, AccountLockout: 15 * time.Minute}, cfg.Login)
+
+		t.Setenv("LOGIN_MAX_FAILURES", "3")
+		t.Setenv("LOGIN_LOCKOUT_BASE", "30s")
+		t.Setenv("LOGIN_LOCKOUT_MAX", "10m")
+		t.Setenv("LOGIN_FAILURE_RESET", "1h")
+		t.Setenv("LOGIN_ACCOUNT_MAX_FAILURES", "0")
+		t.Setenv("LOGIN_ACCOUNT_LOCKOUT", "1h")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.LoginConfig{MaxFailures: 3, LockoutBase: 30 * time.Second, LockoutMax: 10 * time.Minute, FailureReset: time.Hour, AccountLockout: time.Hour}, cfg.Login)
+	})
+
+	t.Run("invalid login throttling settings are rejected", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"LOGIN_MAX_FAILURES":         "0",
+			"LOGIN_LOCKOUT_BASE":         "0s",
+			"LOGIN_LOCKOUT_MAX":          "30s",
+			"LOGIN_FAILURE_RESET":        "soon",
+			"LOGIN_ACCOUNT_MAX_FAILURES": "-1",
+			"LOGIN_ACCOUNT_LOCKOUT":      "0s",
+		} {
+			t.Run(key, func(t *testing.T) {
+				t.Setenv(key, value)
+				_, err := config.Load("svc")
+				assert.ErrorContains(t, err, key)
+			})
+		}
+	})
+
+	t.Run("field encryption and two-factor defaults and settings", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Empty(t, cfg.FieldEncryptionKeys)
+		assert.Equal(t, config.TwoFactorConfig{Issuer: "svc"}, cfg.TwoFactor)
+
+		key1 := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+		t.Setenv("FIELD_ENCRYPTION_KEYS", "k1="+key1)
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, "k1", cfg.FieldEncryptionPrimaryKey)
+
+		t.Setenv("FIELD_ENCRYPTION_KEYS", "k1="+key1+",k2="+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32)))
+		t.Setenv("FIELD_ENCRYPTION_PRIMARY_KEY", "k2")
+		t.Setenv("TWO_FACTOR_ISSUER", "Example")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, bytes.Repeat([]byte{2}, 32), cfg.FieldEncryptionKeys["k2"])
+		assert.Equal(t, "k2", cfg.FieldEncryptionPrimaryKey)
+		assert.Equal(t, config.TwoFactorConfig{Issuer: "Example"}, cfg.TwoFactor)
+	})
+
+	t.Run("invalid field encryption settings are rejected", func(t *testing.T) {
+		key1 := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+		for name, env := range map[string]map[string]string{
+			"short key":           {"FIELD_ENCRYPTION_KEYS": "k1=" + base64.StdEncoding.EncodeToString([]byte("short"))},
+			"unknown primary key": {"FIELD_ENCRYPTION_KEYS": "k1=" + key1, "FIELD_ENCRYPTION_PRIMARY_KEY": "k2"},
+			"primary key only":    {"FIELD_ENCRYPTION_PRIMARY_KEY": "k1"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				for key, value := range env {
+					t.Setenv(key, value)
+				}
+				_, err := config.Load("svc")
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("email defaults and settings", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.EmailConfig{Sender: config.EmailSenderLog, VerificationTTL: 24 * time.Hour, PasswordResetTTL: time.Hour}, cfg.Email)
+
+		t.Setenv("EMAIL_SENDER", "smtp")
+		t.Setenv("EMAIL_FROM", "no-reply@example.com")
+		t.Setenv("SMTP_ADDRESS", "smtp.example.com:587")
+		t.Setenv("SMTP_USERNAME", "mailer")
+		t.Setenv("SMTP_PASSWORD", "hunter2")
+		t.Setenv("EMAIL_VERIFICATION_URL", "https://app.example.com/verify-email")
+		t.Setenv("EMAIL_VERIFICATION_TTL", "2h")
+		t.Setenv("EMAIL_PASSWORD_RESET_URL", "https://app.example.com/reset-password")
+		t.Setenv("EMAIL_PASSWORD_RESET_TTL", "30m")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.EmailConfig{
+			Sender:           config.EmailSenderSMTP,
+			From:             "no-reply@example.com",
+			SMTPAddress:      "smtp.example.com:587",
+			SMTPUsername:     "mailer",
+			SMTPPassword:     "hunter2",
+			VerificationURL:  "https://app.example.com/verify-email",
+			VerificationTTL:  2 * time.Hour,
+			PasswordResetURL: "https://app.example.com/reset-password",
+			PasswordResetTTL: 30 * time.Minute,
+		}, cfg.Email)
+		for _, entry := range cfg.Entries() {
+			assert.NotContains(t, entry.Value, "hunter2", entry.Key)
+		}
+	})
+
+	t.Run("invalid email settings are rejected", func(t *testing.T) {
+		for name, env := range map[string]map[string]string{
+			"unknown sender":              {"EMAIL_SENDER": "pigeon"},
+			"smtp without address":        {"EMAIL_SENDER": "smtp", "EMAIL_FROM": "no-reply@example.com"},
+			"smtp without from":           {"EMAIL_SENDER": "smtp", "SMTP_ADDRESS": "smtp.example.com:587"},
+			"smtp address without port":   {"EMAIL_SENDER": "smtp", "EMAIL_FROM": "no-reply@example.com", "SMTP_ADDRESS": "smtp.example.com"},
+			"username without password":   {"SMTP_USERNAME": "mailer"},
+			"relative verification url":   {"EMAIL_VERIFICATION_URL": "/verify-email"},
+			"zero verification ttl":       {"EMAIL_VERIFICATION_TTL": "0s"},
+			"relative password reset url": {"EMAIL_PASSWORD_RESET_URL": "reset-password"},
+			"negative password reset ttl": {"EMAIL_PASSWORD_RESET_TTL": "-1h"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				for key, value := range env {
+					t.Setenv(key, value)
+				}
+				_, err := config.Load("svc")
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("authorization policy defaults and settings", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.DefaultAuthzPolicy, cfg.Authz.Policy)
+		assert.Equal(t, config.DefaultAuthzRoles, cfg.Authz.Roles)
+		assert.True(t, cfg.Authz.DenyUnlisted)
+		assert.Equal(t, 30*time.Second, cfg.Authz.CacheTTL)
+
+		t.Setenv("AUTHZ_POLICY", "/proto.v1.LedgerService/=admin, /proto.v1.LedgerService/ListLedgers=reader|admin")
+		t.Setenv("AUTHZ_ROLES", "service:CN=ops=admin,api_key:*=reader")
+		t.Setenv("AUTHZ_DENY_UNLISTED", "false")
+		t.Setenv("AUTHZ_CACHE_TTL", "0s")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.AuthzConfig{
+			Policy: map[string][]string{
+				"/proto.v1.LedgerService/":            {"admin"},
+				"/proto.v1.LedgerService/ListLedgers": {"reader", "admin"},
+			},
+			Roles: map[string][]string{
+				"service:CN=ops": {"admin"},
+				"api_key:*":      {"reader"},
+			},
+		}, cfg.Authz)
+		assert.Contains(t, cfg.Entries(), model.ConfigEntry{Key: "authz.roles", Value: "api_key:*=reader,service:CN=ops=admin"})
+	})
+
+	t.Run("invalid authorization settings are rejected", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"AUTHZ_POLICY":        "proto.v1.LedgerService/=admin",
+			"AUTHZ_ROLES":         "service:ops=",
+			"AUTHZ_DENY_UNLISTED": "sometimes",
+			"AUTHZ_CACHE_TTL":     "-1s",
+		} {
+			t.Run(key, func(t *testing.T) {
+				t.Setenv(key, value)
+				_, err := config.Load("svc")
+				assert.ErrorContains(t, err, key)
+			})
+		}
+	})
+
+	t.Run("oidc defaults and settings", func(t *testing.T) {
+		cfg, err := config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.OIDCConfig{JWKSRefreshInterval: time.Hour}, cfg.OIDC)
+
+		t.Setenv("OIDC_ISSUER", "https://auth.example.com/realms/main")
+		t.Setenv("OIDC_AUDIENCE", "go-grpc-template")
+		t.Setenv("OIDC_JWKS_URL", "http://keycloak.auth.svc:8080/realms/main/protocol/openid-connect/certs")
+		t.Setenv("OIDC_JWKS_REFRESH_INTERVAL", "15m")
+		cfg, err = config.Load("svc")
+		require.NoError(t, err)
+		assert.Equal(t, config.OIDCConfig{
+			Issuer:              "https://auth.example.com/realms/main",
+			Audience:            "go-grpc-template",
+			JWKSURL:             "http://keycloak.auth.svc:8080/realms/main/protocol/openid-connect/certs",
+			JWKSRefreshInterval: 15 * time.Minute,
+		}, cfg.OIDC)
+		assert.Contains(t, cfg.Entries(), model.ConfigEntry{Key: "oidc.issuer", Value: "https://auth.example.com/realms/main"})
+	})
+
+	t.Run("invalid oidc settings are rejected", func(t *testing.T) {
+		for name, env := range map[string]map[string]string{
+			"audience without issuer": {"OIDC_AUDIENCE": "svc"},
+			"issuer without audience": {"OIDC_ISSUER": "https://auth.example.com"},
+			"issuer not a url":        {"OIDC_ISSUER": "auth.example.com", "OIDC_AUDIENCE": "svc"},
+			"jwks url scheme":         {"OIDC_ISSUER": "https://auth.example.com", "OIDC_AUDIENCE": "svc", "OIDC_JWKS_URL": "file:///etc/jwks.json"},
+			"refresh interval":        {"OIDC_JWKS_REFRESH_INTERVAL": "0s"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				for key, value := range env {
+					t.Setenv(key, value)
+				}
+				_, err := config.Load("svc")
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("invalid rate limit is rejected", func(t *testing.T) {
+		for _, value := range []string{"abc", "0", "-5"} {
+			t.Setenv("RATE_LIMIT_PER_MINUTE", value)
+			_, err := config.Load("svc")
+			assert.Error(t, err, value)
+		}
+	})
+
+	t.Run("invalid rate limit burst is rejected", func(t *testing.T) {
+		for _, value := range []string{"abc", "0", "-5"} {
+			t.Setenv("RATE_LIMIT_BURST", value)
+			_, err := config.Load("svc")
+			assert.Error(t, err, value)
+		}
+	})
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/stats"
+)
+
+func TestConnectionStatsHandler(t *testing.T) {
+	ctx := context.Background()
+	open := func(h stats.Handler) context.Context {
+		connCtx := h.TagConn(ctx, &stats.ConnTagInfo{})
+		h.HandleConn(connCtx, &stats.ConnBegin{})
+		return connCtx
+	}
+	call := func(h stats.Handler, connCtx context.Context) {
+		rpcCtx := h.TagRPC(connCtx, &stats.RPCTagInfo{FullMethodName: "/test.Service/Method"})
+		h.HandleRPC(rpcCtx, &stats.Begin{})
+		h.HandleRPC(rpcCtx, &stats.End{})
+	}
+	closeReasons := func(meter *mockMeter) map[string]int {
+		return meter.metrics["grpc_server_connections_closed_total"].observations
+	}
+
+	t.Run("counts accepted and active connections", func(t *testing.T) {
+		meter := &mockMeter{}
+		h := interceptor.ConnectionStatsHandler(keepalive.ServerParameters{}, meter)
+
+		first := open(h)
+		open(h)
+		h.HandleConn(first, &stats.ConnEnd{})
+
+		assert.Equal(t, 2, meter.metrics["grpc_server_connections_accepted_total"].observations[""])
+		assert.Equal(t, float64(1), meter.metrics["grpc_server_connections_active"].values[""])
+		assert.Equal(t, 1, meter.metrics["grpc_server_connection_duration_seconds"].observations[interceptor.CloseReasonPeerIdle])
+	})
+
+	t.Run("idle connection closed before server limits is peer idle", func(t *testing.T) {
+		meter := &mockMeter{}
+		h := interceptor.ConnectionStatsHandler(keepalive.ServerParameters{MaxConnectionIdle: time.Hour}, meter)
+
+		connCtx := open(h)
+		call(h, connCtx)
+		h.HandleConn(connCtx, &stats.ConnEnd{})
+
+		assert.Equal(t, map[string]int{interceptor.CloseReasonPeerIdle: 1}, closeReasons(meter))
+	})
+
+	t.Run("connection idle for max connection idle is reaped", func(t *testing.T) {
+		meter := &mockMeter{}
+		h := interceptor.ConnectionStatsHandler(keepalive.ServerParameters{MaxConnectionIdle: 150 * time.Millisecond}, meter)
+
+		connCtx := open(h)
+		call(h, connCtx)
+		time.Sleep(100 * time.Millisecond)
+		h.HandleConn(connCtx, &stats.ConnEnd{})
+
+		assert.Equal(t, map[string]int{interceptor.CloseReasonMaxIdle: 1}, closeReasons(meter))
+	})
+
+	t.Run("connection closed with rpcs running is in flight", func(t *testing.T) {
+		meter := &mockMeter{}
+		h := interceptor.ConnectionStatsHandler(keepalive.ServerParameters{MaxConnectionIdle: time.Nanosecond}, meter)
+
+		connCtx := open(h)
+		h.HandleRPC(h.TagRPC(connCtx, &stats.RPCTagInfo{}), &stats.Begin{})
+		h.HandleConn(connCtx, &stats.ConnEnd{})
+
+		assert.Equal(t, map[string]int{interceptor.CloseReasonInFlight: 1}, closeReasons(meter))
+	})
+
+	t.Run("connection past max connection age is aged out", func(t *testing.T) {
+		meter := &mockMeter{}
+		h := interceptor.ConnectionStatsHandler(keepalive.ServerParameters{MaxConnectionAge: 20 * time.Millisecond}, meter)
+
+		connCtx := open(h)
+		h.HandleRPC(h.TagRPC(connCtx, &stats.RPCTagInfo{}), &stats.Begin{})
+		time.Sleep(20 * time.Millisecond)
+		h.HandleConn(connCtx, &stats.ConnEnd{})
+
+		assert.Equal(t, map[string]int{interceptor.CloseReasonMaxAge: 1}, closeReasons(meter))
+	})
+}
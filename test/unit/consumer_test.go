@@ -0,0 +1,247 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/consumer"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/event"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/retry"
+	retryImpl "github.com/jt828/go-grpc-template/pkg/retry/implementation"
+	"github.com/jt828/go-grpc-template/proto/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// channelSource delivers from a channel and records acked event ids.
+type channelSource struct {
+	deliveries chan *consumer.Delivery
+	mu         sync.Mutex
+	acked      []int64
+}
+
+func (s *channelSource) Receive(ctx context.Context) (*consumer.Delivery, error) {
+	select {
+	case d := <-s.deliveries:
+		return d, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *channelSource) Ack(ctx context.Context, delivery *consumer.Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acked = append(s.acked, delivery.Envelope.Id)
+	return nil
+}
+
+type mockInboxRepository struct {
+	insertFunc func(ctx context.Context, message *model.InboxMessage) (bool, error)
+}
+
+func (m *mockInboxRepository) Insert(ctx context.Context, message *model.InboxMessage) (bool, error) {
+	return m.insertFunc(ctx, message)
+}
+
+func TestConsumer(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	typeURL := event.TypeURL(&events.UserCreatedV1{})
+
+	newDelivery := func(t *testing.T, id int64) *consumer.Delivery {
+		envelope, err := event.Pack(id, &events.UserCreatedV1{UserId: 1, Username: "alice"}, now)
+		require.NoError(t, err)
+		return &consumer.Delivery{Topic: "users", Envelope: envelope}
+	}
+
+	// setup backs the inbox with a map that only keeps inserts from committed
+	// units of work, like the real table.
+	setup := func(t *testing.T, r retry.Retry) (*consumer.Consumer, *[]*model.DeadLetter, *mockMeter) {
+		var mu sync.Mutex
+		inbox := map[model.InboxMessage]bool{}
+		var deadLetters []*model.DeadLetter
+
+		factory := &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) {
+			var pending []model.InboxMessage
+			var pendingDeadLetters []*model.DeadLetter
+			return &mockUnitOfWork{
+				inboxRepo: &mockInboxRepository{insertFunc: func(ctx context.Context, message *model.InboxMessage) (bool, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					key := model.InboxMessage{Handler: message.Handler, EventId: message.EventId}
+					if inbox[key] {
+						return false, nil
+					}
+					pending = append(pending, key)
+					return true, nil
+				}},
+				deadLetterRepo: &mockDeadLetterRepository{
+					insertFunc: func(ctx context.Context, deadLetter *model.DeadLetter) error {
+						pendingDeadLetters = append(pendingDeadLetters, deadLetter)
+						return nil
+					},
+				},
+				commitFunc: func(ctx context.Context) error {
+					mu.Lock()
+					defer mu.Unlock()
+					for _, key := range pending {
+						inbox[key] = true
+					}
+					deadLetters = append(deadLetters, pendingDeadLetters...)
+					return nil
+				},
+				abortFunc: func(ctx context.Context) error { return nil },
+			}, nil
+		}}
+
+		meter := &mockMeter{}
+		c := consumer.NewConsumer(factory, r, &mockSnowflake{id: 900}, meter, &mockLogger{})
+		return c, &deadLetters, meter
+	}
+	fastRetry := func(maxRetries uint64) retry.Retry {
+		return retryImpl.NewRetry(maxRetries, retry.WithInterval(time.Millisecond))
+	}
+
+	t.Run("redelivered event is handled once", func(t *testing.T) {
+		c, deadLetters, meter := setup(t, fastRetry(2))
+		calls := 0
+		c.Register("users", typeURL, consumer.HandlerFunc(func(ctx context.Context, uow repository.UnitOfWork, envelope *event.Envelope) error {
+			var msg events.UserCreatedV1
+			require.NoError(t, event.UnpackTo(envelope, &msg))
+			assert.Equal(t, "alice", msg.GetUsername())
+			calls++
+			return nil
+		}))
+
+		require.NoError(t, c.Process(ctx, newDelivery(t, 1)))
+		require.NoError(t, c.Process(ctx, newDelivery(t, 1)))
+
+		assert.Equal(t, 1, calls)
+		assert.Empty(t, *deadLetters)
+		assert.Equal(t, 1, meter.metrics["consumer_events_handled_total"].observations["users/UserCreatedV1"])
+		assert.Equal(t, 1, meter.metrics["consumer_events_duplicate_total"].observations["users/UserCreatedV1"])
+	})
+
+	t.Run("transient failure is retried", func(t *testing.T) {
+		c, deadLetters, _ := setup(t, fastRetry(2))
+		calls := 0
+		c.Register("users", typeURL, consumer.HandlerFunc(func(ctx context.Context, uow repository.UnitOfWork, envelope *event.Envelope) error {
+			calls++
+			if calls == 1 {
+				return errors.New("deadlock detected")
+			}
+			return nil
+		}))
+
+		require.NoError(t, c.Process(ctx, newDelivery(t, 2)))
+		assert.Equal(t, 2, calls)
+		assert.Empty(t, *deadLetters)
+	})
+
+	t.Run("exhausted retries dead-letter the event", func(t *testing.T) {
+		c, deadLetters, meter := setup(t, fastRetry(2))
+		c.Register("users", typeURL, consumer.HandlerFunc(func(ctx context.Context, uow repository.UnitOfWork, envelope *event.Envelope) error {
+			return errors.New("downstream unavailable")
+		}))
+
+		require.NoError(t, c.Process(ctx, newDelivery(t, 3)))
+
+		require.Len(t, *deadLetters, 1)
+		dl := (*deadLetters)[0]
+		assert.Equal(t, int64(900), dl.Id)
+		assert.Equal(t, model.DeadLetterSourceConsumer, dl.Source)
+		assert.Equal(t, typeURL, dl.EventType)
+		assert.Equal(t, 3, dl.Attempts)
+		assert.Equal(t, "downstream unavailable", dl.LastError)
+
+		var payload map[string]any
+		require.NoError(t, json.Unmarshal([]byte(dl.Payload), &payload))
+		assert.Equal(t, "users", payload["topic"])
+		assert.Equal(t, 1, meter.metrics["consumer_events_dead_lettered_total"].observations["users/UserCreatedV1"])
+	})
+
+	t.Run("permanent failure skips retries", func(t *testing.T) {
+		c, deadLetters, _ := setup(t, fastRetry(5))
+		calls := 0
+		c.Register("users", typeURL, consumer.HandlerFunc(func(ctx context.Context, uow repository.UnitOfWork, envelope *event.Envelope) error {
+			calls++
+			return consumer.Permanent(errors.New("malformed event"))
+		}))
+
+		require.NoError(t, c.Process(ctx, newDelivery(t, 4)))
+		assert.Equal(t, 1, calls)
+		require.Len(t, *deadLetters, 1)
+		assert.Equal(t, 1, (*deadLetters)[0].Attempts)
+	})
+
+	t.Run("event without handler is dead-lettered", func(t *testing.T) {
+		c, deadLetters, _ := setup(t, fastRetry(2))
+
+		require.NoError(t, c.Process(ctx, newDelivery(t, 5)))
+		require.Len(t, *deadLetters, 1)
+		assert.Equal(t, 0, (*deadLetters)[0].Attempts)
+		assert.Contains(t, (*deadLetters)[0].LastError, "no handler for users/UserCreatedV1")
+	})
+
+	t.Run("replay runs the handler on the dead-lettered event", func(t *testing.T) {
+		c, deadLetters, _ := setup(t, fastRetry(0))
+		fail := true
+		var replayedId int64
+		c.Register("users", typeURL, consumer.HandlerFunc(func(ctx context.Context, uow repository.UnitOfWork, envelope *event.Envelope) error {
+			if fail {
+				return errors.New("downstream unavailable")
+			}
+			replayedId = envelope.Id
+			return nil
+		}))
+		require.NoError(t, c.Process(ctx, newDelivery(t, 6)))
+		require.Len(t, *deadLetters, 1)
+
+		fail = false
+		uow := &mockUnitOfWork{inboxRepo: &mockInboxRepository{insertFunc: func(ctx context.Context, message *model.InboxMessage) (bool, error) {
+			return true, nil
+		}}}
+		require.NoError(t, c.Replay(ctx, uow, (*deadLetters)[0]))
+		assert.Equal(t, int64(6), replayedId)
+	})
+
+	t.Run("run acks processed deliveries and drains on shutdown", func(t *testing.T) {
+		c, _, _ := setup(t, fastRetry(0))
+		release := make(chan struct{})
+		started := make(chan struct{})
+		c.Register("users", typeURL, consumer.HandlerFunc(func(ctx context.Context, uow repository.UnitOfWork, envelope *event.Envelope) error {
+			if envelope.Id == 8 {
+				close(started)
+				<-release
+			}
+			return nil
+		}))
+
+		source := &channelSource{deliveries: make(chan *consumer.Delivery, 2)}
+		source.deliveries <- newDelivery(t, 7)
+		source.deliveries <- newDelivery(t, 8)
+
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() { done <- c.Run(runCtx, source, 1) }()
+
+		<-started
+		cancel()
+		select {
+		case <-done:
+			t.Fatal("Run returned before in-flight delivery finished")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		close(release)
+		require.NoError(t, <-done)
+		assert.Equal(t, []int64{7, 8}, source.acked)
+	})
+}
@@ -0,0 +1,231 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/controller/convert"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConvert(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	updatedAt := createdAt.Add(time.Hour)
+
+	t.Run("user round-trips without password", func(t *testing.T) {
+		user := &model.User{
+			Id:        1,
+			Email:     "alice@example.com",
+			Username:  "alice",
+			Password:  "secret",
+			Status:    model.UserStatusSuspended,
+			CreatedAt: createdAt,
+			UpdatedAt: updatedAt,
+		}
+
+		got := convert.FromUser(convert.User(user))
+
+		want := *user
+		want.Password = ""
+		assert.Equal(t, &want, got)
+	})
+
+	t.Run("single-user responses match the user message on the wire", func(t *testing.T) {
+		user := &model.User{Id: 1, Email: "alice@example.com", Username: "alice", Status: model.UserStatusActive, CreatedAt: createdAt, UpdatedAt: updatedAt}
+		want, err := proto.Marshal(convert.User(user))
+		require.NoError(t, err)
+
+		for _, response := range []proto.Message{
+			convert.GetUserByIdResponse(user),
+			convert.CreateUserResponse(user),
+			convert.UpdateUserStatusResponse(user),
+			convert.UpdateUserResponse(user),
+		} {
+			got, err := proto.Marshal(response)
+			require.NoError(t, err)
+			assert.Equal(t, want, got, "%T", response)
+		}
+	})
+
+	t.Run("user status round-trips", func(t *testing.T) {
+		for _, status := range []model.UserStatus{model.UserStatusActive, model.UserStatusSuspended, model.UserStatusDeleted} {
+			got, ok := convert.FromUserStatus(convert.UserStatus(status))
+			assert.True(t, ok)
+			assert.Equal(t, status, got)
+		}
+		_, ok := convert.FromUserStatus(v1.UserStatus_USER_STATUS_UNSPECIFIED)
+		assert.False(t, ok)
+	})
+
+	t.Run("transaction type round-trips", func(t *testing.T) {
+		for _, transactionType := range model.TransactionTypes {
+			assert.True(t, transactionType.IsValid())
+			got, ok := convert.FromTransactionType(convert.TransactionType(transactionType))
+			assert.True(t, ok)
+			assert.Equal(t, transactionType, got)
+		}
+		_, ok := convert.FromTransactionType(v1.TransactionType_TRANSACTION_TYPE_UNSPECIFIED)
+		assert.False(t, ok)
+		assert.False(t, model.TransactionType("refund").IsValid())
+	})
+
+	t.Run("ledger falls back to the deprecated transaction type string", func(t *testing.T) {
+		got, err := convert.FromLedger(&v1.Ledger{Id: 2, TransactionType: "withdraw", Amount: convert.Decimal(decimal.NewFromInt(1))})
+		require.NoError(t, err)
+		assert.Equal(t, model.TransactionTypeWithdraw, got.TransactionType)
+	})
+
+	t.Run("ledger round-trips and attaches owner", func(t *testing.T) {
+		ledger := &model.Ledger{Id: 2, UserId: 1, TransactionType: "deposit", Token: "USDT", Amount: decimal.RequireFromString("1234.500000000000000001"), CreatedAt: createdAt}
+		user := &model.User{Id: 1, Username: "alice", Status: model.UserStatusActive}
+
+		message := convert.Ledger(ledger, user)
+		assert.Equal(t, "1234.500000000000000001", message.Amount.Value)
+		assert.Equal(t, v1.TransactionType_TRANSACTION_TYPE_DEPOSIT, message.Type)
+		assert.Equal(t, "deposit", message.TransactionType)
+		got, err := convert.FromLedger(message)
+		require.NoError(t, err)
+		assert.True(t, ledger.Amount.Equal(got.Amount))
+		got.Amount = ledger.Amount
+		assert.Equal(t, ledger, got)
+		assert.Equal(t, "alice", message.User.Username)
+		assert.Equal(t, v1.UserStatus_USER_STATUS_ACTIVE, message.User.Status)
+		assert.Nil(t, convert.Ledger(ledger, nil).User)
+	})
+
+	t.Run("dead letter round-trips", func(t *testing.T) {
+		replayedAt := updatedAt
+		for _, deadLetter := range []*model.DeadLetter{
+			{Id: 3, Source: model.DeadLetterSourceConsumer, EventType: "users/UserCreatedV1", Payload: `{"id":1}`, LastError: "boom", Attempts: 4, CreatedAt: createdAt},
+			{Id: 4, Source: model.DeadLetterSourceOutbox, Attempts: 1, CreatedAt: createdAt, ReplayedAt: &replayedAt},
+		} {
+			message := convert.DeadLetter(deadLetter)
+			assert.Equal(t, deadLetter.ReplayedAt == nil, message.ReplayedAt == nil)
+			assert.Equal(t, deadLetter, convert.FromDeadLetter(message))
+		}
+	})
+
+	t.Run("active session round-trips without its user", func(t *testing.T) {
+		session := &model.Session{Id: 5, UserAgent: "curl/8.0", Ip: "10.0.0.1", CreatedAt: createdAt, LastSeenAt: updatedAt}
+		assert.Equal(t, session, convert.FromSession(convert.Session(session)))
+	})
+
+	t.Run("user identity round-trips", func(t *testing.T) {
+		identity := &model.UserIdentity{Issuer: "https://auth.example.com", Subject: "alice", UserId: 1, CreatedAt: createdAt, CreatedBy: "service:ops"}
+		assert.Equal(t, identity, convert.FromUserIdentity(convert.UserIdentity(identity)))
+	})
+
+	t.Run("role round-trips", func(t *testing.T) {
+		role := &model.Role{Name: "support", Permissions: []string{"users.read", "users.suspend"}, CreatedAt: createdAt, CreatedBy: "service:ops"}
+		assert.Equal(t, role, convert.FromRole(convert.Role(role)))
+	})
+
+	t.Run("config entry round-trips", func(t *testing.T) {
+		entry := model.ConfigEntry{Key: "postgresql.dsn", Value: "postgres://app:xxxxx@db/app", Redacted: true}
+		assert.Equal(t, entry, convert.FromConfigEntry(convert.ConfigEntry(entry)))
+	})
+
+	t.Run("schema drift round-trips", func(t *testing.T) {
+		for _, kind := range []model.SchemaDriftKind{
+			model.SchemaDriftMissingTable, model.SchemaDriftMissingColumn, model.SchemaDriftUnexpectedColumn,
+			model.SchemaDriftColumnType, model.SchemaDriftColumnNullability, model.SchemaDriftMissingIndex, model.SchemaDriftUnexpectedIndex,
+		} {
+			drift := &model.SchemaDrift{Store: "postgresql", Object: "ledgers.token", Kind: kind, Expected: "character varying(32)", Actual: "text"}
+			message := convert.SchemaDrift(drift)
+			assert.NotEqual(t, v1.SchemaDriftKind_SCHEMA_DRIFT_KIND_UNSPECIFIED, message.Kind, kind)
+			assert.Equal(t, drift, convert.FromSchemaDrift(message))
+		}
+	})
+
+	t.Run("dependency status leaves probe fields unset before first probe", func(t *testing.T) {
+		closed := circuitbreaker.Closed
+		message := convert.DependencyStatus(&model.DependencyStatus{Name: "database", State: model.DependencyStateUnknown, CircuitBreakerState: &closed})
+		assert.Equal(t, v1.DependencyState_DEPENDENCY_STATE_UNSPECIFIED, message.State)
+		assert.Equal(t, v1.CircuitBreakerState_CIRCUIT_BREAKER_STATE_CLOSED, message.CircuitBreakerState)
+		assert.Nil(t, message.LastProbeAt)
+		assert.Nil(t, message.LastProbeLatency)
+
+		message = convert.DependencyStatus(&model.DependencyStatus{Name: "database", State: model.DependencyStateUp, LastProbeAt: createdAt, LastProbeLatency: 5 * time.Millisecond})
+		assert.Equal(t, createdAt, message.LastProbeAt.AsTime())
+		assert.Equal(t, 5*time.Millisecond, message.LastProbeLatency.AsDuration())
+		assert.Equal(t, v1.CircuitBreakerState_CIRCUIT_BREAKER_STATE_UNSPECIFIED, message.CircuitBreakerState)
+	})
+
+	t.Run("dependency and circuit breaker states round-trip", func(t *testing.T) {
+		for _, state := range []model.DependencyState{model.DependencyStateUnknown, model.DependencyStateUp, model.DependencyStateDown} {
+			assert.Equal(t, state, convert.FromDependencyState(convert.DependencyState(state)))
+		}
+		for _, state := range []circuitbreaker.State{circuitbreaker.Closed, circuitbreaker.HalfOpen, circuitbreaker.Open} {
+			assert.Equal(t, &state, convert.FromCircuitBreakerState(convert.CircuitBreakerState(&state)))
+		}
+		assert.Nil(t, convert.FromCircuitBreakerState(convert.CircuitBreakerState(nil)))
+	})
+}
+
+func TestConvertDecimal(t *testing.T) {
+	t.Run("values round-trip exactly in canonical form", func(t *testing.T) {
+		tests := []struct {
+			in   string
+			want string
+		}{
+			{"0", "0"},
+			{"-0", "0"},
+			{"1.50", "1.5"},
+			{"007.25", "7.25"},
+			{"-0.000000000000000001", "-0.000000000000000001"},
+			{"999999999999999999.999999999999999999", "999999999999999999.999999999999999999"},
+			{"0.1", "0.1"},
+		}
+		for _, tt := range tests {
+			d, err := convert.FromDecimal(&v1.DecimalValue{Value: tt.in})
+			require.NoError(t, err, tt.in)
+			assert.Equal(t, tt.want, convert.Decimal(d).Value, tt.in)
+
+			again, err := convert.FromDecimal(convert.Decimal(d))
+			require.NoError(t, err, tt.in)
+			assert.True(t, d.Equal(again), tt.in)
+		}
+	})
+
+	t.Run("sums are exact where floats are not", func(t *testing.T) {
+		a, err := convert.FromDecimal(&v1.DecimalValue{Value: "0.1"})
+		require.NoError(t, err)
+		b, err := convert.FromDecimal(&v1.DecimalValue{Value: "0.2"})
+		require.NoError(t, err)
+		assert.Equal(t, "0.3", convert.Decimal(a.Add(b)).Value)
+	})
+
+	t.Run("invalid values are rejected", func(t *testing.T) {
+		for _, in := range []*v1.DecimalValue{
+			nil,
+			{Value: ""},
+			{Value: "abc"},
+			{Value: "+1"},
+			{Value: "1e5"},
+			{Value: "1."},
+			{Value: ".5"},
+			{Value: "1,5"},
+			{Value: " 1"},
+			{Value: "NaN"},
+			{Value: "Infinity"},
+			{Value: "1000000000000000000"},
+			{Value: "0.0000000000000000001"},
+		} {
+			_, err := convert.FromDecimal(in)
+			assert.ErrorIs(t, err, apperror.ErrInvalidArgument, "%v", in)
+		}
+	})
+
+	t.Run("insignificant zeros do not count toward limits", func(t *testing.T) {
+		d, err := convert.FromDecimal(&v1.DecimalValue{Value: "000000000000000000001.1000000000000000000000"})
+		require.NoError(t, err)
+		assert.Equal(t, "1.1", convert.Decimal(d).Value)
+	})
+}
@@ -0,0 +1,138 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/idempotency"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingExport keeps what a data export hands it, failing with err once
+// it has been handed failAfter ledgers.
+type recordingExport struct {
+	user      *model.User
+	ledgers   []*model.Ledger
+	records   []*idempotency.Record
+	pages     int
+	failAfter int
+	err       error
+}
+
+func (e *recordingExport) Profile(user *model.User) error {
+	e.user = user
+	return nil
+}
+
+func (e *recordingExport) Ledgers(ledgers []*model.Ledger) error {
+	if e.err != nil && len(e.ledgers) >= e.failAfter {
+		return e.err
+	}
+	e.ledgers = append(e.ledgers, ledgers...)
+	e.pages++
+	return nil
+}
+
+func (e *recordingExport) IdempotencyRecords(records []*idempotency.Record) error {
+	e.records = append(e.records, records...)
+	return nil
+}
+
+func TestDataExportService_ExportUserData(t *testing.T) {
+	ctx := context.Background()
+
+	// newService serves user 7 with ledgers 1 to ledgerCount, and records 1
+	// and 3 of user 7 alongside record 2 of user 8, counting the units of
+	// work it starts.
+	newService := func(ledgerCount int64, units *int) service.DataExportService {
+		users := &mockUserRepository{getFunc: func(ctx context.Context, id int64) (*model.User, error) {
+			if id != 7 {
+				return nil, nil
+			}
+			return &model.User{Id: 7, Username: "ada"}, nil
+		}}
+		ledgers := &mockLedgerRepository{getFunc: func(ctx context.Context, query repository.GetQuery) ([]*model.Ledger, error) {
+			var page []*model.Ledger
+			for id := query.IdGt + 1; id <= ledgerCount && len(page) < query.Limit; id++ {
+				if query.UserIdEq == 7 {
+					page = append(page, &model.Ledger{Id: id, UserId: 7})
+				}
+			}
+			return page, nil
+		}}
+		store := &memoryIdempotencyStore{
+			live: map[int64]*idempotency.Record{
+				1: {Id: 1, ReferenceId: 7},
+				2: {Id: 2, ReferenceId: 8},
+				3: {Id: 3, ReferenceId: 7},
+			},
+			quarantined: map[int64]*idempotency.QuarantinedRecord{},
+		}
+		factory := &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) {
+			*units++
+			return &mockUnitOfWork{
+				userRepo:       users,
+				ledgerRepo:     ledgers,
+				quarantineRepo: memoryIdempotencyQuarantine{store},
+				commitFunc:     func(ctx context.Context) error { return nil },
+				abortFunc:      func(ctx context.Context) error { return nil },
+			}, nil
+		}}
+		return service.NewDataExportService(factory, &mockLogger{})
+	}
+
+	t.Run("exports the profile, ledgers and records of the user", func(t *testing.T) {
+		units := 0
+		export := &recordingExport{}
+		found, err := newService(1001, &units).ExportUserData(ctx, 7, export)
+		require.NoError(t, err)
+		assert.True(t, found)
+
+		assert.Equal(t, "ada", export.user.Username)
+		require.Len(t, export.ledgers, 1001)
+		assert.Equal(t, int64(1), export.ledgers[0].Id)
+		assert.Equal(t, int64(1001), export.ledgers[1000].Id)
+		assert.Equal(t, 3, export.pages, "ledgers are handed over page by page")
+		require.Len(t, export.records, 2)
+		assert.Equal(t, int64(1), export.records[0].Id)
+		assert.Equal(t, int64(3), export.records[1].Id)
+		assert.Equal(t, 5, units, "the profile, three ledger pages and one record page each read in their own unit of work")
+	})
+
+	t.Run("reads one more page when the last is full", func(t *testing.T) {
+		units := 0
+		export := &recordingExport{}
+		_, err := newService(500, &units).ExportUserData(ctx, 7, export)
+		require.NoError(t, err)
+		assert.Len(t, export.ledgers, 500)
+		assert.Equal(t, 1, export.pages, "the empty page is not handed over")
+		assert.Equal(t, 4, units)
+	})
+
+	t.Run("reports a missing user", func(t *testing.T) {
+		units := 0
+		export := &recordingExport{}
+		found, err := newService(10, &units).ExportUserData(ctx, 9, export)
+		require.NoError(t, err)
+		assert.False(t, found)
+		assert.Nil(t, export.user)
+		assert.Empty(t, export.ledgers)
+		assert.Equal(t, 1, units)
+	})
+
+	t.Run("stops when the export fails", func(t *testing.T) {
+		units := 0
+		sendErr := errors.New("stream closed")
+		export := &recordingExport{failAfter: 500, err: sendErr}
+		_, err := newService(1001, &units).ExportUserData(ctx, 7, export)
+		assert.ErrorIs(t, err, sendErr)
+		assert.Len(t, export.ledgers, 500)
+		assert.Empty(t, export.records)
+		assert.Equal(t, 3, units)
+	})
+}
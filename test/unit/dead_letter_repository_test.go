@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func deadLetterColumns() []string {
+	return []string{"id", "source", "event_type", "payload", "last_error", "attempts", "created_at", "replayed_at"}
+}
+
+func TestDeadLetterRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	t.Run("list pending by source after cursor", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewDeadLetterRepository(db, &passthroughCB{}, &passthroughRetry{}, false)
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			`SELECT * FROM "main"."dead_letters" WHERE source = $1 AND id > $2 AND replayed_at IS NULL ORDER BY id LIMIT $3`)).
+			WithArgs(model.DeadLetterSourceOutbox, int64(5), 10).
+			WillReturnRows(sqlmock.NewRows(deadLetterColumns()).
+				AddRow(6, "outbox", "user.created", `{"id":1}`, "timeout", 5, now, nil))
+
+		result, err := repo.List(ctx, repository.DeadLetterQuery{
+			SourceEq:    model.DeadLetterSourceOutbox,
+			PendingOnly: true,
+			IdGt:        5,
+			Limit:       10,
+		})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, int64(6), result[0].Id)
+		assert.Equal(t, model.DeadLetterSourceOutbox, result[0].Source)
+		assert.Nil(t, result[0].ReplayedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("mark replayed", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewDeadLetterRepository(db, &passthroughCB{}, &passthroughRetry{}, false)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "main"."dead_letters" SET "replayed_at"=$1 WHERE id = $2`)).
+			WithArgs(now, int64(6)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.MarkReplayed(ctx, 6, now))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("count pending groups by source", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewDeadLetterRepository(db, &passthroughCB{}, &passthroughRetry{}, false)
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			`SELECT source, COUNT(*) AS count FROM "main"."dead_letters" WHERE replayed_at IS NULL GROUP BY "source"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"source", "count"}).
+				AddRow("outbox", 2).
+				AddRow("webhook", 4))
+
+		counts, err := repo.CountPending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, map[model.DeadLetterSource]int64{
+			model.DeadLetterSourceOutbox:  2,
+			model.DeadLetterSourceWebhook: 4,
+		}, counts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
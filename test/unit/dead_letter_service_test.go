@@ -0,0 +1,209 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockDeadLetterRepository struct {
+	getFunc          func(ctx context.Context, id int64) (*model.DeadLetter, error)
+	listFunc         func(ctx context.Context, query repository.DeadLetterQuery) ([]*model.DeadLetter, error)
+	insertFunc       func(ctx context.Context, deadLetter *model.DeadLetter) error
+	markReplayedFunc func(ctx context.Context, id int64, replayedAt time.Time) error
+	countPendingFunc func(ctx context.Context) (map[model.DeadLetterSource]int64, error)
+}
+
+func (m *mockDeadLetterRepository) Get(ctx context.Context, id int64) (*model.DeadLetter, error) {
+	return m.getFunc(ctx, id)
+}
+
+func (m *mockDeadLetterRepository) List(ctx context.Context, query repository.DeadLetterQuery) ([]*model.DeadLetter, error) {
+	return m.listFunc(ctx, query)
+}
+
+func (m *mockDeadLetterRepository) Insert(ctx context.Context, deadLetter *model.DeadLetter) error {
+	if m.insertFunc == nil {
+		return nil
+	}
+	return m.insertFunc(ctx, deadLetter)
+}
+
+func (m *mockDeadLetterRepository) MarkReplayed(ctx context.Context, id int64, replayedAt time.Time) error {
+	return m.markReplayedFunc(ctx, id, replayedAt)
+}
+
+func (m *mockDeadLetterRepository) CountPending(ctx context.Context) (map[model.DeadLetterSource]int64, error) {
+	return m.countPendingFunc(ctx)
+}
+
+type mockDeadLetterReplayer struct {
+	replayed []*model.DeadLetter
+	err      error
+}
+
+func (m *mockDeadLetterReplayer) Replay(ctx context.Context, uow repository.UnitOfWork, deadLetter *model.DeadLetter) error {
+	m.replayed = append(m.replayed, deadLetter)
+	return m.err
+}
+
+func TestDeadLetterService(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	newService := func(repo *mockDeadLetterRepository, replayers map[model.DeadLetterSource]service.DeadLetterReplayer) (service.DeadLetterService, *[]string, *mockMeter) {
+		var outcome []string
+		factory := &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) {
+			return &mockUnitOfWork{
+				deadLetterRepo: repo,
+				commitFunc: func(ctx context.Context) error {
+					outcome = append(outcome, "commit")
+					return nil
+				},
+				abortFunc: func(ctx context.Context) error {
+					outcome = append(outcome, "abort")
+					return nil
+				},
+			}, nil
+		}}
+		meter := &mockMeter{}
+		return service.NewDeadLetterService(factory, replayers, meter, &mockLogger{}), &outcome, meter
+	}
+
+	t.Run("list passes filters and cursor to repository", func(t *testing.T) {
+		var got repository.DeadLetterQuery
+		repo := &mockDeadLetterRepository{
+			listFunc: func(ctx context.Context, query repository.DeadLetterQuery) ([]*model.DeadLetter, error) {
+				got = query
+				return []*model.DeadLetter{{Id: 11}}, nil
+			},
+		}
+		svc, outcome, _ := newService(repo, nil)
+
+		result, err := svc.ListDeadLetters(ctx, service.ListDeadLettersParams{
+			SourceEq:    model.DeadLetterSourceWebhook,
+			PendingOnly: true,
+			AfterId:     10,
+			PageSize:    20,
+		})
+		require.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.Equal(t, repository.DeadLetterQuery{
+			SourceEq:    model.DeadLetterSourceWebhook,
+			PendingOnly: true,
+			IdGt:        10,
+			Limit:       20,
+		}, got)
+		assert.Equal(t, []string{"commit"}, *outcome)
+	})
+
+	t.Run("replay hands event to source replayer and marks it replayed", func(t *testing.T) {
+		var markedId int64
+		repo := &mockDeadLetterRepository{
+			getFunc: func(ctx context.Context, id int64) (*model.DeadLetter, error) {
+				return &model.DeadLetter{Id: id, Source: model.DeadLetterSourceOutbox, CreatedAt: now}, nil
+			},
+			markReplayedFunc: func(ctx context.Context, id int64, replayedAt time.Time) error {
+				markedId = id
+				return nil
+			},
+		}
+		replayer := &mockDeadLetterReplayer{}
+		svc, outcome, _ := newService(repo, map[model.DeadLetterSource]service.DeadLetterReplayer{
+			model.DeadLetterSourceOutbox: replayer,
+		})
+
+		result, err := svc.ReplayDeadLetter(ctx, 7)
+		require.NoError(t, err)
+		require.NotNil(t, result.ReplayedAt)
+		assert.Equal(t, int64(7), markedId)
+		require.Len(t, replayer.replayed, 1)
+		assert.Equal(t, int64(7), replayer.replayed[0].Id)
+		assert.Equal(t, []string{"commit"}, *outcome)
+	})
+
+	t.Run("replay of missing dead letter returns nil", func(t *testing.T) {
+		repo := &mockDeadLetterRepository{
+			getFunc: func(ctx context.Context, id int64) (*model.DeadLetter, error) { return nil, nil },
+		}
+		svc, outcome, _ := newService(repo, nil)
+
+		result, err := svc.ReplayDeadLetter(ctx, 7)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+		assert.Equal(t, []string{"abort"}, *outcome)
+	})
+
+	t.Run("replay of already replayed dead letter fails precondition", func(t *testing.T) {
+		repo := &mockDeadLetterRepository{
+			getFunc: func(ctx context.Context, id int64) (*model.DeadLetter, error) {
+				return &model.DeadLetter{Id: id, Source: model.DeadLetterSourceOutbox, ReplayedAt: &now}, nil
+			},
+		}
+		replayer := &mockDeadLetterReplayer{}
+		svc, outcome, _ := newService(repo, map[model.DeadLetterSource]service.DeadLetterReplayer{
+			model.DeadLetterSourceOutbox: replayer,
+		})
+
+		_, err := svc.ReplayDeadLetter(ctx, 7)
+		assert.ErrorIs(t, err, apperror.ErrFailedPrecondition)
+		assert.Empty(t, replayer.replayed)
+		assert.Equal(t, []string{"abort"}, *outcome)
+	})
+
+	t.Run("replay without registered replayer fails precondition", func(t *testing.T) {
+		repo := &mockDeadLetterRepository{
+			getFunc: func(ctx context.Context, id int64) (*model.DeadLetter, error) {
+				return &model.DeadLetter{Id: id, Source: model.DeadLetterSourceWebhook}, nil
+			},
+		}
+		svc, outcome, _ := newService(repo, nil)
+
+		_, err := svc.ReplayDeadLetter(ctx, 7)
+		assert.ErrorIs(t, err, apperror.ErrFailedPrecondition)
+		assert.Equal(t, []string{"abort"}, *outcome)
+	})
+
+	t.Run("replayer error aborts without marking replayed", func(t *testing.T) {
+		replayErr := errors.New("enqueue failed")
+		repo := &mockDeadLetterRepository{
+			getFunc: func(ctx context.Context, id int64) (*model.DeadLetter, error) {
+				return &model.DeadLetter{Id: id, Source: model.DeadLetterSourceOutbox}, nil
+			},
+			markReplayedFunc: func(ctx context.Context, id int64, replayedAt time.Time) error {
+				t.Fatal("MarkReplayed should not be called when replay fails")
+				return nil
+			},
+		}
+		svc, outcome, _ := newService(repo, map[model.DeadLetterSource]service.DeadLetterReplayer{
+			model.DeadLetterSourceOutbox: &mockDeadLetterReplayer{err: replayErr},
+		})
+
+		_, err := svc.ReplayDeadLetter(ctx, 7)
+		assert.ErrorIs(t, err, replayErr)
+		assert.Equal(t, []string{"abort"}, *outcome)
+	})
+
+	t.Run("record depth sets gauge per source including empty ones", func(t *testing.T) {
+		repo := &mockDeadLetterRepository{
+			countPendingFunc: func(ctx context.Context) (map[model.DeadLetterSource]int64, error) {
+				return map[model.DeadLetterSource]int64{model.DeadLetterSourceWebhook: 3}, nil
+			},
+		}
+		svc, _, meter := newService(repo, nil)
+
+		require.NoError(t, svc.RecordDepth(ctx))
+		depth := meter.metrics["dead_letter_queue_depth"]
+		assert.Equal(t, float64(3), depth.values["webhook"])
+		assert.Equal(t, float64(0), depth.values["outbox"])
+		assert.Contains(t, depth.values, "outbox")
+	})
+}
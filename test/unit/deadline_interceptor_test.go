@@ -0,0 +1,78 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestDeadlineInterceptor(t *testing.T) {
+	i := interceptor.DeadlineInterceptor(10*time.Second, map[string]time.Duration{
+		"/proto.v1.AdminService/":                time.Minute,
+		"/proto.v1.AdminService/GetDependencies": time.Second,
+		"/proto.v1.AdminService/ReplayAll":       0,
+	})
+	deadlineOf := func(t *testing.T, ctx context.Context, method string) (time.Duration, bool) {
+		t.Helper()
+		var remaining time.Duration
+		var ok bool
+		_, err := i(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+			var deadline time.Time
+			deadline, ok = ctx.Deadline()
+			remaining = time.Until(deadline)
+			return nil, nil
+		})
+		require.NoError(t, err)
+		return remaining, ok
+	}
+
+	t.Run("applies the default, service or method timeout", func(t *testing.T) {
+		for method, want := range map[string]time.Duration{
+			"/proto.v1.UserService/GetUserById":      10 * time.Second,
+			"/proto.v1.AdminService/ListDeadLetters": time.Minute,
+			"/proto.v1.AdminService/GetDependencies": time.Second,
+		} {
+			remaining, ok := deadlineOf(t, context.Background(), method)
+			require.True(t, ok, method)
+			assert.InDelta(t, want, remaining, float64(100*time.Millisecond), method)
+		}
+	})
+
+	t.Run("a zero timeout leaves the request unbounded", func(t *testing.T) {
+		_, ok := deadlineOf(t, context.Background(), "/proto.v1.AdminService/ReplayAll")
+		assert.False(t, ok)
+	})
+
+	t.Run("keeps the client's deadline, even when longer", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+		remaining, ok := deadlineOf(t, ctx, "/proto.v1.AdminService/GetDependencies")
+		require.True(t, ok)
+		assert.Greater(t, remaining, time.Minute)
+	})
+
+	t.Run("errors after the deadline wrap context.DeadlineExceeded", func(t *testing.T) {
+		i := interceptor.DeadlineInterceptor(time.Millisecond, nil)
+		statementTimeout := errors.New("canceling statement due to statement timeout")
+		_, err := i(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/proto.v1.UserService/GetUserById"}, func(ctx context.Context, req any) (any, error) {
+			<-ctx.Done()
+			return nil, statementTimeout
+		})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorContains(t, err, statementTimeout.Error())
+	})
+
+	t.Run("errors before the deadline pass through", func(t *testing.T) {
+		failure := errors.New("boom")
+		_, err := i(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/proto.v1.UserService/GetUserById"}, func(ctx context.Context, req any) (any, error) {
+			return nil, failure
+		})
+		assert.Equal(t, failure, err)
+	})
+}
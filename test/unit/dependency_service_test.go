@@ -0,0 +1,82 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencyService_GetDependencies(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unprobed dependencies are unknown", func(t *testing.T) {
+		svc := service.NewDependencyService(service.Dependency{
+			Name:  "postgresql",
+			Probe: func(ctx context.Context) error { return nil },
+		})
+
+		statuses := svc.GetDependencies(ctx)
+		require.Len(t, statuses, 1)
+		assert.Equal(t, "postgresql", statuses[0].Name)
+		assert.Equal(t, model.DependencyStateUnknown, statuses[0].State)
+		assert.True(t, statuses[0].LastProbeAt.IsZero())
+	})
+
+	t.Run("probe records state, latency and error in registration order", func(t *testing.T) {
+		probeErr := errors.New("connection refused")
+		svc := service.NewDependencyService(
+			service.Dependency{
+				Name: "postgresql",
+				Probe: func(ctx context.Context) error {
+					time.Sleep(5 * time.Millisecond)
+					return nil
+				},
+				CircuitBreaker: &passthroughCB{},
+			},
+			service.Dependency{
+				Name:  "otlp_exporter",
+				Probe: func(ctx context.Context) error { return probeErr },
+			},
+		)
+
+		svc.Probe(ctx)
+		statuses := svc.GetDependencies(ctx)
+		require.Len(t, statuses, 2)
+
+		assert.Equal(t, "postgresql", statuses[0].Name)
+		assert.Equal(t, model.DependencyStateUp, statuses[0].State)
+		assert.GreaterOrEqual(t, statuses[0].LastProbeLatency, 5*time.Millisecond)
+		assert.False(t, statuses[0].LastProbeAt.IsZero())
+		assert.Empty(t, statuses[0].LastError)
+		require.NotNil(t, statuses[0].CircuitBreakerState)
+		assert.Equal(t, circuitbreaker.Closed, *statuses[0].CircuitBreakerState)
+
+		assert.Equal(t, "otlp_exporter", statuses[1].Name)
+		assert.Equal(t, model.DependencyStateDown, statuses[1].State)
+		assert.Equal(t, probeErr.Error(), statuses[1].LastError)
+		assert.Nil(t, statuses[1].CircuitBreakerState)
+	})
+
+	t.Run("probe is bounded by a timeout", func(t *testing.T) {
+		svc := service.NewDependencyService(service.Dependency{
+			Name: "slow",
+			Probe: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		})
+
+		svc.Probe(ctx)
+		statuses := svc.GetDependencies(ctx)
+		require.Len(t, statuses, 1)
+		assert.Equal(t, model.DependencyStateDown, statuses[0].State)
+		assert.Equal(t, context.DeadlineExceeded.Error(), statuses[0].LastError)
+	})
+}
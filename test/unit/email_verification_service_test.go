@@ -0,0 +1,219 @@
+package unit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/email"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockVerificationTokenRepository struct {
+	tokens map[string]*model.VerificationToken
+}
+
+func (m *mockVerificationTokenRepository) Get(ctx context.Context, tokenHash string) (*model.VerificationToken, error) {
+	return m.tokens[tokenHash], nil
+}
+
+func (m *mockVerificationTokenRepository) Insert(ctx context.Context, token *model.VerificationToken) error {
+	m.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (m *mockVerificationTokenRepository) Use(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error) {
+	token := m.tokens[tokenHash]
+	if token == nil || token.UsedAt != nil {
+		return false, nil
+	}
+	token.UsedAt = &usedAt
+	return true, nil
+}
+
+func (m *mockVerificationTokenRepository) DeleteUnused(ctx context.Context, userId int64) error {
+	for hash, token := range m.tokens {
+		if token.UserId == userId && token.UsedAt == nil {
+			delete(m.tokens, hash)
+		}
+	}
+	return nil
+}
+
+type recordingSender struct {
+	sent []email.Message
+	err  error
+}
+
+func (s *recordingSender) Send(ctx context.Context, msg email.Message) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestEmailVerificationService(t *testing.T) {
+	ctx := context.Background()
+	newFixture := func(user *model.User) (*mockVerificationTokenRepository, *mockUserRepository, repository.UnitOfWorkFactory) {
+		tokens := &mockVerificationTokenRepository{tokens: map[string]*model.VerificationToken{}}
+		users := &mockUserRepository{
+			getFunc: func(ctx context.Context, id int64) (*model.User, error) {
+				if user == nil || user.Id != id {
+					return nil, nil
+				}
+				u := *user
+				return &u, nil
+			},
+			markVerifiedFunc: func(ctx context.Context, u *model.User) (bool, error) {
+				*user = *u
+				return true, nil
+			},
+		}
+		factory := &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) {
+			return &mockUnitOfWork{
+				userRepo:   users,
+				tokenRepo:  tokens,
+				commitFunc: func(ctx context.Context) error { return nil },
+				abortFunc:  func(ctx context.Context) error { return nil },
+			}, nil
+		}}
+		return tokens, users, factory
+	}
+	hash := func(token string) string {
+		sum := sha256.Sum256([]byte(token))
+		return hex.EncodeToString(sum[:])
+	}
+	// sendToken sends a verification email and returns the token it carried.
+	sendToken := func(t *testing.T, svc service.EmailVerificationService, sender *recordingSender) string {
+		t.Helper()
+		sent, err := svc.Send(ctx, 1)
+		require.NoError(t, err)
+		require.True(t, sent)
+		require.NotEmpty(t, sender.sent)
+		body := sender.sent[len(sender.sent)-1].Body
+		link, err := url.Parse(strings.TrimSpace(body[strings.Index(body, "https://"):]))
+		require.NoError(t, err)
+		return link.Query().Get("token")
+	}
+
+	t.Run("send stores a hashed token bound to the email and mails a link", func(t *testing.T) {
+		tokens, _, factory := newFixture(&model.User{Id: 1, Email: "a@b.com", Status: model.UserStatusActive})
+		sender := &recordingSender{}
+		svc := service.NewEmailVerificationService(factory, sender, "https://app.example.com/verify?src=email", time.Hour, &mockLogger{})
+
+		token := sendToken(t, svc, sender)
+
+		assert.Equal(t, "a@b.com", sender.sent[0].To)
+		assert.Contains(t, sender.sent[0].Body, "src=email", "keeps the link's own query")
+		stored := tokens.tokens[hash(token)]
+		require.NotNil(t, stored, "only the hash is stored")
+		assert.Equal(t, "a@b.com", stored.Email)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+	})
+
+	t.Run("sending again replaces the unused link", func(t *testing.T) {
+		tokens, _, factory := newFixture(&model.User{Id: 1, Email: "a@b.com", Status: model.UserStatusActive})
+		sender := &recordingSender{}
+		svc := service.NewEmailVerificationService(factory, sender, "https://app.example.com/verify", time.Hour, &mockLogger{})
+
+		first := sendToken(t, svc, sender)
+		second := sendToken(t, svc, sender)
+
+		assert.NotEqual(t, first, second)
+		assert.Len(t, tokens.tokens, 1)
+		_, err := svc.Verify(ctx, first)
+		assert.ErrorIs(t, err, apperror.ErrInvalidArgument)
+	})
+
+	t.Run("send reports false for a missing user", func(t *testing.T) {
+		_, _, factory := newFixture(nil)
+		sender := &recordingSender{}
+		svc := service.NewEmailVerificationService(factory, sender, "", time.Hour, &mockLogger{})
+
+		sent, err := svc.Send(ctx, 1)
+		require.NoError(t, err)
+		assert.False(t, sent)
+		assert.Empty(t, sender.sent)
+	})
+
+	t.Run("send refuses an already verified email", func(t *testing.T) {
+		verifiedAt := time.Now()
+		_, _, factory := newFixture(&model.User{Id: 1, Email: "a@b.com", Status: model.UserStatusActive, EmailVerifiedAt: &verifiedAt})
+		svc := service.NewEmailVerificationService(factory, &recordingSender{}, "", time.Hour, &mockLogger{})
+
+		_, err := svc.Send(ctx, 1)
+		assert.ErrorIs(t, err, apperror.ErrFailedPrecondition)
+	})
+
+	t.Run("a failed send is unavailable", func(t *testing.T) {
+		_, _, factory := newFixture(&model.User{Id: 1, Email: "a@b.com", Status: model.UserStatusActive})
+		svc := service.NewEmailVerificationService(factory, &recordingSender{err: errors.New("connection refused")}, "", time.Hour, &mockLogger{})
+
+		_, err := svc.Send(ctx, 1)
+		assert.ErrorIs(t, err, apperror.ErrUnavailable)
+	})
+
+	t.Run("verify marks the email verified, and again is a no-op", func(t *testing.T) {
+		user := &model.User{Id: 1, Email: "a@b.com", Status: model.UserStatusActive, Version: 2}
+		_, _, factory := newFixture(user)
+		sender := &recordingSender{}
+		svc := service.NewEmailVerificationService(factory, sender, "https://app.example.com/verify", time.Hour, &mockLogger{})
+		token := sendToken(t, svc, sender)
+
+		verified, err := svc.Verify(ctx, token)
+		require.NoError(t, err)
+		assert.True(t, verified.EmailVerified())
+		assert.Equal(t, int64(3), verified.Version)
+		assert.True(t, user.EmailVerified(), "written through the repository")
+
+		again, err := svc.Verify(ctx, " "+strings.ToLower(token)+" ")
+		require.NoError(t, err, "a link opened twice, or a code typed by hand, still verifies")
+		assert.True(t, again.EmailVerified())
+	})
+
+	t.Run("verify rejects unknown, expired and stale tokens", func(t *testing.T) {
+		user := &model.User{Id: 1, Email: "a@b.com", Status: model.UserStatusActive}
+		tokens, _, factory := newFixture(user)
+		sender := &recordingSender{}
+		svc := service.NewEmailVerificationService(factory, sender, "https://app.example.com/verify", time.Hour, &mockLogger{})
+
+		_, err := svc.Verify(ctx, "NOTATOKEN")
+		assert.ErrorIs(t, err, apperror.ErrInvalidArgument)
+
+		token := sendToken(t, svc, sender)
+		tokens.tokens[hash(token)].ExpiresAt = time.Now().Add(-time.Second)
+		_, err = svc.Verify(ctx, token)
+		var reason *apperror.ReasonError
+		require.ErrorAs(t, err, &reason)
+		assert.Equal(t, "VERIFICATION_TOKEN_EXPIRED", reason.Reason)
+
+		token = sendToken(t, svc, sender)
+		user.Email = "new@b.com"
+		_, err = svc.Verify(ctx, token)
+		require.ErrorAs(t, err, &reason)
+		assert.Equal(t, "VERIFICATION_TOKEN_INVALID", reason.Reason, "a token stops working once the email changes")
+		assert.False(t, user.EmailVerified())
+	})
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	verifiedAt := time.Now()
+	assert.NoError(t, service.RequireVerifiedEmail(&model.User{Id: 1, EmailVerifiedAt: &verifiedAt}))
+
+	err := service.RequireVerifiedEmail(&model.User{Id: 1})
+	assert.ErrorIs(t, err, apperror.ErrFailedPrecondition)
+	var reason *apperror.ReasonError
+	require.ErrorAs(t, err, &reason)
+	assert.Equal(t, "EMAIL_NOT_VERIFIED", reason.Reason)
+}
@@ -5,12 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/internal/repository"
 	"github.com/jt828/go-grpc-template/pkg/apperror"
 	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/observability/implementation"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -21,6 +27,7 @@ type mockLogger struct {
 		msg    string
 		fields []observability.Field
 	}
+	warnCalls []string
 }
 
 func (m *mockLogger) Debug(msg string, fields ...observability.Field) {}
@@ -30,10 +37,12 @@ func (m *mockLogger) Error(msg string, fields ...observability.Field) {
 		fields []observability.Field
 	}{msg, fields})
 }
-func (m *mockLogger) Fatal(msg string, fields ...observability.Field)            {}
-func (m *mockLogger) Info(msg string, fields ...observability.Field)             {}
-func (m *mockLogger) Warn(msg string, fields ...observability.Field)             {}
-func (m *mockLogger) With(fields ...observability.Field) observability.Logger    { return m }
+func (m *mockLogger) Fatal(msg string, fields ...observability.Field)         {}
+func (m *mockLogger) Info(msg string, fields ...observability.Field)          {}
+func (m *mockLogger) With(fields ...observability.Field) observability.Logger { return m }
+func (m *mockLogger) Warn(msg string, fields ...observability.Field) {
+	m.warnCalls = append(m.warnCalls, msg)
+}
 
 func TestErrorInterceptor(t *testing.T) {
 	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
@@ -113,6 +122,209 @@ func TestErrorInterceptor(t *testing.T) {
 		assert.Len(t, log.errorCalls, 0)
 	})
 
+	t.Run("ValidationError carries its violations as BadRequest details", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, &apperror.ValidationError{Violations: []apperror.FieldViolation{
+				{Field: "password", Reason: "min_length", Description: "must be at least 12 characters"},
+				{Field: "password", Reason: "entropy", Description: "is too predictable"},
+			}}
+		})
+
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		assert.Equal(t, "password must be at least 12 characters; password is too predictable", st.Message())
+		require.Len(t, st.Details(), 1)
+		badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+		require.True(t, ok)
+		require.Len(t, badRequest.FieldViolations, 2)
+		assert.Equal(t, "password", badRequest.FieldViolations[0].Field)
+		assert.Equal(t, "min_length", badRequest.FieldViolations[0].Reason)
+		assert.Equal(t, "is too predictable", badRequest.FieldViolations[1].Description)
+	})
+
+	t.Run("RetryAfterError carries its delay as RetryInfo details", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, &apperror.RetryAfterError{
+				Err:        fmt.Errorf("too many failed login attempts: %w", apperror.ErrResourceExhausted),
+				RetryAfter: 90 * time.Second,
+			}
+		})
+
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.ResourceExhausted, st.Code())
+		assert.Equal(t, "too many failed login attempts: resource exhausted; retry after 1m30s", st.Message())
+		require.Len(t, st.Details(), 1)
+		retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+		require.True(t, ok)
+		assert.Equal(t, 90*time.Second, retryInfo.RetryDelay.AsDuration())
+	})
+
+	t.Run("wrapped ErrFailedPrecondition maps to codes.FailedPrecondition", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, fmt.Errorf("dead letter 1 already replayed: %w", apperror.ErrFailedPrecondition)
+		})
+
+		require.Error(t, err)
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.FailedPrecondition, st.Code())
+		assert.Len(t, log.errorCalls, 0)
+	})
+
+	t.Run("VersionMismatchError carries the current version as ErrorInfo details", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, &apperror.VersionMismatchError{Resource: "user 1", ExpectedVersion: 2, CurrentVersion: 3}
+		})
+
+		st := status.Convert(err)
+		assert.Equal(t, codes.FailedPrecondition, st.Code())
+		assert.Equal(t, "user 1 is at version 3, not 2", st.Message())
+		require.Len(t, st.Details(), 1)
+		errorInfo, ok := st.Details()[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		assert.Equal(t, "VERSION_MISMATCH", errorInfo.Reason)
+		assert.Equal(t, "3", errorInfo.Metadata["current_version"])
+	})
+
+	t.Run("ReasonError carries its reason as ErrorInfo details whatever the code", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, &apperror.RetryAfterError{
+				Err:        apperror.WithReason(fmt.Errorf("quota used up: %w", apperror.ErrResourceExhausted), "QUOTA_EXCEEDED", map[string]string{"quota": "requests"}),
+				RetryAfter: time.Minute,
+			}
+		})
+
+		st := status.Convert(err)
+		assert.Equal(t, codes.ResourceExhausted, st.Code())
+		require.Len(t, st.Details(), 2)
+		errorInfo, ok := st.Details()[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		assert.Equal(t, "QUOTA_EXCEEDED", errorInfo.Reason)
+		assert.Equal(t, "go-grpc-template", errorInfo.Domain)
+		assert.Equal(t, "requests", errorInfo.Metadata["quota"])
+		retryInfo, ok := st.Details()[1].(*errdetails.RetryInfo)
+		require.True(t, ok)
+		assert.Equal(t, time.Minute, retryInfo.RetryDelay.AsDuration())
+	})
+
+	t.Run("FieldError carries its violation as BadRequest details", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, apperror.FieldError("ids", "required", "is required")
+		})
+
+		st := status.Convert(err)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		assert.Equal(t, "ids is required", st.Message())
+		require.Len(t, st.Details(), 1)
+		badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+		require.True(t, ok)
+		require.Len(t, badRequest.FieldViolations, 1)
+		assert.Equal(t, "required", badRequest.FieldViolations[0].Reason)
+	})
+
+	t.Run("wrapped ErrConflict maps to codes.Aborted", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, fmt.Errorf("deleted -> active: %w", apperror.ErrConflict)
+		})
+
+		require.Error(t, err)
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.Aborted, st.Code())
+		assert.Len(t, log.errorCalls, 0)
+	})
+
+	t.Run("wrapped ErrResourceExhausted maps to codes.ResourceExhausted", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, fmt.Errorf("rate limit exceeded: %w", apperror.ErrResourceExhausted)
+		})
+
+		require.Error(t, err)
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.ResourceExhausted, st.Code())
+		assert.Len(t, log.errorCalls, 0)
+	})
+
+	t.Run("wrapped ErrUnauthenticated maps to codes.Unauthenticated", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, fmt.Errorf("signature mismatch: %w", apperror.ErrUnauthenticated)
+		})
+
+		require.Error(t, err)
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.Unauthenticated, st.Code())
+		assert.Len(t, log.errorCalls, 0)
+	})
+
+	t.Run("wrapped ErrPermissionDenied maps to codes.PermissionDenied", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, fmt.Errorf("admin role required: %w", apperror.ErrPermissionDenied)
+		})
+
+		require.Error(t, err)
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.PermissionDenied, st.Code())
+		assert.Len(t, log.errorCalls, 0)
+	})
+
+	t.Run("wrapped ErrAlreadyExists and ErrUnavailable keep their message", func(t *testing.T) {
+		for _, tc := range []struct {
+			err  error
+			code codes.Code
+		}{
+			{fmt.Errorf("email taken: %w", apperror.ErrAlreadyExists), codes.AlreadyExists},
+			{fmt.Errorf("breach checker down: %w", apperror.ErrUnavailable), codes.Unavailable},
+		} {
+			log := &mockLogger{}
+			i := interceptor.ErrorInterceptor(log)
+
+			_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+				return nil, tc.err
+			})
+
+			st := status.Convert(err)
+			assert.Equal(t, tc.code, st.Code())
+			assert.Equal(t, tc.err.Error(), st.Message())
+			assert.Len(t, log.errorCalls, 0)
+			assert.Len(t, log.warnCalls, 0)
+		}
+	})
+
 	t.Run("unknown error maps to codes.Internal with generic message", func(t *testing.T) {
 		log := &mockLogger{}
 		i := interceptor.ErrorInterceptor(log)
@@ -143,4 +355,297 @@ func TestErrorInterceptor(t *testing.T) {
 		assert.Contains(t, log.errorCalls[0].fields, observability.Err(unknownErr))
 		assert.Contains(t, log.errorCalls[0].fields, observability.String("method", info.FullMethod))
 	})
-}
\ No newline at end of file
+	t.Run("transient repository error maps to codes.Unavailable", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, fmt.Errorf("load user: %w", &repository.ErrTransient{Cause: errors.New("connection reset")})
+		})
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, []string{"transient error"}, log.warnCalls)
+		assert.Len(t, log.errorCalls, 0)
+	})
+
+	t.Run("partial commit maps to codes.Internal whatever failed", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, &repository.PartialCommitError{
+				Committed: []string{repository.StoreMain},
+				Failed:    repository.StoreIdempotency,
+				Err:       &repository.ErrTransient{Cause: errors.New("connection reset")},
+			}
+		})
+
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.Internal, st.Code(), "a retry would repeat the committed writes")
+		assert.Equal(t, "request was partially applied and must not be retried", st.Message())
+	})
+
+	t.Run("permanent repository error keeps its domain mapping", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, &repository.ErrPermanent{Cause: apperror.ErrNotFound}
+		})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+	t.Run("context errors map to codes.DeadlineExceeded and codes.Canceled", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		for cause, code := range map[error]codes.Code{context.DeadlineExceeded: codes.DeadlineExceeded, context.Canceled: codes.Canceled} {
+			_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+				return nil, fmt.Errorf("load user: %w", &repository.ErrPermanent{Cause: cause})
+			})
+			assert.Equal(t, code, status.Code(err))
+		}
+		assert.Len(t, log.errorCalls, 0)
+	})
+
+	t.Run("unique violation maps to codes.AlreadyExists", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, &repository.ErrPermanent{Cause: &pgconn.PgError{Code: "23505", Message: `duplicate key value violates unique constraint "users_pkey"`}}
+		})
+
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+		assert.Equal(t, "resource already exists", status.Convert(err).Message())
+		assert.Len(t, log.errorCalls, 0)
+	})
+
+	t.Run("Internal errors are logged with the fields and stack of apperror.Errors", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorInterceptor(log)
+
+		_, err := i(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			cause := apperror.Wrapf(errors.New("disk full"), "write ledger %d", 7).With("ledger_id", int64(7))
+			return nil, apperror.Wrapf(cause, "transfer").With("tenant", "acme")
+		})
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.Equal(t, "internal server error", status.Convert(err).Message())
+		require.Len(t, log.errorCalls, 1)
+		fields := map[string]any{}
+		for _, f := range log.errorCalls[0].fields {
+			fields[f.Key] = f.Value
+		}
+		assert.Equal(t, "acme", fields["tenant"])
+		assert.Equal(t, int64(7), fields["ledger_id"])
+		assert.Contains(t, fields["stacktrace"], "TestErrorInterceptor")
+	})
+}
+
+func TestErrorStreamInterceptor(t *testing.T) {
+	info := &grpc.StreamServerInfo{FullMethod: "/proto.v1.UserService/Watch"}
+	ctx := observability.ContextWithRequestId(context.Background(), "req-1")
+	ss := &trailerServerStream{ctx: ctx}
+
+	t.Run("maps application errors like the unary interceptor", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorStreamInterceptor(log)
+
+		err := i(nil, ss, info, func(srv any, ss grpc.ServerStream) error {
+			return &apperror.ValidationError{Violations: []apperror.FieldViolation{{Field: "email", Reason: "required", Description: "is required"}}}
+		})
+
+		st := status.Convert(err)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		var requestInfo *errdetails.RequestInfo
+		for _, detail := range st.Details() {
+			if d, ok := detail.(*errdetails.RequestInfo); ok {
+				requestInfo = d
+			}
+		}
+		require.NotNil(t, requestInfo)
+		assert.Equal(t, "req-1", requestInfo.RequestId)
+	})
+
+	t.Run("stream statuses pass through unchanged", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorStreamInterceptor(log)
+
+		err := i(nil, ss, info, func(srv any, ss grpc.ServerStream) error {
+			return status.Error(codes.Canceled, "context canceled")
+		})
+
+		assert.Equal(t, codes.Canceled, status.Code(err))
+		assert.Equal(t, "context canceled", status.Convert(err).Message())
+		assert.Len(t, log.errorCalls, 0)
+	})
+
+	t.Run("panic is recovered as codes.Internal", func(t *testing.T) {
+		log := &mockLogger{}
+		i := interceptor.ErrorStreamInterceptor(log)
+
+		err := i(nil, ss, info, func(srv any, ss grpc.ServerStream) error {
+			panic("nil map")
+		})
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.Equal(t, "internal server error", status.Convert(err).Message())
+		require.Len(t, log.errorCalls, 1)
+		assert.Equal(t, "panic recovered", log.errorCalls[0].msg)
+	})
+}
+
+func TestRPCMetricsInterceptors(t *testing.T) {
+	meter := implementation.NewPrometheusMeter()
+	unary, stream := interceptor.RPCMetricsInterceptors(meter, nil)
+	errorUnary := interceptor.ErrorInterceptor(&mockLogger{})
+	call := func(method string, err error) {
+		_, _ = unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+			return errorUnary(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+				return nil, err
+			})
+		})
+	}
+
+	call("/proto.v1.UserService/GetUserById", fmt.Errorf("user 1: %w", apperror.ErrNotFound))
+	call("/proto.v1.UserService/GetUserById", fmt.Errorf("user 2: %w", apperror.ErrNotFound))
+	call("/proto.v1.UserService/GetUserById", nil)
+	call("/proto.v1.UserService/CreateUser", errors.New("boom"))
+	_ = stream(nil, &trailerServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/proto.v1.UserService/Watch"}, func(srv any, ss grpc.ServerStream) error {
+		return status.Error(codes.Canceled, "context canceled")
+	})
+
+	families, err := implementation.PromRegistry(meter).Gather()
+	require.NoError(t, err)
+	counts := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "grpc_server_errors_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			counts[labels["method"]+" "+labels["code"]] = m.GetCounter().GetValue()
+		}
+	}
+	assert.Equal(t, map[string]float64{
+		"/proto.v1.UserService/GetUserById NotFound": 2,
+		"/proto.v1.UserService/CreateUser Internal":  1,
+		"/proto.v1.UserService/Watch Canceled":       1,
+	}, counts, "successful calls are not counted")
+	handled := map[string]uint64{}
+	for _, family := range families {
+		if family.GetName() != "grpc_server_handling_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			handled[labels["grpc_type"]+" "+labels["grpc_service"]+" "+labels["grpc_method"]] = m.GetHistogram().GetSampleCount()
+		}
+	}
+	assert.Equal(t, map[string]uint64{
+		"unary proto.v1.UserService GetUserById":   3,
+		"unary proto.v1.UserService CreateUser":    1,
+		"server_stream proto.v1.UserService Watch": 1,
+	}, handled, "every call is timed")
+}
+
+func TestRPCMetricsInterceptors_TraceExemplars(t *testing.T) {
+	meter := implementation.NewPrometheusMeter()
+	unary, _ := interceptor.RPCMetricsInterceptors(meter, nil)
+	traceId := trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	spanId := trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7}
+	call := func(flags trace.TraceFlags, method string) {
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceId,
+			SpanID:     spanId,
+			TraceFlags: flags,
+		}))
+		_, _ = unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+			return nil, status.Error(codes.Unavailable, "down")
+		})
+	}
+
+	call(trace.FlagsSampled, "/proto.v1.UserService/GetUserById")
+	call(0, "/proto.v1.UserService/CreateUser")
+
+	families, err := implementation.PromRegistry(meter).Gather()
+	require.NoError(t, err)
+	exemplars := map[string]map[string]string{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			var method string
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "method" || l.GetName() == "grpc_method" {
+					method = l.GetValue()
+				}
+			}
+			labels := map[string]string{}
+			for _, l := range m.GetCounter().GetExemplar().GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			for _, b := range m.GetHistogram().GetBucket() {
+				for _, l := range b.GetExemplar().GetLabel() {
+					labels[l.GetName()] = l.GetValue()
+				}
+			}
+			if len(labels) > 0 {
+				exemplars[family.GetName()+" "+method] = labels
+			}
+		}
+	}
+	want := map[string]string{"trace_id": traceId.String(), "span_id": spanId.String()}
+	assert.Equal(t, map[string]map[string]string{
+		"grpc_server_errors_total /proto.v1.UserService/GetUserById": want,
+		"grpc_server_handling_seconds GetUserById":                   want,
+	}, exemplars, "only sampled calls carry exemplars")
+}
+
+func TestRPCMetricsInterceptors_Tenants(t *testing.T) {
+	meter := implementation.NewPrometheusMeter()
+	unary, _ := interceptor.RPCMetricsInterceptors(meter, observability.NewTenantLabels([]string{"acme"}))
+	call := func(tenant string) {
+		ctx := context.Background()
+		if tenant != "" {
+			var err error
+			ctx, err = observability.ContextWithTenant(ctx, tenant)
+			require.NoError(t, err)
+		}
+		_, _ = unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/proto.v1.UserService/GetUserById"}, func(ctx context.Context, req any) (any, error) {
+			return nil, status.Error(codes.NotFound, "user 1")
+		})
+	}
+
+	call("acme")
+	call("globex")
+	call("")
+
+	families, err := implementation.PromRegistry(meter).Gather()
+	require.NoError(t, err)
+	counts := map[string]float64{}
+	handled := map[string]uint64{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() != observability.TenantLabelKey {
+					continue
+				}
+				switch family.GetName() {
+				case "grpc_server_errors_total":
+					counts[l.GetValue()] = m.GetCounter().GetValue()
+				case "grpc_server_handling_seconds":
+					handled[l.GetValue()] = m.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{"acme": 1, observability.OtherTenant: 2}, counts)
+	assert.Equal(t, map[string]uint64{"acme": 1, observability.OtherTenant: 2}, handled)
+}
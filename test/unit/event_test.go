@@ -0,0 +1,83 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/event"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/proto/events"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEvent(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	t.Run("pack and unpack round trip", func(t *testing.T) {
+		msg := event.UserCreated(&model.User{Id: 1, Email: "a@example.com", Username: "alice", Password: "secret", CreatedAt: now})
+
+		envelope, err := event.Pack(42, msg, now)
+		require.NoError(t, err)
+		assert.Equal(t, "type.googleapis.com/proto.events.v1.UserCreatedV1", envelope.TypeURL)
+
+		decoded, err := event.Unpack(envelope)
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(msg, decoded))
+
+		var typed events.UserCreatedV1
+		require.NoError(t, event.UnpackTo(envelope, &typed))
+		assert.Equal(t, "alice", typed.GetUsername())
+		assert.Equal(t, now, typed.GetCreatedAt().AsTime())
+	})
+
+	t.Run("ledger amount survives as canonical decimal", func(t *testing.T) {
+		amount := decimal.RequireFromString("1.50")
+		msg := event.LedgerEntryAdded(&model.Ledger{Id: 7, UserId: 1, Token: "USDT", Amount: amount, CreatedAt: now})
+
+		got, err := event.LedgerAmount(msg)
+		require.NoError(t, err)
+		assert.True(t, amount.Equal(got))
+	})
+
+	t.Run("unpack to wrong type fails", func(t *testing.T) {
+		envelope, err := event.Pack(1, &events.UserCreatedV1{UserId: 1}, now)
+		require.NoError(t, err)
+
+		err = event.UnpackTo(envelope, &events.LedgerEntryAddedV1{})
+		assert.ErrorIs(t, err, event.ErrTypeMismatch)
+	})
+
+	t.Run("unpack of unregistered type fails", func(t *testing.T) {
+		envelope := &event.Envelope{TypeURL: "type.googleapis.com/proto.events.v2.UserCreatedV2"}
+
+		_, err := event.Unpack(envelope)
+		assert.ErrorIs(t, err, event.ErrUnknownType)
+	})
+
+	t.Run("parse type url", func(t *testing.T) {
+		typ, err := event.ParseTypeURL("type.googleapis.com/proto.events.v1.LedgerEntryAddedV1")
+		require.NoError(t, err)
+		assert.Equal(t, "LedgerEntryAdded", typ.Name)
+		assert.Equal(t, 1, typ.Version)
+
+		typ, err = event.ParseTypeURL("type.googleapis.com/proto.events.Legacy")
+		require.NoError(t, err)
+		assert.Equal(t, "Legacy", typ.Name)
+		assert.Zero(t, typ.Version)
+
+		_, err = event.ParseTypeURL("type.googleapis.com/")
+		assert.Error(t, err)
+	})
+
+	t.Run("same event across versions", func(t *testing.T) {
+		assert.True(t, event.SameEvent(
+			"type.googleapis.com/proto.events.v1.UserCreatedV1",
+			"type.googleapis.com/proto.events.v1.UserCreatedV2"))
+		assert.False(t, event.SameEvent(
+			"type.googleapis.com/proto.events.v1.UserCreatedV1",
+			"type.googleapis.com/proto.events.v1.LedgerEntryAddedV1"))
+	})
+}
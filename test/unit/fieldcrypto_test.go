@@ -0,0 +1,77 @@
+package unit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/pkg/fieldcrypto"
+	fieldcryptoImpl "github.com/jt828/go-grpc-template/pkg/fieldcrypto/implementation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAESGCM(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+	ad := []byte("user_two_factor:1")
+
+	t.Run("round trips under the primary key", func(t *testing.T) {
+		cipher, err := fieldcryptoImpl.NewAESGCM(fieldcrypto.WithKey("k1", oldKey))
+		require.NoError(t, err)
+
+		encrypted, err := cipher.Encrypt([]byte("secret"), ad)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encrypted, "k1:"))
+		assert.NotContains(t, encrypted, "secret")
+		decrypted, err := cipher.Decrypt(encrypted, ad)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("secret"), decrypted)
+	})
+
+	t.Run("rejects other associated data and tampering", func(t *testing.T) {
+		cipher, err := fieldcryptoImpl.NewAESGCM(fieldcrypto.WithKey("k1", oldKey))
+		require.NoError(t, err)
+		encrypted, err := cipher.Encrypt([]byte("secret"), ad)
+		require.NoError(t, err)
+
+		_, err = cipher.Decrypt(encrypted, []byte("user_two_factor:2"))
+		assert.Error(t, err)
+		tampered := []byte(encrypted)
+		tampered[len(tampered)-2] ^= 1
+		_, err = cipher.Decrypt(string(tampered), ad)
+		assert.Error(t, err)
+	})
+
+	t.Run("old keys still decrypt after rotation", func(t *testing.T) {
+		before, err := fieldcryptoImpl.NewAESGCM(fieldcrypto.WithKey("k1", oldKey))
+		require.NoError(t, err)
+		encrypted, err := before.Encrypt([]byte("secret"), ad)
+		require.NoError(t, err)
+
+		after, err := fieldcryptoImpl.NewAESGCM(fieldcrypto.WithKey("k1", oldKey), fieldcrypto.WithKey("k2", newKey), fieldcrypto.WithPrimaryKey("k2"))
+		require.NoError(t, err)
+		decrypted, err := after.Decrypt(encrypted, ad)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("secret"), decrypted)
+		reencrypted, err := after.Encrypt(decrypted, ad)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(reencrypted, "k2:"))
+
+		retired, err := fieldcryptoImpl.NewAESGCM(fieldcrypto.WithKey("k2", newKey))
+		require.NoError(t, err)
+		_, err = retired.Decrypt(encrypted, ad)
+		assert.ErrorContains(t, err, "not in the key ring")
+	})
+
+	t.Run("rejects invalid key rings", func(t *testing.T) {
+		_, err := fieldcryptoImpl.NewAESGCM()
+		assert.Error(t, err)
+		_, err = fieldcryptoImpl.NewAESGCM(fieldcrypto.WithKey("k1", oldKey[:16]))
+		assert.ErrorContains(t, err, "must be 32 bytes")
+		_, err = fieldcryptoImpl.NewAESGCM(fieldcrypto.WithKey("k:1", oldKey))
+		assert.Error(t, err)
+		_, err = fieldcryptoImpl.NewAESGCM(fieldcrypto.WithKey("k1", oldKey), fieldcrypto.WithPrimaryKey("k2"))
+		assert.ErrorContains(t, err, "not in the key ring")
+	})
+}
@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/pkg/httpclient"
+	httpclientImpl "github.com/jt828/go-grpc-template/pkg/httpclient/implementation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEgressPolicy(t *testing.T) {
+	policy := httpclient.DefaultEgressPolicy()
+
+	t.Run("CheckURL", func(t *testing.T) {
+		tests := []struct {
+			url     string
+			allowed bool
+		}{
+			{"https://hooks.example.com/callback", true},
+			{"HTTPS://hooks.example.com:443/callback", true},
+			{"http://hooks.example.com/callback", false},
+			{"https://hooks.example.com:8443/callback", false},
+			{"ftp://hooks.example.com/file", false},
+			{"file:///etc/passwd", false},
+		}
+		for _, tt := range tests {
+			u, err := url.Parse(tt.url)
+			require.NoError(t, err)
+			if tt.allowed {
+				assert.NoError(t, policy.CheckURL(u), tt.url)
+			} else {
+				assert.ErrorIs(t, policy.CheckURL(u), httpclient.ErrEgressDenied, tt.url)
+			}
+		}
+	})
+
+	t.Run("CheckAddr", func(t *testing.T) {
+		tests := []struct {
+			addr    string
+			allowed bool
+		}{
+			{"93.184.216.34", true},
+			{"2606:4700:4700::1111", true},
+			{"127.0.0.1", false},
+			{"10.1.2.3", false},
+			{"172.20.0.1", false},
+			{"192.168.1.1", false},
+			{"169.254.169.254", false},
+			{"100.64.0.1", false},
+			{"0.0.0.0", false},
+			{"::1", false},
+			{"::ffff:127.0.0.1", false},
+			{"::ffff:10.0.0.1", false},
+			{"fd00::1", false},
+			{"fe80::1", false},
+		}
+		for _, tt := range tests {
+			err := policy.CheckAddr(netip.MustParseAddr(tt.addr))
+			if tt.allowed {
+				assert.NoError(t, err, tt.addr)
+			} else {
+				assert.ErrorIs(t, err, httpclient.ErrEgressDenied, tt.addr)
+			}
+		}
+	})
+}
+
+func TestHTTPClient(t *testing.T) {
+	redirects := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/loop":
+			redirects++
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/elsewhere":
+			http.Redirect(w, r, "https://example.com:8443/", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	get := func(client httpclient.Client, path string) (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		return client.Do(req)
+	}
+	// loopbackPolicy admits the test server so redirect handling can be
+	// exercised; production policies deny loopback.
+	loopbackPolicy := httpclient.EgressPolicy{AllowedSchemes: []string{"http"}, AllowedPorts: []int{port}, MaxRedirects: 2}
+
+	t.Run("without a policy requests are unrestricted", func(t *testing.T) {
+		resp, err := get(httpclientImpl.NewHTTPClient(), "/")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("default policy rejects plain http before dialling", func(t *testing.T) {
+		_, err := get(httpclientImpl.NewHTTPClient(httpclient.WithEgressPolicy(httpclient.DefaultEgressPolicy())), "/")
+		assert.ErrorIs(t, err, httpclient.ErrEgressDenied)
+	})
+
+	t.Run("denied address is refused at dial time", func(t *testing.T) {
+		policy := loopbackPolicy
+		policy.DeniedPrefixes = httpclient.NonPublicPrefixes()
+		_, err := get(httpclientImpl.NewHTTPClient(httpclient.WithEgressPolicy(policy)), "/")
+		assert.ErrorIs(t, err, httpclient.ErrEgressDenied)
+	})
+
+	t.Run("allowed target succeeds", func(t *testing.T) {
+		resp, err := get(httpclientImpl.NewHTTPClient(httpclient.WithEgressPolicy(loopbackPolicy)), "/")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("redirects are capped", func(t *testing.T) {
+		redirects = 0
+		_, err := get(httpclientImpl.NewHTTPClient(httpclient.WithEgressPolicy(loopbackPolicy)), "/loop")
+		assert.ErrorIs(t, err, httpclient.ErrEgressDenied)
+		assert.Equal(t, 3, redirects)
+	})
+
+	t.Run("redirect targets are checked", func(t *testing.T) {
+		_, err := get(httpclientImpl.NewHTTPClient(httpclient.WithEgressPolicy(loopbackPolicy)), "/elsewhere")
+		assert.ErrorIs(t, err, httpclient.ErrEgressDenied)
+	})
+}
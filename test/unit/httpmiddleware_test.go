@@ -0,0 +1,148 @@
+package unit
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/httpmiddleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain(t *testing.T) {
+	var order []string
+	trace := func(name string) httpmiddleware.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	h := httpmiddleware.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}), trace("first"), trace("second"))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, []string{"first", "second", "handler"}, order)
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := httpmiddleware.SecurityHeaders(httpmiddleware.SecurityPolicy{HSTSMaxAge: 24 * time.Hour, HSTSIncludeSubdomains: true})(ok)
+
+	t.Run("sets the standard headers", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+		assert.Equal(t, "no-referrer", rec.Header().Get("Referrer-Policy"))
+		assert.Equal(t, httpmiddleware.DefaultContentSecurityPolicy, rec.Header().Get("Content-Security-Policy"))
+		assert.Empty(t, rec.Header().Get("Strict-Transport-Security"), "not over plain HTTP")
+	})
+
+	t.Run("sends HSTS over HTTPS only", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = &tls.ConnectionState{}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, "max-age=86400; includeSubDomains", rec.Header().Get("Strict-Transport-Security"))
+
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Forwarded-Proto", "https")
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, "max-age=86400; includeSubDomains", rec.Header().Get("Strict-Transport-Security"))
+	})
+}
+
+func TestCORS(t *testing.T) {
+	policy := httpmiddleware.CORSPolicy{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"authorization", "content-type"},
+		ExposedHeaders: []string{"x-request-id"},
+		MaxAge:         10 * time.Minute,
+	}
+	served := false
+	newHandler := func(policy httpmiddleware.CORSPolicy) http.Handler {
+		served = false
+		return httpmiddleware.CORS(policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served = true
+		}))
+	}
+	request := func(h http.Handler, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/v1/users", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	preflight := map[string]string{"Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "Authorization, Content-Type"}
+
+	t.Run("answers preflights from allowed origins", func(t *testing.T) {
+		h := newHandler(policy)
+		rec := request(h, http.MethodOptions, "https://app.example.com", preflight)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.False(t, served)
+		assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST", rec.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "authorization, content-type", rec.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+		assert.Contains(t, rec.Header().Values("Vary"), "Origin")
+	})
+
+	t.Run("rejects preflights outside the policy", func(t *testing.T) {
+		h := newHandler(policy)
+		for _, tt := range []struct {
+			name    string
+			origin  string
+			headers map[string]string
+		}{
+			{"origin", "https://evil.example.com", preflight},
+			{"method", "https://app.example.com", map[string]string{"Access-Control-Request-Method": "DELETE"}},
+			{"header", "https://app.example.com", map[string]string{"Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "x-internal-caller"}},
+		} {
+			rec := request(h, http.MethodOptions, tt.origin, tt.headers)
+			assert.Equal(t, http.StatusForbidden, rec.Code, tt.name)
+			assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"), tt.name)
+		}
+		assert.False(t, served)
+	})
+
+	t.Run("lets allowed origins read responses", func(t *testing.T) {
+		h := newHandler(policy)
+		rec := request(h, http.MethodGet, "https://app.example.com", nil)
+		assert.True(t, served)
+		assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "x-request-id", rec.Header().Get("Access-Control-Expose-Headers"))
+
+		rec = request(h, http.MethodGet, "https://evil.example.com", nil)
+		assert.True(t, served, "browsers, not the server, withhold the response")
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+		rec = request(h, http.MethodGet, "", nil)
+		assert.True(t, served)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("any origin", func(t *testing.T) {
+		anyOrigin := policy
+		anyOrigin.AllowedOrigins = []string{"*"}
+		rec := request(newHandler(anyOrigin), http.MethodGet, "https://other.example.com", nil)
+		assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+
+		credentials := policy
+		credentials.AllowCredentials = true
+		rec = request(newHandler(credentials), http.MethodGet, "https://app.example.com", nil)
+		assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+	})
+}
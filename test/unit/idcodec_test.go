@@ -0,0 +1,147 @@
+package unit
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/controller/convert"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/idcodec"
+	idcodecImpl "github.com/jt828/go-grpc-template/pkg/idcodec/implementation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBase62Codec(t *testing.T) {
+	plain := idcodecImpl.NewBase62Codec()
+	keyed := idcodecImpl.NewBase62Codec(idcodec.WithKey([]byte("secret")))
+	ids := []int64{1, 61, 62, 1784159718452809728, 1784159718452809729, math.MaxInt64}
+
+	t.Run("round trips at a fixed width", func(t *testing.T) {
+		for _, codec := range []idcodec.Codec{plain, keyed} {
+			for _, id := range ids {
+				encoded := codec.Encode(id)
+				assert.Len(t, encoded, 11)
+				decoded, err := codec.Decode(encoded)
+				require.NoError(t, err)
+				assert.Equal(t, id, decoded)
+			}
+		}
+	})
+
+	t.Run("plain encoding is base62", func(t *testing.T) {
+		assert.Equal(t, "00000000001", plain.Encode(1))
+		assert.Equal(t, "00000000010", plain.Encode(62))
+	})
+
+	t.Run("key scrambles sequential ids", func(t *testing.T) {
+		a, b := keyed.Encode(1784159718452809728), keyed.Encode(1784159718452809729)
+		assert.NotEqual(t, plain.Encode(1784159718452809728), a)
+		assert.NotEqual(t, a[:8], b[:8])
+
+		other := idcodecImpl.NewBase62Codec(idcodec.WithKey([]byte("other")))
+		assert.NotEqual(t, a, other.Encode(1784159718452809728))
+	})
+
+	t.Run("rejects malformed strings", func(t *testing.T) {
+		for _, s := range []string{"", "0000000001", "000000000001", "0000000000-", "zzzzzzzzzzz", "00000000000"} {
+			_, err := plain.Decode(s)
+			assert.ErrorIs(t, err, apperror.ErrInvalidArgument, s)
+		}
+	})
+
+	t.Run("rejects values outside the int64 range", func(t *testing.T) {
+		_, err := plain.Decode("AzL8n0Y58m8")
+		assert.ErrorIs(t, err, apperror.ErrInvalidArgument)
+	})
+}
+
+func TestParseMode(t *testing.T) {
+	for _, mode := range []idcodec.Mode{idcodec.ModeInt64, idcodec.ModeDual, idcodec.ModeOpaque} {
+		parsed, err := idcodec.ParseMode(string(mode))
+		require.NoError(t, err)
+		assert.Equal(t, mode, parsed)
+	}
+	_, err := idcodec.ParseMode("hex")
+	assert.Error(t, err)
+}
+
+func TestConvertIDs(t *testing.T) {
+	codec := idcodecImpl.NewBase62Codec(idcodec.WithKey([]byte("secret")))
+	const id int64 = 1784159718452809728
+	public := codec.Encode(id)
+
+	t.Run("int64 mode", func(t *testing.T) {
+		ids := convert.NewIDs(codec, idcodec.ModeInt64)
+		gotId, gotPublic := ids.Out(id)
+		assert.Equal(t, id, gotId)
+		assert.Empty(t, gotPublic)
+		assert.Equal(t, "1784159718452809728", ids.String(id))
+
+		resolved, err := ids.In("id", "public_id", id, "")
+		require.NoError(t, err)
+		assert.Equal(t, id, resolved)
+
+		_, err = ids.In("id", "public_id", 0, public)
+		assert.ErrorIs(t, err, apperror.ErrInvalidArgument)
+		assert.ErrorContains(t, err, "public_id is not enabled")
+	})
+
+	t.Run("dual mode", func(t *testing.T) {
+		ids := convert.NewIDs(codec, idcodec.ModeDual)
+		gotId, gotPublic := ids.Out(id)
+		assert.Equal(t, id, gotId)
+		assert.Equal(t, public, gotPublic)
+
+		for _, tt := range []struct {
+			id     int64
+			public string
+		}{{id, ""}, {0, public}, {id, public}} {
+			resolved, err := ids.In("id", "public_id", tt.id, tt.public)
+			require.NoError(t, err)
+			assert.Equal(t, id, resolved)
+		}
+
+		_, err := ids.In("id", "public_id", id+1, public)
+		assert.ErrorContains(t, err, "name different ids")
+
+		resolved, err := ids.In("id", "public_id", 0, "")
+		require.NoError(t, err)
+		assert.Zero(t, resolved)
+	})
+
+	t.Run("opaque mode", func(t *testing.T) {
+		ids := convert.NewIDs(codec, idcodec.ModeOpaque)
+		gotId, gotPublic := ids.Out(id)
+		assert.Zero(t, gotId)
+		assert.Equal(t, public, gotPublic)
+		assert.Equal(t, public, ids.String(id))
+
+		resolved, err := ids.In("user_id", "user_public_id", 0, public)
+		require.NoError(t, err)
+		assert.Equal(t, id, resolved)
+
+		_, err = ids.In("user_id", "user_public_id", id, "")
+		assert.ErrorContains(t, err, "user_id is not accepted, use user_public_id")
+
+		_, err = ids.In("user_id", "user_public_id", 0, "not-an-id")
+		assert.ErrorIs(t, err, apperror.ErrInvalidArgument)
+		assert.ErrorContains(t, err, "user_public_id")
+	})
+
+	t.Run("required ids record every violation", func(t *testing.T) {
+		ids := convert.NewIDs(codec, idcodec.ModeOpaque)
+		var violations apperror.ValidationErrors
+
+		assert.Equal(t, id, ids.Require(&violations, "id", "public_id", 0, public))
+		require.NoError(t, violations.Err())
+
+		ids.Require(&violations, "id", "public_id", 0, "not-an-id")
+		ids.Require(&violations, "session_id", "session_public_id", 0, "")
+		var validation *apperror.ValidationError
+		require.ErrorAs(t, violations.Err(), &validation)
+		require.Len(t, validation.Violations, 2)
+		assert.Equal(t, apperror.FieldViolation{Field: "public_id", Reason: "malformed", Description: `"not-an-id" is not a valid id`}, validation.Violations[0])
+		assert.Equal(t, apperror.FieldViolation{Field: "session_id", Reason: "gt", Description: "must be greater than 0"}, validation.Violations[1])
+	})
+}
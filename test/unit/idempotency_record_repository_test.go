@@ -0,0 +1,133 @@
+package unit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyRecordRepository_Quarantine(t *testing.T) {
+	ctx := context.Background()
+	columns := []string{"id", "request_type", "reference_id", "response_data", "created_at"}
+
+	t.Run("moves the record into the quarantine table", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewIdempotencyRecordRepository(db, &passthroughCB{}, &passthroughRetry{}, false)
+		createdAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM "main"."idempotency_records" WHERE "idempotency_records"."id" = $1 RETURNING *`)).
+			WithArgs(int64(100)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(100), "CREATE_USER", int64(200), "{bad", createdAt))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "main"."idempotency_quarantine" ("request_type","reference_id","response_data","created_at","quarantined_at","reason","id") VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING "id"`)).
+			WithArgs("CREATE_USER", int64(200), "{bad", createdAt, sqlmock.AnyArg(), "bad deploy", int64(100)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(100)))
+		mock.ExpectCommit()
+
+		quarantined, err := repo.Quarantine(ctx, 100, "bad deploy")
+		require.NoError(t, err)
+		assert.True(t, quarantined)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("reports false for an unknown record", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewIdempotencyRecordRepository(db, &passthroughCB{}, &passthroughRetry{}, false)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM "main"."idempotency_records" WHERE "idempotency_records"."id" = $1 RETURNING *`)).
+			WithArgs(int64(100)).
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectCommit()
+
+		quarantined, err := repo.Quarantine(ctx, 100, "bad deploy")
+		require.NoError(t, err)
+		assert.False(t, quarantined)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestIdempotencyQuarantineRepository(t *testing.T) {
+	ctx := context.Background()
+	createdAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	t.Run("lists live records in id order after the cursor", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewIdempotencyQuarantineRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."idempotency_records" WHERE id > $1 ORDER BY id LIMIT $2`)).
+			WithArgs(int64(100), 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "request_type", "reference_id", "response_data", "created_at"}).
+				AddRow(int64(101), "create_user", int64(200), "{bad", createdAt))
+
+		records, err := repo.ListRecords(ctx, 100, 2)
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, int64(101), records[0].Id)
+		assert.Equal(t, "{bad", records[0].ResponseData)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("lists the live records of a reference", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewIdempotencyQuarantineRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."idempotency_records" WHERE reference_id = $1 AND id > $2 ORDER BY id LIMIT $3`)).
+			WithArgs(int64(200), int64(100), 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "request_type", "reference_id", "response_data", "created_at"}).
+				AddRow(int64(101), "create_user", int64(200), "{}", createdAt))
+
+		records, err := repo.ListRecordsByReference(ctx, 200, 100, 2)
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, int64(200), records[0].ReferenceId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("restore moves the record back with its new response", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewIdempotencyQuarantineRepository(db, &passthroughCB{}, &passthroughRetry{})
+		quarantinedAt := createdAt.Add(time.Hour)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM "main"."idempotency_quarantine" WHERE "idempotency_quarantine"."id" = $1 RETURNING *`)).
+			WithArgs(int64(100)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "request_type", "reference_id", "response_data", "created_at", "quarantined_at", "reason"}).
+				AddRow(int64(100), "create_user", int64(200), "{bad", createdAt, quarantinedAt, "bad deploy"))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "main"."idempotency_records" ("request_type","reference_id","response_data","created_at","id") VALUES ($1,$2,$3,$4,$5) RETURNING "id"`)).
+			WithArgs("create_user", int64(200), `{"id":"200"}`, createdAt, int64(100)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(100)))
+		mock.ExpectCommit()
+
+		restored, err := repo.Restore(ctx, 100, `{"id":"200"}`)
+		require.NoError(t, err)
+		assert.True(t, restored)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("restore reports false for an unknown record", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewIdempotencyQuarantineRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM "main"."idempotency_quarantine" WHERE "idempotency_quarantine"."id" = $1 RETURNING *`)).
+			WithArgs(int64(100)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectCommit()
+
+		restored, err := repo.Restore(ctx, 100, `{}`)
+		require.NoError(t, err)
+		assert.False(t, restored)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
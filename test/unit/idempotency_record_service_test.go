@@ -0,0 +1,249 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/constant"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/idempotency"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryIdempotencyStore keeps live and quarantined idempotency records for
+// memoryIdempotencyRecords and memoryIdempotencyQuarantine.
+type memoryIdempotencyStore struct {
+	mu          sync.Mutex
+	live        map[int64]*idempotency.Record
+	quarantined map[int64]*idempotency.QuarantinedRecord
+}
+
+type memoryIdempotencyRecords struct{ *memoryIdempotencyStore }
+
+func (m memoryIdempotencyRecords) Lock(ctx context.Context, id int64) error { return nil }
+
+func (m memoryIdempotencyRecords) Get(ctx context.Context, id int64) (*idempotency.Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.live[id], nil
+}
+
+func (m memoryIdempotencyRecords) Insert(ctx context.Context, record *idempotency.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.live[record.Id] = record
+	return nil
+}
+
+func (m memoryIdempotencyRecords) Quarantine(ctx context.Context, id int64, reason string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record := m.live[id]
+	if record == nil {
+		return false, nil
+	}
+	delete(m.live, id)
+	m.quarantined[id] = &idempotency.QuarantinedRecord{Record: *record, QuarantinedAt: time.Now(), Reason: reason}
+	return true, nil
+}
+
+type memoryIdempotencyQuarantine struct{ *memoryIdempotencyStore }
+
+func (m memoryIdempotencyQuarantine) ListRecords(ctx context.Context, afterId int64, limit int) ([]*idempotency.Record, error) {
+	return m.ListRecordsByReference(ctx, 0, afterId, limit)
+}
+
+func (m memoryIdempotencyQuarantine) ListRecordsByReference(ctx context.Context, referenceId, afterId int64, limit int) ([]*idempotency.Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var records []*idempotency.Record
+	for _, id := range slices.Sorted(maps.Keys(m.live)) {
+		if id > afterId && len(records) < limit && (referenceId == 0 || m.live[id].ReferenceId == referenceId) {
+			records = append(records, m.live[id])
+		}
+	}
+	return records, nil
+}
+
+func (m memoryIdempotencyQuarantine) List(ctx context.Context, afterId int64, limit int) ([]*idempotency.QuarantinedRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var records []*idempotency.QuarantinedRecord
+	for _, record := range m.quarantined {
+		if record.Id > afterId {
+			records = append(records, record)
+		}
+	}
+	slices.SortFunc(records, func(a, b *idempotency.QuarantinedRecord) int { return int(a.Id - b.Id) })
+	return records[:min(limit, len(records))], nil
+}
+
+func (m memoryIdempotencyQuarantine) Get(ctx context.Context, id int64) (*idempotency.QuarantinedRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.quarantined[id], nil
+}
+
+func (m memoryIdempotencyQuarantine) Restore(ctx context.Context, id int64, responseData string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record := m.quarantined[id]
+	if record == nil {
+		return false, nil
+	}
+	delete(m.quarantined, id)
+	restored := record.Record
+	restored.ResponseData = responseData
+	m.live[id] = &restored
+	return true, nil
+}
+
+func TestIdempotencyRecordService(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{Id: 7, Username: "alice", Status: model.UserStatusSuspended}
+	userJSON, err := json.Marshal(user)
+	require.NoError(t, err)
+
+	setup := func(records ...*idempotency.Record) (service.IdempotencyRecordService, *memoryIdempotencyStore, *mockMeter) {
+		store := &memoryIdempotencyStore{live: map[int64]*idempotency.Record{}, quarantined: map[int64]*idempotency.QuarantinedRecord{}}
+		for _, record := range records {
+			store.live[record.Id] = record
+		}
+		users := &mockUserRepository{getFunc: func(ctx context.Context, id int64) (*model.User, error) {
+			if id != user.Id {
+				return nil, nil
+			}
+			u := *user
+			return &u, nil
+		}}
+		factory := &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) {
+			return &mockUnitOfWork{
+				userRepo:        users,
+				idempotencyRepo: memoryIdempotencyRecords{store},
+				quarantineRepo:  memoryIdempotencyQuarantine{store},
+				commitFunc:      func(ctx context.Context) error { return nil },
+				abortFunc:       func(ctx context.Context) error { return nil },
+			}, nil
+		}}
+		meter := &mockMeter{}
+		return service.NewIdempotencyRecordService(factory, service.UserIdempotentRequests(), meter, &mockLogger{}), store, meter
+	}
+	record := func(id int64, requestType constant.RequestType, referenceId int64, response string) *idempotency.Record {
+		return &idempotency.Record{Id: id, RequestType: string(requestType), ReferenceId: referenceId, ResponseData: response}
+	}
+
+	t.Run("lists records whose response does not decode", func(t *testing.T) {
+		svc, _, _ := setup(
+			record(1, constant.RequestTypeCreateUser, 7, string(userJSON)),
+			record(2, constant.RequestTypeSuspendUser, 7, `{"id":"seven"}`),
+			record(3, "retired_request", 7, `{bad`),
+			record(4, constant.RequestTypeCreateUser, 7, ``),
+		)
+
+		corrupt, next, err := svc.ListCorrupt(ctx, 0, 3)
+		require.NoError(t, err)
+		require.Len(t, corrupt, 1, "unknown request types are not checked")
+		assert.Equal(t, int64(2), corrupt[0].Id)
+		var corruptErr *idempotency.ErrCorruptRecord
+		assert.ErrorAs(t, corrupt[0].Err, &corruptErr)
+		assert.Equal(t, int64(3), next)
+
+		corrupt, next, err = svc.ListCorrupt(ctx, next, 3)
+		require.NoError(t, err)
+		require.Len(t, corrupt, 1)
+		assert.Equal(t, int64(4), corrupt[0].Id)
+		assert.Zero(t, next, "every record has been checked")
+	})
+
+	t.Run("repair rebuilds the response from the referenced user", func(t *testing.T) {
+		svc, store, meter := setup(record(2, constant.RequestTypeSuspendUser, 7, `{"id":"seven"}`))
+		_, err := svc.Quarantine(ctx, 2, "bad deploy")
+		require.NoError(t, err)
+
+		repaired, err := svc.Repair(ctx, 2)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(userJSON), repaired.ResponseData)
+		assert.Empty(t, store.quarantined)
+		require.NotNil(t, store.live[2])
+		var replayed model.User
+		require.NoError(t, store.live[2].Decode(&replayed), "the record replays again")
+		assert.Equal(t, *user, replayed)
+		assert.Equal(t, float64(1), meter.metrics["idempotency_records_repaired_total"].values[string(constant.RequestTypeSuspendUser)])
+	})
+
+	t.Run("repair fails when the response cannot be rebuilt", func(t *testing.T) {
+		svc, store, _ := setup(
+			record(2, constant.RequestTypeCreateUser, 8, `{bad`),
+			record(3, "retired_request", 7, `{bad`),
+			record(4, constant.RequestTypeCreateUser, 7, `{bad`),
+		)
+		for _, id := range []int64{2, 3, 4} {
+			_, err := svc.Quarantine(ctx, id, "bad deploy")
+			require.NoError(t, err)
+		}
+		store.live[4] = record(4, constant.RequestTypeCreateUser, 7, string(userJSON))
+
+		for id, reason := range map[int64]string{
+			2: "IDEMPOTENCY_RECORD_REFERENCE_GONE",
+			3: "IDEMPOTENCY_RECORD_NOT_REDERIVABLE",
+			4: "IDEMPOTENCY_RECORD_SUPERSEDED",
+		} {
+			_, err := svc.Repair(ctx, id)
+			assert.ErrorIs(t, err, apperror.ErrFailedPrecondition, id)
+			var reasonErr *apperror.ReasonError
+			require.ErrorAs(t, err, &reasonErr)
+			assert.Equal(t, reason, reasonErr.Reason)
+			assert.NotNil(t, store.quarantined[id], "record %d stays quarantined", id)
+		}
+
+		repaired, err := svc.Repair(ctx, 99)
+		require.NoError(t, err)
+		assert.Nil(t, repaired, "nothing is quarantined under the id")
+	})
+
+	t.Run("run quarantines and repairs corrupt records", func(t *testing.T) {
+		svc, store, meter := setup(
+			record(1, constant.RequestTypeCreateUser, 7, string(userJSON)),
+			record(2, constant.RequestTypeReactivateUser, 7, `null`),
+			record(3, constant.RequestTypeCreateUser, 8, `{bad`),
+		)
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			svc.Run(ctx, time.Hour, true)
+			close(done)
+		}()
+		t.Cleanup(func() {
+			cancel()
+			<-done
+		})
+
+		require.Eventually(t, func() bool {
+			quarantined, err := svc.ListQuarantined(ctx, 2, 1)
+			return err == nil && len(quarantined) == 1
+		}, time.Second, time.Millisecond)
+		cancel()
+		<-done
+
+		quarantined, err := svc.ListQuarantined(context.Background(), 0, 10)
+		require.NoError(t, err)
+		require.Len(t, quarantined, 1, "record 2 is repaired, record 3 cannot be")
+		assert.Equal(t, int64(3), quarantined[0].Id)
+		assert.Contains(t, quarantined[0].Reason, "response does not decode")
+		assert.JSONEq(t, string(userJSON), store.live[2].ResponseData)
+		assert.Equal(t, string(userJSON), store.live[1].ResponseData, "sound records are left alone")
+
+		quarantinedTotal := meter.metrics["idempotency_records_quarantined_total"]
+		assert.Equal(t, 1, quarantinedTotal.observations[string(constant.RequestTypeReactivateUser)])
+		assert.Equal(t, 1, quarantinedTotal.observations[string(constant.RequestTypeCreateUser)])
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/jt828/go-grpc-template/internal/constant"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
 	"github.com/jt828/go-grpc-template/pkg/idempotency"
 	"github.com/jt828/go-grpc-template/pkg/idempotency/implementation"
 	"github.com/stretchr/testify/assert"
@@ -19,8 +20,17 @@ type testResult struct {
 }
 
 type mockRecordRepository struct {
-	getFunc    func(ctx context.Context, id int64) (*idempotency.Record, error)
-	insertFunc func(ctx context.Context, record *idempotency.Record) error
+	lockFunc       func(ctx context.Context, id int64) error
+	getFunc        func(ctx context.Context, id int64) (*idempotency.Record, error)
+	insertFunc     func(ctx context.Context, record *idempotency.Record) error
+	quarantineFunc func(ctx context.Context, id int64, reason string) (bool, error)
+}
+
+func (m *mockRecordRepository) Lock(ctx context.Context, id int64) error {
+	if m.lockFunc == nil {
+		return nil
+	}
+	return m.lockFunc(ctx, id)
 }
 
 func (m *mockRecordRepository) Get(ctx context.Context, id int64) (*idempotency.Record, error) {
@@ -31,6 +41,10 @@ func (m *mockRecordRepository) Insert(ctx context.Context, record *idempotency.R
 	return m.insertFunc(ctx, record)
 }
 
+func (m *mockRecordRepository) Quarantine(ctx context.Context, id int64, reason string) (bool, error) {
+	return m.quarantineFunc(ctx, id, reason)
+}
+
 func TestIdempotencyExecute(t *testing.T) {
 	ctx := context.Background()
 	idempotencyId := int64(100)
@@ -56,7 +70,7 @@ func TestIdempotencyExecute(t *testing.T) {
 		}
 
 		idem := implementation.NewIdempotency()
-		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, referenceId, newResult, func() (any, error) {
+		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, &referenceId, newResult, func() (any, error) {
 			return expected, nil
 		})
 
@@ -74,6 +88,30 @@ func TestIdempotencyExecute(t *testing.T) {
 		assert.Equal(t, *expected, stored)
 	})
 
+	t.Run("record refers to the reference id fn leaves behind", func(t *testing.T) {
+		var insertedRecord *idempotency.Record
+		repo := &mockRecordRepository{
+			getFunc: func(ctx context.Context, id int64) (*idempotency.Record, error) {
+				return nil, nil
+			},
+			insertFunc: func(ctx context.Context, record *idempotency.Record) error {
+				insertedRecord = record
+				return nil
+			},
+		}
+
+		id := referenceId
+		idem := implementation.NewIdempotency()
+		_, err := idem.Execute(ctx, repo, idempotencyId, requestType, &id, newResult, func() (any, error) {
+			id = 201
+			return &testResult{}, nil
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, insertedRecord)
+		assert.Equal(t, int64(201), insertedRecord.ReferenceId)
+	})
+
 	t.Run("cache hit returns deserialized result without executing function", func(t *testing.T) {
 		cached := &testResult{Name: "bob", Value: 99}
 		data, _ := json.Marshal(cached)
@@ -95,7 +133,7 @@ func TestIdempotencyExecute(t *testing.T) {
 
 		fnCalled := false
 		idem := implementation.NewIdempotency()
-		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, referenceId, newResult, func() (any, error) {
+		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, &referenceId, newResult, func() (any, error) {
 			fnCalled = true
 			return nil, nil
 		})
@@ -115,7 +153,7 @@ func TestIdempotencyExecute(t *testing.T) {
 		}
 
 		idem := implementation.NewIdempotency()
-		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, referenceId, newResult, func() (any, error) {
+		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, &referenceId, newResult, func() (any, error) {
 			t.Fatal("fn should not be called when Get fails")
 			return nil, nil
 		})
@@ -138,7 +176,7 @@ func TestIdempotencyExecute(t *testing.T) {
 		}
 
 		idem := implementation.NewIdempotency()
-		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, referenceId, newResult, func() (any, error) {
+		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, &referenceId, newResult, func() (any, error) {
 			return nil, fnErr
 		})
 
@@ -146,18 +184,37 @@ func TestIdempotencyExecute(t *testing.T) {
 		assert.ErrorIs(t, err, fnErr)
 	})
 
+	t.Run("record of another request type is a conflict", func(t *testing.T) {
+		repo := &mockRecordRepository{
+			getFunc: func(ctx context.Context, id int64) (*idempotency.Record, error) {
+				return &idempotency.Record{Id: idempotencyId, RequestType: string(constant.RequestTypeSuspendUser), ResponseData: `{"name":"alice","value":42}`}, nil
+			},
+		}
+
+		idem := implementation.NewIdempotency()
+		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, &referenceId, newResult, func() (any, error) {
+			t.Fatal("fn should not be called when the key was used")
+			return nil, nil
+		})
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, apperror.ErrFailedPrecondition)
+		assert.True(t, apperror.HasReason(err, apperror.ReasonIdempotencyConflict))
+	})
+
 	t.Run("invalid cached JSON returns unmarshal error", func(t *testing.T) {
 		repo := &mockRecordRepository{
 			getFunc: func(ctx context.Context, id int64) (*idempotency.Record, error) {
 				return &idempotency.Record{
 					Id:           idempotencyId,
+					RequestType:  string(requestType),
 					ResponseData: "not valid json{{{",
 				}, nil
 			},
 		}
 
 		idem := implementation.NewIdempotency()
-		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, referenceId, newResult, func() (any, error) {
+		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, &referenceId, newResult, func() (any, error) {
 			t.Fatal("fn should not be called when cache hit")
 			return nil, nil
 		})
@@ -166,6 +223,68 @@ func TestIdempotencyExecute(t *testing.T) {
 		assert.Error(t, err)
 		var syntaxErr *json.SyntaxError
 		assert.ErrorAs(t, err, &syntaxErr)
+		var corruptErr *idempotency.ErrCorruptRecord
+		require.ErrorAs(t, err, &corruptErr)
+		assert.Equal(t, idempotencyId, corruptErr.Id)
+	})
+
+	t.Run("null cached response is a corrupt record", func(t *testing.T) {
+		repo := &mockRecordRepository{
+			getFunc: func(ctx context.Context, id int64) (*idempotency.Record, error) {
+				return &idempotency.Record{Id: idempotencyId, RequestType: string(requestType), ResponseData: "null"}, nil
+			},
+		}
+
+		idem := implementation.NewIdempotency()
+		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, &referenceId, newResult, func() (any, error) {
+			t.Fatal("fn should not be called when cache hit")
+			return nil, nil
+		})
+
+		assert.Nil(t, result)
+		var corruptErr *idempotency.ErrCorruptRecord
+		require.ErrorAs(t, err, &corruptErr)
+		assert.Equal(t, idempotencyId, corruptErr.Id)
+		assert.Equal(t, string(requestType), corruptErr.RequestType)
+	})
+
+	t.Run("newResult returning a non-pointer fails without decoding", func(t *testing.T) {
+		repo := &mockRecordRepository{
+			getFunc: func(ctx context.Context, id int64) (*idempotency.Record, error) {
+				return &idempotency.Record{Id: idempotencyId, RequestType: string(requestType), ResponseData: `{"name":"alice","value":42}`}, nil
+			},
+		}
+
+		idem := implementation.NewIdempotency()
+		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, &referenceId, func() any { return testResult{} }, func() (any, error) {
+			t.Fatal("fn should not be called when cache hit")
+			return nil, nil
+		})
+
+		assert.Nil(t, result)
+		require.Error(t, err)
+		var corruptErr *idempotency.ErrCorruptRecord
+		assert.False(t, errors.As(err, &corruptErr), "a bad newResult is not the record's fault")
+	})
+
+	t.Run("result of another type than newResult is not stored", func(t *testing.T) {
+		repo := &mockRecordRepository{
+			getFunc: func(ctx context.Context, id int64) (*idempotency.Record, error) {
+				return nil, nil
+			},
+			insertFunc: func(ctx context.Context, record *idempotency.Record) error {
+				t.Fatal("insert should not be called for a mismatched result")
+				return nil
+			},
+		}
+
+		idem := implementation.NewIdempotency()
+		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, &referenceId, newResult, func() (any, error) {
+			return testResult{Name: "alice"}, nil
+		})
+
+		assert.Nil(t, result)
+		assert.Error(t, err)
 	})
 
 	t.Run("marshal error is propagated when result is not serializable", func(t *testing.T) {
@@ -180,7 +299,7 @@ func TestIdempotencyExecute(t *testing.T) {
 		}
 
 		idem := implementation.NewIdempotency()
-		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, referenceId, newResult, func() (any, error) {
+		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, &referenceId, newResult, func() (any, error) {
 			return func() {}, nil // functions are not JSON-serializable
 		})
 
@@ -203,11 +322,62 @@ func TestIdempotencyExecute(t *testing.T) {
 		}
 
 		idem := implementation.NewIdempotency()
-		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, referenceId, newResult, func() (any, error) {
+		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, &referenceId, newResult, func() (any, error) {
 			return &testResult{Name: "test", Value: 1}, nil
 		})
 
 		assert.Nil(t, result)
 		assert.ErrorIs(t, err, insertErr)
 	})
+
+	t.Run("lock is acquired before reading the record", func(t *testing.T) {
+		var calls []string
+
+		repo := &mockRecordRepository{
+			lockFunc: func(ctx context.Context, id int64) error {
+				assert.Equal(t, idempotencyId, id)
+				calls = append(calls, "lock")
+				return nil
+			},
+			getFunc: func(ctx context.Context, id int64) (*idempotency.Record, error) {
+				calls = append(calls, "get")
+				return nil, nil
+			},
+			insertFunc: func(ctx context.Context, record *idempotency.Record) error {
+				calls = append(calls, "insert")
+				return nil
+			},
+		}
+
+		idem := implementation.NewIdempotency()
+		_, err := idem.Execute(ctx, repo, idempotencyId, requestType, &referenceId, newResult, func() (any, error) {
+			return &testResult{Name: "test", Value: 1}, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"lock", "get", "insert"}, calls)
+	})
+
+	t.Run("repo Lock error is propagated", func(t *testing.T) {
+		lockErr := errors.New("lock failed")
+
+		repo := &mockRecordRepository{
+			lockFunc: func(ctx context.Context, id int64) error {
+				return lockErr
+			},
+			getFunc: func(ctx context.Context, id int64) (*idempotency.Record, error) {
+				t.Fatal("get should not be called when lock fails")
+				return nil, nil
+			},
+		}
+
+		idem := implementation.NewIdempotency()
+		result, err := idem.Execute(ctx, repo, idempotencyId, requestType, &referenceId, newResult, func() (any, error) {
+			t.Fatal("fn should not be called when lock fails")
+			return nil, nil
+		})
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, lockErr)
+	})
 }
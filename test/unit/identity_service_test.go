@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockUserIdentityRepository struct {
+	links map[string]*model.UserIdentity
+}
+
+func (m *mockUserIdentityRepository) Get(ctx context.Context, issuer, subject string) (*model.UserIdentity, error) {
+	return m.links[issuer+" "+subject], nil
+}
+
+func (m *mockUserIdentityRepository) Insert(ctx context.Context, identity *model.UserIdentity) error {
+	m.links[identity.Issuer+" "+identity.Subject] = identity
+	return nil
+}
+
+func (m *mockUserIdentityRepository) Delete(ctx context.Context, issuer, subject string) (bool, error) {
+	_, ok := m.links[issuer+" "+subject]
+	delete(m.links, issuer+" "+subject)
+	return ok, nil
+}
+
+func TestIdentityService(t *testing.T) {
+	ctx := context.Background()
+	issuer := "https://auth.example.com"
+	setup := func() (service.IdentityService, *mockUserIdentityRepository) {
+		identities := &mockUserIdentityRepository{links: map[string]*model.UserIdentity{}}
+		users := &mockUserRepository{getFunc: func(ctx context.Context, id int64) (*model.User, error) {
+			switch id {
+			case 1, 2:
+				return &model.User{Id: id, Status: model.UserStatusActive}, nil
+			case 4:
+				return &model.User{Id: id, Status: model.UserStatusSuspended}, nil
+			}
+			return nil, nil
+		}}
+		factory := &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) {
+			return &mockUnitOfWork{
+				userRepo:     users,
+				identityRepo: identities,
+				commitFunc:   func(ctx context.Context) error { return nil },
+				abortFunc:    func(ctx context.Context) error { return nil },
+			}, nil
+		}}
+		return service.NewIdentityService(factory, &mockLogger{}), identities
+	}
+
+	t.Run("linked subjects resolve to their user", func(t *testing.T) {
+		svc, _ := setup()
+		identity, err := svc.Link(ctx, 1, issuer, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), identity.UserId)
+		assert.WithinDuration(t, time.Now(), identity.CreatedAt, time.Minute)
+
+		userId, err := svc.UserId(ctx, issuer, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), userId)
+
+		_, err = svc.UserId(ctx, "https://other.example.com", "alice")
+		assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+	})
+
+	t.Run("subjects of suspended users do not resolve", func(t *testing.T) {
+		svc, _ := setup()
+		_, err := svc.Link(ctx, 4, issuer, "mallory")
+		require.NoError(t, err)
+
+		_, err = svc.UserId(ctx, issuer, "mallory")
+		assert.ErrorIs(t, err, apperror.ErrPermissionDenied)
+	})
+
+	t.Run("linking again is idempotent but cannot move the subject", func(t *testing.T) {
+		svc, _ := setup()
+		first, err := svc.Link(ctx, 1, issuer, "alice")
+		require.NoError(t, err)
+		again, err := svc.Link(ctx, 1, issuer, "alice")
+		require.NoError(t, err)
+		assert.Same(t, first, again)
+
+		_, err = svc.Link(ctx, 2, issuer, "alice")
+		assert.ErrorIs(t, err, apperror.ErrConflict)
+	})
+
+	t.Run("linking to a missing user is not found", func(t *testing.T) {
+		svc, identities := setup()
+		_, err := svc.Link(ctx, 3, issuer, "alice")
+		assert.ErrorIs(t, err, apperror.ErrNotFound)
+		assert.Empty(t, identities.links)
+	})
+
+	t.Run("unlinked subjects no longer resolve", func(t *testing.T) {
+		svc, _ := setup()
+		_, err := svc.Link(ctx, 1, issuer, "alice")
+		require.NoError(t, err)
+
+		unlinked, err := svc.Unlink(ctx, issuer, "alice")
+		require.NoError(t, err)
+		assert.True(t, unlinked)
+		unlinked, err = svc.Unlink(ctx, issuer, "alice")
+		require.NoError(t, err)
+		assert.False(t, unlinked)
+
+		_, err = svc.UserId(ctx, issuer, "alice")
+		assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+	})
+}
@@ -0,0 +1,53 @@
+package unit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInboxRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	message := &model.InboxMessage{Handler: "users/UserCreatedV1", EventId: 42, EventType: "type.googleapis.com/proto.events.v1.UserCreatedV1", ProcessedAt: now}
+	insertSQL := regexp.QuoteMeta(`INSERT INTO "main"."inbox_messages" ("handler","event_id","event_type","processed_at") VALUES ($1,$2,$3,$4) ON CONFLICT DO NOTHING`)
+
+	t.Run("new message is inserted", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewInboxRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(insertSQL).
+			WithArgs(message.Handler, message.EventId, message.EventType, now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		inserted, err := repo.Insert(ctx, message)
+		require.NoError(t, err)
+		assert.True(t, inserted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already processed message is reported as duplicate", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewInboxRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(insertSQL).
+			WithArgs(message.Handler, message.EventId, message.EventType, now).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		inserted, err := repo.Insert(ctx, message)
+		require.NoError(t, err)
+		assert.False(t, inserted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
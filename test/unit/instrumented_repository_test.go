@@ -0,0 +1,152 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockMetric counts observations and keeps the last value, keyed by the
+// value of the first label.
+type mockMetric struct {
+	mu           sync.Mutex
+	observations map[string]int
+	values       map[string]float64
+}
+
+// record keys observations by the first label's value, or by "" for a
+// metric without labels. With add, v is added to the current value.
+func (m *mockMetric) record(v float64, labels []observability.Label, add bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := labelKey(labels)
+	if add {
+		v += m.values[key]
+	}
+	m.observations[key]++
+	m.values[key] = v
+}
+
+func (m *mockMetric) Inc(v float64, labels ...observability.Label)     { m.record(v, labels, false) }
+func (m *mockMetric) Observe(v float64, labels ...observability.Label) { m.record(v, labels, false) }
+func (m *mockMetric) Set(v float64, labels ...observability.Label)     { m.record(v, labels, false) }
+func (m *mockMetric) Add(v float64, labels ...observability.Label)     { m.record(v, labels, true) }
+
+func labelKey(labels []observability.Label) string {
+	if len(labels) > 0 {
+		return labels[0].Value
+	}
+	return ""
+}
+
+type mockMeter struct {
+	metrics map[string]*mockMetric
+}
+
+func (m *mockMeter) metric(name string) *mockMetric {
+	if m.metrics == nil {
+		m.metrics = map[string]*mockMetric{}
+	}
+	metric := &mockMetric{observations: map[string]int{}, values: map[string]float64{}}
+	m.metrics[name] = metric
+	return metric
+}
+
+func (m *mockMeter) Counter(name string, opts ...observability.MetricOpt) observability.Counter {
+	return m.metric(name)
+}
+
+func (m *mockMeter) Histogram(name string, opts ...observability.MetricOpt) observability.Histogram {
+	return m.metric(name)
+}
+
+func (m *mockMeter) Gauge(name string, opts ...observability.MetricOpt) observability.Gauge {
+	return m.metric(name)
+}
+
+func (m *mockMeter) Timer(name string, opts ...observability.MetricOpt) observability.Timer {
+	return nil
+}
+
+func TestInstrumentedRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	setup := func(t *testing.T) (repository.UnitOfWork, sqlmock.Sqlmock, *mockMeter, *mockTracer, *mockLogger) {
+		db, mock := setupMockDB(t)
+		meter := &mockMeter{}
+		tracer := &mockTracer{}
+		log := &mockLogger{}
+
+		mock.ExpectBegin()
+		factory := repository.NewTransactionDbUnitOfWorkFactory(db, &passthroughCB{}, &passthroughRetry{},
+			repository.WithMetrics(meter),
+			repository.WithTracing(tracer),
+			repository.WithLogging(log),
+		)
+		uow, err := factory.New(ctx)
+		require.NoError(t, err)
+		return uow, mock, meter, tracer, log
+	}
+
+	t.Run("successful operation is traced and timed", func(t *testing.T) {
+		uow, mock, meter, tracer, log := setup(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."users" WHERE "users"."id" = $1`)).
+			WithArgs(int64(1), 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "username", "password", "created_at", "updated_at"}).
+				AddRow(1, "a@example.com", "alice", "pw", now, now))
+
+		user, err := uow.UserRepository().Get(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), user.Id)
+
+		require.Len(t, tracer.spans, 1)
+		assert.Equal(t, "UserRepository.Get", tracer.spans[0].name)
+		assert.True(t, tracer.spans[0].ended)
+		assert.Empty(t, tracer.spans[0].errors)
+		assert.Equal(t, 1, meter.metrics["repository_operation_duration_seconds"].observations["UserRepository.Get"])
+		assert.Zero(t, meter.metrics["repository_operation_errors_total"].observations["UserRepository.Get"])
+		assert.Empty(t, log.errorCalls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failed operation records error on span, counter and log", func(t *testing.T) {
+		uow, mock, meter, tracer, log := setup(t)
+		dbErr := errors.New("connection refused")
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."ledgers"`)).WillReturnError(dbErr)
+
+		_, err := uow.LedgerRepository().Get(ctx, repository.GetQuery{})
+		require.ErrorIs(t, err, dbErr)
+
+		require.Len(t, tracer.spans, 1)
+		assert.Equal(t, "LedgerRepository.Get", tracer.spans[0].name)
+		assert.Len(t, tracer.spans[0].errors, 1)
+		assert.Equal(t, 1, meter.metrics["repository_operation_errors_total"].observations["LedgerRepository.Get"])
+		require.Len(t, log.errorCalls, 1)
+		assert.Equal(t, "repository operation failed", log.errorCalls[0].msg)
+		assert.Contains(t, log.errorCalls[0].fields, observability.String("operation", "LedgerRepository.Get"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("repositories are undecorated without options", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		mock.ExpectBegin()
+		uow, err := repository.NewTransactionDbUnitOfWorkFactory(db, &passthroughCB{}, &passthroughRetry{}).New(ctx)
+		require.NoError(t, err)
+
+		assert.IsType(t, &repository.UserRepositoryImpl{}, uow.UserRepository())
+		assert.IsType(t, &repository.LedgerRepositoryImpl{}, uow.LedgerRepository())
+		assert.IsType(t, &repository.IdempotencyRecordRepositoryImpl{}, uow.IdempotencyRecordRepository())
+	})
+}
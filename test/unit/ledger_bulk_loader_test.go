@@ -0,0 +1,36 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerSlice(t *testing.T) {
+	ledgers := []*model.Ledger{{Id: 1}, {Id: 2}}
+	source := repository.LedgerSlice(ledgers)
+
+	for _, want := range ledgers {
+		got, err := source()
+		require.NoError(t, err)
+		assert.Same(t, want, got)
+	}
+	got, err := source()
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestLedgerBulkLoader_RequiresPgx(t *testing.T) {
+	gormDB, _ := setupMockDB(t)
+	loader := repository.NewLedgerBulkLoader(gormDB, model.DefaultSchema, &passthroughCB{})
+
+	progressCalled := false
+	loaded, err := loader.Load(context.Background(), repository.LedgerSlice([]*model.Ledger{{Id: 1}}), func(int64) { progressCalled = true })
+	assert.ErrorContains(t, err, "requires the pgx driver")
+	assert.Zero(t, loaded)
+	assert.False(t, progressCalled)
+}
@@ -0,0 +1,174 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/authz"
+	"github.com/jt828/go-grpc-template/internal/controller"
+	"github.com/jt828/go-grpc-template/internal/controller/convert"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/idcodec"
+	idcodecImpl "github.com/jt828/go-grpc-template/pkg/idcodec/implementation"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubLedgerService serves ledgers with ids 1 to count, honouring AfterId,
+// BeforeId, NewestFirst and Limit, and remembers the params of the last call.
+type stubLedgerService struct {
+	count  int64
+	params service.GetParams
+}
+
+func (s *stubLedgerService) GetLedgers(ctx context.Context, params service.GetParams) ([]*model.Ledger, error) {
+	s.params = params
+	var ledgers []*model.Ledger
+	add := func(id int64) bool {
+		if params.Limit > 0 && len(ledgers) == params.Limit {
+			return false
+		}
+		ledgers = append(ledgers, &model.Ledger{Id: id, TransactionType: model.TransactionTypeDeposit, Amount: decimal.NewFromInt(1)})
+		return true
+	}
+	if params.NewestFirst {
+		from := s.count
+		if params.BeforeId != 0 {
+			from = params.BeforeId - 1
+		}
+		for id := from; id > params.AfterId && add(id); id-- {
+		}
+		return ledgers, nil
+	}
+	for id := params.AfterId + 1; id <= s.count && add(id); id++ {
+	}
+	return ledgers, nil
+}
+
+func (s *stubLedgerService) GetLedgersWithUsers(ctx context.Context, params service.GetParams) ([]*service.LedgerWithUser, error) {
+	ledgers, err := s.GetLedgers(ctx, params)
+	entries := make([]*service.LedgerWithUser, len(ledgers))
+	for i, ledger := range ledgers {
+		entries[i] = &service.LedgerWithUser{Ledger: ledger}
+	}
+	return entries, err
+}
+
+func TestLedgerController_ListLedgersPageSize(t *testing.T) {
+	limit := controller.PageSizeLimit{Default: 3, ByRole: map[string]int{"reporting": 10, "dashboard": 5}}
+	ids := convert.NewIDs(idcodecImpl.NewBase62Codec(), idcodec.ModeInt64)
+	newController := func(count int64) (*controller.LedgerController, *stubLedgerService) {
+		svc := &stubLedgerService{count: count}
+		return controller.NewLedgerController(svc, ids, limit), svc
+	}
+
+	t.Run("callers get the largest cap of their roles", func(t *testing.T) {
+		assert.Equal(t, 3, limit.For(nil))
+		assert.Equal(t, 3, limit.For([]string{"admin"}))
+		assert.Equal(t, 10, limit.For([]string{"dashboard", "reporting"}))
+	})
+
+	t.Run("unpaginated requests within the cap list every match", func(t *testing.T) {
+		ctrl, svc := newController(3)
+		resp, err := ctrl.ListLedgers(context.Background(), &v1.ListLedgersRequest{})
+		require.NoError(t, err)
+		assert.Len(t, resp.Ledgers, 3)
+		assert.Zero(t, resp.NextAfterId)
+		assert.Equal(t, 4, svc.params.Limit, "one more than the cap")
+	})
+
+	t.Run("unpaginated requests beyond the cap are rejected", func(t *testing.T) {
+		ctrl, _ := newController(4)
+		_, err := ctrl.ListLedgers(context.Background(), &v1.ListLedgersRequest{IncludeUser: true})
+		var validation *apperror.ValidationError
+		require.True(t, errors.As(err, &validation))
+		assert.Equal(t, "page_size", validation.Violations[0].Field)
+		assert.Contains(t, validation.Violations[0].Description, "more than 3 ledgers match")
+	})
+
+	t.Run("pages larger than the cap are rejected", func(t *testing.T) {
+		ctrl, _ := newController(4)
+		_, err := ctrl.ListLedgers(context.Background(), &v1.ListLedgersRequest{PageSize: 4})
+		assert.ErrorIs(t, err, apperror.ErrInvalidArgument)
+	})
+
+	t.Run("every invalid field is reported at once", func(t *testing.T) {
+		ctrl, _ := newController(4)
+		_, err := ctrl.ListLedgers(context.Background(), &v1.ListLedgersRequest{UserPublicId: "abc", TransactionType: "refund", PageSize: 4})
+		var validation *apperror.ValidationError
+		require.True(t, errors.As(err, &validation))
+		fields := make([]string, len(validation.Violations))
+		for i, v := range validation.Violations {
+			fields[i] = v.Field
+		}
+		assert.Equal(t, []string{"user_public_id", "transaction_type", "page_size"}, fields)
+	})
+
+	t.Run("role caps allow larger results", func(t *testing.T) {
+		ctrl, _ := newController(4)
+		resp, err := ctrl.ListLedgers(authz.ContextWithRoles(context.Background(), []string{"dashboard"}), &v1.ListLedgersRequest{})
+		require.NoError(t, err)
+		assert.Len(t, resp.Ledgers, 4)
+	})
+
+	t.Run("pages through with after_id", func(t *testing.T) {
+		ctrl, _ := newController(5)
+		first, err := ctrl.ListLedgers(context.Background(), &v1.ListLedgersRequest{PageSize: 3})
+		require.NoError(t, err)
+		assert.Len(t, first.Ledgers, 3)
+		assert.Equal(t, int64(3), first.NextAfterId)
+
+		second, err := ctrl.ListLedgers(context.Background(), &v1.ListLedgersRequest{PageSize: 3, AfterId: first.NextAfterId})
+		require.NoError(t, err)
+		assert.Len(t, second.Ledgers, 2)
+		assert.Equal(t, int64(4), second.Ledgers[0].Id)
+		assert.Zero(t, second.NextAfterId)
+	})
+
+	t.Run("pages newest first with page_token", func(t *testing.T) {
+		ctrl, svc := newController(5)
+		first, err := ctrl.ListLedgers(context.Background(), &v1.ListLedgersRequest{PageSize: 3, NewestFirst: true})
+		require.NoError(t, err)
+		require.Len(t, first.Ledgers, 3)
+		assert.Equal(t, int64(5), first.Ledgers[0].Id)
+		assert.Zero(t, first.NextAfterId, "after_id only pages in id order")
+		require.NotEmpty(t, first.NextPageToken)
+
+		second, err := ctrl.ListLedgers(context.Background(), &v1.ListLedgersRequest{PageSize: 3, NewestFirst: true, PageToken: first.NextPageToken})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), svc.params.BeforeId)
+		require.Len(t, second.Ledgers, 2)
+		assert.Equal(t, int64(2), second.Ledgers[0].Id)
+		assert.Empty(t, second.NextPageToken)
+	})
+
+	t.Run("page_token pages in id order too", func(t *testing.T) {
+		ctrl, svc := newController(5)
+		first, err := ctrl.ListLedgers(context.Background(), &v1.ListLedgersRequest{PageSize: 3})
+		require.NoError(t, err)
+		_, err = ctrl.ListLedgers(context.Background(), &v1.ListLedgersRequest{PageSize: 3, PageToken: first.NextPageToken})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), svc.params.AfterId)
+	})
+
+	t.Run("invalid cursors are rejected", func(t *testing.T) {
+		ctrl, _ := newController(5)
+		first, err := ctrl.ListLedgers(context.Background(), &v1.ListLedgersRequest{PageSize: 3, NewestFirst: true})
+		require.NoError(t, err)
+
+		for name, request := range map[string]*v1.ListLedgersRequest{
+			"malformed":                {PageSize: 3, PageToken: "not-a-token"},
+			"issued for another order": {PageSize: 3, PageToken: first.NextPageToken},
+			"with after_id":            {PageSize: 3, NewestFirst: true, PageToken: first.NextPageToken, AfterId: 1},
+			"after_id newest first":    {PageSize: 3, NewestFirst: true, AfterId: 1},
+		} {
+			_, err := ctrl.ListLedgers(context.Background(), request)
+			assert.ErrorIs(t, err, apperror.ErrInvalidArgument, name)
+		}
+	})
+}
@@ -27,13 +27,13 @@ type passthroughRetry struct{}
 
 func (p *passthroughRetry) Execute(ctx context.Context, fn func() error) error { return fn() }
 
-func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+func setupMockDB(t testing.TB) (*gorm.DB, sqlmock.Sqlmock) {
 	t.Helper()
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	t.Cleanup(func() { db.Close() })
 
-	gormDB, err := gorm.Open(pgdriver.New(pgdriver.Config{Conn: db}), &gorm.Config{})
+	gormDB, err := gorm.Open(pgdriver.New(pgdriver.Config{Conn: db}), &gorm.Config{NamingStrategy: model.NamingStrategy(model.DefaultSchema)})
 	require.NoError(t, err)
 	return gormDB, mock
 }
@@ -118,7 +118,7 @@ func TestLedgerRepository_Get(t *testing.T) {
 		ledgers, err := repo.Get(ctx, repository.GetQuery{TransactionTypeEq: "deposit"})
 		require.NoError(t, err)
 		assert.Len(t, ledgers, 1)
-		assert.Equal(t, "deposit", ledgers[0].TransactionType)
+		assert.Equal(t, model.TransactionTypeDeposit, ledgers[0].TransactionType)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
@@ -140,6 +140,43 @@ func TestLedgerRepository_Get(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("pages in id order after the cursor", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		repo := repository.NewLedgerRepository(gormDB, cb, r, false)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."ledgers" WHERE user_id = $1 AND id > $2 ORDER BY id LIMIT $3`)).
+			WithArgs(int64(10), int64(5), 2).
+			WillReturnRows(
+				sqlmock.NewRows(ledgerColumns()).
+					AddRow(6, 10, "deposit", "ETH", amt, now).
+					AddRow(9, 10, "withdraw", "ETH", amt, now),
+			)
+
+		ledgers, err := repo.Get(ctx, repository.GetQuery{UserIdEq: 10, IdGt: 5, Limit: 2})
+		require.NoError(t, err)
+		assert.Len(t, ledgers, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("pages newest first before the cursor", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		repo := repository.NewLedgerRepository(gormDB, cb, r, false)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."ledgers" WHERE user_id = $1 AND id < $2 ORDER BY id DESC LIMIT $3`)).
+			WithArgs(int64(10), int64(9), 2).
+			WillReturnRows(
+				sqlmock.NewRows(ledgerColumns()).
+					AddRow(8, 10, "deposit", "ETH", amt, now).
+					AddRow(6, 10, "withdraw", "ETH", amt, now),
+			)
+
+		ledgers, err := repo.Get(ctx, repository.GetQuery{UserIdEq: 10, IdLt: 9, Desc: true, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, ledgers, 2)
+		assert.Equal(t, int64(8), ledgers[0].Id)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("multiple filters combined", func(t *testing.T) {
 		gormDB, mock := setupMockDB(t)
 		repo := repository.NewLedgerRepository(gormDB, cb, r, false)
@@ -235,7 +272,7 @@ func TestLedgerRepository_Get(t *testing.T) {
 		l := ledgers[0]
 		assert.Equal(t, int64(42), l.Id)
 		assert.Equal(t, int64(100), l.UserId)
-		assert.Equal(t, "transfer", l.TransactionType)
+		assert.Equal(t, model.TransactionTypeTransfer, l.TransactionType)
 		assert.Equal(t, "USDC", l.Token)
 		assert.True(t, amount.Equal(l.Amount))
 		assert.Equal(t, now, l.CreatedAt)
@@ -255,9 +292,9 @@ func TestLedgerRepository_Insert(t *testing.T) {
 
 		mock.ExpectBegin()
 		mock.ExpectQuery(regexp.QuoteMeta(
-			`INSERT INTO "main"."ledgers" ("user_id","transaction_type","token","amount","created_at","id") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id"`,
+			`INSERT INTO "main"."ledgers" ("user_id","transaction_type","token","amount","created_at","created_by","id") VALUES ($1,$2,$3,$4,$5,$6,$7) ON CONFLICT ("id") DO NOTHING RETURNING "id"`,
 		)).
-			WithArgs(int64(10), "deposit", "ETH", amt, now, int64(1)).
+			WithArgs(int64(10), "deposit", "ETH", amt, now, "", int64(1)).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 		mock.ExpectCommit()
 
@@ -273,6 +310,20 @@ func TestLedgerRepository_Insert(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("an existing id is treated as already applied", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		repo := repository.NewLedgerRepository(gormDB, cb, r, false)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "main"."ledgers"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectCommit()
+
+		err := repo.Insert(ctx, &model.Ledger{Id: 1, UserId: 10, TransactionType: "deposit", Token: "ETH", Amount: amt, CreatedAt: now})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("insert error is propagated", func(t *testing.T) {
 		gormDB, mock := setupMockDB(t)
 		repo := repository.NewLedgerRepository(gormDB, cb, r, false)
@@ -281,7 +332,7 @@ func TestLedgerRepository_Insert(t *testing.T) {
 		mock.ExpectQuery(regexp.QuoteMeta(
 			`INSERT INTO "main"."ledgers"`,
 		)).
-			WillReturnError(errors.New("duplicate key"))
+			WillReturnError(errors.New("connection reset"))
 		mock.ExpectRollback()
 
 		err := repo.Insert(ctx, &model.Ledger{
@@ -292,7 +343,7 @@ func TestLedgerRepository_Insert(t *testing.T) {
 			Amount:          amt,
 			CreatedAt:       now,
 		})
-		assert.ErrorContains(t, err, "duplicate key")
+		assert.ErrorContains(t, err, "connection reset")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
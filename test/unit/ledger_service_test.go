@@ -8,6 +8,7 @@ import (
 	"github.com/jt828/go-grpc-template/internal/repository"
 	"github.com/jt828/go-grpc-template/internal/service"
 	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -50,6 +51,7 @@ func TestLedgerService_GetLedgers(t *testing.T) {
 
 		svc := service.NewLedgerService(
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
+			&mockTracer{},
 		)
 
 		ledgers, err := svc.GetLedgers(ctx, service.GetParams{UserIdEq: 10, TokenEq: "ETH"})
@@ -74,6 +76,7 @@ func TestLedgerService_GetLedgers(t *testing.T) {
 
 		svc := service.NewLedgerService(
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
+			&mockTracer{},
 		)
 
 		svc.GetLedgers(ctx, service.GetParams{
@@ -85,7 +88,7 @@ func TestLedgerService_GetLedgers(t *testing.T) {
 
 		assert.Equal(t, int64(42), capturedQuery.IdEq)
 		assert.Equal(t, int64(10), capturedQuery.UserIdEq)
-		assert.Equal(t, "deposit", capturedQuery.TransactionTypeEq)
+		assert.Equal(t, model.TransactionTypeDeposit, capturedQuery.TransactionTypeEq)
 		assert.Equal(t, "USDC", capturedQuery.TokenEq)
 	})
 
@@ -102,6 +105,7 @@ func TestLedgerService_GetLedgers(t *testing.T) {
 
 		svc := service.NewLedgerService(
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
+			&mockTracer{},
 		)
 
 		ledgers, err := svc.GetLedgers(ctx, service.GetParams{})
@@ -114,6 +118,7 @@ func TestLedgerService_GetLedgers(t *testing.T) {
 
 		svc := service.NewLedgerService(
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return nil, factoryErr }},
+			&mockTracer{},
 		)
 
 		ledgers, err := svc.GetLedgers(ctx, service.GetParams{})
@@ -137,6 +142,7 @@ func TestLedgerService_GetLedgers(t *testing.T) {
 
 		svc := service.NewLedgerService(
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
+			&mockTracer{},
 		)
 
 		ledgers, err := svc.GetLedgers(ctx, service.GetParams{})
@@ -160,10 +166,114 @@ func TestLedgerService_GetLedgers(t *testing.T) {
 
 		svc := service.NewLedgerService(
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
+			&mockTracer{},
 		)
 
 		ledgers, err := svc.GetLedgers(ctx, service.GetParams{})
 		assert.Nil(t, ledgers)
 		assert.ErrorIs(t, err, commitErr)
 	})
+
+	t.Run("span carries filters and records errors", func(t *testing.T) {
+		tracer := &mockTracer{}
+		repoErr := errors.New("db error")
+
+		uow := &mockUnitOfWork{
+			ledgerRepo: &mockLedgerRepository{
+				getFunc: func(ctx context.Context, query repository.GetQuery) ([]*model.Ledger, error) {
+					return nil, repoErr
+				},
+			},
+			commitFunc: func(ctx context.Context) error { return nil },
+			abortFunc:  func(ctx context.Context) error { return nil },
+		}
+
+		svc := service.NewLedgerService(
+			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
+			tracer,
+		)
+
+		_, err := svc.GetLedgers(ctx, service.GetParams{UserIdEq: 10, TokenEq: "ETH"})
+		require.Error(t, err)
+		require.Len(t, tracer.spans, 1)
+		assert.Equal(t, "LedgerService.GetLedgers", tracer.spans[0].name)
+		assert.Contains(t, tracer.spans[0].attributes, observability.Int64("filter.user_id", 10))
+		assert.Contains(t, tracer.spans[0].attributes, observability.String("filter.token", "ETH"))
+		assert.Equal(t, []error{repoErr}, tracer.spans[0].errors)
+	})
+}
+
+func TestLedgerService_GetLedgersWithUsers(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("fetches distinct owners in one batch", func(t *testing.T) {
+		batches := 0
+		var gotIds []int64
+		var gotProjection repository.Projection
+		uow := &mockUnitOfWork{
+			ledgerRepo: &mockLedgerRepository{
+				getFunc: func(ctx context.Context, query repository.GetQuery) ([]*model.Ledger, error) {
+					return []*model.Ledger{
+						{Id: 1, UserId: 10},
+						{Id: 2, UserId: 20},
+						{Id: 3, UserId: 10},
+						{Id: 4, UserId: 30},
+					}, nil
+				},
+			},
+			userRepo: &mockUserRepository{
+				getByIdsFunc: func(ctx context.Context, ids []int64, projection repository.Projection) ([]*model.User, error) {
+					batches++
+					gotIds, gotProjection = ids, projection
+					return []*model.User{{Id: 10, Username: "alice"}, {Id: 20, Username: "bob"}}, nil
+				},
+			},
+			commitFunc: func(ctx context.Context) error { return nil },
+			abortFunc:  func(ctx context.Context) error { return nil },
+		}
+
+		svc := service.NewLedgerService(
+			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
+			&mockTracer{},
+		)
+
+		entries, err := svc.GetLedgersWithUsers(ctx, service.GetParams{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, batches)
+		assert.Equal(t, []int64{10, 20, 30}, gotIds)
+		assert.Equal(t, repository.UserSummaryProjection, gotProjection)
+		require.Len(t, entries, 4)
+		assert.Equal(t, "alice", entries[0].User.Username)
+		assert.Equal(t, "bob", entries[1].User.Username)
+		assert.Same(t, entries[0].User, entries[2].User)
+		assert.Nil(t, entries[3].User)
+	})
+
+	t.Run("user lookup error aborts", func(t *testing.T) {
+		userErr := errors.New("db error")
+		aborted := false
+		uow := &mockUnitOfWork{
+			ledgerRepo: &mockLedgerRepository{
+				getFunc: func(ctx context.Context, query repository.GetQuery) ([]*model.Ledger, error) {
+					return []*model.Ledger{{Id: 1, UserId: 10}}, nil
+				},
+			},
+			userRepo: &mockUserRepository{
+				getByIdsFunc: func(ctx context.Context, ids []int64, projection repository.Projection) ([]*model.User, error) {
+					return nil, userErr
+				},
+			},
+			commitFunc: func(ctx context.Context) error { t.Fatal("commit should not be called"); return nil },
+			abortFunc:  func(ctx context.Context) error { aborted = true; return nil },
+		}
+
+		svc := service.NewLedgerService(
+			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
+			&mockTracer{},
+		)
+
+		_, err := svc.GetLedgersWithUsers(ctx, service.GetParams{})
+		assert.ErrorIs(t, err, userErr)
+		assert.True(t, aborted)
+	})
 }
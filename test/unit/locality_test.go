@@ -0,0 +1,91 @@
+package unit
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/locality"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// countingServer is a health server counting the checks it answers.
+type countingServer struct {
+	addr   string
+	calls  atomic.Int32
+	server *grpc.Server
+}
+
+func startCountingServer(t *testing.T) *countingServer {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &countingServer{addr: lis.Addr().String()}
+	s.server = grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		s.calls.Add(1)
+		return handler(ctx, req)
+	}))
+	grpc_health_v1.RegisterHealthServer(s.server, health.NewServer())
+	go s.server.Serve(lis)
+	t.Cleanup(s.server.Stop)
+	return s
+}
+
+func TestLocalityClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	local := locality.Locality{Region: "eu-west-1", Zone: "eu-west-1a"}
+
+	sameZone, sameRegion, remote := startCountingServer(t), startCountingServer(t), startCountingServer(t)
+	conn, err := locality.NewClient(local, []locality.Target{
+		{Addr: remote.addr, Locality: locality.Locality{Region: "us-east-1", Zone: "us-east-1a"}},
+		{Addr: sameRegion.addr, Locality: locality.Locality{Region: "eu-west-1", Zone: "eu-west-1b"}},
+		{Addr: sameZone.addr, Locality: local},
+	}, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := grpc_health_v1.NewHealthClient(conn)
+
+	// check waits until the closest ready server is the one expected, since
+	// the others may become ready first, then makes ten calls.
+	check := func(t *testing.T, want *countingServer) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			before := want.calls.Load()
+			_, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{}, grpc.WaitForReady(true))
+			return err == nil && want.calls.Load() > before
+		}, 5*time.Second, 10*time.Millisecond)
+		before := want.calls.Load()
+		for range 10 {
+			_, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{}, grpc.WaitForReady(true))
+			require.NoError(t, err)
+		}
+		assert.Equal(t, before+10, want.calls.Load())
+	}
+
+	t.Run("calls go to the same zone", func(t *testing.T) {
+		check(t, sameZone)
+	})
+
+	t.Run("then to the same region", func(t *testing.T) {
+		sameZone.server.Stop()
+		check(t, sameRegion)
+	})
+
+	t.Run("then anywhere", func(t *testing.T) {
+		sameRegion.server.Stop()
+		check(t, remote)
+	})
+
+	t.Run("needs a target", func(t *testing.T) {
+		_, err := locality.NewClient(local, nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,82 @@
+package unit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginFailureRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	columns := []string{"user_id", "ip", "failures", "locked_until", "last_failed_at"}
+
+	t.Run("get returns nil when nothing is recorded", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewLoginFailureRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."login_failures" WHERE user_id = $1 AND ip = $2 LIMIT $3`)).
+			WithArgs(int64(1), "10.0.0.1", 1).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		failure, err := repo.Get(ctx, 1, "10.0.0.1")
+		require.NoError(t, err)
+		assert.Nil(t, failure)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("fail upserts the count and returns the row", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewLoginFailureRepository(db, &passthroughCB{}, &passthroughRetry{})
+		resetBefore := now.Add(-time.Hour)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "main"."login_failures" ("user_id","ip","failures","locked_until","last_failed_at") VALUES ($1,$2,$3,$4,$5) ON CONFLICT ("user_id","ip") DO UPDATE SET "failures"=CASE WHEN login_failures.last_failed_at < $6 THEN 1 ELSE login_failures.failures + 1 END,"last_failed_at"=excluded.last_failed_at RETURNING *`)).
+			WithArgs(int64(1), "10.0.0.1", 1, nil, now, resetBefore).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(1), "10.0.0.1", 4, nil, now))
+		mock.ExpectCommit()
+
+		failure, err := repo.Fail(ctx, 1, "10.0.0.1", now, resetBefore)
+		require.NoError(t, err)
+		assert.Equal(t, 4, failure.Failures)
+		assert.Nil(t, failure.LockedUntil)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("lock sets locked_until", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewLoginFailureRepository(db, &passthroughCB{}, &passthroughRetry{})
+		until := now.Add(time.Minute)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "main"."login_failures" SET "locked_until"=$1 WHERE user_id = $2 AND ip = $3`)).
+			WithArgs(until, int64(1), "10.0.0.1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.Lock(ctx, 1, "10.0.0.1", until))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("clear user deletes every ip and counts them", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewLoginFailureRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "main"."login_failures" WHERE user_id = $1`)).
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+
+		cleared, err := repo.ClearUser(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), cleared)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
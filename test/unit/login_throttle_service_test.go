@@ -0,0 +1,233 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLoginFailureRepository struct {
+	getFunc       func(ctx context.Context, userId int64, ip string) (*model.LoginFailure, error)
+	failFunc      func(ctx context.Context, userId int64, ip string, failedAt time.Time, resetBefore time.Time) (*model.LoginFailure, error)
+	lockFunc      func(ctx context.Context, userId int64, ip string, until time.Time) error
+	clearFunc     func(ctx context.Context, userId int64, ip string) error
+	clearUserFunc func(ctx context.Context, userId int64) (int64, error)
+}
+
+func (m *mockLoginFailureRepository) Get(ctx context.Context, userId int64, ip string) (*model.LoginFailure, error) {
+	return m.getFunc(ctx, userId, ip)
+}
+
+func (m *mockLoginFailureRepository) Fail(ctx context.Context, userId int64, ip string, failedAt time.Time, resetBefore time.Time) (*model.LoginFailure, error) {
+	return m.failFunc(ctx, userId, ip, failedAt, resetBefore)
+}
+
+func (m *mockLoginFailureRepository) Lock(ctx context.Context, userId int64, ip string, until time.Time) error {
+	return m.lockFunc(ctx, userId, ip, until)
+}
+
+func (m *mockLoginFailureRepository) Clear(ctx context.Context, userId int64, ip string) error {
+	return m.clearFunc(ctx, userId, ip)
+}
+
+func (m *mockLoginFailureRepository) ClearUser(ctx context.Context, userId int64) (int64, error) {
+	return m.clearUserFunc(ctx, userId)
+}
+
+func loginFailureFactory(repo repository.LoginFailureRepository, committed, aborted *int) repository.UnitOfWorkFactory {
+	return &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) {
+		return &mockUnitOfWork{
+			loginFailureRepo: repo,
+			commitFunc:       func(ctx context.Context) error { *committed++; return nil },
+			abortFunc:        func(ctx context.Context) error { *aborted++; return nil },
+		}, nil
+	}}
+}
+
+func TestLoginThrottleService(t *testing.T) {
+	ctx := context.Background()
+	lockout := service.LoginLockout{MaxFailures: 3, LockoutBase: time.Minute, LockoutMax: 5 * time.Minute, FailureReset: time.Hour}
+
+	t.Run("check allows users without a current lockout", func(t *testing.T) {
+		expired := time.Now().Add(-time.Second)
+		for _, failure := range []*model.LoginFailure{nil, {Failures: 2}, {Failures: 3, LockedUntil: &expired}} {
+			var committed, aborted int
+			repo := &mockLoginFailureRepository{getFunc: func(ctx context.Context, userId int64, ip string) (*model.LoginFailure, error) {
+				return failure, nil
+			}}
+			svc := service.NewLoginThrottleService(loginFailureFactory(repo, &committed, &aborted), lockout, &mockMeter{}, &mockLogger{})
+
+			assert.NoError(t, svc.Check(ctx, 1, "10.0.0.1"))
+			assert.Equal(t, 1, committed)
+		}
+	})
+
+	t.Run("check rejects a locked out user with the time left", func(t *testing.T) {
+		var committed, aborted int
+		until := time.Now().Add(90 * time.Second)
+		repo := &mockLoginFailureRepository{getFunc: func(ctx context.Context, userId int64, ip string) (*model.LoginFailure, error) {
+			assert.Equal(t, int64(1), userId)
+			assert.Equal(t, "10.0.0.1", ip)
+			return &model.LoginFailure{UserId: userId, Ip: ip, Failures: 3, LockedUntil: &until}, nil
+		}}
+		svc := service.NewLoginThrottleService(loginFailureFactory(repo, &committed, &aborted), lockout, &mockMeter{}, &mockLogger{})
+
+		err := svc.Check(ctx, 1, "10.0.0.1")
+		assert.ErrorIs(t, err, apperror.ErrResourceExhausted)
+		var retryErr *apperror.RetryAfterError
+		require.ErrorAs(t, err, &retryErr)
+		assert.Equal(t, 90*time.Second, retryErr.RetryAfter)
+	})
+
+	t.Run("failures lock out at the threshold with doubling, capped windows", func(t *testing.T) {
+		var committed, aborted int
+		failures := 0
+		var locks []time.Duration
+		repo := &mockLoginFailureRepository{
+			failFunc: func(ctx context.Context, userId int64, ip string, failedAt time.Time, resetBefore time.Time) (*model.LoginFailure, error) {
+				assert.Equal(t, time.Hour, failedAt.Sub(resetBefore))
+				failures++
+				return &model.LoginFailure{UserId: userId, Ip: ip, Failures: failures, LastFailedAt: failedAt}, nil
+			},
+			lockFunc: func(ctx context.Context, userId int64, ip string, until time.Time) error {
+				locks = append(locks, time.Until(until).Round(time.Minute))
+				return nil
+			},
+		}
+		meter := &mockMeter{}
+		svc := service.NewLoginThrottleService(loginFailureFactory(repo, &committed, &aborted), lockout, meter, &mockLogger{})
+
+		for range 7 {
+			require.NoError(t, svc.RecordFailure(ctx, 1, "10.0.0.1"))
+		}
+		assert.Equal(t, []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}, locks)
+		assert.Equal(t, 7, committed)
+		assert.Equal(t, 7, meter.metrics["login_failures_total"].observations[""])
+		assert.Equal(t, 5, meter.metrics["login_lockouts_total"].observations[""])
+	})
+
+	t.Run("failed write aborts and is not counted", func(t *testing.T) {
+		var committed, aborted int
+		boom := errors.New("boom")
+		repo := &mockLoginFailureRepository{failFunc: func(ctx context.Context, userId int64, ip string, failedAt time.Time, resetBefore time.Time) (*model.LoginFailure, error) {
+			return nil, boom
+		}}
+		meter := &mockMeter{}
+		svc := service.NewLoginThrottleService(loginFailureFactory(repo, &committed, &aborted), lockout, meter, &mockLogger{})
+
+		assert.ErrorIs(t, svc.RecordFailure(ctx, 1, "10.0.0.1"), boom)
+		assert.Equal(t, 1, aborted)
+		assert.Zero(t, meter.metrics["login_failures_total"].observations[""])
+	})
+
+	t.Run("success clears the failures from that ip", func(t *testing.T) {
+		var committed, aborted int
+		var cleared []string
+		repo := &mockLoginFailureRepository{clearFunc: func(ctx context.Context, userId int64, ip string) error {
+			cleared = append(cleared, ip)
+			return nil
+		}}
+		svc := service.NewLoginThrottleService(loginFailureFactory(repo, &committed, &aborted), lockout, &mockMeter{}, &mockLogger{})
+
+		require.NoError(t, svc.RecordSuccess(ctx, 1, "10.0.0.1"))
+		assert.Equal(t, []string{"10.0.0.1"}, cleared)
+		assert.Equal(t, 1, committed)
+	})
+
+	t.Run("unlock clears the user from every ip", func(t *testing.T) {
+		var committed, aborted int
+		repo := &mockLoginFailureRepository{clearUserFunc: func(ctx context.Context, userId int64) (int64, error) {
+			assert.Equal(t, int64(1), userId)
+			return 2, nil
+		}}
+		svc := service.NewLoginThrottleService(loginFailureFactory(repo, &committed, &aborted), lockout, &mockMeter{}, &mockLogger{})
+
+		cleared, err := svc.Unlock(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), cleared)
+		assert.Equal(t, 1, committed)
+	})
+
+	accountLockout := lockout
+	accountLockout.AccountMaxFailures, accountLockout.AccountLockout = 4, 15*time.Minute
+
+	t.Run("failures from every ip lock the account", func(t *testing.T) {
+		var committed, aborted int
+		failures := map[string]int{}
+		locks := map[string]time.Duration{}
+		repo := &mockLoginFailureRepository{
+			failFunc: func(ctx context.Context, userId int64, ip string, failedAt time.Time, resetBefore time.Time) (*model.LoginFailure, error) {
+				failures[ip]++
+				return &model.LoginFailure{UserId: userId, Ip: ip, Failures: failures[ip], LastFailedAt: failedAt}, nil
+			},
+			lockFunc: func(ctx context.Context, userId int64, ip string, until time.Time) error {
+				locks[ip] = time.Until(until).Round(time.Minute)
+				return nil
+			},
+		}
+		meter := &mockMeter{}
+		svc := service.NewLoginThrottleService(loginFailureFactory(repo, &committed, &aborted), accountLockout, meter, &mockLogger{})
+
+		for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"} {
+			require.NoError(t, svc.RecordFailure(ctx, 1, ip))
+		}
+		assert.Equal(t, map[string]time.Duration{"*": 15 * time.Minute}, locks, "no single ip reached its threshold")
+		assert.Equal(t, 1, meter.metrics["login_account_lockouts_total"].observations[""])
+		assert.Zero(t, meter.metrics["login_lockouts_total"].observations[""])
+	})
+
+	t.Run("check rejects a locked account with permission denied", func(t *testing.T) {
+		var committed, aborted int
+		until := time.Now().Add(10 * time.Minute)
+		ipUntil := time.Now().Add(time.Minute)
+		repo := &mockLoginFailureRepository{getFunc: func(ctx context.Context, userId int64, ip string) (*model.LoginFailure, error) {
+			if ip == "*" {
+				return &model.LoginFailure{UserId: userId, Ip: ip, Failures: 4, LockedUntil: &until}, nil
+			}
+			return &model.LoginFailure{UserId: userId, Ip: ip, Failures: 3, LockedUntil: &ipUntil}, nil
+		}}
+		meter := &mockMeter{}
+		svc := service.NewLoginThrottleService(loginFailureFactory(repo, &committed, &aborted), accountLockout, meter, &mockLogger{})
+
+		err := svc.Check(ctx, 1, "10.0.0.1")
+		assert.ErrorIs(t, err, apperror.ErrPermissionDenied)
+		assert.True(t, apperror.HasReason(err, "ACCOUNT_LOCKED"))
+		var retryErr *apperror.RetryAfterError
+		require.ErrorAs(t, err, &retryErr)
+		assert.Equal(t, 10*time.Minute, retryErr.RetryAfter)
+		assert.Equal(t, 1, meter.metrics["login_lockout_rejections_total"].observations["account"])
+	})
+
+	t.Run("success and unlock clear the account count", func(t *testing.T) {
+		var committed, aborted int
+		var cleared []string
+		repo := &mockLoginFailureRepository{
+			getFunc: func(ctx context.Context, userId int64, ip string) (*model.LoginFailure, error) {
+				return &model.LoginFailure{UserId: userId, Ip: ip, Failures: 4}, nil
+			},
+			clearFunc: func(ctx context.Context, userId int64, ip string) error {
+				cleared = append(cleared, ip)
+				return nil
+			},
+			clearUserFunc: func(ctx context.Context, userId int64) (int64, error) {
+				return 3, nil
+			},
+		}
+		svc := service.NewLoginThrottleService(loginFailureFactory(repo, &committed, &aborted), accountLockout, &mockMeter{}, &mockLogger{})
+
+		require.NoError(t, svc.RecordSuccess(ctx, 1, "10.0.0.1"))
+		assert.Equal(t, []string{"10.0.0.1", "*"}, cleared)
+
+		ips, err := svc.Unlock(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), ips, "the account count is not an ip")
+	})
+}
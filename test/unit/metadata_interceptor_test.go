@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestMetadataInterceptors(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/proto.v1.UserService/GetUserById"}
+	newInterceptors := func(maxBytes int) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor, *mockMeter) {
+		meter := &mockMeter{}
+		unary, stream := interceptor.MetadataInterceptors(maxBytes, []string{"x-forwarded-for", "grpcgateway-*"}, meter)
+		return unary, stream, meter
+	}
+	// call runs unary with md and returns the metadata the handler saw.
+	call := func(unary grpc.UnaryServerInterceptor, md metadata.MD) (metadata.MD, error) {
+		var seen metadata.MD
+		_, err := unary(metadata.NewIncomingContext(context.Background(), md), nil, info, func(ctx context.Context, req any) (any, error) {
+			seen, _ = metadata.FromIncomingContext(ctx)
+			return nil, nil
+		})
+		return seen, err
+	}
+
+	t.Run("keeps allowed keys and strips the rest", func(t *testing.T) {
+		unary, _, meter := newInterceptors(0)
+
+		seen, err := call(unary, metadata.MD{
+			"x-request-id":         {" abc "},
+			"traceparent":          {"00-trace"},
+			"x-b3-traceid":         {"b3"},
+			"x-forwarded-for":      {"10.0.0.1"},
+			"grpcgateway-accept":   {"application/json"},
+			"x-internal-caller":    {"admin"},
+			"x-envoy-original-url": {"/"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, metadata.MD{
+			"x-request-id":       {"abc"},
+			"traceparent":        {"00-trace"},
+			"x-b3-traceid":       {"b3"},
+			"x-forwarded-for":    {"10.0.0.1"},
+			"grpcgateway-accept": {"application/json"},
+		}, seen)
+		assert.Equal(t, 2, meter.metrics["grpc_metadata_violations_total"].observations["unknown_key"])
+	})
+
+	t.Run("matches keys in any case and drops values with control characters", func(t *testing.T) {
+		unary, _, meter := newInterceptors(0)
+
+		seen, err := call(unary, metadata.MD{
+			"X-Forwarded-For": {"10.0.0.1", "10.0.0.2\r\nx-internal-caller: admin", "10.0.0.3"},
+			"tracestate":      {"a=1\x7f"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.1", "10.0.0.3"}, seen["x-forwarded-for"])
+		assert.NotContains(t, seen, "tracestate")
+		assert.Equal(t, 2, meter.metrics["grpc_metadata_violations_total"].observations["invalid_value"])
+	})
+
+	t.Run("binary values are kept as they are", func(t *testing.T) {
+		unary, _ := interceptor.MetadataInterceptors(0, []string{"grpc-trace-bin"}, &mockMeter{})
+
+		seen, err := call(unary, metadata.MD{"grpc-trace-bin": {"\x00\x01 "}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"\x00\x01 "}, seen["grpc-trace-bin"])
+	})
+
+	t.Run("rejects metadata over the size limit", func(t *testing.T) {
+		unary, _, meter := newInterceptors(64)
+
+		_, err := call(unary, metadata.MD{"x-request-id": {strings.Repeat("a", 32)}})
+		require.NoError(t, err)
+
+		// Stripped keys count too, so they cannot be used to pad requests.
+		_, err = call(unary, metadata.MD{"x-internal-padding": {strings.Repeat("a", 64)}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.True(t, apperror.HasReason(err, "METADATA_TOO_LARGE"))
+		assert.Equal(t, 1, meter.metrics["grpc_metadata_violations_total"].observations["too_large"])
+	})
+
+	t.Run("rejects repeated credentials", func(t *testing.T) {
+		unary, _, meter := newInterceptors(0)
+
+		_, err := call(unary, metadata.MD{"Authorization": {"Bearer first", "Bearer second"}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		reason, details := apperror.ReasonOf(err)
+		assert.Equal(t, "METADATA_REPEATED_KEY", reason)
+		assert.Equal(t, map[string]string{"key": "authorization"}, details)
+		assert.Equal(t, 1, meter.metrics["grpc_metadata_violations_total"].observations["repeated_key"])
+	})
+
+	t.Run("streams see the guarded metadata", func(t *testing.T) {
+		_, stream, _ := newInterceptors(0)
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+			"x-request-id":      {"abc"},
+			"x-internal-caller": {"admin"},
+		})
+
+		var seen metadata.MD
+		err := stream(nil, &trailerServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/proto.v1.LedgerService/ExportLedgers"}, func(srv any, ss grpc.ServerStream) error {
+			seen, _ = metadata.FromIncomingContext(ss.Context())
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, metadata.MD{"x-request-id": {"abc"}}, seen)
+
+		ctx = metadata.NewIncomingContext(context.Background(), metadata.MD{"x-request-id": {"a", "b"}})
+		err = stream(nil, &trailerServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/proto.v1.LedgerService/ExportLedgers"}, func(srv any, ss grpc.ServerStream) error {
+			t.Fatal("handler ran")
+			return nil
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,114 @@
+package unit
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jt828/go-grpc-template/internal/migrationcheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationCheck_Load(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000002_drop_legacy_name.up.sql":   {Data: []byte("-- phase: contract\nALTER TABLE users DROP COLUMN legacy_name;\n")},
+		"000002_drop_legacy_name.down.sql": {Data: []byte("ALTER TABLE users ADD COLUMN legacy_name TEXT;\n")},
+		"000001_add_name.up.sql":           {Data: []byte("ALTER TABLE users ADD COLUMN name TEXT;\n")},
+		"README.md":                        {Data: []byte("not a migration")},
+	}
+	migrations, err := migrationcheck.Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, uint(1), migrations[0].Version)
+	assert.Equal(t, "add_name", migrations[0].Name)
+	assert.Equal(t, migrationcheck.PhaseExpand, migrations[0].Phase, "no marker is expand")
+	assert.Equal(t, migrationcheck.PhaseContract, migrations[1].Phase)
+
+	_, err = migrationcheck.Load(fstest.MapFS{"000001_x.up.sql": {Data: []byte("-- phase: later\n")}})
+	assert.ErrorContains(t, err, `unknown phase "later"`)
+}
+
+func TestMigrationCheck_Plan(t *testing.T) {
+	migrations := []migrationcheck.Migration{
+		{Version: 1, Phase: migrationcheck.PhaseExpand},
+		{Version: 2, Phase: migrationcheck.PhaseExpand},
+		{Version: 3, Phase: migrationcheck.PhaseContract},
+		{Version: 4, Phase: migrationcheck.PhaseContract},
+		{Version: 5, Phase: migrationcheck.PhaseExpand},
+	}
+	versions := func(ms []migrationcheck.Migration) []uint {
+		var vs []uint
+		for _, m := range ms {
+			vs = append(vs, m.Version)
+		}
+		return vs
+	}
+
+	t.Run("expand stops before the first contract migration", func(t *testing.T) {
+		plan, err := migrationcheck.Plan(migrations, -1, migrationcheck.PhaseExpand)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{1, 2}, versions(plan))
+
+		plan, err = migrationcheck.Plan(migrations, 2, migrationcheck.PhaseExpand)
+		require.NoError(t, err)
+		assert.Empty(t, plan)
+	})
+
+	t.Run("contract applies only the contract migrations at the head", func(t *testing.T) {
+		plan, err := migrationcheck.Plan(migrations, 2, migrationcheck.PhaseContract)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{3, 4}, versions(plan))
+
+		plan, err = migrationcheck.Plan(migrations, 5, migrationcheck.PhaseContract)
+		require.NoError(t, err)
+		assert.Empty(t, plan)
+	})
+
+	t.Run("contract before the expand migrations are applied is out of order", func(t *testing.T) {
+		_, err := migrationcheck.Plan(migrations, 0, migrationcheck.PhaseContract)
+		assert.ErrorIs(t, err, migrationcheck.ErrOutOfOrder)
+	})
+}
+
+func TestMigrationCheck_Check(t *testing.T) {
+	reasons := func(sql string) []string {
+		var rs []string
+		for _, v := range migrationcheck.Check(migrationcheck.Migration{Version: 1, Name: "m", SQL: sql}) {
+			rs = append(rs, v.Reason)
+		}
+		return rs
+	}
+
+	assert.Equal(t, []string{"drops a column"}, reasons("alter table users drop column name;"))
+	assert.Equal(t, []string{"drops a table"}, reasons("DROP TABLE IF EXISTS users;"))
+	assert.Equal(t, []string{"renames a table or column"}, reasons("ALTER TABLE users RENAME COLUMN name TO full_name;"))
+	assert.Equal(t, []string{"changes a column type"}, reasons("ALTER TABLE users ALTER COLUMN name TYPE TEXT;"))
+	assert.Equal(t, []string{"makes an existing column NOT NULL"}, reasons("ALTER TABLE users\n  ALTER COLUMN name SET NOT NULL;"))
+	assert.Equal(t, []string{"adds a NOT NULL column without a default"},
+		reasons("ALTER TABLE users ADD COLUMN a TEXT NOT NULL DEFAULT '', ADD COLUMN b TEXT NOT NULL;"))
+
+	for _, safe := range []string{
+		"ALTER TABLE users ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'active';",
+		"CREATE TABLE t (id BIGINT NOT NULL, name TEXT NOT NULL);",
+		"ALTER TABLE ledgers ADD CONSTRAINT c CHECK (amount IS NOT NULL) NOT VALID;",
+		"-- DROP COLUMN legacy is left for a later release\nALTER TABLE users ADD COLUMN note TEXT;",
+	} {
+		assert.Empty(t, reasons(safe), safe)
+	}
+
+	v := migrationcheck.Check(migrationcheck.Migration{Version: 7, Name: "drop_name", SQL: "ALTER TABLE users\n\tDROP COLUMN name;"})
+	require.Len(t, v, 1)
+	assert.Equal(t, "7_drop_name: drops a column: ALTER TABLE users DROP COLUMN name", v[0].String())
+}
+
+func TestMigrationCheck_RepoMigrationsAreSafe(t *testing.T) {
+	for _, dir := range []string{"../../migrations", "../../migrations/idempotency", "../../migrations/ledger"} {
+		migrations, err := migrationcheck.Load(os.DirFS(dir))
+		require.NoError(t, err, dir)
+		require.NotEmpty(t, migrations, dir)
+		for _, m := range migrations {
+			assert.Empty(t, migrationcheck.Check(m), "%s/%d_%s", dir, m.Version, m.Name)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/observability/noop"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoopObservability(t *testing.T) {
+	obs := noop.NewNoopObservability()
+	ctx := context.Background()
+	require.NoError(t, obs.Start(ctx))
+	require.NoError(t, obs.Check(ctx))
+	defer func() { assert.NoError(t, obs.Close(ctx)) }()
+
+	t.Run("metrics may be registered twice", func(t *testing.T) {
+		meter := obs.Meter()
+		for range 2 {
+			meter.Counter("requests_total").Inc(1, observability.Label{Key: "method", Value: "a"})
+			meter.Histogram("latency_seconds", observability.MetricOpt{Preset: "unknown"}).Observe(1)
+			meter.Gauge("depth").Set(3)
+			meter.Timer("op_seconds").Start()()
+		}
+	})
+
+	t.Run("tracer keeps the context", func(t *testing.T) {
+		type key struct{}
+		parent := context.WithValue(ctx, key{}, "v")
+		spanCtx, span := obs.Tracer().Start(parent, "op")
+		span.SetAttributes(observability.String("k", "v"))
+		span.RecordError(assert.AnError)
+		span.End()
+		assert.Equal(t, "v", spanCtx.Value(key{}))
+	})
+
+	t.Run("services run with it", func(t *testing.T) {
+		repo := &mockSchemaRepository{
+			tableStatsFunc: func(ctx context.Context, schemaName string, tables []string) ([]*model.TableStats, error) {
+				return []*model.TableStats{{Table: "ledgers", SeqScans: 1}}, nil
+			},
+			slowStatementsFunc: func(ctx context.Context, limit int) ([]*model.StatementStats, error) {
+				return nil, nil
+			},
+		}
+		stores := []service.SchemaStore{{Name: "postgresql", Schema: "main", Expected: []model.TableSchema{{Name: "ledgers"}}, Repository: repo}}
+		svc := service.NewTableStatsService(stores, obs.Meter(), obs.Logger().With(observability.String("component", "table_stats")))
+
+		require.NoError(t, svc.Sample(ctx))
+		require.NoError(t, svc.Sample(ctx))
+	})
+}
@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/observability/implementation"
+	"github.com/jt828/go-grpc-template/pkg/observability/noop"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewObservability(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("given components are used instead of the defaults", func(t *testing.T) {
+		log, meter, tracer := &mockLogger{}, &mockMeter{}, noop.NewTracer()
+		obs, err := implementation.NewObservability("svc",
+			implementation.WithLogger(log),
+			implementation.WithMeter(meter),
+			implementation.WithTracer(tracer),
+		)
+		require.NoError(t, err)
+		assert.Same(t, log, obs.Logger())
+		assert.Same(t, meter, obs.Meter())
+		assert.Equal(t, tracer, obs.Tracer())
+
+		// Only Prometheus meters are served, so Start binds nothing.
+		require.NoError(t, obs.Start(ctx))
+		assert.NoError(t, obs.Check(ctx))
+		assert.NoError(t, obs.Close(ctx))
+	})
+
+	t.Run("an unreachable collector does not fail startup", func(t *testing.T) {
+		obs, err := implementation.NewObservability("svc",
+			implementation.WithLogger(&mockLogger{}),
+			implementation.WithMetricsAddress("127.0.0.1:0"),
+			implementation.WithOTLPEndpoint("127.0.0.1:1"),
+		)
+		require.NoError(t, err)
+		_, span := obs.Tracer().Start(ctx, "op")
+		span.End()
+		require.NoError(t, obs.Start(ctx))
+
+		// Spans that cannot be flushed are dropped once Close's context ends.
+		closeCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		obs.Close(closeCtx)
+	})
+
+	t.Run("disabled tracing discards spans", func(t *testing.T) {
+		obs, err := implementation.NewObservability("svc",
+			implementation.WithLogger(&mockLogger{}),
+			implementation.WithDisabledTracing(),
+		)
+		require.NoError(t, err)
+		spanCtx, span := obs.Tracer().Start(ctx, "op")
+		span.End()
+		assert.Equal(t, ctx, spanCtx)
+	})
+
+	t.Run("metrics are served with the configured buckets", func(t *testing.T) {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := lis.Addr().String()
+		require.NoError(t, lis.Close())
+
+		obs, err := implementation.NewObservability("svc",
+			implementation.WithLogger(&mockLogger{}),
+			implementation.WithDisabledTracing(),
+			implementation.WithMetricsAddress(addr),
+			implementation.WithMeterOptions(implementation.WithBucketPresets(map[observability.BucketPreset][]float64{
+				observability.BucketsRPC: {0.5, 5},
+			})),
+		)
+		require.NoError(t, err)
+		obs.Meter().Histogram("op_seconds", observability.MetricOpt{Preset: observability.BucketsRPC}).Observe(1)
+		require.NoError(t, obs.Start(ctx))
+		defer obs.Close(ctx)
+
+		resp, err := http.Get(fmt.Sprintf("http://%s/metrics", addr))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), `op_seconds_bucket{le="5"} 1`)
+	})
+
+	t.Run("the metrics server answers its health check until it stops", func(t *testing.T) {
+		obs, err := implementation.NewObservability("svc",
+			implementation.WithLogger(&mockLogger{}),
+			implementation.WithDisabledTracing(),
+			implementation.WithMetricsAddress("127.0.0.1:0"),
+		)
+		require.NoError(t, err)
+		require.NoError(t, obs.Start(ctx))
+		require.NoError(t, obs.Check(ctx))
+
+		require.NoError(t, obs.Close(ctx))
+		assert.Error(t, obs.Check(ctx))
+	})
+
+	t.Run("a metrics address in use is reported by Start", func(t *testing.T) {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer lis.Close()
+
+		obs, err := implementation.NewObservability("svc",
+			implementation.WithLogger(&mockLogger{}),
+			implementation.WithDisabledTracing(),
+			implementation.WithMetricsAddress(lis.Addr().String()),
+		)
+		require.NoError(t, err)
+		assert.ErrorContains(t, obs.Start(ctx), "metrics server")
+	})
+
+	t.Run("an invalid log configuration is an error", func(t *testing.T) {
+		_, err := implementation.NewObservability("svc", implementation.WithLogConfig(observability.LogConfig{Level: "verbose"}))
+		assert.Error(t, err)
+	})
+}
+
+func TestOtelTracerExport(t *testing.T) {
+	ctx := context.Background()
+	meter, log := &mockMeter{}, &mockLogger{}
+	tracer, shutdown, err := implementation.NewOtelTracer(ctx, "svc", "127.0.0.1:1", observability.TraceExportConfig{
+		MaxQueueSize:  1,
+		MaxBatchSize:  1,
+		BatchTimeout:  time.Hour,
+		ExportTimeout: 100 * time.Millisecond,
+	}, meter, log)
+	require.NoError(t, err)
+
+	for range 3 {
+		_, span := tracer.Start(ctx, "op")
+		span.End()
+	}
+	dropped := meter.metrics["trace_spans_dropped_total"]
+	assert.Equal(t, 2, dropped.observations["queue_full"], "spans beyond the queue are dropped, not blocked on")
+	assert.Equal(t, float64(1), meter.metrics["trace_spans_queued"].values[""])
+
+	// Flushing to an unreachable collector loses the queued span.
+	shutdown(ctx)
+	assert.Equal(t, float64(1), dropped.values["export_failed"])
+	assert.Equal(t, float64(0), meter.metrics["trace_spans_queued"].values[""])
+	assert.Equal(t, []string{"trace export failing, spans are being dropped"}, log.warnCalls)
+}
@@ -0,0 +1,418 @@
+package unit
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	httpclientImpl "github.com/jt828/go-grpc-template/pkg/httpclient/implementation"
+	"github.com/jt828/go-grpc-template/pkg/oidc"
+	oidcImpl "github.com/jt828/go-grpc-template/pkg/oidc/implementation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const oidcAudience = "go-grpc-template"
+
+// oidcProvider serves a discovery document and a key set that tests can
+// rotate, and signs tokens with its current key.
+type oidcProvider struct {
+	server *httptest.Server
+	mu     sync.Mutex
+	kid    string
+	key    *rsa.PrivateKey
+	jwks   []map[string]string
+	// keyFetches counts requests for the key set.
+	keyFetches int
+}
+
+func newOIDCProvider(t *testing.T) *oidcProvider {
+	t.Helper()
+	p := &oidcProvider{}
+	p.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"issuer": p.server.URL, "jwks_uri": p.server.URL + "/keys"})
+		case "/keys":
+			p.keyFetches++
+			json.NewEncoder(w).Encode(map[string]any{"keys": p.jwks})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(p.server.Close)
+	p.rotate(t, "k1")
+	return p
+}
+
+// rotate publishes a new RSA key under kid and signs with it from now on.
+func (p *oidcProvider) rotate(t *testing.T, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.kid, p.key = kid, key
+	p.jwks = append(p.jwks, map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	})
+}
+
+func (p *oidcProvider) fetches() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.keyFetches
+}
+
+// sign returns an RS256 token with claims, defaulting iss, aud, sub and exp
+// to values the verifier accepts.
+func (p *oidcProvider) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	p.mu.Lock()
+	kid, key := p.kid, p.key
+	p.mu.Unlock()
+
+	payload := map[string]any{
+		"iss": p.server.URL,
+		"aud": oidcAudience,
+		"sub": "subject-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range claims {
+		if value == nil {
+			delete(payload, name)
+		} else {
+			payload[name] = value
+		}
+	}
+	signed := encodeSegment(t, map[string]string{"alg": "RS256", "kid": kid}) + "." + encodeSegment(t, payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (p *oidcProvider) verifier(opts ...oidc.Option) oidc.Verifier {
+	return oidcImpl.NewJWKSVerifier(p.server.URL, oidcAudience, httpclientImpl.NewHTTPClient(), &passthroughCB{}, &passthroughRetry{}, opts...)
+}
+
+func encodeSegment(t *testing.T, value any) string {
+	t.Helper()
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestJWKSVerifier(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("accepts a token signed by the issuer", func(t *testing.T) {
+		provider := newOIDCProvider(t)
+		verifier := provider.verifier()
+
+		claims, err := verifier.Verify(ctx, provider.sign(t, map[string]any{
+			"aud":            []string{"other", oidcAudience},
+			"email":          "ada@example.com",
+			"email_verified": true,
+			"sid":            "session-1",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, provider.server.URL, claims.Issuer)
+		assert.Equal(t, "subject-1", claims.Subject)
+		assert.Equal(t, []string{"other", oidcAudience}, claims.Audience)
+		assert.Equal(t, "ada@example.com", claims.Email)
+		assert.True(t, claims.EmailVerified)
+		assert.Equal(t, "session-1", claims.SessionId)
+
+		_, err = verifier.Verify(ctx, provider.sign(t, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 1, provider.fetches(), "keys are cached between tokens")
+	})
+
+	t.Run("rejects tokens failing the claim checks", func(t *testing.T) {
+		provider := newOIDCProvider(t)
+		verifier := provider.verifier()
+
+		for name, claims := range map[string]map[string]any{
+			"other issuer":   {"iss": "https://evil.example.com"},
+			"other audience": {"aud": "other"},
+			"no subject":     {"sub": nil},
+			"no expiry":      {"exp": nil},
+			"expired":        {"exp": time.Now().Add(-2 * time.Minute).Unix()},
+			"not yet valid":  {"nbf": time.Now().Add(2 * time.Minute).Unix()},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := verifier.Verify(ctx, provider.sign(t, claims))
+				assert.ErrorIs(t, err, oidc.ErrInvalidToken)
+			})
+		}
+	})
+
+	t.Run("rejects unsigned and tampered tokens", func(t *testing.T) {
+		provider := newOIDCProvider(t)
+		verifier := provider.verifier()
+		token := provider.sign(t, nil)
+		parts := strings.Split(token, ".")
+
+		unsigned := encodeSegment(t, map[string]string{"alg": "none", "kid": "k1"}) + "." + parts[1] + "."
+		_, err := verifier.Verify(ctx, unsigned)
+		assert.ErrorIs(t, err, oidc.ErrInvalidToken)
+
+		tampered := parts[0] + "." + encodeSegment(t, map[string]any{
+			"iss": provider.server.URL, "aud": oidcAudience, "sub": "admin", "exp": time.Now().Add(time.Hour).Unix(),
+		}) + "." + parts[2]
+		_, err = verifier.Verify(ctx, tampered)
+		assert.ErrorIs(t, err, oidc.ErrInvalidToken)
+
+		_, err = verifier.Verify(ctx, "not-a-token")
+		assert.ErrorIs(t, err, oidc.ErrInvalidToken)
+	})
+
+	t.Run("rotated keys are fetched when a token names an unknown kid", func(t *testing.T) {
+		provider := newOIDCProvider(t)
+		now := time.Now()
+		verifier := provider.verifier(oidc.WithClock(func() time.Time { return now }))
+		_, err := verifier.Verify(ctx, provider.sign(t, nil))
+		require.NoError(t, err)
+
+		provider.rotate(t, "k2")
+		now = now.Add(2 * time.Minute)
+		_, err = verifier.Verify(ctx, provider.sign(t, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 2, provider.fetches())
+	})
+
+	t.Run("unknown kids trigger at most one fetch per min refresh interval", func(t *testing.T) {
+		provider := newOIDCProvider(t)
+		now := time.Now()
+		verifier := provider.verifier(oidc.WithClock(func() time.Time { return now }))
+		_, err := verifier.Verify(ctx, provider.sign(t, nil))
+		require.NoError(t, err)
+
+		forged := func(kid string) string {
+			parts := strings.Split(provider.sign(t, nil), ".")
+			return encodeSegment(t, map[string]string{"alg": "RS256", "kid": kid}) + "." + parts[1] + "." + parts[2]
+		}
+		now = now.Add(2 * time.Minute)
+		for i := range 5 {
+			_, err := verifier.Verify(ctx, forged(fmt.Sprintf("forged-%d", i)))
+			assert.ErrorIs(t, err, oidc.ErrInvalidToken)
+		}
+		assert.Equal(t, 2, provider.fetches())
+	})
+
+	t.Run("cached keys are used while the provider is down", func(t *testing.T) {
+		provider := newOIDCProvider(t)
+		now := time.Now()
+		verifier := provider.verifier(oidc.WithClock(func() time.Time { return now }))
+		token := provider.sign(t, nil)
+		_, err := verifier.Verify(ctx, token)
+		require.NoError(t, err)
+
+		provider.server.Close()
+		now = now.Add(2 * time.Hour)
+		_, err = verifier.Verify(ctx, provider.sign(t, map[string]any{"exp": now.Add(time.Hour).Unix()}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("fetch failures are not reported as invalid tokens", func(t *testing.T) {
+		provider := newOIDCProvider(t)
+		verifier := provider.verifier()
+		token := provider.sign(t, nil)
+		provider.server.Close()
+
+		_, err := verifier.Verify(ctx, token)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, oidc.ErrInvalidToken)
+	})
+
+	t.Run("accepts ES256 tokens", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		provider := newOIDCProvider(t)
+		provider.mu.Lock()
+		provider.jwks = append(provider.jwks, map[string]string{
+			"kty": "EC",
+			"kid": "ec",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		})
+		provider.mu.Unlock()
+
+		signed := encodeSegment(t, map[string]string{"alg": "ES256", "kid": "ec"}) + "." + encodeSegment(t, map[string]any{
+			"iss": provider.server.URL, "aud": oidcAudience, "sub": "subject-2", "exp": time.Now().Add(time.Hour).Unix(),
+		})
+		digest := sha256.Sum256([]byte(signed))
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		require.NoError(t, err)
+		signature := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+
+		claims, err := provider.verifier().Verify(ctx, signed+"."+base64.RawURLEncoding.EncodeToString(signature))
+		require.NoError(t, err)
+		assert.Equal(t, "subject-2", claims.Subject)
+	})
+}
+
+type mockVerifier struct {
+	verifyFunc func(ctx context.Context, rawToken string) (*oidc.Claims, error)
+}
+
+func (m *mockVerifier) Verify(ctx context.Context, rawToken string) (*oidc.Claims, error) {
+	return m.verifyFunc(ctx, rawToken)
+}
+
+type mockLinkedUsers struct {
+	links map[string]int64
+}
+
+func (m *mockLinkedUsers) UserId(ctx context.Context, issuer, subject string) (int64, error) {
+	if userId, ok := m.links[issuer+" "+subject]; ok {
+		return userId, nil
+	}
+	return 0, fmt.Errorf("subject %q is not linked: %w", subject, apperror.ErrUnauthenticated)
+}
+
+type mockSessions struct {
+	revoked map[string]bool
+	touched []string
+}
+
+func (m *mockSessions) Touch(ctx context.Context, userId int64, issuer, sessionId, userAgent, ip string) error {
+	if m.revoked[sessionId] {
+		return apperror.Unauthenticatedf("session %q is not active", sessionId)
+	}
+	m.touched = append(m.touched, fmt.Sprintf("%d %s %s %s %s %s", userId, issuer, sessionId, userAgent, ip, audit.ActorFromContext(ctx)))
+	return nil
+}
+
+func TestOIDCInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/proto.v1.UserService/GetUserById"}
+	verifier := &mockVerifier{verifyFunc: func(ctx context.Context, rawToken string) (*oidc.Claims, error) {
+		switch rawToken {
+		case "good":
+			return &oidc.Claims{Issuer: "https://auth.example.com", Subject: "alice"}, nil
+		case "with-session":
+			return &oidc.Claims{Issuer: "https://auth.example.com", Subject: "alice", SessionId: "sid-1"}, nil
+		case "revoked":
+			return &oidc.Claims{Issuer: "https://auth.example.com", Subject: "alice", SessionId: "sid-0"}, nil
+		case "unlinked":
+			return &oidc.Claims{Issuer: "https://auth.example.com", Subject: "mallory"}, nil
+		case "down":
+			return nil, errors.New("connection refused")
+		}
+		return nil, fmt.Errorf("bad signature: %w", oidc.ErrInvalidToken)
+	}}
+	users := &mockLinkedUsers{links: map[string]int64{"https://auth.example.com alice": 42}}
+	sessions := &mockSessions{revoked: map[string]bool{"sid-0": true}}
+	withToken := func(value string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(interceptor.AuthorizationHeader, value))
+	}
+
+	t.Run("records the linked user as the caller", func(t *testing.T) {
+		i := interceptor.OIDCInterceptor(verifier, users, sessions, &mockMeter{})
+		var caller interceptor.Caller
+		_, err := i(withToken("Bearer good"), nil, info, func(ctx context.Context, req any) (any, error) {
+			caller, _ = interceptor.CallerFromContext(ctx)
+			return nil, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, interceptor.Caller{Kind: interceptor.CallerKindUser, Id: "42"}, caller)
+	})
+
+	t.Run("tokens with a session id touch the session", func(t *testing.T) {
+		sessions := &mockSessions{}
+		i := interceptor.OIDCInterceptor(verifier, users, sessions, &mockMeter{})
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(interceptor.AuthorizationHeader, "Bearer with-session", "user-agent", "curl/8.0"))
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000}})
+
+		_, err := i(ctx, nil, info, func(ctx context.Context, req any) (any, error) { return nil, nil })
+		require.NoError(t, err)
+		assert.Equal(t, []string{"42 https://auth.example.com sid-1 curl/8.0 10.0.0.1 user:42"}, sessions.touched)
+
+		_, err = i(withToken("Bearer good"), nil, info, func(ctx context.Context, req any) (any, error) { return nil, nil })
+		require.NoError(t, err)
+		assert.Len(t, sessions.touched, 1, "tokens without sid are not tracked")
+	})
+
+	t.Run("revoked sessions are unauthenticated", func(t *testing.T) {
+		meter := &mockMeter{}
+		i := interceptor.OIDCInterceptor(verifier, users, sessions, meter)
+		_, err := i(withToken("Bearer revoked"), nil, info, func(ctx context.Context, req any) (any, error) {
+			t.Fatal("handler must not be called")
+			return nil, nil
+		})
+		assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+		assert.Equal(t, 1, meter.metrics["oidc_tokens_rejected_total"].observations["revoked"])
+	})
+
+	t.Run("requests without a bearer token pass through", func(t *testing.T) {
+		i := interceptor.OIDCInterceptor(verifier, users, sessions, &mockMeter{})
+		for _, ctx := range []context.Context{context.Background(), withToken("Basic dXNlcjpwYXNz")} {
+			called := false
+			_, err := i(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+				called = true
+				_, ok := interceptor.CallerFromContext(ctx)
+				assert.False(t, ok)
+				return nil, nil
+			})
+			require.NoError(t, err)
+			assert.True(t, called)
+		}
+	})
+
+	t.Run("invalid and unlinked tokens are unauthenticated", func(t *testing.T) {
+		meter := &mockMeter{}
+		i := interceptor.OIDCInterceptor(verifier, users, sessions, meter)
+		handler := func(ctx context.Context, req any) (any, error) {
+			t.Fatal("handler must not be called")
+			return nil, nil
+		}
+
+		_, err := i(withToken("bearer forged"), nil, info, handler)
+		assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+		_, err = i(withToken("Bearer unlinked"), nil, info, handler)
+		assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+
+		rejected := meter.metrics["oidc_tokens_rejected_total"]
+		assert.Equal(t, 1, rejected.observations["invalid"])
+		assert.Equal(t, 1, rejected.observations["unlinked"])
+	})
+
+	t.Run("provider failures are unavailable", func(t *testing.T) {
+		i := interceptor.OIDCInterceptor(verifier, users, sessions, &mockMeter{})
+		_, err := i(withToken("Bearer down"), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, nil
+		})
+		assert.ErrorIs(t, err, apperror.ErrUnavailable)
+	})
+}
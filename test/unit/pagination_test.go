@@ -0,0 +1,41 @@
+package unit
+
+import (
+	"encoding/base64"
+	"math"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	t.Run("round trips in either order", func(t *testing.T) {
+		for _, cursor := range []pagination.Cursor{
+			{LastId: 1},
+			{LastId: 1784159718452809728, Desc: true},
+			{LastId: math.MaxInt64},
+		} {
+			token := cursor.Encode()
+			decoded, err := pagination.Decode(token)
+			require.NoError(t, err)
+			assert.Equal(t, cursor, decoded)
+		}
+	})
+
+	t.Run("tokens Encode cannot produce are rejected", func(t *testing.T) {
+		for name, token := range map[string]string{
+			"empty":         "",
+			"not base64":    "!!!",
+			"truncated":     pagination.Cursor{LastId: 7}.Encode()[:8],
+			"other version": base64.RawURLEncoding.EncodeToString([]byte{2, 0, 0, 0, 0, 0, 0, 0, 0, 7}),
+			"zero id":       base64.RawURLEncoding.EncodeToString([]byte{1, 0, 0, 0, 0, 0, 0, 0, 0, 0}),
+			"negative id":   base64.RawURLEncoding.EncodeToString([]byte{1, 0, 0xff, 0, 0, 0, 0, 0, 0, 1}),
+		} {
+			_, err := pagination.Decode(token)
+			assert.ErrorIs(t, err, apperror.ErrInvalidArgument, name)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/parallel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallelMap(t *testing.T) {
+	ctx := context.Background()
+	double := func(ctx context.Context, n int) (int, error) { return n * 2, nil }
+
+	t.Run("results keep the order of items", func(t *testing.T) {
+		results, err := parallel.Map(ctx, []int{3, 1, 2}, func(ctx context.Context, n int) (int, error) {
+			time.Sleep(time.Duration(n) * time.Millisecond)
+			return n * 2, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []int{6, 2, 4}, results)
+	})
+
+	t.Run("no items returns no results", func(t *testing.T) {
+		results, err := parallel.Map(ctx, []int{}, double)
+
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("limit bounds concurrent tasks", func(t *testing.T) {
+		var running, peak atomic.Int32
+		_, err := parallel.Map(ctx, make([]int, 10), func(ctx context.Context, n int) (int, error) {
+			now := running.Add(1)
+			for {
+				old := peak.Load()
+				if now <= old || peak.CompareAndSwap(old, now) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			return n, nil
+		}, parallel.WithLimit(2))
+
+		require.NoError(t, err)
+		assert.LessOrEqual(t, peak.Load(), int32(2))
+	})
+
+	t.Run("first error cancels the remaining tasks", func(t *testing.T) {
+		boom := errors.New("boom")
+		var completed atomic.Int32
+		results, err := parallel.Map(ctx, []int{0, 1, 2}, func(ctx context.Context, n int) (int, error) {
+			if n == 0 {
+				return 0, boom
+			}
+			select {
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			case <-time.After(5 * time.Second):
+				completed.Add(1)
+				return n, nil
+			}
+		})
+
+		assert.ErrorIs(t, err, boom)
+		assert.Nil(t, results)
+		assert.Zero(t, completed.Load())
+	})
+
+	t.Run("tasks waiting for a slot are skipped after an error", func(t *testing.T) {
+		var calls atomic.Int32
+		_, err := parallel.Map(ctx, []int{0, 1, 2}, func(ctx context.Context, n int) (int, error) {
+			calls.Add(1)
+			return 0, errors.New("boom")
+		}, parallel.WithLimit(1))
+
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("partial results keep successes and report every failure", func(t *testing.T) {
+		boom := errors.New("boom")
+		results, err := parallel.Map(ctx, []int{1, 2, 3, 4}, func(ctx context.Context, n int) (int, error) {
+			if n%2 == 0 {
+				return 0, boom
+			}
+			return n * 10, nil
+		}, parallel.WithPartialResults())
+
+		assert.Equal(t, []int{10, 0, 30, 0}, results)
+		assert.ErrorIs(t, err, boom)
+		var taskErr *parallel.TaskError
+		require.ErrorAs(t, err, &taskErr)
+		assert.Contains(t, []int{1, 3}, taskErr.Index)
+		assert.EqualError(t, err, "task 1: boom\ntask 3: boom")
+	})
+
+	t.Run("cancelled parent context reaches every task", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := parallel.Map(cancelled, []int{1, 2}, func(ctx context.Context, n int) (int, error) {
+			return 0, ctx.Err()
+		}, parallel.WithPartialResults())
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("tracing starts a span per task", func(t *testing.T) {
+		tracer := &mockTracer{}
+		_, err := parallel.Map(ctx, []int{1, 2}, func(ctx context.Context, n int) (int, error) {
+			if n == 2 {
+				return 0, errors.New("boom")
+			}
+			return n, nil
+		}, parallel.WithTracing(tracer, "UserService.GetUsersByIds.shard"), parallel.WithLimit(1), parallel.WithPartialResults())
+
+		assert.Error(t, err)
+		require.Len(t, tracer.spans, 2)
+		for i, span := range tracer.spans {
+			assert.Equal(t, "UserService.GetUsersByIds.shard", span.name)
+			assert.True(t, span.ended)
+			assert.Contains(t, span.attributes, observability.Int("task.index", i))
+		}
+		assert.Empty(t, tracer.spans[0].errors)
+		assert.Len(t, tracer.spans[1].errors, 1)
+	})
+}
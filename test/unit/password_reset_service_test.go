@@ -0,0 +1,203 @@
+package unit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/password"
+	passwordImpl "github.com/jt828/go-grpc-template/pkg/password/implementation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPasswordResetTokenRepository struct {
+	tokens map[string]*model.PasswordResetToken
+}
+
+func (m *mockPasswordResetTokenRepository) Get(ctx context.Context, selector string) (*model.PasswordResetToken, error) {
+	return m.tokens[selector], nil
+}
+
+func (m *mockPasswordResetTokenRepository) Insert(ctx context.Context, token *model.PasswordResetToken) error {
+	m.tokens[token.Selector] = token
+	return nil
+}
+
+func (m *mockPasswordResetTokenRepository) Use(ctx context.Context, selector string, usedAt time.Time) (bool, error) {
+	token := m.tokens[selector]
+	if token == nil || token.UsedAt != nil {
+		return false, nil
+	}
+	token.UsedAt = &usedAt
+	return true, nil
+}
+
+func (m *mockPasswordResetTokenRepository) DeleteUnused(ctx context.Context, userId int64) error {
+	for selector, token := range m.tokens {
+		if token.UserId == userId && token.UsedAt == nil {
+			delete(m.tokens, selector)
+		}
+	}
+	return nil
+}
+
+func TestPasswordResetService(t *testing.T) {
+	ctx := context.Background()
+	device := service.Device{UserAgent: "curl/8.0", Ip: "10.0.0.1"}
+	policy := passwordImpl.NewPolicy(password.WithLength(8, 64), password.WithMinEntropy(0))
+
+	type fixture struct {
+		svc     service.PasswordResetService
+		tokens  *mockPasswordResetTokenRepository
+		sender  *recordingSender
+		user    *model.User
+		revoked int
+	}
+	newFixture := func(user *model.User, sender *recordingSender) *fixture {
+		f := &fixture{tokens: &mockPasswordResetTokenRepository{tokens: map[string]*model.PasswordResetToken{}}, sender: sender, user: user}
+		users := &mockUserRepository{
+			getFunc: func(ctx context.Context, id int64) (*model.User, error) {
+				if user == nil || user.Id != id {
+					return nil, nil
+				}
+				u := *user
+				return &u, nil
+			},
+			getByEmailFunc: func(ctx context.Context, email string) (*model.User, error) {
+				if user == nil || user.Email != email {
+					return nil, nil
+				}
+				u := *user
+				return &u, nil
+			},
+			updatePasswordFunc: func(ctx context.Context, u *model.User) (bool, error) {
+				u.Version++
+				*user = *u
+				return true, nil
+			},
+		}
+		sessions := &mockSessionRepository{
+			revokeAllFunc: func(ctx context.Context, userId int64, revokedAt time.Time) ([]int64, error) {
+				f.revoked++
+				return []int64{10}, nil
+			},
+			insertEventFunc: func(ctx context.Context, event *model.SessionEvent) error { return nil },
+		}
+		factory := &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) {
+			return &mockUnitOfWork{
+				userRepo:       users,
+				sessionRepo:    sessions,
+				resetTokenRepo: f.tokens,
+				commitFunc:     func(ctx context.Context) error { return nil },
+				abortFunc:      func(ctx context.Context) error { return nil },
+			}, nil
+		}}
+		f.svc = service.NewPasswordResetService(factory, sender, policy, &mockHasher{}, &mockSnowflake{id: 900}, "https://app.example.com/reset", time.Hour, &mockLogger{})
+		return f
+	}
+	// requestToken requests a reset for a@b.com and returns the token mailed.
+	requestToken := func(t *testing.T, f *fixture) string {
+		t.Helper()
+		require.NoError(t, f.svc.Request(ctx, "a@b.com"))
+		require.NotEmpty(t, f.sender.sent)
+		body := f.sender.sent[len(f.sender.sent)-1].Body
+		link, err := url.Parse(strings.TrimSpace(body[strings.Index(body, "https://"):]))
+		require.NoError(t, err)
+		return link.Query().Get("token")
+	}
+	activeUser := func() *model.User {
+		return &model.User{Id: 1, Email: "a@b.com", Username: "alice", Password: "hashed:old", Status: model.UserStatusActive}
+	}
+
+	t.Run("request stores only the verifier's hash and mails the token", func(t *testing.T) {
+		f := newFixture(activeUser(), &recordingSender{})
+
+		token := requestToken(t, f)
+
+		assert.Equal(t, "a@b.com", f.sender.sent[0].To)
+		require.Len(t, token, 52)
+		stored := f.tokens.tokens[token[:26]]
+		require.NotNil(t, stored, "found by its selector")
+		sum := sha256.Sum256([]byte(token[26:]))
+		assert.Equal(t, hex.EncodeToString(sum[:]), stored.VerifierHash)
+		assert.NotContains(t, stored.VerifierHash, token[26:])
+		assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+	})
+
+	t.Run("request succeeds silently for unknown and deleted users and failed sends", func(t *testing.T) {
+		f := newFixture(activeUser(), &recordingSender{})
+		require.NoError(t, f.svc.Request(ctx, "nobody@b.com"))
+		assert.Empty(t, f.sender.sent)
+
+		deleted := activeUser()
+		deleted.Status = model.UserStatusDeleted
+		f = newFixture(deleted, &recordingSender{})
+		require.NoError(t, f.svc.Request(ctx, "a@b.com"))
+		assert.Empty(t, f.sender.sent)
+		assert.Empty(t, f.tokens.tokens)
+
+		f = newFixture(activeUser(), &recordingSender{err: errors.New("connection refused")})
+		assert.NoError(t, f.svc.Request(ctx, "a@b.com"), "a failed send would reveal the account")
+	})
+
+	t.Run("confirm sets the password, signs out sessions and is idempotent", func(t *testing.T) {
+		f := newFixture(activeUser(), &recordingSender{})
+		token := requestToken(t, f)
+
+		change, err := f.svc.Confirm(ctx, token, "a brand new password", device)
+		require.NoError(t, err)
+		assert.Equal(t, &service.PasswordChange{RevokedSessions: 1}, change)
+		assert.Equal(t, "hashed:a brand new password", f.user.Password)
+
+		again, err := f.svc.Confirm(ctx, " "+strings.ToLower(token)+" ", "a brand new password", device)
+		require.NoError(t, err, "a retried confirmation succeeds")
+		assert.Equal(t, &service.PasswordChange{}, again)
+		assert.Equal(t, 1, f.revoked, "and changes nothing")
+
+		_, err = f.svc.Confirm(ctx, token, "another password", device)
+		assert.ErrorIs(t, err, apperror.ErrInvalidArgument, "a used token sets no other password")
+	})
+
+	t.Run("confirm rejects unknown, forged and expired tokens", func(t *testing.T) {
+		f := newFixture(activeUser(), &recordingSender{})
+		reason := func(err error) string {
+			var reasonErr *apperror.ReasonError
+			require.ErrorAs(t, err, &reasonErr)
+			return reasonErr.Reason
+		}
+
+		_, err := f.svc.Confirm(ctx, "NOTATOKEN", "a brand new password", device)
+		assert.Equal(t, "PASSWORD_RESET_TOKEN_INVALID", reason(err))
+
+		token := requestToken(t, f)
+		forged := token[:26] + strings.Repeat("A", 26)
+		_, err = f.svc.Confirm(ctx, forged, "a brand new password", device)
+		assert.Equal(t, "PASSWORD_RESET_TOKEN_INVALID", reason(err), "the selector alone is not enough")
+
+		f.tokens.tokens[token[:26]].ExpiresAt = time.Now().Add(-time.Second)
+		_, err = f.svc.Confirm(ctx, token, "a brand new password", device)
+		assert.Equal(t, "PASSWORD_RESET_TOKEN_EXPIRED", reason(err))
+		assert.Equal(t, "hashed:old", f.user.Password)
+	})
+
+	t.Run("confirm applies the password policy", func(t *testing.T) {
+		f := newFixture(activeUser(), &recordingSender{})
+		token := requestToken(t, f)
+
+		_, err := f.svc.Confirm(ctx, token, "short", device)
+		var validationErr *apperror.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "new_password", validationErr.Violations[0].Field)
+		assert.Nil(t, f.tokens.tokens[token[:26]].UsedAt, "the token can still be used")
+	})
+}
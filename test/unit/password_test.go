@@ -0,0 +1,201 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	httpclientImpl "github.com/jt828/go-grpc-template/pkg/httpclient/implementation"
+	"github.com/jt828/go-grpc-template/pkg/password"
+	passwordImpl "github.com/jt828/go-grpc-template/pkg/password/implementation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockBreachChecker struct {
+	breached bool
+	err      error
+	calls    int
+}
+
+func (m *mockBreachChecker) Breached(ctx context.Context, pw string) (bool, error) {
+	m.calls++
+	return m.breached, m.err
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	ctx := context.Background()
+	rules := func(violations []password.Violation) []string {
+		var rules []string
+		for _, v := range violations {
+			rules = append(rules, v.Rule)
+		}
+		return rules
+	}
+
+	t.Run("strong password is accepted", func(t *testing.T) {
+		policy := passwordImpl.NewPolicy()
+		assert.Empty(t, policy.Check(ctx, "plum-Tractor-41-lagoon", "alice", "alice@example.com"))
+	})
+
+	t.Run("length is bounded", func(t *testing.T) {
+		policy := passwordImpl.NewPolicy(password.WithLength(8, 16), password.WithMinEntropy(0))
+		assert.Equal(t, []string{password.RuleMinLength}, rules(policy.Check(ctx, "k7#Qw")))
+		assert.Equal(t, []string{password.RuleMaxLength}, rules(policy.Check(ctx, "k7#Qw-k7#Qw-k7#Qw")))
+		assert.Empty(t, policy.Check(ctx, "k7#Qw-9zPx"))
+	})
+
+	t.Run("length counts characters, not bytes", func(t *testing.T) {
+		policy := passwordImpl.NewPolicy(password.WithLength(1, 4), password.WithMinEntropy(0))
+		assert.Empty(t, policy.Check(ctx, "éèêë"))
+	})
+
+	t.Run("repeated and sequential characters are too predictable", func(t *testing.T) {
+		policy := passwordImpl.NewPolicy()
+		for _, pw := range []string{"aaaaaaaaaaaaaaaa", "abcdefghijklmnop", "9876543210987654"} {
+			assert.Equal(t, []string{password.RuleEntropy}, rules(policy.Check(ctx, pw)), pw)
+		}
+	})
+
+	t.Run("password must not contain user info", func(t *testing.T) {
+		policy := passwordImpl.NewPolicy()
+		assert.Equal(t, []string{password.RuleUserInfo}, rules(policy.Check(ctx, "plum-Tractor-ALICE", "alice")))
+		assert.Equal(t, []string{password.RuleUserInfo}, rules(policy.Check(ctx, "plum-Tractor-jsmith", "bob", "jsmith@example.com")))
+		assert.Empty(t, policy.Check(ctx, "plum-Tractor-41-al", "al"))
+	})
+
+	t.Run("every violation is reported", func(t *testing.T) {
+		policy := passwordImpl.NewPolicy()
+		assert.Equal(t, []string{password.RuleMinLength, password.RuleEntropy, password.RuleUserInfo}, rules(policy.Check(ctx, "alice", "alice")))
+	})
+
+	t.Run("breached password is rejected", func(t *testing.T) {
+		breaches := &mockBreachChecker{breached: true}
+		policy := passwordImpl.NewPolicy(password.WithBreachChecker(breaches, nil))
+		assert.Equal(t, []string{password.RuleBreached}, rules(policy.Check(ctx, "plum-Tractor-41-lagoon")))
+	})
+
+	t.Run("breach check is skipped for passwords rejected anyway", func(t *testing.T) {
+		breaches := &mockBreachChecker{breached: true}
+		policy := passwordImpl.NewPolicy(password.WithBreachChecker(breaches, nil))
+		assert.Equal(t, []string{password.RuleMinLength, password.RuleEntropy}, rules(policy.Check(ctx, "short")))
+		assert.Zero(t, breaches.calls)
+	})
+
+	t.Run("failed breach check accepts the password and reports the error", func(t *testing.T) {
+		boom := errors.New("boom")
+		var reported error
+		policy := passwordImpl.NewPolicy(password.WithBreachChecker(&mockBreachChecker{err: boom}, func(ctx context.Context, err error) {
+			reported = err
+		}))
+		assert.Empty(t, policy.Check(ctx, "plum-Tractor-41-lagoon"))
+		assert.ErrorIs(t, reported, boom)
+	})
+}
+
+func TestPwnedPasswords(t *testing.T) {
+	ctx := context.Background()
+	// The SHA-1 of "password" is 5BAA6 followed by passwordSuffix.
+	const passwordSuffix = "1E4C9B93F3F0682250B6CF8331B7EE68FD8"
+	var requests []*http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		if body == "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+	checker := passwordImpl.NewPwnedPasswords(server.URL+"/range/", httpclientImpl.NewHTTPClient(), &passthroughCB{}, &passthroughRetry{})
+
+	t.Run("breached password is found by hash prefix", func(t *testing.T) {
+		requests = nil
+		body = "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" + passwordSuffix + ":9659365\r\n"
+
+		breached, err := checker.Breached(ctx, "password")
+		require.NoError(t, err)
+		assert.True(t, breached)
+		require.Len(t, requests, 1)
+		assert.Equal(t, "/range/5BAA6", requests[0].URL.Path)
+		assert.Equal(t, "true", requests[0].Header.Get("Add-Padding"))
+	})
+
+	t.Run("password absent from the range is not breached", func(t *testing.T) {
+		body = "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n"
+
+		breached, err := checker.Breached(ctx, "password")
+		require.NoError(t, err)
+		assert.False(t, breached)
+	})
+
+	t.Run("padding entries do not count as breached", func(t *testing.T) {
+		body = passwordSuffix + ":0\r\n"
+
+		breached, err := checker.Breached(ctx, "password")
+		require.NoError(t, err)
+		assert.False(t, breached)
+	})
+
+	t.Run("unexpected status is an error", func(t *testing.T) {
+		body = ""
+
+		_, err := checker.Breached(ctx, "password")
+		assert.ErrorContains(t, err, "503")
+	})
+}
+
+func TestPasswordHasher(t *testing.T) {
+	argon := passwordImpl.NewHasher(password.AlgorithmArgon2id)
+	bcrypt := passwordImpl.NewHasher(password.AlgorithmBcrypt)
+
+	t.Run("hashes verify and never contain the password", func(t *testing.T) {
+		for _, hasher := range []password.Hasher{argon, bcrypt} {
+			hash, err := hasher.Hash("correct horse battery")
+			require.NoError(t, err)
+			assert.NotContains(t, hash, "correct horse")
+
+			ok, err := hasher.Verify("correct horse battery", hash)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			ok, err = hasher.Verify("correct horse battery!", hash)
+			require.NoError(t, err)
+			assert.False(t, ok)
+		}
+	})
+
+	t.Run("argon2id hashes are salted PHC strings", func(t *testing.T) {
+		first, err := argon.Hash("correct horse battery")
+		require.NoError(t, err)
+		second, err := argon.Hash("correct horse battery")
+		require.NoError(t, err)
+		assert.Regexp(t, `^\$argon2id\$v=19\$m=19456,t=2,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`, first)
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("either algorithm verifies the other's hashes", func(t *testing.T) {
+		hash, err := bcrypt.Hash("correct horse battery")
+		require.NoError(t, err)
+		ok, err := argon.Verify("correct horse battery", hash)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("unknown formats are reported", func(t *testing.T) {
+		for _, stored := range []string{"correct horse battery", "$argon2id$v=16$m=19456,t=2,p=1$c2FsdA$a2V5", "$argon2id$v=19$m=1,t=0,p=1$c2FsdA$a2V5", "$2b$12$short"} {
+			_, err := argon.Verify("correct horse battery", stored)
+			assert.ErrorIs(t, err, password.ErrUnknownHash, stored)
+		}
+	})
+
+	t.Run("bcrypt rejects passwords it would truncate", func(t *testing.T) {
+		_, err := bcrypt.Hash(strings.Repeat("a", 73))
+		assert.ErrorIs(t, err, apperror.ErrInvalidArgument)
+	})
+}
@@ -0,0 +1,66 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jt828/go-grpc-template/pkg/pgclass"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPgClass(t *testing.T) {
+	connReset := &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+	tests := []struct {
+		name          string
+		err           error
+		retryable     bool
+		conflict      bool
+		serialization bool
+		connection    bool
+	}{
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true, false, true, false},
+		{"deadlock detected", &pgconn.PgError{Code: "40P01"}, true, false, true, false},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, true, false, false, true},
+		{"unable to establish connection", &pgconn.PgError{Code: "08001"}, true, false, false, true},
+		{"connection rejected", &pgconn.PgError{Code: "08004"}, true, false, false, true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false, true, false, false},
+		{"syntax error", &pgconn.PgError{Code: "42601"}, false, false, false, false},
+		{"wrapped pg error", fmt.Errorf("query: %w", &pgconn.PgError{Code: "40001"}), true, false, true, false},
+		{"wrapped unique violation", fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505"}), false, true, false, false},
+		{"connection reset", connReset, true, false, false, true},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true, false, false, true},
+		{"deadline exceeded", context.DeadlineExceeded, false, false, false, false},
+		{"canceled", fmt.Errorf("query: %w", context.Canceled), false, false, false, false},
+		{"deadline on network read", errors.Join(context.DeadlineExceeded, connReset), false, false, false, true},
+		{"unknown error", errors.New("boom"), false, false, false, false},
+		{"nil", nil, false, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.retryable, pgclass.IsRetryable(tt.err), "IsRetryable")
+			assert.Equal(t, tt.conflict, pgclass.IsConflict(tt.err), "IsConflict")
+			assert.Equal(t, tt.serialization, pgclass.IsSerializationFailure(tt.err), "IsSerializationFailure")
+			assert.Equal(t, tt.connection, pgclass.IsConnectionError(tt.err), "IsConnectionError")
+		})
+	}
+
+	t.Run("constraint violations", func(t *testing.T) {
+		fk := fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23503", ConstraintName: "sessions_user_id_fkey"})
+		assert.True(t, pgclass.IsForeignKeyViolation(fk))
+		assert.False(t, pgclass.IsCheckViolation(fk))
+		assert.Equal(t, "sessions_user_id_fkey", pgclass.Constraint(fk))
+		assert.True(t, pgclass.IsCheckViolation(&pgconn.PgError{Code: "23514"}))
+		assert.Empty(t, pgclass.Constraint(errors.New("boom")))
+	})
+
+	t.Run("Code", func(t *testing.T) {
+		assert.Equal(t, "23505", pgclass.Code(fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505"})))
+		assert.Empty(t, pgclass.Code(errors.New("boom")))
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/jt828/go-grpc-template/internal/bootstrap"
+	"github.com/jt828/go-grpc-template/internal/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -54,4 +55,26 @@ func TestPodNodeID(t *testing.T) {
 		_, err := bootstrap.PodNodeID()
 		assert.Error(t, err)
 	})
+
+	t.Run("node ID stays within a node range", func(t *testing.T) {
+		nodes := config.NodeRange{First: 256, Last: 511}
+		seen := map[int64]bool{}
+		for _, hostname := range []string{
+			"go-grpc-template-7f8b9c6d4-x2k9p",
+			"go-grpc-template-7f8b9c6d4-a3m7n",
+			"go-grpc-template-abc123-def456",
+			"my-app-pod-zzzzz",
+		} {
+			id, err := nodes.NodeID(hostname)
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, id, int64(256))
+			assert.LessOrEqual(t, id, int64(511))
+			seen[id] = true
+		}
+		assert.Greater(t, len(seen), 1)
+
+		id, err := config.NodeRange{First: 7, Last: 7}.NodeID("any")
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), id)
+	})
 }
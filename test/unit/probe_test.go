@@ -0,0 +1,127 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/probe"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// lifecycleUserServer keeps users in memory, replaying creations by
+// idempotency id, so the probe's create and get round trip can be observed.
+type lifecycleUserServer struct {
+	v1.UnimplementedUserServiceServer
+	mu       sync.Mutex
+	users    map[int64]*v1.GetUserByIdResponse
+	created  map[int64]*v1.CreateUserResponse
+	failGets bool
+}
+
+func newLifecycleUserServer(failGets bool) *lifecycleUserServer {
+	return &lifecycleUserServer{users: map[int64]*v1.GetUserByIdResponse{}, created: map[int64]*v1.CreateUserResponse{}, failGets: failGets}
+}
+
+func (s *lifecycleUserServer) CreateUser(ctx context.Context, req *v1.CreateUserRequest) (*v1.CreateUserResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if response, ok := s.created[req.GetIdempotencyId()]; ok {
+		return response, nil
+	}
+	id := int64(len(s.users) + 1)
+	s.users[id] = &v1.GetUserByIdResponse{Id: id, Email: req.GetEmail(), Username: req.GetUsername(), Status: v1.UserStatus_USER_STATUS_ACTIVE}
+	s.created[req.GetIdempotencyId()] = &v1.CreateUserResponse{Id: id, Email: req.GetEmail(), Username: req.GetUsername()}
+	return s.created[req.GetIdempotencyId()], nil
+}
+
+func (s *lifecycleUserServer) GetUserById(ctx context.Context, req *v1.GetUserByIdRequest) (*v1.GetUserByIdResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[req.GetId()]
+	if !ok || s.failGets {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	return user, nil
+}
+
+// warnSignalLogger reports each warning on a channel, so a test can wait for
+// the probe loop without racing on the logger's state.
+type warnSignalLogger struct {
+	mockLogger
+	warned chan string
+}
+
+func (l *warnSignalLogger) Warn(msg string, fields ...observability.Field) { l.warned <- msg }
+
+func TestProber(t *testing.T) {
+	ctx := context.Background()
+	startUsers := func(t *testing.T, users *lifecycleUserServer) *grpc.ClientConn {
+		return startSmokeTarget(t, grpc_health_v1.HealthCheckResponse_SERVING, nil, func(s *grpc.Server) {
+			v1.RegisterUserServiceServer(s, users)
+		})
+	}
+
+	t.Run("successful runs create one probe user and read it back", func(t *testing.T) {
+		users := newLifecycleUserServer(false)
+		meter := &mockMeter{}
+		prober := probe.NewProber(startUsers(t, users), meter, &mockLogger{})
+
+		require.NoError(t, prober.Probe(ctx))
+		require.NoError(t, prober.Probe(ctx))
+
+		require.Len(t, users.users, 1)
+		assert.Equal(t, "probe@probe.invalid", users.users[1].Email)
+		assert.Contains(t, users.created, probe.IdempotencyId)
+		duration := meter.metrics["e2e_probe_duration_seconds"]
+		for _, step := range []string{probe.StepCreate, probe.StepGet, probe.StepTotal} {
+			assert.Equal(t, 2, duration.observations[step], step)
+		}
+		assert.Equal(t, 2, meter.metrics["e2e_probe_runs_total"].observations["success"])
+		assert.Empty(t, meter.metrics["e2e_probe_failures_total"].observations)
+	})
+
+	t.Run("failed step is reported and stops the run", func(t *testing.T) {
+		users := newLifecycleUserServer(true)
+		meter := &mockMeter{}
+		prober := probe.NewProber(startUsers(t, users), meter, &mockLogger{})
+
+		err := prober.Probe(ctx)
+
+		assert.ErrorContains(t, err, "get: ")
+		assert.Equal(t, codes.NotFound, status.Code(errors.Unwrap(err)))
+		assert.Equal(t, 1, meter.metrics["e2e_probe_runs_total"].observations["failure"])
+		assert.Equal(t, 1, meter.metrics["e2e_probe_failures_total"].observations[probe.StepGet])
+		assert.Equal(t, 0, meter.metrics["e2e_probe_duration_seconds"].observations[probe.StepTotal])
+	})
+
+	t.Run("run logs failures until cancelled", func(t *testing.T) {
+		users := newLifecycleUserServer(true)
+		log := &warnSignalLogger{warned: make(chan string, 1)}
+		prober := probe.NewProber(startUsers(t, users), &mockMeter{}, log)
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+
+		go func() {
+			prober.Run(runCtx, time.Hour)
+			close(done)
+		}()
+		select {
+		case msg := <-log.warned:
+			assert.Equal(t, "synthetic probe failed", msg)
+		case <-time.After(5 * time.Second):
+			t.Fatal("probe failure was not logged")
+		}
+		cancel()
+		<-done
+	})
+}
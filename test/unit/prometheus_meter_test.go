@@ -0,0 +1,165 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/httpmiddleware"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/observability/implementation"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// histogramOf returns the single series of the named histogram.
+func histogramOf(t *testing.T, meter observability.Meter, name string) *dto.Histogram {
+	t.Helper()
+	families, err := implementation.PromRegistry(meter).Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == name {
+			require.Len(t, family.GetMetric(), 1)
+			return family.GetMetric()[0].GetHistogram()
+		}
+	}
+	t.Fatalf("metric %s not gathered", name)
+	return nil
+}
+
+func upperBounds(h *dto.Histogram) []float64 {
+	bounds := make([]float64, len(h.GetBucket()))
+	for i, b := range h.GetBucket() {
+		bounds[i] = b.GetUpperBound()
+	}
+	return bounds
+}
+
+func TestPrometheusMeterBuckets(t *testing.T) {
+	t.Run("preset buckets apply when none are given", func(t *testing.T) {
+		meter := implementation.NewPrometheusMeter()
+		meter.Histogram("db_seconds", observability.MetricOpt{Preset: observability.BucketsDB}).Observe(0.002)
+
+		assert.Equal(t, observability.DefaultBucketPresets()[observability.BucketsDB], upperBounds(histogramOf(t, meter, "db_seconds")))
+	})
+
+	t.Run("explicit buckets win over the preset", func(t *testing.T) {
+		meter := implementation.NewPrometheusMeter()
+		meter.Histogram("sizes", observability.MetricOpt{Buckets: []float64{1, 10}, Preset: observability.BucketsDB}).Observe(5)
+
+		assert.Equal(t, []float64{1, 10}, upperBounds(histogramOf(t, meter, "sizes")))
+	})
+
+	t.Run("configured presets replace the defaults", func(t *testing.T) {
+		meter := implementation.NewPrometheusMeter(implementation.WithBucketPresets(map[observability.BucketPreset][]float64{
+			observability.BucketsExternal: {0.5, 5},
+		}))
+		meter.Histogram("external_seconds", observability.MetricOpt{Preset: observability.BucketsExternal}).Observe(1)
+		meter.Histogram("rpc_seconds", observability.MetricOpt{Preset: observability.BucketsRPC}).Observe(1)
+
+		assert.Equal(t, []float64{0.5, 5}, upperBounds(histogramOf(t, meter, "external_seconds")))
+		assert.Equal(t, observability.DefaultBucketPresets()[observability.BucketsRPC], upperBounds(histogramOf(t, meter, "rpc_seconds")))
+	})
+
+	t.Run("millisecond unit scales preset buckets", func(t *testing.T) {
+		meter := implementation.NewPrometheusMeter(implementation.WithBucketPresets(map[observability.BucketPreset][]float64{
+			observability.BucketsDB: {0.001, 0.01},
+		}))
+		meter.Histogram("db_ms", observability.MetricOpt{Preset: observability.BucketsDB, Unit: observability.UnitMilliseconds}).Observe(2)
+
+		assert.Equal(t, []float64{1, 10}, upperBounds(histogramOf(t, meter, "db_ms")))
+	})
+
+	t.Run("unknown preset or unit panics", func(t *testing.T) {
+		meter := implementation.NewPrometheusMeter()
+		assert.Panics(t, func() { meter.Histogram("a", observability.MetricOpt{Preset: "disk"}) })
+		assert.Panics(t, func() { meter.Timer("b", observability.MetricOpt{Unit: "minutes"}) })
+	})
+}
+
+func TestPrometheusMeterNamespace(t *testing.T) {
+	meter := implementation.NewPrometheusMeter(
+		implementation.WithNamespace("acme", "users"),
+		implementation.WithConstLabels(observability.Label{Key: "service", Value: "svc"}, observability.Label{Key: "env", Value: "prod"}),
+	)
+	meter.Counter("requests_total", observability.MetricOpt{LabelKeys: []string{"method"}}).Inc(1, observability.Label{Key: "method", Value: "Get"})
+	meter.Gauge("in_flight", observability.MetricOpt{LabelKeys: []string{"group"}}).Set(3, observability.Label{Key: "group", Value: "default"})
+	implementation.PromRegisterer(meter).MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "external_total"}))
+
+	families, err := implementation.PromRegistry(meter).Gather()
+	require.NoError(t, err)
+	labels := map[string]map[string]string{}
+	for _, family := range families {
+		require.Len(t, family.GetMetric(), 1)
+		labels[family.GetName()] = map[string]string{}
+		for _, pair := range family.GetMetric()[0].GetLabel() {
+			labels[family.GetName()][pair.GetName()] = pair.GetValue()
+		}
+	}
+	assert.Equal(t, map[string]map[string]string{
+		"acme_users_requests_total": {"service": "svc", "env": "prod", "method": "Get"},
+		"acme_users_in_flight":      {"service": "svc", "env": "prod", "group": "default"},
+		"acme_users_external_total": {"service": "svc", "env": "prod"},
+	}, labels)
+}
+
+func TestPrometheusTimer(t *testing.T) {
+	for _, tt := range []struct {
+		unit     string
+		min, max float64
+	}{
+		{observability.UnitSeconds, 0.005, 1},
+		{observability.UnitMilliseconds, 5, 1000},
+	} {
+		t.Run(tt.unit, func(t *testing.T) {
+			meter := implementation.NewPrometheusMeter()
+			timer := meter.Timer("op_duration", observability.MetricOpt{
+				Preset:    observability.BucketsRPC,
+				Unit:      tt.unit,
+				LabelKeys: []string{"op"},
+			})
+
+			stop := timer.Start(observability.Label{Key: "op", Value: "sleep"})
+			time.Sleep(5 * time.Millisecond)
+			stop()
+
+			h := histogramOf(t, meter, "op_duration")
+			assert.Equal(t, uint64(1), h.GetSampleCount())
+			assert.GreaterOrEqual(t, h.GetSampleSum(), tt.min)
+			assert.Less(t, h.GetSampleSum(), tt.max)
+		})
+	}
+}
+
+func TestMetricsServer(t *testing.T) {
+	ctx := context.Background()
+	srv, err := implementation.StartMetricsServer("127.0.0.1:0", prometheus.NewRegistry(),
+		httpmiddleware.SecurityHeaders(httpmiddleware.SecurityPolicy{}),
+	)
+	require.NoError(t, err)
+
+	resp, err := http.Get("http://" + srv.ListenAddr() + "/healthz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"), "served through the middleware")
+	require.NoError(t, srv.Check(ctx))
+
+	t.Run("a check that gets no answer in time fails", func(t *testing.T) {
+		expired, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.Error(t, srv.Check(expired))
+	})
+
+	t.Run("a stopped server fails its check", func(t *testing.T) {
+		require.NoError(t, srv.Shutdown(ctx))
+		assert.Eventually(t, func() bool {
+			err := srv.Check(ctx)
+			return errors.Is(err, http.ErrServerClosed)
+		}, time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,98 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/observability/implementation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPropagator(t *testing.T) {
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	sampled := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceId, SpanID: spanId, TraceFlags: trace.FlagsSampled})
+	ctx := trace.ContextWithSpanContext(context.Background(), sampled)
+	ctx, err := observability.ContextWithTenant(ctx, "acme")
+	require.NoError(t, err)
+
+	extract := func(p propagation.TextMapPropagator, carrier propagation.MapCarrier) trace.SpanContext {
+		return trace.SpanContextFromContext(p.Extract(context.Background(), carrier))
+	}
+
+	t.Run("defaults carry W3C trace context and baggage", func(t *testing.T) {
+		p := implementation.NewPropagator(observability.DefaultPropagators())
+		carrier := propagation.MapCarrier{}
+		p.Inject(ctx, carrier)
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", carrier["traceparent"])
+		assert.Equal(t, "tenant_id=acme", carrier["baggage"])
+		assert.NotContains(t, carrier, "b3")
+
+		out := p.Extract(context.Background(), carrier)
+		assert.Equal(t, traceId, trace.SpanContextFromContext(out).TraceID())
+		assert.Equal(t, "acme", observability.TenantFromContext(out))
+	})
+
+	t.Run("b3 injects the single header and b3multi the X-B3 headers", func(t *testing.T) {
+		carrier := propagation.MapCarrier{}
+		implementation.NewPropagator([]observability.Propagator{observability.PropagatorB3}).Inject(ctx, carrier)
+		assert.Equal(t, propagation.MapCarrier{"b3": "4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1"}, carrier)
+
+		carrier = propagation.MapCarrier{}
+		implementation.NewPropagator([]observability.Propagator{observability.PropagatorB3Multi}).Inject(ctx, carrier)
+		assert.Equal(t, propagation.MapCarrier{
+			"x-b3-traceid": "4bf92f3577b34da6a3ce929d0e0e4736",
+			"x-b3-spanid":  "00f067aa0ba902b7",
+			"x-b3-sampled": "1",
+		}, carrier)
+	})
+
+	t.Run("b3 extracts either form, with 64-bit trace ids padded", func(t *testing.T) {
+		p := implementation.NewPropagator([]observability.Propagator{observability.PropagatorB3})
+
+		sc := extract(p, propagation.MapCarrier{"b3": "a3ce929d0e0e4736-00f067aa0ba902b7-d"})
+		assert.Equal(t, "0000000000000000a3ce929d0e0e4736", sc.TraceID().String())
+		assert.True(t, sc.IsSampled(), "debug is sampled")
+		assert.True(t, sc.IsRemote())
+
+		sc = extract(p, propagation.MapCarrier{"x-b3-traceid": "4bf92f3577b34da6a3ce929d0e0e4736", "x-b3-spanid": "00f067aa0ba902b7", "x-b3-sampled": "0"})
+		assert.Equal(t, spanId, sc.SpanID())
+		assert.False(t, sc.IsSampled())
+
+		for _, header := range []string{"1", "not-hex", "4bf92f3577b34da6a3ce929d0e0e4736-short"} {
+			assert.False(t, extract(p, propagation.MapCarrier{"b3": header}).IsValid(), header)
+		}
+	})
+
+	t.Run("the last propagator listed wins", func(t *testing.T) {
+		carrier := propagation.MapCarrier{
+			"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"b3":          "11111111111111111111111111111111-2222222222222222-1",
+		}
+		sc := extract(implementation.NewPropagator([]observability.Propagator{observability.PropagatorB3, observability.PropagatorTraceContext}), carrier)
+		assert.Equal(t, traceId, sc.TraceID())
+		sc = extract(implementation.NewPropagator([]observability.Propagator{observability.PropagatorTraceContext, observability.PropagatorB3}), carrier)
+		assert.Equal(t, "11111111111111111111111111111111", sc.TraceID().String())
+	})
+}
+
+func TestBaggage(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, observability.TenantFromContext(ctx))
+
+	ctx, err := observability.ContextWithBaggage(ctx, "region", "eu west")
+	require.NoError(t, err)
+	ctx, err = observability.ContextWithTenant(ctx, "acme")
+	require.NoError(t, err)
+	ctx, err = observability.ContextWithTenant(ctx, "globex")
+	require.NoError(t, err)
+	assert.Equal(t, "globex", observability.TenantFromContext(ctx), "a member is replaced, not duplicated")
+	assert.Equal(t, "eu west", observability.BaggageFromContext(ctx, "region"))
+
+	_, err = observability.ContextWithBaggage(ctx, "", "x")
+	assert.Error(t, err)
+}
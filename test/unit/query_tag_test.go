@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/observability/implementation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGormQueryTagPlugin(t *testing.T) {
+	cb := &passthroughCB{}
+	r := &passthroughRetry{}
+	now := time.Now().Truncate(time.Second)
+
+	t.Run("appends tags from context to query", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		require.NoError(t, gormDB.Use(implementation.NewGormQueryTagPlugin("go-grpc-template")))
+		repo := repository.NewLedgerRepository(gormDB, cb, r, false)
+
+		ctx := observability.ContextWithQueryTags(context.Background(), observability.QueryTags{
+			Method:    "/proto.v1.LedgerService/GetLedgers",
+			RequestId: "req-1",
+		})
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			`SELECT * FROM "main"."ledgers" WHERE user_id = $1 /*application='go-grpc-template',request_id='req-1',rpc='%2Fproto.v1.LedgerService%2FGetLedgers'*/`,
+		)).WithArgs(int64(10)).WillReturnRows(sqlmock.NewRows(ledgerColumns()).AddRow(1, 10, "deposit", "ETH", "1.5", now))
+
+		ledgers, err := repo.Get(ctx, repository.GetQuery{UserIdEq: 10})
+		require.NoError(t, err)
+		assert.Len(t, ledgers, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("tags inserts inside a transaction", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		require.NoError(t, gormDB.Use(implementation.NewGormQueryTagPlugin("go-grpc-template")))
+
+		ctx := observability.ContextWithQueryTags(context.Background(), observability.QueryTags{Method: "/svc/Create"})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "main"."users"`) + `.*` + regexp.QuoteMeta(`RETURNING * /*application='go-grpc-template',rpc='%2Fsvc%2FCreate'*/`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectCommit()
+
+		repo := repository.NewUserRepository(gormDB, cb, r, false)
+		require.NoError(t, repo.Insert(ctx, &model.User{Id: 1, Email: "a@b.com", Username: "alice", CreatedAt: now, UpdatedAt: now}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("escapes values that could terminate the comment", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		require.NoError(t, gormDB.Use(implementation.NewGormQueryTagPlugin("")))
+		repo := repository.NewLedgerRepository(gormDB, cb, r, false)
+
+		ctx := observability.ContextWithQueryTags(context.Background(), observability.QueryTags{RequestId: "x'*/; DROP TABLE users; --"})
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			`SELECT * FROM "main"."ledgers" /*request_id='x%27%2A%2F%3B+DROP+TABLE+users%3B+--'*/`,
+		)).WillReturnRows(sqlmock.NewRows(ledgerColumns()))
+
+		_, err := repo.Get(ctx, repository.GetQuery{})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no tags leaves query untouched", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		require.NoError(t, gormDB.Use(implementation.NewGormQueryTagPlugin("")))
+		repo := repository.NewLedgerRepository(gormDB, cb, r, false)
+
+		mock.ExpectQuery(`^` + regexp.QuoteMeta(`SELECT * FROM "main"."ledgers"`) + `$`).
+			WillReturnRows(sqlmock.NewRows(ledgerColumns()))
+
+		_, err := repo.Get(context.Background(), repository.GetQuery{})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestQueryTagInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+
+	t.Run("stores method and request id in context", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "req-1"))
+
+		var tags observability.QueryTags
+		_, err := interceptor.QueryTagInterceptor()(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+			tags = observability.QueryTagsFromContext(ctx)
+			return nil, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, observability.QueryTags{Method: info.FullMethod, RequestId: "req-1"}, tags)
+	})
+
+	t.Run("missing request id leaves it empty", func(t *testing.T) {
+		var tags observability.QueryTags
+		_, err := interceptor.QueryTagInterceptor()(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			tags = observability.QueryTagsFromContext(ctx)
+			return nil, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, observability.QueryTags{Method: info.FullMethod}, tags)
+	})
+}
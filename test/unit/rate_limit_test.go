@@ -0,0 +1,260 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/ratelimit"
+	ratelimitImpl "github.com/jt828/go-grpc-template/pkg/ratelimit/implementation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// trailerStream captures trailers set by interceptors.
+type trailerStream struct {
+	trailer metadata.MD
+}
+
+func (s *trailerStream) Method() string                  { return "" }
+func (s *trailerStream) SetHeader(md metadata.MD) error  { return nil }
+func (s *trailerStream) SendHeader(md metadata.MD) error { return nil }
+func (s *trailerStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+func TestTokenBucketLimiter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := ratelimitImpl.NewTokenBucket(3, 10*time.Second, ratelimitImpl.NewMemoryStore(), ratelimit.WithClock(func() time.Time { return now }))
+
+	allow := func(key string) ratelimit.Decision {
+		decision, err := limiter.Allow(context.Background(), key)
+		require.NoError(t, err)
+		return decision
+	}
+
+	t.Run("allows a burst up to capacity", func(t *testing.T) {
+		assert.Equal(t, ratelimit.Decision{Allowed: true, Limit: 3, Remaining: 2, Reset: 10 * time.Second}, allow("a"))
+		assert.Equal(t, ratelimit.Decision{Allowed: true, Limit: 3, Remaining: 1, Reset: 20 * time.Second}, allow("a"))
+		assert.Equal(t, ratelimit.Decision{Allowed: true, Limit: 3, Remaining: 0, Reset: 30 * time.Second}, allow("a"))
+		assert.Equal(t, ratelimit.Decision{Allowed: false, Limit: 3, Remaining: 0, Reset: 30 * time.Second, RetryAfter: 10 * time.Second}, allow("a"))
+	})
+
+	t.Run("keys have separate buckets", func(t *testing.T) {
+		assert.True(t, allow("b").Allowed)
+	})
+
+	t.Run("tokens refill one per interval", func(t *testing.T) {
+		now = now.Add(4 * time.Second)
+		assert.Equal(t, ratelimit.Decision{Allowed: false, Limit: 3, Remaining: 0, Reset: 26 * time.Second, RetryAfter: 6 * time.Second}, allow("a"))
+		now = now.Add(6 * time.Second)
+		assert.Equal(t, ratelimit.Decision{Allowed: true, Limit: 3, Remaining: 0, Reset: 30 * time.Second}, allow("a"))
+	})
+
+	t.Run("refills no further than capacity", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		assert.Equal(t, ratelimit.Decision{Allowed: true, Limit: 3, Remaining: 2, Reset: 10 * time.Second}, allow("a"))
+	})
+
+	t.Run("a clock stepping backwards does not refill", func(t *testing.T) {
+		allow("c")
+		allow("c")
+		allow("c")
+		now = now.Add(-time.Minute)
+		assert.False(t, allow("c").Allowed)
+	})
+}
+
+// fakeRedis records the scripts it is asked to run and returns reply.
+type fakeRedis struct {
+	keys  []string
+	args  []any
+	reply any
+	err   error
+}
+
+func (f *fakeRedis) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	f.keys, f.args = keys, args
+	return f.reply, f.err
+}
+
+func TestRedisStore(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	bucket := ratelimit.Bucket{Capacity: 5, Interval: 100 * time.Millisecond}
+
+	t.Run("runs the script on the prefixed key and decodes its reply", func(t *testing.T) {
+		client := &fakeRedis{reply: []any{int64(0), int64(0), int64(500000), int64(40000)}}
+		store := ratelimitImpl.NewRedisStore(client, "ratelimit:")
+
+		decision, err := store.Take(context.Background(), "user:42", bucket, now)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ratelimit:user:42"}, client.keys)
+		assert.Equal(t, []any{5, int64(100000), now.UnixMicro()}, client.args)
+		assert.Equal(t, ratelimit.Decision{Allowed: false, Limit: 5, Remaining: 0, Reset: 500 * time.Millisecond, RetryAfter: 40 * time.Millisecond}, decision)
+	})
+
+	t.Run("decodes an allowed reply", func(t *testing.T) {
+		store := ratelimitImpl.NewRedisStore(&fakeRedis{reply: []any{int64(1), int64(3), int64(150000), int64(0)}}, "")
+
+		decision, err := store.Take(context.Background(), "a", bucket, now)
+		require.NoError(t, err)
+		assert.Equal(t, ratelimit.Decision{Allowed: true, Limit: 5, Remaining: 3, Reset: 150 * time.Millisecond}, decision)
+	})
+
+	t.Run("fails on redis errors and unexpected replies", func(t *testing.T) {
+		for _, client := range []*fakeRedis{
+			{err: errors.New("connection refused")},
+			{reply: "OK"},
+			{reply: []any{int64(1), int64(3)}},
+			{reply: []any{int64(1), "3", int64(0), int64(0)}},
+		} {
+			_, err := ratelimitImpl.NewRedisStore(client, "").Take(context.Background(), "a", bucket, now)
+			assert.Error(t, err)
+		}
+	})
+}
+
+// failingLimiter fails every request, as a limiter whose store is down.
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string) (ratelimit.Decision, error) {
+	return ratelimit.Decision{}, errors.New("store unavailable")
+}
+
+// trailerServerStream is a grpc.ServerStream capturing trailers.
+type trailerServerStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	trailer metadata.MD
+}
+
+func (s *trailerServerStream) Context() context.Context { return s.ctx }
+func (s *trailerServerStream) SetTrailer(md metadata.MD) {
+	s.trailer = metadata.Join(s.trailer, md)
+}
+
+func TestRateLimitInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/proto.v1.UserService/GetUserById"}
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+
+	newContext := func(ctx context.Context) (context.Context, *trailerStream) {
+		stream := &trailerStream{}
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.7"), Port: 51234}})
+		return grpc.NewContextWithServerTransportStream(ctx, stream), stream
+	}
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := ratelimit.WithClock(func() time.Time { return now })
+
+	t.Run("sets quota trailers and rejects over-limit callers", func(t *testing.T) {
+		meter := &mockMeter{}
+		i := interceptor.RateLimitInterceptor(ratelimitImpl.NewTokenBucket(1, time.Minute, ratelimitImpl.NewMemoryStore(), clock), meter)
+
+		ctx, stream := newContext(context.Background())
+		resp, err := i(ctx, nil, info, handler)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp)
+		assert.Equal(t, []string{"1"}, stream.trailer.Get("x-ratelimit-limit"))
+		assert.Equal(t, []string{"0"}, stream.trailer.Get("x-ratelimit-remaining"))
+		assert.Equal(t, []string{"60"}, stream.trailer.Get("x-ratelimit-reset"))
+
+		ctx, stream = newContext(context.Background())
+		_, err = i(ctx, nil, info, handler)
+		assert.ErrorIs(t, err, apperror.ErrResourceExhausted)
+		var retryErr *apperror.RetryAfterError
+		require.ErrorAs(t, err, &retryErr)
+		assert.Equal(t, time.Minute, retryErr.RetryAfter)
+		assert.Equal(t, []string{"0"}, stream.trailer.Get("x-ratelimit-remaining"))
+
+		assert.Equal(t, 1, meter.metrics["ratelimit_requests_allowed_total"].observations["peer"])
+		assert.Equal(t, 1, meter.metrics["ratelimit_requests_throttled_total"].observations["peer"])
+	})
+
+	t.Run("authenticated callers have their own quota from the same peer", func(t *testing.T) {
+		meter := &mockMeter{}
+		i := interceptor.RateLimitInterceptor(ratelimitImpl.NewTokenBucket(1, time.Minute, ratelimitImpl.NewMemoryStore()), meter)
+
+		ctx, _ := newContext(context.Background())
+		_, err := i(ctx, nil, info, handler)
+		require.NoError(t, err)
+
+		for _, caller := range []interceptor.Caller{
+			{Kind: interceptor.CallerKindAPIKey, Id: "key-1"},
+			{Kind: interceptor.CallerKindUser, Id: "42"},
+		} {
+			ctx, _ := newContext(interceptor.ContextWithCaller(context.Background(), caller))
+			_, err := i(ctx, nil, info, handler)
+			require.NoError(t, err)
+			_, err = i(ctx, nil, info, handler)
+			assert.ErrorIs(t, err, apperror.ErrResourceExhausted)
+		}
+
+		allowed := meter.metrics["ratelimit_requests_allowed_total"].observations
+		assert.Equal(t, 1, allowed["api_key"])
+		assert.Equal(t, 1, allowed["user"])
+		assert.Equal(t, 1, meter.metrics["ratelimit_requests_throttled_total"].observations["api_key"])
+	})
+
+	t.Run("health checks are not limited", func(t *testing.T) {
+		i := interceptor.RateLimitInterceptor(ratelimitImpl.NewTokenBucket(1, time.Minute, ratelimitImpl.NewMemoryStore()), &mockMeter{})
+		healthInfo := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+
+		for range 3 {
+			ctx, stream := newContext(context.Background())
+			_, err := i(ctx, nil, healthInfo, handler)
+			require.NoError(t, err)
+			assert.Empty(t, stream.trailer)
+		}
+	})
+
+	t.Run("lets requests through when the limiter fails", func(t *testing.T) {
+		meter := &mockMeter{}
+		i := interceptor.RateLimitInterceptor(failingLimiter{}, meter)
+
+		ctx, stream := newContext(context.Background())
+		resp, err := i(ctx, nil, info, handler)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp)
+		assert.Empty(t, stream.trailer)
+		assert.Equal(t, 1, meter.metrics["ratelimit_store_errors_total"].observations[""])
+	})
+
+	t.Run("streams are charged when they open", func(t *testing.T) {
+		meter := &mockMeter{}
+		_, i := interceptor.RateLimitInterceptors(ratelimitImpl.NewTokenBucket(1, time.Minute, ratelimitImpl.NewMemoryStore(), clock), meter)
+		streamInfo := &grpc.StreamServerInfo{FullMethod: "/proto.v1.LedgerService/ExportLedgers"}
+		opened := 0
+		streamHandler := func(srv any, stream grpc.ServerStream) error {
+			opened++
+			return nil
+		}
+
+		ctx, _ := newContext(context.Background())
+		first := &trailerServerStream{ctx: ctx}
+		require.NoError(t, i(nil, first, streamInfo, streamHandler))
+		assert.Equal(t, []string{"0"}, first.trailer.Get("x-ratelimit-remaining"))
+
+		second := &trailerServerStream{ctx: ctx}
+		err := i(nil, second, streamInfo, streamHandler)
+		st, _ := status.FromError(err)
+		assert.Equal(t, codes.ResourceExhausted, st.Code())
+		require.Len(t, st.Details(), 2)
+		assert.Equal(t, apperror.ReasonRateLimited, st.Details()[0].(*errdetails.ErrorInfo).Reason)
+		assert.Equal(t, time.Minute, st.Details()[1].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+		assert.True(t, apperror.HasReason(err, apperror.ReasonRateLimited))
+		assert.Equal(t, []string{"60"}, second.trailer.Get("x-ratelimit-reset"))
+		assert.Equal(t, 1, opened)
+		assert.Equal(t, 1, meter.metrics["ratelimit_requests_throttled_total"].observations["peer"])
+	})
+}
@@ -0,0 +1,156 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/circuitbreaker"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"classified transient", &repository.ErrTransient{Cause: errors.New("boom")}, true},
+		{"classified permanent", &repository.ErrPermanent{Cause: &pgconn.PgError{Code: "40001"}}, false},
+		{"wrapped classified transient", fmt.Errorf("get user: %w", &repository.ErrTransient{Cause: errors.New("boom")}), true},
+		{"open circuit breaker", fmt.Errorf("%w: open", circuitbreaker.ErrOpen), true},
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"canceled", context.Canceled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, repository.IsTransient(tt.err))
+		})
+	}
+}
+
+func TestRepositoryErrorClassification(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("retryable pg error is returned as ErrTransient", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		repo := repository.NewUserRepository(gormDB, &passthroughCB{}, &passthroughRetry{}, true)
+		pgErr := &pgconn.PgError{Code: "40001"}
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."users"`)).WillReturnError(pgErr)
+
+		_, err := repo.Get(ctx, 1)
+
+		var transient *repository.ErrTransient
+		assert.ErrorAs(t, err, &transient)
+		assert.ErrorIs(t, err, pgErr)
+	})
+
+	t.Run("constraint violation is returned as ErrPermanent", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		repo := repository.NewUserRepository(gormDB, &passthroughCB{}, &passthroughRetry{}, true)
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "main"."users"`)).WillReturnError(&pgconn.PgError{Code: "23505"})
+		mock.ExpectRollback()
+
+		err := repo.Insert(ctx, &model.User{Id: 1})
+
+		var permanent *repository.ErrPermanent
+		assert.ErrorAs(t, err, &permanent)
+		assert.False(t, repository.IsTransient(err))
+	})
+
+	t.Run("constraint violations are translated to domain errors", func(t *testing.T) {
+		for _, tt := range []struct {
+			name    string
+			pgErr   *pgconn.PgError
+			want    error
+			message string
+			field   string
+		}{
+			{"known unique constraint", &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}, apperror.ErrAlreadyExists, "email already registered: already exists", "email"},
+			{"other unique constraint", &pgconn.PgError{Code: "23505", ConstraintName: "users_pkey"}, apperror.ErrAlreadyExists, "resource already exists: already exists", ""},
+			{"foreign key", &pgconn.PgError{Code: "23503", ConstraintName: "users_tenant_fkey"}, apperror.ErrFailedPrecondition, "referenced resource does not exist or is still referenced: failed precondition", ""},
+			{"check", &pgconn.PgError{Code: "23514", ConstraintName: "users_status_check"}, apperror.ErrInvalidArgument, "value out of range: invalid argument", ""},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				gormDB, mock := setupMockDB(t)
+				repo := repository.NewUserRepository(gormDB, &passthroughCB{}, &passthroughRetry{}, true)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "main"."users"`)).WillReturnError(tt.pgErr)
+				mock.ExpectRollback()
+
+				err := repo.Insert(ctx, &model.User{Id: 1})
+
+				assert.ErrorIs(t, err, tt.want)
+				assert.ErrorIs(t, err, tt.pgErr, "the driver error stays in the chain")
+				assert.EqualError(t, err, tt.message)
+				var constraintErr *repository.ConstraintError
+				require.ErrorAs(t, err, &constraintErr)
+				assert.Equal(t, tt.pgErr.ConstraintName, constraintErr.Constraint)
+				assert.Equal(t, tt.field, constraintErr.Field)
+				assert.False(t, repository.IsTransient(err))
+			})
+		}
+	})
+
+	t.Run("known constraints carry typed details", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		repo := repository.NewUserRepository(gormDB, &passthroughCB{}, &passthroughRetry{}, true)
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "main"."users"`)).WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"})
+		mock.ExpectRollback()
+
+		err := repo.Insert(ctx, &model.User{Id: 1})
+
+		var reasonErr *apperror.ReasonError
+		require.ErrorAs(t, err, &reasonErr)
+		assert.Equal(t, "EMAIL_TAKEN", reasonErr.Reason)
+		assert.Equal(t, map[string]string{"field": "email"}, reasonErr.Metadata)
+
+		ledgers := repository.NewLedgerRepository(gormDB, &passthroughCB{}, &passthroughRetry{}, true)
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "main"."ledgers"`)).WillReturnError(&pgconn.PgError{Code: "23514", ConstraintName: "ledgers_transaction_type_check"})
+		mock.ExpectRollback()
+
+		err = ledgers.Insert(ctx, &model.Ledger{Id: 1})
+
+		var validationErr *apperror.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []apperror.FieldViolation{{Field: "transaction_type", Reason: "invalid", Description: "must be deposit, withdraw or transfer"}}, validationErr.Violations)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("other permanent errors are not translated", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		repo := repository.NewUserRepository(gormDB, &passthroughCB{}, &passthroughRetry{}, true)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."users"`)).WillReturnError(&pgconn.PgError{Code: "42601"})
+
+		_, err := repo.Get(ctx, 1)
+
+		assert.False(t, errors.As(err, new(*repository.ConstraintError)))
+	})
+
+	t.Run("open circuit breaker is returned as ErrTransient", func(t *testing.T) {
+		gormDB, _ := setupMockDB(t)
+		repo := repository.NewUserRepository(gormDB, &openCB{}, &passthroughRetry{}, true)
+
+		_, err := repo.Get(ctx, 1)
+
+		assert.ErrorIs(t, err, circuitbreaker.ErrOpen)
+		assert.True(t, repository.IsTransient(err))
+	})
+}
+
+type openCB struct{}
+
+func (o *openCB) Execute(fn func() (any, error)) (any, error) { return nil, circuitbreaker.ErrOpen }
+func (o *openCB) State() circuitbreaker.State                 { return circuitbreaker.Open }
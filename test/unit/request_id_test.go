@@ -0,0 +1,183 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// headerStream captures headers set by interceptors.
+type headerStream struct {
+	trailerStream
+	header metadata.MD
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+// fieldLogger is a mockLogger remembering the fields it was derived With.
+// Error records the logger written to in logged, when set.
+type fieldLogger struct {
+	mockLogger
+	fields []observability.Field
+	logged *observability.Logger
+}
+
+func (l *fieldLogger) With(fields ...observability.Field) observability.Logger {
+	return &fieldLogger{fields: append(append([]observability.Field{}, l.fields...), fields...), logged: l.logged}
+}
+
+func (l *fieldLogger) Error(msg string, fields ...observability.Field) {
+	if l.logged != nil {
+		*l.logged = l
+	}
+}
+
+func TestRequestIdInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/proto.v1.UserService/GetUserById"}
+
+	call := func(t *testing.T, md metadata.MD) (string, observability.Logger, metadata.MD) {
+		t.Helper()
+		stream := &headerStream{}
+		ctx := grpc.NewContextWithServerTransportStream(metadata.NewIncomingContext(context.Background(), md), stream)
+
+		var id string
+		var log observability.Logger
+		_, err := interceptor.RequestIdInterceptor()(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+			id = observability.RequestIdFromContext(ctx)
+			log = observability.LoggerFromContext(ctx, &fieldLogger{fields: []observability.Field{observability.Module("service")}})
+			return nil, nil
+		})
+		require.NoError(t, err)
+		return id, log, stream.header
+	}
+
+	t.Run("keeps the client's id and echoes it", func(t *testing.T) {
+		id, log, header := call(t, metadata.Pairs("x-request-id", "req-1"))
+		assert.Equal(t, "req-1", id)
+		assert.Equal(t, []string{"req-1"}, header.Get("x-request-id"))
+		assert.Equal(t, []observability.Field{observability.Module("service"), observability.String("request_id", "req-1")}, log.(*fieldLogger).fields)
+	})
+
+	t.Run("generates an id when the client sends none or an unsafe one", func(t *testing.T) {
+		for _, md := range []metadata.MD{
+			nil,
+			metadata.Pairs("x-request-id", ""),
+			metadata.Pairs("x-request-id", "req 1\nforged log line"),
+			metadata.Pairs("x-request-id", strings.Repeat("a", 129)),
+		} {
+			id, _, header := call(t, md)
+			assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{32}$`), id)
+			assert.Equal(t, []string{id}, header.Get("x-request-id"))
+		}
+
+		first, _, _ := call(t, nil)
+		second, _, _ := call(t, nil)
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("query tags use the generated id", func(t *testing.T) {
+		var tags observability.QueryTags
+		chain := func(ctx context.Context) {
+			_, err := interceptor.RequestIdInterceptor()(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+				return interceptor.QueryTagInterceptor()(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+					tags = observability.QueryTagsFromContext(ctx)
+					return nil, nil
+				})
+			})
+			require.NoError(t, err)
+		}
+
+		chain(grpc.NewContextWithServerTransportStream(context.Background(), &headerStream{}))
+		assert.Len(t, tags.RequestId, 32)
+	})
+
+	t.Run("errors carry the id and are logged with it", func(t *testing.T) {
+		var log observability.Logger
+		ctx := observability.ContextWithRequestId(context.Background(), "req-1")
+		ctx = observability.ContextWithLogFields(ctx, observability.String("request_id", "req-1"))
+
+		_, err := interceptor.ErrorInterceptor(&fieldLogger{logged: &log})(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, errors.New("database exploded")
+		})
+		st, _ := status.FromError(err)
+		assert.Equal(t, codes.Internal, st.Code())
+		require.Len(t, st.Details(), 1)
+		assert.Equal(t, "req-1", st.Details()[0].(*errdetails.RequestInfo).RequestId)
+		require.NotNil(t, log)
+		assert.Equal(t, []observability.Field{observability.String("request_id", "req-1")}, log.(*fieldLogger).fields)
+	})
+}
+
+// headerServerStream is the server side of a stream, capturing its headers.
+type headerServerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *headerServerStream) Context() context.Context { return s.ctx }
+func (s *headerServerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestRequestIdStreamInterceptor(t *testing.T) {
+	info := &grpc.StreamServerInfo{FullMethod: "/proto.v1.UserService/ExportUserData", IsServerStream: true}
+	_, requestId := interceptor.RequestIdInterceptors()
+	_, queryTag := interceptor.QueryTagInterceptors()
+	errorStream := interceptor.ErrorStreamInterceptor(&mockLogger{})
+
+	// chain runs handler behind the interceptors in the order the server
+	// registers them.
+	chain := func(stream grpc.ServerStream, handler grpc.StreamHandler) error {
+		return requestId(nil, stream, info, func(srv any, stream grpc.ServerStream) error {
+			return queryTag(srv, stream, info, func(srv any, stream grpc.ServerStream) error {
+				return errorStream(srv, stream, info, handler)
+			})
+		})
+	}
+
+	t.Run("the stream's context carries the client's id", func(t *testing.T) {
+		stream := &headerServerStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "req-1"))}
+		var id string
+		var tags observability.QueryTags
+		err := chain(stream, func(srv any, stream grpc.ServerStream) error {
+			id = observability.RequestIdFromContext(stream.Context())
+			tags = observability.QueryTagsFromContext(stream.Context())
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "req-1", id)
+		assert.Equal(t, observability.QueryTags{Method: info.FullMethod, RequestId: "req-1"}, tags)
+		assert.Equal(t, []string{"req-1"}, stream.header.Get("x-request-id"))
+	})
+
+	t.Run("a failed stream returns its generated id as RequestInfo", func(t *testing.T) {
+		stream := &headerServerStream{ctx: context.Background()}
+		err := chain(stream, func(srv any, stream grpc.ServerStream) error {
+			return errors.New("database exploded")
+		})
+		st, _ := status.FromError(err)
+		assert.Equal(t, codes.Internal, st.Code())
+		require.Len(t, st.Details(), 1)
+		id := stream.header.Get("x-request-id")
+		require.Len(t, id, 1)
+		assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{32}$`), id[0])
+		assert.Equal(t, id[0], st.Details()[0].(*errdetails.RequestInfo).RequestId)
+	})
+}
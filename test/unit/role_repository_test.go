@@ -0,0 +1,76 @@
+package unit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("permissions are the distinct permissions of the roles", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewRoleRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT "permission" FROM "main"."role_permissions" WHERE role IN ($1,$2) ORDER BY permission`)).
+			WithArgs("support", "auditor").
+			WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("audit.read").AddRow("users.read"))
+
+		permissions, err := repo.Permissions(ctx, []string{"support", "auditor"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"audit.read", "users.read"}, permissions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no roles carry no permissions", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewRoleRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		permissions, err := repo.Permissions(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, permissions)
+		assert.NoError(t, mock.ExpectationsWereMet(), "no query is run")
+	})
+
+	t.Run("list by user selects the assigned roles", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewRoleRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."roles" WHERE name IN (SELECT "role" FROM "main"."user_roles" WHERE user_id = $1) ORDER BY name`)).
+			WithArgs(int64(7)).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "created_at", "created_by"}).AddRow("support", time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), "service:ops"))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."role_permissions" WHERE role IN ($1) ORDER BY permission`)).
+			WithArgs("support").
+			WillReturnRows(sqlmock.NewRows([]string{"role", "permission"}).AddRow("support", "users.read").AddRow("support", "users.unlock"))
+
+		roles, err := repo.ListByUser(ctx, 7)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, "support", roles[0].Name)
+		assert.Equal(t, []string{"users.read", "users.unlock"}, roles[0].Permissions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unassign reports whether the user held the role", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewRoleRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "main"."user_roles" WHERE user_id = $1 AND role = $2`)).
+			WithArgs(int64(7), "support").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		unassigned, err := repo.Unassign(ctx, 7, "support")
+		require.NoError(t, err)
+		assert.True(t, unassigned)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,194 @@
+package unit
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/authz"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRoleRepository struct {
+	roles    map[string]*model.Role
+	assigned map[int64][]string
+	lookups  int
+}
+
+func (m *mockRoleRepository) Get(ctx context.Context, name string) (*model.Role, error) {
+	return m.roles[name], nil
+}
+
+func (m *mockRoleRepository) List(ctx context.Context) ([]*model.Role, error) {
+	var roles []*model.Role
+	for _, name := range slices.Sorted(maps.Keys(m.roles)) {
+		roles = append(roles, m.roles[name])
+	}
+	return roles, nil
+}
+
+func (m *mockRoleRepository) ListByUser(ctx context.Context, userId int64) ([]*model.Role, error) {
+	m.lookups++
+	var roles []*model.Role
+	for _, name := range m.assigned[userId] {
+		roles = append(roles, m.roles[name])
+	}
+	return roles, nil
+}
+
+func (m *mockRoleRepository) Put(ctx context.Context, role *model.Role) error {
+	m.roles[role.Name] = role
+	return nil
+}
+
+func (m *mockRoleRepository) Permissions(ctx context.Context, roles []string) ([]string, error) {
+	var permissions []string
+	for _, name := range roles {
+		if role := m.roles[name]; role != nil {
+			permissions = append(permissions, role.Permissions...)
+		}
+	}
+	slices.Sort(permissions)
+	return slices.Compact(permissions), nil
+}
+
+func (m *mockRoleRepository) Assign(ctx context.Context, assignment *model.UserRole) (bool, error) {
+	if slices.Contains(m.assigned[assignment.UserId], assignment.Role) {
+		return false, nil
+	}
+	m.assigned[assignment.UserId] = append(m.assigned[assignment.UserId], assignment.Role)
+	return true, nil
+}
+
+func (m *mockRoleRepository) Unassign(ctx context.Context, userId int64, role string) (bool, error) {
+	i := slices.Index(m.assigned[userId], role)
+	if i < 0 {
+		return false, nil
+	}
+	m.assigned[userId] = slices.Delete(m.assigned[userId], i, i+1)
+	return true, nil
+}
+
+func TestRoleService(t *testing.T) {
+	ctx := context.Background()
+	setup := func() (service.RoleService, *mockRoleRepository) {
+		roles := &mockRoleRepository{roles: map[string]*model.Role{}, assigned: map[int64][]string{}}
+		users := &mockUserRepository{getFunc: func(ctx context.Context, id int64) (*model.User, error) {
+			if id != 1 {
+				return nil, nil
+			}
+			return &model.User{Id: id}, nil
+		}}
+		factory := &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) {
+			return &mockUnitOfWork{
+				userRepo:   users,
+				roleRepo:   roles,
+				commitFunc: func(ctx context.Context) error { return nil },
+				abortFunc:  func(ctx context.Context) error { return nil },
+			}, nil
+		}}
+		return service.NewRoleService(factory, time.Minute, &mockLogger{}), roles
+	}
+
+	t.Run("users get their assigned roles and the permissions of all their roles", func(t *testing.T) {
+		svc, _ := setup()
+		_, err := svc.PutRole(ctx, "support", []string{"users.suspend", "users.read", "users.read"})
+		require.NoError(t, err)
+		_, err = svc.PutRole(ctx, "auditor", []string{"audit.read"})
+		require.NoError(t, err)
+		assigned, err := svc.AssignRole(ctx, 1, "support")
+		require.NoError(t, err)
+		assert.True(t, assigned)
+
+		access, err := svc.Resolve(ctx, "user:1", []string{"auditor"})
+		require.NoError(t, err)
+		assert.Equal(t, authz.Access{
+			Roles:       []string{"auditor", "support"},
+			Permissions: []string{"audit.read", "users.read", "users.suspend"},
+		}, access)
+
+		access, err = svc.Resolve(ctx, "service:ops", []string{"support"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"support"}, access.Roles, "only users are assigned roles")
+		assert.Equal(t, []string{"users.read", "users.suspend"}, access.Permissions)
+	})
+
+	t.Run("access is cached until roles change", func(t *testing.T) {
+		svc, roles := setup()
+		_, err := svc.PutRole(ctx, "support", []string{"users.read"})
+		require.NoError(t, err)
+
+		for range 3 {
+			access, err := svc.Resolve(ctx, "user:1", nil)
+			require.NoError(t, err)
+			assert.Empty(t, access.Roles)
+		}
+		assert.Equal(t, 1, roles.lookups)
+
+		_, err = svc.AssignRole(ctx, 1, "support")
+		require.NoError(t, err)
+		access, err := svc.Resolve(ctx, "user:1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"support"}, access.Roles)
+		assert.Equal(t, 2, roles.lookups)
+
+		unassigned, err := svc.UnassignRole(ctx, 1, "support")
+		require.NoError(t, err)
+		assert.True(t, unassigned)
+		access, err = svc.Resolve(ctx, "user:1", nil)
+		require.NoError(t, err)
+		assert.Empty(t, access.Roles)
+	})
+
+	t.Run("assigning fails for a missing user or role", func(t *testing.T) {
+		svc, _ := setup()
+		_, err := svc.PutRole(ctx, "support", nil)
+		require.NoError(t, err)
+
+		_, err = svc.AssignRole(ctx, 2, "support")
+		assert.ErrorIs(t, err, apperror.ErrNotFound)
+		_, err = svc.AssignRole(ctx, 1, "root")
+		assert.ErrorIs(t, err, apperror.ErrNotFound)
+
+		assigned, err := svc.AssignRole(ctx, 1, "support")
+		require.NoError(t, err)
+		assert.True(t, assigned)
+		assigned, err = svc.AssignRole(ctx, 1, "support")
+		require.NoError(t, err)
+		assert.False(t, assigned)
+	})
+
+	t.Run("invalid permissions are rejected", func(t *testing.T) {
+		svc, roles := setup()
+		_, err := svc.PutRole(ctx, "support", []string{"users.read", ""})
+		assert.ErrorIs(t, err, apperror.ErrInvalidArgument)
+		assert.Empty(t, roles.roles)
+	})
+
+	t.Run("lists all roles or a user's", func(t *testing.T) {
+		svc, _ := setup()
+		for _, name := range []string{"support", "auditor"} {
+			_, err := svc.PutRole(ctx, name, nil)
+			require.NoError(t, err)
+		}
+		_, err := svc.AssignRole(ctx, 1, "support")
+		require.NoError(t, err)
+
+		all, err := svc.ListRoles(ctx, 0)
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+		assert.Equal(t, "auditor", all[0].Name)
+
+		assigned, err := svc.ListRoles(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, assigned, 1)
+		assert.Equal(t, "support", assigned[0].Name)
+	})
+}
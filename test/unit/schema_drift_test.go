@@ -0,0 +1,159 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSchemaRepository struct {
+	describeFunc       func(ctx context.Context, schemaName string, tables []string) ([]model.TableSchema, error)
+	tableStatsFunc     func(ctx context.Context, schemaName string, tables []string) ([]*model.TableStats, error)
+	slowStatementsFunc func(ctx context.Context, limit int) ([]*model.StatementStats, error)
+}
+
+func (m *mockSchemaRepository) Describe(ctx context.Context, schemaName string, tables []string) ([]model.TableSchema, error) {
+	return m.describeFunc(ctx, schemaName, tables)
+}
+
+func (m *mockSchemaRepository) TableStats(ctx context.Context, schemaName string, tables []string) ([]*model.TableStats, error) {
+	return m.tableStatsFunc(ctx, schemaName, tables)
+}
+
+func (m *mockSchemaRepository) SlowStatements(ctx context.Context, limit int) ([]*model.StatementStats, error) {
+	if m.slowStatementsFunc == nil {
+		return nil, nil
+	}
+	return m.slowStatementsFunc(ctx, limit)
+}
+
+func TestSchemaRepository_Describe(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := repository.NewSchemaRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+	mock.ExpectQuery(`FROM information_schema.columns\s+WHERE table_schema = \$1 AND table_name IN \(\$2,\$3\)`).
+		WithArgs("main", "ledgers", "users").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "data_type", "character_maximum_length", "numeric_precision", "numeric_scale", "is_nullable"}).
+			AddRow("ledgers", "id", "bigint", nil, 64, 0, "NO").
+			AddRow("ledgers", "amount", "numeric", nil, 36, 18, "NO").
+			AddRow("users", "email", "character varying", 255, nil, nil, "NO").
+			AddRow("users", "deleted_at", "timestamp with time zone", nil, nil, nil, "YES"))
+	mock.ExpectQuery(`FROM pg_indexes\s+WHERE schemaname = \$1 AND tablename IN \(\$2,\$3\)`).
+		WithArgs("main", "ledgers", "users").
+		WillReturnRows(sqlmock.NewRows([]string{"tablename", "indexname"}).
+			AddRow("ledgers", "ledgers_pkey").
+			AddRow("users", "users_pkey"))
+
+	tables, err := repo.Describe(context.Background(), "main", []string{"ledgers", "users"})
+	require.NoError(t, err)
+	assert.Equal(t, []model.TableSchema{
+		{
+			Name: "ledgers",
+			Columns: []model.ColumnSchema{
+				{Name: "id", Type: "bigint"},
+				{Name: "amount", Type: "numeric(36,18)"},
+			},
+			Indexes: []string{"ledgers_pkey"},
+		},
+		{
+			Name: "users",
+			Columns: []model.ColumnSchema{
+				{Name: "email", Type: "character varying(255)"},
+				{Name: "deleted_at", Type: "timestamp with time zone", Nullable: true},
+			},
+			Indexes: []string{"users_pkey"},
+		},
+	}, tables)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSchemaDriftService(t *testing.T) {
+	ctx := context.Background()
+	expected := []model.TableSchema{
+		{
+			Name: "ledgers",
+			Columns: []model.ColumnSchema{
+				{Name: "id", Type: "bigint"},
+				{Name: "token", Type: "character varying(32)"},
+				{Name: "amount", Type: "numeric(36,18)"},
+				{Name: "note", Type: "text", Nullable: true},
+			},
+			Indexes: []string{"ledgers_pkey", "ledgers_user_id_token_idx"},
+		},
+		{Name: "inbox_messages", Columns: []model.ColumnSchema{{Name: "handler", Type: "character varying(255)"}}},
+	}
+
+	t.Run("matching schema has no drift", func(t *testing.T) {
+		var gotSchema string
+		var gotTables []string
+		svc := service.NewSchemaDriftService(service.SchemaStore{
+			Name:     "postgresql",
+			Schema:   "main",
+			Expected: expected,
+			Repository: &mockSchemaRepository{describeFunc: func(ctx context.Context, schemaName string, tables []string) ([]model.TableSchema, error) {
+				gotSchema, gotTables = schemaName, tables
+				return expected, nil
+			}},
+		})
+
+		drifts, err := svc.CheckSchemaDrift(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, drifts)
+		assert.Equal(t, "main", gotSchema)
+		assert.Equal(t, []string{"ledgers", "inbox_messages"}, gotTables)
+	})
+
+	t.Run("hand-applied changes are reported", func(t *testing.T) {
+		live := []model.TableSchema{{
+			Name: "ledgers",
+			Columns: []model.ColumnSchema{
+				{Name: "id", Type: "bigint"},
+				{Name: "token", Type: "character varying(64)"},
+				{Name: "amount", Type: "numeric(36,18)", Nullable: true},
+				{Name: "hotfix_flag", Type: "boolean", Nullable: true},
+			},
+			Indexes: []string{"ledgers_pkey", "ledgers_hotfix_idx"},
+		}}
+		svc := service.NewSchemaDriftService(service.SchemaStore{
+			Name:     "postgresql_ledger",
+			Schema:   "ledger",
+			Expected: expected,
+			Repository: &mockSchemaRepository{describeFunc: func(ctx context.Context, schemaName string, tables []string) ([]model.TableSchema, error) {
+				return live, nil
+			}},
+		})
+
+		drifts, err := svc.CheckSchemaDrift(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*model.SchemaDrift{
+			{Store: "postgresql_ledger", Object: "ledgers.token", Kind: model.SchemaDriftColumnType, Expected: "character varying(32)", Actual: "character varying(64)"},
+			{Store: "postgresql_ledger", Object: "ledgers.amount", Kind: model.SchemaDriftColumnNullability, Expected: "NOT NULL", Actual: "NULL"},
+			{Store: "postgresql_ledger", Object: "ledgers.note", Kind: model.SchemaDriftMissingColumn, Expected: "text"},
+			{Store: "postgresql_ledger", Object: "ledgers.hotfix_flag", Kind: model.SchemaDriftUnexpectedColumn, Actual: "boolean"},
+			{Store: "postgresql_ledger", Object: "ledgers_user_id_token_idx", Kind: model.SchemaDriftMissingIndex, Expected: "ledgers"},
+			{Store: "postgresql_ledger", Object: "ledgers_hotfix_idx", Kind: model.SchemaDriftUnexpectedIndex, Actual: "ledgers"},
+			{Store: "postgresql_ledger", Object: "inbox_messages", Kind: model.SchemaDriftMissingTable},
+		}, drifts)
+	})
+
+	t.Run("catalog error fails the check", func(t *testing.T) {
+		dbErr := errors.New("connection refused")
+		svc := service.NewSchemaDriftService(service.SchemaStore{
+			Name:     "postgresql",
+			Expected: expected,
+			Repository: &mockSchemaRepository{describeFunc: func(ctx context.Context, schemaName string, tables []string) ([]model.TableSchema, error) {
+				return nil, dbErr
+			}},
+		})
+
+		_, err := svc.CheckSchemaDrift(ctx)
+		assert.ErrorIs(t, err, dbErr)
+	})
+}
@@ -0,0 +1,41 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pgdriver "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestNamingStrategy(t *testing.T) {
+	tests := []struct {
+		name       string
+		schemaName string
+		entity     any
+		want       string
+	}{
+		{"default schema users", model.DefaultSchema, &model.UserDataEntity{}, `SELECT * FROM "main"."users"`},
+		{"custom schema users", "tenant_a", &model.UserDataEntity{}, `SELECT * FROM "tenant_a"."users"`},
+		{"custom schema ledgers", "public", &[]model.LedgerDataEntity{}, `SELECT * FROM "public"."ledgers"`},
+		{"custom schema idempotency records", "tenant_a", &model.IdempotencyRecordDataEntity{}, `SELECT * FROM "tenant_a"."idempotency_records"`},
+		{"empty schema resolves through search_path", "", &model.UserDataEntity{}, `SELECT * FROM "users"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB, _, err := sqlmock.New()
+			require.NoError(t, err)
+			t.Cleanup(func() { sqlDB.Close() })
+
+			db, err := gorm.Open(pgdriver.New(pgdriver.Config{Conn: sqlDB}), &gorm.Config{NamingStrategy: model.NamingStrategy(tt.schemaName)})
+			require.NoError(t, err)
+
+			stmt := db.Session(&gorm.Session{DryRun: true}).Find(tt.entity).Statement
+			assert.Equal(t, tt.want, stmt.SQL.String())
+		})
+	}
+}
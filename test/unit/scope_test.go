@@ -0,0 +1,99 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func dryRunSQL(t *testing.T, scopes ...repository.Scope) (string, []any) {
+	t.Helper()
+	db, _ := setupMockDB(t)
+	stmt := db.Session(&gorm.Session{DryRun: true}).Scopes(scopes...).Find(&[]model.LedgerDataEntity{}).Statement
+	return stmt.SQL.String(), stmt.Vars
+}
+
+func TestScopes(t *testing.T) {
+	const (
+		id        repository.Column[int64]     = "id"
+		token     repository.Column[string]    = "token"
+		createdAt repository.Column[time.Time] = "created_at"
+	)
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+
+	t.Run("no scopes", func(t *testing.T) {
+		sql, vars := dryRunSQL(t)
+		assert.Equal(t, `SELECT * FROM "main"."ledgers"`, sql)
+		assert.Empty(t, vars)
+	})
+
+	t.Run("Eq", func(t *testing.T) {
+		sql, vars := dryRunSQL(t, repository.Eq(id, int64(1)))
+		assert.Equal(t, `SELECT * FROM "main"."ledgers" WHERE id = $1`, sql)
+		assert.Equal(t, []any{int64(1)}, vars)
+	})
+
+	t.Run("zero values are skipped", func(t *testing.T) {
+		sql, vars := dryRunSQL(t,
+			repository.Eq(id, 0),
+			repository.Eq(token, ""),
+			repository.Gte(createdAt, time.Time{}),
+			repository.In(id, nil),
+			repository.Limit(0),
+			repository.Select(nil),
+		)
+		assert.Equal(t, `SELECT * FROM "main"."ledgers"`, sql)
+		assert.Empty(t, vars)
+	})
+
+	t.Run("range", func(t *testing.T) {
+		sql, vars := dryRunSQL(t, repository.Gte(createdAt, since), repository.Lt(createdAt, until))
+		assert.Equal(t, `SELECT * FROM "main"."ledgers" WHERE created_at >= $1 AND created_at < $2`, sql)
+		assert.Equal(t, []any{since, until}, vars)
+	})
+
+	t.Run("Gt", func(t *testing.T) {
+		sql, vars := dryRunSQL(t, repository.Gt(id, int64(100)))
+		assert.Equal(t, `SELECT * FROM "main"."ledgers" WHERE id > $1`, sql)
+		assert.Equal(t, []any{int64(100)}, vars)
+	})
+
+	t.Run("IsNull", func(t *testing.T) {
+		sql, vars := dryRunSQL(t, repository.IsNull(createdAt))
+		assert.Equal(t, `SELECT * FROM "main"."ledgers" WHERE created_at IS NULL`, sql)
+		assert.Empty(t, vars)
+	})
+
+	t.Run("In", func(t *testing.T) {
+		sql, vars := dryRunSQL(t, repository.In(token, []string{"BTC", "ETH"}))
+		assert.Equal(t, `SELECT * FROM "main"."ledgers" WHERE token IN ($1,$2)`, sql)
+		assert.Equal(t, []any{"BTC", "ETH"}, vars)
+	})
+
+	t.Run("Select", func(t *testing.T) {
+		sql, vars := dryRunSQL(t, repository.Select(repository.Projection{"id", "token"}), repository.Eq(id, int64(1)))
+		assert.Equal(t, `SELECT "id","token" FROM "main"."ledgers" WHERE id = $1`, sql)
+		assert.Equal(t, []any{int64(1)}, vars)
+	})
+
+	t.Run("order and limit", func(t *testing.T) {
+		sql, _ := dryRunSQL(t, repository.OrderBy(createdAt, true), repository.OrderBy(id, false), repository.Limit(10))
+		assert.Equal(t, `SELECT * FROM "main"."ledgers" ORDER BY created_at DESC,id LIMIT $1`, sql)
+	})
+
+	t.Run("combined", func(t *testing.T) {
+		sql, vars := dryRunSQL(t,
+			repository.Eq(token, "BTC"),
+			repository.In(id, []int64{1, 2}),
+			repository.Gte(createdAt, since),
+			repository.OrderBy(createdAt, true),
+		)
+		assert.Equal(t, `SELECT * FROM "main"."ledgers" WHERE token = $1 AND id IN ($2,$3) AND created_at >= $4 ORDER BY created_at DESC`, sql)
+		assert.Equal(t, []any{"BTC", int64(1), int64(2), since}, vars)
+	})
+}
@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// requestServerStream is a grpc.ServerStream receiving a single
+// ExportUserDataRequest.
+type requestServerStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	request *v1.ExportUserDataRequest
+}
+
+func (s *requestServerStream) Context() context.Context { return s.ctx }
+func (s *requestServerStream) RecvMsg(m any) error {
+	m.(*v1.ExportUserDataRequest).Id = s.request.Id
+	return nil
+}
+
+type streamContextKey struct{}
+
+func TestUnaryOnServerStreams(t *testing.T) {
+	serverStream := &grpc.StreamServerInfo{FullMethod: v1.UserService_ExportUserData_FullMethodName, IsServerStream: true}
+	var seen []string
+	tag := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			seen = append(seen, name+":"+info.FullMethod)
+			return handler(context.WithValue(ctx, streamContextKey{}, name), req)
+		}
+	}
+	reject := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if req.(*v1.ExportUserDataRequest).Id == 0 {
+			return nil, status.Error(codes.InvalidArgument, "id is required")
+		}
+		return handler(ctx, req)
+	}
+	handler := func(srv any, ss grpc.ServerStream) error {
+		var request v1.ExportUserDataRequest
+		if err := ss.RecvMsg(&request); err != nil {
+			return err
+		}
+		seen = append(seen, "handler:"+ss.Context().Value(streamContextKey{}).(string))
+		return nil
+	}
+
+	t.Run("runs the interceptors on the request", func(t *testing.T) {
+		seen = nil
+		i := interceptor.UnaryOnServerStreams(tag("first"), reject, tag("second"))
+		stream := &requestServerStream{ctx: context.Background(), request: &v1.ExportUserDataRequest{Id: 7}}
+		require.NoError(t, i(nil, stream, serverStream, handler))
+		assert.Equal(t, []string{
+			"first:" + v1.UserService_ExportUserData_FullMethodName,
+			"second:" + v1.UserService_ExportUserData_FullMethodName,
+			"handler:second",
+		}, seen)
+	})
+
+	t.Run("fails the receive with the interceptor error", func(t *testing.T) {
+		seen = nil
+		i := interceptor.UnaryOnServerStreams(tag("first"), reject)
+		stream := &requestServerStream{ctx: context.Background(), request: &v1.ExportUserDataRequest{}}
+		err := i(nil, stream, serverStream, handler)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, []string{"first:" + v1.UserService_ExportUserData_FullMethodName}, seen)
+	})
+
+	t.Run("passes client streams through", func(t *testing.T) {
+		seen = nil
+		i := interceptor.UnaryOnServerStreams(reject)
+		stream := &requestServerStream{ctx: context.WithValue(context.Background(), streamContextKey{}, "caller"), request: &v1.ExportUserDataRequest{}}
+		clientStream := &grpc.StreamServerInfo{FullMethod: "/proto.v1.UserService/Upload", IsClientStream: true}
+		require.NoError(t, i(nil, stream, clientStream, handler))
+		assert.Equal(t, []string{"handler:caller"}, seen)
+	})
+}
@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	t.Run("list active skips revoked sessions and orders by last seen", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewSessionRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."user_sessions" WHERE user_id = $1 AND revoked_at IS NULL ORDER BY last_seen_at DESC LIMIT $2`)).
+			WithArgs(int64(1), 100).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "user_agent", "ip", "created_at", "last_seen_at", "revoked_at"}).
+				AddRow(int64(10), int64(1), "curl/8.0", "10.0.0.1", now, now, nil))
+
+		sessions, err := repo.ListActive(ctx, 1, 100)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, int64(10), sessions[0].Id)
+		assert.Equal(t, "curl/8.0", sessions[0].UserAgent)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get by external id returns nil when there is none", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewSessionRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."user_sessions" WHERE issuer = $1 AND external_id = $2 LIMIT $3`)).
+			WithArgs("https://auth.example.com", "sid-1", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		session, err := repo.GetByExternalId(ctx, "https://auth.example.com", "sid-1")
+		require.NoError(t, err)
+		assert.Nil(t, session)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("touch only writes a stale active session", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewSessionRepository(db, &passthroughCB{}, &passthroughRetry{})
+		staleBefore := now.Add(-time.Minute)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "main"."user_sessions" SET "ip"=$1,"last_seen_at"=$2,"user_agent"=$3 WHERE id = $4 AND revoked_at IS NULL AND last_seen_at < $5`)).
+			WithArgs("10.0.0.2", now, "curl/8.1", int64(10), staleBefore).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.Touch(ctx, 10, "curl/8.1", "10.0.0.2", now, staleBefore))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revoke reports false for another user's session", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewSessionRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "main"."user_sessions" SET "revoked_at"=$1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`)).
+			WithArgs(now, int64(10), int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		revoked, err := repo.Revoke(ctx, 10, 2, now)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("revoke all returns the ids it revoked", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewSessionRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`UPDATE "main"."user_sessions" SET "revoked_at"=$1 WHERE user_id = $2 AND revoked_at IS NULL RETURNING "id"`)).
+			WithArgs(now, int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(10)).AddRow(int64(11)))
+		mock.ExpectCommit()
+
+		ids, err := repo.RevokeAll(ctx, 1, now)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{10, 11}, ids)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,197 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSessionRepository struct {
+	insertFunc      func(ctx context.Context, session *model.Session) error
+	getFunc         func(ctx context.Context, issuer, externalId string) (*model.Session, error)
+	listActiveFunc  func(ctx context.Context, userId int64, limit int) ([]*model.Session, error)
+	touchFunc       func(ctx context.Context, id int64, userAgent string, ip string, seenAt time.Time, staleBefore time.Time) error
+	revokeFunc      func(ctx context.Context, id int64, userId int64, revokedAt time.Time) (bool, error)
+	revokeAllFunc   func(ctx context.Context, userId int64, revokedAt time.Time) ([]int64, error)
+	insertEventFunc func(ctx context.Context, event *model.SessionEvent) error
+}
+
+func (m *mockSessionRepository) Insert(ctx context.Context, session *model.Session) error {
+	return m.insertFunc(ctx, session)
+}
+
+func (m *mockSessionRepository) GetByExternalId(ctx context.Context, issuer, externalId string) (*model.Session, error) {
+	return m.getFunc(ctx, issuer, externalId)
+}
+
+func (m *mockSessionRepository) ListActive(ctx context.Context, userId int64, limit int) ([]*model.Session, error) {
+	return m.listActiveFunc(ctx, userId, limit)
+}
+
+func (m *mockSessionRepository) Touch(ctx context.Context, id int64, userAgent string, ip string, seenAt time.Time, staleBefore time.Time) error {
+	return m.touchFunc(ctx, id, userAgent, ip, seenAt, staleBefore)
+}
+
+func (m *mockSessionRepository) Revoke(ctx context.Context, id int64, userId int64, revokedAt time.Time) (bool, error) {
+	return m.revokeFunc(ctx, id, userId, revokedAt)
+}
+
+func (m *mockSessionRepository) RevokeAll(ctx context.Context, userId int64, revokedAt time.Time) ([]int64, error) {
+	return m.revokeAllFunc(ctx, userId, revokedAt)
+}
+
+func (m *mockSessionRepository) InsertEvent(ctx context.Context, event *model.SessionEvent) error {
+	return m.insertEventFunc(ctx, event)
+}
+
+func sessionFactory(repo repository.SessionRepository) repository.UnitOfWorkFactory {
+	return &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) {
+		return &mockUnitOfWork{
+			sessionRepo: repo,
+			commitFunc:  func(ctx context.Context) error { return nil },
+			abortFunc:   func(ctx context.Context) error { return nil },
+		}, nil
+	}}
+}
+
+func TestSessionService(t *testing.T) {
+	ctx := context.Background()
+	device := service.Device{UserAgent: "curl/8.0", Ip: "10.0.0.1"}
+
+	t.Run("touch opens an unknown session with a started event", func(t *testing.T) {
+		var inserted *model.Session
+		var events []*model.SessionEvent
+		repo := &mockSessionRepository{
+			getFunc:         func(ctx context.Context, issuer, externalId string) (*model.Session, error) { return nil, nil },
+			insertFunc:      func(ctx context.Context, session *model.Session) error { inserted = session; return nil },
+			insertEventFunc: func(ctx context.Context, event *model.SessionEvent) error { events = append(events, event); return nil },
+		}
+		svc := service.NewSessionService(sessionFactory(repo), &mockSnowflake{id: 10}, &mockLogger{})
+
+		require.NoError(t, svc.Touch(ctx, 1, "https://auth.example.com", "sid-1", strings.Repeat("a", 600), "10.0.0.1"))
+		require.NotNil(t, inserted)
+		assert.Equal(t, int64(1), inserted.UserId)
+		assert.Equal(t, "https://auth.example.com", inserted.Issuer)
+		assert.Equal(t, "sid-1", inserted.ExternalId)
+		assert.Len(t, inserted.UserAgent, 512)
+		assert.Equal(t, inserted.CreatedAt, inserted.LastSeenAt)
+		require.Len(t, events, 1)
+		assert.Equal(t, model.SessionEventStarted, events[0].Event)
+		assert.Equal(t, inserted.Id, events[0].SessionId)
+		assert.Equal(t, "10.0.0.1", events[0].Ip)
+	})
+
+	t.Run("touch updates a known session at most once a minute", func(t *testing.T) {
+		var seenAt, staleBefore time.Time
+		repo := &mockSessionRepository{
+			getFunc: func(ctx context.Context, issuer, externalId string) (*model.Session, error) {
+				return &model.Session{Id: 10, UserId: 1}, nil
+			},
+			touchFunc: func(ctx context.Context, id int64, userAgent string, ip string, s time.Time, b time.Time) error {
+				assert.Equal(t, int64(10), id)
+				seenAt, staleBefore = s, b
+				return nil
+			},
+		}
+		svc := service.NewSessionService(sessionFactory(repo), &mockSnowflake{id: 10}, &mockLogger{})
+
+		require.NoError(t, svc.Touch(ctx, 1, "https://auth.example.com", "sid-1", device.UserAgent, device.Ip))
+		assert.Equal(t, time.Minute, seenAt.Sub(staleBefore))
+	})
+
+	t.Run("touch rejects a revoked or another user's session", func(t *testing.T) {
+		revokedAt := time.Now()
+		for _, session := range []*model.Session{{Id: 10, UserId: 1, RevokedAt: &revokedAt}, {Id: 10, UserId: 2}} {
+			repo := &mockSessionRepository{
+				getFunc: func(ctx context.Context, issuer, externalId string) (*model.Session, error) { return session, nil },
+			}
+			svc := service.NewSessionService(sessionFactory(repo), &mockSnowflake{id: 10}, &mockLogger{})
+
+			err := svc.Touch(ctx, 1, "https://auth.example.com", "sid-1", device.UserAgent, device.Ip)
+			assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+		}
+	})
+
+	t.Run("touch refreshes a session a concurrent request opened", func(t *testing.T) {
+		gets := 0
+		repo := &mockSessionRepository{
+			getFunc: func(ctx context.Context, issuer, externalId string) (*model.Session, error) {
+				gets++
+				if gets == 1 {
+					return nil, nil
+				}
+				return &model.Session{Id: 10, UserId: 1}, nil
+			},
+			insertFunc: func(ctx context.Context, session *model.Session) error {
+				return apperror.AlreadyExistsf("resource already exists")
+			},
+			touchFunc: func(ctx context.Context, id int64, userAgent string, ip string, seenAt time.Time, staleBefore time.Time) error {
+				return nil
+			},
+		}
+		svc := service.NewSessionService(sessionFactory(repo), &mockSnowflake{id: 10}, &mockLogger{})
+
+		require.NoError(t, svc.Touch(ctx, 1, "https://auth.example.com", "sid-1", device.UserAgent, device.Ip))
+		assert.Equal(t, 2, gets)
+	})
+
+	t.Run("list is bounded", func(t *testing.T) {
+		repo := &mockSessionRepository{
+			listActiveFunc: func(ctx context.Context, userId int64, limit int) ([]*model.Session, error) {
+				assert.Equal(t, 100, limit)
+				return []*model.Session{{Id: 10, UserId: userId}}, nil
+			},
+		}
+		svc := service.NewSessionService(sessionFactory(repo), &mockSnowflake{id: 10}, &mockLogger{})
+
+		sessions, err := svc.List(ctx, 1)
+		require.NoError(t, err)
+		assert.Len(t, sessions, 1)
+	})
+
+	t.Run("revoke records a revoked event with the acting device", func(t *testing.T) {
+		var events []*model.SessionEvent
+		repo := &mockSessionRepository{
+			revokeFunc: func(ctx context.Context, id int64, userId int64, revokedAt time.Time) (bool, error) {
+				return true, nil
+			},
+			insertEventFunc: func(ctx context.Context, event *model.SessionEvent) error { events = append(events, event); return nil },
+		}
+		svc := service.NewSessionService(sessionFactory(repo), &mockSnowflake{id: 20}, &mockLogger{})
+
+		revoked, err := svc.Revoke(ctx, 1, 10, device)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		require.Len(t, events, 1)
+		assert.Equal(t, model.SessionEvent{
+			Id:        20,
+			SessionId: 10,
+			UserId:    1,
+			Event:     model.SessionEventRevoked,
+			UserAgent: "curl/8.0",
+			Ip:        "10.0.0.1",
+			CreatedAt: events[0].CreatedAt,
+		}, *events[0])
+	})
+
+	t.Run("revoke of an unknown session records nothing", func(t *testing.T) {
+		repo := &mockSessionRepository{
+			revokeFunc: func(ctx context.Context, id int64, userId int64, revokedAt time.Time) (bool, error) {
+				return false, nil
+			},
+		}
+		svc := service.NewSessionService(sessionFactory(repo), &mockSnowflake{id: 20}, &mockLogger{})
+
+		revoked, err := svc.Revoke(ctx, 1, 10, device)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	})
+}
@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestSignatureInterceptor(t *testing.T) {
+	const method = "/proto.v1.UserService/GetUserById"
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	secret := []byte("partner-secret")
+	secrets := interceptor.StaticSigningSecrets{"partner": secret}
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	req := &v1.GetUserByIdRequest{Id: 42}
+
+	signedContext := func(t *testing.T, keyId string, signedAt time.Time, signedMethod string, signedReq *v1.GetUserByIdRequest) context.Context {
+		t.Helper()
+		signature, err := interceptor.SignRequest(secret, signedMethod, signedAt.Unix(), signedReq)
+		require.NoError(t, err)
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			interceptor.SignatureKeyHeader, keyId,
+			interceptor.SignatureTimestampHeader, strconv.FormatInt(signedAt.Unix(), 10),
+			interceptor.SignatureHeader, signature,
+		))
+	}
+	call := func(ctx context.Context, required []string) (interceptor.Caller, bool, error, *mockMeter) {
+		meter := &mockMeter{}
+		i := interceptor.SignatureInterceptor(secrets, required, meter, interceptor.WithSignatureClock(func() time.Time { return now }))
+		var caller interceptor.Caller
+		var authenticated bool
+		_, err := i(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+			caller, authenticated = interceptor.CallerFromContext(ctx)
+			return "ok", nil
+		})
+		return caller, authenticated, err, meter
+	}
+
+	t.Run("valid signature records the key as caller", func(t *testing.T) {
+		caller, authenticated, err, _ := call(signedContext(t, "partner", now.Add(-time.Minute), method, req), nil)
+		require.NoError(t, err)
+		assert.True(t, authenticated)
+		assert.Equal(t, interceptor.Caller{Kind: interceptor.CallerKindAPIKey, Id: "partner"}, caller)
+	})
+
+	t.Run("signature hex is case-insensitive", func(t *testing.T) {
+		signature, err := interceptor.SignRequest(secret, method, now.Unix(), req)
+		require.NoError(t, err)
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			interceptor.SignatureKeyHeader, "partner",
+			interceptor.SignatureTimestampHeader, strconv.FormatInt(now.Unix(), 10),
+			interceptor.SignatureHeader, strings.ToUpper(signature),
+		))
+		_, authenticated, err, _ := call(ctx, nil)
+		require.NoError(t, err)
+		assert.True(t, authenticated)
+	})
+
+	t.Run("unsigned request passes when not required", func(t *testing.T) {
+		_, authenticated, err, _ := call(context.Background(), []string{"/proto.v1.LedgerService/"})
+		require.NoError(t, err)
+		assert.False(t, authenticated)
+	})
+
+	t.Run("unsigned request is rejected when required", func(t *testing.T) {
+		for _, required := range []string{method, "/proto.v1.UserService/"} {
+			_, _, err, meter := call(context.Background(), []string{required})
+			assert.ErrorIs(t, err, apperror.ErrUnauthenticated, required)
+			assert.Equal(t, 1, meter.metrics["request_signature_failures_total"].observations["missing"])
+		}
+	})
+
+	t.Run("rejections", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			ctx    context.Context
+			reason string
+		}{
+			{"tampered request", signedContext(t, "partner", now, method, &v1.GetUserByIdRequest{Id: 43}), "mismatch"},
+			{"signed for another method", signedContext(t, "partner", now, "/proto.v1.UserService/UpdateUserStatus", req), "mismatch"},
+			{"stale timestamp", signedContext(t, "partner", now.Add(-6*time.Minute), method, req), "expired"},
+			{"future timestamp", signedContext(t, "partner", now.Add(6*time.Minute), method, req), "expired"},
+			{"unknown key", signedContext(t, "stranger", now, method, req), "unknown_key"},
+			{"incomplete headers", metadata.NewIncomingContext(context.Background(), metadata.Pairs(interceptor.SignatureKeyHeader, "partner")), "malformed"},
+			{"non-numeric timestamp", metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+				interceptor.SignatureKeyHeader, "partner",
+				interceptor.SignatureTimestampHeader, "yesterday",
+				interceptor.SignatureHeader, "00",
+			)), "malformed"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, authenticated, err, meter := call(tt.ctx, nil)
+				assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+				assert.False(t, authenticated)
+				assert.Equal(t, 1, meter.metrics["request_signature_failures_total"].observations[tt.reason])
+			})
+		}
+	})
+}
+
+func TestCanonicalRequest(t *testing.T) {
+	canonical, err := interceptor.CanonicalRequest("/proto.v1.UserService/GetUserById", 1767268800, &v1.GetUserByIdRequest{Id: 42})
+	require.NoError(t, err)
+	lines := strings.Split(string(canonical), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, []string{"v1", "/proto.v1.UserService/GetUserById", "1767268800"}, lines[:3])
+	assert.Len(t, lines[3], 64)
+}
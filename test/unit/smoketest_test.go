@@ -0,0 +1,121 @@
+package unit
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/internal/smoketest"
+	"github.com/jt828/go-grpc-template/pkg/observability/noop"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type probeUserServer struct {
+	v1.UnimplementedUserServiceServer
+}
+
+func (s *probeUserServer) GetUserById(ctx context.Context, req *v1.GetUserByIdRequest) (*v1.GetUserByIdResponse, error) {
+	return &v1.GetUserByIdResponse{Id: req.GetId(), PublicId: req.GetPublicId()}, nil
+}
+
+// startSmokeTarget serves the smoke-tested services over an in-memory
+// listener. register customises the server before it starts.
+func startSmokeTarget(t *testing.T, status grpc_health_v1.HealthCheckResponse_ServingStatus, opts []grpc.ServerOption, register func(s *grpc.Server), dialOpts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(opts...)
+	register(server)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", status)
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	dialOpts = append(dialOpts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	conn, err := grpc.NewClient("passthrough:///bufnet", dialOpts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func registerAll(s *grpc.Server) {
+	v1.RegisterUserServiceServer(s, &probeUserServer{})
+	v1.RegisterLedgerServiceServer(s, &v1.UnimplementedLedgerServiceServer{})
+}
+
+func checksByName(report *smoketest.Report) map[string]smoketest.Check {
+	checks := map[string]smoketest.Check{}
+	for _, c := range report.Checks {
+		checks[c.Name] = c
+	}
+	return checks
+}
+
+func TestSmoketest(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("healthy server passes every check", func(t *testing.T) {
+		conn := startSmokeTarget(t, grpc_health_v1.HealthCheckResponse_SERVING, nil, registerAll)
+
+		report := smoketest.Run(ctx, conn, "bufnet", smoketest.Config{ProbeUserId: 42})
+
+		assert.True(t, report.Passed)
+		assert.Equal(t, "bufnet", report.Target)
+		require.Len(t, report.Checks, 3)
+		for _, c := range report.Checks {
+			assert.True(t, c.Passed, "%s: %s", c.Name, c.Error)
+		}
+	})
+
+	t.Run("failures are reported per check", func(t *testing.T) {
+		conn := startSmokeTarget(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, nil, func(s *grpc.Server) {
+			v1.RegisterUserServiceServer(s, &v1.UnimplementedUserServiceServer{})
+		})
+
+		report := smoketest.Run(ctx, conn, "bufnet", smoketest.Config{ProbeUserPublicId: "abc"})
+
+		assert.False(t, report.Passed)
+		checks := checksByName(report)
+		assert.Contains(t, checks["reflection"].Error, v1.LedgerService_ServiceDesc.ServiceName)
+		assert.Contains(t, checks["health"].Error, "NOT_SERVING")
+		assert.False(t, checks["get_user_by_id"].Passed)
+	})
+
+	t.Run("AdminService must be kept off the target", func(t *testing.T) {
+		exposed := startSmokeTarget(t, grpc_health_v1.HealthCheckResponse_SERVING, nil, func(s *grpc.Server) {
+			registerAll(s)
+			v1.RegisterAdminServiceServer(s, &v1.UnimplementedAdminServiceServer{})
+		})
+		report := smoketest.Run(ctx, exposed, "bufnet", smoketest.Config{ProbeUserId: 42})
+		assert.Contains(t, checksByName(report)["reflection"].Error, "exposed on the public listener")
+	})
+
+	t.Run("probe lookup is signed by the signing client interceptor", func(t *testing.T) {
+		secrets := interceptor.StaticSigningSecrets{"smoke": []byte("s3cret")}
+		opts := []grpc.ServerOption{grpc.UnaryInterceptor(
+			interceptor.SignatureInterceptor(secrets, []string{v1.UserService_GetUserById_FullMethodName}, noop.NewMeter()),
+		)}
+
+		unsignedConn := startSmokeTarget(t, grpc_health_v1.HealthCheckResponse_SERVING, opts, registerAll)
+		unsigned := smoketest.Run(ctx, unsignedConn, "bufnet", smoketest.Config{ProbeUserId: 42})
+		assert.False(t, checksByName(unsigned)["get_user_by_id"].Passed)
+
+		signedConn := startSmokeTarget(t, grpc_health_v1.HealthCheckResponse_SERVING, opts, registerAll,
+			grpc.WithUnaryInterceptor(interceptor.SigningClientInterceptor("smoke", []byte("s3cret"))))
+		signed := smoketest.Run(ctx, signedConn, "bufnet", smoketest.Config{ProbeUserId: 42})
+		assert.True(t, signed.Passed, "%+v", signed.Checks)
+	})
+}
@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/statemachine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type door struct {
+	state  string
+	locked bool
+}
+
+func TestStateMachine(t *testing.T) {
+	ctx := context.Background()
+
+	newMachine := func(hooks *[]string) *statemachine.Machine[string, *door] {
+		m := statemachine.New(
+			func(d *door) string { return d.state },
+			func(d *door, s string) { d.state = s },
+			statemachine.Transition[string, *door]{
+				From: "closed",
+				To:   "open",
+				Guard: func(ctx context.Context, d *door) error {
+					if d.locked {
+						return errors.New("door is locked")
+					}
+					return nil
+				},
+				Hooks: []statemachine.Hook[string, *door]{
+					func(ctx context.Context, d *door, from, to string) error {
+						*hooks = append(*hooks, "opened")
+						return nil
+					},
+				},
+			},
+			statemachine.Transition[string, *door]{From: "open", To: "closed"},
+		)
+		m.OnTransition(func(ctx context.Context, d *door, from, to string) error {
+			*hooks = append(*hooks, from+"->"+to)
+			return nil
+		})
+		return m
+	}
+
+	t.Run("declared transition sets state and runs hooks in order", func(t *testing.T) {
+		var hooks []string
+		d := &door{state: "closed"}
+
+		require.NoError(t, newMachine(&hooks).Fire(ctx, d, "open"))
+		assert.Equal(t, "open", d.state)
+		assert.Equal(t, []string{"opened", "closed->open"}, hooks)
+	})
+
+	t.Run("undeclared transition is a conflict", func(t *testing.T) {
+		var hooks []string
+		d := &door{state: "open"}
+
+		err := newMachine(&hooks).Fire(ctx, d, "open")
+		assert.ErrorIs(t, err, statemachine.ErrInvalidTransition)
+		assert.ErrorIs(t, err, apperror.ErrConflict)
+		assert.Equal(t, "open", d.state)
+		assert.Empty(t, hooks)
+	})
+
+	t.Run("guard vetoes transition", func(t *testing.T) {
+		var hooks []string
+		d := &door{state: "closed", locked: true}
+
+		err := newMachine(&hooks).Fire(ctx, d, "open")
+		assert.EqualError(t, err, "door is locked")
+		assert.Equal(t, "closed", d.state)
+		assert.Empty(t, hooks)
+	})
+
+	t.Run("hook error restores previous state", func(t *testing.T) {
+		var hooks []string
+		m := newMachine(&hooks)
+		hookErr := errors.New("audit write failed")
+		m.OnTransition(func(ctx context.Context, d *door, from, to string) error { return hookErr })
+		d := &door{state: "open"}
+
+		assert.ErrorIs(t, m.Fire(ctx, d, "closed"), hookErr)
+		assert.Equal(t, "open", d.state)
+	})
+
+	t.Run("can reports declared transitions", func(t *testing.T) {
+		m := newMachine(&[]string{})
+		assert.True(t, m.Can("closed", "open"))
+		assert.False(t, m.Can("open", "open"))
+	})
+}
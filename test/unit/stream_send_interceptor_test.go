@@ -0,0 +1,108 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// blockingServerStream is a grpc.ServerStream whose sends wait until release
+// is closed, as they do while the client's flow-control window is full.
+type blockingServerStream struct {
+	grpc.ServerStream
+	release chan struct{}
+	sent    int
+}
+
+func (s *blockingServerStream) Context() context.Context { return context.Background() }
+func (s *blockingServerStream) SendMsg(m any) error {
+	<-s.release
+	s.sent++
+	return nil
+}
+
+func TestStreamSendInterceptor(t *testing.T) {
+	method := v1.UserService_ExportUserData_FullMethodName
+	serverStream := &grpc.StreamServerInfo{FullMethod: method, IsServerStream: true}
+	chunk := &v1.ExportUserDataResponse{Chunk: []byte("{\"user\":{}}\n")}
+	send := func(times int) grpc.StreamHandler {
+		return func(srv any, ss grpc.ServerStream) error {
+			for range times {
+				if err := ss.SendMsg(chunk); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	t.Run("counts what streams send", func(t *testing.T) {
+		meter := &mockMeter{}
+		stream := &blockingServerStream{release: make(chan struct{})}
+		close(stream.release)
+		i := interceptor.StreamSendInterceptor(time.Second, meter)
+
+		require.NoError(t, i(nil, stream, serverStream, send(3)))
+		assert.Equal(t, 3, stream.sent)
+		assert.Equal(t, 3, meter.metrics["grpc_stream_sent_messages_total"].observations[method])
+		assert.Equal(t, 3, meter.metrics["grpc_stream_sent_bytes_total"].observations[method])
+		assert.Equal(t, float64(proto.Size(chunk)), meter.metrics["grpc_stream_sent_bytes_total"].values[method], "each send adds its size")
+		assert.Equal(t, 3, meter.metrics["grpc_stream_send_seconds"].observations[method])
+		assert.Equal(t, 6, meter.metrics["grpc_stream_buffered_messages"].observations[method])
+		assert.Zero(t, meter.metrics["grpc_stream_buffered_messages"].values[method], "every message left the buffer")
+		assert.Equal(t, 1, meter.metrics["grpc_stream_throughput_bytes_per_second"].observations[method])
+		assert.Positive(t, meter.metrics["grpc_stream_throughput_bytes_per_second"].values[method])
+		assert.Empty(t, meter.metrics["grpc_stream_send_timeouts_total"].observations)
+	})
+
+	t.Run("aborts streams whose client stopped reading", func(t *testing.T) {
+		meter := &mockMeter{}
+		stream := &blockingServerStream{release: make(chan struct{})}
+		defer close(stream.release)
+		i := interceptor.StreamSendInterceptor(20*time.Millisecond, meter)
+
+		var errs []error
+		err := i(nil, stream, serverStream, func(srv any, ss grpc.ServerStream) error {
+			errs = append(errs, ss.SendMsg(chunk), ss.SendMsg(chunk))
+			return errs[0]
+		})
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		assert.True(t, apperror.HasReason(err, "STREAM_SEND_TIMEOUT"))
+		assert.Equal(t, errs[0], errs[1], "later sends fail without waiting")
+		assert.Equal(t, 1, meter.metrics["grpc_stream_send_timeouts_total"].observations[method])
+		assert.Empty(t, meter.metrics["grpc_stream_sent_messages_total"].observations)
+		assert.Empty(t, meter.metrics["grpc_stream_throughput_bytes_per_second"].observations)
+	})
+
+	t.Run("without a timeout sends wait for the client", func(t *testing.T) {
+		meter := &mockMeter{}
+		stream := &blockingServerStream{release: make(chan struct{})}
+		time.AfterFunc(30*time.Millisecond, func() { close(stream.release) })
+		i := interceptor.StreamSendInterceptor(0, meter)
+
+		require.NoError(t, i(nil, stream, serverStream, send(1)))
+		assert.Equal(t, 1, stream.sent)
+		assert.GreaterOrEqual(t, meter.metrics["grpc_stream_send_seconds"].values[method], 0.03)
+	})
+
+	t.Run("passes client streams through", func(t *testing.T) {
+		meter := &mockMeter{}
+		stream := &blockingServerStream{release: make(chan struct{})}
+		close(stream.release)
+		i := interceptor.StreamSendInterceptor(time.Second, meter)
+
+		clientStream := &grpc.StreamServerInfo{FullMethod: "/proto.v1.UserService/Upload", IsClientStream: true}
+		require.NoError(t, i(nil, stream, clientStream, send(1)))
+		assert.Empty(t, meter.metrics["grpc_stream_sent_messages_total"].observations)
+	})
+}
@@ -0,0 +1,155 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaRepository_TableStats(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := repository.NewSchemaRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+	mock.ExpectQuery(`FROM pg_stat_user_tables\s+WHERE schemaname = \$1 AND relname IN \(\$2,\$3\)`).
+		WithArgs("main", "ledgers", "users").
+		WillReturnRows(sqlmock.NewRows([]string{"table", "seq_scans", "seq_tuples_read", "index_scans", "live_tuples"}).
+			AddRow("ledgers", 120, 2400000, 3, 20000).
+			AddRow("users", 1, 10, 500, 100))
+
+	stats, err := repo.TableStats(context.Background(), "main", []string{"ledgers", "users"})
+	require.NoError(t, err)
+	assert.Equal(t, []*model.TableStats{
+		{Table: "ledgers", SeqScans: 120, SeqTuplesRead: 2400000, IndexScans: 3, LiveTuples: 20000},
+		{Table: "users", SeqScans: 1, SeqTuplesRead: 10, IndexScans: 500, LiveTuples: 100},
+	}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSchemaRepository_SlowStatements(t *testing.T) {
+	extensionSQL := `SELECT EXISTS \(SELECT 1 FROM pg_extension WHERE extname = 'pg_stat_statements'\)`
+
+	t.Run("reads pg_stat_statements when installed", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewSchemaRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectQuery(extensionSQL).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery(`FROM pg_stat_statements\s+WHERE dbid = .+ORDER BY mean_exec_time DESC\s+LIMIT \$1`).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"queryid", "query", "calls", "rows", "mean_exec_time", "total_exec_time"}).
+				AddRow(42, `SELECT * FROM "main"."ledgers" WHERE token = $1`, 10, 30, 250.5, 2505.0))
+
+		statements, err := repo.SlowStatements(context.Background(), 5)
+		require.NoError(t, err)
+		assert.Equal(t, []*model.StatementStats{{
+			QueryId:   42,
+			Query:     `SELECT * FROM "main"."ledgers" WHERE token = $1`,
+			Calls:     10,
+			Rows:      30,
+			MeanTime:  250500 * time.Microsecond,
+			TotalTime: 2505 * time.Millisecond,
+		}}, statements)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("returns nothing without the extension", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewSchemaRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectQuery(extensionSQL).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		statements, err := repo.SlowStatements(context.Background(), 5)
+		require.NoError(t, err)
+		assert.Nil(t, statements)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTableStatsService(t *testing.T) {
+	ctx := context.Background()
+	expected := []model.TableSchema{{Name: "ledgers"}, {Name: "users"}}
+
+	newService := func(samples [][]*model.TableStats, statements []*model.StatementStats) (service.TableStatsService, *mockMeter, *mockLogger) {
+		call := 0
+		repo := &mockSchemaRepository{
+			tableStatsFunc: func(ctx context.Context, schemaName string, tables []string) ([]*model.TableStats, error) {
+				assert.Equal(t, []string{"ledgers", "users"}, tables)
+				stats := samples[call]
+				call++
+				return stats, nil
+			},
+			slowStatementsFunc: func(ctx context.Context, limit int) ([]*model.StatementStats, error) {
+				return statements, nil
+			},
+		}
+		meter := &mockMeter{}
+		log := &mockLogger{}
+		stores := []service.SchemaStore{{Name: "postgresql", Schema: "main", Expected: expected, Repository: repo}}
+		return service.NewTableStatsService(stores, meter, log), meter, log
+	}
+
+	t.Run("exports counters per table", func(t *testing.T) {
+		svc, meter, log := newService([][]*model.TableStats{{
+			{Table: "ledgers", SeqScans: 120, SeqTuplesRead: 2400000, IndexScans: 3, LiveTuples: 20000},
+		}}, nil)
+
+		require.NoError(t, svc.Sample(ctx))
+		assert.Equal(t, float64(120), meter.metrics["db_table_seq_scans"].values["ledgers"])
+		assert.Equal(t, float64(2400000), meter.metrics["db_table_seq_tuples_read"].values["ledgers"])
+		assert.Equal(t, float64(3), meter.metrics["db_table_index_scans"].values["ledgers"])
+		assert.Equal(t, float64(20000), meter.metrics["db_table_live_tuples"].values["ledgers"])
+		assert.Empty(t, log.warnCalls, "first sample has no interval to judge")
+	})
+
+	t.Run("warns when sequential scans dominate a large table", func(t *testing.T) {
+		svc, _, log := newService([][]*model.TableStats{
+			{{Table: "ledgers", SeqScans: 100, IndexScans: 50, LiveTuples: 20000}, {Table: "users", SeqScans: 10, IndexScans: 0, LiveTuples: 50}},
+			{{Table: "ledgers", SeqScans: 140, IndexScans: 60, LiveTuples: 20000}, {Table: "users", SeqScans: 90, IndexScans: 0, LiveTuples: 50}},
+		}, nil)
+
+		require.NoError(t, svc.Sample(ctx))
+		require.NoError(t, svc.Sample(ctx))
+		assert.Equal(t, []string{"sequential scans dominate reads on a large table, an index may be missing"}, log.warnCalls)
+	})
+
+	t.Run("index-backed reads and statistics resets are not flagged", func(t *testing.T) {
+		svc, _, log := newService([][]*model.TableStats{
+			{{Table: "ledgers", SeqScans: 100, IndexScans: 50, LiveTuples: 20000}},
+			{{Table: "ledgers", SeqScans: 101, IndexScans: 500, LiveTuples: 20000}},
+			{{Table: "ledgers", SeqScans: 5, IndexScans: 0, LiveTuples: 20000}},
+		}, nil)
+
+		for range 3 {
+			require.NoError(t, svc.Sample(ctx))
+		}
+		assert.Empty(t, log.warnCalls)
+	})
+
+	t.Run("logs statements above the slow threshold", func(t *testing.T) {
+		svc, _, log := newService([][]*model.TableStats{nil}, []*model.StatementStats{
+			{QueryId: 1, Query: "SELECT 1", MeanTime: 300 * time.Millisecond},
+			{QueryId: 2, Query: "SELECT 2", MeanTime: 150 * time.Millisecond},
+			{QueryId: 3, Query: "SELECT 3", MeanTime: 5 * time.Millisecond},
+		})
+
+		require.NoError(t, svc.Sample(ctx))
+		assert.Equal(t, []string{"slow statement", "slow statement"}, log.warnCalls)
+	})
+
+	t.Run("catalog error fails the sample", func(t *testing.T) {
+		dbErr := errors.New("connection refused")
+		repo := &mockSchemaRepository{tableStatsFunc: func(ctx context.Context, schemaName string, tables []string) ([]*model.TableStats, error) {
+			return nil, dbErr
+		}}
+		svc := service.NewTableStatsService([]service.SchemaStore{{Name: "postgresql", Expected: expected, Repository: repo}}, &mockMeter{}, &mockLogger{})
+
+		assert.ErrorIs(t, svc.Sample(ctx), dbErr)
+	})
+}
@@ -0,0 +1,31 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantLabels(t *testing.T) {
+	tenants := observability.NewTenantLabels([]string{"beta", "acme"})
+
+	t.Run("allow-listed tenants keep their value", func(t *testing.T) {
+		assert.Equal(t, observability.Label{Key: "tenant", Value: "acme"}, tenants.Label("acme"))
+	})
+
+	t.Run("other tenants are bucketed", func(t *testing.T) {
+		assert.Equal(t, observability.Label{Key: "tenant", Value: "other"}, tenants.Label("globex"))
+		assert.Equal(t, observability.Label{Key: "tenant", Value: "other"}, tenants.Label(""))
+	})
+
+	t.Run("nil labels bucket every tenant", func(t *testing.T) {
+		var none *observability.TenantLabels
+		assert.Equal(t, "other", none.Label("acme").Value)
+		assert.Empty(t, none.Allowed())
+	})
+
+	t.Run("allowed is sorted", func(t *testing.T) {
+		assert.Equal(t, []string{"acme", "beta"}, tenants.Allowed())
+	})
+}
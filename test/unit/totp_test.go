@@ -0,0 +1,53 @@
+package unit
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTP(t *testing.T) {
+	// The RFC 6238 appendix B SHA1 secret; its eight-digit code at T=59 is
+	// 94287082.
+	secret := []byte("12345678901234567890")
+	at := time.Unix(59, 0)
+
+	t.Run("matches the RFC 6238 test vector", func(t *testing.T) {
+		assert.Equal(t, "287082", totp.Code(secret, totp.Step(at)))
+	})
+
+	t.Run("accepts codes within the skew", func(t *testing.T) {
+		code := totp.Code(secret, totp.Step(at))
+		for _, offset := range []time.Duration{-totp.Period, 0, totp.Period} {
+			step, ok := totp.Validate(secret, code, at.Add(offset), 0)
+			assert.True(t, ok)
+			assert.Equal(t, totp.Step(at), step)
+		}
+		_, ok := totp.Validate(secret, code, at.Add(2*totp.Period), 0)
+		assert.False(t, ok)
+		_, ok = totp.Validate(secret, "000000", at, 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("rejects a replayed code", func(t *testing.T) {
+		code := totp.Code(secret, totp.Step(at))
+		step, ok := totp.Validate(secret, code, at, 0)
+		require.True(t, ok)
+		_, ok = totp.Validate(secret, code, at, step)
+		assert.False(t, ok)
+	})
+
+	t.Run("uri carries the secret and issuer", func(t *testing.T) {
+		u, err := url.Parse(totp.URI("go-grpc-template", "alice", secret))
+		require.NoError(t, err)
+		assert.Equal(t, "otpauth", u.Scheme)
+		assert.Equal(t, "totp", u.Host)
+		assert.Equal(t, "/go-grpc-template:alice", u.Path)
+		assert.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", u.Query().Get("secret"))
+		assert.Equal(t, "go-grpc-template", u.Query().Get("issuer"))
+	})
+}
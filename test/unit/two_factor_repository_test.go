@@ -0,0 +1,83 @@
+package unit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoFactorRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	t.Run("get returns nil when not enrolled", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewTwoFactorRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."user_two_factor" WHERE user_id = $1 LIMIT $2`)).
+			WithArgs(int64(1), 1).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "secret", "last_used_step", "created_at", "enabled_at"}))
+
+		twoFactor, err := repo.Get(ctx, 1)
+		require.NoError(t, err)
+		assert.Nil(t, twoFactor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("enable only confirms a pending enrollment with a newer step", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewTwoFactorRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "main"."user_two_factor" SET "enabled_at"=$1,"last_used_step"=$2 WHERE user_id = $3 AND enabled_at IS NULL AND last_used_step < $4`)).
+			WithArgs(now, int64(100), int64(1), int64(100)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		enabled, err := repo.Enable(ctx, 1, 100, now)
+		require.NoError(t, err)
+		assert.False(t, enabled)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("use recovery code marks an unused code used", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewTwoFactorRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "main"."user_recovery_codes" SET "used_at"=$1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL`)).
+			WithArgs(now, int64(1), "hash").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		used, err := repo.UseRecoveryCode(ctx, 1, "hash", now)
+		require.NoError(t, err)
+		assert.True(t, used)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("replace recovery codes discards the old ones", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewTwoFactorRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "main"."user_recovery_codes" WHERE user_id = $1`)).
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 10))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "main"."user_recovery_codes" ("user_id","code_hash","used_at") VALUES ($1,$2,$3),($4,$5,$6)`)).
+			WithArgs(int64(1), "a", nil, int64(1), "b", nil).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.ReplaceRecoveryCodes(ctx, 1, []string{"a", "b"}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
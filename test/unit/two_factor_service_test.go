@@ -0,0 +1,229 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/fieldcrypto"
+	fieldcryptoImpl "github.com/jt828/go-grpc-template/pkg/fieldcrypto/implementation"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockTwoFactorRepository struct {
+	getFunc                  func(ctx context.Context, userId int64) (*model.TwoFactor, error)
+	upsertFunc               func(ctx context.Context, twoFactor *model.TwoFactor) error
+	enableFunc               func(ctx context.Context, userId int64, step int64, enabledAt time.Time) (bool, error)
+	useStepFunc              func(ctx context.Context, userId int64, step int64) (bool, error)
+	deleteFunc               func(ctx context.Context, userId int64) error
+	replaceRecoveryCodesFunc func(ctx context.Context, userId int64, codeHashes []string) error
+	useRecoveryCodeFunc      func(ctx context.Context, userId int64, codeHash string, usedAt time.Time) (bool, error)
+}
+
+func (m *mockTwoFactorRepository) Get(ctx context.Context, userId int64) (*model.TwoFactor, error) {
+	return m.getFunc(ctx, userId)
+}
+
+func (m *mockTwoFactorRepository) Upsert(ctx context.Context, twoFactor *model.TwoFactor) error {
+	return m.upsertFunc(ctx, twoFactor)
+}
+
+func (m *mockTwoFactorRepository) Enable(ctx context.Context, userId int64, step int64, enabledAt time.Time) (bool, error) {
+	return m.enableFunc(ctx, userId, step, enabledAt)
+}
+
+func (m *mockTwoFactorRepository) UseStep(ctx context.Context, userId int64, step int64) (bool, error) {
+	return m.useStepFunc(ctx, userId, step)
+}
+
+func (m *mockTwoFactorRepository) Delete(ctx context.Context, userId int64) error {
+	return m.deleteFunc(ctx, userId)
+}
+
+func (m *mockTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userId int64, codeHashes []string) error {
+	return m.replaceRecoveryCodesFunc(ctx, userId, codeHashes)
+}
+
+func (m *mockTwoFactorRepository) UseRecoveryCode(ctx context.Context, userId int64, codeHash string, usedAt time.Time) (bool, error) {
+	return m.useRecoveryCodeFunc(ctx, userId, codeHash, usedAt)
+}
+
+// recordingThrottle allows every check and records outcomes.
+type recordingThrottle struct {
+	checkErr  error
+	failures  int
+	successes int
+}
+
+func (t *recordingThrottle) Check(ctx context.Context, userId int64, ip string) error {
+	return t.checkErr
+}
+
+func (t *recordingThrottle) RecordFailure(ctx context.Context, userId int64, ip string) error {
+	t.failures++
+	return nil
+}
+
+func (t *recordingThrottle) RecordSuccess(ctx context.Context, userId int64, ip string) error {
+	t.successes++
+	return nil
+}
+
+func (t *recordingThrottle) Unlock(ctx context.Context, userId int64) (int64, error) {
+	return 0, nil
+}
+
+func twoFactorFactory(users repository.UserRepository, repo repository.TwoFactorRepository) repository.UnitOfWorkFactory {
+	return &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) {
+		return &mockUnitOfWork{
+			userRepo:      users,
+			twoFactorRepo: repo,
+			commitFunc:    func(ctx context.Context) error { return nil },
+			abortFunc:     func(ctx context.Context) error { return nil },
+		}, nil
+	}}
+}
+
+func TestTwoFactorService(t *testing.T) {
+	ctx := context.Background()
+	cipher, err := fieldcryptoImpl.NewAESGCM(fieldcrypto.WithKey("k1", bytes.Repeat([]byte{1}, 32)))
+	require.NoError(t, err)
+	secret := []byte("12345678901234567890")
+	encrypted, err := cipher.Encrypt(secret, []byte("user_two_factor:1"))
+	require.NoError(t, err)
+	enabledAt := time.Now().Add(-time.Hour)
+	pending := func() *model.TwoFactor { return &model.TwoFactor{UserId: 1, Secret: encrypted} }
+	enabled := func() *model.TwoFactor { return &model.TwoFactor{UserId: 1, Secret: encrypted, EnabledAt: &enabledAt} }
+	users := &mockUserRepository{getFunc: func(ctx context.Context, id int64) (*model.User, error) {
+		return &model.User{Id: id, Username: "alice"}, nil
+	}}
+
+	t.Run("enroll stores an encrypted secret and returns it for the app", func(t *testing.T) {
+		var stored *model.TwoFactor
+		repo := &mockTwoFactorRepository{
+			getFunc:    func(ctx context.Context, userId int64) (*model.TwoFactor, error) { return nil, nil },
+			upsertFunc: func(ctx context.Context, twoFactor *model.TwoFactor) error { stored = twoFactor; return nil },
+		}
+		svc := service.NewTwoFactorService(twoFactorFactory(users, repo), cipher, &recordingThrottle{}, "template", &mockLogger{})
+
+		enrollment, err := svc.Enroll(ctx, 1)
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Nil(t, stored.EnabledAt)
+		plaintext, err := cipher.Decrypt(stored.Secret, []byte("user_two_factor:1"))
+		require.NoError(t, err)
+		assert.Equal(t, base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(plaintext), enrollment.Secret)
+		uri, err := url.Parse(enrollment.URI)
+		require.NoError(t, err)
+		assert.Equal(t, "/template:alice", uri.Path)
+	})
+
+	t.Run("enroll refuses when already enabled", func(t *testing.T) {
+		repo := &mockTwoFactorRepository{getFunc: func(ctx context.Context, userId int64) (*model.TwoFactor, error) { return enabled(), nil }}
+		svc := service.NewTwoFactorService(twoFactorFactory(users, repo), cipher, &recordingThrottle{}, "template", &mockLogger{})
+
+		_, err := svc.Enroll(ctx, 1)
+		assert.ErrorIs(t, err, apperror.ErrFailedPrecondition)
+	})
+
+	t.Run("verify enables with a current code and returns recovery codes", func(t *testing.T) {
+		var hashes []string
+		repo := &mockTwoFactorRepository{
+			getFunc: func(ctx context.Context, userId int64) (*model.TwoFactor, error) { return pending(), nil },
+			enableFunc: func(ctx context.Context, userId int64, step int64, enabledAt time.Time) (bool, error) {
+				assert.Equal(t, totp.Step(time.Now()), step)
+				return true, nil
+			},
+			replaceRecoveryCodesFunc: func(ctx context.Context, userId int64, codeHashes []string) error {
+				hashes = codeHashes
+				return nil
+			},
+		}
+		throttle := &recordingThrottle{}
+		svc := service.NewTwoFactorService(twoFactorFactory(users, repo), cipher, throttle, "template", &mockLogger{})
+
+		codes, err := svc.Verify(ctx, 1, "10.0.0.1", totp.Code(secret, totp.Step(time.Now())))
+		require.NoError(t, err)
+		assert.Len(t, codes, 10)
+		assert.Len(t, hashes, 10)
+		assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, codes[0])
+		assert.NotContains(t, hashes, codes[0])
+		assert.Equal(t, 1, throttle.successes)
+	})
+
+	t.Run("verify rejects a wrong code and records the failure", func(t *testing.T) {
+		repo := &mockTwoFactorRepository{getFunc: func(ctx context.Context, userId int64) (*model.TwoFactor, error) { return pending(), nil }}
+		throttle := &recordingThrottle{}
+		svc := service.NewTwoFactorService(twoFactorFactory(users, repo), cipher, throttle, "template", &mockLogger{})
+
+		_, err := svc.Verify(ctx, 1, "10.0.0.1", "000000")
+		assert.ErrorIs(t, err, apperror.ErrInvalidArgument)
+		assert.Equal(t, 1, throttle.failures)
+	})
+
+	t.Run("locked out callers are not checked", func(t *testing.T) {
+		throttle := &recordingThrottle{checkErr: apperror.ErrResourceExhausted}
+		svc := service.NewTwoFactorService(twoFactorFactory(users, &mockTwoFactorRepository{}), cipher, throttle, "template", &mockLogger{})
+
+		_, err := svc.Verify(ctx, 1, "10.0.0.1", "123456")
+		assert.ErrorIs(t, err, apperror.ErrResourceExhausted)
+		assert.ErrorIs(t, svc.Disable(ctx, 1, "10.0.0.1", "123456"), apperror.ErrResourceExhausted)
+	})
+
+	t.Run("disable accepts an unused recovery code", func(t *testing.T) {
+		var deleted bool
+		repo := &mockTwoFactorRepository{
+			getFunc: func(ctx context.Context, userId int64) (*model.TwoFactor, error) { return enabled(), nil },
+			useRecoveryCodeFunc: func(ctx context.Context, userId int64, codeHash string, usedAt time.Time) (bool, error) {
+				return true, nil
+			},
+			deleteFunc: func(ctx context.Context, userId int64) error { deleted = true; return nil },
+		}
+		svc := service.NewTwoFactorService(twoFactorFactory(users, repo), cipher, &recordingThrottle{}, "template", &mockLogger{})
+
+		require.NoError(t, svc.Disable(ctx, 1, "10.0.0.1", "ABCDE-FGHIJ"))
+		assert.True(t, deleted)
+	})
+
+	t.Run("recovery codes match regardless of case and dash", func(t *testing.T) {
+		var hashes []string
+		repo := &mockTwoFactorRepository{
+			getFunc: func(ctx context.Context, userId int64) (*model.TwoFactor, error) { return enabled(), nil },
+			useRecoveryCodeFunc: func(ctx context.Context, userId int64, codeHash string, usedAt time.Time) (bool, error) {
+				hashes = append(hashes, codeHash)
+				return false, nil
+			},
+		}
+		svc := service.NewTwoFactorService(twoFactorFactory(users, repo), cipher, &recordingThrottle{}, "template", &mockLogger{})
+
+		assert.ErrorIs(t, svc.Disable(ctx, 1, "10.0.0.1", "ABCDE-FGHIJ"), apperror.ErrUnauthenticated)
+		assert.ErrorIs(t, svc.Disable(ctx, 1, "10.0.0.1", "abcdefghij"), apperror.ErrUnauthenticated)
+		require.Len(t, hashes, 2)
+		assert.Equal(t, hashes[0], hashes[1])
+	})
+
+	t.Run("disable rejects a replayed code", func(t *testing.T) {
+		repo := &mockTwoFactorRepository{
+			getFunc: func(ctx context.Context, userId int64) (*model.TwoFactor, error) {
+				twoFactor := enabled()
+				twoFactor.LastUsedStep = totp.Step(time.Now())
+				return twoFactor, nil
+			},
+		}
+		throttle := &recordingThrottle{}
+		svc := service.NewTwoFactorService(twoFactorFactory(users, repo), cipher, throttle, "template", &mockLogger{})
+
+		err := svc.Disable(ctx, 1, "10.0.0.1", totp.Code(secret, totp.Step(time.Now())))
+		assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+		assert.Equal(t, 1, throttle.failures)
+	})
+}
@@ -0,0 +1,181 @@
+package unit
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timeoutArg matches a millisecond timeout no longer than max.
+type timeoutArg struct {
+	max time.Duration
+}
+
+func (a timeoutArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
+	ms, err := strconv.ParseInt(s, 10, 64)
+	return err == nil && ms > 0 && ms <= a.max.Milliseconds()+1
+}
+
+func TestTransactionDbUnitOfWorkFactory_DeadlineTimeouts(t *testing.T) {
+	setTimeouts := regexp.QuoteMeta("SELECT set_config('statement_timeout', $1, true), set_config('idle_in_transaction_session_timeout', $2, true)")
+
+	t.Run("transaction timeouts follow the context deadline", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		factory := repository.NewTransactionDbUnitOfWorkFactory(db, &passthroughCB{}, &passthroughRetry{}, repository.WithDeadlineTimeouts())
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(setTimeouts).
+			WithArgs(timeoutArg{max: 2 * time.Second}, timeoutArg{max: 2 * time.Second}).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := factory.New(ctx)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("context without a deadline keeps the server's timeouts", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		factory := repository.NewTransactionDbUnitOfWorkFactory(db, &passthroughCB{}, &passthroughRetry{}, repository.WithDeadlineTimeouts())
+
+		mock.ExpectBegin()
+
+		_, err := factory.New(context.Background())
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("expired deadline does not begin a transaction", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		factory := repository.NewTransactionDbUnitOfWorkFactory(db, &passthroughCB{}, &passthroughRetry{}, repository.WithDeadlineTimeouts())
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		_, err := factory.New(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failure to set timeouts rolls back", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		factory := repository.NewTransactionDbUnitOfWorkFactory(db, &passthroughCB{}, &passthroughRetry{}, repository.WithDeadlineTimeouts())
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		setErr := errors.New("connection reset")
+
+		mock.ExpectBegin()
+		mock.ExpectExec(setTimeouts).WillReturnError(setErr)
+		mock.ExpectRollback()
+
+		_, err := factory.New(ctx)
+		assert.ErrorIs(t, err, setErr)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("timeouts are left alone without the option", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		factory := repository.NewTransactionDbUnitOfWorkFactory(db, &passthroughCB{}, &passthroughRetry{})
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		mock.ExpectBegin()
+
+		_, err := factory.New(ctx)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTransactionDbUnitOfWorkFactory_IdRegeneration(t *testing.T) {
+	ctx := context.Background()
+	savepoint := `SAVEPOINT sp\d+`
+	rollbackTo := `ROLLBACK TO SAVEPOINT sp\d+`
+	insert := regexp.QuoteMeta(`INSERT INTO "main"."user_sessions"`)
+	collision := &pgconn.PgError{Code: "23505", ConstraintName: "user_sessions_pkey"}
+
+	setup := func(t *testing.T) (repository.UnitOfWork, sqlmock.Sqlmock, *mockMeter) {
+		db, mock := setupMockDB(t)
+		meter := &mockMeter{}
+		factory := repository.NewTransactionDbUnitOfWorkFactory(db, &passthroughCB{}, &passthroughRetry{},
+			repository.WithIdRegeneration(&mockSnowflake{id: 20}, 2, meter))
+		mock.ExpectBegin()
+		uow, err := factory.New(ctx)
+		require.NoError(t, err)
+		return uow, mock, meter
+	}
+
+	t.Run("a taken id is replaced and the insert retried", func(t *testing.T) {
+		uow, mock, meter := setup(t)
+		mock.ExpectExec(savepoint).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(insert).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), int64(10)).
+			WillReturnError(collision)
+		mock.ExpectExec(rollbackTo).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(savepoint).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(insert).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), int64(20)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(20)))
+
+		session := &model.Session{Id: 10, UserId: 1}
+		require.NoError(t, uow.SessionRepository().Insert(ctx, session))
+		assert.Equal(t, int64(20), session.Id, "the caller sees the id written")
+		assert.Equal(t, float64(1), meter.metrics["repository_id_collisions_total"].values["user_sessions"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("users are covered", func(t *testing.T) {
+		uow, mock, meter := setup(t)
+		userInsert := regexp.QuoteMeta(`INSERT INTO "main"."users"`)
+		mock.ExpectExec(savepoint).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(userInsert).WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "users_pkey"})
+		mock.ExpectExec(rollbackTo).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(savepoint).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(userInsert).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(20)))
+
+		user := &model.User{Id: 10, Email: "alice@example.com"}
+		require.NoError(t, uow.UserRepository().Insert(ctx, user))
+		assert.Equal(t, int64(20), user.Id)
+		assert.Equal(t, float64(1), meter.metrics["repository_id_collisions_total"].values["users"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("collisions beyond the attempts fail the insert", func(t *testing.T) {
+		uow, mock, _ := setup(t)
+		for range 2 {
+			mock.ExpectExec(savepoint).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(insert).WillReturnError(collision)
+			mock.ExpectExec(rollbackTo).WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+
+		err := uow.SessionRepository().Insert(ctx, &model.Session{Id: 10, UserId: 1})
+		assert.ErrorIs(t, err, apperror.ErrAlreadyExists)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("other unique violations are not retried", func(t *testing.T) {
+		uow, mock, meter := setup(t)
+		mock.ExpectExec(savepoint).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(insert).WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "user_sessions_token_key"})
+		mock.ExpectExec(rollbackTo).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := uow.SessionRepository().Insert(ctx, &model.Session{Id: 10, UserId: 1})
+		assert.ErrorIs(t, err, apperror.ErrAlreadyExists)
+		assert.Empty(t, meter.metrics["repository_id_collisions_total"].values)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
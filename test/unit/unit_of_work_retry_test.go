@@ -0,0 +1,150 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunInUnitOfWorkWithRetry(t *testing.T) {
+	ctx := context.Background()
+	serializationErr := &repository.ErrTransient{Cause: &pgconn.PgError{Code: "40001"}}
+
+	// newFactory hands out a fresh unit of work per New call and records
+	// how each one ended.
+	newFactory := func(commit func(attempt int) error) (*mockUnitOfWorkFactory, *[]string) {
+		var outcome []string
+		attempt := 0
+		return &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) {
+			attempt++
+			n := attempt
+			return &mockUnitOfWork{
+				commitFunc: func(ctx context.Context) error {
+					if err := commit(n); err != nil {
+						outcome = append(outcome, "commit failed")
+						return err
+					}
+					outcome = append(outcome, "commit")
+					return nil
+				},
+				abortFunc: func(ctx context.Context) error { outcome = append(outcome, "abort"); return nil },
+			}, nil
+		}}, &outcome
+	}
+	commitOK := func(int) error { return nil }
+
+	t.Run("commits on success", func(t *testing.T) {
+		factory, outcome := newFactory(commitOK)
+
+		result, err := service.RunInUnitOfWorkWithRetry(ctx, factory, 3, func(uow repository.UnitOfWork) (string, error) {
+			return "done", nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "done", result)
+		assert.Equal(t, []string{"commit"}, *outcome)
+	})
+
+	t.Run("serialization failure restarts the transaction in a fresh unit of work", func(t *testing.T) {
+		factory, outcome := newFactory(commitOK)
+		var seen []repository.UnitOfWork
+
+		result, err := service.RunInUnitOfWorkWithRetry(ctx, factory, 3, func(uow repository.UnitOfWork) (int, error) {
+			seen = append(seen, uow)
+			if len(seen) < 3 {
+				return 0, serializationErr
+			}
+			return len(seen), nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result)
+		assert.Equal(t, []string{"abort", "abort", "commit"}, *outcome)
+		assert.NotSame(t, seen[0], seen[1])
+	})
+
+	t.Run("deadlock on commit is retried", func(t *testing.T) {
+		factory, outcome := newFactory(func(attempt int) error {
+			if attempt == 1 {
+				return &pgconn.PgError{Code: "40P01"}
+			}
+			return nil
+		})
+
+		_, err := service.RunInUnitOfWorkWithRetry(ctx, factory, 3, func(uow repository.UnitOfWork) (string, error) {
+			return "done", nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"commit failed", "commit"}, *outcome)
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		factory, outcome := newFactory(commitOK)
+		calls := 0
+
+		_, err := service.RunInUnitOfWorkWithRetry(ctx, factory, 2, func(uow repository.UnitOfWork) (string, error) {
+			calls++
+			return "", serializationErr
+		})
+		assert.ErrorIs(t, err, serializationErr)
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, []string{"abort", "abort"}, *outcome)
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		factory, outcome := newFactory(commitOK)
+		boom := errors.New("boom")
+		calls := 0
+
+		_, err := service.RunInUnitOfWorkWithRetry(ctx, factory, 3, func(uow repository.UnitOfWork) (string, error) {
+			calls++
+			return "", boom
+		})
+		assert.ErrorIs(t, err, boom)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, []string{"abort"}, *outcome)
+	})
+
+	t.Run("partial commit is not retried", func(t *testing.T) {
+		factory, _ := newFactory(func(int) error {
+			return &repository.PartialCommitError{Committed: []string{"main"}, Failed: "ledger", Err: &pgconn.PgError{Code: "40001"}}
+		})
+		calls := 0
+
+		_, err := service.RunInUnitOfWorkWithRetry(ctx, factory, 3, func(uow repository.UnitOfWork) (string, error) {
+			calls++
+			return "done", nil
+		})
+		assert.ErrorIs(t, err, repository.ErrPartialCommit)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("canceled context stops retrying", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		factory, _ := newFactory(commitOK)
+		calls := 0
+
+		_, err := service.RunInUnitOfWorkWithRetry(ctx, factory, 3, func(uow repository.UnitOfWork) (string, error) {
+			calls++
+			cancel()
+			return "", serializationErr
+		})
+		assert.ErrorIs(t, err, serializationErr)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("factory error is propagated", func(t *testing.T) {
+		factoryErr := errors.New("begin failed")
+		factory := &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return nil, factoryErr }}
+
+		_, err := service.RunInUnitOfWorkWithRetry(ctx, factory, 3, func(uow repository.UnitOfWork) (string, error) {
+			t.Fatal("fn should not run without a unit of work")
+			return "", nil
+		})
+		assert.ErrorIs(t, err, factoryErr)
+	})
+}
@@ -0,0 +1,78 @@
+package unit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageRepository(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+
+	t.Run("add upserts onto the stored totals", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewUsageRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "main"."api_usage" ("usage_date","caller","method","requests","units") VALUES ($1,$2,$3,$4,$5),($6,$7,$8,$9,$10) ON CONFLICT ("usage_date","caller","method") DO UPDATE SET "requests"=api_usage.requests + excluded.requests,"units"=api_usage.units + excluded.units`)).
+			WithArgs(day, "api_key:partner", "/a", int64(2), int64(10), day, "api_key:partner", "/b", int64(1), int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		err := repo.Add(ctx, []*model.ApiUsage{
+			{UsageDate: day, Caller: "api_key:partner", Method: "/a", Requests: 2, Units: 10},
+			{UsageDate: day, Caller: "api_key:partner", Method: "/b", Requests: 1, Units: 1},
+		})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("empty add is a no-op", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewUsageRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		require.NoError(t, repo.Add(ctx, nil))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unreported dates exclude reported days", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewUsageRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT "usage_date" FROM "main"."api_usage" WHERE usage_date NOT IN (SELECT "usage_date" FROM "main"."api_usage_reports") AND usage_date < $1 ORDER BY usage_date`)).
+			WithArgs(day).
+			WillReturnRows(sqlmock.NewRows([]string{"usage_date"}).AddRow(day.AddDate(0, 0, -2)).AddRow(day.AddDate(0, 0, -1)))
+
+		dates, err := repo.UnreportedDates(ctx, day)
+		require.NoError(t, err)
+		assert.Equal(t, []time.Time{day.AddDate(0, 0, -2), day.AddDate(0, 0, -1)}, dates)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("mark reported reports whether this call marked the day", func(t *testing.T) {
+		insertSQL := regexp.QuoteMeta(`INSERT INTO "main"."api_usage_reports" ("usage_date","reported_at") VALUES ($1,$2) ON CONFLICT DO NOTHING`)
+		now := time.Now().Truncate(time.Second)
+
+		for _, affected := range []int64{1, 0} {
+			db, mock := setupMockDB(t)
+			repo := repository.NewUsageRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+			mock.ExpectBegin()
+			mock.ExpectExec(insertSQL).WithArgs(day, now).WillReturnResult(sqlmock.NewResult(0, affected))
+			mock.ExpectCommit()
+
+			marked, err := repo.MarkReported(ctx, day, now)
+			require.NoError(t, err)
+			assert.Equal(t, affected == 1, marked)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+}
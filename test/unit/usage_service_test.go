@@ -0,0 +1,243 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/event"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/proto/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+type mockUsageRepository struct {
+	addFunc             func(ctx context.Context, usage []*model.ApiUsage) error
+	listByDateFunc      func(ctx context.Context, date time.Time) ([]*model.ApiUsage, error)
+	unreportedDatesFunc func(ctx context.Context, before time.Time) ([]time.Time, error)
+	markReportedFunc    func(ctx context.Context, date time.Time, reportedAt time.Time) (bool, error)
+}
+
+func (m *mockUsageRepository) Add(ctx context.Context, usage []*model.ApiUsage) error {
+	return m.addFunc(ctx, usage)
+}
+
+func (m *mockUsageRepository) ListByDate(ctx context.Context, date time.Time) ([]*model.ApiUsage, error) {
+	return m.listByDateFunc(ctx, date)
+}
+
+func (m *mockUsageRepository) UnreportedDates(ctx context.Context, before time.Time) ([]time.Time, error) {
+	return m.unreportedDatesFunc(ctx, before)
+}
+
+func (m *mockUsageRepository) MarkReported(ctx context.Context, date time.Time, reportedAt time.Time) (bool, error) {
+	return m.markReportedFunc(ctx, date, reportedAt)
+}
+
+type mockPublisher struct {
+	published []*event.Envelope
+	err       error
+}
+
+func (m *mockPublisher) Publish(ctx context.Context, topic string, envelope *event.Envelope) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.published = append(m.published, envelope)
+	return nil
+}
+
+func usageFactory(repo repository.UsageRepository, committed, aborted *int) repository.UnitOfWorkFactory {
+	return &mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) {
+		return &mockUnitOfWork{
+			usageRepo:  repo,
+			commitFunc: func(ctx context.Context) error { *committed++; return nil },
+			abortFunc:  func(ctx context.Context) error { *aborted++; return nil },
+		}, nil
+	}}
+}
+
+func TestUsageService(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+
+	t.Run("flush writes aggregated usage per day, caller and method", func(t *testing.T) {
+		var written []*model.ApiUsage
+		var committed, aborted int
+		repo := &mockUsageRepository{addFunc: func(ctx context.Context, usage []*model.ApiUsage) error {
+			written = append(written, usage...)
+			return nil
+		}}
+		svc := service.NewUsageService(usageFactory(repo, &committed, &aborted), nil, &mockLogger{})
+
+		svc.Record(day.Add(time.Hour), "api_key:a", "/m", 5)
+		svc.Record(day.Add(2*time.Hour), "api_key:a", "/m", 5)
+		svc.Record(day.Add(-time.Hour), "api_key:a", "/m", 5)
+		svc.Record(day.Add(time.Hour), "api_key:b", "/m", 1)
+
+		require.NoError(t, svc.Flush(ctx))
+		sort.Slice(written, func(i, j int) bool {
+			if !written[i].UsageDate.Equal(written[j].UsageDate) {
+				return written[i].UsageDate.Before(written[j].UsageDate)
+			}
+			return written[i].Caller < written[j].Caller
+		})
+		assert.Equal(t, []*model.ApiUsage{
+			{UsageDate: day.AddDate(0, 0, -1), Caller: "api_key:a", Method: "/m", Requests: 1, Units: 5},
+			{UsageDate: day, Caller: "api_key:a", Method: "/m", Requests: 2, Units: 10},
+			{UsageDate: day, Caller: "api_key:b", Method: "/m", Requests: 1, Units: 1},
+		}, written)
+		assert.Equal(t, 1, committed)
+
+		// Nothing new was recorded, so the next flush does not touch the
+		// database.
+		require.NoError(t, svc.Flush(ctx))
+		assert.Equal(t, 1, committed)
+	})
+
+	t.Run("failed flush keeps usage for the next one", func(t *testing.T) {
+		writeErr := errors.New("connection reset")
+		var written []*model.ApiUsage
+		var committed, aborted int
+		repo := &mockUsageRepository{addFunc: func(ctx context.Context, usage []*model.ApiUsage) error {
+			if writeErr != nil {
+				return writeErr
+			}
+			written = append(written, usage...)
+			return nil
+		}}
+		svc := service.NewUsageService(usageFactory(repo, &committed, &aborted), nil, &mockLogger{})
+
+		svc.Record(day, "api_key:a", "/m", 2)
+		assert.ErrorIs(t, svc.Flush(ctx), writeErr)
+		assert.Equal(t, 1, aborted)
+
+		writeErr = nil
+		svc.Record(day, "api_key:a", "/m", 2)
+		require.NoError(t, svc.Flush(ctx))
+		assert.Equal(t, []*model.ApiUsage{{UsageDate: day, Caller: "api_key:a", Method: "/m", Requests: 2, Units: 4}}, written)
+	})
+
+	reportRepo := func(marked bool) *mockUsageRepository {
+		return &mockUsageRepository{
+			unreportedDatesFunc: func(ctx context.Context, before time.Time) ([]time.Time, error) {
+				assert.Equal(t, day.AddDate(0, 0, 1), before)
+				return []time.Time{day}, nil
+			},
+			markReportedFunc: func(ctx context.Context, date time.Time, reportedAt time.Time) (bool, error) {
+				return marked, nil
+			},
+			listByDateFunc: func(ctx context.Context, date time.Time) ([]*model.ApiUsage, error) {
+				return []*model.ApiUsage{
+					{UsageDate: day, Caller: "api_key:a", Method: "/m1", Requests: 2, Units: 10},
+					{UsageDate: day, Caller: "api_key:a", Method: "/m2", Requests: 3, Units: 3},
+					{UsageDate: day, Caller: "api_key:b", Method: "/m1", Requests: 1, Units: 5},
+				}, nil
+			},
+		}
+	}
+
+	t.Run("report publishes one event per caller", func(t *testing.T) {
+		var committed, aborted int
+		publisher := &mockPublisher{}
+		svc := service.NewUsageService(usageFactory(reportRepo(true), &committed, &aborted), publisher, &mockLogger{})
+
+		require.NoError(t, svc.ReportUsage(ctx, day.AddDate(0, 0, 1).Add(time.Hour)))
+		require.Len(t, publisher.published, 2)
+
+		var first events.ApiUsageReportedV1
+		require.NoError(t, event.UnpackTo(publisher.published[0], &first))
+		assert.Equal(t, "api_key:a", first.GetCaller())
+		assert.Equal(t, "2026-10-15", first.GetUsageDate())
+		assert.Len(t, first.GetMethods(), 2)
+		assert.Equal(t, int64(5), first.GetTotalRequests())
+		assert.Equal(t, int64(13), first.GetTotalUnits())
+		assert.NotEqual(t, publisher.published[0].Id, publisher.published[1].Id)
+
+		// A republished report keeps its event id so consumers can drop it.
+		again := &mockPublisher{}
+		svc = service.NewUsageService(usageFactory(reportRepo(true), &committed, &aborted), again, &mockLogger{})
+		require.NoError(t, svc.ReportUsage(ctx, day.AddDate(0, 0, 1)))
+		assert.Equal(t, publisher.published[0].Id, again.published[0].Id)
+	})
+
+	t.Run("day already reported elsewhere is skipped", func(t *testing.T) {
+		var committed, aborted int
+		publisher := &mockPublisher{}
+		svc := service.NewUsageService(usageFactory(reportRepo(false), &committed, &aborted), publisher, &mockLogger{})
+
+		require.NoError(t, svc.ReportUsage(ctx, day.AddDate(0, 0, 1)))
+		assert.Empty(t, publisher.published)
+		assert.Equal(t, 1, aborted)
+	})
+
+	t.Run("publish failure leaves the day unreported", func(t *testing.T) {
+		var committed, aborted int
+		publishErr := errors.New("broker unavailable")
+		svc := service.NewUsageService(usageFactory(reportRepo(true), &committed, &aborted), &mockPublisher{err: publishErr}, &mockLogger{})
+
+		assert.ErrorIs(t, svc.ReportUsage(ctx, day.AddDate(0, 0, 1)), publishErr)
+		assert.Equal(t, 1, aborted)
+		// Only the transaction listing unreported dates committed.
+		assert.Equal(t, 1, committed)
+	})
+
+	t.Run("without a publisher nothing is reported", func(t *testing.T) {
+		svc := service.NewUsageService(&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) {
+			t.Fatal("unexpected unit of work")
+			return nil, nil
+		}}, nil, &mockLogger{})
+
+		assert.NoError(t, svc.ReportUsage(ctx, day))
+	})
+}
+
+type usageCall struct {
+	caller string
+	method string
+	units  int64
+}
+
+type mockUsageRecorder struct {
+	calls []usageCall
+}
+
+func (m *mockUsageRecorder) Record(at time.Time, caller, method string, units int64) {
+	m.calls = append(m.calls, usageCall{caller: caller, method: method, units: units})
+}
+
+func TestMeteringInterceptor(t *testing.T) {
+	ok := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+	peerCtx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.7"), Port: 51234}})
+
+	recorder := &mockUsageRecorder{}
+	i := interceptor.MeteringInterceptor(recorder, map[string]int64{"/proto.v1.LedgerService/ListLedgers": 5})
+
+	apiKeyCtx := interceptor.ContextWithCaller(peerCtx, interceptor.Caller{Kind: interceptor.CallerKindAPIKey, Id: "partner"})
+	_, err := i(apiKeyCtx, nil, &grpc.UnaryServerInfo{FullMethod: "/proto.v1.LedgerService/ListLedgers"}, ok)
+	require.NoError(t, err)
+	_, err = i(peerCtx, nil, &grpc.UnaryServerInfo{FullMethod: "/proto.v1.UserService/GetUserById"}, ok)
+	require.NoError(t, err)
+
+	handlerErr := errors.New("boom")
+	_, err = i(apiKeyCtx, nil, &grpc.UnaryServerInfo{FullMethod: "/proto.v1.UserService/GetUserById"}, func(ctx context.Context, req any) (any, error) {
+		return nil, handlerErr
+	})
+	assert.ErrorIs(t, err, handlerErr)
+	_, err = i(apiKeyCtx, nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, ok)
+	require.NoError(t, err)
+
+	assert.Equal(t, []usageCall{
+		{caller: "api_key:partner", method: "/proto.v1.LedgerService/ListLedgers", units: 5},
+		{caller: "anonymous", method: "/proto.v1.UserService/GetUserById", units: interceptor.DefaultMethodCost},
+	}, recorder.calls)
+}
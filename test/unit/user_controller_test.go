@@ -0,0 +1,194 @@
+package unit
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/internal/controller"
+	"github.com/jt828/go-grpc-template/internal/controller/convert"
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	"github.com/jt828/go-grpc-template/pkg/idcodec"
+	idcodecImpl "github.com/jt828/go-grpc-template/pkg/idcodec/implementation"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// stubTwoFactorService remembers the users it was asked to act on.
+type stubTwoFactorService struct {
+	users []int64
+}
+
+func (s *stubTwoFactorService) Enroll(ctx context.Context, userId int64) (*service.TwoFactorEnrollment, error) {
+	s.users = append(s.users, userId)
+	return &service.TwoFactorEnrollment{Secret: "secret", URI: "otpauth://totp/template"}, nil
+}
+
+func (s *stubTwoFactorService) Verify(ctx context.Context, userId int64, ip string, code string) ([]string, error) {
+	s.users = append(s.users, userId)
+	return []string{"abcde-fghij"}, nil
+}
+
+func (s *stubTwoFactorService) Disable(ctx context.Context, userId int64, ip string, code string) error {
+	s.users = append(s.users, userId)
+	return nil
+}
+
+func userCaller(id string) context.Context {
+	return interceptor.ContextWithCaller(context.Background(), interceptor.Caller{Kind: interceptor.CallerKindUser, Id: id})
+}
+
+func TestUserControllerTwoFactor(t *testing.T) {
+	ids := convert.NewIDs(idcodecImpl.NewBase62Codec(), idcodec.ModeInt64)
+	newController := func() (*controller.UserController, *stubTwoFactorService) {
+		twoFactor := &stubTwoFactorService{}
+		return controller.NewUserController(nil, ids, nil, twoFactor, nil, nil, nil, nil), twoFactor
+	}
+
+	t.Run("acts on the calling user", func(t *testing.T) {
+		ctrl, twoFactor := newController()
+		ctx := userCaller("7")
+
+		_, err := ctrl.Enroll2FA(ctx, &v1.Enroll2FARequest{Id: 7})
+		require.NoError(t, err)
+		_, err = ctrl.Verify2FA(ctx, &v1.Verify2FARequest{Id: 7, Code: "123456"})
+		require.NoError(t, err)
+		_, err = ctrl.Disable2FA(ctx, &v1.Disable2FARequest{Id: 7, Code: "123456"})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{7, 7, 7}, twoFactor.users)
+	})
+
+	t.Run("rejects another user's account", func(t *testing.T) {
+		ctrl, twoFactor := newController()
+		ctx := userCaller("8")
+
+		_, err := ctrl.Enroll2FA(ctx, &v1.Enroll2FARequest{Id: 7})
+		assert.ErrorIs(t, err, apperror.ErrPermissionDenied)
+		_, err = ctrl.Verify2FA(ctx, &v1.Verify2FARequest{Id: 7, Code: "123456"})
+		assert.ErrorIs(t, err, apperror.ErrPermissionDenied)
+		_, err = ctrl.Disable2FA(ctx, &v1.Disable2FARequest{Id: 7, Code: "123456"})
+		assert.ErrorIs(t, err, apperror.ErrPermissionDenied)
+		assert.Empty(t, twoFactor.users)
+	})
+
+	t.Run("requires a user caller", func(t *testing.T) {
+		ctrl, twoFactor := newController()
+
+		_, err := ctrl.Enroll2FA(context.Background(), &v1.Enroll2FARequest{Id: 7})
+		assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+		apiKey := interceptor.ContextWithCaller(context.Background(), interceptor.Caller{Kind: interceptor.CallerKindAPIKey, Id: "7"})
+		_, err = ctrl.Disable2FA(apiKey, &v1.Disable2FARequest{Id: 7, Code: "123456"})
+		assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+		assert.Empty(t, twoFactor.users)
+	})
+}
+
+// exportStream is the server side of an ExportUserData call, collecting the
+// chunks sent.
+type exportStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	chunks [][]byte
+}
+
+func (s *exportStream) Context() context.Context { return s.ctx }
+func (s *exportStream) Send(response *v1.ExportUserDataResponse) error {
+	s.chunks = append(s.chunks, slices.Clone(response.Chunk))
+	return nil
+}
+
+// stubDataExportService exports a profile for any user it is asked for.
+type stubDataExportService struct {
+	users []int64
+}
+
+func (s *stubDataExportService) ExportUserData(ctx context.Context, userId int64, export service.UserDataExport) (bool, error) {
+	s.users = append(s.users, userId)
+	return true, export.Profile(&model.User{Id: userId, Username: "alice"})
+}
+
+func TestUserControllerExportUserData(t *testing.T) {
+	ids := convert.NewIDs(idcodecImpl.NewBase62Codec(), idcodec.ModeInt64)
+	exports := &stubDataExportService{}
+	ctrl := controller.NewUserController(nil, ids, nil, nil, nil, nil, nil, exports)
+
+	stream := &exportStream{ctx: userCaller("7")}
+	require.NoError(t, ctrl.ExportUserData(&v1.ExportUserDataRequest{Id: 7}, stream))
+	require.Len(t, stream.chunks, 1)
+	assert.Contains(t, string(stream.chunks[0]), `"username":"alice"`)
+
+	err := ctrl.ExportUserData(&v1.ExportUserDataRequest{Id: 7}, &exportStream{ctx: userCaller("8")})
+	assert.ErrorIs(t, err, apperror.ErrPermissionDenied)
+	err = ctrl.ExportUserData(&v1.ExportUserDataRequest{Id: 7}, &exportStream{ctx: context.Background()})
+	assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+	assert.Equal(t, []int64{7}, exports.users)
+}
+
+func TestUserControllerChangePassword(t *testing.T) {
+	ids := convert.NewIDs(idcodecImpl.NewBase62Codec(), idcodec.ModeInt64)
+	ctrl := controller.NewUserController(nil, ids, nil, nil, nil, nil, nil, nil)
+	request := &v1.ChangePasswordRequest{Id: 7, CurrentPassword: "old", NewPassword: "new"}
+
+	_, err := ctrl.ChangePassword(userCaller("8"), request)
+	assert.ErrorIs(t, err, apperror.ErrPermissionDenied)
+	_, err = ctrl.ChangePassword(context.Background(), request)
+	assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+}
+
+func TestUserControllerSessions(t *testing.T) {
+	ids := convert.NewIDs(idcodecImpl.NewBase62Codec(), idcodec.ModeInt64)
+	ctrl := controller.NewUserController(nil, ids, nil, nil, nil, nil, nil, nil)
+
+	_, err := ctrl.ListSessions(userCaller("8"), &v1.ListSessionsRequest{Id: 7})
+	assert.ErrorIs(t, err, apperror.ErrPermissionDenied)
+	_, err = ctrl.RevokeSession(userCaller("8"), &v1.RevokeSessionRequest{Id: 7, SessionId: 10})
+	assert.ErrorIs(t, err, apperror.ErrPermissionDenied)
+	_, err = ctrl.RevokeSession(context.Background(), &v1.RevokeSessionRequest{Id: 7, SessionId: 10})
+	assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+}
+
+func TestUserControllerUpdateUser(t *testing.T) {
+	ids := convert.NewIDs(idcodecImpl.NewBase62Codec(), idcodec.ModeInt64)
+	ctrl := controller.NewUserController(nil, ids, nil, nil, nil, nil, nil, nil)
+	request := &v1.UpdateUserRequest{Id: 7, Email: "mallory@example.com", UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"email"}}}
+
+	_, err := ctrl.UpdateUser(userCaller("8"), request)
+	assert.ErrorIs(t, err, apperror.ErrPermissionDenied)
+	_, err = ctrl.UpdateUser(context.Background(), request)
+	assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+}
+
+// stubEmailVerificationService remembers the users it was asked to mail.
+type stubEmailVerificationService struct {
+	users []int64
+}
+
+func (s *stubEmailVerificationService) Send(ctx context.Context, userId int64) (bool, error) {
+	s.users = append(s.users, userId)
+	return true, nil
+}
+
+func (s *stubEmailVerificationService) Verify(ctx context.Context, token string) (*model.User, error) {
+	return nil, nil
+}
+
+func TestUserControllerSendVerificationEmail(t *testing.T) {
+	ids := convert.NewIDs(idcodecImpl.NewBase62Codec(), idcodec.ModeInt64)
+	verification := &stubEmailVerificationService{}
+	ctrl := controller.NewUserController(nil, ids, nil, nil, nil, verification, nil, nil)
+	request := &v1.SendVerificationEmailRequest{Id: 7}
+
+	_, err := ctrl.SendVerificationEmail(userCaller("7"), request)
+	require.NoError(t, err)
+	_, err = ctrl.SendVerificationEmail(userCaller("8"), request)
+	assert.ErrorIs(t, err, apperror.ErrPermissionDenied)
+	_, err = ctrl.SendVerificationEmail(context.Background(), request)
+	assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+	assert.Equal(t, []int64{7}, verification.users)
+}
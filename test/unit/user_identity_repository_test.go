@@ -0,0 +1,65 @@
+package unit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserIdentityRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	issuer := "https://auth.example.com/realms/main"
+
+	t.Run("get looks the link up by issuer and subject", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewUserIdentityRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."user_identities" WHERE issuer = $1 AND subject = $2 LIMIT $3`)).
+			WithArgs(issuer, "alice", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"issuer", "subject", "user_id", "created_at", "created_by"}).
+				AddRow(issuer, "alice", int64(42), now, "service:ops"))
+
+		identity, err := repo.Get(ctx, issuer, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, &model.UserIdentity{Issuer: issuer, Subject: "alice", UserId: 42, CreatedAt: now, CreatedBy: "service:ops"}, identity)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get returns nil for an unlinked subject", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewUserIdentityRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."user_identities" WHERE issuer = $1 AND subject = $2 LIMIT $3`)).
+			WithArgs(issuer, "mallory", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"issuer", "subject", "user_id", "created_at", "created_by"}))
+
+		identity, err := repo.Get(ctx, issuer, "mallory")
+		require.NoError(t, err)
+		assert.Nil(t, identity)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete reports whether the subject was linked", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewUserIdentityRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "main"."user_identities" WHERE issuer = $1 AND subject = $2`)).
+			WithArgs(issuer, "alice").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		deleted, err := repo.Delete(ctx, issuer, "alice")
+		require.NoError(t, err)
+		assert.False(t, deleted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,208 @@
+package unit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/jt828/go-grpc-template/pkg/audit"
+	auditImpl "github.com/jt828/go-grpc-template/pkg/audit/implementation"
+	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	userInsertSQL = regexp.QuoteMeta(`INSERT INTO "main"."users" ("email","username","password","status","created_at","updated_at","created_by","updated_by","version","email_verified_at","id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) RETURNING *`)
+	userSelectSQL = regexp.QuoteMeta(`SELECT * FROM "main"."users" WHERE "users"."id" = $1 ORDER BY "users"."id" LIMIT $2`)
+)
+
+func userColumns() []string {
+	return []string{"id", "email", "username", "password", "status", "created_at", "updated_at", "created_by", "updated_by", "version"}
+}
+
+func TestUserRepository_Insert(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	t.Run("returns the stored row in one statement", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		require.NoError(t, gormDB.Use(auditImpl.NewGormActorPlugin()))
+		repo := repository.NewUserRepository(gormDB, &passthroughCB{}, &passthroughRetry{}, false)
+		ctx := audit.ContextWithActor(context.Background(), "user:42")
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(userInsertSQL).
+			WithArgs("a@b.com", "alice", "hash", model.UserStatusActive, now, now, "user:42", "user:42", int64(1), nil, int64(1)).
+			WillReturnRows(sqlmock.NewRows(userColumns()).
+				AddRow(1, "a@b.com", "alice", "hash", model.UserStatusActive, now, now, "user:42", "user:42", 1))
+		mock.ExpectCommit()
+
+		user := &model.User{Id: 1, Email: "a@b.com", Username: "alice", Password: "hash", Status: model.UserStatusActive, CreatedAt: now, UpdatedAt: now}
+		require.NoError(t, repo.Insert(ctx, user))
+		assert.Equal(t, &model.User{
+			Id: 1, Email: "a@b.com", Username: "alice", Password: "hash", Status: model.UserStatusActive,
+			CreatedAt: now, UpdatedAt: now, CreatedBy: "user:42", UpdatedBy: "user:42", Version: 1,
+		}, user)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unique violation is returned as a permanent conflict", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		repo := repository.NewUserRepository(gormDB, &passthroughCB{}, &passthroughRetry{}, false)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(userInsertSQL).WillReturnError(&pgconn.PgError{Code: "23505"})
+		mock.ExpectRollback()
+
+		err := repo.Insert(context.Background(), &model.User{Id: 1})
+		var permanent *repository.ErrPermanent
+		assert.ErrorAs(t, err, &permanent)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserRepository_GetByIds(t *testing.T) {
+	tests := []struct {
+		name       string
+		projection repository.Projection
+		sql        string
+	}{
+		{"nil projection reads every column", nil, `SELECT * FROM "main"."users" WHERE id IN ($1,$2)`},
+		{"summary projection", repository.UserSummaryProjection, `SELECT "id","username","status" FROM "main"."users" WHERE id IN ($1,$2)`},
+		{"public projection leaves out the password", repository.UserPublicProjection, `SELECT "id","email","username","status","created_at","updated_at","version","email_verified_at" FROM "main"."users" WHERE id IN ($1,$2)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock := setupMockDB(t)
+			repo := repository.NewUserRepository(gormDB, &passthroughCB{}, &passthroughRetry{}, false)
+
+			mock.ExpectQuery(regexp.QuoteMeta(tt.sql)).
+				WithArgs(int64(1), int64(2)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "username", "status"}).AddRow(1, "alice", model.UserStatusActive))
+
+			users, err := repo.GetByIds(context.Background(), []int64{1, 2}, tt.projection)
+			require.NoError(t, err)
+			assert.Equal(t, []*model.User{{Id: 1, Username: "alice", Status: model.UserStatusActive}}, users)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUserRepository_UpdateProfile(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	updateSQL := regexp.QuoteMeta(`UPDATE "main"."users" SET "email"=$1,"email_verified_at"=$2,"updated_at"=$3,"username"=$4,"version"=version + 1 WHERE id = $5 AND version = $6`)
+
+	for _, rows := range []int64{1, 0} {
+		gormDB, mock := setupMockDB(t)
+		require.NoError(t, gormDB.Use(auditImpl.NewGormTimestampPlugin(func() time.Time { return now })))
+		repo := repository.NewUserRepository(gormDB, &passthroughCB{}, &passthroughRetry{}, false)
+		user := &model.User{Id: 1, Email: "a@b.com", Username: "alice", Version: 3}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(updateSQL).
+			WithArgs("a@b.com", nil, now, "alice", int64(1), int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, rows))
+		mock.ExpectCommit()
+
+		updated, err := repo.UpdateProfile(context.Background(), user)
+		require.NoError(t, err)
+		assert.Equal(t, rows == 1, updated, "reports whether the row was still at the version")
+		assert.Equal(t, rows == 1, user.UpdatedAt.Equal(now), "hands back the stamped updated_at when written")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestUserRepository_MarkEmailVerified(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	gormDB, mock := setupMockDB(t)
+	require.NoError(t, gormDB.Use(auditImpl.NewGormTimestampPlugin(func() time.Time { return now })))
+	repo := repository.NewUserRepository(gormDB, &passthroughCB{}, &passthroughRetry{}, false)
+	user := &model.User{Id: 1, Email: "a@b.com", Version: 3, EmailVerifiedAt: &now}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "main"."users" SET "email_verified_at"=$1,"updated_at"=$2,"version"=version + 1 WHERE (id = $3 AND version = $4) AND email = $5`)).
+		WithArgs(now, now, int64(1), int64(3), "a@b.com").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	updated, err := repo.MarkEmailVerified(context.Background(), user)
+	require.NoError(t, err)
+	assert.False(t, updated, "reports false once the email has changed")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_UpdatePassword(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	gormDB, mock := setupMockDB(t)
+	require.NoError(t, gormDB.Use(auditImpl.NewGormTimestampPlugin(func() time.Time { return now })))
+	repo := repository.NewUserRepository(gormDB, &passthroughCB{}, &passthroughRetry{}, false)
+	user := &model.User{Id: 1, Password: "hashed", Version: 3}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "main"."users" SET "password"=$1,"updated_at"=$2,"version"=version + 1 WHERE id = $3 AND version = $4`)).
+		WithArgs("hashed", now, int64(1), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	updated, err := repo.UpdatePassword(context.Background(), user)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.True(t, user.UpdatedAt.Equal(now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// BenchmarkUserRepository_Create compares inserting then reading the user
+// back against relying on Insert's RETURNING, on a database that takes
+// roundTrip per statement.
+// Run with: go test ./test/unit/ -run '^$' -bench UserRepository_Create
+func BenchmarkUserRepository_Create(b *testing.B) {
+	const roundTrip = time.Millisecond
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	row := func() *sqlmock.Rows {
+		return sqlmock.NewRows(userColumns()).AddRow(1, "a@b.com", "alice", "hash", model.UserStatusActive, now, now, audit.SystemActor, audit.SystemActor, 1)
+	}
+	newUser := func() *model.User {
+		return &model.User{Id: 1, Email: "a@b.com", Username: "alice", Password: "hash", Status: model.UserStatusActive, CreatedAt: now, UpdatedAt: now}
+	}
+
+	b.Run("insert then get", func(b *testing.B) {
+		gormDB, mock := setupMockDB(b)
+		repo := repository.NewUserRepository(gormDB, &passthroughCB{}, &passthroughRetry{}, false)
+		for i := 0; i < b.N; i++ {
+			mock.ExpectBegin()
+			mock.ExpectQuery(userInsertSQL).WillDelayFor(roundTrip).WillReturnRows(row())
+			mock.ExpectCommit()
+			mock.ExpectQuery(userSelectSQL).WillDelayFor(roundTrip).WillReturnRows(row())
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := repo.Insert(ctx, newUser()); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := repo.Get(ctx, 1); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("insert", func(b *testing.B) {
+		gormDB, mock := setupMockDB(b)
+		repo := repository.NewUserRepository(gormDB, &passthroughCB{}, &passthroughRetry{}, false)
+		for i := 0; i < b.N; i++ {
+			mock.ExpectBegin()
+			mock.ExpectQuery(userInsertSQL).WillDelayFor(roundTrip).WillReturnRows(row())
+			mock.ExpectCommit()
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := repo.Insert(ctx, newUser()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -3,14 +3,19 @@ package unit
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jt828/go-grpc-template/internal/constant"
 	"github.com/jt828/go-grpc-template/internal/repository"
 	"github.com/jt828/go-grpc-template/internal/service"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
 	"github.com/jt828/go-grpc-template/pkg/idempotency"
 	"github.com/jt828/go-grpc-template/pkg/model"
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/password"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -23,21 +28,67 @@ type mockSnowflake struct {
 
 func (m *mockSnowflake) Generate() int64 { return m.id }
 
+// mockHasher "hashes" a password by prefixing it with "hashed:".
+type mockHasher struct{}
+
+func (m *mockHasher) Hash(pw string) (string, error) { return "hashed:" + pw, nil }
+
+func (m *mockHasher) Verify(pw, hash string) (bool, error) {
+	if !strings.HasPrefix(hash, "hashed:") {
+		return false, password.ErrUnknownHash
+	}
+	return hash == "hashed:"+pw, nil
+}
+
 type mockUserRepository struct {
-	getFunc    func(ctx context.Context, id int64) (*model.User, error)
-	insertFunc func(ctx context.Context, user *model.User) error
+	getFunc            func(ctx context.Context, id int64) (*model.User, error)
+	getByEmailFunc     func(ctx context.Context, email string) (*model.User, error)
+	getByIdsFunc       func(ctx context.Context, ids []int64, projection repository.Projection) ([]*model.User, error)
+	insertFunc         func(ctx context.Context, user *model.User) error
+	updateStatusFunc   func(ctx context.Context, user *model.User) (bool, error)
+	updateProfileFunc  func(ctx context.Context, user *model.User) (bool, error)
+	updatePasswordFunc func(ctx context.Context, user *model.User) (bool, error)
+	markVerifiedFunc   func(ctx context.Context, user *model.User) (bool, error)
 }
 
 func (m *mockUserRepository) Get(ctx context.Context, id int64) (*model.User, error) {
 	return m.getFunc(ctx, id)
 }
 
+func (m *mockUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	return m.getByEmailFunc(ctx, email)
+}
+
+func (m *mockUserRepository) GetByIds(ctx context.Context, ids []int64, projection repository.Projection) ([]*model.User, error) {
+	return m.getByIdsFunc(ctx, ids, projection)
+}
+
 func (m *mockUserRepository) Insert(ctx context.Context, user *model.User) error {
 	return m.insertFunc(ctx, user)
 }
 
+func (m *mockUserRepository) UpdateStatus(ctx context.Context, user *model.User) (bool, error) {
+	return m.updateStatusFunc(ctx, user)
+}
+
+func (m *mockUserRepository) UpdateProfile(ctx context.Context, user *model.User) (bool, error) {
+	return m.updateProfileFunc(ctx, user)
+}
+
+func (m *mockUserRepository) UpdatePassword(ctx context.Context, user *model.User) (bool, error) {
+	return m.updatePasswordFunc(ctx, user)
+}
+
+func (m *mockUserRepository) MarkEmailVerified(ctx context.Context, user *model.User) (bool, error) {
+	return m.markVerifiedFunc(ctx, user)
+}
+
 type mockIdempotencyRecordRepository struct{}
 
+func (m *mockIdempotencyRecordRepository) Lock(ctx context.Context, id int64) error {
+	return nil
+}
+
 func (m *mockIdempotencyRecordRepository) Get(ctx context.Context, id int64) (*idempotency.Record, error) {
 	return nil, nil
 }
@@ -46,19 +97,71 @@ func (m *mockIdempotencyRecordRepository) Insert(ctx context.Context, record *id
 	return nil
 }
 
+func (m *mockIdempotencyRecordRepository) Quarantine(ctx context.Context, id int64, reason string) (bool, error) {
+	return false, nil
+}
+
 type mockUnitOfWork struct {
-	userRepo        repository.UserRepository
-	ledgerRepo      repository.LedgerRepository
-	idempotencyRepo idempotency.RecordRepository
-	commitFunc      func(ctx context.Context) error
-	abortFunc       func(ctx context.Context) error
+	userRepo         repository.UserRepository
+	ledgerRepo       repository.LedgerRepository
+	idempotencyRepo  idempotency.RecordRepository
+	deadLetterRepo   repository.DeadLetterRepository
+	inboxRepo        repository.InboxRepository
+	statusChangeRepo repository.UserStatusChangeRepository
+	usageRepo        repository.UsageRepository
+	loginFailureRepo repository.LoginFailureRepository
+	twoFactorRepo    repository.TwoFactorRepository
+	sessionRepo      repository.SessionRepository
+	identityRepo     repository.UserIdentityRepository
+	tokenRepo        repository.VerificationTokenRepository
+	resetTokenRepo   repository.PasswordResetTokenRepository
+	quarantineRepo   repository.IdempotencyQuarantineRepository
+	roleRepo         repository.RoleRepository
+	commitFunc       func(ctx context.Context) error
+	abortFunc        func(ctx context.Context) error
 }
 
-func (m *mockUnitOfWork) UserRepository() repository.UserRepository           { return m.userRepo }
-func (m *mockUnitOfWork) LedgerRepository() repository.LedgerRepository       { return m.ledgerRepo }
+func (m *mockUnitOfWork) UserRepository() repository.UserRepository     { return m.userRepo }
+func (m *mockUnitOfWork) LedgerRepository() repository.LedgerRepository { return m.ledgerRepo }
 func (m *mockUnitOfWork) IdempotencyRecordRepository() idempotency.RecordRepository {
 	return m.idempotencyRepo
 }
+func (m *mockUnitOfWork) DeadLetterRepository() repository.DeadLetterRepository {
+	return m.deadLetterRepo
+}
+func (m *mockUnitOfWork) InboxRepository() repository.InboxRepository {
+	return m.inboxRepo
+}
+func (m *mockUnitOfWork) UserStatusChangeRepository() repository.UserStatusChangeRepository {
+	return m.statusChangeRepo
+}
+func (m *mockUnitOfWork) UsageRepository() repository.UsageRepository {
+	return m.usageRepo
+}
+func (m *mockUnitOfWork) LoginFailureRepository() repository.LoginFailureRepository {
+	return m.loginFailureRepo
+}
+func (m *mockUnitOfWork) TwoFactorRepository() repository.TwoFactorRepository {
+	return m.twoFactorRepo
+}
+func (m *mockUnitOfWork) SessionRepository() repository.SessionRepository {
+	return m.sessionRepo
+}
+func (m *mockUnitOfWork) UserIdentityRepository() repository.UserIdentityRepository {
+	return m.identityRepo
+}
+func (m *mockUnitOfWork) VerificationTokenRepository() repository.VerificationTokenRepository {
+	return m.tokenRepo
+}
+func (m *mockUnitOfWork) PasswordResetTokenRepository() repository.PasswordResetTokenRepository {
+	return m.resetTokenRepo
+}
+func (m *mockUnitOfWork) IdempotencyQuarantineRepository() repository.IdempotencyQuarantineRepository {
+	return m.quarantineRepo
+}
+func (m *mockUnitOfWork) RoleRepository() repository.RoleRepository {
+	return m.roleRepo
+}
 func (m *mockUnitOfWork) Commit(ctx context.Context) error { return m.commitFunc(ctx) }
 func (m *mockUnitOfWork) Abort(ctx context.Context) error  { return m.abortFunc(ctx) }
 
@@ -66,16 +169,41 @@ type mockUnitOfWorkFactory struct {
 	newFunc func() (repository.UnitOfWork, error)
 }
 
-func (m *mockUnitOfWorkFactory) New() (repository.UnitOfWork, error) { return m.newFunc() }
+func (m *mockUnitOfWorkFactory) New(ctx context.Context) (repository.UnitOfWork, error) {
+	return m.newFunc()
+}
 
 type mockIdempotency struct {
-	executeFunc func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId int64, newResult func() any, fn func() (any, error)) (any, error)
+	executeFunc func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId *int64, newResult func() any, fn func() (any, error)) (any, error)
 }
 
-func (m *mockIdempotency) Execute(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId int64, newResult func() any, fn func() (any, error)) (any, error) {
+func (m *mockIdempotency) Execute(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId *int64, newResult func() any, fn func() (any, error)) (any, error) {
 	return m.executeFunc(ctx, repo, id, requestType, referenceId, newResult, fn)
 }
 
+type mockSpan struct {
+	name       string
+	attributes []observability.Field
+	errors     []error
+	ended      bool
+}
+
+func (m *mockSpan) End()                  { m.ended = true }
+func (m *mockSpan) RecordError(err error) { m.errors = append(m.errors, err) }
+func (m *mockSpan) SetAttributes(fields ...observability.Field) {
+	m.attributes = append(m.attributes, fields...)
+}
+
+type mockTracer struct {
+	spans []*mockSpan
+}
+
+func (m *mockTracer) Start(ctx context.Context, name string) (context.Context, observability.Span) {
+	span := &mockSpan{name: name}
+	m.spans = append(m.spans, span)
+	return ctx, span
+}
+
 // --- tests ---
 
 func TestUserService_GetUser(t *testing.T) {
@@ -97,7 +225,7 @@ func TestUserService_GetUser(t *testing.T) {
 
 		svc := service.NewUserService(
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
-			nil, nil,
+			nil, nil, &mockHasher{}, nil, &mockTracer{},
 		)
 
 		user, err := svc.GetUser(ctx, 1)
@@ -119,7 +247,7 @@ func TestUserService_GetUser(t *testing.T) {
 
 		svc := service.NewUserService(
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
-			nil, nil,
+			nil, nil, &mockHasher{}, nil, &mockTracer{},
 		)
 
 		user, err := svc.GetUser(ctx, 999)
@@ -132,7 +260,7 @@ func TestUserService_GetUser(t *testing.T) {
 
 		svc := service.NewUserService(
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return nil, factoryErr }},
-			nil, nil,
+			nil, nil, &mockHasher{}, nil, &mockTracer{},
 		)
 
 		user, err := svc.GetUser(ctx, 1)
@@ -156,7 +284,7 @@ func TestUserService_GetUser(t *testing.T) {
 
 		svc := service.NewUserService(
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
-			nil, nil,
+			nil, nil, &mockHasher{}, nil, &mockTracer{},
 		)
 
 		user, err := svc.GetUser(ctx, 1)
@@ -180,7 +308,7 @@ func TestUserService_GetUser(t *testing.T) {
 
 		svc := service.NewUserService(
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
-			nil, nil,
+			nil, nil, &mockHasher{}, nil, &mockTracer{},
 		)
 
 		user, err := svc.GetUser(ctx, 1)
@@ -189,25 +317,79 @@ func TestUserService_GetUser(t *testing.T) {
 	})
 }
 
+func TestUserService_GetUsersByIds(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns users in request order and reports missing ids", func(t *testing.T) {
+		var gotIds []int64
+		var gotProjection repository.Projection
+		uow := &mockUnitOfWork{
+			userRepo: &mockUserRepository{
+				getByIdsFunc: func(ctx context.Context, ids []int64, projection repository.Projection) ([]*model.User, error) {
+					gotIds, gotProjection = ids, projection
+					return []*model.User{{Id: 1, Username: "alice"}, {Id: 3, Username: "carol"}}, nil
+				},
+			},
+			commitFunc: func(ctx context.Context) error { return nil },
+			abortFunc:  func(ctx context.Context) error { return nil },
+		}
+
+		svc := service.NewUserService(
+			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
+			nil, nil, &mockHasher{}, nil, &mockTracer{},
+		)
+
+		result, err := svc.GetUsersByIds(ctx, []int64{3, 2, 1})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{3, 2, 1}, gotIds)
+		assert.Equal(t, repository.UserPublicProjection, gotProjection, "never reads password hashes")
+		require.Len(t, result.Users, 2)
+		assert.Equal(t, "carol", result.Users[0].Username)
+		assert.Equal(t, "alice", result.Users[1].Username)
+		assert.Equal(t, []int64{2}, result.MissingIds)
+	})
+
+	t.Run("repository error aborts and is propagated", func(t *testing.T) {
+		repoErr := errors.New("db error")
+		aborted := false
+		uow := &mockUnitOfWork{
+			userRepo: &mockUserRepository{
+				getByIdsFunc: func(ctx context.Context, ids []int64, projection repository.Projection) ([]*model.User, error) {
+					return nil, repoErr
+				},
+			},
+			commitFunc: func(ctx context.Context) error { t.Fatal("commit should not be called"); return nil },
+			abortFunc:  func(ctx context.Context) error { aborted = true; return nil },
+		}
+
+		svc := service.NewUserService(
+			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
+			nil, nil, &mockHasher{}, nil, &mockTracer{},
+		)
+
+		result, err := svc.GetUsersByIds(ctx, []int64{1})
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, repoErr)
+		assert.True(t, aborted)
+	})
+}
+
 func TestUserService_CreateUser(t *testing.T) {
 	ctx := context.Background()
 	snowflakeId := int64(12345)
 
-	t.Run("creates user with snowflake ID and timestamps", func(t *testing.T) {
-		var insertedUser *model.User
+	t.Run("creates user with snowflake ID, leaving timestamps to the repository", func(t *testing.T) {
+		stampedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
 		committed := false
 
 		userRepo := &mockUserRepository{
 			insertFunc: func(ctx context.Context, user *model.User) error {
-				insertedUser = &model.User{
-					Id: user.Id, Email: user.Email, Username: user.Username,
-					Password: user.Password, CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt,
-				}
+				assert.True(t, user.CreatedAt.IsZero())
+				assert.True(t, user.UpdatedAt.IsZero())
+				assert.Equal(t, "hashed:hash", user.Password, "never the plain text")
+				user.CreatedAt, user.UpdatedAt = stampedAt, stampedAt
 				return nil
 			},
-			getFunc: func(ctx context.Context, id int64) (*model.User, error) {
-				return insertedUser, nil
-			},
 		}
 
 		uow := &mockUnitOfWork{
@@ -219,10 +401,10 @@ func TestUserService_CreateUser(t *testing.T) {
 
 		// Use a passthrough idempotency that always executes fn (cache miss)
 		idem := &mockIdempotency{
-			executeFunc: func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId int64, newResult func() any, fn func() (any, error)) (any, error) {
+			executeFunc: func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId *int64, newResult func() any, fn func() (any, error)) (any, error) {
 				assert.Equal(t, int64(99), id)
 				assert.Equal(t, constant.RequestTypeCreateUser, requestType)
-				assert.Equal(t, snowflakeId, referenceId)
+				assert.Equal(t, snowflakeId, *referenceId)
 				return fn()
 			},
 		}
@@ -231,22 +413,15 @@ func TestUserService_CreateUser(t *testing.T) {
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
 			idem,
 			&mockSnowflake{id: snowflakeId},
+			&mockHasher{}, nil, &mockTracer{},
 		)
 
-		before := time.Now().UTC()
 		user, err := svc.CreateUser(ctx, 99, &model.User{Email: "a@b.com", Username: "alice", Password: "hash"})
-		after := time.Now().UTC()
-
 		require.NoError(t, err)
 		assert.Equal(t, snowflakeId, user.Id)
 		assert.Equal(t, "a@b.com", user.Email)
+		assert.Equal(t, stampedAt, user.CreatedAt)
 		assert.True(t, committed)
-
-		// Verify timestamps are set to approximately now
-		assert.False(t, insertedUser.CreatedAt.IsZero())
-		assert.False(t, insertedUser.UpdatedAt.IsZero())
-		assert.True(t, !insertedUser.CreatedAt.Before(before) && !insertedUser.CreatedAt.After(after))
-		assert.Equal(t, insertedUser.CreatedAt, insertedUser.UpdatedAt)
 	})
 
 	t.Run("idempotency cache hit returns cached user without insert", func(t *testing.T) {
@@ -266,7 +441,7 @@ func TestUserService_CreateUser(t *testing.T) {
 		}
 
 		idem := &mockIdempotency{
-			executeFunc: func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId int64, newResult func() any, fn func() (any, error)) (any, error) {
+			executeFunc: func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId *int64, newResult func() any, fn func() (any, error)) (any, error) {
 				return cached, nil // simulate cache hit
 			},
 		}
@@ -275,6 +450,7 @@ func TestUserService_CreateUser(t *testing.T) {
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
 			idem,
 			&mockSnowflake{id: snowflakeId},
+			&mockHasher{}, nil, &mockTracer{},
 		)
 
 		user, err := svc.CreateUser(ctx, 99, &model.User{Email: "a@b.com"})
@@ -290,6 +466,7 @@ func TestUserService_CreateUser(t *testing.T) {
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return nil, factoryErr }},
 			nil,
 			&mockSnowflake{id: snowflakeId},
+			&mockHasher{}, nil, &mockTracer{},
 		)
 
 		user, err := svc.CreateUser(ctx, 99, &model.User{})
@@ -303,7 +480,6 @@ func TestUserService_CreateUser(t *testing.T) {
 
 		userRepo := &mockUserRepository{
 			insertFunc: func(ctx context.Context, user *model.User) error { return insertErr },
-			getFunc:    func(ctx context.Context, id int64) (*model.User, error) { return nil, nil },
 		}
 
 		uow := &mockUnitOfWork{
@@ -314,7 +490,7 @@ func TestUserService_CreateUser(t *testing.T) {
 		}
 
 		idem := &mockIdempotency{
-			executeFunc: func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId int64, newResult func() any, fn func() (any, error)) (any, error) {
+			executeFunc: func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId *int64, newResult func() any, fn func() (any, error)) (any, error) {
 				return fn()
 			},
 		}
@@ -323,6 +499,7 @@ func TestUserService_CreateUser(t *testing.T) {
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
 			idem,
 			&mockSnowflake{id: snowflakeId},
+			&mockHasher{}, nil, &mockTracer{},
 		)
 
 		user, err := svc.CreateUser(ctx, 99, &model.User{})
@@ -331,24 +508,22 @@ func TestUserService_CreateUser(t *testing.T) {
 		assert.True(t, aborted)
 	})
 
-	t.Run("get after insert error aborts and is propagated", func(t *testing.T) {
-		getErr := errors.New("get failed after insert")
-		aborted := false
+	t.Run("commit error is propagated", func(t *testing.T) {
+		commitErr := errors.New("commit failed")
 
 		userRepo := &mockUserRepository{
 			insertFunc: func(ctx context.Context, user *model.User) error { return nil },
-			getFunc:    func(ctx context.Context, id int64) (*model.User, error) { return nil, getErr },
 		}
 
 		uow := &mockUnitOfWork{
 			userRepo:        userRepo,
 			idempotencyRepo: &mockIdempotencyRecordRepository{},
-			commitFunc:      func(ctx context.Context) error { t.Fatal("commit should not be called"); return nil },
-			abortFunc:       func(ctx context.Context) error { aborted = true; return nil },
+			commitFunc:      func(ctx context.Context) error { return commitErr },
+			abortFunc:       func(ctx context.Context) error { return nil },
 		}
 
 		idem := &mockIdempotency{
-			executeFunc: func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId int64, newResult func() any, fn func() (any, error)) (any, error) {
+			executeFunc: func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId *int64, newResult func() any, fn func() (any, error)) (any, error) {
 				return fn()
 			},
 		}
@@ -357,33 +532,94 @@ func TestUserService_CreateUser(t *testing.T) {
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
 			idem,
 			&mockSnowflake{id: snowflakeId},
+			&mockHasher{}, nil, &mockTracer{},
 		)
 
 		user, err := svc.CreateUser(ctx, 99, &model.User{})
 		assert.Nil(t, user)
-		assert.ErrorIs(t, err, getErr)
-		assert.True(t, aborted)
+		assert.ErrorIs(t, err, commitErr)
 	})
 
-	t.Run("commit error is propagated", func(t *testing.T) {
-		commitErr := errors.New("commit failed")
-
+	t.Run("serialization failure reruns the whole transaction", func(t *testing.T) {
+		var insertedIds []int64
+		var outcome []string
 		userRepo := &mockUserRepository{
-			insertFunc: func(ctx context.Context, user *model.User) error { return nil },
-			getFunc: func(ctx context.Context, id int64) (*model.User, error) {
-				return &model.User{Id: id}, nil
+			insertFunc: func(ctx context.Context, user *model.User) error {
+				insertedIds = append(insertedIds, user.Id)
+				if len(insertedIds) == 1 {
+					return &repository.ErrTransient{Cause: &pgconn.PgError{Code: "40001"}}
+				}
+				return nil
+			},
+		}
+		newUow := func() (repository.UnitOfWork, error) {
+			return &mockUnitOfWork{
+				userRepo:        userRepo,
+				idempotencyRepo: &mockIdempotencyRecordRepository{},
+				commitFunc:      func(ctx context.Context) error { outcome = append(outcome, "commit"); return nil },
+				abortFunc:       func(ctx context.Context) error { outcome = append(outcome, "abort"); return nil },
+			}, nil
+		}
+		idem := &mockIdempotency{
+			executeFunc: func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId *int64, newResult func() any, fn func() (any, error)) (any, error) {
+				return fn()
 			},
 		}
 
+		svc := service.NewUserService(&mockUnitOfWorkFactory{newFunc: newUow}, idem, &mockSnowflake{id: snowflakeId}, &mockHasher{}, nil, &mockTracer{})
+
+		user, err := svc.CreateUser(ctx, 99, &model.User{})
+		require.NoError(t, err)
+		assert.Equal(t, snowflakeId, user.Id)
+		assert.Equal(t, []int64{snowflakeId, snowflakeId}, insertedIds)
+		assert.Equal(t, []string{"abort", "commit"}, outcome)
+	})
+}
+
+func TestUserService_Tracing(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("GetUser starts span with user id", func(t *testing.T) {
+		tracer := &mockTracer{}
 		uow := &mockUnitOfWork{
-			userRepo:        userRepo,
+			userRepo: &mockUserRepository{
+				getFunc: func(ctx context.Context, id int64) (*model.User, error) {
+					return &model.User{Id: id}, nil
+				},
+			},
+			commitFunc: func(ctx context.Context) error { return nil },
+			abortFunc:  func(ctx context.Context) error { return nil },
+		}
+
+		svc := service.NewUserService(
+			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
+			nil, nil, &mockHasher{}, nil, tracer,
+		)
+
+		_, err := svc.GetUser(ctx, 7)
+		require.NoError(t, err)
+		require.Len(t, tracer.spans, 1)
+		assert.Equal(t, "UserService.GetUser", tracer.spans[0].name)
+		assert.Contains(t, tracer.spans[0].attributes, observability.Int64("user_id", 7))
+		assert.Empty(t, tracer.spans[0].errors)
+		assert.True(t, tracer.spans[0].ended)
+	})
+
+	t.Run("CreateUser records error on abort", func(t *testing.T) {
+		tracer := &mockTracer{}
+		insertErr := errors.New("insert failed")
+
+		uow := &mockUnitOfWork{
+			userRepo: &mockUserRepository{
+				insertFunc: func(ctx context.Context, user *model.User) error { return insertErr },
+			},
 			idempotencyRepo: &mockIdempotencyRecordRepository{},
-			commitFunc:      func(ctx context.Context) error { return commitErr },
+			commitFunc:      func(ctx context.Context) error { return nil },
 			abortFunc:       func(ctx context.Context) error { return nil },
 		}
 
 		idem := &mockIdempotency{
-			executeFunc: func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId int64, newResult func() any, fn func() (any, error)) (any, error) {
+			executeFunc: func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId *int64, newResult func() any, fn func() (any, error)) (any, error) {
 				return fn()
 			},
 		}
@@ -391,11 +627,450 @@ func TestUserService_CreateUser(t *testing.T) {
 		svc := service.NewUserService(
 			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
 			idem,
-			&mockSnowflake{id: snowflakeId},
+			&mockSnowflake{id: 12345},
+			&mockHasher{},
+			nil,
+			tracer,
 		)
 
-		user, err := svc.CreateUser(ctx, 99, &model.User{})
+		_, err := svc.CreateUser(ctx, 99, &model.User{})
+		require.Error(t, err)
+		require.Len(t, tracer.spans, 1)
+		assert.Equal(t, "UserService.CreateUser", tracer.spans[0].name)
+		assert.Contains(t, tracer.spans[0].attributes, observability.Int64("idempotency_id", 99))
+		assert.Contains(t, tracer.spans[0].attributes, observability.Int64("user_id", 12345))
+		assert.Equal(t, []error{insertErr}, tracer.spans[0].errors)
+		assert.True(t, tracer.spans[0].ended)
+	})
+}
+
+type mockUserStatusChangeRepository struct {
+	inserted []*model.UserStatusChange
+}
+
+func (m *mockUserStatusChangeRepository) Insert(ctx context.Context, change *model.UserStatusChange) error {
+	m.inserted = append(m.inserted, change)
+	return nil
+}
+
+func TestUserService_UpdateUserStatus(t *testing.T) {
+	ctx := context.Background()
+
+	newService := func(userRepo *mockUserRepository, idem idempotency.Idempotency) (service.UserService, *[]string, *mockUserStatusChangeRepository) {
+		var outcome []string
+		audit := &mockUserStatusChangeRepository{}
+		uow := &mockUnitOfWork{
+			userRepo:         userRepo,
+			idempotencyRepo:  &mockIdempotencyRecordRepository{},
+			statusChangeRepo: audit,
+			commitFunc:       func(ctx context.Context) error { outcome = append(outcome, "commit"); return nil },
+			abortFunc:        func(ctx context.Context) error { outcome = append(outcome, "abort"); return nil },
+		}
+		svc := service.NewUserService(
+			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
+			idem, &mockSnowflake{id: 777}, &mockHasher{}, nil, &mockTracer{},
+		)
+		return svc, &outcome, audit
+	}
+	userWithStatus := func(status model.UserStatus) func(ctx context.Context, id int64) (*model.User, error) {
+		return func(ctx context.Context, id int64) (*model.User, error) {
+			return &model.User{Id: id, Status: status, Version: 3}, nil
+		}
+	}
+	passthrough := &mockIdempotency{
+		executeFunc: func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId *int64, newResult func() any, fn func() (any, error)) (any, error) {
+			return fn()
+		},
+	}
+
+	t.Run("allowed transition updates status conditionally and is audited", func(t *testing.T) {
+		stampedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		var gotVersion int64
+		var gotTo model.UserStatus
+		svc, outcome, audit := newService(&mockUserRepository{
+			getFunc: userWithStatus(model.UserStatusActive),
+			updateStatusFunc: func(ctx context.Context, user *model.User) (bool, error) {
+				gotVersion, gotTo = user.Version, user.Status
+				user.UpdatedAt = stampedAt
+				return true, nil
+			},
+		}, nil)
+
+		user, err := svc.UpdateUserStatus(ctx, 1, model.UserStatusSuspended, 0)
+		require.NoError(t, err)
+		assert.Equal(t, model.UserStatusSuspended, user.Status)
+		assert.Equal(t, int64(3), gotVersion, "conditional on the version read")
+		assert.Equal(t, int64(4), user.Version)
+		assert.Equal(t, model.UserStatusSuspended, gotTo)
+		require.Len(t, audit.inserted, 1)
+		assert.Equal(t, int64(777), audit.inserted[0].Id)
+		assert.Equal(t, model.UserStatusActive, audit.inserted[0].FromStatus)
+		assert.Equal(t, model.UserStatusSuspended, audit.inserted[0].ToStatus)
+		assert.Equal(t, stampedAt, audit.inserted[0].ChangedAt, "at the updated_at the repository stamped")
+		assert.Equal(t, []string{"commit"}, *outcome)
+	})
+
+	t.Run("deleted user cannot be reactivated", func(t *testing.T) {
+		svc, outcome, audit := newService(&mockUserRepository{
+			getFunc: userWithStatus(model.UserStatusDeleted),
+			updateStatusFunc: func(ctx context.Context, user *model.User) (bool, error) {
+				t.Fatal("UpdateStatus should not be called for an invalid transition")
+				return false, nil
+			},
+		}, nil)
+
+		_, err := svc.UpdateUserStatus(ctx, 1, model.UserStatusActive, 0)
+		assert.ErrorIs(t, err, apperror.ErrConflict)
+		assert.Empty(t, audit.inserted)
+		assert.Equal(t, []string{"abort"}, *outcome)
+	})
+
+	t.Run("concurrent status change is a conflict", func(t *testing.T) {
+		svc, outcome, audit := newService(&mockUserRepository{
+			getFunc: userWithStatus(model.UserStatusActive),
+			updateStatusFunc: func(ctx context.Context, user *model.User) (bool, error) {
+				return false, nil
+			},
+		}, nil)
+
+		_, err := svc.UpdateUserStatus(ctx, 1, model.UserStatusDeleted, 0)
+		assert.ErrorIs(t, err, apperror.ErrConflict)
+		assert.Empty(t, audit.inserted)
+		assert.Equal(t, []string{"abort"}, *outcome)
+	})
+
+	t.Run("expected version must match the version read", func(t *testing.T) {
+		svc, outcome, audit := newService(&mockUserRepository{
+			getFunc: userWithStatus(model.UserStatusActive),
+			updateStatusFunc: func(ctx context.Context, user *model.User) (bool, error) {
+				return true, nil
+			},
+		}, nil)
+
+		_, err := svc.UpdateUserStatus(ctx, 1, model.UserStatusSuspended, 2)
+		var mismatch *apperror.VersionMismatchError
+		require.ErrorAs(t, err, &mismatch)
+		assert.ErrorIs(t, err, apperror.ErrFailedPrecondition)
+		assert.Equal(t, int64(3), mismatch.CurrentVersion)
+		assert.Empty(t, audit.inserted)
+		assert.Equal(t, []string{"abort"}, *outcome)
+
+		user, err := svc.UpdateUserStatus(ctx, 1, model.UserStatusSuspended, 3)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), user.Version)
+	})
+
+	t.Run("missing user returns nil", func(t *testing.T) {
+		svc, outcome, _ := newService(&mockUserRepository{
+			getFunc: func(ctx context.Context, id int64) (*model.User, error) { return nil, nil },
+		}, nil)
+
+		user, err := svc.UpdateUserStatus(ctx, 1, model.UserStatusSuspended, 0)
+		require.NoError(t, err)
 		assert.Nil(t, user)
-		assert.ErrorIs(t, err, commitErr)
+		assert.Equal(t, []string{"abort"}, *outcome)
+	})
+
+	t.Run("suspend runs under idempotency and records reason", func(t *testing.T) {
+		var gotId int64
+		var gotType constant.RequestType
+		idem := &mockIdempotency{
+			executeFunc: func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId *int64, newResult func() any, fn func() (any, error)) (any, error) {
+				gotId, gotType = id, requestType
+				return fn()
+			},
+		}
+		svc, outcome, audit := newService(&mockUserRepository{
+			getFunc: userWithStatus(model.UserStatusActive),
+			updateStatusFunc: func(ctx context.Context, user *model.User) (bool, error) {
+				return true, nil
+			},
+		}, idem)
+
+		user, err := svc.SuspendUser(ctx, 55, 1, "chargeback")
+		require.NoError(t, err)
+		assert.Equal(t, model.UserStatusSuspended, user.Status)
+		assert.Equal(t, int64(55), gotId)
+		assert.Equal(t, constant.RequestTypeSuspendUser, gotType)
+		require.Len(t, audit.inserted, 1)
+		assert.Equal(t, "chargeback", audit.inserted[0].Reason)
+		assert.Equal(t, []string{"commit"}, *outcome)
+	})
+
+	t.Run("retried suspend returns cached result", func(t *testing.T) {
+		cached := &model.User{Id: 1, Status: model.UserStatusSuspended}
+		idem := &mockIdempotency{
+			executeFunc: func(ctx context.Context, repo idempotency.RecordRepository, id int64, requestType constant.RequestType, referenceId *int64, newResult func() any, fn func() (any, error)) (any, error) {
+				return cached, nil
+			},
+		}
+		svc, outcome, audit := newService(&mockUserRepository{}, idem)
+
+		user, err := svc.SuspendUser(ctx, 55, 1, "chargeback")
+		require.NoError(t, err)
+		assert.Equal(t, cached, user)
+		assert.Empty(t, audit.inserted)
+		assert.Equal(t, []string{"commit"}, *outcome)
+	})
+
+	t.Run("reactivate of active user is a conflict", func(t *testing.T) {
+		svc, outcome, _ := newService(&mockUserRepository{getFunc: userWithStatus(model.UserStatusActive)}, passthrough)
+
+		_, err := svc.ReactivateUser(ctx, 56, 1, "appeal granted")
+		assert.ErrorIs(t, err, apperror.ErrConflict)
+		assert.Equal(t, []string{"abort"}, *outcome)
+	})
+
+	t.Run("suspend of missing user returns nil without recording a result", func(t *testing.T) {
+		svc, outcome, _ := newService(&mockUserRepository{
+			getFunc: func(ctx context.Context, id int64) (*model.User, error) { return nil, nil },
+		}, passthrough)
+
+		user, err := svc.SuspendUser(ctx, 57, 1, "fraud")
+		require.NoError(t, err)
+		assert.Nil(t, user)
+		assert.Equal(t, []string{"abort"}, *outcome)
+	})
+}
+
+func TestUserService_UpdateUser(t *testing.T) {
+	ctx := context.Background()
+	updatedAt := time.Date(2026, 3, 1, 12, 0, 0, 123456000, time.UTC)
+
+	newService := func(userRepo *mockUserRepository) (service.UserService, *[]string) {
+		var outcome []string
+		uow := &mockUnitOfWork{
+			userRepo:   userRepo,
+			commitFunc: func(ctx context.Context) error { outcome = append(outcome, "commit"); return nil },
+			abortFunc:  func(ctx context.Context) error { outcome = append(outcome, "abort"); return nil },
+		}
+		svc := service.NewUserService(
+			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
+			nil, &mockSnowflake{}, &mockHasher{}, nil, &mockTracer{},
+		)
+		return svc, &outcome
+	}
+	stored := func(status model.UserStatus) func(ctx context.Context, id int64) (*model.User, error) {
+		return func(ctx context.Context, id int64) (*model.User, error) {
+			return &model.User{Id: id, Email: "old@example.com", Username: "alice", Status: status, UpdatedAt: updatedAt, Version: 3}, nil
+		}
+	}
+	email := "new@example.com"
+
+	t.Run("changes only the fields given, conditionally on the version read", func(t *testing.T) {
+		var written model.User
+		svc, outcome := newService(&mockUserRepository{
+			getFunc: stored(model.UserStatusActive),
+			updateProfileFunc: func(ctx context.Context, user *model.User) (bool, error) {
+				written = *user
+				user.UpdatedAt = updatedAt.Add(time.Minute)
+				return true, nil
+			},
+		})
+
+		user, err := svc.UpdateUser(ctx, 1, service.UserUpdate{Email: &email})
+		require.NoError(t, err)
+		assert.Equal(t, "new@example.com", written.Email)
+		assert.Equal(t, "alice", written.Username, "fields not in the update are kept")
+		assert.Equal(t, int64(3), written.Version, "conditional on the version read")
+		assert.Equal(t, updatedAt.Add(time.Minute), user.UpdatedAt, "as the repository stamped it")
+		assert.Equal(t, int64(4), user.Version)
+		assert.Equal(t, []string{"commit"}, *outcome)
+	})
+
+	t.Run("changing the email clears its verification", func(t *testing.T) {
+		verifiedAt := updatedAt
+		for _, change := range []struct {
+			email    string
+			verified bool
+		}{{"new@example.com", false}, {"old@example.com", true}} {
+			var written model.User
+			svc, _ := newService(&mockUserRepository{
+				getFunc: func(ctx context.Context, id int64) (*model.User, error) {
+					return &model.User{Id: id, Email: "old@example.com", Status: model.UserStatusActive, Version: 3, EmailVerifiedAt: &verifiedAt}, nil
+				},
+				updateProfileFunc: func(ctx context.Context, user *model.User) (bool, error) { written = *user; return true, nil },
+			})
+
+			_, err := svc.UpdateUser(ctx, 1, service.UserUpdate{Email: &change.email})
+			require.NoError(t, err)
+			assert.Equal(t, change.verified, written.EmailVerified(), change.email)
+		}
+	})
+
+	t.Run("stale expected version fails with a version mismatch", func(t *testing.T) {
+		svc, outcome := newService(&mockUserRepository{getFunc: stored(model.UserStatusActive)})
+
+		_, err := svc.UpdateUser(ctx, 1, service.UserUpdate{Email: &email, ExpectedVersion: 2})
+		var mismatch *apperror.VersionMismatchError
+		require.ErrorAs(t, err, &mismatch)
+		assert.Equal(t, int64(3), mismatch.CurrentVersion)
+		assert.Equal(t, []string{"abort"}, *outcome)
+	})
+
+	t.Run("expected updated_at is compared to the microsecond", func(t *testing.T) {
+		svc, _ := newService(&mockUserRepository{
+			getFunc:           stored(model.UserStatusActive),
+			updateProfileFunc: func(ctx context.Context, user *model.User) (bool, error) { return true, nil },
+		})
+
+		_, err := svc.UpdateUser(ctx, 1, service.UserUpdate{Email: &email, ExpectedUpdatedAt: updatedAt.Add(789)})
+		require.NoError(t, err, "nanoseconds Postgres does not store are ignored")
+
+		_, err = svc.UpdateUser(ctx, 1, service.UserUpdate{Email: &email, ExpectedUpdatedAt: updatedAt.Add(-time.Second)})
+		assert.ErrorIs(t, err, apperror.ErrFailedPrecondition)
+		var reasonErr *apperror.ReasonError
+		require.ErrorAs(t, err, &reasonErr)
+		assert.Equal(t, "UPDATED_AT_MISMATCH", reasonErr.Reason)
+		assert.Equal(t, "3", reasonErr.Metadata["current_version"])
+	})
+
+	t.Run("a write losing the race is a conflict", func(t *testing.T) {
+		svc, outcome := newService(&mockUserRepository{
+			getFunc:           stored(model.UserStatusActive),
+			updateProfileFunc: func(ctx context.Context, user *model.User) (bool, error) { return false, nil },
+		})
+
+		_, err := svc.UpdateUser(ctx, 1, service.UserUpdate{Email: &email})
+		assert.ErrorIs(t, err, apperror.ErrConflict)
+		assert.Equal(t, []string{"abort"}, *outcome)
+	})
+
+	t.Run("deleted user cannot be updated", func(t *testing.T) {
+		svc, _ := newService(&mockUserRepository{getFunc: stored(model.UserStatusDeleted)})
+
+		_, err := svc.UpdateUser(ctx, 1, service.UserUpdate{Email: &email})
+		assert.ErrorIs(t, err, apperror.ErrFailedPrecondition)
+	})
+
+	t.Run("missing user returns nil", func(t *testing.T) {
+		svc, outcome := newService(&mockUserRepository{
+			getFunc: func(ctx context.Context, id int64) (*model.User, error) { return nil, nil },
+		})
+
+		user, err := svc.UpdateUser(ctx, 1, service.UserUpdate{Email: &email})
+		require.NoError(t, err)
+		assert.Nil(t, user)
+		assert.Equal(t, []string{"abort"}, *outcome)
+	})
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	ctx := context.Background()
+	device := service.Device{UserAgent: "curl/8.0", Ip: "10.0.0.1"}
+	changedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	type recorded struct {
+		written   *model.User
+		revokedAt time.Time
+		events    []*model.SessionEvent
+		throttle  recordingThrottle
+	}
+	newService := func(stored *model.User, updated bool) (service.UserService, *recorded) {
+		rec := &recorded{}
+		uow := &mockUnitOfWork{
+			userRepo: &mockUserRepository{
+				getFunc: func(ctx context.Context, id int64) (*model.User, error) { return stored, nil },
+				updatePasswordFunc: func(ctx context.Context, user *model.User) (bool, error) {
+					written := *user
+					rec.written = &written
+					user.UpdatedAt = changedAt
+					return updated, nil
+				},
+			},
+			sessionRepo: &mockSessionRepository{
+				revokeAllFunc: func(ctx context.Context, userId int64, revokedAt time.Time) ([]int64, error) {
+					rec.revokedAt = revokedAt
+					return []int64{10, 11}, nil
+				},
+				insertEventFunc: func(ctx context.Context, event *model.SessionEvent) error {
+					rec.events = append(rec.events, event)
+					return nil
+				},
+			},
+			commitFunc: func(ctx context.Context) error { return nil },
+			abortFunc:  func(ctx context.Context) error { return nil },
+		}
+		return service.NewUserService(
+			&mockUnitOfWorkFactory{newFunc: func() (repository.UnitOfWork, error) { return uow, nil }},
+			nil, &mockSnowflake{id: 900}, &mockHasher{}, &rec.throttle, &mockTracer{},
+		), rec
+	}
+
+	t.Run("stores the new hash and revokes every session", func(t *testing.T) {
+		svc, rec := newService(&model.User{Id: 1, Password: "hashed:old", Status: model.UserStatusActive, Version: 3}, true)
+
+		change, err := svc.ChangePassword(ctx, 1, "old", "new", device)
+		require.NoError(t, err)
+		assert.Equal(t, &service.PasswordChange{RevokedSessions: 2}, change)
+		assert.Equal(t, "hashed:new", rec.written.Password)
+		assert.Equal(t, int64(3), rec.written.Version)
+		assert.Equal(t, changedAt, rec.revokedAt, "sessions are revoked at the stamped updated_at")
+		assert.Equal(t, 1, rec.throttle.successes)
+		require.Len(t, rec.events, 2)
+		for i, event := range rec.events {
+			assert.Equal(t, int64(10+i), event.SessionId)
+			assert.Equal(t, model.SessionEventRevoked, event.Event)
+			assert.Equal(t, "10.0.0.1", event.Ip)
+		}
+	})
+
+	t.Run("wrong current password is unauthenticated", func(t *testing.T) {
+		svc, rec := newService(&model.User{Id: 1, Password: "hashed:old", Status: model.UserStatusActive}, true)
+
+		change, err := svc.ChangePassword(ctx, 1, "guess", "new", device)
+		assert.Nil(t, change)
+		assert.ErrorIs(t, err, apperror.ErrUnauthenticated)
+		assert.Nil(t, rec.written)
+		assert.Equal(t, 1, rec.throttle.failures)
+	})
+
+	t.Run("locked out users are not checked", func(t *testing.T) {
+		svc, rec := newService(&model.User{Id: 1, Password: "hashed:old", Status: model.UserStatusActive}, true)
+		rec.throttle.checkErr = apperror.ErrResourceExhausted
+
+		_, err := svc.ChangePassword(ctx, 1, "old", "new", device)
+		assert.ErrorIs(t, err, apperror.ErrResourceExhausted)
+		assert.Nil(t, rec.written)
+	})
+
+	t.Run("missing user returns nil", func(t *testing.T) {
+		svc, _ := newService(nil, true)
+
+		change, err := svc.ChangePassword(ctx, 1, "old", "new", device)
+		require.NoError(t, err)
+		assert.Nil(t, change)
+	})
+
+	t.Run("suspended and deleted users are denied even with the right password", func(t *testing.T) {
+		for status, reason := range map[model.UserStatus]string{
+			model.UserStatusSuspended: "USER_SUSPENDED",
+			model.UserStatusDeleted:   "USER_DELETED",
+		} {
+			svc, rec := newService(&model.User{Id: 1, Password: "hashed:old", Status: status}, true)
+
+			_, err := svc.ChangePassword(ctx, 1, "old", "new", device)
+			assert.ErrorIs(t, err, apperror.ErrPermissionDenied, status)
+			var reasonErr *apperror.ReasonError
+			require.ErrorAs(t, err, &reasonErr)
+			assert.Equal(t, reason, reasonErr.Reason)
+			assert.Nil(t, rec.written)
+		}
+	})
+
+	t.Run("plain text stored before hashing must be reset", func(t *testing.T) {
+		svc, _ := newService(&model.User{Id: 1, Password: "old", Status: model.UserStatusActive}, true)
+
+		_, err := svc.ChangePassword(ctx, 1, "old", "new", device)
+		assert.ErrorIs(t, err, apperror.ErrFailedPrecondition)
+	})
+
+	t.Run("concurrent change conflicts", func(t *testing.T) {
+		svc, rec := newService(&model.User{Id: 1, Password: "hashed:old", Status: model.UserStatusActive}, false)
+
+		_, err := svc.ChangePassword(ctx, 1, "old", "new", device)
+		assert.ErrorIs(t, err, apperror.ErrConflict)
+		assert.Empty(t, rec.events)
+		assert.Zero(t, rec.throttle.failures, "only a wrong password counts as a failure")
 	})
 }
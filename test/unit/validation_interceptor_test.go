@@ -0,0 +1,165 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/jt828/go-grpc-template/internal/interceptor"
+	"github.com/jt828/go-grpc-template/pkg/apperror"
+	v1 "github.com/jt828/go-grpc-template/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+func TestValidationInterceptor(t *testing.T) {
+	i := interceptor.ValidationInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/proto.v1.UserService/CreateUser"}
+	called := false
+	handler := func(ctx context.Context, req any) (any, error) {
+		called = true
+		return "ok", nil
+	}
+
+	violations := func(t *testing.T, req any) []apperror.FieldViolation {
+		t.Helper()
+		called = false
+		_, err := i(context.Background(), req, info, handler)
+		assert.False(t, called)
+		assert.ErrorIs(t, err, apperror.ErrInvalidArgument)
+		var validationErr *apperror.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		return validationErr.Violations
+	}
+
+	t.Run("lists every violated rule", func(t *testing.T) {
+		assert.Equal(t, []apperror.FieldViolation{
+			{Field: "idempotency_id", Reason: "int64.gt", Description: "must be greater than 0"},
+			{Field: "email", Reason: "required", Description: "is required"},
+			{Field: "username", Reason: "required", Description: "is required"},
+			{Field: "password", Reason: "required", Description: "is required"},
+		}, violations(t, &v1.CreateUserRequest{}))
+	})
+
+	t.Run("valid requests reach the handler", func(t *testing.T) {
+		called = false
+		resp, err := i(context.Background(), &v1.CreateUserRequest{IdempotencyId: 1, Email: "a@example.com", Username: "alice", Password: "pw"}, info, handler)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp)
+		assert.True(t, called)
+	})
+
+	t.Run("lengths are counted in characters", func(t *testing.T) {
+		_, err := i(context.Background(), &v1.UnlinkUserIdentityRequest{Issuer: strings.Repeat("é", 255), Subject: "alice"}, info, handler)
+		require.NoError(t, err)
+
+		assert.Equal(t, []apperror.FieldViolation{
+			{Field: "issuer", Reason: "string.max_len", Description: "must be at most 255 characters"},
+		}, violations(t, &v1.UnlinkUserIdentityRequest{Issuer: strings.Repeat("é", 256), Subject: "alice"}))
+	})
+
+	t.Run("integer bounds include zero", func(t *testing.T) {
+		_, err := i(context.Background(), &v1.ListDeadLettersRequest{}, info, handler)
+		require.NoError(t, err)
+
+		assert.Equal(t, []apperror.FieldViolation{
+			{Field: "after_id", Reason: "int64.gte", Description: "must be at least 0"},
+			{Field: "page_size", Reason: "int32.lte", Description: "must be at most 500"},
+		}, violations(t, &v1.ListDeadLettersRequest{AfterId: -1, PageSize: 501}))
+		assert.Equal(t, []apperror.FieldViolation{
+			{Field: "id", Reason: "int64.gt", Description: "must be greater than 0"},
+		}, violations(t, &v1.GetDeadLetterRequest{Id: -3}))
+	})
+
+	t.Run("repeated fields are bounded by item count", func(t *testing.T) {
+		assert.Equal(t, []apperror.FieldViolation{
+			{Field: "permissions", Reason: "repeated.max_items", Description: "must have at most 100 items"},
+		}, violations(t, &v1.PutRoleRequest{Name: "auditor", Permissions: make([]string, 101)}))
+	})
+
+	t.Run("requests without rules pass through", func(t *testing.T) {
+		_, err := i(context.Background(), &v1.GetConfigRequest{}, info, handler)
+		require.NoError(t, err)
+		_, err = i(context.Background(), "not a proto message", info, handler)
+		require.NoError(t, err)
+	})
+}
+
+// TestValidationRulesAreSupported fails if a proto annotates a field with a
+// buf.validate rule ValidationInterceptor does not enforce, so the rule is not
+// silently ignored.
+func TestValidationRulesAreSupported(t *testing.T) {
+	supported := map[string]bool{
+		"required": true,
+		"int32.gt": true, "int32.gte": true, "int32.lt": true, "int32.lte": true,
+		"int64.gt": true, "int64.gte": true, "int64.lt": true, "int64.lte": true,
+		"string.min_len": true, "string.max_len": true,
+		"repeated.max_items": true,
+	}
+
+	var ruleIds func(prefix string, rules protoreflect.Message) []string
+	ruleIds = func(prefix string, rules protoreflect.Message) []string {
+		var ids []string
+		rules.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+			if fd.Message() != nil && !fd.IsList() {
+				ids = append(ids, ruleIds(prefix+string(fd.Name())+".", v.Message())...)
+			} else {
+				ids = append(ids, prefix+string(fd.Name()))
+			}
+			return true
+		})
+		return ids
+	}
+
+	annotated := 0
+	protoregistry.GlobalFiles.RangeFilesByPackage("proto.v1", func(file protoreflect.FileDescriptor) bool {
+		messages := file.Messages()
+		for i := range messages.Len() {
+			fields := messages.Get(i).Fields()
+			for j := range fields.Len() {
+				fd := fields.Get(j)
+				rules, ok := proto.GetExtension(fd.Options(), validate.E_Field).(*validate.FieldRules)
+				if !ok || rules == nil {
+					continue
+				}
+				annotated++
+				for _, id := range ruleIds("", rules.ProtoReflect()) {
+					assert.True(t, supported[id], "%s uses unsupported rule %s", fd.FullName(), id)
+				}
+			}
+		}
+		return true
+	})
+	assert.NotZero(t, annotated)
+}
+
+func TestValidationErrors(t *testing.T) {
+	t.Run("no violations is no error", func(t *testing.T) {
+		var violations apperror.ValidationErrors
+		assert.True(t, violations.Merge(nil))
+		assert.NoError(t, violations.Err())
+	})
+
+	t.Run("violations are listed in the order recorded", func(t *testing.T) {
+		var violations apperror.ValidationErrors
+		violations.Add("id", "gt", "must be greater than 0")
+		assert.True(t, violations.Merge(&apperror.ValidationError{Violations: []apperror.FieldViolation{
+			{Field: "status", Reason: "required", Description: "is required"},
+		}}))
+
+		err := violations.Err()
+		assert.ErrorIs(t, err, apperror.ErrInvalidArgument)
+		assert.EqualError(t, err, "id must be greater than 0; status is required")
+	})
+
+	t.Run("other errors are not merged", func(t *testing.T) {
+		var violations apperror.ValidationErrors
+		assert.False(t, violations.Merge(apperror.ErrNotFound))
+		assert.NoError(t, violations.Err())
+	})
+}
@@ -0,0 +1,62 @@
+package unit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jt828/go-grpc-template/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerificationTokenRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	t.Run("get returns nil for an unknown token", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewVerificationTokenRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "main"."verification_tokens" WHERE token_hash = $1 LIMIT $2`)).
+			WithArgs("hash", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"token_hash", "user_id", "email", "created_at", "expires_at", "used_at"}))
+
+		token, err := repo.Get(ctx, "hash")
+		require.NoError(t, err)
+		assert.Nil(t, token)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("use only redeems an unused token", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewVerificationTokenRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "main"."verification_tokens" SET "used_at"=$1 WHERE token_hash = $2 AND used_at IS NULL`)).
+			WithArgs(now, "hash").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		used, err := repo.Use(ctx, "hash", now)
+		require.NoError(t, err)
+		assert.False(t, used)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete unused keeps redeemed tokens", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewVerificationTokenRepository(db, &passthroughCB{}, &passthroughRetry{})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "main"."verification_tokens" WHERE user_id = $1 AND used_at IS NULL`)).
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.DeleteUnused(ctx, 1))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,117 @@
+package unit
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jt828/go-grpc-template/pkg/observability/noop"
+	"github.com/jt828/go-grpc-template/pkg/workerpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPool(t *testing.T) {
+	ctx := context.Background()
+	metrics := workerpool.NewMetrics(noop.NewMeter())
+	newPool := func(workers, queueSize int) *workerpool.Pool {
+		return workerpool.New(workerpool.Config{Name: "test", Workers: workers, QueueSize: queueSize}, metrics, noop.NewLogger())
+	}
+
+	t.Run("close drains queued tasks", func(t *testing.T) {
+		pool := newPool(2, 10)
+		var ran atomic.Int32
+		for range 10 {
+			require.NoError(t, pool.Submit(ctx, func(ctx context.Context) {
+				time.Sleep(time.Millisecond)
+				ran.Add(1)
+			}))
+		}
+
+		require.NoError(t, pool.Close(ctx))
+		assert.Equal(t, int32(10), ran.Load())
+	})
+
+	t.Run("workers bound concurrent tasks", func(t *testing.T) {
+		pool := newPool(2, 10)
+		var running, peak atomic.Int32
+		for range 10 {
+			require.NoError(t, pool.Submit(ctx, func(ctx context.Context) {
+				now := running.Add(1)
+				for {
+					old := peak.Load()
+					if now <= old || peak.CompareAndSwap(old, now) {
+						break
+					}
+				}
+				time.Sleep(2 * time.Millisecond)
+				running.Add(-1)
+			}))
+		}
+
+		require.NoError(t, pool.Close(ctx))
+		assert.LessOrEqual(t, peak.Load(), int32(2))
+	})
+
+	t.Run("full queue blocks submit until ctx ends", func(t *testing.T) {
+		pool := newPool(1, 0)
+		release := make(chan struct{})
+		require.NoError(t, pool.Submit(ctx, func(ctx context.Context) { <-release }))
+
+		submitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		err := pool.Submit(submitCtx, func(ctx context.Context) { t.Error("task submitted past a full queue ran") })
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		close(release)
+		require.NoError(t, pool.Close(ctx))
+	})
+
+	t.Run("panicking task does not stop its worker", func(t *testing.T) {
+		pool := newPool(1, 1)
+		var ran atomic.Bool
+		require.NoError(t, pool.Submit(ctx, func(ctx context.Context) { panic("boom") }))
+		require.NoError(t, pool.Submit(ctx, func(ctx context.Context) { ran.Store(true) }))
+
+		require.NoError(t, pool.Close(ctx))
+		assert.True(t, ran.Load())
+	})
+
+	t.Run("tasks outlive the submitter's context", func(t *testing.T) {
+		pool := newPool(1, 1)
+		submitCtx, cancel := context.WithCancel(ctx)
+		var taskErr atomic.Value
+		require.NoError(t, pool.Submit(submitCtx, func(ctx context.Context) {
+			time.Sleep(5 * time.Millisecond)
+			taskErr.Store(ctx.Err() == nil)
+		}))
+		cancel()
+
+		require.NoError(t, pool.Close(ctx))
+		assert.Equal(t, true, taskErr.Load())
+	})
+
+	t.Run("submit after close is rejected", func(t *testing.T) {
+		pool := newPool(1, 1)
+		require.NoError(t, pool.Close(ctx))
+		require.NoError(t, pool.Close(ctx), "close is idempotent")
+
+		err := pool.Submit(ctx, func(ctx context.Context) {})
+
+		assert.ErrorIs(t, err, workerpool.ErrClosed)
+	})
+
+	t.Run("close gives up waiting when ctx ends", func(t *testing.T) {
+		pool := newPool(1, 0)
+		release := make(chan struct{})
+		require.NoError(t, pool.Submit(ctx, func(ctx context.Context) { <-release }))
+
+		closeCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, pool.Close(closeCtx), context.DeadlineExceeded)
+
+		close(release)
+		require.NoError(t, pool.Close(ctx))
+	})
+}
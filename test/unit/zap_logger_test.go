@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jt828/go-grpc-template/pkg/observability"
+	"github.com/jt828/go-grpc-template/pkg/observability/implementation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logMessages returns the msg of every JSON entry written to path.
+func logMessages(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		messages = append(messages, entry["msg"].(string))
+	}
+	return messages
+}
+
+func TestZapLogger(t *testing.T) {
+	t.Run("each sink applies its own level", func(t *testing.T) {
+		dir := t.TempDir()
+		debugFile, infoFile := filepath.Join(dir, "debug.log"), filepath.Join(dir, "info.log")
+		log, err := implementation.NewZapLogger(observability.LogConfig{
+			Level: observability.LevelDebug,
+			Sinks: []observability.LogSink{{Path: debugFile}, {Path: infoFile, Level: observability.LevelInfo}},
+		})
+		require.NoError(t, err)
+
+		log.Debug("d")
+		log.Info("i")
+		log.Error("e")
+
+		assert.Equal(t, []string{"d", "i", "e"}, logMessages(t, debugFile))
+		assert.Equal(t, []string{"i", "e"}, logMessages(t, infoFile))
+	})
+
+	t.Run("module levels override the default level", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "app.log")
+		log, err := implementation.NewZapLogger(observability.LogConfig{
+			Level: observability.LevelInfo,
+			Modules: map[string]observability.Level{
+				"repository":  observability.LevelDebug,
+				"interceptor": observability.LevelWarn,
+			},
+			Sinks: []observability.LogSink{{Path: file}},
+		})
+		require.NoError(t, err)
+
+		repoLog := log.With(observability.Module("repository"))
+		repoLog.Debug("repository debug")
+		repoLog.With(observability.String("table", "users")).Debug("repository child debug")
+		interceptorLog := log.With(observability.Module("interceptor"))
+		interceptorLog.Info("interceptor info")
+		interceptorLog.Warn("interceptor warn")
+		log.With(observability.Module("service")).Debug("service debug")
+		log.Debug("root debug")
+		log.Info("root info")
+
+		assert.Equal(t, []string{"repository debug", "repository child debug", "interceptor warn", "root info"}, logMessages(t, file))
+	})
+
+	t.Run("invalid levels are rejected", func(t *testing.T) {
+		_, err := implementation.NewZapLogger(observability.LogConfig{Level: "verbose"})
+		assert.Error(t, err)
+		_, err = implementation.NewZapLogger(observability.LogConfig{Modules: map[string]observability.Level{"repository": "loud"}})
+		assert.ErrorContains(t, err, "repository")
+		_, err = implementation.NewZapLogger(observability.LogConfig{Sinks: []observability.LogSink{{Path: "stdout", Level: "x"}}})
+		assert.ErrorContains(t, err, "stdout")
+	})
+}